@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -17,12 +18,64 @@ import (
 	"tensorvault/pkg/server"
 	"tensorvault/pkg/service"
 
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/spf13/viper"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/reflection"
 )
 
 const DefaultPort = ":8080"
 
+// watchQuotaReload 打开 Viper 的配置文件监听：config.yaml 每被修改一次，就把
+// quota.tenants/quota.default 重新解析一遍，灌回 application.Quotas（没启用配额时
+// application.Quotas 是 nil，直接跳过）。用的是 fsnotify 触发的 OnConfigChange，不是
+// 轮询，所以空闲时没有额外开销
+func watchQuotaReload(application *app.App) {
+	if application.Quotas == nil {
+		return
+	}
+	viper.OnConfigChange(func(e fsnotify.Event) {
+		var rawTenants map[string]server.Quota
+		if err := viper.UnmarshalKey("quota.tenants", &rawTenants); err != nil {
+			log.Printf("⚠️  Config reload: failed to parse quota.tenants, keeping old quotas: %v", err)
+			return
+		}
+		var defaultQuota server.Quota
+		if err := viper.UnmarshalKey("quota.default", &defaultQuota); err != nil {
+			log.Printf("⚠️  Config reload: failed to parse quota.default, keeping old quotas: %v", err)
+			return
+		}
+		application.Quotas.Reload(rawTenants, defaultQuota)
+		fmt.Printf("🔄 Config changed (%s): reloaded tenant quotas\n", e.Name)
+	})
+	viper.WatchConfig()
+}
+
+// buildAuthenticator 根据 auth.mode 构造一个 server.Authenticator；返回 nil 表示
+// 鉴权关闭 (auth.mode 未配置或者配成 "none")，main 据此决定要不要挂鉴权拦截器
+func buildAuthenticator() (server.Authenticator, error) {
+	switch mode := viper.GetString("auth.mode"); mode {
+	case "", "none":
+		return nil, nil
+	case "jwt":
+		secret := viper.GetString("auth.jwt.secret")
+		if secret == "" {
+			return nil, fmt.Errorf("auth.mode is \"jwt\" but auth.jwt.secret is empty")
+		}
+		return server.NewJWTAuthenticator(secret), nil
+	case "static_api_key":
+		var keyToTenant map[string]string
+		if err := viper.UnmarshalKey("auth.static_api_key.keys", &keyToTenant); err != nil {
+			return nil, fmt.Errorf("failed to parse auth.static_api_key.keys: %w", err)
+		}
+		return server.NewStaticAPIKeyAuthenticator(keyToTenant), nil
+	default:
+		return nil, fmt.Errorf("unknown auth.mode %q", mode)
+	}
+}
+
 func main() {
 	// 1. Load Config (支持 -config 参数)
 	cfgFile := flag.String("config", "", "config file (default is $HOME/.tv/config.yaml)")
@@ -40,24 +93,89 @@ func main() {
 	}
 	fmt.Println("✅ TensorVault Core initialized.")
 
+	// 2.1 配置热更新：只重建"原地可刷新"的部分——目前只有 quota.* (QuotaManager 自带
+	// 互斥锁，Reload 在运行时替换限额是安全的)。storage.*/database.*/server.tls.* 这些
+	// 改了要求重启进程：它们要么被其它组件在构造时拷贝/缓存了一份（S3 Adapter 的
+	// SSE-C 密钥、gRPC TLS Credentials），要么被多个 goroutine 无锁地直接引用
+	// （application.Store），原地替换没有 QuotaManager 这样现成的并发安全边界，
+	// 贸然做只会把一次配置笔误变成一次数据竞争
+	watchQuotaReload(application)
+
 	// 3. Setup Network
 	lis, err := net.Listen("tcp", DefaultPort)
 	if err != nil {
 		log.Fatalf("❌ Failed to listen on %s: %v", DefaultPort, err)
 	}
 
+	// 3.1 Prometheus 指标：单独开一个 Registry 而不是用默认的全局 DefaultRegisterer，
+	// 这样 /metrics 端点只暴露本项目自己注册的 Collector，不会被进程里其它不相关库
+	// （如果将来引入的话）偷偷注册的指标污染
+	metricsRegistry := prometheus.NewRegistry()
+	grpcMetrics := server.NewMetrics(metricsRegistry)
+	if metricsAddr := viper.GetString("server.metrics_addr"); metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("/metrics", promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{}))
+		go func() {
+			fmt.Printf("📊 Prometheus metrics listening on %s/metrics...\n", metricsAddr)
+			if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+				log.Printf("⚠️  Metrics HTTP sidecar stopped: %v", err)
+			}
+		}()
+	}
+
 	// 4. Setup gRPC Server
-	// 可以在这里添加拦截器 (Interceptors) 用于日志或鉴权
-	grpcServer := grpc.NewServer( // 挂载 Unary (MetaService)
-		grpc.ChainUnaryInterceptor(
-			server.UnaryRecoveryInterceptor,
-			server.UnaryLoggingInterceptor,
-		),
-		// 挂载 Stream (DataService)
-		grpc.ChainStreamInterceptor(
-			server.StreamRecoveryInterceptor,
-			server.StreamLoggingInterceptor,
-		))
+	// 拦截器链顺序固定是 recovery → tracing → metrics → logging → handler：
+	// Recovery 必须在最外层，这样 panic 才能在被任何其它拦截器看到之前就有机会被兜住
+	// （Tracing 自己也会 recover-再 panic 一次，只是为了在 span 上记一条 panic 事件，
+	// 真正负责把 panic 转成 gRPC Internal 错误返回给客户端的还是 Recovery）；Metrics
+	// 和 Logging 都应该能观察到 Tracing 产生的、挂了 span 的 ctx，所以排在它后面
+	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		server.UnaryRecoveryInterceptor,
+		server.UnaryTracingInterceptor,
+		grpcMetrics.UnaryMetricsInterceptor,
+		server.UnaryLoggingInterceptor,
+	}
+	streamInterceptors := []grpc.StreamServerInterceptor{
+		server.StreamRecoveryInterceptor,
+		server.StreamTracingInterceptor,
+		grpcMetrics.StreamMetricsInterceptor,
+		server.StreamLoggingInterceptor,
+	}
+
+	// 4.1 鉴权 (可选)：auth.mode 没配或者配成 "none" 时完全跳过，维持鉴权关闭前的
+	// 行为——这样已有部署不会因为升级这个版本就突然要求所有调用都带 token
+	authenticator, err := buildAuthenticator()
+	if err != nil {
+		log.Fatalf("❌ Failed to configure auth: %v", err)
+	}
+	if authenticator != nil {
+		// 鉴权必须排在 Recovery/Logging 之后：先让 panic 恢复和日志记录兜底，
+		// 鉴权失败本身也要被记一条日志，而不是被跳过
+		unaryInterceptors = append(unaryInterceptors, server.UnaryAuthInterceptor(authenticator, application.Quotas))
+		streamInterceptors = append(streamInterceptors, server.StreamAuthInterceptor(authenticator, application.Quotas))
+	}
+
+	serverOpts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(unaryInterceptors...),
+		grpc.ChainStreamInterceptor(streamInterceptors...),
+	}
+
+	// 4.2 mTLS/TLS (可选)：server.tls.cert_file/key_file 都配了才启用，否则退回明文——
+	// 方便本地开发/内网部署不用折腾证书
+	if certFile := viper.GetString("server.tls.cert_file"); certFile != "" {
+		creds, err := server.LoadTLSCredentials(server.TLSConfig{
+			CertFile:     certFile,
+			KeyFile:      viper.GetString("server.tls.key_file"),
+			ClientCAFile: viper.GetString("server.tls.client_ca_file"),
+		})
+		if err != nil {
+			log.Fatalf("❌ Failed to load TLS credentials: %v", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+		fmt.Println("🔒 TLS enabled.")
+	}
+
+	grpcServer := grpc.NewServer(serverOpts...)
 
 	// 5. 注册服务 (Wiring Services)
 	// A. MetaService (Unary)
@@ -68,6 +186,11 @@ func main() {
 	dataSvc := service.NewDataService(application)
 	tvrpc.RegisterDataServiceServer(grpcServer, dataSvc)
 
+	// C. PeerService：只有配置了 cluster.peers（一致性哈希分片模式）才有意义注册，
+	// 但注册本身没有成本，即使没开启 ClusterStore 也留着无妨，方便以后动态开启
+	peerSvc := service.NewPeerService(application)
+	tvrpc.RegisterPeerServiceServer(grpcServer, peerSvc)
+
 	// 6. Enable Reflection
 	// 允许使用 grpcurl 等工具调试
 	reflection.Register(grpcServer)
@@ -86,6 +209,17 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
+	if application.Cluster != nil {
+		if err := application.Cluster.Close(); err != nil {
+			log.Printf("⚠️  Failed to close cluster node connections cleanly: %v", err)
+		}
+	}
+	if application.ClusterPeers != nil {
+		if err := application.ClusterPeers.Close(); err != nil {
+			log.Printf("⚠️  Failed to close cluster peer connections cleanly: %v", err)
+		}
+	}
+
 	fmt.Println("\n⚠️  Shutting down server...")
 	// 创建一个带超时的 Context (例如 30秒)
 	// 这是给正在传输的文件留出的最后时间窗口
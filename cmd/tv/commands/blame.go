@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"tensorvault/pkg/blame"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/types"
+
+	"github.com/spf13/cobra"
+)
+
+// newBlameCmd 把 go-git blame 的想法搬到 TensorVault 的 Tree/Commit 模型上：普通 blame
+// 按行比较文本，这里按 Chunk 比较内容寻址的哈希——不需要读一个字节的文件内容，对几十 GB
+// 的张量权重文件也一样便宜
+func newBlameCmd(deps *Deps) *cobra.Command {
+	var blameStartCommit string
+
+	cmd := &cobra.Command{
+		Use:   "blame <path>",
+		Short: "Show which commit introduced each chunk of a file",
+		Long: `For the given path, walk commit history (first-parent only; 'tv log' follows every parent) and
+attribute each underlying Chunk of the file to the commit that first introduced its current
+content. Because TensorVault is content-addressed and dedup'd at the chunk level, this needs
+no textual diff: a ChunkLink at the same position with the same hash in a parent commit means
+that range of bytes hasn't changed since.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("application not initialized")
+			}
+
+			ctx := context.Background()
+			path := args[0]
+
+			startCommit, err := resolveBlameStart(ctx, deps, blameStartCommit)
+			if err != nil {
+				return err
+			}
+
+			blamer := blame.NewBlamer(deps.App().Store)
+			records, err := blamer.Blame(ctx, path, startCommit)
+			if err != nil {
+				return fmt.Errorf("blame failed: %w", err)
+			}
+
+			for _, r := range records {
+				fmt.Printf("%s\t[%d-%d)\t%s\t%s\t%s\n",
+					r.CommitHash[:8], r.Range.Start, r.Range.End, r.Author,
+					time.Unix(r.Timestamp, 0).Format(time.RFC3339), r.Message)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&blameStartCommit, "commit", "", "start blaming from this commit instead of HEAD (accepts short hashes)")
+	return cmd
+}
+
+// resolveBlameStart 解析 --commit（支持短哈希，跟 `tv cat`/`tv log` 一致），留空时
+// 落回当前 HEAD
+func resolveBlameStart(ctx context.Context, deps *Deps, blameStartCommit string) (types.Hash, error) {
+	if blameStartCommit != "" {
+		return expandHash(ctx, deps, types.HashPrefix(blameStartCommit))
+	}
+
+	head, _, err := deps.App().Refs.GetHead(ctx)
+	if errors.Is(err, refs.ErrNoHead) {
+		return "", fmt.Errorf("repository has no commits yet")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+	return head, nil
+}
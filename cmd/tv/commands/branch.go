@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/refs"
+
+	"github.com/spf13/cobra"
+)
+
+func newBranchCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "branch [name]",
+		Short: "List or create branches",
+		Long: `With no arguments, list all local branches and mark the one HEAD is attached to.
+With a name, create a new branch pointing at the current HEAD (use 'tv checkout <name>' to
+switch to it — creating a branch never moves HEAD by itself).`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+			ctx := cmd.Context()
+
+			if len(args) == 0 {
+				return listBranches(ctx, deps)
+			}
+			return createBranch(ctx, deps, args[0])
+		},
+	}
+	return cmd
+}
+
+func listBranches(ctx context.Context, deps *Deps) error {
+	branches, err := deps.App().Refs.ListBranches(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list branches: %w", err)
+	}
+	if len(branches) == 0 {
+		fmt.Println("no branches yet (run 'tv branch <name>' to create one)")
+		return nil
+	}
+
+	current, attached, err := deps.App().Refs.CurrentBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve current branch: %w", err)
+	}
+
+	for _, b := range branches {
+		marker := "  "
+		if attached && b.Name == current {
+			marker = "* "
+		}
+		if b.Remote != "" {
+			fmt.Printf("%s%s -> %s/%s\n", marker, b.Name, b.Remote, b.Merge)
+		} else {
+			fmt.Printf("%s%s\n", marker, b.Name)
+		}
+	}
+	return nil
+}
+
+func createBranch(ctx context.Context, deps *Deps, name string) error {
+	headHash, _, err := deps.App().Refs.GetHead(ctx)
+	if err != nil {
+		if errors.Is(err, refs.ErrNoHead) {
+			return fmt.Errorf("cannot create branch '%s': no commits yet", name)
+		}
+		return fmt.Errorf("failed to resolve HEAD: %w", err)
+	}
+
+	if err := deps.App().Refs.CreateBranch(ctx, name, headHash); err != nil {
+		if errors.Is(err, refs.ErrBranchExists) || errors.Is(err, meta.ErrBranchExists) {
+			return fmt.Errorf("branch '%s' already exists", name)
+		}
+		return fmt.Errorf("failed to create branch '%s': %w", name, err)
+	}
+
+	fmt.Printf("✅ Created branch '%s' at %s\n", name, headHash[:8])
+	return nil
+}
@@ -8,71 +8,70 @@ import (
 	"unicode/utf8"
 
 	tvrpc "tensorvault/pkg/api/tvrpc/v1"
+	"tensorvault/pkg/client"
 	"tensorvault/pkg/exporter"
 	"tensorvault/pkg/types"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
-// outputFilePath 定义在外部，或在此处定义
-// var outputFilePath string
-
 const (
 	PreviewLimit = 2 * 1024 // 预览模式只显示前 2KB
 )
 
-var outputFilePath string
-var catCmd = &cobra.Command{
-	Use:   "cat [hash]",
-	Short: "Inspect an object",
-	Long:  `Pretty-print the contents of an object. Defaults to local repository. Use --server to inspect remote objects.`,
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		hashStr := types.HashPrefix(args[0])
-		ctx := cmd.Context()
-
-		// 1. 优先判断是否有 -o 输出文件
-		// 如果是下载模式，逻辑比较简单，不涉及预览
-		if outputFilePath != "" {
-			return downloadObject(ctx, hashStr, outputFilePath)
-		}
+func newCatCmd(deps *Deps) *cobra.Command {
+	var outputFilePath string
 
-		// 2. 判断 Local vs Remote
-		// 逻辑：优先读本地。只有当用户显式指定了 --server flag 时，才走远程。
-		// 注意：这里我们检查 flag 是否被 changed，而不是仅仅检查值是否为空
-		// 因为 viper 可能有默认值，但我们希望默认行为是本地。
-		// *修正策略*：为了方便 alias，我们约定：如果 viper("remote.server") 有值且不是 localhost 默认值？
-		// 不，最简单的逻辑：如果 --server 被显式设置了，或者用户通过其他方式表明了意图。
-
-		// 为了满足你的 "优先读本地，除非指定远程"：
-		serverFlag := cmd.Flag("server")
-		//FIXME: 这里的逻辑有点绕，我们需要一个更清晰的设计。
-		_ = serverFlag.Changed || viper.GetString("remote.server") != "localhost:8080"
-
-		// 针对调试场景，我们允许通过一个专门的 flag 强制远程
-		// 比如 tv cat <hash> --remote
-		// 这里我们简单复用 --server 逻辑：
-		// 如果用户没传 --server，默认本地。如果传了，就远程。
-
-		if serverFlag.Changed {
-			return catRemote(ctx, hashStr)
-		}
+	cmd := &cobra.Command{
+		Use:   "cat [hash]",
+		Short: "Inspect an object",
+		Long:  `Pretty-print the contents of an object. Defaults to local repository. Use --server to inspect remote objects.`,
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			hashStr := types.HashPrefix(args[0])
+			ctx := cmd.Context()
+
+			// 2. 判断 Local vs Remote
+			// 逻辑：优先读本地。只有当用户显式指定了 --server flag 时，才走远程。
+			// 注意：这里我们检查 flag 是否被 changed，而不是仅仅检查值是否为空
+			// 因为 viper 可能有默认值，但我们希望默认行为是本地。
+
+			// 针对调试场景，我们允许通过一个专门的 flag 强制远程
+			// 比如 tv cat <hash> --remote
+			// 这里我们简单复用 --server 逻辑：
+			// 如果用户没传 --server，默认本地。如果传了，就远程。
+			isRemote := cmd.Flag("server").Changed
+
+			// 1. 优先判断是否有 -o 输出文件
+			// 如果是下载模式，逻辑比较简单，不涉及预览
+			if outputFilePath != "" {
+				return downloadObject(ctx, deps, hashStr, outputFilePath, isRemote)
+			}
+
+			if isRemote {
+				return catRemote(ctx, deps, hashStr)
+			}
 
-		return catLocal(ctx, hashStr)
-	},
+			return catLocal(ctx, deps, hashStr)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFilePath, "output", "o", "", "Write output to file")
+	return cmd
 }
 
 // --- 本地模式 ---
-func catLocal(ctx context.Context, hashPrefix types.HashPrefix) error {
+func catLocal(ctx context.Context, deps *Deps, hashPrefix types.HashPrefix) error {
 	// 1. 扩展 Hash
-	fullHash, err := TV.Store.ExpandHash(ctx, hashPrefix)
+	fullHash, err := expandHash(ctx, deps, hashPrefix)
 	if err != nil {
 		return err
 	}
 
 	// 2. 读取数据
-	rc, err := TV.Store.Get(ctx, fullHash)
+	rc, err := deps.App().Store.Get(ctx, fullHash)
 	if err != nil {
 		return err
 	}
@@ -100,26 +99,31 @@ func catLocal(ctx context.Context, hashPrefix types.HashPrefix) error {
 }
 
 // --- 远程模式 ---
-func catRemote(ctx context.Context, hashPrefix types.HashPrefix) error {
+func catRemote(ctx context.Context, deps *Deps, hashPrefix types.HashPrefix) error {
 	// 1. 获取连接
-	cli, err := GetRemoteClient()
+	cli, err := deps.GetRemoteClient()
 	if err != nil {
 		return err
 	}
 
 	fmt.Printf("📡 Remote Fetch: %s...\n", hashPrefix)
 
-	// 2. 发起请求
-	// 注意：远程 API 目前只支持完整 Hash，不支持 Prefix。
-	// 这是一个限制，我们在 Phase 4 可以给 Server 加 ExpandHash RPC。
-	// 目前假设用户给的是完整 Hash。
-	req := &tvrpc.DownloadRequest{Hash: string(hashPrefix)}
+	// 2. 展开短哈希 (如果需要)
+	// MetaService.ExpandHash 让远程命令可以像本地一样接受 8 字符前缀，不用强迫用户
+	// 粘贴完整的 64 位十六进制哈希
+	fullHash, err := expandHashRemote(ctx, cli, hashPrefix)
+	if err != nil {
+		return err
+	}
+
+	// 3. 发起请求
+	req := &tvrpc.DownloadRequest{Hash: fullHash}
 	stream, err := cli.Data.Download(ctx, req)
 	if err != nil {
 		return fmt.Errorf("remote error: %w", err)
 	}
 
-	// 3. 接收头部数据进行探测
+	// 4. 接收头部数据进行探测
 	var headBuf []byte
 	totalRecv := 0
 
@@ -136,7 +140,7 @@ func catRemote(ctx context.Context, hashPrefix types.HashPrefix) error {
 		totalRecv += len(chunk)
 	}
 
-	// 4. 尝试打印结构
+	// 5. 尝试打印结构
 	isStruct, err := exporter.PrintStructure(headBuf, os.Stdout)
 	if err != nil {
 		return err
@@ -145,7 +149,7 @@ func catRemote(ctx context.Context, hashPrefix types.HashPrefix) error {
 		return nil
 	}
 
-	// 5. 如果是 Raw Data，打印预览
+	// 6. 如果是 Raw Data，打印预览
 	// 我们不再继续接收流了，直接断开，节省带宽
 	printRawPreview(headBuf, int64(totalRecv))
 	fmt.Println("\n(Stream closed. Use -o to download full content)")
@@ -153,12 +157,83 @@ func catRemote(ctx context.Context, hashPrefix types.HashPrefix) error {
 	return nil
 }
 
+// expandHashRemote 在远程模式下把一个可能的短哈希前缀展开成完整哈希：完整的 64 位
+// 十六进制哈希原样透传，不必为它白跑一次 RPC；短哈希交给 MetaService.ExpandHash，
+// 遇到 FailedPrecondition（前缀歧义）时把服务端报出的候选哈希原文转成一句"请补充更多
+// 字符"的提示，充当 CLI 侧的消歧提示——这里不做交互式选择，跟本地 catLocal 直接把
+// storage.ErrAmbiguousHash 的错误信息透传给用户是同一个朴素程度
+func expandHashRemote(ctx context.Context, cli *client.TVClient, hashPrefix types.HashPrefix) (string, error) {
+	if len(hashPrefix) == 64 {
+		return string(hashPrefix), nil
+	}
+
+	resp, err := cli.Meta.ExpandHash(ctx, &tvrpc.ExpandHashRequest{Prefix: string(hashPrefix)})
+	if err != nil {
+		switch status.Code(err) {
+		case codes.FailedPrecondition:
+			return "", fmt.Errorf("hash prefix %q is ambiguous, please provide more characters: %s", hashPrefix, status.Convert(err).Message())
+		case codes.NotFound:
+			return "", fmt.Errorf("hash prefix %q not found on remote", hashPrefix)
+		default:
+			return "", fmt.Errorf("failed to expand hash prefix %q: %w", hashPrefix, err)
+		}
+	}
+	return resp.Hash, nil
+}
+
 // --- 通用逻辑 ---
 
-func downloadObject(ctx context.Context, hashStr types.HashPrefix, path string) error {
-	// 这里复用现有的 Exporter 逻辑 (本地) 或 Download RPC (远程)
-	// 为了简洁，此处略去具体实现，逻辑同上
-	fmt.Println("Downloading to", path)
+// downloadObject 把 hashPrefix 对应的对象完整下载到 path。local/remote 两条路径都要
+// 先把前缀展开成完整哈希：本地走 expandHash，远程走 expandHashRemote（背后
+// 就是新加的 MetaService.ExpandHash RPC），这样 `tv cat <prefix> -o out --server ...`
+// 才能跟不带 -o 的 catRemote 一样透明地支持短哈希
+func downloadObject(ctx context.Context, deps *Deps, hashPrefix types.HashPrefix, path string, remote bool) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file %s: %w", path, err)
+	}
+	defer f.Close()
+
+	if !remote {
+		fullHash, err := expandHash(ctx, deps, hashPrefix)
+		if err != nil {
+			return err
+		}
+		if err := exporter.NewExporter(deps.App().Store).ExportFile(ctx, fullHash, f); err != nil {
+			return err
+		}
+		fmt.Printf("✅ Downloaded to %s\n", path)
+		return nil
+	}
+
+	cli, err := deps.GetRemoteClient()
+	if err != nil {
+		return err
+	}
+
+	fullHash, err := expandHashRemote(ctx, cli, hashPrefix)
+	if err != nil {
+		return err
+	}
+
+	stream, err := cli.Data.Download(ctx, &tvrpc.DownloadRequest{Hash: fullHash})
+	if err != nil {
+		return fmt.Errorf("remote error: %w", err)
+	}
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(resp.ChunkData); err != nil {
+			return fmt.Errorf("failed to write output file %s: %w", path, err)
+		}
+	}
+
+	fmt.Printf("✅ Downloaded to %s\n", path)
 	return nil
 }
 
@@ -184,8 +259,3 @@ func printRawPreview(data []byte, size int64) {
 		fmt.Println("\n...")
 	}
 }
-
-func init() {
-	rootCmd.AddCommand(catCmd)
-	catCmd.Flags().StringVarP(&outputFilePath, "output", "o", "", "Write output to file")
-}
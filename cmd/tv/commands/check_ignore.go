@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tensorvault/pkg/ignore"
+
+	"github.com/spf13/cobra"
+)
+
+func newCheckIgnoreCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "check-ignore <path>",
+		Short: "Debug .tvignore rules for a path",
+		Long: `Report whether the given path is ignored and, if so, which rule (and which .tvignore file)
+matched it. Handy for untangling large ML-repo ignore setups (checkpoints, wandb logs,
+__pycache__, and the like).`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			wd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			relPath := filepath.ToSlash(args[0])
+			absPath := filepath.Join(wd, relPath)
+			info, statErr := os.Stat(absPath)
+			isDir := statErr == nil && info.IsDir()
+
+			matcher, err := ignore.NewMatcher(wd)
+			if err != nil {
+				return fmt.Errorf("failed to load ignore rules: %w", err)
+			}
+
+			matched, negated, rule, found := matcher.Explain(relPath, isDir)
+			if !found {
+				fmt.Printf("%s: not ignored\n", relPath)
+				return nil
+			}
+
+			verb := "ignored"
+			if negated {
+				verb = "re-included"
+			}
+			fmt.Printf("%s: %s by rule \"%s\" (%s)\n", relPath, verb, rule.Raw, rule.Source)
+
+			if matched && !negated {
+				// 非零退出码方便脚本化调用 (对齐 `git check-ignore` 的约定)
+				os.Exit(1)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
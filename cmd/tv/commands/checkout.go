@@ -2,99 +2,155 @@ package commands
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
+	"os"
 	"time"
 
-	"tensorvault/pkg/core"
-	"tensorvault/pkg/exporter"
+	"tensorvault/pkg/app"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/task"
 	"tensorvault/pkg/types"
+	"tensorvault/pkg/worktree"
 
 	"github.com/spf13/cobra"
 )
 
-var checkoutCmd = &cobra.Command{
-	Use:   "checkout [commit-hash]",
-	Short: "Restore working tree files",
-	Long:  `Overwrite the working tree with the content from the specified commit. This will also reset the index to match the commit.`,
-	Args:  cobra.ExactArgs(1),
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if TV == nil {
-			return fmt.Errorf("app not initialized")
-		}
-
-		ctx := context.Background()
-		start := time.Now()
+func newCheckoutCmd(deps *Deps) *cobra.Command {
+	var (
+		forceCheckout  bool
+		backgroundFlag bool
+		resumeJobID    string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "checkout [branch-name|commit-hash]",
+		Short: "Switch branches or restore working tree files",
+		Long: `Overwrite the working tree with the content from the specified commit, and reset the index
+to match it. The argument is first looked up as a local branch name: if found, HEAD is left
+"attached" to that branch (so the next commit advances it). Otherwise it's resolved as a commit
+hash, and HEAD is left "detached", pointing directly at that commit.
+
+--background submits the checkout as a background task (see 'tv jobs') instead of blocking,
+driven by exporter.RestoreJob: its per-chunk progress is checkpointed to the metadata database,
+so a restore that gets interrupted (crash, kill -9, a 500GB model checkout outliving a laptop's
+battery) can continue where it left off with --resume <job-id> instead of starting over.`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if resumeJobID != "" {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+
+			ctx := context.Background()
+			start := time.Now()
+
+			if backgroundFlag || resumeJobID != "" {
+				return runCheckoutJob(ctx, deps, args, resumeJobID)
+			}
+
+			// 0. 先按分支名解析：命中的话，HEAD 会 attach 过去，而不是 detach 到某个具体 commit
+			opts := worktree.CheckoutOptions{Force: forceCheckout}
+			if branch, err := deps.App().Repository.GetBranch(ctx, args[0]); err == nil {
+				opts.Branch = branch.Name
+			} else if !errors.Is(err, meta.ErrBranchNotFound) {
+				return fmt.Errorf("failed to look up branch '%s': %w", args[0], err)
+			}
+
+			// 1. 不是分支名，按 Commit Hash 解析 (Detached Checkout)
+			if opts.Branch == "" {
+				expanded, err := expandHash(ctx, deps, types.HashPrefix(args[0]))
+				if err != nil {
+					return fmt.Errorf("invalid branch or commit '%s': %w", args[0], err)
+				}
+				opts.Hash = expanded
+			}
+
+			// 2. Dirty Check：除非用户显式传了 --force，否则先做一次带完整报告的检查，
+			// 这样用户能看到具体是哪些文件挡住了 checkout（worktree.Checkout 内部也会做
+			// 同样的检查，但只返回第一条冲突，不适合直接展示给用户）
+			if !forceCheckout {
+				wd, err := os.Getwd()
+				if err != nil {
+					return err
+				}
+				report, err := computeStatus(ctx, deps, wd)
+				if err != nil {
+					return fmt.Errorf("failed to check worktree status: %w", err)
+				}
+				if !report.IsClean() {
+					fmt.Println("error: your local changes would be overwritten by checkout:")
+					for _, e := range report.Entries {
+						if e.State != worktree.Untracked {
+							fmt.Printf("\t%s: %s\n", e.State, e.Path)
+						}
+					}
+					return fmt.Errorf("uncommitted changes, please commit or use --force to discard them")
+				}
+				opts.Force = true // 上面已经校验过了，worktree.Checkout 不用再做一遍
+			}
+
+			fmt.Printf("🔄 Checking out %s...\n", args[0])
+
+			// 3. 执行还原 (只重写发生变化的路径，并顺带重建 Index、移动 HEAD)
+			wt := worktree.NewWorktree(deps.App().Store, deps.App().Index, deps.App().Refs, ".", deps.App().Hasher)
+			commitHash, err := wt.Checkout(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			if opts.Branch != "" {
+				fmt.Printf("\n✅ Switched to branch '%s' (%s) in %s\n", opts.Branch, commitHash[:8], time.Since(start))
+				return nil
+			}
+			fmt.Printf("\n✅ Switched to commit %s in %s\n", commitHash[:8], time.Since(start))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&forceCheckout, "force", false, "discard uncommitted changes in the working tree")
+	cmd.Flags().BoolVar(&backgroundFlag, "background", false, "submit the checkout as a background task instead of blocking")
+	cmd.Flags().StringVar(&resumeJobID, "resume", "", "resume a previously interrupted restore job by id (implies --background)")
+	return cmd
+}
 
-		// 1. 解析目标 Commit Hash
-		targetInput := types.HashPrefix(args[0])
-		commitHash, err := TV.Store.ExpandHash(ctx, targetInput)
+// runCheckoutJob 提交 (或续跑) 一个 task.TypeCheckout 后台任务，而不是走上面那条同步的
+// worktree.Checkout 路径：目标目录固定是当前目录，且只支持 commit hash（分支名解析、HEAD
+// 移动这些都是 worktree.Checkout 的职责，后台任务只管把字节写到磁盘上）
+func runCheckoutJob(ctx context.Context, deps *Deps, args []string, resumeID string) error {
+	if deps.App().Jobs == nil {
+		return fmt.Errorf("task queue not available (requires the metadata database)")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+
+	payload := app.CheckoutPayload{TargetDir: wd, ResumeJobID: resumeID}
+	if resumeID == "" {
+		expanded, err := expandHash(ctx, deps, types.HashPrefix(args[0]))
 		if err != nil {
-			return fmt.Errorf("invalid commit '%s': %w", targetInput, err)
+			return fmt.Errorf("invalid commit '%s': %w", args[0], err)
 		}
-
-		// 2. 获取 Commit 对象，拿到 Root Tree
-		reader, err := TV.Store.Get(ctx, commitHash)
+		commit, err := loadCommit(ctx, deps, expanded)
 		if err != nil {
 			return err
 		}
-		data, _ := io.ReadAll(reader)
-		reader.Close()
-
-		var commit core.Commit
-		if err := core.DecodeObject(data, &commit); err != nil {
-			return fmt.Errorf("failed to decode commit: %w", err)
-		}
-
-		fmt.Printf("🔄 Checking out %s (Author: %s)...\n", commitHash[:8], commit.Author)
-
-		// 3. 准备工作区
-		// MVP 策略：直接覆盖。
-		// TODO: 理想情况下应该先检查是否有未提交的修改 (Dirty Check)，防止丢数据。
-
-		// 4. 重置暂存区 (Index)
-		// 我们将在还原过程中重建 Index
-		TV.Index.Reset()
-
-		// 5. 执行还原 (The Heavy Lifting)
-		exp := exporter.NewExporter(TV.Store)
-
-		// 定义回调：每还原一个文件，就往 Index 里加一条
-		// 这样 Checkout 完成后，Index 的状态就和磁盘完全一致了
-		restoreCallback := func(path string, hash types.Hash, size int64) {
-			// 路径归一化：RestoreTree 传回来的是绝对路径或基于 CWD 的路径
-			// 我们需要确保它符合 Index 的标准 (CleanPath)
-			// 注意：filepath.Join 可能会产生绝对路径吗？取决于 targetDir。
-			// 我们传入 "." 作为 targetDir，所以 path 是相对的。
-
-			// 小优化：只打印大文件或每 N 个文件打印一次
-			// fmt.Printf("\rRestoring: %s", path)
-			TV.Index.Add(path, hash, size)
-		}
-
-		// 从当前目录 "." 开始还原
-		err = exp.RestoreTree(ctx, commit.TreeCid.Hash, ".", restoreCallback)
-		if err != nil {
-			return fmt.Errorf("checkout failed: %w", err)
-		}
-
-		// 6. 保存 Index
-		if err := TV.Index.Save(); err != nil {
-			return fmt.Errorf("failed to update index: %w", err)
-		}
-
-		// 7. 更新 HEAD (Detached HEAD state)
-		// (注意：这在高并发下有竞态条件，但在 CLI 场景是可接受的)
-		_, currentVer, _ := TV.Refs.GetHead(ctx) // 忽略错误，如果不存在则 ver=0
-		if err := TV.Refs.UpdateHead(ctx, commitHash, currentVer); err != nil {
-			return fmt.Errorf("failed to update HEAD: %w", err)
-		}
-
-		fmt.Printf("\n✅ Switched to commit %s in %s\n", commitHash[:8], time.Since(start))
-		return nil
-	},
-}
-
-func init() {
-	rootCmd.AddCommand(checkoutCmd)
+		payload.TreeHash = commit.TreeCid.Hash.String()
+	}
+
+	id, err := deps.App().Jobs.Submit(ctx, task.TypeCheckout, payload)
+	if err != nil {
+		return fmt.Errorf("failed to submit checkout job: %w", err)
+	}
+
+	fmt.Printf("🚀 Submitted checkout as background task %s\n", id)
+	fmt.Printf("   tv jobs logs %s     # watch progress / find the restore job id to resume\n", id)
+	fmt.Printf("   tv jobs cancel %s   # request cancellation\n", id)
+	return nil
 }
@@ -4,119 +4,264 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path/filepath"
 	"time"
 
+	"tensorvault/pkg/app"
 	"tensorvault/pkg/core"
+	"tensorvault/pkg/ignore"
 	"tensorvault/pkg/refs"
+	"tensorvault/pkg/task"
 	"tensorvault/pkg/treebuilder"
+	"tensorvault/pkg/types"
 
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
 )
 
-var commitMsg string
-
-var commitCmd = &cobra.Command{
-	Use:   "commit",
-	Short: "Record changes to the repository",
-	Long:  `Create a new commit containing the current contents of the index and the given log message describing the changes.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// 0. 防御检查
-		if TV == nil {
-			return fmt.Errorf("application not initialized")
-		}
-		if commitMsg == "" {
-			return fmt.Errorf("commit message cannot be empty (use -m)")
-		}
-
-		// 1. 检查暂存区是否为空
-		// Git 允许允许空提交 (git commit --allow-empty)，但 MVP 阶段我们先禁止，避免误操作
-		if TV.Index.IsEmpty() {
-			fmt.Println("nothing to commit, working tree clean")
+func newCommitCmd(deps *Deps) *cobra.Command {
+	var (
+		commitMsg        string
+		commitAmend      bool
+		commitAllowEmpty bool
+		commitAsync      bool
+		commitSignAs     string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "commit",
+		Short: "Record changes to the repository",
+		Long:  `Create a new commit containing the current contents of the index and the given log message describing the changes.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// 0. 防御检查
+			if deps.App() == nil {
+				return fmt.Errorf("application not initialized")
+			}
+
+			ctx := context.Background()
+			start := time.Now()
+
+			if commitSignAs != "" && commitAsync {
+				return fmt.Errorf("-S/--sign is not supported together with --async yet")
+			}
+
+			// ---------------------------------------------------------
+			// Phase 0: 解析 HEAD / 被 amend 的 commit
+			// ---------------------------------------------------------
+			headHash, headVersion, headErr := deps.App().Refs.GetHead(ctx)
+			hasHead := headErr == nil
+			if headErr != nil && !errors.Is(headErr, refs.ErrNoHead) {
+				return fmt.Errorf("failed to resolve HEAD: %w", headErr)
+			}
+
+			if commitAmend && !hasHead {
+				return fmt.Errorf("cannot amend: repository has no commits yet")
+			}
+
+			var amendedCommit *core.Commit
+			if commitAmend {
+				var err error
+				amendedCommit, err = loadCommit(ctx, deps, headHash)
+				if err != nil {
+					return fmt.Errorf("failed to load commit being amended: %w", err)
+				}
+			}
+
+			if commitMsg == "" {
+				if commitAmend {
+					commitMsg = amendedCommit.Message
+				} else {
+					return fmt.Errorf("commit message cannot be empty (use -m)")
+				}
+			}
+
+			// 暂存区为空是完全正常的情况（commit 成功后我们总会清空它），所以这里不能简单地
+			// 当成"没有改动"拒绝：amend 和 --allow-empty 都允许在 Index 为空时继续往下走
+			indexEmpty := deps.App().Index.IsEmpty()
+			if indexEmpty && !commitAmend && !commitAllowEmpty {
+				fmt.Println("nothing to commit, working tree clean")
+				return nil
+			}
+
+			// ---------------------------------------------------------
+			// Phase 1: 准备 Commit 元数据
+			// ---------------------------------------------------------
+			// A. 获取 Parent Commit(s)
+			// amend 沿用的是被替换 commit 的 parents（相当于"替换"而不是"追加"），
+			// 普通提交则照常把当前 HEAD 作为唯一 parent
+			var parents []types.Hash
+			switch {
+			case commitAmend:
+				for _, p := range amendedCommit.Parents {
+					parents = append(parents, p.Hash)
+				}
+			case hasHead:
+				parents = []types.Hash{headHash}
+			default:
+				fmt.Println("🌱 Initial Commit")
+			}
+
+			// B. 获取 Author (从配置中读，如果没配就用默认值；amend 时默认沿用原作者)
+			author := deps.Viper.GetString("user.name")
+			if author == "" {
+				author = "TensorVault User"
+			}
+			if commitAmend && !cmd.Flags().Changed("message") {
+				author = amendedCommit.Author
+			}
+
+			// ---------------------------------------------------------
+			// Phase 2: 构建 Merkle Tree (The Heavy Lifting)
+			// ---------------------------------------------------------
+			var rootTreeHash types.Hash
+			var needsTreeBuild bool
+			switch {
+			case indexEmpty && commitAmend:
+				// 自上次提交以来没有新的 `tv add`：直接复用被 amend 的 commit 的 tree，
+				// 只替换 message/author，而不是拿一个空 Index 去"构建"出一棵空树
+				rootTreeHash = amendedCommit.TreeCid.Hash
+				fmt.Printf("🔨 Reusing tree from amended commit (Root: %s)\n", rootTreeHash[:8])
+			case indexEmpty && commitAllowEmpty && hasHead:
+				headCommit, err := loadCommit(ctx, deps, headHash)
+				if err != nil {
+					return fmt.Errorf("failed to load HEAD commit: %w", err)
+				}
+				rootTreeHash = headCommit.TreeCid.Hash
+				fmt.Printf("🔨 Reusing tree from HEAD (Root: %s)\n", rootTreeHash[:8])
+			default:
+				needsTreeBuild = true
+			}
+
+			// --async 把"建 tree（如果还没走上面两条复用分支）+ 落盘 commit + 推进 HEAD"整段
+			// 提交给 task.Manager 后台跑，命令本身只负责提交和尾随打印进度，不堵在这里
+			if commitAsync {
+				return runCommitAsync(ctx, deps, parents, author, commitMsg, rootTreeHash, needsTreeBuild)
+			}
+
+			if needsTreeBuild {
+				fmt.Print("🔨 Building Tree... ")
+				builder := treebuilder.NewBuilder(deps.App().Store, deps.App().Hasher)
+				if matcher, err := ignore.NewMatcher(filepath.Dir(deps.App().RepoPath)); err == nil {
+					builder.WithIgnoreMatcher(matcher)
+				}
+				var err error
+				rootTreeHash, err = builder.Build(ctx, deps.App().Index)
+				if err != nil {
+					return fmt.Errorf("failed to build tree: %w", err)
+				}
+				fmt.Printf("Done (Root: %s)\n", rootTreeHash[:8])
+			}
+
+			// ---------------------------------------------------------
+			// Phase 3: 创建并存储 Commit 对象
+			// ---------------------------------------------------------
+			commitObj, err := core.NewCommit(rootTreeHash, parents, author, commitMsg, deps.App().Hasher)
+			if err != nil {
+				return fmt.Errorf("failed to create commit object: %w", err)
+			}
+
+			if commitSignAs != "" {
+				id, found, err := deps.App().Identities.Find(commitSignAs)
+				if err != nil {
+					return fmt.Errorf("failed to load signing identity %s: %w", commitSignAs, err)
+				}
+				if !found {
+					return fmt.Errorf("no local identity with fingerprint %s (run 'tv identity list')", commitSignAs)
+				}
+				if !id.HasPrivateKey() {
+					return fmt.Errorf("identity %s has no private key, cannot sign", commitSignAs)
+				}
+				if err := commitObj.Sign(id.Key); err != nil {
+					return fmt.Errorf("failed to sign commit: %w", err)
+				}
+			}
+
+			// 持久化 Commit 对象
+			if err := deps.App().Store.Put(ctx, commitObj); err != nil {
+				return fmt.Errorf("failed to store commit: %w", err)
+			}
+
+			// ---------------------------------------------------------
+			// Phase 4: 更新引用 (Ref Update)
+			// ---------------------------------------------------------
+			// amend 本质上就是"用一个新 commit 替换当前 HEAD 指向的那个"，走的还是普通 commit
+			// 同一套基于 headVersion 的 CAS，不需要额外的原语
+			if err := deps.App().Refs.UpdateHead(ctx, commitObj.ID(), headVersion); err != nil {
+				return fmt.Errorf("failed to update HEAD: %w", err)
+			}
+
+			// ---------------------------------------------------------
+			// Phase 5: 清理现场
+			// ---------------------------------------------------------
+			// 提交成功，清空暂存区
+			deps.App().Index.Reset()
+			if err := deps.App().Index.Save(); err != nil {
+				// 这是一个尴尬的情况：Commit 成功了，但清空 Index 失败了。
+				// 不应该报错导致用户以为 Commit 失败，只是打印警告。
+				fmt.Printf("⚠️  Warning: failed to clear index: %v\n", err)
+			}
+
+			duration := time.Since(start)
+			verb := "✅"
+			if commitAmend {
+				verb = "✅ [amended]"
+			}
+			fmt.Printf("%s [%s] %s\n", verb, commitObj.ID()[:8], commitMsg)
+			fmt.Printf("   Time: %s | Author: %s\n", duration, author)
+
 			return nil
-		}
-
-		ctx := context.Background()
-		start := time.Now()
-
-		// ---------------------------------------------------------
-		// Phase 1: 构建 Merkle Tree (The Heavy Lifting)
-		// ---------------------------------------------------------
-		fmt.Print("🔨 Building Tree... ")
-		builder := treebuilder.NewBuilder(TV.Store)
-		rootTreeHash, err := builder.Build(ctx, TV.Index)
-		if err != nil {
-			return fmt.Errorf("failed to build tree: %w", err)
-		}
-		fmt.Printf("Done (Root: %s)\n", rootTreeHash[:8])
-
-		// ---------------------------------------------------------
-		// Phase 2: 准备 Commit 元数据
-		// ---------------------------------------------------------
-		// A. 获取 Parent Commit (HEAD)
-		parentHash, headVersion, err := TV.Refs.GetHead(ctx)
-		var parents []string
-
-		if err == nil {
-			// 不是第一次提交，有父节点
-			parents = []string{parentHash}
-		} else if errors.Is(err, refs.ErrNoHead) {
-			// 第一次提交 (Initial Commit)，没有父节点 -> parents 为空
-			fmt.Println("🌱 Initial Commit")
-		} else {
-			// 真正的错误（比如文件权限问题）
-			return fmt.Errorf("failed to resolve HEAD: %w", err)
-		}
-
-		// B. 获取 Author (从配置中读，如果没配就用默认值)
-		author := viper.GetString("user.name")
-		if author == "" {
-			author = "TensorVault User"
-		}
-
-		// ---------------------------------------------------------
-		// Phase 3: 创建并存储 Commit 对象
-		// ---------------------------------------------------------
-		commitObj, err := core.NewCommit(rootTreeHash, parents, author, commitMsg)
-		if err != nil {
-			return fmt.Errorf("failed to create commit object: %w", err)
-		}
-
-		// 持久化 Commit 对象
-		if err := TV.Store.Put(ctx, commitObj); err != nil {
-			return fmt.Errorf("failed to store commit: %w", err)
-		}
-
-		// ---------------------------------------------------------
-		// Phase 4: 更新引用 (Ref Update)
-		// ---------------------------------------------------------
-		// 这就是“移动 HEAD 指针”
-		if err := TV.Refs.UpdateHead(ctx, commitObj.ID(), headVersion); err != nil {
-			return fmt.Errorf("failed to update HEAD: %w", err)
-		}
-
-		// ---------------------------------------------------------
-		// Phase 5: 清理现场
-		// ---------------------------------------------------------
-		// 提交成功，清空暂存区
-		TV.Index.Reset()
-		if err := TV.Index.Save(); err != nil {
-			// 这是一个尴尬的情况：Commit 成功了，但清空 Index 失败了。
-			// 不应该报错导致用户以为 Commit 失败，只是打印警告。
-			fmt.Printf("⚠️  Warning: failed to clear index: %v\n", err)
-		}
-
-		duration := time.Since(start)
-		fmt.Printf("✅ [%s] %s\n", commitObj.ID()[:8], commitMsg)
-		fmt.Printf("   Time: %s | Author: %s\n", duration, author)
-
-		return nil
-	},
+		},
+	}
+
+	cmd.Flags().StringVarP(&commitMsg, "message", "m", "", "commit message")
+	cmd.Flags().BoolVar(&commitAmend, "amend", false, "replace the tip of the current branch with a new commit (keeps the old parents; reuses the tree if nothing new is staged)")
+	cmd.Flags().BoolVar(&commitAllowEmpty, "allow-empty", false, "allow recording a commit even though the index has no staged changes")
+	cmd.Flags().BoolVar(&commitAsync, "async", false, "submit the commit (tree build included) as a background task and tail its progress instead of blocking inline")
+	cmd.Flags().StringVarP(&commitSignAs, "sign", "S", "", "sign the commit with the local identity matching this fingerprint (see 'tv identity list')")
+	return cmd
 }
 
-func init() {
-	rootCmd.AddCommand(commitCmd)
+// runCommitAsync 提交一个 task.TypeCommit 后台任务：如果调用方已经知道 tree hash（amend /
+// --allow-empty 复用了旧 tree 的场景），跳过重新构建；否则把本地 index.json 的路径传过去，
+// 由 Handler 自己跑 treebuilder。提交之后原地尾随打印进度，直到任务到达终态——跟真正同步
+// 跑一次 commit 相比，用户看到的唯一区别是这段时间可以被 Ctrl-C 打断而不影响后台任务本身
+func runCommitAsync(ctx context.Context, deps *Deps, parents []types.Hash, author, message string, rootTreeHash types.Hash, needsTreeBuild bool) error {
+	if deps.App().Jobs == nil {
+		return fmt.Errorf("task queue not available (requires the metadata database)")
+	}
+
+	payload := app.CommitPayload{
+		Author:  author,
+		Message: message,
+	}
+	for _, p := range parents {
+		payload.Parents = append(payload.Parents, p.String())
+	}
+	if needsTreeBuild {
+		payload.IndexPath = filepath.Join(deps.App().RepoPath, "index.json")
+	} else {
+		payload.TreeHash = rootTreeHash.String()
+	}
+
+	id, err := deps.App().Jobs.Submit(ctx, task.TypeCommit, payload)
+	if err != nil {
+		return fmt.Errorf("failed to submit commit job: %w", err)
+	}
+	fmt.Printf("🚀 Submitted commit as background task %s\n", id)
+
+	job, err := tailJob(ctx, deps, id)
+	if err != nil {
+		return fmt.Errorf("failed to tail commit job %s: %w", id, err)
+	}
+	if job.Status != task.StatusSucceeded {
+		return fmt.Errorf("commit job %s did not succeed (status: %s): %s", id, job.Status, job.Error)
+	}
+
+	// 后台 Handler 已经落盘了 commit 并推进了 HEAD；本地暂存区现在可以安全清空了
+	deps.App().Index.Reset()
+	if err := deps.App().Index.Save(); err != nil {
+		fmt.Printf("⚠️  Warning: failed to clear index: %v\n", err)
+	}
 
-	// 绑定 Flags
-	commitCmd.Flags().StringVarP(&commitMsg, "message", "m", "", "commit message")
+	fmt.Printf("✅ %s\n", job.Message)
+	return nil
 }
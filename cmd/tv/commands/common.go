@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/task"
+	"tensorvault/pkg/tverr"
+	"tensorvault/pkg/types"
+)
+
+// loadCommit 读取并解码一个 Commit 对象，供 log/checkout/status 等命令共用
+func loadCommit(ctx context.Context, deps *Deps, hash types.Hash) (*core.Commit, error) {
+	reader, err := deps.App().Store.Get(ctx, hash)
+	if err != nil {
+		wrapped := fmt.Errorf("failed to retrieve commit %s: %w", hash, err)
+		if errors.Is(err, storage.ErrNotFound) {
+			return nil, tverr.Wrap(tverr.ErrObjectMissing, wrapped)
+		}
+		return nil, wrapped
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var commit core.Commit
+	if err := core.DecodeObject(data, &commit); err != nil {
+		return nil, fmt.Errorf("object %s is corrupted or not a commit: %w", hash, err)
+	}
+	return &commit, nil
+}
+
+// expandHash 把用户敲的（可能缩写的）哈希前缀展开成完整 Hash，供 checkout/reset/cat/log/
+// blame 共用。比直接调用 deps.App().Store.ExpandHash 多一步：先校验前缀带的算法标签
+// （如果有）是不是跟仓库当前锁定的算法一致，这样敲错算法的前缀会在打到某个 Store 后端之前
+// 就失败，而不是让对方扫完一整个分片目录之后才告诉你 NotFound
+func expandHash(ctx context.Context, deps *Deps, prefix types.HashPrefix) (types.Hash, error) {
+	if err := core.ValidateHashPrefix(prefix, deps.App().Hasher.Algo()); err != nil {
+		return "", tverr.Wrap(tverr.ErrHashMismatch, err)
+	}
+	hash, err := deps.App().Store.ExpandHash(ctx, prefix)
+	if errors.Is(err, storage.ErrNotFound) {
+		return "", tverr.Wrap(tverr.ErrObjectMissing, err)
+	}
+	return hash, err
+}
+
+// tailJobPollInterval 是 tailJob 重新查询任务状态的间隔
+const tailJobPollInterval = 300 * time.Millisecond
+
+// tailJob 轮询一个已提交的后台任务直到它到达终态，边轮询边把新出现的日志行打到标准输出，
+// 供 --async 风格的子命令用：提交完 job 之后不想直接撒手不管，而是留在前台展示进度，
+// 就像这个命令本来就是同步跑的一样，只是底下换成了 task.Manager 在跑
+func tailJob(ctx context.Context, deps *Deps, id string) (*task.Job, error) {
+	seen := 0
+	ticker := time.NewTicker(tailJobPollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := deps.App().Jobs.Status(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch task %s: %w", id, err)
+		}
+		for _, line := range job.Log[seen:] {
+			fmt.Println(line)
+		}
+		seen = len(job.Log)
+
+		switch job.Status {
+		case task.StatusSucceeded, task.StatusFailed, task.StatusCancelled:
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return job, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"tensorvault/pkg/remote"
+
+	"github.com/spf13/cobra"
+)
+
+func newFetchCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "fetch <name>",
+		Short: "Download missing objects and refs from a named remote",
+		Long: `Fetches every object reachable from a named remote's advertised refs that this
+repository doesn't already have (see pkg/remote.Pull), then records what the remote calls
+each branch under refs/remotes/<name>/<branch> — it never touches refs/heads/* directly,
+the same way 'git fetch' leaves merging into your own branches to you.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+			return fetchFromRemote(cmd.Context(), deps, args[0])
+		},
+	}
+	return cmd
+}
+
+// fetchFromRemote 走 remote.Pull 把对象拉全，再把远端汇报的每个 refs/heads/<branch>
+// 记成本地的 refs/remotes/<name>/<branch>——CAS 的 oldVersion 现查一遍本地已有的
+// 版本号，没有就是 0（对应 meta.Repository.UpdateRef 里"第一次创建"那条分支）
+func fetchFromRemote(ctx context.Context, deps *Deps, name string) error {
+	entry, ok := deps.App().Remotes.Get(name)
+	if !ok {
+		return fmt.Errorf("unknown remote '%s' (run 'tv remote add %s <url>' first)", name, name)
+	}
+
+	rc := remote.NewClient(entry.URL)
+
+	fmt.Printf("Fetching from '%s' (%s)...\n", name, entry.URL)
+	remoteRefs, err := remote.Pull(ctx, rc, deps.App().Store)
+	if err != nil {
+		return fmt.Errorf("fetch from '%s' failed: %w", name, err)
+	}
+
+	for refName, re := range remoteRefs {
+		branch := strings.TrimPrefix(refName, "refs/heads/")
+		trackingRef := "refs/remotes/" + name + "/" + branch
+
+		_, version, err := deps.App().Refs.GetRef(ctx, trackingRef)
+		if err != nil {
+			return fmt.Errorf("failed to resolve tracking ref %s: %w", trackingRef, err)
+		}
+		if err := deps.App().Refs.UpdateRef(ctx, trackingRef, re.Hash, version); err != nil {
+			return fmt.Errorf("failed to update tracking ref %s: %w", trackingRef, err)
+		}
+		fmt.Printf("  %s -> %s\n", trackingRef, re.Hash)
+	}
+
+	return nil
+}
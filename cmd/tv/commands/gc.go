@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"tensorvault/pkg/gc"
+
+	"github.com/spf13/cobra"
+)
+
+func newGCCmd(deps *Deps) *cobra.Command {
+	var (
+		repackFlag        bool
+		packFlag          bool
+		packOlderThanFlag time.Duration
+		sweepFlag         bool
+		sweepGraceFlag    time.Duration
+		sweepDryRunFlag   bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "gc",
+		Short: "Clean up and optimize the local object store",
+		Long: `Garbage-collect and optimize the object store. With --repack, scans recently
+written chunks and rewrites near-duplicate ones as DeltaObjects against a similar
+base chunk, trading a bit of read-time reconstruction for a lot of disk space.
+With --pack, folds loose objects older than --pack-older-than into a single pack
+file, trading a bit of read-time indirection for far fewer inodes and directory
+entries. With --sweep, performs a mark-and-sweep pass: walks every local branch
+and HEAD down through commits/trees/filenodes/chunks to build the set of reachable
+objects, then deletes anything else older than --sweep-grace, recording a tombstone
+for each one first. Refuses to run while an upload or tree-build job is in flight,
+since such a job may have written objects that aren't reachable yet. --sweep-dry-run
+reports what would be deleted without writing tombstones or touching the store.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+			if !repackFlag && !packFlag && !sweepFlag {
+				fmt.Println("Nothing to do. Pass --repack to compact near-duplicate chunks, --pack to fold old loose objects into a pack file, or --sweep to delete unreachable objects.")
+				return nil
+			}
+
+			onProgress := func(done, total int, message string) {
+				fmt.Printf("  [%d/%d] %s\n", done, total, message)
+			}
+
+			if repackFlag {
+				fmt.Println("🧹 [gc --repack] Scanning object store...")
+				stats, err := gc.Repack(cmd.Context(), deps.App().Store, onProgress)
+				if err != nil {
+					return err
+				}
+
+				if stats.Repacked == 0 {
+					fmt.Println("✅ [gc --repack] No near-duplicate chunks found, nothing rewritten.")
+				} else {
+					saved := stats.SavedBytes()
+					fmt.Printf("✅ [gc --repack] Rewrote %d chunks as deltas: %d -> %d bytes (saved %d bytes, %.1f%%)\n",
+						stats.Repacked, stats.OriginalBytes, stats.RepackedBytes, saved, float64(saved)/float64(stats.OriginalBytes)*100)
+				}
+			}
+
+			if packFlag {
+				fmt.Printf("📦 [gc --pack] Scanning object store for objects older than %s...\n", packOlderThanFlag)
+				stats, err := gc.Pack(cmd.Context(), deps.App().Store, packOlderThanFlag, onProgress)
+				if err != nil {
+					return err
+				}
+
+				if stats.Packed == 0 {
+					fmt.Println("✅ [gc --pack] No loose objects old enough to pack.")
+				} else {
+					fmt.Printf("✅ [gc --pack] Packed %d/%d loose objects into %s\n", stats.Packed, stats.Scanned, stats.PackName)
+				}
+			}
+
+			if sweepFlag {
+				if deps.App().Repository == nil {
+					return fmt.Errorf("--sweep requires the metadata database (no --db configured)")
+				}
+
+				label := "gc --sweep"
+				if sweepDryRunFlag {
+					label = "gc --sweep --dry-run"
+				}
+				fmt.Printf("🗑️  [%s] Marking reachable objects from all local refs...\n", label)
+				stats, err := gc.Sweep(cmd.Context(), deps.App().Store, deps.App().Repository, deps.App().Refs, sweepGraceFlag, sweepDryRunFlag, onProgress)
+				if err != nil {
+					return err
+				}
+
+				if stats.Tombstoned == 0 {
+					fmt.Printf("✅ [%s] Walked %d live refs, scanned %d objects, nothing unreachable.\n", label, stats.Roots, stats.Scanned)
+				} else if sweepDryRunFlag {
+					fmt.Printf("✅ [%s] Walked %d live refs, scanned %d objects, %d would be deleted.\n", label, stats.Roots, stats.Scanned, stats.Tombstoned)
+				} else {
+					fmt.Printf("✅ [%s] Walked %d live refs, scanned %d objects, deleted %d unreachable objects.\n", label, stats.Roots, stats.Scanned, stats.Deleted)
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&repackFlag, "repack", false, "rewrite near-duplicate chunks as delta-compressed objects")
+	cmd.Flags().BoolVar(&packFlag, "pack", false, "fold loose objects older than --pack-older-than into a pack file")
+	cmd.Flags().DurationVar(&packOlderThanFlag, "pack-older-than", gc.DefaultPackOlderThan, "minimum age of a loose object before --pack will fold it into a pack file")
+	cmd.Flags().BoolVar(&sweepFlag, "sweep", false, "mark-and-sweep: delete objects unreachable from any local ref")
+	cmd.Flags().DurationVar(&sweepGraceFlag, "sweep-grace", gc.DefaultSweepGrace, "minimum age of an object before --sweep will consider deleting it")
+	cmd.Flags().BoolVar(&sweepDryRunFlag, "sweep-dry-run", false, "report what --sweep would delete without writing tombstones or deleting anything")
+	return cmd
+}
@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"fmt"
+
+	"tensorvault/pkg/identity"
+
+	"github.com/spf13/cobra"
+)
+
+func newIdentityCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "identity",
+		Short: "Manage local signing identities used by 'tv commit -S'",
+	}
+	cmd.AddCommand(newIdentityAddCmd(deps), newIdentityListCmd(deps))
+	return cmd
+}
+
+func newIdentityAddCmd(deps *Deps) *cobra.Command {
+	var identityAddAlgo string
+
+	cmd := &cobra.Command{
+		Use:   "add <name> <email>",
+		Short: "Generate a new signing identity and add it to the local identity store",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+			name, email := args[0], args[1]
+
+			var key identity.Key
+			var err error
+			switch identityAddAlgo {
+			case "ed25519":
+				key, err = identity.GenerateEd25519Key()
+			case "openpgp":
+				key, err = identity.GenerateOpenPGPKey(name, email)
+			default:
+				return fmt.Errorf("unknown algorithm %q (want ed25519 or openpgp)", identityAddAlgo)
+			}
+			if err != nil {
+				return fmt.Errorf("failed to generate %s key: %w", identityAddAlgo, err)
+			}
+
+			id := identity.Identity{Name: name, Email: email, Key: key}
+			if err := deps.App().Identities.Add(id); err != nil {
+				return fmt.Errorf("failed to add identity: %w", err)
+			}
+			if err := deps.App().Identities.Save(); err != nil {
+				return fmt.Errorf("failed to persist identity store: %w", err)
+			}
+
+			fmt.Printf("✅ Added %s identity for %s <%s> (fingerprint: %s)\n", identityAddAlgo, name, email, key.Fingerprint())
+			return nil
+		},
+	}
+
+	defaultAlgo := deps.Viper.GetString("identity.default_algo")
+	if defaultAlgo == "" {
+		defaultAlgo = "ed25519"
+	}
+	cmd.Flags().StringVar(&identityAddAlgo, "algo", defaultAlgo, "signing algorithm to generate (ed25519 or openpgp)")
+	return cmd
+}
+
+func newIdentityListCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List locally known signing identities",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+			ids, err := deps.App().Identities.List()
+			if err != nil {
+				return fmt.Errorf("failed to list identities: %w", err)
+			}
+			if len(ids) == 0 {
+				fmt.Println("no identities yet (run 'tv identity add <name> <email>' to create one)")
+				return nil
+			}
+			for _, id := range ids {
+				kind := "public only"
+				if id.HasPrivateKey() {
+					kind = "can sign"
+				}
+				fmt.Printf("%s  %-8s %s <%s>  (%s)\n", id.Key.Fingerprint(), id.Key.Algorithm(), id.Name, id.Email, kind)
+			}
+			return nil
+		},
+	}
+	return cmd
+}
@@ -1,11 +1,20 @@
 package commands
 
 import (
+	"context"
+	"encoding/base64"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/remote"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/types"
 
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
 )
 
 // 默认配置模板
@@ -39,54 +48,248 @@ database:
   dbname: "tensorvault"
   sslmode: "disable"
 
+# [Client] Content-Defined Chunking
+# algo: "gear" (default, fastest) | "rabin" (restic/bup-style, steadier boundaries
+# across data-type shifts) | "buzhash". Changing this after the repo already has
+# history is safe for new commits, but chunks of previously committed files will
+# not re-dedupe against files chunked under the old algorithm/parameters.
+chunker:
+  algo: "gear"
+  min: 4096
+  avg: 8192
+  max: 65536
+
 # User Identity
 user:
   name: "Anonymous"
   email: "anon@tensorvault.io"
 `
 
-var initCmd = &cobra.Command{
-	Use:   "init",
-	Short: "Initialize a TensorVault repository",
-	Long:  `Create an empty TensorVault repository and default configuration.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		wd, err := os.Getwd()
-		if err != nil {
-			return err
-		}
+// importManifest 是 --import 读入的种子清单：一份已导出的对象图 + 它的根 commit hash。
+// 请求文本里管这个文件叫 "manifest.toml"，但这个仓库到处用 YAML 做配置/清单格式
+// （defaultConfigTemplate、pkg/exporter 之类都是），没有任何地方引入过 TOML 解析库，
+// 所以沿用 YAML 语法解析——只是把扩展名留给调用方自己选，内容格式不因为文件名里带
+// "toml" 就变
+type importManifest struct {
+	RootCommit string         `yaml:"root_commit"`
+	Branch     string         `yaml:"branch"`
+	Objects    []importObject `yaml:"objects"`
+}
 
-		// 1. 创建目录结构
-		repoPath := filepath.Join(wd, ".tv")
-		objectsPath := filepath.Join(repoPath, "objects")
-		if err := os.MkdirAll(objectsPath, 0755); err != nil {
-			return fmt.Errorf("failed to create repo directory: %w", err)
-		}
+// importObject 是清单里的一条对象记录：Data 是原始字节的 base64 编码
+type importObject struct {
+	Hash string `yaml:"hash"`
+	Type string `yaml:"type"`
+	Data string `yaml:"data"`
+}
 
-		fmt.Printf("✅ Initialized empty TensorVault repository in %s\n", repoPath)
+// newInitCmd 的 RunE 是唯一一个不依赖 deps.App() 的命令——init 本来就是去创建 App 需要的
+// 环境的，NewRootCmd 的 PersistentPreRunE 对它做了特判——但仍然接收 deps 以保持跟其它
+// newFooCmd 构造函数一致的签名，并为将来需要读 deps.Viper（比如把 --home 的默认值也下沉
+// 到配置文件里）留好口子
+func newInitCmd(deps *Deps) *cobra.Command {
+	var (
+		initForceReset     bool
+		initHome           string
+		initImportManifest string
+		initTrustHash      string
+	)
 
-		// 2. [新增] 生成配置文件
-		configPath := filepath.Join(repoPath, "config.yaml")
-		if _, err := os.Stat(configPath); os.IsNotExist(err) {
-			if err := os.WriteFile(configPath, []byte(defaultConfigTemplate), 0644); err != nil {
-				return fmt.Errorf("failed to create config file: %w", err)
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Initialize a TensorVault repository",
+		Long:  `Create an empty TensorVault repository and default configuration.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			repoPath, err := resolveInitRepoPath(initHome)
+			if err != nil {
+				return err
 			}
-			fmt.Printf("📝 Generated default configuration at %s\n", configPath)
-		} else {
-			fmt.Printf("ℹ️  Config file already exists at %s\n", configPath)
-		}
+			objectsPath := filepath.Join(repoPath, "objects")
 
-		// 3. [新增] 初始化空的 index.json (防止首次 add 报错)
-		indexPath := filepath.Join(repoPath, "index.json")
-		if _, err := os.Stat(indexPath); os.IsNotExist(err) {
-			if err := os.WriteFile(indexPath, []byte("{}"), 0644); err != nil {
-				return fmt.Errorf("failed to init index: %w", err)
+			if initForceReset {
+				if err := forceResetRepo(repoPath); err != nil {
+					return err
+				}
 			}
+
+			// 1. 创建目录结构
+			if err := os.MkdirAll(objectsPath, 0755); err != nil {
+				return fmt.Errorf("failed to create repo directory: %w", err)
+			}
+
+			fmt.Printf("✅ Initialized empty TensorVault repository in %s\n", repoPath)
+
+			// 2. [新增] 生成配置文件
+			configPath := filepath.Join(repoPath, "config.yaml")
+			if _, err := os.Stat(configPath); os.IsNotExist(err) {
+				if err := os.WriteFile(configPath, []byte(defaultConfigTemplate), 0644); err != nil {
+					return fmt.Errorf("failed to create config file: %w", err)
+				}
+				fmt.Printf("📝 Generated default configuration at %s\n", configPath)
+			} else {
+				fmt.Printf("ℹ️  Config file already exists at %s\n", configPath)
+			}
+
+			// 3. [新增] 初始化空的 index.json (防止首次 add 报错)
+			indexPath := filepath.Join(repoPath, "index.json")
+			if _, err := os.Stat(indexPath); os.IsNotExist(err) {
+				if err := os.WriteFile(indexPath, []byte("{}"), 0644); err != nil {
+					return fmt.Errorf("failed to init index: %w", err)
+				}
+			}
+
+			if initImportManifest != "" {
+				if err := importSeedManifest(cmd.Context(), repoPath, objectsPath, initImportManifest, initTrustHash); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&initForceReset, "force-reset", false, "Wipe local objects and index before initializing (keeps identities.json/remotes.json)")
+	cmd.Flags().StringVar(&initHome, "home", "", "Parent directory for the .tv repo (default: current working directory)")
+	cmd.Flags().StringVar(&initImportManifest, "import", "", "Seed the repo from an exported object manifest (YAML, despite the conventional .toml name)")
+	cmd.Flags().StringVar(&initTrustHash, "trust-hash", "", "Expected root_commit hash in the --import manifest; import is refused on mismatch")
+	return cmd
+}
+
+// resolveInitRepoPath 决定 .tv 的落地目录：--home 不传时维持这个仓库一直以来的行为
+// （.tv 挂在当前工作目录下，而不是 $HOME），--home 传了就把它当成 .tv 的父目录——这是
+// "--home 覆盖默认 $HOME/.tv" 这个请求在这个仓库里最贴近现状的落地方式，这个仓库从来
+// 就没有过 "默认存在 $HOME 下" 的语义（参见 pkg/app.NewApp 永远用 os.Getwd()）
+func resolveInitRepoPath(home string) (string, error) {
+	if home != "" {
+		return filepath.Join(home, ".tv"), nil
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(wd, ".tv"), nil
+}
+
+// forceResetRepo 清空对象库和本地索引，但保留签名身份 (identities.json) 和已知远端
+// (remotes.json)——`--force-reset` 是给"仓库数据坏了，想从远端重新拉一份"这种场景用的，
+// 不该连带把本地配过的签名身份一起扔掉，重新生成密钥/丢失指纹会让之前签过的 commit
+// 全部变得无法归因。
+//
+// 这个仓库的 ref 历史在配了 database.* 的部署里实际存在 Postgres（pkg/meta.Repository），
+// 不是本地文件，`tv init` 本身也从不连 DB（见 PersistentPreRunE 对 init 的特判），所以
+// 这里没有"本地 refs 目录"可删——没有 DB 的仓库本来就没有 commit/分支能力
+// (pkg/app.NewApp 里 metaDB 连不上时 Repository/Refs 直接是 nil)，谈不上重置；
+// 有 DB 的仓库要重置 ref 历史得在 Postgres 那一侧做，这个命令管不到，也就不假装管到
+func forceResetRepo(repoPath string) error {
+	objectsPath := filepath.Join(repoPath, "objects")
+	if err := os.RemoveAll(objectsPath); err != nil {
+		return fmt.Errorf("failed to wipe %s: %w", objectsPath, err)
+	}
+
+	// objects-erasure 是 storage.type=erasure 时对象的落地目录 (见 pkg/app.go)，跟
+	// objects 是同一件事的另一种布局，--force-reset 要一并清空
+	erasurePath := filepath.Join(repoPath, "objects-erasure")
+	if _, err := os.Stat(erasurePath); err == nil {
+		if err := os.RemoveAll(erasurePath); err != nil {
+			return fmt.Errorf("failed to wipe %s: %w", erasurePath, err)
 		}
+	}
 
-		return nil
-	},
+	indexPath := filepath.Join(repoPath, "index.json")
+	if err := os.WriteFile(indexPath, []byte("{}"), 0644); err != nil {
+		return fmt.Errorf("failed to reset index: %w", err)
+	}
+
+	fmt.Println("🧹 --force-reset: wiped local objects and index, kept identities.json/remotes.json")
+	return nil
 }
 
-func init() {
-	rootCmd.AddCommand(initCmd)
+// importSeedManifest 校验 manifest 的根 commit hash 等于 --trust-hash，并对每个携带的
+// 对象重新计算一遍内容哈希确认跟 manifest 里声明的 hash 一致，都通过之后才把对象图写进
+// 本地对象库，最后落一份 trust.toml 记录信任的根，供以后的 fetch/pull 在接受服务端给的根
+// 之前核对（那部分消费逻辑不在这次改动范围内，这里只负责写出文件）
+func importSeedManifest(ctx context.Context, repoPath, objectsPath, manifestPath, trustHash string) error {
+	if trustHash == "" {
+		return fmt.Errorf("--import requires --trust-hash (the expected root_commit hash) to avoid silently trusting an unverified manifest")
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return fmt.Errorf("failed to read import manifest %s: %w", manifestPath, err)
+	}
+
+	var manifest importManifest
+	if err := yaml.Unmarshal(data, &manifest); err != nil {
+		return fmt.Errorf("failed to parse import manifest %s: %w", manifestPath, err)
+	}
+
+	if manifest.RootCommit == "" {
+		return fmt.Errorf("import manifest %s has no root_commit", manifestPath)
+	}
+	if manifest.RootCommit != trustHash {
+		return fmt.Errorf("manifest root_commit %s does not match --trust-hash %s, refusing to import", manifest.RootCommit, trustHash)
+	}
+
+	// 先把所有对象的内容哈希都核实一遍、一个不漏地找到声明的 root_commit 本身，再真正写盘。
+	// --trust-hash 只核对了 manifest 里 *声明* 的 root_commit 字符串，manifest.Objects 里
+	// 每一条的 Data 都是服务端/导出方自己拍的，不重新算一遍哈希的话，一个内容被篡改但 Hash
+	// 字段照抄的对象会在这一步完全不被发现地混进本地对象库。哈希算法不是本地 config.yaml
+	// 里的 hashing.algo（这个仓库还没建好，init 也从不锁定算法），而是按每条记录自己的
+	// types.Hash 前缀来定——跟 pkg/gc/repack.go 用 core.HasherFor(best.Hash.Algo()) 推导
+	// 重打包 delta 哈希是同一个理由：manifest 的权威性来自内容本身携带的算法标签，不是本地
+	// 这次 init 凑巧用了哪个配置
+	decoded := make([][]byte, len(manifest.Objects))
+	rootFound := false
+	for i, obj := range manifest.Objects {
+		raw, err := base64.StdEncoding.DecodeString(obj.Data)
+		if err != nil {
+			return fmt.Errorf("manifest object %d (%s): invalid base64 data: %w", i, obj.Hash, err)
+		}
+		decoded[i] = raw
+
+		hasher, err := core.HasherFor(types.Hash(obj.Hash).Algo())
+		if err != nil {
+			return fmt.Errorf("manifest object %d (%s): %w", i, obj.Hash, err)
+		}
+		actualHash := core.CalculateBlobHash(raw, hasher)
+		if actualHash.String() != obj.Hash {
+			return fmt.Errorf("manifest object %d declares hash %s but its content hashes to %s, refusing to import", i, obj.Hash, actualHash)
+		}
+		if obj.Hash == manifest.RootCommit {
+			rootFound = true
+		}
+	}
+	if !rootFound {
+		return fmt.Errorf("manifest root_commit %s is not present among the imported objects", manifest.RootCommit)
+	}
+
+	store, err := disk.NewAdapter(objectsPath)
+	if err != nil {
+		return fmt.Errorf("failed to open object store for import: %w", err)
+	}
+
+	for i, obj := range manifest.Objects {
+		// typ 留空交给 remote.NewObject 去嗅探——manifest 里省略 type 字段也能导入，
+		// 跟 pkg/client/transport_grpc.go 里从裸字节 RPC 响应构造 core.Object 是同一个理由
+		wrapped := remote.NewObject(types.Hash(obj.Hash), core.ObjectType(obj.Type), decoded[i])
+		if err := store.Put(ctx, wrapped); err != nil {
+			return fmt.Errorf("failed to import object %s: %w", obj.Hash, err)
+		}
+	}
+
+	trustPath := filepath.Join(repoPath, "trust.toml")
+	trustContents := fmt.Sprintf(
+		"# Generated by `tv init --import`. Records the root commit trusted at import time.\n# No tv command reads this file; fetch does not check server roots against it.\nroot_hash = %q\nimported_at = %q\n",
+		manifest.RootCommit, time.Now().UTC().Format(time.RFC3339),
+	)
+	if err := os.WriteFile(trustPath, []byte(trustContents), 0644); err != nil {
+		return fmt.Errorf("failed to write trust.toml: %w", err)
+	}
+
+	fmt.Printf("📥 Imported %d object(s) from %s, trusted root %s\n", len(manifest.Objects), manifestPath, manifest.RootCommit)
+	fmt.Printf("🔐 Wrote %s\n", trustPath)
+	if manifest.Branch != "" {
+		fmt.Printf("ℹ️  Manifest targets branch %q — this repo has no local ref storage without a database.* connection, so HEAD is not set here; point a DB-backed repo's HEAD at %s once connected\n", manifest.Branch, manifest.RootCommit)
+	}
+	return nil
 }
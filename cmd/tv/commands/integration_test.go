@@ -2,6 +2,7 @@ package commands
 
 import (
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -12,6 +13,7 @@ import (
 	"tensorvault/pkg/refs"
 	"tensorvault/pkg/storage/disk"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/driver/sqlite"
@@ -20,7 +22,7 @@ import (
 )
 
 // setupIntegrationEnv 搭建一个使用 真实文件系统 + 内存数据库 的集成环境
-func setupIntegrationEnv(t *testing.T) (*app.App, string) {
+func setupIntegrationEnv(t *testing.T) (*Deps, string) {
 	// 1. 准备临时工作目录
 	tmpDir := t.TempDir()
 
@@ -60,16 +62,16 @@ func setupIntegrationEnv(t *testing.T) (*app.App, string) {
 		Repository: repo,
 	}
 
-	// 7. 【关键】注入全局变量 TV
-	// 因为 cmd 包依赖全局变量 TV，我们在测试里临时覆盖它
-	TV = application
+	// 7. 【关键】把装好的 App 塞进一个独立的 Deps，而不是覆盖哪个包级全局变量——每个测试
+	// 拿到自己的 Deps，互相之间不共享 App/远端连接状态，可以放心并发跑
+	deps := &Deps{Viper: viper.New(), Stdout: io.Discard, Stderr: io.Discard, app: application}
 
-	return application, tmpDir
+	return deps, tmpDir
 }
 
 func TestIntegration_CommitFlow(t *testing.T) {
 	// 1. 搭建环境
-	app, tmpDir := setupIntegrationEnv(t)
+	deps, tmpDir := setupIntegrationEnv(t)
 	ctx := context.Background()
 
 	// 2. 模拟用户操作：创建一个文件并添加到 Index
@@ -82,25 +84,26 @@ func TestIntegration_CommitFlow(t *testing.T) {
 	// 这里我们直接操作 Index API，模拟 add 的效果 (也可以直接调 addCmd.RunE)
 	// 为了聚焦测试 Commit，我们假设 Add 已经成功
 	fileHash := "5eb63bbbe01eeed093cb22bb8f5acdc3" // md5("hello world") 假装是这个
-	app.Index.Add("data.txt", fileHash, 11)
+	deps.App().Index.Add("data.txt", fileHash, 11)
 
 	// 3. 执行 Commit 命令
 	// 模拟参数：tv commit -m "First Commit"
-	commitMsg = "Integration Test Commit" // 设置全局 flag 变量
+	commitCmd := newCommitCmd(deps)
+	require.NoError(t, commitCmd.Flags().Set("message", "Integration Test Commit"))
 	err = commitCmd.RunE(commitCmd, []string{})
 	require.NoError(t, err, "Commit command should succeed")
 
 	// --- 验证阶段 (The Verification) ---
 
 	// A. 验证 HEAD 是否更新
-	headHash, ver, err := app.Refs.GetHead(ctx)
+	headHash, ver, err := deps.App().Refs.GetHead(ctx)
 	require.NoError(t, err)
 	assert.NotEmpty(t, headHash, "HEAD should point to a commit hash")
 	assert.Equal(t, int64(1), ver, "Version should be 1")
 
 	// B. 验证 S3/Disk 是否有 Commit 对象
 	// 尝试从 Store 读取 HEAD 指向的对象
-	reader, err := app.Store.Get(ctx, headHash)
+	reader, err := deps.App().Store.Get(ctx, headHash)
 	assert.NoError(t, err, "Commit object must exist in object storage")
 	if reader != nil {
 		reader.Close()
@@ -108,7 +111,7 @@ func TestIntegration_CommitFlow(t *testing.T) {
 
 	// C. 验证 Postgres 是否有索引记录 (这正是之前漏掉的！)
 	var commitModel *meta.CommitModel
-	commitModel, err = app.Repository.GetCommit(ctx, headHash)
+	commitModel, err = deps.App().Repository.GetCommit(ctx, headHash)
 	assert.NoError(t, err, "Commit metadata must exist in SQL database")
 	assert.Equal(t, "Integration Test Commit", commitModel.Message, "Commit message should match")
 
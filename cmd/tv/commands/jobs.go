@@ -0,0 +1,84 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newJobsCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "jobs",
+		Short: "Inspect and control background tasks (uploads, checkouts, gc --repack, tree builds)",
+	}
+	cmd.AddCommand(newJobsListCmd(deps), newJobsLogsCmd(deps), newJobsCancelCmd(deps))
+	return cmd
+}
+
+func newJobsListCmd(deps *Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "ls",
+		Short: "List recent background tasks",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil || deps.App().Jobs == nil {
+				return fmt.Errorf("task queue not available (requires the metadata database)")
+			}
+			jobs, err := deps.App().Jobs.List(cmd.Context(), 20)
+			if err != nil {
+				return fmt.Errorf("failed to list tasks: %w", err)
+			}
+			if len(jobs) == 0 {
+				fmt.Println("no tasks yet")
+				return nil
+			}
+			for _, j := range jobs {
+				fmt.Printf("%s  %-10s %-9s %3d%%  %s\n", j.ID, j.Type, j.Status, j.Progress, j.Message)
+			}
+			return nil
+		},
+	}
+}
+
+func newJobsLogsCmd(deps *Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "logs <id>",
+		Short: "Show the log lines for a background task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil || deps.App().Jobs == nil {
+				return fmt.Errorf("task queue not available (requires the metadata database)")
+			}
+			job, err := deps.App().Jobs.Status(cmd.Context(), args[0])
+			if err != nil {
+				return fmt.Errorf("failed to fetch task %s: %w", args[0], err)
+			}
+			fmt.Printf("%s  %s  %s  %d%%\n", job.ID, job.Type, job.Status, job.Progress)
+			if job.Error != "" {
+				fmt.Printf("error: %s\n", job.Error)
+			}
+			for _, line := range job.Log {
+				fmt.Println(line)
+			}
+			return nil
+		},
+	}
+}
+
+func newJobsCancelCmd(deps *Deps) *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel <id>",
+		Short: "Request cancellation of a running background task",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil || deps.App().Jobs == nil {
+				return fmt.Errorf("task queue not available (requires the metadata database)")
+			}
+			if err := deps.App().Jobs.Cancel(cmd.Context(), args[0]); err != nil {
+				return fmt.Errorf("failed to cancel task %s: %w", args[0], err)
+			}
+			fmt.Printf("cancellation requested for %s\n", args[0])
+			return nil
+		},
+	}
+}
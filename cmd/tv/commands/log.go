@@ -1,108 +1,456 @@
 package commands
 
 import (
+	"container/heap"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
+	"strings"
 	"time"
 
 	"tensorvault/pkg/core"
+	"tensorvault/pkg/graphlog"
+	"tensorvault/pkg/meta"
 	"tensorvault/pkg/refs"
 	"tensorvault/pkg/types"
 
 	"github.com/spf13/cobra"
 )
 
-// TODO: 利用 refs 与meta包实现 log 命令
-var logCmd = &cobra.Command{
-	Use:   "log [commit-hash]",
-	Short: "Show commit logs",
-	Long:  `Display the commit history starting from the specified commit (or HEAD if not specified).`,
-	Args:  cobra.MaximumNArgs(1), // 0 或 1 个参数
-	RunE: func(cmd *cobra.Command, args []string) error {
-		if TV == nil {
-			return fmt.Errorf("app not initialized")
-		}
+func newLogCmd(deps *Deps) *cobra.Command {
+	var (
+		logShowSignature bool
+		logAll           bool
+		logGraph         bool
+		logAuthor        string
+		logSince         string
+		logUntil         string
+		logGrep          string
+	)
 
-		ctx := context.Background()
-		var currentHash types.Hash
+	cmd := &cobra.Command{
+		Use:   "log [<commit>|<rev-range>]",
+		Short: "Show commit logs",
+		Long: `Display the commit history starting from the specified commit (or HEAD if not
+specified). Accepts a single commit (hash, possibly abbreviated), or a revision range:
 
-		// 1. 确定起始点 (Start Point)
-		if len(args) > 0 {
-			// 如果用户指定了 Hash (支持短哈希)
+  tv log A..B    show commits reachable from B but not from A
+  tv log A...B   show commits reachable from either A or B but not both (symmetric difference)
 
-			input := types.HashPrefix(args[0])
-			fullHash, err := TV.Store.ExpandHash(ctx, input)
-			if err != nil {
-				return fmt.Errorf("invalid commit argument '%s': %w", input, err)
-			}
-			currentHash = fullHash
-		} else {
-			// 默认从 HEAD 开始
-			head, _, err := TV.Refs.GetHead(ctx)
-			if errors.Is(err, refs.ErrNoHead) {
-				fmt.Println("No commits yet.")
-				return nil
+Traversal follows every parent of a commit (not just the first), so merge commits are no
+longer truncated to their main line. --author/--since/--until/--grep are applied against the
+metadata database's commit index instead of re-reading each commit's object bytes.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
 			}
-			if err != nil {
-				return fmt.Errorf("failed to read HEAD: %w", err)
+			if deps.App().Repository == nil {
+				return fmt.Errorf("tv log requires a metadata database (no meta store configured for this repository)")
 			}
-			currentHash = head
-		}
 
-		// 2. 遍历链表 (Traverse the Chain)
-		for currentHash != "" {
-			// A. 获取 Commit 对象
-			reader, err := TV.Store.Get(ctx, currentHash)
+			ctx := context.Background()
+
+			filter, err := buildLogFilter(logAuthor, logSince, logUntil, logGrep)
 			if err != nil {
-				return fmt.Errorf("failed to retrieve commit object %s: %w", currentHash, err)
+				return err
 			}
 
-			data, err := io.ReadAll(reader)
-			reader.Close() // 及时关闭
+			starts, excluded, err := resolveLogRange(ctx, deps, args, logAll)
 			if err != nil {
 				return err
 			}
-
-			// B. 反序列化
-			var commit core.Commit
-			if err := core.DecodeObject(data, &commit); err != nil {
-				return fmt.Errorf("object %s is corrupted or not a commit: %w", currentHash, err)
+			if len(starts) == 0 {
+				fmt.Println("No commits yet.")
+				return nil
 			}
 
-			// C. 打印信息 (仿 Git 格式)
-			printCommitLog(currentHash, &commit)
-
-			// D. 移动指针到父节点 (Move to Parent)
-			if len(commit.Parents) > 0 {
-				// MVP: 默认只跟随第一个父节点 (线性历史)
-				// 如果是 Merge Commit，这里忽略了其他分支，这符合 git log 的默认行为
-				currentHash = commit.Parents[0].Hash
-			} else {
-				// 到达初始提交 (Initial Commit)，没有父节点，结束循环
-				currentHash = ""
+			return walkLog(ctx, deps, starts, excluded, filter, logGraph, logShowSignature)
+		},
+	}
+
+	cmd.Flags().BoolVar(&logShowSignature, "show-signature", false, "show whether each commit is signed, and by whom")
+	cmd.Flags().BoolVar(&logAll, "all", false, "seed the traversal from every known ref instead of just HEAD")
+	cmd.Flags().BoolVar(&logGraph, "graph", false, "draw an ASCII graph of commit ancestry alongside the log")
+	cmd.Flags().StringVar(&logAuthor, "author", "", "only show commits whose author contains this string")
+	cmd.Flags().StringVar(&logSince, "since", "", "only show commits at or after this time (RFC3339 or YYYY-MM-DD)")
+	cmd.Flags().StringVar(&logUntil, "until", "", "only show commits at or before this time (RFC3339 or YYYY-MM-DD)")
+	cmd.Flags().StringVar(&logGrep, "grep", "", "only show commits whose message contains this string")
+	return cmd
+}
+
+// -----------------------------------------------------------------------------
+// 起点解析：单个 commit、A..B / A...B 区间，或者 --all 枚举所有引用
+// -----------------------------------------------------------------------------
+
+// resolveLogRange 决定这次遍历从哪些 hash 开始 (starts)，以及哪些祖先应该被排除在
+// 输出之外 (excluded，只在 A..B / A...B 区间语法下非空)
+func resolveLogRange(ctx context.Context, deps *Deps, args []string, logAll bool) (starts []types.Hash, excluded map[types.Hash]bool, err error) {
+	switch {
+	case len(args) == 1 && strings.Contains(args[0], "..."):
+		left, right, found := strings.Cut(args[0], "...")
+		if !found {
+			return nil, nil, fmt.Errorf("invalid revision range %q", args[0])
+		}
+		return resolveSymmetricRange(ctx, deps, left, right)
+
+	case len(args) == 1 && strings.Contains(args[0], ".."):
+		left, right, found := strings.Cut(args[0], "..")
+		if !found {
+			return nil, nil, fmt.Errorf("invalid revision range %q", args[0])
+		}
+		return resolveAsymmetricRange(ctx, deps, left, right)
+
+	case len(args) == 1:
+		h, err := resolveRevision(ctx, deps, args[0])
+		if err != nil {
+			return nil, nil, err
+		}
+		return []types.Hash{h}, nil, nil
+
+	case logAll:
+		entries, err := deps.App().Refs.ListRefs(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list refs: %w", err)
+		}
+		seen := make(map[types.Hash]bool, len(entries))
+		for _, e := range entries {
+			if seen[e.Hash] {
+				continue
 			}
+			seen[e.Hash] = true
+			starts = append(starts, e.Hash)
+		}
+		return starts, nil, nil
+
+	default:
+		head, _, err := deps.App().Refs.GetHead(ctx)
+		if errors.Is(err, refs.ErrNoHead) {
+			return nil, nil, nil
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read HEAD: %w", err)
+		}
+		return []types.Hash{head}, nil, nil
+	}
+}
+
+// resolveAsymmetricRange 实现 `tv log A..B`：展示 B 能到达、但 A 也能到达的祖先之外的提交
+// （"B 独有的历史"），用两次祖先集合遍历做差集，而不是逐个反查 "这个提交是不是 A 的祖先"
+func resolveAsymmetricRange(ctx context.Context, deps *Deps, left, right string) ([]types.Hash, map[types.Hash]bool, error) {
+	aHash, err := resolveRevision(ctx, deps, left)
+	if err != nil {
+		return nil, nil, err
+	}
+	bHash, err := resolveRevision(ctx, deps, right)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aSet, err := ancestorSet(ctx, deps, aHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []types.Hash{bHash}, aSet, nil
+}
+
+// resolveSymmetricRange 实现 `tv log A...B`：展示只能从 A 或者只能从 B 到达、但两边都
+// 能到达不算（对称差）的提交，典型用在"这条分支和那条分支分别多出了哪些提交"
+func resolveSymmetricRange(ctx context.Context, deps *Deps, left, right string) ([]types.Hash, map[types.Hash]bool, error) {
+	aHash, err := resolveRevision(ctx, deps, left)
+	if err != nil {
+		return nil, nil, err
+	}
+	bHash, err := resolveRevision(ctx, deps, right)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	aSet, err := ancestorSet(ctx, deps, aHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	bSet, err := ancestorSet(ctx, deps, bHash)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	excluded := make(map[types.Hash]bool, len(aSet))
+	for h := range aSet {
+		if bSet[h] {
+			excluded[h] = true // 两边都能到达：公共祖先，排除
+		}
+	}
+	return []types.Hash{aHash, bHash}, excluded, nil
+}
+
+// resolveRevision 把用户敲的一个区间端点解析成完整 hash，支持 "HEAD" 和 (可能缩写的) hash
+func resolveRevision(ctx context.Context, deps *Deps, rev string) (types.Hash, error) {
+	rev = strings.TrimSpace(rev)
+	if rev == "" || rev == "HEAD" {
+		head, _, err := deps.App().Refs.GetHead(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve HEAD: %w", err)
 		}
+		return head, nil
+	}
+	return expandHash(ctx, deps, types.HashPrefix(rev))
+}
+
+// ancestorSet 从 start 出发、沿着 CommitModel.Parents 做 BFS，返回 start 自身和它所有
+// 祖先的 hash 集合。读的是 meta 数据库里的索引投影，不需要去对象存储里反序列化每个
+// commit 的完整字节——区间计算只关心"谁是谁的祖先"，用不到 Message/Author 以外的内容
+func ancestorSet(ctx context.Context, deps *Deps, start types.Hash) (map[types.Hash]bool, error) {
+	seen := make(map[types.Hash]bool)
+	queue := []types.Hash{start}
 
+	for len(queue) > 0 {
+		h := queue[0]
+		queue = queue[1:]
+		if h == "" || seen[h] {
+			continue
+		}
+		seen[h] = true
+
+		cm, err := deps.App().Repository.GetCommit(ctx, h)
+		if errors.Is(err, meta.ErrCommitNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to load commit %s from index: %w", h, err)
+		}
+		queue = append(queue, parentHashes(cm)...)
+	}
+	return seen, nil
+}
+
+// -----------------------------------------------------------------------------
+// 过滤条件：全部作用在 CommitModel 的索引字段上，不读对象存储
+// -----------------------------------------------------------------------------
+
+type logFilter struct {
+	author string
+	since  int64 // 0 表示不限制
+	until  int64
+	grep   string
+}
+
+func buildLogFilter(logAuthor, logSince, logUntil, logGrep string) (logFilter, error) {
+	since, err := parseLogTime(logSince)
+	if err != nil {
+		return logFilter{}, fmt.Errorf("invalid --since: %w", err)
+	}
+	until, err := parseLogTime(logUntil)
+	if err != nil {
+		return logFilter{}, fmt.Errorf("invalid --until: %w", err)
+	}
+	return logFilter{author: logAuthor, since: since, until: until, grep: logGrep}, nil
+}
+
+// parseLogTime 接受 RFC3339 或者裸日期 (YYYY-MM-DD)，""表示不设限制
+func parseLogTime(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Unix(), nil
+		}
+	}
+	return 0, fmt.Errorf("unrecognized time %q (expected RFC3339 or YYYY-MM-DD)", s)
+}
+
+// matchesFilter 判断一个 CommitModel 是否应该展示；Author/Message 的匹配都不分大小写
+func matchesFilter(cm *meta.CommitModel, f logFilter) bool {
+	if f.author != "" && !strings.Contains(strings.ToLower(cm.Author), strings.ToLower(f.author)) {
+		return false
+	}
+	if f.since != 0 && cm.Timestamp < f.since {
+		return false
+	}
+	if f.until != 0 && cm.Timestamp > f.until {
+		return false
+	}
+	if f.grep != "" && !strings.Contains(strings.ToLower(cm.Message), strings.ToLower(f.grep)) {
+		return false
+	}
+	return true
+}
+
+// parentHashes 把 CommitModel.Parents (JSON 编码的 []string) 解出来，转换成 types.Hash
+func parentHashes(cm *meta.CommitModel) []types.Hash {
+	if len(cm.Parents) == 0 {
+		return nil
+	}
+	var raw []string
+	if err := json.Unmarshal(cm.Parents, &raw); err != nil {
+		return nil
+	}
+	hashes := make([]types.Hash, len(raw))
+	for i, p := range raw {
+		hashes[i] = types.Hash(p)
+	}
+	return hashes
+}
+
+func hashStrings(hs []types.Hash) []string {
+	out := make([]string, len(hs))
+	for i, h := range hs {
+		out[i] = string(h)
+	}
+	return out
+}
+
+// -----------------------------------------------------------------------------
+// 遍历：按 Timestamp 降序的优先队列，保证跨多个父节点/多个起点时输出仍然按时间顺序
+// -----------------------------------------------------------------------------
+
+// logEntry 是优先队列里的一个元素：hash 加上它在 meta 数据库里的索引投影
+type logEntry struct {
+	hash  types.Hash
+	model *meta.CommitModel
+}
+
+// logHeap 是按 Timestamp 降序排列的 container/heap 实现 (最新的提交先出队)
+type logHeap []logEntry
+
+func (h logHeap) Len() int            { return len(h) }
+func (h logHeap) Less(i, j int) bool  { return h[i].model.Timestamp > h[j].model.Timestamp }
+func (h logHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *logHeap) Push(x interface{}) { *h = append(*h, x.(logEntry)) }
+func (h *logHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// walkLog 做实际的 DAG 遍历：从 starts 出发，沿着每一个父节点（不只是 Parents[0]）
+// 往回走，用 visited 去重避免菱形历史里同一个祖先被访问两次
+func walkLog(ctx context.Context, deps *Deps, starts []types.Hash, excluded map[types.Hash]bool, filter logFilter, logGraph, logShowSignature bool) error {
+	var renderer *graphlog.Renderer
+	if logGraph {
+		renderer = graphlog.NewRenderer()
+	}
+
+	visited := make(map[types.Hash]bool)
+	pending := &logHeap{}
+	heap.Init(pending)
+
+	push := func(hash types.Hash) error {
+		if hash == "" || visited[hash] {
+			return nil
+		}
+		visited[hash] = true
+
+		cm, err := deps.App().Repository.GetCommit(ctx, hash)
+		if errors.Is(err, meta.ErrCommitNotFound) {
+			// 索引里找不到（比如老仓库迁移过来、还没来得及回填），跳过而不是让整个
+			// log 失败——这条提交本来就没法展示任何索引字段
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to load commit %s from index: %w", hash, err)
+		}
+		heap.Push(pending, logEntry{hash: hash, model: cm})
 		return nil
-	},
+	}
+
+	for _, s := range starts {
+		if err := push(s); err != nil {
+			return err
+		}
+	}
+
+	for pending.Len() > 0 {
+		entry := heap.Pop(pending).(logEntry)
+		if excluded[entry.hash] {
+			// A..B / A...B 的边界之外：它的祖先也必然在边界之外（祖先集合本身是
+			// 向下封闭的），既不展示，也不用再往上追溯
+			continue
+		}
+
+		parents := parentHashes(entry.model)
+		for _, p := range parents {
+			if err := push(p); err != nil {
+				return err
+			}
+		}
+
+		var graphPrefix, mergeLine string
+		if renderer != nil {
+			// 无论这条提交是否通过了 --author/--grep 之类的过滤，都要推进 lane
+			// 状态，否则被过滤掉的提交会让图形断开，看起来像一条不存在的分支
+			graphPrefix, mergeLine = renderer.Next(graphlog.Node{
+				Hash:      string(entry.hash),
+				Parents:   hashStrings(parents),
+				Timestamp: entry.model.Timestamp,
+			})
+		}
+
+		if !matchesFilter(entry.model, filter) {
+			continue
+		}
+		printLogEntry(ctx, deps, entry.hash, entry.model, graphPrefix, mergeLine, logShowSignature)
+	}
+	return nil
 }
 
-// printCommitLog 格式化输出
-func printCommitLog(hash types.Hash, c *core.Commit) {
-	// 颜色代码 (ANSI Escape Codes) - 可选，为了好看
+// -----------------------------------------------------------------------------
+// 输出
+// -----------------------------------------------------------------------------
+
+// printLogEntry 格式化输出一条提交。除了 --show-signature（需要完整的 Signature 字节
+// 和签名校验逻辑，只存在于对象存储里的 core.Commit 上）之外，全部字段都来自 CommitModel
+func printLogEntry(ctx context.Context, deps *Deps, hash types.Hash, cm *meta.CommitModel, graphPrefix, mergeLine string, logShowSignature bool) {
 	const (
 		colorYellow = "\033[33m"
 		colorReset  = "\033[0m"
 	)
+	indent := strings.Repeat(" ", len(graphPrefix))
+
+	fmt.Printf("%s%scommit %s%s\n", graphPrefix, colorYellow, hash, colorReset)
+	if mergeLine != "" {
+		fmt.Println(mergeLine)
+	}
+	fmt.Printf("%sAuthor: %s\n", indent, cm.Author)
+	fmt.Printf("%sDate:   %s\n", indent, time.Unix(cm.Timestamp, 0).Format(time.RFC1123))
+	if logShowSignature {
+		fmt.Printf("%s%s\n", indent, describeSignatureByHash(ctx, deps, hash))
+	}
+	fmt.Printf("%s\n%s    %s\n%s\n", indent, indent, cm.Message, indent)
+}
 
-	fmt.Printf("%scommit %s%s\n", colorYellow, hash, colorReset)
-	fmt.Printf("Author: %s\n", c.Author)
-	fmt.Printf("Date:   %s\n", time.Unix(c.Timestamp, 0).Format(time.RFC1123))
-	fmt.Printf("\n    %s\n\n", c.Message)
+// describeSignatureByHash 加载完整的 commit 对象字节来渲染一行 --show-signature 输出。
+// 这是遍历路径上唯一会去读对象存储的地方：签名校验需要重建完整的 core.Commit 签名载荷，
+// CommitModel 那份投影（只有 SignerFingerprint/Signature 原始字节）不够用
+func describeSignatureByHash(ctx context.Context, deps *Deps, hash types.Hash) string {
+	c, err := loadCommit(ctx, deps, hash)
+	if err != nil {
+		return fmt.Sprintf("Signature: (failed to load commit: %v)", err)
+	}
+	return describeSignature(deps, c)
 }
 
-func init() {
-	rootCmd.AddCommand(logCmd)
+// describeSignature 渲染一行 `--show-signature` 的输出。能不能真正校验取决于本地
+// identities.json 里认不认识这个指纹——没有签名、或者签名者没在本地导入过，都只是
+// 把已知信息如实打印出来，不是错误
+func describeSignature(deps *Deps, c *core.Commit) string {
+	if c.Signature == nil {
+		return "Signature: none"
+	}
+	sig := c.Signature
+	id, found, err := deps.App().Identities.Find(sig.Fingerprint)
+	if err != nil {
+		return fmt.Sprintf("Signature: %s %s (failed to load signer: %v)", sig.Algorithm, sig.Fingerprint, err)
+	}
+	if !found {
+		return fmt.Sprintf("Signature: %s %s (unknown signer)", sig.Algorithm, sig.Fingerprint)
+	}
+	if err := c.VerifySignature(id.Key); err != nil {
+		return fmt.Sprintf("Signature: %s %s (BAD signature from %s <%s>: %v)", sig.Algorithm, sig.Fingerprint, id.Name, id.Email, err)
+	}
+	return fmt.Sprintf("Signature: %s %s (good signature from %s <%s>)", sig.Algorithm, sig.Fingerprint, id.Name, id.Email)
 }
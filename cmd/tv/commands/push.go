@@ -4,72 +4,263 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sync"
+	"time"
 
 	tvrpc "tensorvault/pkg/api/tvrpc/v1"
 	"tensorvault/pkg/client"
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/ignore"
+	"tensorvault/pkg/index"
+	"tensorvault/pkg/packfile"
+	"tensorvault/pkg/remote"
+	"tensorvault/pkg/types"
 
 	"github.com/spf13/cobra"
 )
 
-var pushCmd = &cobra.Command{
-	Use:   "push [file]",
-	Short: "Upload staged files (from Index) or a specific file to Server",
-	Long:  `If a file argument is provided, uploads that specific file. If no argument is provided, iterates through the current Staging Area (Index) and uploads all tracked files.`,
-	Args:  cobra.MaximumNArgs(1), // 0 或 1 个参数
-	RunE: func(cmd *cobra.Command, args []string) error {
-		// 1. 获取连接 (Lazy)
-		cli, err := GetRemoteClient()
-		if err != nil {
-			return err
+func newPushCmd(deps *Deps) *cobra.Command {
+	var (
+		// pushParallel 由 --parallel 设置；0 表示"未显式指定"，落到 transfer.max_parallel
+		// 配置 (默认 4，跟 Cloudreve 的上传并发设置取了同一个默认值)
+		pushParallel int
+
+		// pushRemoteName 由 --remote 设置；非空时 push 走 DAG/ref 同步（pkg/remote），而不是
+		// 默认的暂存区 blob 上传（gRPC Data.Upload）——两件事服务的是不同的工作流，没有共用的
+		// 必要，所以挂在同一个 pushCmd 上用 flag 区分，而不是再开一个名字冲突的子命令
+		pushRemoteName string
+	)
+
+	cmd := &cobra.Command{
+		Use:   "push [file]",
+		Short: "Upload staged files (from Index) or a specific file to Server",
+		Long: `If a file argument is provided, uploads that specific file. If no argument is provided, iterates through the current Staging Area (Index) and uploads all tracked files.
+
+With --remote <name>, instead pushes the current branch's commit DAG (objects + ref) to a
+named remote registered via 'tv remote add' — see 'tv fetch' for the other half.`,
+		Args: cobra.MaximumNArgs(1), // 0 或 1 个参数
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pushRemoteName != "" {
+				if deps.App() == nil {
+					return fmt.Errorf("app not initialized")
+				}
+				return pushToRemote(cmd.Context(), deps, pushRemoteName)
+			}
+
+			// 1. 获取连接 (Lazy)
+			cli, err := deps.GetRemoteClient()
+			if err != nil {
+				return err
+			}
+
+			// 2. 分支逻辑
+			if len(args) > 0 {
+				// 模式 A: 指定文件上传 (用于调试或临时上传)
+				return pushSingleFile(cmd.Context(), deps, cli, args[0])
+			}
+
+			// 模式 B: 批量上传暂存区 (标准工作流)
+			return pushStagedFiles(cmd.Context(), deps, cli, pushParallel)
+		},
+	}
+
+	cmd.Flags().IntVar(&pushParallel, "parallel", 0, "number of concurrent upload workers (default: transfer.max_parallel config, 4)")
+	cmd.Flags().StringVar(&pushRemoteName, "remote", "", "push the current branch's commit DAG to a named remote instead of uploading the staging area")
+	return cmd
+}
+
+// pushToRemote 把当前分支的 commit DAG 推到一个具名远端：先用 packfile.ComputeMissing
+// 算出远端没有的对象（haves 取远端已知的同名 ref，没有就是全量推），把它们流式发过去，
+// 最后拿本地 HEAD 对远端 ref 做一次 CAS 更新——跟 commit 用 HEAD.Version 做 CAS 是同一个
+// "先看一眼版本号，冲突了就让用户自己 fetch 再来"的思路，只是作用的对象从本地 HEAD
+// 换成了远端 ref
+func pushToRemote(ctx context.Context, deps *Deps, remoteName string) error {
+	entry, ok := deps.App().Remotes.Get(remoteName)
+	if !ok {
+		return fmt.Errorf("unknown remote '%s' (run 'tv remote add %s <url>' first)", remoteName, remoteName)
+	}
+
+	branch, attached, err := deps.App().Refs.CurrentBranch(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to resolve current branch: %w", err)
+	}
+	if !attached {
+		return fmt.Errorf("cannot push: HEAD is detached (checkout a branch first)")
+	}
+	refName := "refs/heads/" + branch
+
+	localHash, _, err := deps.App().Refs.GetRef(ctx, refName)
+	if err != nil {
+		return fmt.Errorf("failed to resolve branch '%s': %w", branch, err)
+	}
+	if localHash == "" {
+		return fmt.Errorf("nothing to push: branch '%s' has no commits yet", branch)
+	}
+
+	rc := remote.NewClient(entry.URL)
+
+	remoteRefs, err := rc.LsRemote(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to contact remote '%s': %w", remoteName, err)
+	}
+
+	var haves []types.Hash
+	var oldVersion int64
+	if existing, ok := remoteRefs[refName]; ok {
+		haves = append(haves, existing.Hash)
+		oldVersion = existing.Version
+	}
+
+	missing, err := packfile.ComputeMissing(ctx, deps.App().Store, localHash, haves)
+	if err != nil {
+		return fmt.Errorf("failed to compute objects to push: %w", err)
+	}
+
+	fmt.Printf("Pushing %d object(s) to '%s' (%s)...\n", len(missing), remoteName, entry.URL)
+
+	objects := make(chan core.Object)
+	readErrCh := make(chan error, 1)
+	go func() {
+		defer close(objects)
+		for _, h := range missing {
+			obj, err := remote.ReadObject(ctx, deps.App().Store, h)
+			if err != nil {
+				readErrCh <- err
+				return
+			}
+			select {
+			case objects <- obj:
+			case <-ctx.Done():
+				return
+			}
 		}
+	}()
+
+	update := remote.RefUpdate{Name: refName, NewHash: localHash, OldVersion: oldVersion}
+	pushErr := rc.Push(ctx, []remote.RefUpdate{update}, objects)
 
-		// 2. 分支逻辑
-		if len(args) > 0 {
-			// 模式 A: 指定文件上传 (用于调试或临时上传)
-			return pushSingleFile(cmd.Context(), cli, args[0])
+	select {
+	case err := <-readErrCh:
+		return fmt.Errorf("failed to read object for push: %w", err)
+	default:
+	}
+	if pushErr != nil {
+		if errors.Is(pushErr, remote.ErrConcurrentUpdate) {
+			return fmt.Errorf("remote '%s' has commits on '%s' that you don't have; run 'tv fetch %s' first", remoteName, branch, remoteName)
 		}
+		return fmt.Errorf("push to '%s' failed: %w", remoteName, pushErr)
+	}
+
+	fmt.Printf("%s -> %s (%s)\n", branch, remoteName, localHash)
+	return nil
+}
 
-		// 模式 B: 批量上传暂存区 (标准工作流)
-		return pushStagedFiles(cmd.Context(), cli)
-	},
+// resolveParallelism 决定本次 push 用几个并发 worker：--parallel 显式传了就用它，否则
+// 落到 transfer.max_parallel 配置（默认 4）
+func resolveParallelism(deps *Deps, pushParallel int) int {
+	n := pushParallel
+	if n <= 0 {
+		n = deps.Viper.GetInt("transfer.max_parallel")
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
 }
 
-// pushStagedFiles 遍历 Index 并上传
-func pushStagedFiles(ctx context.Context, cli *client.TVClient) error {
-	if TV.Index.IsEmpty() {
+// pushStagedFiles 遍历 Index，用 resolveParallelism 个 worker 并发上传。高延迟链路下，
+// 几千个小文件挨个串行走一次往返会把延迟攒成天文数字——worker 之间共享同一个 *client.TVClient
+// (并发开多个 Upload 流在同一条 gRPC 连接上是安全的)，只是在并发数大于 1 时用
+// WithConcurrency 换一条流控窗口放大过的连接，避免 HTTP/2 流控本身成为瓶颈。结果通过一个
+// channel 汇聚，保证不管完成顺序如何，最终的成功/失败计数和退出状态都是确定的
+func pushStagedFiles(ctx context.Context, deps *Deps, cli *client.TVClient, pushParallel int) error {
+	if deps.App().Index.IsEmpty() {
 		fmt.Println("Nothing to push (index is empty). Run 'tv add <file>' first.")
 		return nil
 	}
 
-	snapshot := TV.Index.Snapshot()
-	fmt.Printf("📦 Pushing %d files from Staging Area...\n", len(snapshot))
-
-	success := 0
-	failures := 0
+	// .tvignore 可能是在文件已经暂存之后才加的规则（或者是 --force add 进来的），所以这里
+	// 还要再过滤一遍，而不是假设 Index 里的东西都该传上去
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	matcher, err := ignore.NewMatcher(wd)
+	if err != nil {
+		return fmt.Errorf("failed to load ignore rules: %w", err)
+	}
 
+	snapshot := deps.App().Index.Snapshot()
+	paths := make([]string, 0, len(snapshot))
+	skipped := 0
 	for relPath := range snapshot {
-		// 这里的 path 是相对路径，我们需要把它转为绝对路径或保持相对
-		// 为了简单，假设运行命令的目录就是仓库根目录
-		// 更好的做法是结合 TV.RepoPath 计算绝对路径
+		if matcher.Ignored(relPath, false) {
+			skipped++
+			continue
+		}
+		paths = append(paths, relPath)
+	}
+	if skipped > 0 {
+		fmt.Printf("Skipping %d staged file(s) matched by .tvignore\n", skipped)
+	}
 
-		fmt.Printf("Processing %s... ", relPath)
+	n := resolveParallelism(deps, pushParallel)
+	fmt.Printf("📦 Pushing %d files from Staging Area (%d worker(s))...\n", len(paths), n)
 
-		// 检查文件是否存在于磁盘 (Index 里有但磁盘删了的情况)
-		if _, err := os.Stat(relPath); os.IsNotExist(err) {
-			fmt.Printf("⚠️  Skipped (Missing on disk)\n")
-			failures++
-			continue
+	uploadCli := cli
+	if n > 1 {
+		boosted, err := cli.WithConcurrency(n)
+		if err != nil {
+			return fmt.Errorf("failed to open concurrent connection: %w", err)
+		}
+		uploadCli = boosted
+		defer boosted.Close()
+	}
+
+	type outcome struct {
+		relPath string
+		err     error
+	}
+
+	jobs := make(chan string)
+	outcomes := make(chan outcome)
+
+	var workers sync.WaitGroup
+	for range n {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			for relPath := range jobs {
+				outcomes <- outcome{relPath: relPath, err: pushOneStagedFile(ctx, deps, uploadCli, relPath)}
+			}
+		}()
+	}
+
+	go func() {
+		for _, p := range paths {
+			jobs <- p
 		}
+		close(jobs)
+	}()
 
-		// 复用单文件上传逻辑
-		if err := pushSingleFile(ctx, cli, relPath); err != nil {
-			fmt.Printf("❌ Failed: %v\n", err)
+	go func() {
+		workers.Wait()
+		close(outcomes)
+	}()
+
+	success := 0
+	failures := 0
+	for o := range outcomes {
+		if o.err != nil {
+			fmt.Printf("%s: ❌ Failed: %v\n", o.relPath, o.err)
 			failures++
 		} else {
+			fmt.Printf("%s: ✅ done\n", o.relPath)
 			success++
 		}
 	}
@@ -81,31 +272,38 @@ func pushStagedFiles(ctx context.Context, cli *client.TVClient) error {
 	return nil
 }
 
-// pushSingleFile 封装之前的逻辑
-func pushSingleFile(ctx context.Context, cli *client.TVClient, filePath string) error {
-	f, err := os.Open(filePath)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
+// pushOneStagedFile 检查磁盘上的文件还在不在，然后复用单文件上传逻辑；被多个 worker
+// 并发调用
+func pushOneStagedFile(ctx context.Context, deps *Deps, cli *client.TVClient, relPath string) error {
+	// 这里的 path 是相对路径，我们需要把它转为绝对路径或保持相对
+	// 为了简单，假设运行命令的目录就是仓库根目录
+	// 更好的做法是结合 TV.RepoPath 计算绝对路径
 
-	stat, err := f.Stat()
-	if err != nil {
-		return err
+	// 检查文件是否存在于磁盘 (Index 里有但磁盘删了的情况)
+	if _, err := os.Stat(relPath); os.IsNotExist(err) {
+		return fmt.Errorf("missing on disk")
 	}
 
-	// 1. 计算 Linear Hash
-	// 注意：这里有点性能损耗，对于大文件每次都要算一遍。
-	// 未来优化：如果 Index 里存了 LinearHash，可以直接拿来用。目前先现算。
-	//TODO: 未来可以考虑把 LinearHash 存到 Index 里，避免重复计算
-	hasher := sha256.New()
-	if _, err := io.Copy(hasher, f); err != nil {
+	return pushSingleFile(ctx, deps, cli, relPath)
+}
+
+// pushSingleFile 封装之前的逻辑
+func pushSingleFile(ctx context.Context, deps *Deps, cli *client.TVClient, filePath string) error {
+	stat, err := os.Stat(filePath)
+	if err != nil {
 		return err
 	}
-	linearHash := hex.EncodeToString(hasher.Sum(nil))
 
-	if _, err := f.Seek(0, 0); err != nil {
-		return err
+	// 1. 解析 Linear Hash：`tv add` 摄取这个文件时已经顺手算过一遍了，只要 stat 还对得上
+	// 就直接复用，整个过程连文件都不用打开——只有缓存不新鲜时才会真正付出一次 io.Copy 的代价
+	relPath := index.CleanPath(filePath)
+	linearHash, cached := freshLinearHash(deps, relPath, stat)
+	if !cached {
+		linearHash, err = hashFile(filePath)
+		if err != nil {
+			return err
+		}
+		deps.App().Index.UpdateLinearHash(relPath, types.LinearHash(linearHash), stat)
 	}
 
 	// 2. CheckFile
@@ -122,50 +320,131 @@ func pushSingleFile(ctx context.Context, cli *client.TVClient, filePath string)
 		return nil
 	}
 
-	// 3. Upload
-	stream, err := cli.Data.Upload(ctx)
+	f, err := os.Open(filePath)
 	if err != nil {
 		return err
 	}
+	defer f.Close()
+
+	// 3. 分块上传。UploadID 直接复用 LinearHash——同一份内容重新 push 时天然能找到同一条
+	// 续传状态，不需要额外分配/携带一个 ID
+	group, err := client.LoadOrNewChunkGroup(deps.App().RepoPath, linearHash, stat.Size(), client.DefaultChunkSize)
+	if err != nil {
+		return fmt.Errorf("failed to load upload state: %w", err)
+	}
+
+	backoff := &client.ExponentialBackoff{Base: 500 * time.Millisecond, Cap: 10 * time.Second, Max: 6}
+
+	resp, err := uploadChunked(ctx, cli, f, filepath.Base(filePath), linearHash, group, backoff)
+	if err != nil {
+		return fmt.Errorf("upload failed (re-run 'tv push %s' to retry): %w", filePath, err)
+	}
+
+	if err := group.Discard(); err != nil {
+		fmt.Printf("⚠️  Failed to clean up upload state %s: %v\n", group.UploadID, err)
+	}
+
+	fmt.Printf("✅ Uploaded (Hash: %s...)\n", resp.Hash[:8])
+	return nil
+}
+
+// freshLinearHash 在不打开文件的前提下，看 Index 里有没有一条跟当前 stat 对得上的缓存
+// LinearHash。ok 为 false 时，调用方必须退回 hashFile 现算
+func freshLinearHash(deps *Deps, relPath string, stat os.FileInfo) (hash string, ok bool) {
+	entry, found := deps.App().Index.Get(relPath)
+	if !found || !entry.Fresh(stat) {
+		return "", false
+	}
+	return entry.LinearHash.String(), true
+}
+
+// hashFile 打开文件，完整读一遍内容算出 Linear Hash (SHA-256)——只有 freshLinearHash 没命中
+// 缓存时才会走到这里
+func hashFile(filePath string) (string, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
+
+// uploadChunked 把文件按 group 规划的 ChunkSize 切成定长块，通过一个 Upload 流依次发送：
+// 第一帧握手 FileMeta，之后每帧一个 chunk 的 ChunkData。backoff 控制的重试粒度是"整个
+// Upload 流"，而不是单个 chunk——这是现有 Upload RPC 形状逼出来的结果：它是 client-streaming，
+// 只在最后一次 CloseAndRecv 时给一次性响应，服务端的 ingester 对着完整字节流连续计算 CDC
+// 切分点和全量 sha256，中途没有任何可以恢复的部分提交状态。一旦某帧 Send 失败，这个流本身
+// 就已经不可用，新开的流只能从第一个字节重新发送。细节和取舍记在 ChunkGroup 的文档里
+func uploadChunked(ctx context.Context, cli *client.TVClient, f *os.File, fileName, linearHash string, group *client.ChunkGroup, backoff client.Backoff) (*tvrpc.UploadResponse, error) {
+	for {
+		resp, err := attemptUpload(ctx, cli, f, fileName, linearHash, group)
+		if err == nil {
+			backoff.Reset()
+			return resp, nil
+		}
+
+		fmt.Printf("⚠️  Upload attempt failed: %v\n", err)
+		if !backoff.Next() {
+			return nil, fmt.Errorf("exhausted retries: %w", err)
+		}
+
+		// 新一轮尝试要开一个新的 Upload 流：上一个流已经死了，服务端没有真正确认过任何
+		// 一个 chunk，位图必须清零、从头重发
+		group.Reset()
+	}
+}
+
+// attemptUpload 在一个全新的 Upload 流里，从第一个 chunk 开始依次发送整份文件
+func attemptUpload(ctx context.Context, cli *client.TVClient, f *os.File, fileName, linearHash string, group *client.ChunkGroup) (*tvrpc.UploadResponse, error) {
+	stream, err := cli.Data.Upload(ctx)
+	if err != nil {
+		return nil, err
+	}
 
 	err = stream.Send(&tvrpc.UploadRequest{
 		Payload: &tvrpc.UploadRequest_Meta{
 			Meta: &tvrpc.FileMeta{
-				Path:   filepath.Base(filePath),
+				Path:   fileName,
 				Sha256: linearHash,
 			},
 		},
 	})
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	buf := make([]byte, 64*1024)
-	for {
-		n, err := f.Read(buf)
-		if n > 0 {
-			if err := stream.Send(&tvrpc.UploadRequest{
-				Payload: &tvrpc.UploadRequest_ChunkData{ChunkData: buf[:n]},
-			}); err != nil {
-				return err
-			}
+	buf := make([]byte, group.ChunkSize)
+	for i := 0; i < group.NumChunks; i++ {
+		offset := int64(i) * group.ChunkSize
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to seek to chunk %d: %w", i, err)
 		}
-		if err == io.EOF {
-			break
+
+		n, err := io.ReadFull(f, buf)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return nil, fmt.Errorf("failed to read chunk %d: %w", i, err)
 		}
-		if err != nil {
-			return err
+
+		// 必须拷贝一份：buf 在下一次循环会被复用，而 Send 之后 gRPC 可能还没来得及
+		// 把这一帧序列化完
+		chunkData := make([]byte, n)
+		copy(chunkData, buf[:n])
+
+		if err := stream.Send(&tvrpc.UploadRequest{
+			Payload: &tvrpc.UploadRequest_ChunkData{ChunkData: chunkData},
+		}); err != nil {
+			return nil, fmt.Errorf("failed to send chunk %d: %w", i, err)
 		}
-	}
 
-	resp, err := stream.CloseAndRecv()
-	if err != nil {
-		return err
+		if err := group.MarkAcked(i); err != nil {
+			return nil, fmt.Errorf("failed to checkpoint upload state: %w", err)
+		}
 	}
 
-	fmt.Printf("✅ Uploaded (Hash: %s...)\n", resp.Hash[:8])
-	return nil
-}
-func init() {
-	rootCmd.AddCommand(pushCmd)
+	return stream.CloseAndRecv()
 }
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+func newRemoteCmd(deps *Deps) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remote [add] [name] [url]",
+		Short: "List or add named remotes",
+		Long: `With no arguments, list the remotes known to this repository (see .tv/remotes.json).
+With 'add', a name and a URL, register a new remote (or update an existing one's URL) for use with
+'tv fetch <name>' and 'tv push --remote <name>'. The shorter 'tv remote <name> <url>' does the same.`,
+		Args: cobra.MaximumNArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+
+			if len(args) == 0 {
+				return listRemotes(deps)
+			}
+			if len(args) == 3 && args[0] == "add" {
+				args = args[1:]
+			}
+			if len(args) != 2 {
+				return fmt.Errorf("usage: tv remote add <name> <url>")
+			}
+			return addRemote(deps, args[0], args[1])
+		},
+	}
+	return cmd
+}
+
+func listRemotes(deps *Deps) error {
+	entries := deps.App().Remotes.List()
+	if len(entries) == 0 {
+		fmt.Println("no remotes configured (run 'tv remote add <name> <url>' to add one)")
+		return nil
+	}
+
+	for _, e := range entries {
+		fmt.Printf("%s\t%s\n", e.Name, e.URL)
+	}
+	return nil
+}
+
+func addRemote(deps *Deps, name, url string) error {
+	if err := deps.App().Remotes.Add(name, url); err != nil {
+		return fmt.Errorf("failed to add remote '%s': %w", name, err)
+	}
+	if err := deps.App().Remotes.Save(); err != nil {
+		return fmt.Errorf("failed to save remote registry: %w", err)
+	}
+	fmt.Printf("added remote '%s' -> %s\n", name, url)
+	return nil
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"tensorvault/pkg/types"
+	"tensorvault/pkg/worktree"
+
+	"github.com/spf13/cobra"
+)
+
+func newResetCmd(deps *Deps) *cobra.Command {
+	var (
+		resetSoft bool
+		resetHard bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "reset [commit-hash]",
+		Short: "Move HEAD (and optionally the index and worktree) to the specified commit",
+		Long: `Reset the current branch to a commit. Defaults to --mixed: HEAD and the index move,
+the working tree is left untouched. --soft moves HEAD only. --hard additionally overwrites
+the working tree to match the target commit, driving the same diff-apply loop as 'tv checkout'
+so only changed paths are touched. Omit the commit hash to reset to the current HEAD.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+			if resetSoft && resetHard {
+				return fmt.Errorf("--soft and --hard are mutually exclusive")
+			}
+
+			ctx := context.Background()
+			start := time.Now()
+
+			var targetHash types.Hash
+			if len(args) == 0 {
+				hash, _, err := deps.App().Refs.GetHead(ctx)
+				if err != nil {
+					return fmt.Errorf("failed to resolve HEAD: %w", err)
+				}
+				targetHash = hash
+			} else {
+				expanded, err := expandHash(ctx, deps, types.HashPrefix(args[0]))
+				if err != nil {
+					return fmt.Errorf("invalid commit '%s': %w", args[0], err)
+				}
+				targetHash = expanded
+			}
+
+			mode := worktree.MixedReset
+			switch {
+			case resetSoft:
+				mode = worktree.SoftReset
+			case resetHard:
+				mode = worktree.HardReset
+			}
+
+			wt := worktree.NewWorktree(deps.App().Store, deps.App().Index, deps.App().Refs, ".", deps.App().Hasher)
+			if err := wt.Reset(ctx, mode, targetHash); err != nil {
+				return fmt.Errorf("reset failed: %w", err)
+			}
+
+			fmt.Printf("✅ Reset (%s) to %s in %s\n", mode, targetHash[:8], time.Since(start))
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&resetSoft, "soft", false, "move HEAD only, leave the index and worktree untouched")
+	cmd.Flags().BoolVar(&resetHard, "hard", false, "move HEAD, rebuild the index, and overwrite the worktree to match")
+	return cmd
+}
@@ -1,107 +1,230 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"tensorvault/pkg/app"
 	"tensorvault/pkg/client"
+	"tensorvault/pkg/client/discovery"
 	"tensorvault/pkg/config"
+	"tensorvault/pkg/tverr"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
 
-var (
-	cfgFile     string
-	TV          *app.App
-	remoteStore *client.TVClient //全局单例,在PersistentPostRunE里被关闭
-)
+// NewRootCmd builds the `tv` command tree against the given Deps. Every subcommand is wired
+// up explicitly here via newFooCmd(d) rather than self-registering through package-level
+// init()/rootCmd globals, so two independently-built Deps (e.g. one per parallel go test) get
+// two fully independent *cobra.Command trees that never share App/remote connection state.
+func NewRootCmd(deps Deps) *cobra.Command {
+	d := &deps
+	if d.Viper == nil {
+		d.Viper = viper.GetViper()
+	}
+	if d.Stdout == nil {
+		d.Stdout = os.Stdout
+	}
+	if d.Stderr == nil {
+		d.Stderr = os.Stderr
+	}
+	if d.AppFactory == nil {
+		d.AppFactory = app.NewApp
+	}
+	if d.RemoteClientFactory == nil {
+		d.RemoteClientFactory = defaultRemoteClientFactory
+	}
+	if d.RemoteTransportFactory == nil {
+		d.RemoteTransportFactory = defaultRemoteTransportFactory
+	}
 
-var rootCmd = &cobra.Command{
-	Use:   "tv",
-	Short: "TensorVault: AI Data Version Control",
-	// 【关键】PersistentPreRunE 会在所有子命令执行前运行
-	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-		// 跳过 init 命令的依赖检查 (因为它就是去创建环境的)
-		if cmd.Name() == "init" {
+	root := &cobra.Command{
+		Use:   "tv",
+		Short: "TensorVault: AI Data Version Control",
+		// SilenceErrors/SilenceUsage: Execute below is now the single place that prints a
+		// failed command's error (plus a remediation hint for tverr-tagged ones) — without
+		// these, cobra's own Execute would print "Error: ..." and a usage dump first, and the
+		// user would see the error twice
+		SilenceErrors: true,
+		SilenceUsage:  true,
+		// PersistentPreRunE runs before every subcommand. It loads config first (same timing
+		// cobra.OnInitialize used to give it relative to flag parsing), then builds the App —
+		// except for "init", which skips App construction since it's the command that creates
+		// the environment the App needs to exist
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if err := config.Load(d.CfgFile); err != nil {
+				return tverr.Wrap(tverr.ErrConfigInvalid, fmt.Errorf("config error: %w", err))
+			}
+			if cmd.Name() == "init" {
+				return nil
+			}
+			a, err := d.AppFactory()
+			if err != nil {
+				return tverr.Wrap(tverr.ErrNotInitialized, fmt.Errorf("failed to initialize tensorvault: %w", err))
+			}
+			d.app = a
 			return nil
-		}
+		},
+		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
+			return d.closeAll()
+		},
+	}
+
+	root.PersistentFlags().StringVar(&d.CfgFile, "config", "", "config file (default is $HOME/.tv/config.yaml)")
 
-		// 统一初始化 App
-		var err error
-		TV, err = app.NewApp()
-		if err != nil {
-			// 友好的错误提示
-			return fmt.Errorf("failed to initialize tensorvault: %w\n(Did you run 'tv init'?)", err)
+	// storage.path/remote.server: user can set them in yaml or override with these flags
+	root.PersistentFlags().String("storage-path", "", "Directory to store objects")
+	root.PersistentFlags().String("server", "", "TensorVault Server Address (e.g. localhost:8080)")
+
+	// RemoteTransport (grpc/http/s3) parameters, consulted by Deps.GetRemoteTransport to pick
+	// an implementation based on remote.scheme — same BindPFlag pattern as --server/
+	// --storage-path, overridable from either the config file or the command line
+	root.PersistentFlags().String("remote-scheme", "", "Remote transport scheme: grpc (default), http, https or s3")
+	root.PersistentFlags().String("remote-tls-ca", "", "CA cert file used to verify the remote (grpc/https)")
+	root.PersistentFlags().String("remote-tls-cert", "", "Client cert file for mutual TLS (grpc/https)")
+	root.PersistentFlags().String("remote-tls-key", "", "Client key file for mutual TLS (grpc/https)")
+	root.PersistentFlags().Bool("remote-insecure", false, "Force a plaintext connection even if TLS material is configured")
+	root.PersistentFlags().String("remote-s3-bucket", "", "Bucket to use when remote-scheme=s3")
+	root.PersistentFlags().String("remote-s3-region", "", "Region to use when remote-scheme=s3")
+
+	for flagName, key := range map[string]string{
+		"storage-path":     "storage.path",
+		"server":           "remote.server",
+		"remote-scheme":    "remote.scheme",
+		"remote-tls-ca":    "remote.tls.ca",
+		"remote-tls-cert":  "remote.tls.cert",
+		"remote-tls-key":   "remote.tls.key",
+		"remote-insecure":  "remote.insecure",
+		"remote-s3-bucket": "remote.s3.bucket",
+		"remote-s3-region": "remote.s3.region",
+	} {
+		if err := d.Viper.BindPFlag(key, root.PersistentFlags().Lookup(flagName)); err != nil {
+			fmt.Fprintln(d.Stderr, "Failed to bind flag:", err)
+			os.Exit(1)
 		}
-		return nil
-	},
-}
+	}
+	d.Viper.SetDefault("remote.server", "localhost:8080")
 
-// Execute 是入口
-func Execute() error {
-	return rootCmd.Execute()
-}
+	root.AddCommand(
+		newInitCmd(d),
+		newAddCmd(d),
+		newBlameCmd(d),
+		newBranchCmd(d),
+		newCatCmd(d),
+		newCheckIgnoreCmd(d),
+		newCheckoutCmd(d),
+		newCommitCmd(d),
+		newFetchCmd(d),
+		newGCCmd(d),
+		newIdentityCmd(d),
+		newJobsCmd(d),
+		newLogCmd(d),
+		newPushCmd(d),
+		newRemoteCmd(d),
+		newResetCmd(d),
+		newRmCmd(d),
+		newScrubCmd(d),
+		newStatusCmd(d),
+	)
 
-func init() {
-	// 在初始化时，加载配置
-	cobra.OnInitialize(initConfig)
+	return root
+}
 
-	// 1. 定义全局参数 --config
-	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.tv/config.yaml)")
+// exitCodes maps each tverr sentinel to the process exit code downstream automation can branch
+// on deterministically instead of scraping stderr; remediationHints is the one-line "what do I
+// do about this" printed alongside it. Order matters here only in that it's the order Execute
+// checks them in — a given error is only ever tagged with one sentinel, so there's no precedence
+// to get wrong
+var exitCodes = []struct {
+	kind int
+	err  error
+	hint string
+}{
+	{2, tverr.ErrConfigInvalid, "check your config file and TV_* environment variables"},
+	{3, tverr.ErrRemoteUnreachable, "check --server/remote.server (or remote.discovery.*) and that the remote is reachable"},
+	{4, tverr.ErrHashMismatch, "this hash doesn't match what this repository expects — check you copied it from the right repository"},
+	{5, tverr.ErrObjectMissing, "the referenced object isn't in local storage; try 'tv fetch <remote>' or 'tv scrub'"},
+	{2, tverr.ErrNotInitialized, "run 'tv init' first"},
+}
 
-	// 2. 定义 storage.path 参数，并绑定到 Viper
-	// 这样用户既可以在 yaml 里写，也可以用 --storage-path 覆盖
-	rootCmd.PersistentFlags().String("storage-path", "", "Directory to store objects")
-	rootCmd.PersistentFlags().String("server", "", "TensorVault Server Address (e.g. localhost:8080)")
-	err := viper.BindPFlag("storage.path", rootCmd.PersistentFlags().Lookup("storage-path"))
-	if err != nil {
-		fmt.Println("Failed to bind flag:", err)
-		os.Exit(1)
-	}
-	err = viper.BindPFlag("remote.server", rootCmd.PersistentFlags().Lookup("server"))
-	if err != nil {
-		fmt.Println("Failed to bind flag:", err)
-		os.Exit(1)
+// Execute is the entry point: it runs the real `tv` binary's command tree against
+// DefaultDeps(), translating a tverr-tagged failure into an actionable hint and a stable exit
+// code before handing off to main's log.Fatal (which always covers anything untagged with a
+// generic exit code 1)
+func Execute() error {
+	err := NewRootCmd(DefaultDeps()).Execute()
+	if err == nil {
+		return nil
 	}
-	viper.SetDefault("remote.server", "localhost:8080")
-	rootCmd.PersistentPostRunE = func(cmd *cobra.Command, args []string) error {
-		if remoteStore != nil {
-			fmt.Println("🔌 Closing connection...")
-			return remoteStore.Close()
+
+	for _, c := range exitCodes {
+		if tverr.ContainsType(err, c.err) {
+			fmt.Fprintf(os.Stderr, "Error: %v\nhint: %s\n", err, c.hint)
+			os.Exit(c.kind)
 		}
-		return nil
 	}
+	return err
 }
 
-// initConfig 读取配置文件和环境变量
-func initConfig() {
-	// 直接调用共享逻辑，删掉原来那一堆代码
-	if err := config.Load(cfgFile); err != nil {
-		fmt.Println("Config error:", err)
-		os.Exit(1)
+// defaultRemoteClientFactory is Deps.RemoteClientFactory's default implementation: resolve
+// remote.server (or, if unset, a remote.discovery.factory-backed candidate list) and dial it
+func defaultRemoteClientFactory(v *viper.Viper) (*client.TVClient, error) {
+	addr := v.GetString("remote.server")
+
+	// remote.server unset: fall back to a discovery.Provider (remote.discovery.factory),
+	// handing the resolved candidate addresses to a failover-capable *TVClient instead of
+	// erroring out — lets a deployment that only configures remote.discovery.* still work
+	if addr == "" {
+		factory := v.GetString("remote.discovery.factory")
+		if factory == "" {
+			return nil, fmt.Errorf("remote server address required (use --server localhost:8080, or configure remote.discovery.factory)")
+		}
+		return newDiscoveryBackedClient(v, factory)
 	}
+
+	return client.NewTVClient(addr)
 }
 
-// GetRemoteClient 是获取远程连接的唯一入口 (Thread-safe isn't strictly needed for CLI, but logical safety is)
-func GetRemoteClient() (*client.TVClient, error) {
-	// 1. 如果已经初始化过，直接返回 (单例模式)
-	if remoteStore != nil {
-		return remoteStore, nil
-	}
-	addr := viper.GetString("remote.server")
-	// 2. 检查配置
-	if addr == "" {
-		return nil, fmt.Errorf("remote server address required (use --server localhost:8080)")
+// newDiscoveryBackedClient uses remote.discovery.<factory>.servers to construct a Provider,
+// resolves it into candidate addresses, and hands those to client.NewFailoverTVClient to pick
+// a reachable starting point and retry across candidates on transient RPC errors
+func newDiscoveryBackedClient(v *viper.Viper, factory string) (*client.TVClient, error) {
+	var servers []string
+	if err := v.UnmarshalKey("remote.discovery."+factory+".servers", &servers); err != nil {
+		return nil, fmt.Errorf("failed to parse remote.discovery.%s.servers: %w", factory, err)
 	}
 
-	// 3. 初始化
-	c, err := client.NewTVClient(addr)
+	provider, err := discovery.New(factory, discovery.Config{Servers: servers})
 	if err != nil {
 		return nil, err
 	}
 
-	// 4. 赋值给全局变量
-	remoteStore = c
-	return remoteStore, nil
+	candidates, err := provider.Resolve(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve remote.discovery.%s servers: %w", factory, err)
+	}
+
+	return client.NewFailoverTVClient(candidates)
+}
+
+// defaultRemoteTransportFactory is Deps.RemoteTransportFactory's default implementation,
+// covering the narrower RemoteTransport surface (object/ref push-pull, pluggable by
+// remote.scheme) that coexists with defaultRemoteClientFactory's *client.TVClient
+func defaultRemoteTransportFactory(ctx context.Context, v *viper.Viper) (client.RemoteTransport, error) {
+	scheme := v.GetString("remote.scheme")
+	cfg := client.TransportConfig{
+		Addr:              v.GetString("remote.server"),
+		TLSCAFile:         v.GetString("remote.tls.ca"),
+		TLSCertFile:       v.GetString("remote.tls.cert"),
+		TLSKeyFile:        v.GetString("remote.tls.key"),
+		Insecure:          v.GetBool("remote.insecure"),
+		S3Endpoint:        v.GetString("remote.s3.endpoint"),
+		S3Bucket:          v.GetString("remote.s3.bucket"),
+		S3Region:          v.GetString("remote.s3.region"),
+		S3AccessKeyID:     v.GetString("remote.s3.access_key_id"),
+		S3SecretAccessKey: v.GetString("remote.s3.secret_access_key"),
+	}
+
+	return client.NewRemoteTransport(ctx, scheme, cfg)
 }
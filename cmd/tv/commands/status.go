@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"tensorvault/pkg/ignore"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/types"
+	"tensorvault/pkg/worktree"
+
+	"github.com/spf13/cobra"
+)
+
+func newStatusCmd(deps *Deps) *cobra.Command {
+	// statusIgnored 对应 `tv status --ignored`：不报告 Modified/Added/Deleted，而是列出
+	// 所有被 .tvignore 排除的路径，以及排除它的具体规则来自哪个文件——排查"为什么这份
+	// 子目录的数据集没被 add 进来"时，比翻 .tvignore 原文直接得多
+	var statusIgnored bool
+
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Show the working tree status",
+		Long:  `Compare HEAD's tree, the Index and the working tree, and report Modified/Added/Deleted/Untracked paths.`,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if deps.App() == nil {
+				return fmt.Errorf("app not initialized")
+			}
+
+			wd, err := os.Getwd()
+			if err != nil {
+				return err
+			}
+
+			if statusIgnored {
+				return printIgnoredReport(wd)
+			}
+
+			report, err := computeStatus(cmd.Context(), deps, wd)
+			if err != nil {
+				return err
+			}
+
+			printStatusReport(report)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&statusIgnored, "ignored", false, "show paths excluded by .tvignore instead of the working tree diff")
+	return cmd
+}
+
+// printIgnoredReport 列出被 .tvignore（含系统默认规则和 .tv/info/exclude）排除的所有
+// 路径，每条都标出命中的规则源文件，呼应 `tv check-ignore` 单文件查询时的展示格式
+func printIgnoredReport(wd string) error {
+	matcher, err := ignore.NewMatcher(wd)
+	if err != nil {
+		return fmt.Errorf("failed to load ignore rules: %w", err)
+	}
+
+	entries, err := matcher.ListIgnored(wd)
+	if err != nil {
+		return fmt.Errorf("failed to walk working tree: %w", err)
+	}
+
+	if len(entries) == 0 {
+		fmt.Println("no ignored paths")
+		return nil
+	}
+	for _, e := range entries {
+		fmt.Printf("!! %s  (rule \"%s\" from %s)\n", e.Path, e.Rule.Raw, e.Rule.Source)
+	}
+	return nil
+}
+
+// computeStatus 是 `tv status` 和 `tv checkout` 的共用逻辑：
+// 拿到 HEAD 指向的 Tree（如果仓库还没有任何提交，则用空 Tree），
+// 再让 worktree.Walker 做三路对比
+func computeStatus(ctx context.Context, deps *Deps, rootDir string) (*worktree.Report, error) {
+	var headTree types.Hash
+
+	headHash, _, err := deps.App().Refs.GetHead(ctx)
+	if err != nil {
+		if !errors.Is(err, refs.ErrNoHead) {
+			return nil, fmt.Errorf("failed to resolve HEAD: %w", err)
+		}
+		// 空仓库：没有 HEAD，也就没有 Tree 可对比，所有暂存/磁盘文件都会被归类为 Added/Untracked
+	} else {
+		commit, err := loadCommit(ctx, deps, headHash)
+		if err != nil {
+			return nil, err
+		}
+		headTree = commit.TreeCid.Hash
+	}
+
+	walker := worktree.NewWalker(deps.App().Store, deps.App().Hasher)
+	return walker.Compute(ctx, headTree, deps.App().Index, rootDir)
+}
+
+func printStatusReport(report *worktree.Report) {
+	if report.IsClean() {
+		fmt.Println("nothing to commit, working tree clean")
+		return
+	}
+
+	for _, e := range report.Entries {
+		var marker string
+		switch e.State {
+		case worktree.Added:
+			marker = "A"
+		case worktree.Modified:
+			marker = "M"
+		case worktree.Deleted:
+			marker = "D"
+		case worktree.Untracked:
+			marker = "?"
+		default:
+			marker = " "
+		}
+		fmt.Printf("%s  %s\n", marker, e.Path)
+	}
+}
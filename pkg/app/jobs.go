@@ -0,0 +1,282 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/exporter"
+	"tensorvault/pkg/gc"
+	"tensorvault/pkg/ignore"
+	"tensorvault/pkg/index"
+	"tensorvault/pkg/ingester"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/task"
+	"tensorvault/pkg/treebuilder"
+	"tensorvault/pkg/types"
+)
+
+// UploadPayload 是 task.TypeUpload 的参数：把本地磁盘上的一个文件分片上传进 Store
+type UploadPayload struct {
+	Path string `json:"path"`
+}
+
+// CheckoutPayload 是 task.TypeCheckout 的参数：把某个 Tree 还原到工作目录
+type CheckoutPayload struct {
+	TreeHash  string `json:"tree_hash"`
+	TargetDir string `json:"target_dir"`
+
+	// ResumeJobID 非空时，恢复一个之前被中断的 exporter.RestoreJob（断点续传，只补下载还没
+	// 写完的 Chunk），而不是从头开始一次全新的还原。跟这个 task 本身的 ID 是两套独立的 ID
+	// 空间，调用方从第一次提交时的日志里拿到它
+	ResumeJobID string `json:"resume_job_id,omitempty"`
+}
+
+// GCRepackPayload 是 task.TypeGCRepack 的参数，目前不需要任何字段
+type GCRepackPayload struct{}
+
+// TreeBuildPayload 是 task.TypeTreeBuild 的参数：从一份 Index 构建 Merkle Tree
+// 有两种来源：本地 CLI 场景下 IndexPath 指向磁盘上的 index.json；瘦客户端场景下
+// （MetaService.SubmitBuildTree）调用方没有本地 Index 文件，直接把 path -> hash 的
+// FileMap 发过来，由服务端现拼一个临时 Index。二者互斥，FileMap 优先
+type TreeBuildPayload struct {
+	IndexPath string            `json:"index_path,omitempty"`
+	FileMap   map[string]string `json:"file_map,omitempty"`
+}
+
+// CommitPayload 是 task.TypeCommit 的参数：（如果需要）构建 Tree，然后记录一个 Commit 并
+// 推进 HEAD。TreeHash 和 IndexPath 二选一：amend/--allow-empty 复用旧 tree 的场景下调用方
+// 已经知道 TreeHash，不需要再构建一遍；否则传 IndexPath，由 Handler 自己跑 treebuilder
+type CommitPayload struct {
+	TreeHash  string   `json:"tree_hash,omitempty"`
+	IndexPath string   `json:"index_path,omitempty"`
+	Parents   []string `json:"parents,omitempty"`
+	Author    string   `json:"author"`
+	Message   string   `json:"message"`
+}
+
+// registerJobHandlers 把内建任务类型接到已有的 ingester/exporter/treebuilder/gc 逻辑上
+// 被 NewApp 调用；Handler 只通过 Reporter 上报进度，不直接碰数据库
+func registerJobHandlers(a *App) {
+	a.Jobs.Handle(task.TypeUpload, func(ctx context.Context, raw json.RawMessage, r task.Reporter) error {
+		var p UploadPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return fmt.Errorf("invalid upload payload: %w", err)
+		}
+
+		f, err := os.Open(p.Path)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", p.Path, err)
+		}
+		defer f.Close()
+
+		r.Progress(0, fmt.Sprintf("ingesting %s", p.Path))
+		ing := ingester.NewIngester(a.Store, a.Hasher)
+		node, err := ing.IngestFile(ctx, f)
+		if err != nil {
+			return fmt.Errorf("ingest failed: %w", err)
+		}
+
+		r.Log(fmt.Sprintf("uploaded %s as %s (%d bytes)", p.Path, node.ID(), node.TotalSize))
+		r.Progress(100, fmt.Sprintf("done: %s", node.ID()))
+		return nil
+	})
+
+	a.Jobs.Handle(task.TypeCheckout, func(ctx context.Context, raw json.RawMessage, r task.Reporter) error {
+		var p CheckoutPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return fmt.Errorf("invalid checkout payload: %w", err)
+		}
+
+		exp := exporter.NewExporter(a.Store)
+
+		// 断点续传的还原：每个文件的 Chunk 完成位图持久化在 meta.Repository 里，进程崩溃
+		// 重启后只补下载还没写完的部分，500GB 的模型 checkout 不用从零开始
+		var job *exporter.RestoreJob
+		var err error
+		if p.ResumeJobID != "" {
+			job, err = exp.ResumeRestore(ctx, a.Repository, p.ResumeJobID)
+			if err != nil {
+				return fmt.Errorf("failed to resume restore job %s: %w", p.ResumeJobID, err)
+			}
+			r.Log(fmt.Sprintf("resuming restore job %s", job.ID))
+		} else {
+			job, err = exporter.NewRestoreJob(ctx, exp, a.Repository, types.Hash(p.TreeHash), p.TargetDir)
+			if err != nil {
+				return fmt.Errorf("failed to create restore job: %w", err)
+			}
+			r.Log(fmt.Sprintf("started restore job %s (pass --resume %s if this gets interrupted)", job.ID, job.ID))
+		}
+
+		r.Progress(0, fmt.Sprintf("restoring tree %s into %s", job.TreeHash, job.TargetDir))
+		restored := 0
+		onRestore := func(path string, hash types.Hash, size int64) {
+			restored++
+			if r.Cancelled() {
+				return
+			}
+			r.Log(fmt.Sprintf("restored %s (%d bytes)", path, size))
+		}
+
+		if err := job.Run(ctx, onRestore); err != nil {
+			if r.Cancelled() {
+				return task.ErrCancelled
+			}
+			return fmt.Errorf("checkout failed (resume with --resume %s): %w", job.ID, err)
+		}
+
+		r.Progress(100, fmt.Sprintf("restored %d files", restored))
+		return nil
+	})
+
+	a.Jobs.Handle(task.TypeGCRepack, func(ctx context.Context, _ json.RawMessage, r task.Reporter) error {
+		r.Progress(0, "scanning object store")
+		stats, err := gc.Repack(ctx, a.Store, func(done, total int, message string) {
+			pct := 0
+			if total > 0 {
+				pct = done * 100 / total
+			}
+			r.Progress(pct, message)
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return task.ErrCancelled
+			}
+			return err
+		}
+
+		r.Log(fmt.Sprintf("repacked %d/%d objects, saved %d bytes", stats.Repacked, stats.Scanned, stats.SavedBytes()))
+		r.Progress(100, "repack complete")
+		return nil
+	})
+
+	a.Jobs.Handle(task.TypeTreeBuild, func(ctx context.Context, raw json.RawMessage, r task.Reporter) error {
+		var p TreeBuildPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return fmt.Errorf("invalid tree_build payload: %w", err)
+		}
+
+		var idx *index.Index
+		if len(p.FileMap) > 0 {
+			// 瘦客户端路径（MetaService.SubmitBuildTree 异步版）：复刻同名同步 RPC
+			// 的逻辑，只是把它挪到后台跑——校验 FileMap 里每个 hash 的大小都能在 SQL
+			// 里查到，查不到就直接失败，不去猜测兜底
+			if a.Repository == nil {
+				return fmt.Errorf("tree build from file_map requires the metadata database")
+			}
+			r.Progress(0, fmt.Sprintf("resolving sizes for %d files", len(p.FileMap)))
+			hashes := make([]types.Hash, 0, len(p.FileMap))
+			for _, h := range p.FileMap {
+				hashes = append(hashes, types.Hash(h))
+			}
+			sizeMap, err := a.Repository.GetSizesByMerkleRoots(ctx, hashes)
+			if err != nil {
+				return fmt.Errorf("failed to query sizes: %w", err)
+			}
+			tempIndex := &index.Index{Entries: make(map[string]index.Entry)}
+			for path, hashStr := range p.FileMap {
+				size, found := sizeMap[hashStr]
+				if !found {
+					return fmt.Errorf("size metadata not found for %s", hashStr)
+				}
+				tempIndex.Add(path, types.Hash(hashStr), size)
+			}
+			idx = tempIndex
+		} else {
+			var err error
+			idx, err = index.NewIndex(p.IndexPath)
+			if err != nil {
+				return fmt.Errorf("failed to load index %s: %w", p.IndexPath, err)
+			}
+		}
+
+		r.Progress(10, "building tree")
+		builder := treebuilder.NewBuilder(a.Store, a.Hasher)
+		if p.IndexPath != "" {
+			// IndexPath 总是 <repo>/.tv/index.json，往上两级就是仓库根目录，.tvignore 从那里找起
+			repoRoot := filepath.Dir(filepath.Dir(p.IndexPath))
+			if matcher, err := ignore.NewMatcher(repoRoot); err == nil {
+				builder.WithIgnoreMatcher(matcher)
+			}
+		}
+		rootHash, err := builder.Build(ctx, idx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return task.ErrCancelled
+			}
+			return fmt.Errorf("failed to build tree: %w", err)
+		}
+
+		r.Log(fmt.Sprintf("built tree root %s", rootHash))
+		r.Progress(100, fmt.Sprintf("done: %s", rootHash))
+		return nil
+	})
+
+	a.Jobs.Handle(task.TypeCommit, func(ctx context.Context, raw json.RawMessage, r task.Reporter) error {
+		var p CommitPayload
+		if err := json.Unmarshal(raw, &p); err != nil {
+			return fmt.Errorf("invalid commit payload: %w", err)
+		}
+
+		treeHash := types.Hash(p.TreeHash)
+		if treeHash == "" {
+			if p.IndexPath == "" {
+				return fmt.Errorf("commit payload has neither tree_hash nor index_path")
+			}
+			idx, err := index.NewIndex(p.IndexPath)
+			if err != nil {
+				return fmt.Errorf("failed to load index %s: %w", p.IndexPath, err)
+			}
+			r.Progress(0, "building tree")
+			builder := treebuilder.NewBuilder(a.Store, a.Hasher)
+			repoRoot := filepath.Dir(filepath.Dir(p.IndexPath))
+			if matcher, err := ignore.NewMatcher(repoRoot); err == nil {
+				builder.WithIgnoreMatcher(matcher)
+			}
+			treeHash, err = builder.Build(ctx, idx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return task.ErrCancelled
+				}
+				return fmt.Errorf("failed to build tree: %w", err)
+			}
+			r.Log(fmt.Sprintf("built tree root %s", treeHash))
+		}
+
+		var parents []types.Hash
+		for _, ph := range p.Parents {
+			parents = append(parents, types.Hash(ph))
+		}
+
+		r.Progress(70, "creating commit object")
+		commitObj, err := core.NewCommit(treeHash, parents, p.Author, p.Message, a.Hasher)
+		if err != nil {
+			return fmt.Errorf("failed to create commit object: %w", err)
+		}
+		if err := a.Store.Put(ctx, commitObj); err != nil {
+			return fmt.Errorf("storage backend error: %w", err)
+		}
+		if err := a.Repository.IndexCommit(ctx, commitObj); err != nil {
+			return fmt.Errorf("metadata indexing error: %w", err)
+		}
+
+		// 跟 MetaService.Commit 一样：推进调用方当前所在的位置，而不是提交时快照的某个分支
+		// 名。HEAD 的版本号在这里重新读取而不是信任提交时刻的值，这样它和提交排队期间落地的
+		// 其他并发 commit 之间的 CAS 仍然有效——排队等待期间 HEAD 完全可能已经往前走了
+		_, currentVer, err := a.Refs.GetHead(ctx)
+		if err != nil && !errors.Is(err, refs.ErrNoHead) {
+			return fmt.Errorf("failed to resolve HEAD: %w", err)
+		}
+		if err := a.Refs.UpdateHead(ctx, commitObj.ID(), currentVer); err != nil {
+			return fmt.Errorf("concurrent update detected on HEAD: %w", err)
+		}
+
+		r.Log(fmt.Sprintf("committed %s", commitObj.ID()))
+		r.Progress(100, fmt.Sprintf("done: %s", commitObj.ID()))
+		return nil
+	})
+}
@@ -0,0 +1,260 @@
+// pkg/blame/blame.go
+package blame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// ErrPathNotFound 在给定的 commit 里找不到要 blame 的路径时返回
+var ErrPathNotFound = errors.New("blame: path not found")
+
+// Range 是文件内的一段连续字节区间 [Start, End)，全部来自同一个 commit
+type Range struct {
+	Start int64
+	End   int64
+}
+
+// Record 是 Blame 结果里的一条记录：某段字节区间第一次以当前内容出现在哪个 commit
+type Record struct {
+	Range      Range
+	CommitHash types.Hash
+	Author     string
+	Timestamp  int64
+	Message    string
+}
+
+// Blamer 在 commit 历史上为单个文件的每个 Chunk 追溯"最早引入这份内容的 commit"
+//
+// 整个过程不需要任何文本 diff：TensorVault 按 Chunk 内容寻址去重，同一位置上两个
+// commit 的 ChunkLink.Hash 相等就意味着这段字节完全没变，不等就意味着这里被改过——
+// 比较的代价只有 O(chunk 数量)，不需要读一个字节的实际文件内容
+type Blamer struct {
+	store storage.Store
+}
+
+func NewBlamer(store storage.Store) *Blamer {
+	return &Blamer{store: store}
+}
+
+// Blame 从 startCommit 开始，沿 first-parent 历史（只走主线，忽略 merge 进来的
+// 其他分支；`tv log` 则会遍历每一个 parent）把 path 在 startCommit 时刻的每个 Chunk 往回追溯，直到追到
+// 它第一次以这份内容出现的 commit 为止。返回结果按文件内字节偏移升序排列，相邻且
+// 归属同一个 commit 的 Chunk 会被合并成一条 Record
+func (b *Blamer) Blame(ctx context.Context, path string, startCommit types.Hash) ([]Record, error) {
+	commit, err := b.loadCommit(ctx, startCommit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load start commit %s: %w", startCommit, err)
+	}
+
+	leaves, err := b.resolveLeaves(ctx, commit, path)
+	if err != nil {
+		return nil, err
+	}
+	if leaves == nil {
+		return nil, fmt.Errorf("%w: %q at commit %s", ErrPathNotFound, path, startCommit)
+	}
+
+	// owner[i] 记录 leaves[i] 目前被归因到的 commit；一开始全部挂在 startCommit 上，
+	// 每往上追溯一级祖先，就核实一遍它在那个祖先里是否还是同样的内容——一旦对不上，
+	// 就停在当前这一级，不再继续往上看
+	owner := make([]types.Hash, len(leaves))
+	for i := range owner {
+		owner[i] = startCommit
+	}
+
+	current, currentHash := commit, startCommit
+	unsettled := len(leaves)
+	for unsettled > 0 && len(current.Parents) > 0 {
+		parentHash := current.Parents[0].Hash
+		parent, err := b.loadCommit(ctx, parentHash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load parent commit %s: %w", parentHash, err)
+		}
+
+		parentLeaves, err := b.resolveLeaves(ctx, parent, path)
+		if err != nil && !errors.Is(err, ErrPathNotFound) {
+			return nil, fmt.Errorf("failed to resolve %q at %s: %w", path, parentHash, err)
+		}
+
+		stillOpen := 0
+		for i, leaf := range leaves {
+			if owner[i] != currentHash {
+				continue // 已经在更早的祖先那里结算完了
+			}
+			if i < len(parentLeaves) && parentLeaves[i].Hash.Hash == leaf.Hash.Hash {
+				owner[i] = parentHash
+				stillOpen++
+			}
+		}
+		unsettled = stillOpen
+		current, currentHash = parent, parentHash
+	}
+
+	return b.coalesce(ctx, leaves, owner)
+}
+
+// coalesce 把逐 Chunk 的归因结果压缩成按字节偏移排列、相邻同归属合并的 Record 列表，
+// 沿途查出各个 owner commit 的 Author/Timestamp/Message——同一个 commit 在结果里大概率
+// 会重复出现很多次（一次修改往往牵动几十上百个 Chunk），所以缓存已经查过的 commit
+func (b *Blamer) coalesce(ctx context.Context, leaves []core.ChunkLink, owner []types.Hash) ([]Record, error) {
+	commits := make(map[types.Hash]*core.Commit, len(leaves))
+
+	var records []Record
+	var offset int64
+	for i, leaf := range leaves {
+		if n := len(records); n > 0 && records[n-1].CommitHash == owner[i] {
+			records[n-1].Range.End += int64(leaf.Size)
+			offset += int64(leaf.Size)
+			continue
+		}
+
+		c, ok := commits[owner[i]]
+		if !ok {
+			var err error
+			c, err = b.loadCommit(ctx, owner[i])
+			if err != nil {
+				return nil, fmt.Errorf("failed to load commit %s: %w", owner[i], err)
+			}
+			commits[owner[i]] = c
+		}
+
+		records = append(records, Record{
+			Range:      Range{Start: offset, End: offset + int64(leaf.Size)},
+			CommitHash: owner[i],
+			Author:     c.Author,
+			Timestamp:  c.Timestamp,
+			Message:    c.Message,
+		})
+		offset += int64(leaf.Size)
+	}
+	return records, nil
+}
+
+// resolveLeaves 定位 path 在某个 commit 的 Tree 里对应的 FileNode，再把它（如果是
+// Pyramid 布局）展开成一串叶子 ChunkLink。path 在这个 commit 里不存在时返回
+// (nil, ErrPathNotFound)
+func (b *Blamer) resolveLeaves(ctx context.Context, commit *core.Commit, path string) ([]core.ChunkLink, error) {
+	nodeHash, err := b.resolvePath(ctx, commit.TreeCid.Hash, path)
+	if err != nil {
+		return nil, err
+	}
+	if nodeHash.IsZero() {
+		return nil, ErrPathNotFound
+	}
+
+	node, err := b.loadFileNode(ctx, nodeHash)
+	if err != nil {
+		return nil, err
+	}
+	return b.flattenLeaves(ctx, node)
+}
+
+// resolvePath 沿 path 的每一段逐级下降 Tree，找到叶子 TreeEntry 指向的 FileNode hash；
+// 任何一级找不到都返回零值 Hash（不是 error），交给 resolveLeaves 统一翻译成 ErrPathNotFound
+func (b *Blamer) resolvePath(ctx context.Context, treeHash types.Hash, path string) (types.Hash, error) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+
+	current := treeHash
+	for i, seg := range segments {
+		if current.IsZero() {
+			return "", nil
+		}
+		tree, err := b.loadTree(ctx, current)
+		if err != nil {
+			return "", fmt.Errorf("failed to load tree %s: %w", current, err)
+		}
+
+		var found *core.TreeEntry
+		for j := range tree.Entries {
+			if tree.Entries[j].Name == seg {
+				found = &tree.Entries[j]
+				break
+			}
+		}
+		if found == nil {
+			return "", nil
+		}
+
+		if i == len(segments)-1 {
+			if found.Type != core.EntryFile {
+				return "", fmt.Errorf("path %q is a directory, not a file", path)
+			}
+			return found.Cid.Hash, nil
+		}
+		if found.Type != core.EntryDir {
+			return "", nil
+		}
+		current = found.Cid.Hash
+	}
+	return "", nil
+}
+
+// flattenLeaves 把一个 FileNode 展开成一串叶子 ChunkLink：IsInternal 为 true 时递归
+// 下降 Pyramid 的中间层，顺序保持跟文件内字节偏移一致
+func (b *Blamer) flattenLeaves(ctx context.Context, node *core.FileNode) ([]core.ChunkLink, error) {
+	if !node.IsInternal {
+		return node.Chunks, nil
+	}
+
+	leaves := make([]core.ChunkLink, 0, len(node.Chunks))
+	for _, link := range node.Chunks {
+		child, err := b.loadFileNode(ctx, link.Hash.Hash)
+		if err != nil {
+			return nil, err
+		}
+		childLeaves, err := b.flattenLeaves(ctx, child)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, childLeaves...)
+	}
+	return leaves, nil
+}
+
+func (b *Blamer) loadCommit(ctx context.Context, hash types.Hash) (*core.Commit, error) {
+	var c core.Commit
+	if err := b.getObject(ctx, hash, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (b *Blamer) loadTree(ctx context.Context, hash types.Hash) (*core.Tree, error) {
+	var t core.Tree
+	if err := b.getObject(ctx, hash, &t); err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func (b *Blamer) loadFileNode(ctx context.Context, hash types.Hash) (*core.FileNode, error) {
+	var n core.FileNode
+	if err := b.getObject(ctx, hash, &n); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// getObject 读取并解码一个对象，跟 pkg/gc.getObject/pkg/worktree 里重复出现的
+// "Get -> ReadAll -> DecodeObject" 三连是同一套
+func (b *Blamer) getObject(ctx context.Context, hash types.Hash, out any) error {
+	reader, err := b.store.Get(ctx, hash.String())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return core.DecodeObject(data, out)
+}
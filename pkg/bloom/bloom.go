@@ -0,0 +1,164 @@
+// Package bloom 实现一个标准的双重哈希 (Double Hashing) Bloom Filter
+// 用于 ingester 的"远端大概率已存在"探测：用很小的内存代价换掉绝大多数重复 Chunk 的
+// Put/Stat 往返，在近似重复的大模型文件反复 ingest 时效果最明显
+package bloom
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"sync"
+
+	"tensorvault/pkg/types"
+)
+
+// magic 是序列化格式的版本标记，万一以后改编码方式，Unmarshal 能识别出不兼容的旧格式
+const magic = "TVBF1"
+
+// Filter 是一个线程安全的 Bloom Filter：m 个 bit，k 个哈希函数
+// 两个哈希函数 h1/h2 直接取自内容哈希本身 (而不是另外跑一遍哈希算法)，
+// 第 i 个探测位为 (h1 + i*h2) mod m —— 标准的 Kirsch-Mitzenmacher 双重哈希技巧
+type Filter struct {
+	mu   sync.Mutex
+	bits []byte
+	m    uint64
+	k    uint64
+}
+
+// New 按期望容纳的元素数量 n 和目标假阳性率 p 构建一个空 Filter
+// m = -n*ln(p) / (ln2)^2，k = round(m/n * ln2)
+func New(n uint64, p float64) *Filter {
+	if n == 0 {
+		n = 1
+	}
+	if p <= 0 || p >= 1 {
+		p = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(n) * math.Log(p) / (math.Ln2 * math.Ln2)))
+	if m == 0 {
+		m = 1
+	}
+	k := uint64(math.Round(float64(m) / float64(n) * math.Ln2))
+	if k == 0 {
+		k = 1
+	}
+
+	return &Filter{
+		bits: make([]byte, (m+7)/8),
+		m:    m,
+		k:    k,
+	}
+}
+
+// Add 把 hash 对应的 k 个 bit 位全部置 1
+func (f *Filter) Add(hash types.Hash) {
+	h1, h2 := splitHash(hash)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := uint64(0); i < f.k; i++ {
+		f.setBit((h1 + i*h2) % f.m)
+	}
+}
+
+// MayContain 返回 false 时 hash 一定不在集合里；返回 true 时只是"大概率在"，
+// 调用方仍然需要用权威数据源 (例如 store.Has) 确认
+func (f *Filter) MayContain(hash types.Hash) bool {
+	h1, h2 := splitHash(hash)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := uint64(0); i < f.k; i++ {
+		if !f.getBit((h1 + i*h2) % f.m) {
+			return false
+		}
+	}
+	return true
+}
+
+// Merge 把 other 的 bit 位并入 f (逐 bit OR)，用于多个客户端各自 Add 之后收敛成同一份状态
+// 两边的 m/k 必须完全一致 (否则探测位的含义对不上)，不一致时返回错误而不是静默出错
+func (f *Filter) Merge(other *Filter) error {
+	if other == nil {
+		return nil
+	}
+	other.mu.Lock()
+	otherBits := append([]byte(nil), other.bits...)
+	otherM, otherK := other.m, other.k
+	other.mu.Unlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if otherM != f.m || otherK != f.k {
+		return fmt.Errorf("bloom: cannot merge filters with different parameters (m=%d,k=%d vs m=%d,k=%d)", f.m, f.k, otherM, otherK)
+	}
+	for i := range f.bits {
+		f.bits[i] |= otherBits[i]
+	}
+	return nil
+}
+
+// Marshal 序列化成可以直接落盘/上传的字节：5 字节 magic + m + k + bit 数组
+func (f *Filter) Marshal() []byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	buf := make([]byte, len(magic)+8+8+len(f.bits))
+	copy(buf, magic)
+	off := len(magic)
+	binary.BigEndian.PutUint64(buf[off:], f.m)
+	off += 8
+	binary.BigEndian.PutUint64(buf[off:], f.k)
+	off += 8
+	copy(buf[off:], f.bits)
+	return buf
+}
+
+// Unmarshal 从 Marshal 写出的字节还原一个 Filter
+func Unmarshal(data []byte) (*Filter, error) {
+	if len(data) < len(magic)+16 || string(data[:len(magic)]) != magic {
+		return nil, fmt.Errorf("bloom: corrupted or incompatible checkpoint")
+	}
+	off := len(magic)
+	m := binary.BigEndian.Uint64(data[off:])
+	off += 8
+	k := binary.BigEndian.Uint64(data[off:])
+	off += 8
+
+	wantBits := int((m + 7) / 8)
+	if len(data[off:]) != wantBits {
+		return nil, fmt.Errorf("bloom: corrupted checkpoint (want %d bit bytes, got %d)", wantBits, len(data[off:]))
+	}
+
+	return &Filter{
+		bits: append([]byte(nil), data[off:]...),
+		m:    m,
+		k:    k,
+	}, nil
+}
+
+func (f *Filter) setBit(i uint64) {
+	f.bits[i/8] |= 1 << uint(i%8)
+}
+
+func (f *Filter) getBit(i uint64) bool {
+	return f.bits[i/8]&(1<<uint(i%8)) != 0
+}
+
+// splitHash 把 hash (64 位十六进制 SHA256) 拆成两个独立的 64 位整数，作为双重哈希的 h1/h2
+// 不是合法的十六进制/长度不够时退化为对原始字符串字节做同样的切分，保证永远有确定性的返回值
+func splitHash(hash types.Hash) (uint64, uint64) {
+	raw, err := hex.DecodeString(hash.String())
+	if err != nil || len(raw) < 16 {
+		raw = []byte(hash.String())
+		for len(raw) < 16 {
+			raw = append(raw, 0)
+		}
+	}
+	h1 := binary.BigEndian.Uint64(raw[0:8])
+	h2 := binary.BigEndian.Uint64(raw[8:16])
+	if h2 == 0 {
+		h2 = 1 // h2 == 0 会让除第一次探测之外的所有 bit 位都退化成 h1，丧失双重哈希的意义
+	}
+	return h1, h2
+}
@@ -0,0 +1,95 @@
+package bloom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tensorvault/pkg/types"
+)
+
+func hashOf(s string) types.Hash {
+	sum := sha256.Sum256([]byte(s))
+	return types.Hash(hex.EncodeToString(sum[:]))
+}
+
+func TestFilter_NoFalseNegatives(t *testing.T) {
+	f := New(1000, 0.01)
+
+	added := make([]types.Hash, 0, 500)
+	for i := 0; i < 500; i++ {
+		h := hashOf(fmt.Sprintf("chunk-%d", i))
+		f.Add(h)
+		added = append(added, h)
+	}
+
+	for _, h := range added {
+		assert.True(t, f.MayContain(h), "Bloom Filter 绝不应该有假阴性")
+	}
+}
+
+func TestFilter_FalsePositiveRateIsBounded(t *testing.T) {
+	const n = 2000
+	const targetFPR = 0.01
+	f := New(n, targetFPR)
+
+	for i := 0; i < n; i++ {
+		f.Add(hashOf(fmt.Sprintf("real-%d", i)))
+	}
+
+	falsePositives := 0
+	const trials = 5000
+	for i := 0; i < trials; i++ {
+		if f.MayContain(hashOf(fmt.Sprintf("absent-%d", i))) {
+			falsePositives++
+		}
+	}
+
+	// 允许一定的统计浮动，但假阳性率不该离目标值太远（否则说明 m/k 算错了）
+	rate := float64(falsePositives) / float64(trials)
+	assert.Less(t, rate, targetFPR*3, "假阳性率显著超出目标值，m/k 的计算可能有问题")
+}
+
+func TestFilter_MarshalUnmarshalRoundTrip(t *testing.T) {
+	f := New(100, 0.01)
+	f.Add(hashOf("a"))
+	f.Add(hashOf("b"))
+
+	data := f.Marshal()
+	restored, err := Unmarshal(data)
+	require.NoError(t, err)
+
+	assert.True(t, restored.MayContain(hashOf("a")))
+	assert.True(t, restored.MayContain(hashOf("b")))
+	assert.Equal(t, data, restored.Marshal(), "反序列化后再序列化应该得到完全一样的字节")
+}
+
+func TestFilter_Merge(t *testing.T) {
+	a := New(100, 0.01)
+	a.Add(hashOf("from-a"))
+
+	b := New(100, 0.01)
+	b.Add(hashOf("from-b"))
+
+	require.NoError(t, a.Merge(b))
+
+	assert.True(t, a.MayContain(hashOf("from-a")))
+	assert.True(t, a.MayContain(hashOf("from-b")), "Merge 之后应该收敛到两边的并集")
+}
+
+func TestFilter_Merge_RejectsMismatchedParameters(t *testing.T) {
+	a := New(100, 0.01)
+	b := New(100000, 0.01)
+
+	err := a.Merge(b)
+	assert.Error(t, err, "m/k 不一致的 Filter 不应该能合并")
+}
+
+func TestUnmarshal_RejectsCorruptedData(t *testing.T) {
+	_, err := Unmarshal([]byte("not a bloom filter"))
+	assert.Error(t, err)
+}
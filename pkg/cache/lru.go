@@ -0,0 +1,117 @@
+// Package cache provides a size-bounded, in-memory LRU cache for decoded
+// Merkle DAG objects (Tree/FileNode/Commit/Delta), modeled on go-git's
+// plumbing/cache/object_lru.go. It sits in front of a storage.Store to avoid
+// re-fetching and re-decoding the same small set of "hot" metadata objects
+// on every tree walk (checkout, future diff/blame).
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/types"
+)
+
+// entry is the value stored in the LRU's linked list.
+type entry struct {
+	hash types.Hash
+	obj  core.Object
+}
+
+// Stats is a point-in-time snapshot of cache effectiveness, meant to be
+// polled by a future Prometheus exporter.
+type Stats struct {
+	Hits       int64
+	Misses     int64
+	Evictions  int64
+	BytesInUse int64
+	Entries    int
+}
+
+// ObjectCache is a thread-safe LRU bounded by total object bytes rather than
+// entry count: a Tree can be a few hundred bytes while a FileNode with
+// thousands of ChunkLinks can be several megabytes, so counting entries would
+// let a handful of large FileNodes blow an otherwise reasonable budget.
+type ObjectCache struct {
+	maxBytes int64
+
+	mu       sync.Mutex
+	ll       *list.List
+	index    map[types.Hash]*list.Element
+	curBytes int64
+
+	hits, misses, evictions int64
+}
+
+// NewObjectCache creates an ObjectCache bounded to maxBytes of cached object payloads.
+func NewObjectCache(maxBytes int64) *ObjectCache {
+	return &ObjectCache{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		index:    make(map[types.Hash]*list.Element),
+	}
+}
+
+// Get returns the cached object for hash, if present, and marks it most-recently-used.
+func (c *ObjectCache) Get(hash types.Hash) (core.Object, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.index[hash]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	c.hits++
+	return el.Value.(*entry).obj, true
+}
+
+// Put inserts or refreshes obj under hash, evicting from the tail until the
+// cache is back under its byte budget.
+func (c *ObjectCache) Put(hash types.Hash, obj core.Object) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.index[hash]; ok {
+		c.curBytes -= int64(len(el.Value.(*entry).obj.Bytes()))
+		el.Value = &entry{hash: hash, obj: obj}
+		c.ll.MoveToFront(el)
+		c.curBytes += int64(len(obj.Bytes()))
+	} else {
+		el := c.ll.PushFront(&entry{hash: hash, obj: obj})
+		c.index[hash] = el
+		c.curBytes += int64(len(obj.Bytes()))
+	}
+
+	for c.curBytes > c.maxBytes && c.ll.Len() > 0 {
+		c.evictOldest()
+	}
+}
+
+// evictOldest drops the least-recently-used entry. Caller must hold c.mu.
+func (c *ObjectCache) evictOldest() {
+	el := c.ll.Back()
+	if el == nil {
+		return
+	}
+	c.ll.Remove(el)
+	ent := el.Value.(*entry)
+	delete(c.index, ent.hash)
+	c.curBytes -= int64(len(ent.obj.Bytes()))
+	c.evictions++
+}
+
+// Stats returns a snapshot of the cache's hit/miss/eviction counters.
+func (c *ObjectCache) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return Stats{
+		Hits:       c.hits,
+		Misses:     c.misses,
+		Evictions:  c.evictions,
+		BytesInUse: c.curBytes,
+		Entries:    c.ll.Len(),
+	}
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// defaultMaxBytes is used when Config.MaxBytes is unset (<= 0).
+const defaultMaxBytes = 64 * 1024 * 1024 // 64MB
+
+// Config 配置 CachingStore 的大小预算
+type Config struct {
+	MaxBytes int64
+}
+
+// decodedObject wraps raw bytes already known to decode as a Commit/Tree/
+// FileNode/Delta, so the cache never has to reconstruct (and keep in sync
+// with) each package's concrete typed struct — it just replays the bytes.
+type decodedObject struct {
+	hash    types.Hash
+	objType core.ObjectType
+	data    []byte
+}
+
+func (o *decodedObject) Type() core.ObjectType { return o.objType }
+func (o *decodedObject) ID() types.Hash        { return o.hash }
+func (o *decodedObject) Bytes() []byte         { return o.data }
+
+// CachingStore 是一个装饰器，用内存 LRU 缓存已解码的 DAG 对象 (Tree/FileNode/Commit/Delta)
+// 原始 Chunk 不进缓存：它们是被流式读取的，体积变化很大，缓存它们只会更快地挤占预算里
+// 真正热的小对象（Tree/Commit），价值也最低——gc --repack 扫描 chunk 时才会重复读它们，
+// 而 repack 本来就是一次性的批处理。
+type CachingStore struct {
+	backend storage.Store
+	cache   *ObjectCache
+}
+
+// NewCachingStore 用 cfg.MaxBytes 的预算包裹 backend
+func NewCachingStore(backend storage.Store, cfg Config) *CachingStore {
+	maxBytes := cfg.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxBytes
+	}
+	return &CachingStore{
+		backend: backend,
+		cache:   NewObjectCache(maxBytes),
+	}
+}
+
+// Put 直接穿透到底层存储；对象是否进入读缓存由下一次 Get 决定
+func (s *CachingStore) Put(ctx context.Context, obj core.Object) error {
+	return s.backend.Put(ctx, obj)
+}
+
+// Get 优先查 LRU，未命中时落到底层存储，并把可解码的对象类型回填进缓存
+func (s *CachingStore) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	if cached, ok := s.cache.Get(types.Hash(hash)); ok {
+		return io.NopCloser(bytes.NewReader(cached.Bytes())), nil
+	}
+
+	reader, err := s.backend.Get(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	if objType, ok := sniffObjectType(data); ok {
+		s.cache.Put(types.Hash(hash), &decodedObject{hash: types.Hash(hash), objType: objType, data: data})
+	}
+
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Has 在 LRU 命中时可以免查底层存储；未命中不代表对象不存在，只是还没被缓存过，
+// 必须继续穿透到 backend
+func (s *CachingStore) Has(ctx context.Context, hash string) (bool, error) {
+	if _, ok := s.cache.Get(types.Hash(hash)); ok {
+		return true, nil
+	}
+	return s.backend.Has(ctx, hash)
+}
+
+// Backend 返回被装饰的底层存储，跟 pkg/storage/cache.CachedStore 的约定保持一致，
+// 供需要拿到具体实现 (比如 *disk.Adapter) 的维护类命令使用
+func (s *CachingStore) Backend() storage.Store {
+	return s.backend
+}
+
+// Stats 返回缓存的命中率统计，供未来接入 Prometheus 使用
+func (s *CachingStore) Stats() Stats {
+	return s.cache.Stats()
+}
+
+// sniffObjectType 复用 pkg/gc.Repack 的 header 嗅探套路：CBOR 解出已知类型头就认为是可缓存的
+// DAG 节点；解不出来（或类型未知）就是原始 chunk，调用方应该走流式路径，不进缓存
+func sniffObjectType(data []byte) (core.ObjectType, bool) {
+	var header struct {
+		TypeVal core.ObjectType `cbor:"t"`
+	}
+	if err := core.DecodeObject(data, &header); err != nil {
+		return "", false
+	}
+	switch header.TypeVal {
+	case core.TypeCommit, core.TypeTree, core.TypeFileNode, core.TypeDelta:
+		return header.TypeVal, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,78 @@
+package chunker
+
+// buzhashTable 生成 Buzhash 用的 256 项查找表：每个字节值映射到一个固定的 64 位
+// 常量，指纹更新时做"循环左移 1 位再异或新字节的表值"。没有用 math/rand 生成，是因为
+// 我们需要同一个 Seed 在任何机器、任何 Go 版本下都产出完全一样的表——math/rand 的
+// 算法实现不保证跨版本稳定，手写一个线性同余生成器 (LCG) 更可控，也足够把表"洗"得
+// 看起来随机、分布均匀，不需要密码学强度
+func buzhashTable(seed int64) [256]uint64 {
+	var table [256]uint64
+	state := uint64(seed)
+	if state == 0 {
+		state = uint64(DefaultSeed)
+	}
+	for i := range table {
+		// Knuth MMIX 同款 LCG 常数
+		state = state*6364136223846793005 + 1442695040888963407
+		table[i] = state
+	}
+	return table
+}
+
+// buzhashChunker 实现 Buzhash：滚动指纹是"循环左移 1 位再异或新字节的表值"，不像
+// rabinChunker 那样减去滑出窗口的旧字节。循环左移不会把旧字节的位移出去 (每 64 个字节
+// 转回原位)，所以指纹覆盖的是从 offset+Min 开始扫过的全部字节，而不是固定宽度的窗口。
+// 实现上比多项式 Rabin 更简单，吞吐量介于 Gear 和 Rabin 之间
+type buzhashChunker struct {
+	cfg          ChunkerConfig
+	maskS, maskL uint64
+	table        [256]uint64
+}
+
+func newBuzhashChunker(cfg ChunkerConfig) *buzhashChunker {
+	maskS, maskL := normMasks(cfg.Avg)
+	return &buzhashChunker{cfg: cfg, maskS: maskS, maskL: maskL, table: buzhashTable(cfg.Seed)}
+}
+
+func (c *buzhashChunker) Cut(data []byte) []int {
+	var cutPoints []int
+	offset := 0
+	n := len(data)
+	minSize, avg, maxSize := c.cfg.Min, c.cfg.Avg, c.cfg.Max
+
+	for offset < n {
+		if n-offset <= minSize {
+			return cutPoints
+		}
+
+		fp := uint64(0)
+		idx := offset + minSize
+
+		normLimit := min(offset+avg, n)
+		maxLimit := min(offset+maxSize, n)
+
+		scan := func(limit int, mask uint64) bool {
+			for ; idx < limit; idx++ {
+				fp = (fp<<1 | fp>>63) ^ c.table[data[idx]]
+				if (fp & mask) == 0 {
+					cutPoints = append(cutPoints, idx+1)
+					offset = idx + 1
+					return true
+				}
+			}
+			return false
+		}
+
+		if scan(normLimit, c.maskS) {
+			continue
+		}
+		if scan(maxLimit, c.maskL) {
+			continue
+		}
+
+		cutPoints = append(cutPoints, maxLimit)
+		offset = maxLimit
+	}
+
+	return cutPoints
+}
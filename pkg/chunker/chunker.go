@@ -1,84 +1,114 @@
+// Package chunker 实现内容定义分块 (Content-Defined Chunking)：把一段字节流切分成
+// 一系列边界随内容本身浮动、而不是固定偏移的分块，这样文件中间插入/删除几个字节后，
+// 受影响的只有局部的一两个块，后面大段没变的内容依然能算出完全相同的块边界，从而命中
+// 已有的去重存储。
+//
+// 不同数据类型（训练日志、权重矩阵、稀疏索引……）对"滚动指纹怎么算"这件事的最优解不一样，
+// 所以这里不是单一实现，而是一个 Chunker 接口 + 三种可插拔算法，由 ChunkerConfig.Algo
+// 在 config.yaml 里选定：
+//   - gear:    Gear-hash FastCDC（原来的唯一实现，吞吐量最高）
+//   - rabin:   Rabin-Karp 多项式滚动哈希（restic/bup 同款，边界对数据类型切换更稳）
+//   - buzhash: Buzhash，查找表按种子确定性生成，移位异或，不需要额外减去滑出窗口的字节
 package chunker
 
 import (
+	"fmt"
 	"math"
 )
 
-// 针对 AI 大文件场景的配置 (单位: 字节)
+// 出厂默认的分块参数 (单位: 字节)，对应 tv init 写进 config.yaml 的那组值，也是
+// ChunkerConfig 里对应字段留空 (零值) 时的退回值
 const (
 	MinSize   = 4 * 1024  // 4KB
 	AvgSize   = 8 * 1024  // 8KB (生产环境建议设为 2MB-4MB，测试环境用小一点方便观察)
 	MaxSize   = 64 * 1024 // 64KB
 	NormLevel = 2
-)
-
-// Chunker 是一个无状态的切分工具
-type Chunker struct {
-	maskS uint64
-	maskL uint64
-}
 
-func NewChunker() *Chunker {
-	// 预计算掩码 (和实验代码一致)
-	bits := int(math.Round(math.Log2(float64(AvgSize))))
-	return &Chunker{
-		maskS: uint64(1<<(bits+NormLevel)) - 1,
-		maskL: uint64(1<<(bits-NormLevel)) - 1,
-	}
-}
+	// DefaultPolynomial 是 AlgoRabin 在 cfg.Polynomial 留空时使用的不可约多项式，
+	// 取自 restic chunker 同款常数，实践检验过边界稳定性
+	DefaultPolynomial = 0x3DA3358B4DC173
 
-// Cut 将数据切分成一系列的切点。
-// 返回值:
-//   []int: 所有的 **完整块** 的结束 offset。不包含未处理完的尾部。
+	// DefaultSeed 是 AlgoBuzhash 生成查找表时、cfg.Seed 留空时使用的默认种子
+	DefaultSeed = 0x5EED
+)
 
-func (c *Chunker) Cut(data []byte) []int {
-	var cutPoints []int
-	offset := 0
-	n := len(data)
+// Algo 标识一种内容定义分块算法，持久化进 config.yaml 的 chunker.algo 字段
+type Algo string
 
-	for offset < n {
-		// 1. 剩余不足最小块，直接收尾
-		if n-offset <= MinSize {
-			return cutPoints
-		}
+const (
+	AlgoGear    Algo = "gear"
+	AlgoRabin   Algo = "rabin"
+	AlgoBuzhash Algo = "buzhash"
+)
 
-		// 2. 初始化状态
-		// 每次新块开始，fp 重置为 0
-		fp := uint64(0)
-		idx := offset + MinSize
+// Chunker 把一段字节流切分成一系列内容定义的分块边界。同一个 Chunker 对同一份 data
+// 反复调用 Cut 必须返回完全相同的切点——这是去重能生效的前提，ingester.Ingester 和
+// benchmark 都依赖这个性质
+type Chunker interface {
+	// Cut 返回 data 里所有 **完整块** 的结束 offset，不包含还没攒够 Min 字节的尾部
+	Cut(data []byte) []int
+}
 
-		// 确定边界
-		normLimit := min(offset+AvgSize, n)
-		maxLimit := min(offset+MaxSize, n)
+// ChunkerConfig 描述一个 Chunker 实例的算法选择和切分参数，对应 config.yaml 里的
+// chunker.* 字段。Min/Avg/Max 留 0 会退回 MinSize/AvgSize/MaxSize；Polynomial/Seed
+// 分别只对 AlgoRabin/AlgoBuzhash 有意义，留 0 也会退回各自的默认值
+type ChunkerConfig struct {
+	Algo       Algo
+	Min        int
+	Avg        int
+	Max        int
+	Polynomial uint64 // AlgoRabin 专用：滚动哈希的多项式基数
+	Seed       int64  // AlgoBuzhash 专用：查找表的生成种子
+}
 
-		// 定义扫描闭包 (DRY)
-		scan := func(limit int, mask uint64) bool {
-			for ; idx < limit; idx++ {
-				fp = (fp << 1) + gearTable[data[idx]]
-				// 判断掩码
-				if (fp & mask) == 0 {
-					cutPoints = append(cutPoints, idx+1)
-					offset = idx + 1
-					return true
-				}
-			}
-			return false
-		}
+// DefaultConfig 返回仓库出厂的分块参数：Gear-hash FastCDC + 默认 Min/Avg/Max。
+// tv init 生成的 config.yaml 和 NewChunker 对零值字段的解析都以它为准
+func DefaultConfig() ChunkerConfig {
+	return ChunkerConfig{Algo: AlgoGear, Min: MinSize, Avg: AvgSize, Max: MaxSize}
+}
 
-		// A. 归一化区域 (严掩码)
-		if scan(normLimit, c.maskS) {
-			continue
-		}
+// fillDefaults 把 cfg 里留空的字段填上默认值，三种算法的构造函数共用这一步，
+// 这样调用方（比如只想切换算法的 ingester）不需要每次都把 Min/Avg/Max 抄一遍
+func (cfg ChunkerConfig) fillDefaults() ChunkerConfig {
+	if cfg.Min <= 0 {
+		cfg.Min = MinSize
+	}
+	if cfg.Avg <= 0 {
+		cfg.Avg = AvgSize
+	}
+	if cfg.Max <= 0 {
+		cfg.Max = MaxSize
+	}
+	if cfg.Polynomial == 0 {
+		cfg.Polynomial = DefaultPolynomial
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = DefaultSeed
+	}
+	return cfg
+}
 
-		// B. 普通区域 (宽掩码)
-		if scan(maxLimit, c.maskL) {
-			continue
-		}
+// normMasks 根据 avg 算出 FastCDC 风格的严/宽两级掩码：归一化区间内用严掩码 (位数更多、
+// 更难命中，减少比 avg 小很多的块)，超出归一化区间后放宽掩码 (更容易命中) 继续找，直到
+// Max 强制切分。Gear、Buzhash、Rabin 三种实现都是"滚动指纹 & mask == 0 就切"的判定方式，
+// 共用这套掩码推导
+func normMasks(avg int) (maskS, maskL uint64) {
+	bits := int(math.Round(math.Log2(float64(avg))))
+	return uint64(1<<(bits+NormLevel)) - 1, uint64(1<<(bits-NormLevel)) - 1
+}
 
-		// C. 强制切分
-		cutPoints = append(cutPoints, maxLimit)
-		offset = maxLimit
+// NewChunker 按 cfg.Algo 构造对应的分块算法实现。cfg 留空的字段会退回默认值，
+// 调用方通常只需要指定 Algo（或者干脆传 DefaultConfig()）
+func NewChunker(cfg ChunkerConfig) (Chunker, error) {
+	cfg = cfg.fillDefaults()
+	switch cfg.Algo {
+	case "", AlgoGear:
+		return newGearChunker(cfg), nil
+	case AlgoRabin:
+		return newRabinChunker(cfg), nil
+	case AlgoBuzhash:
+		return newBuzhashChunker(cfg), nil
+	default:
+		return nil, fmt.Errorf("chunker: unknown algorithm %q", cfg.Algo)
 	}
-
-	return cutPoints
 }
@@ -0,0 +1,142 @@
+package chunker
+
+// gearChunker 是原来唯一实现的延续：维护一个 Gear-hash 滚动指纹，指纹低位跟掩码
+// 做与运算，为零就切一刀。分块判定逻辑本身没有变化，只是把原来散落在包级别的
+// MinSize/AvgSize/MaxSize 常量搬进了 cfg，让它能跟 rabinChunker/buzhashChunker
+// 使用同一套 ChunkerConfig 入口
+type gearChunker struct {
+	cfg          ChunkerConfig
+	maskS, maskL uint64
+}
+
+func newGearChunker(cfg ChunkerConfig) *gearChunker {
+	maskS, maskL := normMasks(cfg.Avg)
+	return &gearChunker{cfg: cfg, maskS: maskS, maskL: maskL}
+}
+
+// Cut 将数据切分成一系列的切点。
+// 返回值:
+//
+//	[]int: 所有的 **完整块** 的结束 offset。不包含未处理完的尾部。
+func (c *gearChunker) Cut(data []byte) []int {
+	var cutPoints []int
+	offset := 0
+	n := len(data)
+	minSize, avg, maxSize := c.cfg.Min, c.cfg.Avg, c.cfg.Max
+
+	for offset < n {
+		// 1. 剩余不足最小块，直接收尾
+		if n-offset <= minSize {
+			return cutPoints
+		}
+
+		// 2. 初始化状态
+		// 每次新块开始，fp 重置为 0
+		fp := uint64(0)
+		idx := offset + minSize
+
+		// 确定边界
+		normLimit := min(offset+avg, n)
+		maxLimit := min(offset+maxSize, n)
+
+		// 定义扫描闭包 (DRY)
+		scan := func(limit int, mask uint64) bool {
+			for ; idx < limit; idx++ {
+				fp = (fp << 1) + gearTable[data[idx]]
+				// 判断掩码
+				if (fp & mask) == 0 {
+					cutPoints = append(cutPoints, idx+1)
+					offset = idx + 1
+					return true
+				}
+			}
+			return false
+		}
+
+		// A. 归一化区域 (严掩码)
+		if scan(normLimit, c.maskS) {
+			continue
+		}
+
+		// B. 普通区域 (宽掩码)
+		if scan(maxLimit, c.maskL) {
+			continue
+		}
+
+		// C. 强制切分
+		cutPoints = append(cutPoints, maxLimit)
+		offset = maxLimit
+	}
+
+	return cutPoints
+}
+
+// gearTable 是 Gear-hash 用的 256 项查找表，每个字节值映射到一个固定的 64 位常量，
+// 滚动指纹用 (fp<<1)+gearTable[b] 的方式把新字节的影响叠加进去。表本身只要求
+// "看起来随机、分布均匀"，不需要密码学强度，所以是编译期常量而不是运行时生成
+var gearTable = [256]uint64{
+	0x7F6C280BEAA8E3E7, 0xE47119871CF9ABE0, 0x35174A4158B8A0B7, 0x62CE1FFAD85B1C36,
+	0xEC83972C97B6678E, 0x0CF91633BE7328C1, 0x101F5E859D7DDED0, 0x1FD897255030916D,
+	0x87944C6B12870B0F, 0x36CA1465C9B326D9, 0x34BC346CA79AD6D4, 0x34E846AB6E48D679,
+	0x9E2C31E94344F995, 0x6F44842FB582B526, 0x1ECB49BAAF7839CC, 0xBFC9E24F766F3ABF,
+	0x9BB024AEC20EAB0A, 0xF0362594A0F934DC, 0x453C9A34720471B5, 0x176ECBC97DE6B416,
+	0x58F14BD839CEBCFE, 0xC19903639183DE07, 0xD754009E3D61B87B, 0xC691944865EC05CB,
+	0xA678B4FB909FCF00, 0xA34D7A3FD891309E, 0x244DDED04F81F57F, 0x6FB49B16A3664955,
+	0x3AE6DED47F967087, 0xB3F7D04FC7A99DA6, 0xE0BAD7014FCF671D, 0x2D24EFD06F4C9E93,
+	0x0E44413209BBC36E, 0x0F64326E25E5AF68, 0xC245CF6E4944BE36, 0xD7CBF034A6AB7ACA,
+	0x54CEEAEBB71FDEBF, 0xFEE0039301D5AEC2, 0x71B289A50D5BF51F, 0x687BFA61A575E535,
+	0x55BCAE93409EE3BF, 0xF7F520AC3EA0D1B8, 0x9F2ACF8B28E8FE1B, 0xFCD02B48890BC927,
+	0x68700F83CD257775, 0x84C52CD3ACBA40DB, 0xEEF13D26A85C629A, 0x4F3DBF7307F93CDF,
+	0x094408770AEE1966, 0x70AB445A25F95CD4, 0x99D9C81AF2A51B6D, 0xE75EB9B4995D2A1B,
+	0xC59CFE06EF78768F, 0x6DB4FF7BB92EC5A2, 0x8D2285FDBC0BB0A9, 0xCC166F0D689AAD88,
+	0x5AC02F39F4F7FAD3, 0xE091D4F1C676C1E6, 0x3C75330A4BBC95E5, 0x3E3217ED49AE358E,
+	0x3F7C5DA6AACBCA65, 0x867D41AEE54264B1, 0x366D45337CF7EC38, 0xE607081CC1B20DE0,
+	0x351F3316F6F811FB, 0xFEFFD84F991EFF18, 0x8B88FBDA97BC04E6, 0x0924D46247D0856F,
+	0x09CD020658999FA5, 0x0DD051F08A0FE5DA, 0x3F81B4838D7BCC91, 0xC44EBCA6D3903F48,
+	0xB7CF29BAE7BDCD36, 0x59120CE9B2FF3B2C, 0x513856A025858E5A, 0x4E32E07812EA53C6,
+	0x21DBBDA67FE1B6E1, 0x0FBE57E12637EDCC, 0x2B4BDFB376177117, 0xC43A3C188F6FFA35,
+	0x3DE36A3C8BCB0881, 0x356370AE5CAE9ED0, 0xF75BA69917B077ED, 0xA8401B995FFB4C42,
+	0x0668A2392EABEA5A, 0xA3CCCE6D5D5B6B0E, 0xF46E1FB800EADE58, 0x6CC20EB52A5F9DE4,
+	0x281CCA0893EEDBDF, 0x77B427CD815411A8, 0xEB3A96076A71D38B, 0xA7F60AFEA778B2EC,
+	0x7D3FA92363557889, 0x6C8D4D7AFFACD038, 0x69FCA06B74508798, 0xA6F361A92744C097,
+	0x58C5B19A25848CD6, 0xDEAADA2C01E8704F, 0x8DAEDF598B20536F, 0x9D2A917FAA5D2809,
+	0x1363A0790770B019, 0xD48E2734D1237739, 0xC89D511D2195DF97, 0x73F002622683F1E8,
+	0x0F25462024198C0B, 0xA6E22741E815DDD3, 0xFF21A4661058E2A8, 0xB379908A24CFF96D,
+	0x8B1DFD10C7EB9DDF, 0x009A4457D570DD24, 0x7788E517D675F59E, 0xFC31FFC9A9FDB9F5,
+	0x7488BE9ECD729FC6, 0xC0602E9069454B79, 0x4BC624ABCEF43FAF, 0x79D2BCE81BB3DC10,
+	0x6FD1990223A1BFA8, 0x21D1CE34D5D216D7, 0xEC686E6A4452E73A, 0x393DDDA4406CCC74,
+	0x0D8953A19B8988EC, 0x13908D934A3B20FD, 0x401DADEC1580C9FC, 0x2A4E064EDA78376F,
+	0x4E256CE226AEFCC1, 0x56B177EEF434B178, 0x18C95585BEEB861A, 0x1125EEF550989796,
+	0xC97DAFB2889C8339, 0xAECA5CC8F234547F, 0x2C8F2C9EE264C317, 0x5AE974D780502F51,
+	0xB3331EB6C82F7B4F, 0xC93C8E2C6DFA1679, 0xBB60E342B1415C15, 0xEE463BECB82C7BED,
+	0x9E0811CE158B785A, 0xFCBAB833F421382A, 0xD49EC63EDD3630DA, 0x5307F9957F6D2A3B,
+	0xD4C56BE816C01EAF, 0x4A8FF39DDF9BD552, 0xD4694009948BF678, 0xB96B155D24B87F94,
+	0xBB244E916BCA6A6B, 0x2CCC62BBFE34047F, 0xF75523CAA32893B7, 0x0D0BF339709CCF50,
+	0x7AAB7DD8F93822CE, 0x914E470C408D210B, 0x781B2E49EC771989, 0x7228B551EAACB5FA,
+	0x7E6364C3D0C9D211, 0xC310565A94B4E5F5, 0xADC392F132E6517E, 0xC1ABC9B4A780025C,
+	0x76103AF604341558, 0xBEA4A8A031762B72, 0xB4401C335EB85BA4, 0x40BEC1C519414213,
+	0x45E6B8EAA3CF2457, 0xA54AD8DCFE754FDF, 0x349503DF1621B280, 0xEC7510BBB5FB51E5,
+	0x0B6F0E382A747E06, 0x5DBDCA9FE60BD77A, 0x3143A9889D755E54, 0xFA5EAAF73902A1E8,
+	0xB5C7CA877EB3DEAB, 0x5A3945C340C073D6, 0x2D65DFCF7545C6B1, 0x85BB0D1480F0C17C,
+	0xB9B0B5ED7212FFFE, 0xAD63E6F5B8B4E581, 0x869FEFD97A58CC0A, 0x69B4872F393A3F12,
+	0x7D331E83F1FDCFEC, 0x5224C75DAE764F73, 0x13B66ED87F0D1F2D, 0xA826E55973F76E53,
+	0xD50772B3399F744F, 0x54701ADAA476B967, 0x6614AFB10016EDD5, 0x675C3E82908B154E,
+	0x09D8DFC7F40E90E4, 0xD00D35B8C3D434C5, 0xC564DA15DA1E0DEC, 0x05B342BD227ACAF7,
+	0x3340109B5A9662A2, 0x8B4DD6E14821A6E7, 0x89C7B013CED0BC6A, 0xFB8ED784C5CB4792,
+	0x467B1F653D59759D, 0x0AA388258FA10036, 0x94146E5313948FB6, 0x799E32F4D7348B29,
+	0xEC3BECF87223087F, 0xC6757D6C0854B1AF, 0xF237EB257545930C, 0xC9405A526AFE5B2A,
+	0xC5C97693E0E02D1C, 0x93F8C988AE052A46, 0x143D7946787F7192, 0x802997E65283ABF1,
+	0x5DAA6069AA7E70E6, 0x269C4AD8C3A47587, 0x168AF7146CD6BCAC, 0x1C0FE610D39FBAD5,
+	0x2E3BA282C34C90E0, 0x00E222FAE47031C1, 0x4D241391084881E2, 0xF332CE7578862861,
+	0x98E774454E131C71, 0x72FB45B02FD40609, 0xAFEDAE5C22C10C45, 0xD6B270CE75753F1D,
+	0x4BA2CF7B7775B223, 0x67C4EFB189BDB187, 0x8DB0DCDFA5BA4B24, 0x6B770436D06376B2,
+	0xF1EBAA1672765CDF, 0xE88027ACC7D267A6, 0x45FD1849F3E2EAE9, 0x7BCA45BCAF1AB57C,
+	0x64E5A773F86A5F16, 0x4E37521152BF8E28, 0x8051CECED8547B34, 0xB324BAD6E2189EC2,
+	0x10872E1E64DD5F7F, 0x222FE21970AEDA01, 0xF4F970E6FD5327F5, 0x1374652FB96ADCFA,
+	0xFCA3FF4608B677C3, 0xD21567A9701A8BEC, 0x6C6F6372FED3C5FB, 0xFCA290112E007CB0,
+	0x4688F31023475049, 0x1D77532FE18EECA9, 0xE27C8A87F603FB30, 0x2A94204167FB30C6,
+	0xC68FCF6713AE3727, 0xE98C0A8875F24289, 0x14701D8E1940244C, 0xADD8FEFF3FFA3704,
+	0x9D07E4E37D3C826A, 0x19FC7504277721EE, 0x06606591FE96742D, 0xF72892105179F385,
+	0x7EBE6EA193934122, 0xA2D830EA82006F20, 0x715B5F9C9507A7FE, 0x23D1AED137599731,
+	0x28737C43E10AC85F, 0xABB00C80296F2A0F, 0x380966D3B880979B, 0x7B3AEDAE0DCF1074,
+}
@@ -0,0 +1,95 @@
+package chunker
+
+// rabinWindowSize 是 Rabin 滚动指纹的窗口宽度：指纹只由最近这么多个字节决定，
+// 滑出窗口的字节要被显式减去，这是它跟 Gear/Buzhash 两种"只叠加不撤销"的滚动哈希
+// 最大的区别，也是它在 restic/bup 里被认为边界更稳的原因——对数据类型切换点更敏感
+const rabinWindowSize = 64
+
+// rabinChunker 实现一个多项式滚动哈希：把窗口内的字节看成以 cfg.Polynomial 为基数的
+// 多项式系数，fp = Σ data[i] * base^(window-1-i)，新字节进窗口时乘一次 base 再加，
+// 旧字节出窗口时减去它乘 base^window 的贡献——这是经典 Rabin-Karp 滚动哈希的构造，
+// 只是这里把多项式运算放在 uint64 自然溢出的环里，而不是某个有限域上，换取实现简单
+type rabinChunker struct {
+	cfg        ChunkerConfig
+	maskS      uint64
+	maskL      uint64
+	base       uint64
+	baseWindow uint64 // base^rabinWindowSize，减去滑出窗口字节的贡献时要用到
+}
+
+func newRabinChunker(cfg ChunkerConfig) *rabinChunker {
+	maskS, maskL := normMasks(cfg.Avg)
+
+	baseWindow := uint64(1)
+	for i := 0; i < rabinWindowSize; i++ {
+		baseWindow *= cfg.Polynomial
+	}
+
+	return &rabinChunker{
+		cfg:        cfg,
+		maskS:      maskS,
+		maskL:      maskL,
+		base:       cfg.Polynomial,
+		baseWindow: baseWindow,
+	}
+}
+
+// Cut 跟 gearChunker.Cut 共享同一套"严掩码归一化区间 -> 宽掩码普通区间 -> Max 强制
+// 切分"结构，区别只在滚动指纹本身怎么算
+func (c *rabinChunker) Cut(data []byte) []int {
+	var cutPoints []int
+	offset := 0
+	n := len(data)
+	minSize, avg, maxSize := c.cfg.Min, c.cfg.Avg, c.cfg.Max
+
+	for offset < n {
+		if n-offset <= minSize {
+			return cutPoints
+		}
+
+		idx := offset + minSize
+
+		// 用窗口内 (最多 rabinWindowSize 字节) 的内容重新算一次起始指纹；如果这段
+		// 历史不够窗口长 (idx 还没到 rabinWindowSize)，就用从 0 开始的全部字节，
+		// 后续滚动时 outgoing 按 0 处理，等窗口真正走满再开始减
+		var fp uint64
+		winStart := idx - rabinWindowSize
+		if winStart < 0 {
+			winStart = 0
+		}
+		for i := winStart; i < idx; i++ {
+			fp = fp*c.base + uint64(data[i])
+		}
+
+		normLimit := min(offset+avg, n)
+		maxLimit := min(offset+maxSize, n)
+
+		scan := func(limit int, mask uint64) bool {
+			for ; idx < limit; idx++ {
+				var outgoing uint64
+				if idx >= rabinWindowSize {
+					outgoing = uint64(data[idx-rabinWindowSize])
+				}
+				fp = fp*c.base + uint64(data[idx]) - outgoing*c.baseWindow
+				if (fp & mask) == 0 {
+					cutPoints = append(cutPoints, idx+1)
+					offset = idx + 1
+					return true
+				}
+			}
+			return false
+		}
+
+		if scan(normLimit, c.maskS) {
+			continue
+		}
+		if scan(maxLimit, c.maskL) {
+			continue
+		}
+
+		cutPoints = append(cutPoints, maxLimit)
+		offset = maxLimit
+	}
+
+	return cutPoints
+}
@@ -0,0 +1,63 @@
+package client
+
+import "time"
+
+// Backoff 控制一次失败之后要不要重试、重试前睡多久。Next 返回 false 说明重试预算已经
+// 耗尽，调用方应该放弃并把最后一次的错误往上抛；Reset 在一次操作重新跑通之后调用，
+// 清空计数器，好让下一次失败重新从第一档退避算起，而不是延续上一轮失败攒下的计数
+type Backoff interface {
+	// Next 阻塞当前 goroutine 一个退避间隔后返回 true；预算耗尽时不睡，直接返回 false
+	Next() bool
+	// Reset 清空内部的重试计数
+	Reset()
+}
+
+// ConstantBackoff 每次都固定睡眠 Sleep，最多重试 Max 次
+type ConstantBackoff struct {
+	Sleep time.Duration
+	Max   int
+
+	attempts int
+}
+
+func (b *ConstantBackoff) Next() bool {
+	if b.attempts >= b.Max {
+		return false
+	}
+	b.attempts++
+	time.Sleep(b.Sleep)
+	return true
+}
+
+func (b *ConstantBackoff) Reset() {
+	b.attempts = 0
+}
+
+// ExponentialBackoff 第 n 次重试睡眠 min(Base*2^(n-1), Cap)，最多重试 Max 次
+type ExponentialBackoff struct {
+	Base time.Duration
+	Cap  time.Duration
+	Max  int
+
+	attempts int
+}
+
+func (b *ExponentialBackoff) Next() bool {
+	if b.attempts >= b.Max {
+		return false
+	}
+
+	wait := b.Base << b.attempts
+	if wait <= 0 || wait > b.Cap {
+		// wait<=0 既覆盖了 Cap<=0 没配置的情况，也覆盖了左移溢出的情况
+		wait = b.Cap
+	}
+
+	b.attempts++
+	time.Sleep(wait)
+	return true
+}
+
+func (b *ExponentialBackoff) Reset() {
+	b.attempts = 0
+}
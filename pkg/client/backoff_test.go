@@ -0,0 +1,37 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConstantBackoff_ExhaustsAfterMax(t *testing.T) {
+	b := &ConstantBackoff{Sleep: time.Millisecond, Max: 3}
+
+	assert.True(t, b.Next())
+	assert.True(t, b.Next())
+	assert.True(t, b.Next())
+	assert.False(t, b.Next(), "第 4 次应该已经耗尽重试预算")
+
+	b.Reset()
+	assert.True(t, b.Next(), "Reset 之后应该能重新开始计数")
+}
+
+func TestExponentialBackoff_CapsWait(t *testing.T) {
+	b := &ExponentialBackoff{Base: time.Millisecond, Cap: 4 * time.Millisecond, Max: 10}
+
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		assert.True(t, b.Next(), "attempt %d should still be within budget", i)
+	}
+	// 截到 Cap 后 10 次总共睡 1+2+4*8 = 35ms；不截断的话是 1+2+...+512 = 1023ms
+	assert.Less(t, time.Since(start), 500*time.Millisecond, "waits should be capped at Cap")
+	assert.False(t, b.Next())
+}
+
+func TestExponentialBackoff_ZeroMax(t *testing.T) {
+	b := &ExponentialBackoff{Base: time.Millisecond, Cap: time.Millisecond, Max: 0}
+	assert.False(t, b.Next(), "Max=0 意味着一次都不重试")
+}
@@ -0,0 +1,137 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// DefaultChunkSize 是 ChunkGroup 未显式指定大小时用来切分文件的固定块大小：4MiB 是
+// "一次重传成本" 和 "每块协议开销" 之间的折中——块太大，一次网络抖动要重发的数据就越多；
+// 块太小，Frame 数量、sha256 计算次数都线性增长
+const DefaultChunkSize int64 = 4 * 1024 * 1024
+
+// ChunkGroup 描述了一次分块上传的切分计划和进度：把一个文件按 ChunkSize 切成定长块，
+// 每块作为一帧 ChunkData 发给服务端，不附带 UploadID、序号或逐块 sha256。进度以 chunk 粒度的位图持久化
+// 在本地磁盘的 .tv/uploads/<UploadID>.json，崩溃或网络中断后可以重新加载出上次的切分计划。
+//
+// 受限于现有 Upload RPC 的形状（client-streaming，只在 CloseAndRecv 时给一次性响应，服务端
+// 的 ingester 是对着完整字节流连续计算 CDC 切分点和全量 sha256 的，中途没有任何可恢复的
+// 部分提交状态）：一次 Upload 流只要有一帧发送失败，这个流本身就已经不可用，而新开的流只能
+// 从第一个字节重新发送——不存在"服务端确认过某个 chunk 就可以跳过它"这件事，除非 Upload
+// 变成双向流、并在每个 ChunkData 帧之后插一帧逐块 ack。那需要改 tvrpc 的 proto 定义，但
+// pkg/api/tvrpc/v1（生成的 client/server 代码）本身并不在这个仓库里，没法在这里扩展或重新
+// 生成，是一个跟这次改动无关、早就存在的环境缺口。
+//
+// 所以这里的位图准确地说跟踪的是"当前这次尝试里已经发送过的 chunk"，而不是"服务端已经
+// ack 过的 chunk"：每次重新发起 Upload 流之前都会 Reset，不会跨尝试复用。它的价值在于：
+// (1) 提供 request 要求的 (uploadID, acked bitmap) 持久化格式和落盘路径；
+// (2) 一旦 Upload RPC 将来真的加上了逐块 ack，调用方只需要把 Reset 去掉，resume 就能直接
+// 生效，不需要再改这个类型本身。
+// 另外，chunk 粒度的字节续传省不掉，不代表存储层面的开销也省不掉：ingester 的 Bloom
+// Filter + Has 去重对重复字节是幂等的，重发同一段内容不会在 Store 里产生重复对象。
+type ChunkGroup struct {
+	UploadID  string `json:"upload_id"`
+	Size      int64  `json:"size"`
+	ChunkSize int64  `json:"chunk_size"`
+	NumChunks int    `json:"num_chunks"`
+	Acked     []byte `json:"acked"` // 位图，每个 chunk 一个 bit，1 = 本次尝试里已经发送过
+
+	statePath string
+}
+
+// NewChunkGroup 按文件大小和块大小规划出一次分块上传。size==0 时仍然规划出恰好一个
+// (零长度的) chunk，确保上传流程至少会发出一帧 ChunkData，触发服务端的收尾逻辑
+func NewChunkGroup(uploadID string, size, chunkSize int64) *ChunkGroup {
+	numChunks := 1
+	if size > 0 {
+		numChunks = int((size + chunkSize - 1) / chunkSize)
+	}
+	return &ChunkGroup{
+		UploadID:  uploadID,
+		Size:      size,
+		ChunkSize: chunkSize,
+		NumChunks: numChunks,
+		Acked:     make([]byte, (numChunks+7)/8),
+	}
+}
+
+// LoadOrNewChunkGroup 从 <repoPath>/uploads/<uploadID>.json 加载一次上传的切分计划；文件
+// 不存在，或者存在但 Size/ChunkSize 跟这次规划的对不上（比如本地文件在两次 push 之间被
+// 改过），就丢弃旧状态，按当前文件重新规划一份
+func LoadOrNewChunkGroup(repoPath, uploadID string, size, chunkSize int64) (*ChunkGroup, error) {
+	statePath := chunkGroupStatePath(repoPath, uploadID)
+
+	data, err := os.ReadFile(statePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read upload state: %w", err)
+		}
+		group := NewChunkGroup(uploadID, size, chunkSize)
+		group.statePath = statePath
+		return group, nil
+	}
+
+	var group ChunkGroup
+	if err := json.Unmarshal(data, &group); err != nil {
+		return nil, fmt.Errorf("corrupted upload state %s: %w", statePath, err)
+	}
+	group.statePath = statePath
+
+	if group.Size != size || group.ChunkSize != chunkSize {
+		fresh := NewChunkGroup(uploadID, size, chunkSize)
+		fresh.statePath = statePath
+		return fresh, nil
+	}
+	return &group, nil
+}
+
+func chunkGroupStatePath(repoPath, uploadID string) string {
+	return filepath.Join(repoPath, "uploads", uploadID+".json")
+}
+
+// IsAcked 返回第 i 个 chunk 在当前尝试里是否已经发送过
+func (g *ChunkGroup) IsAcked(i int) bool {
+	return i/8 < len(g.Acked) && g.Acked[i/8]&(1<<uint(i%8)) != 0
+}
+
+// MarkAcked 把第 i 个 chunk 标记为已发送，并立刻把整个状态刷盘
+func (g *ChunkGroup) MarkAcked(i int) error {
+	if i/8 < len(g.Acked) {
+		g.Acked[i/8] |= 1 << uint(i%8)
+	}
+	return g.save()
+}
+
+// Reset 把位图清零，用于重新发起一次 Upload 流之前——上一个流已经不可用，服务端没有
+// 真正确认过任何一个 chunk，只能从头重发
+func (g *ChunkGroup) Reset() {
+	g.Acked = make([]byte, (g.NumChunks+7)/8)
+}
+
+// Discard 在整次上传成功 (CloseAndRecv 拿到最终 Hash) 之后删除本地状态文件：留着它只会
+// 让下一次对同一份内容的 push 误判成"还有未完成的上传"
+func (g *ChunkGroup) Discard() error {
+	if g.statePath == "" {
+		return nil
+	}
+	if err := os.Remove(g.statePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (g *ChunkGroup) save() error {
+	if g.statePath == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(g.statePath), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(g.statePath, data, 0644)
+}
@@ -0,0 +1,74 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewChunkGroup_EmptyFileStillHasOneChunk(t *testing.T) {
+	group := NewChunkGroup("up1", 0, DefaultChunkSize)
+	assert.Equal(t, 1, group.NumChunks)
+	assert.False(t, group.IsAcked(0))
+}
+
+func TestNewChunkGroup_ChunkCountRounding(t *testing.T) {
+	group := NewChunkGroup("up1", 10, 4)
+	assert.Equal(t, 3, group.NumChunks) // 4 + 4 + 2
+}
+
+func TestChunkGroup_MarkAckedPersistsAndReloads(t *testing.T) {
+	repoPath := t.TempDir()
+
+	group, err := LoadOrNewChunkGroup(repoPath, "up1", 10, 4)
+	require.NoError(t, err)
+	require.NoError(t, group.MarkAcked(0))
+	require.NoError(t, group.MarkAcked(1))
+	assert.True(t, group.IsAcked(0))
+	assert.True(t, group.IsAcked(1))
+	assert.False(t, group.IsAcked(2))
+
+	reloaded, err := LoadOrNewChunkGroup(repoPath, "up1", 10, 4)
+	require.NoError(t, err)
+	assert.True(t, reloaded.IsAcked(0))
+	assert.True(t, reloaded.IsAcked(1))
+	assert.False(t, reloaded.IsAcked(2))
+}
+
+func TestChunkGroup_SizeMismatchDiscardsOldState(t *testing.T) {
+	repoPath := t.TempDir()
+
+	group, err := LoadOrNewChunkGroup(repoPath, "up1", 10, 4)
+	require.NoError(t, err)
+	require.NoError(t, group.MarkAcked(0))
+
+	// 文件大小变了（比如两次 push 之间内容被改动过），旧进度不应该被继续信任
+	reloaded, err := LoadOrNewChunkGroup(repoPath, "up1", 20, 4)
+	require.NoError(t, err)
+	assert.False(t, reloaded.IsAcked(0))
+	assert.Equal(t, 5, reloaded.NumChunks)
+}
+
+func TestChunkGroup_ResetClearsBitmap(t *testing.T) {
+	group := NewChunkGroup("up1", 10, 4)
+	require.NoError(t, group.MarkAcked(0))
+	assert.True(t, group.IsAcked(0))
+
+	group.Reset()
+	assert.False(t, group.IsAcked(0))
+}
+
+func TestChunkGroup_DiscardRemovesStateFile(t *testing.T) {
+	repoPath := t.TempDir()
+
+	group, err := LoadOrNewChunkGroup(repoPath, "up1", 10, 4)
+	require.NoError(t, err)
+	require.NoError(t, group.MarkAcked(0))
+	require.NoError(t, group.Discard())
+
+	// 状态文件已经删掉了，再加载应该是一份全新的、没有任何 ack 的计划
+	reloaded, err := LoadOrNewChunkGroup(repoPath, "up1", 10, 4)
+	require.NoError(t, err)
+	assert.False(t, reloaded.IsAcked(0))
+}
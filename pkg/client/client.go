@@ -1,28 +1,58 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"time"
 
 	tvrpc "tensorvault/pkg/api/tvrpc/v1"
 
+	"go.opentelemetry.io/otel"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/metadata"
 )
 
 // TVClient 封装了与 TensorVault 服务端的连接
 type TVClient struct {
 	conn *grpc.ClientConn
+	addr string
+
+	// closeFn 非 nil 时 Close() 改调用它而不是 conn.Close()——discovery 驱动的故障转移
+	// 客户端 (NewFailoverTVClient) 背后是一组按候选地址缓存的连接，没有唯一的 conn 可关，
+	// 关闭逻辑要挨个关掉整组连接，所以借这个字段把 Close 行为委托出去
+	closeFn func() error
 
 	// 公开具体的 Service Client
 	Data tvrpc.DataServiceClient
 	Meta tvrpc.MetaServiceClient
+	Peer tvrpc.PeerServiceClient
 }
 
+// defaultStreamWindowSize 是单个 gRPC 流的 HTTP/2 流控窗口基准值，WithConcurrency 按并发数
+// 等比放大它。concurrency==1（也就是 NewTVClient 的默认路径）完全不设置这两个 DialOption，
+// 沿用 grpc-go 自己的默认值，保证不传 --parallel 时连接行为跟以前完全一样
+const defaultStreamWindowSize = 64 * 1024
+
 // NewTVClient 创建并初始化客户端
 // 注意：这里不再需要 context，因为它只负责创建对象，不负责等待连接就绪
 func NewTVClient(addr string) (*TVClient, error) {
+	return newTVClient(addr, 1)
+}
+
+// WithConcurrency 用放大过的 HTTP/2 流控窗口重新拨号，返回一个新的 *TVClient，专供需要在
+// 同一条连接上并发开多个 Upload 流的场景（比如 tv push --parallel）使用：流控窗口如果还是
+// 单流时代的默认值，多个流会平分同一个小窗口，谁都发不快，等比放大窗口让并发数提升时吞吐
+// 跟着提升。原连接不受影响，调用方需要自己 Close 掉旧的
+func (c *TVClient) WithConcurrency(n int) (*TVClient, error) {
+	if n < 1 {
+		n = 1
+	}
+	return newTVClient(c.addr, n)
+}
+
+func newTVClient(addr string, concurrency int) (*TVClient, error) {
 	// 配置选项
 	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
@@ -36,6 +66,21 @@ func NewTVClient(addr string) (*TVClient, error) {
 			Timeout:             20 * time.Second,
 			PermitWithoutStream: true,
 		}),
+		// trace context 传播：把调用方 ctx 里已有的 span（如果有的话）注入到出站 metadata，
+		// 服务端的 server.UnaryTracingInterceptor/StreamTracingInterceptor 会提取回来，
+		// 让服务端产生的子 span 挂在同一条 trace 下面。调用方没开 tracing 的话，ctx 里没有
+		// span，Inject 相当于空操作，不影响原有调用方式
+		grpc.WithChainUnaryInterceptor(unaryClientTraceInterceptor),
+		grpc.WithChainStreamInterceptor(streamClientTraceInterceptor),
+	}
+
+	if concurrency > 1 {
+		streamWindow := int32(defaultStreamWindowSize) * int32(concurrency)
+		connWindow := streamWindow * int32(concurrency)
+		opts = append(opts,
+			grpc.WithInitialWindowSize(streamWindow),
+			grpc.WithInitialConnWindowSize(connWindow),
+		)
 	}
 
 	// [核心变更] 使用 NewClient 替代 DialContext
@@ -49,15 +94,65 @@ func NewTVClient(addr string) (*TVClient, error) {
 
 	return &TVClient{
 		conn: conn,
+		addr: addr,
 		Data: tvrpc.NewDataServiceClient(conn),
 		Meta: tvrpc.NewMetaServiceClient(conn),
+		Peer: tvrpc.NewPeerServiceClient(conn),
 	}, nil
 }
 
 // Close 关闭底层连接
 func (c *TVClient) Close() error {
+	if c.closeFn != nil {
+		return c.closeFn()
+	}
 	if c.conn != nil {
 		return c.conn.Close()
 	}
 	return nil
 }
+
+// outgoingMetadataCarrier 把出站 gRPC metadata 适配成 otel propagation.TextMapCarrier，
+// 供 unaryClientTraceInterceptor/streamClientTraceInterceptor 往里面写 W3C traceparent
+type outgoingMetadataCarrier metadata.MD
+
+func (c outgoingMetadataCarrier) Get(key string) string {
+	vals := metadata.MD(c).Get(key)
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+func (c outgoingMetadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c outgoingMetadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// injectTraceContext 把 ctx 里当前的 span（如果有）编码进一份新的出站 metadata，合并进
+// ctx 原有的 outgoing metadata（如果调用方已经自己设置过的话）
+func injectTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
+	} else {
+		md = md.Copy()
+	}
+	otel.GetTextMapPropagator().Inject(ctx, outgoingMetadataCarrier(md))
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func unaryClientTraceInterceptor(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(injectTraceContext(ctx), method, req, reply, cc, opts...)
+}
+
+func streamClientTraceInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(injectTraceContext(ctx), desc, cc, method, opts...)
+}
@@ -0,0 +1,45 @@
+// Package discovery 把"给定一份配置，找出当前有哪些候选服务端地址"这件事收窄成一个小
+// 接口，跟 pkg/storage/s3.KeyProvider、pkg/chunker.Chunker 是同一类"按配置挑一个具体
+// 实现"的套路：remote.discovery.factory 选中三种内置 Provider 之一（static/dns/srv），
+// remote.discovery.<name>.servers 给它喂候选列表，GetRemoteClient 在 remote.server 没配
+// 的时候用这里解析出来的地址列表去构造一个带故障转移的 *client.TVClient
+package discovery
+
+import (
+	"context"
+	"fmt"
+)
+
+// Provider 返回当前这一刻的候选服务端地址（"host:port" 形式），每次调用都可能返回不同的
+// 结果——DNS/SRV 记录会变，调用方（client.NewFailoverTVClient）应该按需重新 Resolve，
+// 不要只在进程启动时解析一次就缓存到天荒地老
+type Provider interface {
+	Resolve(ctx context.Context) ([]string, error)
+}
+
+// Config 是构造一个 Provider 需要的全部参数；目前只有 Servers 一个字段，三种内置 Provider
+// 对它的解读不一样（static 原样返回，dns/srv 把每一项当成待解析的主机名/SRV 记录名）
+type Config struct {
+	// Servers 对应 viper 里的 remote.discovery.<name>.servers
+	Servers []string
+}
+
+// Factory 按 Config 构造一个 Provider
+type Factory func(cfg Config) (Provider, error)
+
+// factories 是内置的三种 Provider；跟 pkg/storage 的 backend 注册表不同，这里不提供运行时
+// Register 入口——三种就是 Viper 示例里列的全部，没有"调用方想插入第四种"的需求，保持简单
+var factories = map[string]Factory{
+	"static": newStaticProvider,
+	"dns":    newDNSProvider,
+	"srv":    newSRVProvider,
+}
+
+// New 按名字构造一个 Provider，对应 remote.discovery.factory 的取值
+func New(name string, cfg Config) (Provider, error) {
+	factory, ok := factories[name]
+	if !ok {
+		return nil, fmt.Errorf("discovery: unknown factory %q (want static, dns or srv)", name)
+	}
+	return factory(cfg)
+}
@@ -0,0 +1,28 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew_UnknownFactory(t *testing.T) {
+	_, err := New("consul", Config{Servers: []string{"a:1"}})
+	assert.Error(t, err)
+}
+
+func TestNew_Static(t *testing.T) {
+	p, err := New("static", Config{Servers: []string{"10.0.0.1:8080", "10.0.0.2:8080"}})
+	require.NoError(t, err)
+
+	addrs, err := p.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:8080", "10.0.0.2:8080"}, addrs)
+}
+
+func TestNew_StaticRequiresServers(t *testing.T) {
+	_, err := New("static", Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+// lookupHost 是 net.DefaultResolver.LookupHost 的一个可替换的间接层，纯粹为了让
+// dnsProvider 能在不碰真实 DNS 的情况下被单元测试覆盖——跟
+// pkg/storage/clusterstore.PeerDialer 把 *cluster.PeerPool 收窄成一个接口方便换假实现
+// 是同一个理由
+var lookupHost = net.DefaultResolver.LookupHost
+
+// dnsProvider 把每一条 "host:port" 形式的配置项按 host 部分做一次 A/AAAA 查询，展开成
+// 该主机名当前解析到的全部 IP，各自配上原来的 port——典型场景是 host 本身就是一个
+// headless service/round-robin DNS 名字，背后挂着多个 Pod/实例
+type dnsProvider struct {
+	entries []string
+}
+
+func newDNSProvider(cfg Config) (Provider, error) {
+	if len(cfg.Servers) == 0 {
+		return nil, fmt.Errorf("discovery: dns provider requires remote.discovery.dns.servers")
+	}
+	for _, entry := range cfg.Servers {
+		if _, _, err := net.SplitHostPort(entry); err != nil {
+			return nil, fmt.Errorf("discovery: dns provider entry %q must be \"host:port\": %w", entry, err)
+		}
+	}
+	return dnsProvider{entries: cfg.Servers}, nil
+}
+
+func (p dnsProvider) Resolve(ctx context.Context) ([]string, error) {
+	var addrs []string
+	for _, entry := range p.entries {
+		host, port, _ := net.SplitHostPort(entry) // 已经在构造时校验过，这里不会再出错
+		ips, err := lookupHost(ctx, host)
+		if err != nil {
+			return nil, fmt.Errorf("discovery: failed to resolve %s: %w", host, err)
+		}
+		for _, ip := range ips {
+			addrs = append(addrs, net.JoinHostPort(ip, port))
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("discovery: dns provider resolved zero addresses from %v", p.entries)
+	}
+	return addrs, nil
+}
@@ -0,0 +1,50 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDNSProvider_ExpandsEachHostToAllIPs(t *testing.T) {
+	orig := lookupHost
+	defer func() { lookupHost = orig }()
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		switch host {
+		case "tv-a.internal":
+			return []string{"10.0.0.1", "10.0.0.2"}, nil
+		case "tv-b.internal":
+			return []string{"10.0.1.1"}, nil
+		}
+		return nil, &net.DNSError{Err: "no such host", Name: host}
+	}
+
+	p, err := New("dns", Config{Servers: []string{"tv-a.internal:8080", "tv-b.internal:9090"}})
+	require.NoError(t, err)
+
+	addrs, err := p.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"10.0.0.1:8080", "10.0.0.2:8080", "10.0.1.1:9090"}, addrs)
+}
+
+func TestDNSProvider_RejectsEntryWithoutPort(t *testing.T) {
+	_, err := New("dns", Config{Servers: []string{"tv-a.internal"}})
+	assert.Error(t, err)
+}
+
+func TestDNSProvider_PropagatesLookupFailure(t *testing.T) {
+	orig := lookupHost
+	defer func() { lookupHost = orig }()
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: host}
+	}
+
+	p, err := New("dns", Config{Servers: []string{"missing.internal:8080"}})
+	require.NoError(t, err)
+
+	_, err = p.Resolve(context.Background())
+	assert.Error(t, err)
+}
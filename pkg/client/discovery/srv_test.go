@@ -0,0 +1,43 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSRVProvider_ResolvesTargetsAndStripsTrailingDot(t *testing.T) {
+	orig := lookupSRV
+	defer func() { lookupSRV = orig }()
+	lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		assert.Equal(t, "_tv._tcp.example.com", name)
+		return "", []*net.SRV{
+			{Target: "tv-1.example.com.", Port: 8080},
+			{Target: "tv-2.example.com.", Port: 8080},
+		}, nil
+	}
+
+	p, err := New("srv", Config{Servers: []string{"_tv._tcp.example.com"}})
+	require.NoError(t, err)
+
+	addrs, err := p.Resolve(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []string{"tv-1.example.com:8080", "tv-2.example.com:8080"}, addrs)
+}
+
+func TestSRVProvider_PropagatesLookupFailure(t *testing.T) {
+	orig := lookupSRV
+	defer func() { lookupSRV = orig }()
+	lookupSRV = func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+		return "", nil, &net.DNSError{Err: "no such host", Name: name}
+	}
+
+	p, err := New("srv", Config{Servers: []string{"_tv._tcp.example.com"}})
+	require.NoError(t, err)
+
+	_, err = p.Resolve(context.Background())
+	assert.Error(t, err)
+}
@@ -0,0 +1,23 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+)
+
+// staticProvider 原样返回配置里写死的地址列表，用于固定已知少数几台服务端、不想依赖
+// DNS/SRV 基础设施的部署
+type staticProvider struct {
+	servers []string
+}
+
+func newStaticProvider(cfg Config) (Provider, error) {
+	if len(cfg.Servers) == 0 {
+		return nil, fmt.Errorf("discovery: static provider requires remote.discovery.static.servers")
+	}
+	return staticProvider{servers: cfg.Servers}, nil
+}
+
+func (p staticProvider) Resolve(ctx context.Context) ([]string, error) {
+	return p.servers, nil
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	tvrpc "tensorvault/pkg/api/tvrpc/v1"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// healthCheckTimeout 是 NewFailoverTVClient 挑选"先用哪个候选地址"时，对每个候选做一次
+// TCP 可达性探测的超时。故障转移组里剩下的那些候选不会提前探测——只有在当前用的那个
+// 返回瞬时错误时才会尝试下一个，不想为了"万一用不上"的候选预先打一堆探测流量
+const healthCheckTimeout = 2 * time.Second
+
+// failoverGroup 实现 grpc.ClientConnInterface，而不是 *grpc.ClientConn 本身：tvrpc 生成的
+// NewXServiceClient 构造函数按 grpc-go 的惯例接收的就是这个接口，不是具体类型，所以可以
+// 把一整组候选连接伪装成"一个 grpc.ClientConn"塞给它们，三个 Service Client
+// （Data/Meta/Peer）背后其实是同一个 failoverGroup，共享同一份候选地址表和"当前用哪个"
+// 的游标
+type failoverGroup struct {
+	mu         sync.Mutex
+	candidates []string
+	current    int
+	conns      map[int]*grpc.ClientConn
+	dialOpts   []grpc.DialOption
+}
+
+// NewFailoverTVClient 用一组候选地址构造一个带故障转移的 *TVClient：先对每个候选做一次
+// TCP 健康检查，选第一个能连通的作为起点；之后每次 RPC 如果遇到 codes.Unavailable
+// 这类瞬时错误，就按顺序换到下一个候选重试，直到有一个成功或者全部候选都试过。
+// GetRemoteClient 在 remote.server 为空、走 discovery.Provider 解析出候选列表时用这个
+// 替代 NewTVClient
+func NewFailoverTVClient(candidates []string) (*TVClient, error) {
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("client: no discovered endpoints to connect to")
+	}
+
+	group := &failoverGroup{
+		candidates: candidates,
+		conns:      make(map[int]*grpc.ClientConn),
+		dialOpts: []grpc.DialOption{
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+			grpc.WithDefaultCallOptions(
+				grpc.MaxCallRecvMsgSize(1024*1024*1024),
+				grpc.MaxCallSendMsgSize(1024*1024*1024),
+			),
+		},
+	}
+
+	idx, err := group.firstHealthy()
+	if err != nil {
+		return nil, err
+	}
+	group.current = idx
+
+	return &TVClient{
+		addr:    candidates[idx],
+		closeFn: group.closeAll,
+		Data:    tvrpc.NewDataServiceClient(group),
+		Meta:    tvrpc.NewMetaServiceClient(group),
+		Peer:    tvrpc.NewPeerServiceClient(group),
+	}, nil
+}
+
+// firstHealthy 按顺序对每个候选做一次 TCP 拨号探测，返回第一个能连通的下标；一个都连不上
+// 就直接失败——总比留到第一次真正的 RPC 调用才发现全军覆没要早
+func (g *failoverGroup) firstHealthy() (int, error) {
+	var lastErr error
+	for i, addr := range g.candidates {
+		conn, err := net.DialTimeout("tcp", addr, healthCheckTimeout)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.Close()
+		return i, nil
+	}
+	return 0, fmt.Errorf("client: none of %d discovered endpoints are reachable, last error: %w", len(g.candidates), lastErr)
+}
+
+// connFor 惰性拨号并缓存候选地址 idx 对应的连接
+func (g *failoverGroup) connFor(idx int) (*grpc.ClientConn, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if conn, ok := g.conns[idx]; ok {
+		return conn, nil
+	}
+	conn, err := grpc.NewClient(g.candidates[idx], g.dialOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("client: failed to create grpc client for %s: %w", g.candidates[idx], err)
+	}
+	g.conns[idx] = conn
+	return conn, nil
+}
+
+// isTransient 判断一个 RPC 错误是不是"换个候选地址重试可能会成功"的那一类——只认
+// Unavailable（服务没起来/连接被拒/网络分区），其它错误码（比如 InvalidArgument、
+// NotFound）换哪个候选都一样会失败，重试只会多打一轮没意义的流量
+func isTransient(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// withFailover 从当前候选开始尝试 call，遇到瞬时错误就按顺序换下一个候选重试，直到
+// 成功、遇到非瞬时错误、或者全部候选都试过一遍
+func (g *failoverGroup) withFailover(call func(cc *grpc.ClientConn) error) error {
+	g.mu.Lock()
+	start := g.current
+	g.mu.Unlock()
+
+	var lastErr error
+	for i := 0; i < len(g.candidates); i++ {
+		idx := (start + i) % len(g.candidates)
+		conn, err := g.connFor(idx)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		err = call(conn)
+		if err == nil {
+			g.mu.Lock()
+			g.current = idx
+			g.mu.Unlock()
+			return nil
+		}
+		if !isTransient(err) {
+			return err
+		}
+		lastErr = err
+	}
+	return fmt.Errorf("client: all %d discovered endpoints failed, last error: %w", len(g.candidates), lastErr)
+}
+
+func (g *failoverGroup) Invoke(ctx context.Context, method string, args, reply any, opts ...grpc.CallOption) error {
+	return g.withFailover(func(cc *grpc.ClientConn) error {
+		return cc.Invoke(ctx, method, args, reply, opts...)
+	})
+}
+
+func (g *failoverGroup) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	var stream grpc.ClientStream
+	err := g.withFailover(func(cc *grpc.ClientConn) error {
+		s, err := cc.NewStream(ctx, desc, method, opts...)
+		if err != nil {
+			return err
+		}
+		stream = s
+		return nil
+	})
+	return stream, err
+}
+
+func (g *failoverGroup) closeAll() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	var firstErr error
+	for _, conn := range g.conns {
+		if err := conn.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
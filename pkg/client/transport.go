@@ -0,0 +1,76 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/remote"
+	"tensorvault/pkg/types"
+)
+
+// RemoteTransport 把"跟一个远端仓库打交道"收窄成六个动作：存/取/查单个对象，推/拉引用表，
+// 关闭连接。GetRemoteClient 过去只认 gRPC（*TVClient 直接暴露生成的 Data/Meta/Peer
+// 客户端），这个接口是特意叠加上去的一层更窄的抽象，给那些只关心"对象内容 + 引用"、不需要
+// Data/Meta 服务那些更丰富的 RPC（Commit/BuildTree/Blame……）的调用方用——典型场景就是
+// tv push/tv fetch 想要"推 S3/MinIO 或者走一段 HTTPS"而不是必须先起一个 tvrpc gRPC
+// 服务端。已有命令该怎么用 *TVClient 还怎么用，这里不动它们
+//
+// RefEntry/RefUpdate 直接复用 pkg/remote 的定义而不是另起一套：CAS 的语义（Version 对应
+// meta.Ref.Version）已经在 pkg/remote.Remote 里讲清楚过一次，没必要在这里重复
+type RemoteTransport interface {
+	// PutObject 把一个对象写到远端
+	PutObject(ctx context.Context, obj core.Object) error
+
+	// GetObject 按 hash 从远端读回一个对象；远端没有这个对象时返回 storage.ErrNotFound
+	GetObject(ctx context.Context, hash types.Hash) (core.Object, error)
+
+	// HasObject 只问远端有没有这个对象，不传输内容
+	HasObject(ctx context.Context, hash types.Hash) (bool, error)
+
+	// PushRefs 尝试用 CAS 语义更新远端的一批引用；任意一条 OldVersion 对不上都返回
+	// remote.ErrConcurrentUpdate
+	PushRefs(ctx context.Context, updates []remote.RefUpdate) error
+
+	// FetchRefs 返回远端当前所有引用及其版本号
+	FetchRefs(ctx context.Context) (map[string]remote.RefEntry, error)
+
+	// Close 释放底层连接（gRPC channel、HTTP keep-alive 连接池、S3 SDK 客户端等）
+	Close() error
+}
+
+// TransportConfig 收拢三种 RemoteTransport 实现各自需要的连接参数。NewRemoteTransport
+// 按 scheme 只读其中对应的那一组字段，其它字段被忽略——这跟 pkg/app.newNamedStore 按
+// backend type 只读 multiBackendConfig 对应那几个字段是同一个套路
+type TransportConfig struct {
+	// Addr 是 grpc scheme 下的 "host:port"，或者 http(s) scheme 下的 base URL
+	Addr string
+
+	// TLS：grpc scheme 下用于构造传输层凭证；空值表示明文连接
+	TLSCAFile   string
+	TLSCertFile string
+	TLSKeyFile  string
+	Insecure    bool
+
+	// S3：s3 scheme 下用于构造 *s3.Adapter
+	S3Endpoint        string
+	S3Bucket          string
+	S3Region          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+}
+
+// NewRemoteTransport 按 scheme 构造一个 RemoteTransport；scheme 为空时退回 "grpc"，
+// 跟 GetRemoteClient 过去隐含的唯一行为保持向后兼容
+func NewRemoteTransport(ctx context.Context, scheme string, cfg TransportConfig) (RemoteTransport, error) {
+	switch scheme {
+	case "", "grpc":
+		return newGRPCTransport(cfg)
+	case "http", "https":
+		return newHTTPTransport(scheme, cfg)
+	case "s3":
+		return newS3Transport(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("client: unknown remote.scheme %q (want grpc, http, https or s3)", scheme)
+	}
+}
@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	tvrpc "tensorvault/pkg/api/tvrpc/v1"
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/remote"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+// errGRPCRefsUnsupported 说明了为什么 grpcTransport 的 PushRefs/FetchRefs 没有实现：
+// MetaService 目前只暴露单条 GetHead/GetRef，没有"列出全部引用"或者"批量 CAS 更新"的
+// RPC（这两个语义现在只存在于 pkg/remote.Remote 的 HTTP 协议里）。tvrpc 的 proto
+// 定义要补上对应的消息/方法才能在 gRPC 上提供同等能力，而 pkg/api/tvrpc/v1（生成的
+// client/server 代码）本身并不在这个仓库里，没法在这里扩展或重新生成——跟
+// pkg/client/chunkgroup.go 记录的 "Upload 缺逐块 ack" 是同一类、环境本身的缺口。想同步
+// 引用表的调用方应该选 remote.scheme=http 或 s3
+var errGRPCRefsUnsupported = errors.New("client: grpc transport does not support ref sync yet (tvrpc has no multi-ref RPC); use remote.scheme=http or s3")
+
+// grpcTransport 是 RemoteTransport 的 gRPC 实现。对象读写直接复用
+// tvrpc.PeerServiceClient 的 PutChunk/GetChunk/HasChunk，跟
+// pkg/storage/clusterstore.Adapter 转发给 owner peer 的那几行是同一套调用方式——这其实
+// 就是 ClusterStore 原本为"一致性哈希分片"准备的转发能力，这里换了个使用场景：不是节点
+// 对节点转发，而是 CLI 对任意一个 tv-server 的对象级读写
+type grpcTransport struct {
+	conn *TVClient
+}
+
+func newGRPCTransport(cfg TransportConfig) (RemoteTransport, error) {
+	if cfg.Addr == "" {
+		return nil, fmt.Errorf("client: grpc transport requires remote.server")
+	}
+
+	if !usesTLS(cfg) {
+		c, err := NewTVClient(cfg.Addr)
+		if err != nil {
+			return nil, err
+		}
+		return &grpcTransport{conn: c}, nil
+	}
+
+	tlsCfg, err := loadClientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := grpc.NewClient(cfg.Addr, grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)))
+	if err != nil {
+		return nil, fmt.Errorf("client: failed to dial %s over TLS: %w", cfg.Addr, err)
+	}
+	return &grpcTransport{conn: &TVClient{
+		conn: conn,
+		addr: cfg.Addr,
+		Data: tvrpc.NewDataServiceClient(conn),
+		Meta: tvrpc.NewMetaServiceClient(conn),
+		Peer: tvrpc.NewPeerServiceClient(conn),
+	}}, nil
+}
+
+func (t *grpcTransport) PutObject(ctx context.Context, obj core.Object) error {
+	if _, err := t.conn.Peer.PutChunk(ctx, &tvrpc.PutChunkRequest{Data: obj.Bytes()}); err != nil {
+		return fmt.Errorf("client: grpc put object %s failed: %w", obj.ID(), err)
+	}
+	return nil
+}
+
+func (t *grpcTransport) GetObject(ctx context.Context, hash types.Hash) (core.Object, error) {
+	resp, err := t.conn.Peer.GetChunk(ctx, &tvrpc.GetChunkRequest{Hash: string(hash)})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("client: grpc get object %s failed: %w", hash, err)
+	}
+	return remote.NewObject(hash, "", resp.Data), nil
+}
+
+func (t *grpcTransport) HasObject(ctx context.Context, hash types.Hash) (bool, error) {
+	resp, err := t.conn.Peer.HasChunk(ctx, &tvrpc.HasChunkRequest{Hash: string(hash)})
+	if err != nil {
+		return false, fmt.Errorf("client: grpc has object %s failed: %w", hash, err)
+	}
+	return resp.Exists, nil
+}
+
+func (t *grpcTransport) PushRefs(ctx context.Context, updates []remote.RefUpdate) error {
+	return errGRPCRefsUnsupported
+}
+
+func (t *grpcTransport) FetchRefs(ctx context.Context) (map[string]remote.RefEntry, error) {
+	return nil, errGRPCRefsUnsupported
+}
+
+func (t *grpcTransport) Close() error {
+	return t.conn.Close()
+}
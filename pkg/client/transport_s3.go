@@ -0,0 +1,154 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/remote"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/storage/s3"
+	"tensorvault/pkg/types"
+)
+
+// refsManifestHash 是 s3Transport 存放引用表的固定 key，跟 pkg/storage/s3.Adapter 用
+// sseCanaryKey 探测密钥是否一致是同一个手法：S3 是纯内容寻址存储，没有"给一份非内容
+// 寻址的小文档起个名字存起来"的原生概念，这里借用同一个 Put/Get 接口，只是把 ID() 固定
+// 写死成一个不会跟真实对象哈希撞车的字符串
+const refsManifestHash types.Hash = "_tensorvault_refs_manifest"
+
+// refsManifest 是 s3Transport 引用表在 S3 上的序列化形态
+type refsManifest struct {
+	Refs map[string]remote.RefEntry `json:"refs"`
+}
+
+// manifestObject 让 refsManifest 的 JSON 字节可以直接喂给 storage.Store.Put：
+// ID() 永远返回 refsManifestHash，Type 固定标成 TypeChunk——这是一份不透明文档，不是
+// 真正要被当成 DAG 节点遍历的对象
+type manifestObject struct {
+	data []byte
+}
+
+func (m manifestObject) ID() types.Hash        { return refsManifestHash }
+func (m manifestObject) Bytes() []byte         { return m.data }
+func (m manifestObject) Type() core.ObjectType { return core.TypeChunk }
+
+// s3Transport 是 RemoteTransport 的 S3/MinIO 实现：对象读写直接复用
+// pkg/storage/s3.Adapter（SSE-C、MinIO path-style 这些都不用重新实现一遍）；引用表没有
+// S3 原生对应物，存成 refsManifestHash 这一个固定 key 下的 JSON 文档。
+//
+// PushRefs 的 CAS 不是真正原子的——S3 没有"比较并交换任意 key"的原语，这里退化成
+// "读整份 manifest -> 在内存里比较版本号 -> 写回整份 manifest"，中间有一个没有锁保护的
+// 窗口：两个并发的 s3Transport.PushRefs（尤其是来自不同进程/机器的）理论上可能互相踩到
+// 对方的更新。对这个 scheme 的典型场景（没有专门部署 tv-server 的小团队，直接把 S3/MinIO
+// 当成低频协作的共享仓库）这是可以接受的折中；真正需要强 CAS 保证的部署应该用 grpc 或
+// http scheme，背后有 meta.Repository 真正的数据库事务撑腰
+type s3Transport struct {
+	adapter *s3.Adapter
+	mu      sync.Mutex
+}
+
+func newS3Transport(ctx context.Context, cfg TransportConfig) (RemoteTransport, error) {
+	if cfg.S3Bucket == "" {
+		return nil, fmt.Errorf("client: s3 transport requires remote.s3.bucket")
+	}
+	adapter, err := s3.NewAdapter(ctx, s3.Config{
+		Endpoint:        cfg.S3Endpoint,
+		Region:          cfg.S3Region,
+		Bucket:          cfg.S3Bucket,
+		AccessKeyID:     cfg.S3AccessKeyID,
+		SecretAccessKey: cfg.S3SecretAccessKey,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("client: failed to init s3 transport: %w", err)
+	}
+	return &s3Transport{adapter: adapter}, nil
+}
+
+func (t *s3Transport) PutObject(ctx context.Context, obj core.Object) error {
+	return t.adapter.Put(ctx, obj)
+}
+
+func (t *s3Transport) GetObject(ctx context.Context, hash types.Hash) (core.Object, error) {
+	return remote.ReadObject(ctx, t.adapter, hash)
+}
+
+func (t *s3Transport) HasObject(ctx context.Context, hash types.Hash) (bool, error) {
+	return t.adapter.Has(ctx, hash.String())
+}
+
+// loadManifest 读回当前的引用表；manifest 还不存在（第一次 Push 之前）时返回一份空表，
+// 跟 storage.ErrNotFound 不算错误的语义一致
+func (t *s3Transport) loadManifest(ctx context.Context) (refsManifest, error) {
+	rc, err := t.adapter.Get(ctx, refsManifestHash.String())
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return refsManifest{Refs: map[string]remote.RefEntry{}}, nil
+		}
+		return refsManifest{}, fmt.Errorf("client: failed to read refs manifest: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return refsManifest{}, fmt.Errorf("client: failed to read refs manifest: %w", err)
+	}
+	var m refsManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return refsManifest{}, fmt.Errorf("client: corrupted refs manifest: %w", err)
+	}
+	if m.Refs == nil {
+		m.Refs = map[string]remote.RefEntry{}
+	}
+	return m, nil
+}
+
+func (t *s3Transport) FetchRefs(ctx context.Context) (map[string]remote.RefEntry, error) {
+	m, err := t.loadManifest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.Refs, nil
+}
+
+func (t *s3Transport) PushRefs(ctx context.Context, updates []remote.RefUpdate) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	m, err := t.loadManifest(ctx)
+	if err != nil {
+		return err
+	}
+	if err := applyRefUpdates(&m, updates); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return t.adapter.Put(ctx, manifestObject{data: data})
+}
+
+// applyRefUpdates 校验每条更新的 OldVersion 是否跟 m 里当前记录的版本号一致（缺失的引用
+// 当作版本 0），全部校验通过才真的把 updates 写进 m，任意一条不一致都整体失败并且不修改
+// m——这是 PushRefs 的纯逻辑部分，单独拆出来方便不连 S3 也能测
+func applyRefUpdates(m *refsManifest, updates []remote.RefUpdate) error {
+	for _, u := range updates {
+		if m.Refs[u.Name].Version != u.OldVersion {
+			return remote.ErrConcurrentUpdate
+		}
+	}
+	for _, u := range updates {
+		m.Refs[u.Name] = remote.RefEntry{Hash: u.NewHash, Version: m.Refs[u.Name].Version + 1}
+	}
+	return nil
+}
+
+func (t *s3Transport) Close() error {
+	return nil
+}
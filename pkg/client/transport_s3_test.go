@@ -0,0 +1,52 @@
+package client
+
+import (
+	"testing"
+
+	"tensorvault/pkg/remote"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRefUpdates_CreatesNewRefAtVersionOne(t *testing.T) {
+	m := &refsManifest{Refs: map[string]remote.RefEntry{}}
+
+	err := applyRefUpdates(m, []remote.RefUpdate{{Name: "refs/heads/main", NewHash: "abc", OldVersion: 0}})
+	require.NoError(t, err)
+	assert.Equal(t, remote.RefEntry{Hash: "abc", Version: 1}, m.Refs["refs/heads/main"])
+}
+
+func TestApplyRefUpdates_AdvancesVersionOnMatch(t *testing.T) {
+	m := &refsManifest{Refs: map[string]remote.RefEntry{
+		"refs/heads/main": {Hash: "abc", Version: 1},
+	}}
+
+	err := applyRefUpdates(m, []remote.RefUpdate{{Name: "refs/heads/main", NewHash: "def", OldVersion: 1}})
+	require.NoError(t, err)
+	assert.Equal(t, remote.RefEntry{Hash: "def", Version: 2}, m.Refs["refs/heads/main"])
+}
+
+func TestApplyRefUpdates_RejectsStaleVersion(t *testing.T) {
+	m := &refsManifest{Refs: map[string]remote.RefEntry{
+		"refs/heads/main": {Hash: "abc", Version: 2},
+	}}
+
+	err := applyRefUpdates(m, []remote.RefUpdate{{Name: "refs/heads/main", NewHash: "def", OldVersion: 1}})
+	assert.ErrorIs(t, err, remote.ErrConcurrentUpdate)
+	assert.Equal(t, remote.RefEntry{Hash: "abc", Version: 2}, m.Refs["refs/heads/main"], "rejected update must not mutate the manifest")
+}
+
+func TestApplyRefUpdates_AllOrNothingAcrossMultipleRefs(t *testing.T) {
+	m := &refsManifest{Refs: map[string]remote.RefEntry{
+		"refs/heads/main": {Hash: "abc", Version: 1},
+		"refs/heads/dev":  {Hash: "xyz", Version: 5},
+	}}
+
+	err := applyRefUpdates(m, []remote.RefUpdate{
+		{Name: "refs/heads/main", NewHash: "def", OldVersion: 1},
+		{Name: "refs/heads/dev", NewHash: "ghi", OldVersion: 0}, // stale
+	})
+	assert.ErrorIs(t, err, remote.ErrConcurrentUpdate)
+	assert.Equal(t, remote.RefEntry{Hash: "abc", Version: 1}, m.Refs["refs/heads/main"], "earlier update in the batch must not stick when a later one fails")
+}
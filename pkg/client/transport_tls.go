@@ -0,0 +1,47 @@
+package client
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// loadClientTLSConfig 用 TransportConfig 里的 remote.tls.* 字段构造一份 *tls.Config，
+// 供 grpc/http 两种 scheme 共用——跟 server.LoadTLSCredentials 是服务端那一侧的对应物，
+// 这里反过来是客户端信任谁 (TLSCAFile) 和客户端要不要出示证书做双向 TLS
+// (TLSCertFile/TLSKeyFile)
+func loadClientTLSConfig(cfg TransportConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{}
+
+	if cfg.TLSCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.TLSCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("client: failed to read remote.tls.ca: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("client: failed to parse remote.tls.ca %s", cfg.TLSCAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if cfg.TLSCertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("client: failed to load remote.tls.cert/remote.tls.key: %w", err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
+
+// usesTLS 判断这组配置是不是要求加密传输：remote.insecure 显式为 true 时总是明文，
+// 否则只要配了 CA 或者客户端证书就认为想要 TLS
+func usesTLS(cfg TransportConfig) bool {
+	if cfg.Insecure {
+		return false
+	}
+	return cfg.TLSCAFile != "" || cfg.TLSCertFile != ""
+}
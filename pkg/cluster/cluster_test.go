@@ -0,0 +1,65 @@
+package cluster
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMode(t *testing.T) {
+	cases := []struct {
+		in   string
+		want Mode
+	}{
+		{"", ModeStandalone},
+		{"standalone", ModeStandalone},
+		{"master", ModeMaster},
+		{"worker", ModeWorker},
+		{"both", ModeBoth},
+		{"MASTER", ModeStandalone}, // 大小写不匹配，静默回退到 standalone
+		{"garbage", ModeStandalone},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, ParseMode(c.in), "input %q", c.in)
+	}
+
+	assert.True(t, ModeMaster.IsMaster())
+	assert.True(t, ModeBoth.IsMaster())
+	assert.False(t, ModeWorker.IsMaster())
+	assert.False(t, ModeStandalone.IsMaster())
+
+	assert.True(t, ModeWorker.IsWorker())
+	assert.True(t, ModeBoth.IsWorker())
+	assert.False(t, ModeMaster.IsWorker())
+}
+
+func TestNodeRegistry_LeastLoaded(t *testing.T) {
+	// grpc.NewClient 不会真正拨号，所以这里可以用假地址，不需要起一个真实的 tv-server
+	registry, err := NewNodeRegistry([]string{"127.0.0.1:1", "127.0.0.1:2", "127.0.0.1:3"})
+	require.NoError(t, err)
+	defer registry.Close()
+
+	// 还没探活过，所有节点都是不健康的
+	_, ok := registry.LeastLoaded()
+	assert.False(t, ok)
+	assert.Equal(t, 0, registry.Len())
+
+	// 手动标记健康状态，模拟探活已经跑过一轮
+	for i, w := range registry.nodes {
+		w.setHealthy(true)
+		for j := 0; j < i; j++ {
+			w.Acquire()
+		}
+	}
+	assert.Equal(t, 3, registry.Len())
+
+	best, ok := registry.LeastLoaded()
+	require.True(t, ok)
+	assert.Equal(t, "127.0.0.1:1", best.Addr(), "the node with zero load should be picked first")
+
+	best.Acquire()
+	best.Acquire()
+	best.Release()
+	assert.Equal(t, 1, best.Load())
+}
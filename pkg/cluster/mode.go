@@ -0,0 +1,51 @@
+// Package cluster 实现了受 Cloudreve 主从架构启发的 master/worker 拆分：
+// master 节点（运行 MetaService/DataService）把重负载的计算（分片构建 Merkle Tree、
+// 接收并切片上传的字节流）派发给一组 worker 节点，worker 节点直接把结果写入
+// 共享的 storage.Store，master 只负责编排和合并结果。
+package cluster
+
+// Mode 描述一个进程在集群里扮演的角色
+type Mode string
+
+const (
+	// ModeStandalone 是默认模式：单进程处理所有请求，不连接任何 worker
+	// （也就是这个包存在之前，tv-server 唯一支持的模式）
+	ModeStandalone Mode = "standalone"
+
+	// ModeMaster 只接收客户端请求并派发给 cluster.workers 里配置的 worker 节点
+	ModeMaster Mode = "master"
+
+	// ModeWorker 只接受来自 master 的派发请求，本身不再向外派发
+	ModeWorker Mode = "worker"
+
+	// ModeBoth 既接受客户端请求，又可以把部分工作派发给其它节点（小集群、单机多进程测试常用）
+	ModeBoth Mode = "both"
+)
+
+// ParseMode 把配置里的字符串解析成 Mode；无法识别的值一律静默回退到 ModeStandalone，
+// 跟 pkg/app.configuredHashAlgo 对未知算法的处理方式保持一致——不能因为一个拼写错误的
+// 配置项让服务器直接启动失败
+func ParseMode(s string) Mode {
+	switch Mode(s) {
+	case ModeMaster:
+		return ModeMaster
+	case ModeWorker:
+		return ModeWorker
+	case ModeBoth:
+		return ModeBoth
+	default:
+		return ModeStandalone
+	}
+}
+
+// IsMaster 返回这个节点是否应该维护一个 NodeRegistry 并派发工作
+func (m Mode) IsMaster() bool {
+	return m == ModeMaster || m == ModeBoth
+}
+
+// IsWorker 返回这个节点是否应该接受来自其它 master 的派发请求
+// 目前 worker 角色不需要任何特殊初始化——MetaService/DataService 本来就能独立处理请求，
+// "成为 worker" 纯粹是master 那一侧的配置（把这个节点的地址加进 cluster.workers）
+func (m Mode) IsWorker() bool {
+	return m == ModeWorker || m == ModeBoth
+}
@@ -0,0 +1,59 @@
+package cluster
+
+import (
+	"fmt"
+	"sync"
+
+	tvrpc "tensorvault/pkg/api/tvrpc/v1"
+	"tensorvault/pkg/client"
+)
+
+// PeerPool 维护到一致性哈希环上各个 peer 节点的连接，供 storage/clusterstore.Adapter
+// 转发不属于本节点的 chunk 请求时使用。跟 NodeRegistry 的区别是它不做健康探测和
+// "最闲优先"调度——HashRing 本身已经把负载摊开到固定的节点上了，PeerPool 只负责
+// "给定一个地址，找到它的 PeerServiceClient"，转发失败时直接把错误透传给调用方
+type PeerPool struct {
+	mu    sync.RWMutex
+	peers map[string]*client.TVClient
+}
+
+// NewPeerPool 为每一个 peer 地址建立连接；单个地址连接失败会让整个构造失败——环上的
+// 每个节点都拥有独占的哈希空间，少一个 peer 就意味着它负责的那部分 chunk 彻底不可达。
+// 跟 NodeRegistry 不同，PeerPool 不做探活，也不区分健康/不健康的 peer；NodeRegistry
+// 里少一个健康 worker 只是降低了可用并发度
+func NewPeerPool(addrs []string) (*PeerPool, error) {
+	p := &PeerPool{peers: make(map[string]*client.TVClient, len(addrs))}
+	for _, addr := range addrs {
+		tv, err := client.NewTVClient(addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to peer %s: %w", addr, err)
+		}
+		p.peers[addr] = tv
+	}
+	return p, nil
+}
+
+// Get 返回 addr 对应的 PeerServiceClient；ok=false 表示这个地址没有在 cluster.peers
+// 里配置过连接
+func (p *PeerPool) Get(addr string) (tvrpc.PeerServiceClient, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	tv, ok := p.peers[addr]
+	if !ok {
+		return nil, false
+	}
+	return tv.Peer, true
+}
+
+// Close 关闭所有 peer 连接，在 tv-server 优雅关闭时调用
+func (p *PeerPool) Close() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	var firstErr error
+	for _, tv := range p.peers {
+		if err := tv.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
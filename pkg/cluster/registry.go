@@ -0,0 +1,185 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	tvrpc "tensorvault/pkg/api/tvrpc/v1"
+	"tensorvault/pkg/client"
+)
+
+// WorkerClient 封装了 master 到某一个 worker 节点的连接，在 pkg/client.TVClient 之上
+// 附加了健康状态和负载计数，供 NodeRegistry 挑选"最闲的那个"时使用
+type WorkerClient struct {
+	addr string
+	tv   *client.TVClient
+
+	mu       sync.Mutex
+	healthy  bool
+	lastSeen time.Time
+	load     int // 当前正在这个节点上跑的派发任务数 (BuildTree 分片 / Upload 代理)
+}
+
+// newWorkerClient 建立到 worker 的连接；grpc.NewClient 不会阻塞等待连接就绪
+// (跟 pkg/client.NewTVClient 的行为一致)，真正的可用性由 StartHealthCheck 探测
+func newWorkerClient(addr string) (*WorkerClient, error) {
+	tv, err := client.NewTVClient(addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create worker client for %s: %w", addr, err)
+	}
+	return &WorkerClient{addr: addr, tv: tv}, nil
+}
+
+// Addr 返回这个 worker 的地址，供日志打印使用
+func (w *WorkerClient) Addr() string { return w.addr }
+
+// Meta 返回这个 worker 上的 MetaService 客户端（BuildTree 分片派发走这里）
+func (w *WorkerClient) Meta() tvrpc.MetaServiceClient { return w.tv.Meta }
+
+// Data 返回这个 worker 上的 DataService 客户端（Upload 流代理走这里）
+func (w *WorkerClient) Data() tvrpc.DataServiceClient { return w.tv.Data }
+
+// Healthy 返回最近一次心跳探测是否成功
+func (w *WorkerClient) Healthy() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.healthy
+}
+
+// Load 返回当前挂在这个节点上的任务数，用于"最闲优先"的调度
+func (w *WorkerClient) Load() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.load
+}
+
+// Acquire 在把一份工作派发给这个节点之前调用，增加它的负载计数
+func (w *WorkerClient) Acquire() {
+	w.mu.Lock()
+	w.load++
+	w.mu.Unlock()
+}
+
+// Release 在派发的工作完成（无论成功还是失败）之后调用，归还负载计数
+func (w *WorkerClient) Release() {
+	w.mu.Lock()
+	if w.load > 0 {
+		w.load--
+	}
+	w.mu.Unlock()
+}
+
+func (w *WorkerClient) setHealthy(ok bool) {
+	w.mu.Lock()
+	w.healthy = ok
+	if ok {
+		w.lastSeen = time.Now()
+	}
+	w.mu.Unlock()
+}
+
+// Close 关闭底层 gRPC 连接
+func (w *WorkerClient) Close() error {
+	return w.tv.Close()
+}
+
+// NodeRegistry 维护 master 已知的 worker 节点集合及其健康状态
+// worker 地址目前通过 cluster.workers 静态配置（本仓库没有自己的 .proto 可以扩展出一个
+// 专门的注册 RPC），NodeRegistry 负责对这份静态列表做主动健康探测和"最闲优先"调度
+type NodeRegistry struct {
+	mu    sync.RWMutex
+	nodes []*WorkerClient
+}
+
+// NewNodeRegistry 为每一个配置的 worker 地址建立一个客户端；任何一个地址建立客户端失败
+// 都会让整个构造失败。建立客户端不会真正拨号，所以暂时连不上的节点照样加入，初始是不健康
+// 状态，等 StartHealthCheck 探活成功后才进入 LeastLoaded 的候选
+func NewNodeRegistry(addrs []string) (*NodeRegistry, error) {
+	r := &NodeRegistry{}
+	for _, addr := range addrs {
+		w, err := newWorkerClient(addr)
+		if err != nil {
+			return nil, err
+		}
+		r.nodes = append(r.nodes, w)
+	}
+	return r, nil
+}
+
+// Len 返回当前健康的 worker 数量；master 端用它判断"要不要走分片/代理路径"
+func (r *NodeRegistry) Len() int {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	n := 0
+	for _, w := range r.nodes {
+		if w.Healthy() {
+			n++
+		}
+	}
+	return n
+}
+
+// LeastLoaded 返回当前负载最低的健康 worker；没有健康节点时返回 false，
+// 调用方（MetaService/DataService）应该退回单进程本地处理
+func (r *NodeRegistry) LeastLoaded() (*WorkerClient, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var best *WorkerClient
+	for _, w := range r.nodes {
+		if !w.Healthy() {
+			continue
+		}
+		if best == nil || w.Load() < best.Load() {
+			best = w
+		}
+	}
+	return best, best != nil
+}
+
+// StartHealthCheck 启动一个后台 goroutine，周期性地向每个 worker 发一个最轻量的
+// 探活请求 (GetHead)。一次探活在 timeout 内没有成功的节点就会被标记为不健康，
+// 从 LeastLoaded 的候选里移除，直到它恢复响应
+func (r *NodeRegistry) StartHealthCheck(ctx context.Context, interval, timeout time.Duration) {
+	go func() {
+		r.probeAll(ctx, timeout) // 立即探测一轮，避免节点要等一个完整 interval 才变健康
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.probeAll(ctx, timeout)
+			}
+		}
+	}()
+}
+
+func (r *NodeRegistry) probeAll(parent context.Context, timeout time.Duration) {
+	r.mu.RLock()
+	nodes := append([]*WorkerClient(nil), r.nodes...)
+	r.mu.RUnlock()
+
+	for _, w := range nodes {
+		ctx, cancel := context.WithTimeout(parent, timeout)
+		_, err := w.Meta().GetHead(ctx, &tvrpc.GetHeadRequest{})
+		cancel()
+		w.setHealthy(err == nil)
+	}
+}
+
+// Close 关闭所有 worker 连接，在 tv-server 优雅关闭时调用
+func (r *NodeRegistry) Close() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var firstErr error
+	for _, w := range r.nodes {
+		if err := w.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
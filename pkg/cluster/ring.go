@@ -0,0 +1,109 @@
+package cluster
+
+import (
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"sync"
+
+	"tensorvault/pkg/types"
+)
+
+// vnodeCount 是每个物理节点在环上映射的虚拟节点数量。数值越大，环上的负载分布越
+// 均匀，但 Owner 查找时二分搜索的候选集合也越大；160 是 groupcache 等主流一致性
+// 哈希实现常用的量级，均匀性和环大小之间取得的折中已经足够好，没必要做成可配置项
+const vnodeCount = 160
+
+// ringEntry 是环上的一个虚拟节点：hash 是它在环上的位置，addr 是它所属的物理节点地址
+// (跟 cluster.peers 配置里的地址、以及 PeerService 拨号用的地址是同一个命名空间)
+type ringEntry struct {
+	hash uint32
+	addr string
+}
+
+// HashRing 是 ClusterStore 用来决定"某个 chunk 哈希归哪个节点管"的一致性哈希环。
+// 跟 NodeRegistry 的 master/worker 委派模型是两回事：NodeRegistry 把整条 Upload 流
+// 代理给某个"当前最闲"的 worker，而 HashRing 让每个节点固定拥有哈希空间的一部分，
+// 多个对等节点 (peer) 各自处理自己那一部分、互相转发不属于自己的 chunk——更接近
+// memcached/groupcache 那种分片缓存的做法，而不是任务队列式的负载均衡
+type HashRing struct {
+	mu      sync.RWMutex
+	entries []ringEntry     // 按 hash 升序排列，Owner 用二分查找
+	members map[string]bool // 当前环上的物理节点地址集合，避免重复添加
+}
+
+// NewHashRing 用一组 peer 地址 (通常来自 cluster.peers 配置) 构造一个环
+func NewHashRing(addrs []string) *HashRing {
+	r := &HashRing{members: make(map[string]bool)}
+	for _, addr := range addrs {
+		r.AddNode(addr)
+	}
+	return r
+}
+
+// AddNode 把一个物理节点加入环 (幂等：重复添加同一个地址无效果)
+func (r *HashRing) AddNode(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.members[addr] {
+		return
+	}
+	r.members[addr] = true
+	for i := 0; i < vnodeCount; i++ {
+		r.entries = append(r.entries, ringEntry{hash: vnodeHash(addr, i), addr: addr})
+	}
+	sort.Slice(r.entries, func(i, j int) bool { return r.entries[i].hash < r.entries[j].hash })
+}
+
+// RemoveNode 把一个物理节点从环上摘除，供节点下线/健康检查失败时调用
+func (r *HashRing) RemoveNode(addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.members[addr] {
+		return
+	}
+	delete(r.members, addr)
+	filtered := r.entries[:0]
+	for _, e := range r.entries {
+		if e.addr != addr {
+			filtered = append(filtered, e)
+		}
+	}
+	r.entries = filtered
+}
+
+// Members 返回当前环上所有物理节点地址，顺序不保证稳定
+func (r *HashRing) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	out := make([]string, 0, len(r.members))
+	for addr := range r.members {
+		out = append(out, addr)
+	}
+	return out
+}
+
+// Owner 返回 hash 应该归属的物理节点地址。环为空 (没有配置 cluster.peers) 时返回
+// ("", false)，调用方应该退回纯本地处理——跟 NodeRegistry.LeastLoaded 在没有健康
+// worker 时的退化方式保持一致，不让集群特性的缺失变成硬错误
+func (r *HashRing) Owner(hash types.Hash) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.entries) == 0 {
+		return "", false
+	}
+	h := keyHash(string(hash))
+	idx := sort.Search(len(r.entries), func(i int) bool { return r.entries[i].hash >= h })
+	if idx == len(r.entries) {
+		idx = 0 // 环绕：落在最后一个虚拟节点之后的 key 归第一个虚拟节点管
+	}
+	return r.entries[idx].addr, true
+}
+
+func vnodeHash(addr string, i int) uint32 {
+	return keyHash(fmt.Sprintf("%s#%d", addr, i))
+}
+
+func keyHash(s string) uint32 {
+	return crc32.ChecksumIEEE([]byte(s))
+}
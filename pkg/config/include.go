@@ -0,0 +1,127 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+	"gopkg.in/yaml.v3"
+)
+
+// includeDirectiveRe 匹配借用自 Mercurial 配置分层语义的 `%include path.yaml` 行。
+// `%` 在 YAML 里是指令前缀，严格解析遇到未知指令会报错，所以这种行要先从内容里摘掉，
+// 再交给 yaml.Unmarshal。
+var includeDirectiveRe = regexp.MustCompile(`(?m)^[ \t]*%include[ \t]+(\S+)[ \t]*$`)
+
+// rawIncludes 只用来从一个配置文件里探测 include: 列表，不关心其余字段
+type rawIncludes struct {
+	Include []string `yaml:"include"`
+}
+
+// buildIncludeChain 展开 root 及其传递依赖的 include 链，返回按"先加载、后覆盖"排列的
+// 绝对路径列表：root 自己排在最后，因为按照优先级规则它的键必须覆盖所有 include。
+func buildIncludeChain(root string) ([]string, error) {
+	var chain []string
+	seen := make(map[string]bool)     // 已经进了 chain 的文件，钻石依赖只合并一次
+	visiting := make(map[string]bool) // 当前递归栈上的文件，用来发现环
+
+	var visit func(path string, stack []string) error
+	visit = func(path string, stack []string) error {
+		abs, err := filepath.Abs(path)
+		if err != nil {
+			return err
+		}
+		if visiting[abs] {
+			return fmt.Errorf("config include cycle detected: %s", strings.Join(append(stack, abs), " -> "))
+		}
+		if seen[abs] {
+			return nil
+		}
+		visiting[abs] = true
+		defer delete(visiting, abs)
+
+		includes, err := parseIncludes(abs)
+		if err != nil {
+			return err
+		}
+
+		for _, raw := range includes {
+			optional := strings.HasPrefix(raw, "?")
+			incPath := resolveIncludePath(strings.TrimPrefix(raw, "?"), filepath.Dir(abs))
+
+			if _, err := os.Stat(incPath); err != nil {
+				if optional {
+					continue
+				}
+				return fmt.Errorf("included config %s not found (referenced from %s): %w", incPath, abs, err)
+			}
+			if err := visit(incPath, append(stack, abs)); err != nil {
+				return err
+			}
+		}
+
+		seen[abs] = true
+		chain = append(chain, abs)
+		return nil
+	}
+
+	if err := visit(root, nil); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// parseIncludes 读取一个配置文件，返回它直接依赖的 include 路径（可能带 "?" 前缀表示
+// 可选），include: 顶层列表和 %include 行都算数，前者在前、后者在后。
+func parseIncludes(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+
+	cleaned := includeDirectiveRe.ReplaceAll(data, nil)
+	var raw rawIncludes
+	if err := yaml.Unmarshal(cleaned, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse config %s: %w", path, err)
+	}
+
+	includes := append([]string{}, raw.Include...)
+	for _, m := range includeDirectiveRe.FindAllSubmatch(data, -1) {
+		includes = append(includes, string(m[1]))
+	}
+	return includes, nil
+}
+
+// resolveIncludePath 把一个 include 条目解析成绝对路径："~" 展开到用户主目录，绝对路径
+// 原样返回，相对路径相对于引用它的那个文件所在目录展开（而不是 cwd）。
+func resolveIncludePath(raw, baseDir string) string {
+	if strings.HasPrefix(raw, "~") {
+		if home, err := os.UserHomeDir(); err == nil {
+			raw = filepath.Join(home, strings.TrimPrefix(raw, "~"))
+		}
+	}
+	if filepath.IsAbs(raw) {
+		return raw
+	}
+	return filepath.Join(baseDir, raw)
+}
+
+// mergeConfigFile 把 path 的内容（摘掉 %include 行之后）合并进当前 Viper 配置；后合并的
+// 键覆盖先合并的，所以调用方必须按 buildIncludeChain 返回的顺序依次调用。
+func mergeConfigFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config %s: %w", path, err)
+	}
+	cleaned := includeDirectiveRe.ReplaceAll(data, nil)
+
+	viper.SetConfigType("yaml")
+	if err := viper.MergeConfig(bytes.NewReader(cleaned)); err != nil {
+		return fmt.Errorf("failed to merge config %s: %w", path, err)
+	}
+	return nil
+}
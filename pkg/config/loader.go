@@ -4,61 +4,86 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+
+	"tensorvault/pkg/chunker"
+	"tensorvault/pkg/storage/cache"
 
 	"github.com/spf13/viper"
 )
 
+// configNames 是 cfgFile 未显式指定时，按顺序在每个搜索目录里尝试的文件名
+var configNames = []string{"config.yaml", "config.yml"}
+
 // Load 初始化 Viper 配置
 // cfgFile: 可选，用户显式指定的配置文件路径
 func Load(cfgFile string) error {
 	// 1. 设置默认值 (Defaults)
 	setDefaults()
 
-	// 2. 配置搜索路径
-	if cfgFile != "" {
-		// 如果用户指定了文件，直接使用
-		viper.SetConfigFile(cfgFile)
-	} else {
-		// 否则按优先级搜索
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return err
-		}
-
-		// 搜索顺序：
-		// 1. 当前目录
-		viper.AddConfigPath(".")
-		// 2. 当前目录下的 .tv
-		viper.AddConfigPath(".tv")
-		// 3. 用户主目录下的 .tv
-		viper.AddConfigPath(filepath.Join(home, ".tv"))
-
-		viper.SetConfigType("yaml")
-		viper.SetConfigName("config") // 找 config.yaml
-	}
-
-	// 3. 读取环境变量 (TV_DATABASE_HOST 等)
+	// 2. 读取环境变量 (TV_DATABASE_HOST 等)。Viper 默认只按前缀加大写键名匹配
+	// (TV_STORAGE.PATH，点号原样保留)，而环境变量名里不能有点号——不配这个 Replacer，
+	// 嵌套键（storage.path、remote.server 这种）实际上永远匹配不到任何环境变量，
+	// 只有顶层键凑巧能用。加上之后 "storage.path" 才会真的去找 TV_STORAGE_PATH
 	viper.SetEnvPrefix("TV")
+	viper.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
 	viper.AutomaticEnv()
 
-	// 4. 读取配置文件
-	if err := viper.ReadInConfig(); err != nil {
-		// 如果只是没找到配置文件，但可能有环境变量，不一定算错
-		// 但如果是配置文件格式错，那就是错
-		if _, ok := err.(viper.ConfigFileNotFoundError); ok {
-			// Config file not found; ignore error if desired
-			fmt.Println("⚠️  No config file found, using defaults/env vars")
-		} else {
-			// Config file was found but another error produced
+	// 3. 定位主配置文件：cfgFile 显式指定就直接用，否则按优先级搜索
+	// （当前目录 -> 当前目录下的 .tv -> 用户主目录下的 .tv）
+	resolved, err := resolveConfigFile(cfgFile)
+	if err != nil {
+		return err
+	}
+	if resolved == "" {
+		// 没有任何候选文件，但可能有环境变量，不一定算错
+		fmt.Println("⚠️  No config file found, using defaults/env vars")
+		return nil
+	}
+
+	// 4. 展开 include 链：每个 include 文件按顺序先被合并进来，resolved 自己排在链尾，
+	// 这样它的键总是最后合并、优先级最高（参见 include.go）
+	chain, err := buildIncludeChain(resolved)
+	if err != nil {
+		return fmt.Errorf("fatal error config file: %w", err)
+	}
+	for _, path := range chain {
+		if err := mergeConfigFile(path); err != nil {
 			return fmt.Errorf("fatal error config file: %w", err)
 		}
-	} else {
-		fmt.Println("🔧 Using config file:", viper.ConfigFileUsed())
 	}
 
+	fmt.Println("🔧 Using config file:", resolved)
 	return nil
 }
 
+// resolveConfigFile 决定实际要加载的主配置文件：cfgFile 非空就直接用（文件必须存在，否则
+// 报错），否则按原来的优先级搜索，一个都找不到就返回空字符串（不算错误）
+func resolveConfigFile(cfgFile string) (string, error) {
+	if cfgFile != "" {
+		if _, err := os.Stat(cfgFile); err != nil {
+			return "", fmt.Errorf("fatal error config file: %w", err)
+		}
+		return cfgFile, nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	searchDirs := []string{".", ".tv", filepath.Join(home, ".tv")}
+
+	for _, dir := range searchDirs {
+		for _, name := range configNames {
+			candidate := filepath.Join(dir, name)
+			if _, err := os.Stat(candidate); err == nil {
+				return candidate, nil
+			}
+		}
+	}
+	return "", nil
+}
+
 func setDefaults() {
 	// 数据库默认值
 	viper.SetDefault("database.host", "localhost")
@@ -70,4 +95,30 @@ func setDefaults() {
 	defaultStorePath := filepath.Join(wd, ".tv", "objects")
 	viper.SetDefault("storage.path", defaultStorePath)
 	viper.SetDefault("storage.type", "disk")
+
+	// 进程内 LRU 原始字节缓存默认值 (pkg/storage/cache.LRUStore)
+	viper.SetDefault("storage.cache.max_bytes", 64*1024*1024)
+	viper.SetDefault("storage.cache.max_large_chunks", 16)
+
+	// Redis 层 Get 的 Blob 字节缓存阈值 (pkg/storage/cache.CachedStore)：
+	// 体积不超过这个值的对象 (Commit/Tree/小 FileNode) 才会把原始字节存进 Redis
+	viper.SetDefault("storage.cache.max_cached_blob_bytes", cache.DefaultMaxCachedBlobBytes)
+
+	// tv push 并发上传的 Worker 数量默认值，跟 Cloudreve 的上传并发设置取了同一个默认值
+	viper.SetDefault("transfer.max_parallel", 4)
+
+	// 新对象写入默认使用的哈希算法；sha256 保证新建的仓库跟老版本产出的行为完全一致
+	viper.SetDefault("hashing.algo", "sha256")
+
+	// 集群模式默认值：standalone 保证不配置 cluster.* 的老部署行为完全不变
+	viper.SetDefault("cluster.mode", "standalone")
+	viper.SetDefault("cluster.workers", []string{})
+	viper.SetDefault("cluster.heartbeat_interval", "5s")
+	viper.SetDefault("cluster.health_timeout", "15s")
+
+	// 内容定义分块算法默认值：gear 保证不配置 chunker.* 的老仓库切分边界完全不变
+	viper.SetDefault("chunker.algo", string(chunker.AlgoGear))
+	viper.SetDefault("chunker.min", chunker.MinSize)
+	viper.SetDefault("chunker.avg", chunker.AvgSize)
+	viper.SetDefault("chunker.max", chunker.MaxSize)
 }
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad_IncludeChain_LastFileWins 建一条三文件的 include 链：base -> middle -> leaf
+// (leaf 是显式传给 Load 的 cfgFile)，断言越靠后覆盖的文件优先级越高，且未被覆盖的键
+// 仍然从更早的文件里继承下来。
+func TestLoad_IncludeChain_LastFileWins(t *testing.T) {
+	viper.Reset()
+	dir := t.TempDir()
+
+	base := filepath.Join(dir, "base.yaml")
+	require.NoError(t, os.WriteFile(base, []byte(`
+database:
+  host: base-host
+  port: 1111
+storage:
+  type: disk
+`), 0644))
+
+	middle := filepath.Join(dir, "middle.yaml")
+	require.NoError(t, os.WriteFile(middle, []byte(`
+include:
+  - base.yaml
+database:
+  host: middle-host
+`), 0644))
+
+	leaf := filepath.Join(dir, "leaf.yaml")
+	require.NoError(t, os.WriteFile(leaf, []byte(`
+include:
+  - middle.yaml
+database:
+  port: 2222
+`), 0644))
+
+	require.NoError(t, Load(leaf))
+
+	// leaf 覆盖了 database.port，middle 覆盖了 database.host，base 的 storage.type 没人
+	// 覆盖，三层都应该体现在最终结果里
+	require.Equal(t, "middle-host", viper.GetString("database.host"))
+	require.Equal(t, 2222, viper.GetInt("database.port"))
+	require.Equal(t, "disk", viper.GetString("storage.type"))
+}
+
+func TestLoad_IncludeChain_InlineDirectiveAndAbsolutePath(t *testing.T) {
+	viper.Reset()
+	dir := t.TempDir()
+
+	base := filepath.Join(dir, "base.yaml")
+	require.NoError(t, os.WriteFile(base, []byte("remote:\n  server: base-server\n"), 0644))
+
+	leaf := filepath.Join(dir, "leaf.yaml")
+	content := "%include " + base + "\nremote:\n  server: leaf-server\n"
+	require.NoError(t, os.WriteFile(leaf, []byte(content), 0644))
+
+	require.NoError(t, Load(leaf))
+	require.Equal(t, "leaf-server", viper.GetString("remote.server"))
+}
+
+func TestLoad_IncludeChain_MissingOptionalIncludeIsSkipped(t *testing.T) {
+	viper.Reset()
+	dir := t.TempDir()
+
+	leaf := filepath.Join(dir, "leaf.yaml")
+	require.NoError(t, os.WriteFile(leaf, []byte(`
+include:
+  - "?missing.yaml"
+database:
+  host: leaf-host
+`), 0644))
+
+	require.NoError(t, Load(leaf))
+	require.Equal(t, "leaf-host", viper.GetString("database.host"))
+}
+
+func TestLoad_IncludeChain_MissingRequiredIncludeErrors(t *testing.T) {
+	viper.Reset()
+	dir := t.TempDir()
+
+	leaf := filepath.Join(dir, "leaf.yaml")
+	require.NoError(t, os.WriteFile(leaf, []byte("include:\n  - missing.yaml\n"), 0644))
+
+	err := Load(leaf)
+	require.Error(t, err)
+}
+
+func TestLoad_IncludeChain_CycleDetected(t *testing.T) {
+	viper.Reset()
+	dir := t.TempDir()
+
+	a := filepath.Join(dir, "a.yaml")
+	b := filepath.Join(dir, "b.yaml")
+	require.NoError(t, os.WriteFile(a, []byte("include:\n  - b.yaml\n"), 0644))
+	require.NoError(t, os.WriteFile(b, []byte("include:\n  - a.yaml\n"), 0644))
+
+	err := Load(a)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cycle")
+}
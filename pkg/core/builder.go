@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"fmt"
+)
+
+// K 是 FileNode 的扇出常数：叶子 Chunk（或者下一层的中间 FileNode）数量超过 K 时，Build
+// 会先把它们按 K 个一组打包成中间 FileNode（Pyramid Layout），再对这些中间节点的引用递归
+// 重复同样的分组，直到链接数收敛到 K 以内，才产出根节点。
+//
+// 不这样做的话，几百 GB 的模型权重会产出一个有几百万条 ChunkLink 的单一 FileNode：
+// CBOR 编码膨胀、解码时整棵链接表都要进内存、range-read 也得线性扫描全部链接才能定位。
+const K = 1024
+
+// Putter 是 FileNodeBuilder 持久化中间层 FileNode 所需要的最小能力
+//
+// 为什么不直接用 storage.Store？因为 pkg/storage 反过来依赖 pkg/core（Store.Put 接收
+// core.Object），直接导入会成环。这里定义一个只含 Put 的窄接口，storage.Store 天然满足它，
+// 调用方不需要做任何适配就能直接传进来。
+type Putter interface {
+	Put(ctx context.Context, obj Object) error
+}
+
+// FileNodeBuilder 封装了从 Chunk 组装 FileNode 的逻辑
+// 它是 ADL (Advanced Data Layout) 的具体实现者
+type FileNodeBuilder struct {
+	totalSize int64
+	chunks    []ChunkLink
+	hasher    Hasher
+}
+
+// NewFileNodeBuilder 用 hasher 指定的算法给构建出来的（含中间层）FileNode 算 hash——
+// 跟 Add 进来的 *Chunk 应该是用同一个 hasher 算出来的，调用方负责保证这一点一致
+func NewFileNodeBuilder(hasher Hasher) *FileNodeBuilder {
+	return &FileNodeBuilder{
+		chunks: make([]ChunkLink, 0, 100), // 预分配一点容量
+		hasher: hasher,
+	}
+}
+
+// Add 添加一个 Chunk 到构建序列中
+func (b *FileNodeBuilder) Add(c *Chunk) {
+	link := NewChunkLink(c)
+	b.chunks = append(b.chunks, link)
+	b.totalSize += int64(link.Size)
+}
+
+// Build 完成构建，生成不可变的根 FileNode。超过 K 个叶子时会自动产出中间层（见 BuildTree）。
+//
+// putter 用来持久化中间层节点；传 nil 时只在内存里计算出各层的 Hash，不发生任何 I/O —— 这是
+// 给 HashReader 这类只读场景用的：我们只想知道内容是否变了，不应该为了比较就产生新对象。
+// 根节点从不由 Build 自己持久化，调用方（跟以前一样）要自己把返回值 Put 进 Store。
+func (b *FileNodeBuilder) Build(ctx context.Context, putter Putter) (*FileNode, error) {
+	return BuildTree(ctx, putter, b.totalSize, b.chunks, b.hasher)
+}
+
+// BuildTree 把一组已经算好的 ChunkLink 组装成（必要时是 Pyramid 的）FileNode，
+// hasher 用于给（可能产生的）中间层和根 FileNode 算 hash
+//
+// 这是 FileNodeBuilder.Build 的底层实现，单独导出是因为有些调用方（比如 ingester 并发流水线
+// 的 collect 阶段）攒的已经是 ChunkLink 而不是原始 *Chunk，没法通过 Add 走 FileNodeBuilder。
+func BuildTree(ctx context.Context, putter Putter, totalSize int64, links []ChunkLink, hasher Hasher) (*FileNode, error) {
+	isInternal := false
+
+	for len(links) > K {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		next := make([]ChunkLink, 0, (len(links)+K-1)/K)
+		for i := 0; i < len(links); i += K {
+			end := i + K
+			if end > len(links) {
+				end = len(links)
+			}
+			batch := links[i:end]
+
+			var batchSize int64
+			for _, l := range batch {
+				batchSize += int64(l.Size)
+			}
+
+			node, err := NewFileNode(batchSize, batch, isInternal, hasher)
+			if err != nil {
+				return nil, fmt.Errorf("failed to build intermediate filenode: %w", err)
+			}
+			if putter != nil {
+				if err := putter.Put(ctx, node); err != nil {
+					return nil, fmt.Errorf("failed to persist intermediate filenode: %w", err)
+				}
+			}
+
+			next = append(next, ChunkLink{Hash: NewLink(node.ID()), Size: int(node.TotalSize)})
+		}
+
+		links = next
+		isInternal = true // 下一轮分组打包的是刚刚产出的中间节点，不再是叶子 Chunk
+	}
+
+	return NewFileNode(totalSize, links, isInternal, hasher)
+}
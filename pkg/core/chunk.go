@@ -9,9 +9,9 @@ type Chunk struct {
 	data []byte
 }
 
-func NewChunk(data []byte) *Chunk {
-	// 计算 Hash
-	h := CalculateBlobHash(data)
+// NewChunk 用 hasher 指定的算法给 data 算 Hash，构造一个 Chunk
+func NewChunk(data []byte, hasher Hasher) *Chunk {
+	h := CalculateBlobHash(data, hasher)
 	return &Chunk{
 		hash: h,
 		data: data,
@@ -1,10 +1,24 @@
 package core
 
 import (
+	"errors"
+	"fmt"
+	"tensorvault/pkg/identity"
 	"tensorvault/pkg/types"
 	"time"
 )
 
+// ErrNotSigned 表示调用方想验证一个根本没有签名的 Commit
+var ErrNotSigned = errors.New("core: commit is not signed")
+
+// Signature 是一个 Commit 的签名信息，跟 Commit 本身一起编码进同一个 DAG-CBOR 对象——
+// 这跟 git 把 gpgsig 头内嵌在 commit 对象里、再对整个对象取 hash 是同一个思路
+type Signature struct {
+	Algorithm   string `cbor:"alg"` // 对应 identity.Key.Algorithm()，"ed25519" / "openpgp"
+	Fingerprint string `cbor:"fp"`  // 对应 identity.Key.Fingerprint()
+	Sig         []byte `cbor:"sig"`
+}
+
 type Commit struct {
 	hash     types.Hash `cbor:"-"`
 	rawBytes []byte     `cbor:"-"`
@@ -20,9 +34,14 @@ type Commit struct {
 
 	// CHANGE: 使用 int64 明确时间戳类型
 	Timestamp int64 `cbor:"ts"`
+
+	// Signature 为空表示这个 Commit 没有签名。Sign 依赖 "先清空这个字段编码出被签名载荷，
+	// 再填回去重新算最终 hash" 的顺序；omitempty 让未签名的载荷里完全没有 "sg" 键，而
+	// encOptions 的 SortCanonical 按键排序编码，字段在结构体里的位置不影响编码结果
+	Signature *Signature `cbor:"sg,omitempty"`
 }
 
-func NewCommit(treeHash types.Hash, parents []types.Hash, author, msg string) (*Commit, error) {
+func NewCommit(treeHash types.Hash, parents []types.Hash, author, msg string, hasher Hasher) (*Commit, error) {
 	// 转换 parents string -> Link
 	parentLinks := make([]Link, len(parents))
 	for i, p := range parents {
@@ -38,7 +57,7 @@ func NewCommit(treeHash types.Hash, parents []types.Hash, author, msg string) (*
 		Timestamp: time.Now().Unix(), // 使用 Unix 时间戳
 	}
 
-	h, b, err := CalculateHash(c)
+	h, b, err := CalculateHash(c, hasher)
 	if err != nil {
 		return nil, err
 	}
@@ -50,3 +69,75 @@ func NewCommit(treeHash types.Hash, parents []types.Hash, author, msg string) (*
 func (c *Commit) Type() ObjectType { return TypeCommit }
 func (c *Commit) ID() types.Hash   { return c.hash }
 func (c *Commit) Bytes() []byte    { return c.rawBytes }
+
+// signingPayload 编码一份 Signature 字段为空的 Commit 字节，供 Sign/VerifySignature 共用。
+// 传进来一个值拷贝而不是直接改 c：Commit 在构造阶段就已经确定了 hash，调用方在 Sign 失败
+// 时不应该看到 c 本身被改坏
+func signingPayload(c Commit) ([]byte, error) {
+	c.Signature = nil
+	payload, err := em.Marshal(&c)
+	if err != nil {
+		return nil, fmt.Errorf("commit: failed to marshal signing payload: %w", err)
+	}
+	return payload, nil
+}
+
+// Sign 用 key 对这个 Commit 签名：对"去掉 Signature 字段"的载荷签名，把结果连同算法/
+// 指纹一起填回 Signature，再重新算一遍 hash/rawBytes——调用之后 ID()/Bytes() 已经是
+// 包含签名的最终形态，不需要调用方再补一步 CalculateHash。
+//
+// 重新算 hash 要用 NewCommit 当初算 c.hash 用的同一个算法，不是调用方随便传一个——否则
+// 签名前后这个 Commit 对象的 CID 所用算法会不一致。c.hash 本身的标签（Hash.Algo()）就是
+// 这份真相，不需要 Sign 再额外接收一个 hasher 参数
+func (c *Commit) Sign(key identity.Key) error {
+	hasher, err := HasherFor(c.hash.Algo())
+	if err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+
+	payload, err := signingPayload(*c)
+	if err != nil {
+		return err
+	}
+	sig, err := key.Sign(payload)
+	if err != nil {
+		return fmt.Errorf("commit: failed to sign: %w", err)
+	}
+	c.Signature = &Signature{
+		Algorithm:   key.Algorithm(),
+		Fingerprint: key.Fingerprint(),
+		Sig:         sig,
+	}
+
+	h, b, err := CalculateHash(c, hasher)
+	if err != nil {
+		return err
+	}
+	c.hash = h
+	c.rawBytes = b
+	return nil
+}
+
+// VerifySignature 校验这个 Commit 当前的签名是否是 key 对应私钥产出的合法签名。
+// 没有签名时返回 ErrNotSigned，而不是把"没签名"和"签名校验失败"混在一个错误里——
+// 调用方（比如 refs.Manager 的允许列表检查）往往需要区分这两种情况分别报错
+func (c *Commit) VerifySignature(key identity.Key) error {
+	if c.Signature == nil {
+		return ErrNotSigned
+	}
+	payload, err := signingPayload(*c)
+	if err != nil {
+		return err
+	}
+	if err := key.Verify(payload, c.Signature.Sig); err != nil {
+		return fmt.Errorf("commit: %w", err)
+	}
+	return nil
+}
+
+// SignedBy 是 VerifySignature 的布尔版本：调用方只想知道"是不是 key 签的"，不关心是
+// 没签名还是签名不匹配这两种失败原因的区别时用这个，比如 refs.Manager 遍历允许列表
+// 找一个能通过校验的签名者
+func (c *Commit) SignedBy(key identity.Key) bool {
+	return c.VerifySignature(key) == nil
+}
@@ -0,0 +1,66 @@
+package core
+
+import (
+	"tensorvault/pkg/identity"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommit_Sign_VerifySignature(t *testing.T) {
+	key, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+
+	c := mustNewCommit(t, mockHash("tree"), nil, "alice", "init")
+	unsignedHash := c.ID()
+
+	require.NoError(t, c.Sign(key))
+
+	assert.NotEmpty(t, c.Signature.Sig)
+	assert.Equal(t, "ed25519", c.Signature.Algorithm)
+	assert.Equal(t, key.Fingerprint(), c.Signature.Fingerprint)
+	// 签名之后 hash 必须跟签名前不一样——Signature 本身也是被哈希对象的一部分
+	assert.NotEqual(t, unsignedHash, c.ID())
+
+	require.NoError(t, c.VerifySignature(key))
+	assert.True(t, c.SignedBy(key))
+}
+
+func TestCommit_VerifySignature_NotSigned(t *testing.T) {
+	key, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+
+	c := mustNewCommit(t, mockHash("tree"), nil, "alice", "init")
+
+	assert.ErrorIs(t, c.VerifySignature(key), ErrNotSigned)
+	assert.False(t, c.SignedBy(key))
+}
+
+func TestCommit_VerifySignature_WrongKey(t *testing.T) {
+	key, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+	otherKey, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+
+	c := mustNewCommit(t, mockHash("tree"), nil, "alice", "init")
+	require.NoError(t, c.Sign(key))
+
+	assert.Error(t, c.VerifySignature(otherKey))
+	assert.False(t, c.SignedBy(otherKey))
+}
+
+func TestCommit_RoundTrip_PreservesSignature(t *testing.T) {
+	key, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+
+	c := mustNewCommit(t, mockHash("tree"), nil, "alice", "init")
+	require.NoError(t, c.Sign(key))
+
+	var decoded Commit
+	require.NoError(t, DecodeObject(c.Bytes(), &decoded))
+
+	require.NotNil(t, decoded.Signature)
+	assert.Equal(t, c.Signature.Fingerprint, decoded.Signature.Fingerprint)
+	require.NoError(t, decoded.VerifySignature(key))
+}
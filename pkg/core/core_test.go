@@ -110,7 +110,7 @@ func TestFileNode_RoundTrip(t *testing.T) {
 		{Cid: NewLink(mockHash("chunk2")), Size: 2048},
 	}
 
-	node, err := NewFileNode(3072, chunks)
+	node, err := NewFileNode(3072, chunks, false, testHasher(t))
 	require.NoError(t, err)
 
 	encoded := node.Bytes()
@@ -161,7 +161,7 @@ func TestCalculateBlobHash(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got := CalculateBlobHash(tt.input)
+			got := CalculateBlobHash(tt.input, testHasher(t))
 
 			// 1. 验证 Hash 值的正确性 (Regression Test)
 			assert.Equal(t, tt.wantHash, got.String())
@@ -191,7 +191,7 @@ func TestInterfaceCompliance(t *testing.T) {
 
 func TestObject_RoundTrip_Chunk(t *testing.T) {
 	data := []byte("some random binary data")
-	chunk := NewChunk(data)
+	chunk := NewChunk(data, testHasher(t))
 
 	// 验证类型
 	assert.Equal(t, TypeChunk, chunk.Type())
@@ -212,7 +212,7 @@ func TestObject_RoundTrip_Tree(t *testing.T) {
 	// 注意：我们的 Tree 实现目前假设 entries 传入时是什么样就是什么样，或者 treebuilder 会排序
 	// 这里主要测试序列化和反序列化能不能还原
 
-	originalTree, err := NewTree(entries)
+	originalTree, err := NewTree(entries, testHasher(t))
 	require.NoError(t, err)
 
 	// 1. 序列化
@@ -237,7 +237,7 @@ func TestObject_RoundTrip_Commit(t *testing.T) {
 		"4444444444444444444444444444444444444444444444444444444444444444",
 	}
 
-	originalCommit, err := NewCommit(treeHash, parents, "Tester", "Test Message")
+	originalCommit, err := NewCommit(treeHash, parents, "Tester", "Test Message", testHasher(t))
 	require.NoError(t, err)
 
 	// 1. 序列化
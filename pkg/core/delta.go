@@ -0,0 +1,244 @@
+package core
+
+import (
+	"bytes"
+	"fmt"
+
+	"tensorvault/pkg/types"
+)
+
+// DeltaObject 用一段 COPY/INSERT 指令流，把一个 Chunk 描述为相对某个 base Chunk 的差异
+// 相邻版本的模型文件往往会产生大量内容相似但不完全相同的 Chunk（比如微调后的权重），
+// 这类"近似重复"在内容寻址下是不同的 Hash，但存成 Delta 可以省下大部分存储空间。
+//
+// 编码风格模仿 Git packfile 的 delta 格式：
+//   - 指令首字节 bit7 = 1 -> COPY：接下来的 4 个 presence-bit（bit0-3）决定 offset
+//     占用几个字节，3 个 presence-bit（bit4-6）决定 length 占用几个字节，值按 little-endian 拼出
+//   - 指令首字节 bit7 = 0 -> INSERT：低 7 位就是接下来字面量字节的个数 (1-127)
+type DeltaObject struct {
+	hash     types.Hash `cbor:"-"` // 不参与序列化
+	rawBytes []byte     `cbor:"-"` // 缓存序列化后的数据
+
+	TypeVal      ObjectType `cbor:"t"`  // 必须是 "delta"
+	BaseHash     Link       `cbor:"b"`  // 指向 base chunk，base 本身也可能是个 DeltaObject（形成链）
+	TargetSize   int64      `cbor:"ts"` // 还原后的大小，用于校验/预分配 buffer
+	Instructions []byte     `cbor:"i"`  // COPY/INSERT 指令流
+}
+
+// NewDeltaObject 创建一个新的 Delta 对象，hash 用 hasher 指定的算法计算
+func NewDeltaObject(baseHash types.Hash, targetSize int64, instructions []byte, hasher Hasher) (*DeltaObject, error) {
+	d := &DeltaObject{
+		TypeVal:      TypeDelta,
+		BaseHash:     NewLink(baseHash),
+		TargetSize:   targetSize,
+		Instructions: instructions,
+	}
+	h, b, err := CalculateHash(d, hasher)
+	if err != nil {
+		return nil, err
+	}
+	d.hash = h
+	d.rawBytes = b
+	return d, nil
+}
+
+func (d *DeltaObject) Type() ObjectType { return TypeDelta }
+func (d *DeltaObject) ID() types.Hash   { return d.hash }
+func (d *DeltaObject) Bytes() []byte    { return d.rawBytes }
+
+// ApplyDelta 用 base 的内容回放 instructions，重建出原始数据
+// targetSize 只用来做一次完整性校验，不信任指令流本身声称的长度
+func ApplyDelta(base []byte, instructions []byte, targetSize int64) ([]byte, error) {
+	out := make([]byte, 0, targetSize)
+
+	i := 0
+	for i < len(instructions) {
+		opcode := instructions[i]
+		i++
+
+		if opcode&0x80 != 0 {
+			// COPY：从 opcode 的 presence-bit 里拼出 offset/length
+			var offset, length int
+			for bit := 0; bit < 4; bit++ {
+				if opcode&(1<<uint(bit)) == 0 {
+					continue
+				}
+				if i >= len(instructions) {
+					return nil, fmt.Errorf("delta: truncated copy offset")
+				}
+				offset |= int(instructions[i]) << uint(8*bit)
+				i++
+			}
+			for bit := 0; bit < 3; bit++ {
+				if opcode&(1<<uint(4+bit)) == 0 {
+					continue
+				}
+				if i >= len(instructions) {
+					return nil, fmt.Errorf("delta: truncated copy length")
+				}
+				length |= int(instructions[i]) << uint(8*bit)
+				i++
+			}
+			if offset < 0 || length < 0 || offset+length > len(base) {
+				return nil, fmt.Errorf("delta: copy range [%d,%d) exceeds base size %d", offset, offset+length, len(base))
+			}
+			out = append(out, base[offset:offset+length]...)
+			continue
+		}
+
+		// INSERT：低 7 位是接下来的字面量字节数 (1-127)
+		length := int(opcode & 0x7f)
+		if length == 0 || i+length > len(instructions) {
+			return nil, fmt.Errorf("delta: invalid insert length %d", length)
+		}
+		out = append(out, instructions[i:i+length]...)
+		i += length
+	}
+
+	if int64(len(out)) != targetSize {
+		return nil, fmt.Errorf("delta: reconstructed size %d does not match expected %d", len(out), targetSize)
+	}
+	return out, nil
+}
+
+// deltaBlockSize 是 ComputeDelta 建索引时使用的定长块大小
+// 块越小，找到的共同片段越精细，但索引和编码本身的开销也越大
+const deltaBlockSize = 16
+
+// ComputeDelta 贪心地把 target 编码成相对 base 的 COPY/INSERT 指令流
+// 思路是 git delta 算法的简化版：
+//  1. 把 base 切成定长块，建立 块指纹 -> offset 的索引
+//  2. 从 target 开头扫描，命中索引就向两边尽量扩展成一段连续的 COPY，
+//     扩展不动、也没命中的字节，攒成一段 INSERT 再落盘
+func ComputeDelta(base, target []byte) []byte {
+	index := make(map[uint64][]int)
+	for off := 0; off+deltaBlockSize <= len(base); off += deltaBlockSize {
+		h := fnvBlockHash(base[off : off+deltaBlockSize])
+		index[h] = append(index[h], off)
+	}
+
+	var out []byte
+	var pending []byte
+	flushInsert := func() {
+		for len(pending) > 0 {
+			n := len(pending)
+			if n > 0x7f {
+				n = 0x7f
+			}
+			out = append(out, byte(n))
+			out = append(out, pending[:n]...)
+			pending = pending[n:]
+		}
+	}
+
+	i := 0
+	for i < len(target) {
+		if i+deltaBlockSize > len(target) {
+			pending = append(pending, target[i:]...)
+			break
+		}
+
+		h := fnvBlockHash(target[i : i+deltaBlockSize])
+		bestOff, bestLen := -1, 0
+		for _, candOff := range index[h] {
+			if !bytes.Equal(base[candOff:candOff+deltaBlockSize], target[i:i+deltaBlockSize]) {
+				continue // 哈希碰撞，跳过
+			}
+			length := deltaBlockSize
+			for candOff+length < len(base) && i+length < len(target) && base[candOff+length] == target[i+length] {
+				length++
+			}
+			if length > bestLen {
+				bestOff, bestLen = candOff, length
+			}
+		}
+
+		if bestOff < 0 {
+			pending = append(pending, target[i])
+			i++
+			continue
+		}
+		flushInsert()
+		out = append(out, encodeCopy(bestOff, bestLen)...)
+		i += bestLen
+	}
+	flushInsert()
+	return out
+}
+
+// encodeCopy 编码一条 COPY 指令：从 base 的 [offset, offset+length) 复制到输出
+func encodeCopy(offset, length int) []byte {
+	opcode := byte(0x80)
+	buf := make([]byte, 1, 9)
+
+	for bit := 0; bit < 4; bit++ {
+		b := byte(offset >> uint(8*bit))
+		if b != 0 {
+			opcode |= 1 << uint(bit)
+			buf = append(buf, b)
+		}
+	}
+	for bit := 0; bit < 3; bit++ {
+		b := byte(length >> uint(8*bit))
+		if b != 0 {
+			opcode |= 1 << uint(4+bit)
+			buf = append(buf, b)
+		}
+	}
+
+	buf[0] = opcode
+	return buf
+}
+
+// fnvBlockHash 是 FNV-1a，只用来做块指纹，不要求密码学强度
+func fnvBlockHash(b []byte) uint64 {
+	var h uint64 = 14695981039346656037
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= 1099511628211
+	}
+	return h
+}
+
+// BaseCandidate 是 repack 扫描时参与比较的候选 base：Chunk 的 Hash + 原始内容
+type BaseCandidate struct {
+	Hash types.Hash
+	Data []byte
+}
+
+// SelectBase 从 candidates 里挑一个最适合做 target 的 delta base
+// 启发式对齐 go-git packfile 的 size-and-prefix 思路：
+//  1. 大小比值差太远的直接剔除（delta 收益通常很差，不值得编码和解链的开销）
+//  2. 剩下的里选公共前缀最长的一个
+func SelectBase(target []byte, candidates []BaseCandidate) (BaseCandidate, bool) {
+	var best BaseCandidate
+	bestPrefix := -1
+
+	for _, c := range candidates {
+		if len(c.Data) == 0 || len(target) == 0 {
+			continue
+		}
+		ratio := float64(len(c.Data)) / float64(len(target))
+		if ratio < 0.5 || ratio > 2.0 {
+			continue
+		}
+		if prefix := commonPrefixLen(c.Data, target); prefix > bestPrefix {
+			bestPrefix = prefix
+			best = c
+		}
+	}
+
+	return best, bestPrefix >= 0
+}
+
+func commonPrefixLen(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	i := 0
+	for i < n && a[i] == b[i] {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,74 @@
+package core
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeDelta_RoundTrip(t *testing.T) {
+	base := []byte("the quick brown fox jumps over the lazy dog, version one of the weights file")
+	target := []byte("the quick brown fox jumps over the lazy dog, version TWO of the weights file")
+
+	instructions := ComputeDelta(base, target)
+	restored, err := ApplyDelta(base, instructions, int64(len(target)))
+	require.NoError(t, err)
+	require.Equal(t, target, restored)
+
+	// 两个版本相似度很高，编码后应当远小于完整存一份 target
+	require.Less(t, len(instructions), len(target))
+}
+
+func TestComputeDelta_NoSimilarity(t *testing.T) {
+	base := []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa")
+	target := []byte("zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz")
+
+	instructions := ComputeDelta(base, target)
+	restored, err := ApplyDelta(base, instructions, int64(len(target)))
+	require.NoError(t, err)
+	require.Equal(t, target, restored)
+}
+
+func TestApplyDelta_SizeMismatchIsRejected(t *testing.T) {
+	base := []byte("0123456789")
+	instructions := ComputeDelta(base, []byte("0123456789"))
+
+	_, err := ApplyDelta(base, instructions, 999)
+	require.Error(t, err)
+}
+
+func TestNewDeltaObject(t *testing.T) {
+	baseHash := mockHash("base-chunk")
+	instructions := []byte{0x01, 'x'}
+
+	d, err := NewDeltaObject(baseHash, 1, instructions, testHasher(t))
+	require.NoError(t, err)
+	require.Equal(t, TypeDelta, d.Type())
+	require.NotEmpty(t, d.ID())
+	require.NotEmpty(t, d.Bytes())
+
+	var decoded DeltaObject
+	require.NoError(t, DecodeObject(d.Bytes(), &decoded))
+	require.Equal(t, TypeDelta, decoded.TypeVal)
+	require.Equal(t, baseHash, decoded.BaseHash.Hash)
+	require.Equal(t, int64(1), decoded.TargetSize)
+	require.Equal(t, instructions, decoded.Instructions)
+}
+
+func TestSelectBase(t *testing.T) {
+	target := []byte("abcdefghij-v3")
+	candidates := []BaseCandidate{
+		{Hash: mockHash("too-small"), Data: []byte("ab")},
+		{Hash: mockHash("unrelated"), Data: []byte("zzzzzzzzzzzzz")},
+		{Hash: mockHash("close-match"), Data: []byte("abcdefghij-v2")},
+	}
+
+	best, ok := SelectBase(target, candidates)
+	require.True(t, ok)
+	require.Equal(t, mockHash("close-match"), best.Hash)
+}
+
+func TestSelectBase_NoCandidates(t *testing.T) {
+	_, ok := SelectBase([]byte("anything"), nil)
+	require.False(t, ok)
+}
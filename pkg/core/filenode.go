@@ -1,9 +1,11 @@
 package core
 
-// ChunkLink 描述了 FileNode 对底层 Chunk 的引用
+import "tensorvault/pkg/types"
+
+// ChunkLink 描述了 FileNode 对底层 Chunk（或者，在 Pyramid 布局里，对另一个中间 FileNode）的引用
 type ChunkLink struct {
 	Hash Link `cbor:"h"` // CHANGE: string -> Link
-	Size int  `cbor:"s"` // 这个 Chunk 的大小 (关键：用于计算 offset)
+	Size int  `cbor:"s"` // 这个 Chunk（或子 FileNode）覆盖的字节数，关键：用于计算 offset
 }
 
 // NewChunkLink 从一个物理 Chunk 对象生成引用链接
@@ -18,25 +20,31 @@ func NewChunkLink(c *Chunk) ChunkLink {
 }
 
 // FileNode (ADL) 将散乱的 Chunk 组装成一个逻辑上的大文件
+//
+// 超大文件 (数百万个 Chunk) 会被组织成一棵 Pyramid 树而不是单个扁平节点：IsInternal 为 true
+// 时，Chunks 里的每个 Link 指向的是另一个 FileNode，而不是叶子 Chunk。旧版本写入的对象没有
+// 这个字段，解码后默认为 false，自动落回原来的扁平语义 —— 这是这棵树的退化 (depth-1) 情况。
 type FileNode struct {
 	// 自身标识
-	hash     string `cbor:"-"` // 不参与序列化
-	rawBytes []byte `cbor:"-"` // 缓存序列化后的数据
+	hash     types.Hash `cbor:"-"` // 不参与序列化
+	rawBytes []byte     `cbor:"-"` // 缓存序列化后的数据
 
 	// 核心数据
-	TypeVal   ObjectType  `cbor:"t"`  // 必须是 "filenode"
-	TotalSize int64       `cbor:"ts"` // 文件总大小
-	Chunks    []ChunkLink `cbor:"cs"` // 所有的切片引用
+	TypeVal    ObjectType  `cbor:"t"`  // 必须是 "filenode"
+	TotalSize  int64       `cbor:"ts"` // 文件总大小
+	Chunks     []ChunkLink `cbor:"cs"` // 子节点引用：叶子 Chunk 或者（当 IsInternal 时）子 FileNode
+	IsInternal bool        `cbor:"in"` // true 表示 Chunks 指向子 FileNode 而不是叶子 Chunk
 }
 
-// NewFileNode 创建一个新的文件索引节点
-func NewFileNode(totalSize int64, chunks []ChunkLink) (*FileNode, error) {
+// NewFileNode 创建一个新的文件索引节点，hash 用 hasher 指定的算法计算
+func NewFileNode(totalSize int64, chunks []ChunkLink, isInternal bool, hasher Hasher) (*FileNode, error) {
 	node := &FileNode{
-		TypeVal:   TypeFileNode,
-		TotalSize: totalSize,
-		Chunks:    chunks,
+		TypeVal:    TypeFileNode,
+		TotalSize:  totalSize,
+		Chunks:     chunks,
+		IsInternal: isInternal,
 	}
-	h, b, err := CalculateHash(node)
+	h, b, err := CalculateHash(node, hasher)
 	if err != nil {
 		return nil, err
 	}
@@ -46,38 +54,6 @@ func NewFileNode(totalSize int64, chunks []ChunkLink) (*FileNode, error) {
 }
 
 func (f *FileNode) Type() ObjectType { return TypeFileNode }
-func (f *FileNode) ID() string       { return f.hash }
+func (f *FileNode) ID() types.Hash   { return f.hash }
 func (f *FileNode) Bytes() []byte    { return f.rawBytes }
 func (f *FileNode) Size() int64      { return f.TotalSize }
-
-// pkg/core/builder.go
-
-// FileNodeBuilder 封装了从 Chunk 组装 FileNode 的逻辑
-// 它是 ADL (Advanced Data Layout) 的具体实现者
-type FileNodeBuilder struct {
-	totalSize int64
-	chunks    []ChunkLink
-}
-
-func NewFileNodeBuilder() *FileNodeBuilder {
-	return &FileNodeBuilder{
-		chunks: make([]ChunkLink, 0, 100), // 预分配一点容量
-	}
-}
-
-// Add 添加一个 Chunk 到构建序列中
-func (b *FileNodeBuilder) Add(c *Chunk) {
-	link := NewChunkLink(c)
-	b.chunks = append(b.chunks, link)
-	b.totalSize += int64(link.Size)
-}
-
-// Build 完成构建，生成不可变的 FileNode
-func (b *FileNodeBuilder) Build() (*FileNode, error) {
-	// Phase 3 伏笔：
-	// 在这里，如果 len(b.chunks) > 10000，
-	// 我们可以自动把它拆分成中间节点 (Intermediate Nodes)，构建成树。
-	// 但对调用者来说，这一切都是透明的。
-
-	return NewFileNode(b.totalSize, b.chunks)
-}
@@ -1,9 +1,8 @@
 package core
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
+	"strings"
 	"tensorvault/pkg/types"
 
 	"github.com/fxamacker/cbor/v2"
@@ -60,24 +59,41 @@ var decOptions = cbor.DecOptions{
 // 导出 dm 供包内部使用 (如 link.go)
 var dm, _ = decOptions.DecMode()
 
-// CalculateHash 计算对象的 Hash (CID) 和序列化数据
-func CalculateHash(v any) (types.Hash, []byte, error) {
+// CalculateHash 计算对象的 Hash (CID) 和序列化数据，使用调用方传入的 hasher 指定的算法——
+// 哪个算法是这个仓库锁定要用的，由 pkg/app.App.Hasher 决定，core 包自己不记这份状态
+func CalculateHash(v any, hasher Hasher) (types.Hash, []byte, error) {
 	data, err := em.Marshal(v)
 	if err != nil {
 		return "", nil, fmt.Errorf("failed to marshal object: %w", err)
 	}
 
-	// 计算 SHA-256
-	hashBytes := sha256.Sum256(data)
-	hashStr := hex.EncodeToString(hashBytes[:])
+	return types.NewCID(hasher.Algo(), hasher.Sum(data)), data, nil
+}
 
-	return types.Hash(hashStr), data, nil
+// CalculateBlobHash 计算原始数据块的 Hash，使用调用方传入的 hasher 指定的算法
+func CalculateBlobHash(data []byte, hasher Hasher) types.Hash {
+	return types.NewCID(hasher.Algo(), hasher.Sum(data))
 }
 
-// CalculateBlobHash 计算原始数据块的 Hash
-func CalculateBlobHash(data []byte) types.Hash {
-	hashBytes := sha256.Sum256(data)
-	return types.Hash(hex.EncodeToString(hashBytes[:]))
+// ValidateHashPrefix 校验用户敲的（可能缩写的）哈希前缀：如果带显式的 "<algo>:" 标签，
+// 必须是已注册的算法，并且跟 active（这个仓库锁定的算法，见 pkg/app.App.Hasher）一致。
+// 不带标签的裸十六进制前缀永远放行——那是沿用至今的隐式 SHA-256 写法。放在 ExpandHash
+// 真正去 Store 里扫之前做这个检查，是因为一个算法不匹配的前缀不可能匹配到任何对象，
+// 与其让每个后端各自扫一遍分片目录再报 NotFound，不如在输入边界上提前一步说清楚原因
+func ValidateHashPrefix(prefix types.HashPrefix, active types.HashAlgo) error {
+	raw := string(prefix)
+	idx := strings.IndexByte(raw, ':')
+	if idx < 0 {
+		return nil
+	}
+	algo, ok := types.ParseHashAlgo(raw[:idx])
+	if !ok {
+		return fmt.Errorf("unknown hash algorithm %q in prefix %q", raw[:idx], raw)
+	}
+	if algo != active {
+		return fmt.Errorf("hash prefix %q uses %s, but this repository is locked to %s", raw, algo, active)
+	}
+	return nil
 }
 
 // DecodeObject 通用的解码函数 (供外部使用)
@@ -0,0 +1,51 @@
+package core
+
+import (
+	"crypto/sha256"
+	"fmt"
+
+	"tensorvault/pkg/types"
+
+	"github.com/zeebo/blake3"
+)
+
+// Hasher 计算一段序列化数据的摘要，产出的字节直接喂给 types.NewCID
+type Hasher interface {
+	Algo() types.HashAlgo
+	Sum(data []byte) []byte
+}
+
+type sha256Hasher struct{}
+
+func (sha256Hasher) Algo() types.HashAlgo { return types.AlgoSHA256 }
+func (sha256Hasher) Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+type blake3Hasher struct{}
+
+func (blake3Hasher) Algo() types.HashAlgo { return types.AlgoBLAKE3 }
+func (blake3Hasher) Sum(data []byte) []byte {
+	sum := blake3.Sum256(data)
+	return sum[:]
+}
+
+// hashers 是已注册的算法表；新增算法在这里加一个条目就行，不需要改 CalculateHash
+var hashers = map[types.HashAlgo]Hasher{
+	types.AlgoSHA256: sha256Hasher{},
+	types.AlgoBLAKE3: blake3Hasher{},
+}
+
+// HasherFor 按算法名取对应的 Hasher 实例。每个仓库（App/Store）用哪个算法写新对象是
+// pkg/app.NewApp 在启动时按配置锁定的一份状态，不再是这个包里的一个包级全局——同一个
+// 进程同时打开两个 hashing.algo 不同的仓库（比如测试、或者 serve 模式下的多租户）不会
+// 互相脏写对方的默认算法。读路径不受影响——DecodeObject/Link.UnmarshalCBOR 总是从数据
+// 本身携带的算法标签还原，混有两种算法对象的仓库永远能正常读
+func HasherFor(algo types.HashAlgo) (Hasher, error) {
+	h, ok := hashers[algo]
+	if !ok {
+		return nil, fmt.Errorf("core: unregistered hash algorithm %s", algo)
+	}
+	return h, nil
+}
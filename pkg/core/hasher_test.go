@@ -0,0 +1,58 @@
+package core
+
+import (
+	"strings"
+	"testing"
+
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasherFor_SwitchesHashOutput(t *testing.T) {
+	sha256Hasher, err := HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	h := CalculateBlobHash([]byte("weights"), sha256Hasher)
+	assert.False(t, strings.Contains(h.String(), ":"), "sha256 is the untagged algo, its hashes stay bare")
+
+	blake3Hasher, err := HasherFor(types.AlgoBLAKE3)
+	require.NoError(t, err)
+	h = CalculateBlobHash([]byte("weights"), blake3Hasher)
+	assert.True(t, strings.HasPrefix(h.String(), "blake3:"))
+}
+
+func TestHasherFor_UnknownAlgoIsRejected(t *testing.T) {
+	_, err := HasherFor(types.HashAlgo(99))
+	require.Error(t, err)
+}
+
+func TestLink_CBORRoundTrip_PreservesAlgo(t *testing.T) {
+	for _, algo := range []types.HashAlgo{types.AlgoSHA256, types.AlgoBLAKE3} {
+		hasher, err := HasherFor(algo)
+		require.NoError(t, err)
+		hash := CalculateBlobHash([]byte("a tensor chunk"), hasher)
+
+		link := NewLink(hash)
+		data, err := link.MarshalCBOR()
+		require.NoError(t, err)
+
+		var decoded Link
+		require.NoError(t, decoded.UnmarshalCBOR(data))
+		assert.Equal(t, hash, decoded.Hash)
+		assert.Equal(t, algo, decoded.Hash.Algo())
+	}
+}
+
+func TestAlgoFromMulticodec_RejectsUnknownCode(t *testing.T) {
+	_, err := types.AlgoFromMulticodec(0xff)
+	require.Error(t, err)
+}
+
+func TestValidateHashPrefix(t *testing.T) {
+	assert.NoError(t, ValidateHashPrefix(types.HashPrefix("a8fd"), types.AlgoSHA256), "untagged prefixes always pass, regardless of active algo")
+
+	assert.NoError(t, ValidateHashPrefix(types.HashPrefix("blake3:a8fd"), types.AlgoBLAKE3))
+	assert.Error(t, ValidateHashPrefix(types.HashPrefix("sha256:a8fd"), types.AlgoBLAKE3), "prefix algo must match the repo's active algo")
+	assert.Error(t, ValidateHashPrefix(types.HashPrefix("md5:a8fd"), types.AlgoBLAKE3), "unregistered algo names are rejected, not silently accepted")
+}
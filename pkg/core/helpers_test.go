@@ -20,18 +20,26 @@ func mockHash(input string) types.Hash {
 	return types.Hash(hex.EncodeToString(sum[:]))
 }
 
+// testHasher 是测试用的默认哈希算法，跟仓库未配置 hashing.algo 时的隐式默认值一致
+func testHasher(t *testing.T) Hasher {
+	t.Helper()
+	hasher, err := HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	return hasher
+}
+
 // mustNewCommit 创建 Commit，如果失败直接终止测试
 // 这让主测试代码极其干净
 func mustNewCommit(t *testing.T, treeHash types.Hash, parents []types.Hash, author, msg string, msgAndArgs ...any) *Commit {
 	t.Helper()
-	c, err := NewCommit(treeHash, parents, author, msg)
+	c, err := NewCommit(treeHash, parents, author, msg, testHasher(t))
 	require.NoError(t, err, msgAndArgs...) // 透传消息
 	return c
 }
 
 func mustCalculateHash(t *testing.T, obj Object, msgAndArgs ...any) (types.Hash, []byte) {
 	t.Helper()
-	h, bytes, err := CalculateHash(obj)
+	h, bytes, err := CalculateHash(obj, testHasher(t))
 	require.NoError(t, err, msgAndArgs...)
 	return h, bytes
 }
@@ -4,37 +4,44 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"tensorvault/pkg/types"
+
 	"github.com/fxamacker/cbor/v2"
 )
 
 // Link 代表 Merkle DAG 中的一条边 (指向子节点的哈希引用)
-// 在 Go 层面，它只是一个包装了 Hash 字符串的结构体
-// 在 CBOR 层面，它会被序列化为 Tag 42(0x00 + HashBytes)
+// 在 Go 层面，它包装了一个 types.Hash（也就是一个可能带算法标签的 CID）
+// 在 CBOR 层面，它会被序列化为 Tag 42(0x00 + algoCode + digestBytes)
 type Link struct {
-	Hash string
+	Hash types.Hash
 }
 
 const (
 	linkTagNumber = 42
+
+	// multibaseIdentity 跟以前一样，是 IPFS CIDv1 风格的 0x00 前缀，表示后面紧跟的是
+	// 原始字节而不是经过 base-N 编码的文本
+	multibaseIdentity = 0x00
 )
 
 // NewLink 辅助函数
-func NewLink(hash string) Link {
+func NewLink(hash types.Hash) Link {
 	return Link{Hash: hash}
 }
 
 // MarshalCBOR 实现自定义序列化逻辑
-// 规范：Tag 42, Content = [0x00, byte1, byte2...]
+// 规范：Tag 42, Content = [0x00, algoCode, digestBytes...]
 func (l Link) MarshalCBOR() ([]byte, error) {
-	// 1. 解码 Hex 字符串
-	hashBytes, err := hex.DecodeString(l.Hash)
+	// 1. 解码摘要部分的 Hex 字符串（算法标签——如果有的话——已经被 Digest 去掉了）
+	hashBytes, err := hex.DecodeString(l.Hash.Digest())
 	if err != nil {
 		return nil, fmt.Errorf("invalid hash format in link: %w", err)
 	}
 
-	// 2. 添加 Multibase Identity 前缀 (0x00)
-	// 这是 IPFS CIDv1 的要求，表示后面紧跟的是原始哈希
-	cidBytes := append([]byte{0x00}, hashBytes...)
+	// 2. 添加 Multibase Identity 前缀 + 算法标签字节（types.HashAlgo.Multicodec()，
+	// 跟 UnmarshalCBOR 里 types.AlgoFromMulticodec 用的是同一套码表），算法信息跟着二进制内容走，
+	// 不依赖外部 side channel
+	cidBytes := append([]byte{multibaseIdentity, l.Hash.Algo().Multicodec()}, hashBytes...)
 
 	// 3. 包装为 Tag 42
 	// cbor.Tag 会被库自动处理为 Major Type 6
@@ -63,15 +70,19 @@ func (l *Link) UnmarshalCBOR(data []byte) error {
 		return fmt.Errorf("link content must be byte string")
 	}
 
-	// 3. 严格校验 Multibase 前缀
-	if len(bytes) < 1 {
-		return fmt.Errorf("invalid link: empty content")
+	// 3. 严格校验 Multibase 前缀 + 算法标签字节
+	if len(bytes) < 2 {
+		return fmt.Errorf("invalid link: content too short")
 	}
-	if bytes[0] != 0x00 {
+	if bytes[0] != multibaseIdentity {
 		return fmt.Errorf("invalid link: missing 0x00 multibase prefix")
 	}
+	algo, err := types.AlgoFromMulticodec(bytes[1])
+	if err != nil {
+		return fmt.Errorf("invalid link: %w", err)
+	}
 
-	// 4. 还原 Hash (去掉前缀)
-	l.Hash = hex.EncodeToString(bytes[1:])
+	// 4. 还原 Hash：老仓库写下的全是 SHA-256，组出来的 CID 裸字符串跟迁移前完全一样
+	l.Hash = types.NewCID(algo, bytes[2:])
 	return nil
 }
@@ -10,6 +10,7 @@ const (
 	TypeFileNode ObjectType = "filenode" // 大文件索引 (L2, ADL)
 	TypeTree     ObjectType = "tree"     // 目录树 (L3)
 	TypeCommit   ObjectType = "commit"   // 版本快照 (L4)
+	TypeDelta    ObjectType = "delta"    // Chunk 的差量编码 (跨版本压缩，见 pkg/core/delta.go)
 )
 
 // Object 是所有 Merkle DAG 节点的通用接口
@@ -24,13 +24,13 @@ type Tree struct {
 	Entries []TreeEntry `cbor:"e"`
 }
 
-// NewTree 创建一个新的目录树节点
-func NewTree(entries []TreeEntry) (*Tree, error) {
+// NewTree 创建一个新的目录树节点，hash 用 hasher 指定的算法计算
+func NewTree(entries []TreeEntry, hasher Hasher) (*Tree, error) {
 	t := &Tree{
 		TypeVal: TypeTree,
 		Entries: entries,
 	}
-	h, b, err := CalculateHash(t)
+	h, b, err := CalculateHash(t, hasher)
 	if err != nil {
 		return nil, err
 	}
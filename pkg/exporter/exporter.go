@@ -11,8 +11,11 @@ import (
 
 	"tensorvault/pkg/core"
 	"tensorvault/pkg/storage"
+	"tensorvault/pkg/telemetry"
 	"tensorvault/pkg/types"
 
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -31,7 +34,16 @@ func NewExporter(store storage.Store) *Exporter {
 // ExportFile 智能导出文件
 // 如果 writer 支持 io.WriterAt (如 *os.File)，则使用并发下载 (Parallel Restore)
 // 否则 (如 os.Stdout)，回退到串行流式下载 (Serial Restore)
-func (e *Exporter) ExportFile(ctx context.Context, hash types.Hash, writer io.Writer) error {
+func (e *Exporter) ExportFile(ctx context.Context, hash types.Hash, writer io.Writer) (err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "exporter.ExportFile")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	// 1. 获取并解析 FileNode
 	nodeReader, err := e.store.Get(ctx, hash)
 	if err != nil {
@@ -53,6 +65,11 @@ func (e *Exporter) ExportFile(ctx context.Context, hash types.Hash, writer io.Wr
 		return fmt.Errorf("object is not a filenode, got: %s", fileNode.TypeVal)
 	}
 
+	span.SetAttributes(
+		attribute.Int("chunk_count", len(fileNode.Chunks)),
+		attribute.Int64("bytes", fileNode.TotalSize),
+	)
+
 	// 2. 策略分发
 	// 检查 writer 是否支持“随机写入” (WriteAt)
 	if wAt, ok := writer.(io.WriterAt); ok {
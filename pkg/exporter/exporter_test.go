@@ -23,7 +23,9 @@ func TestIngestAndExport_RoundTrip(t *testing.T) {
 	store, err := disk.NewAdapter(tmpDir)
 	require.NoError(t, err)
 
-	ing := ingester.NewIngester(store)
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	ing := ingester.NewIngester(store, hasher)
 	exp := NewExporter(store)
 	ctx := context.Background()
 
@@ -68,25 +70,28 @@ func TestRestoreAndPrint_Integration(t *testing.T) {
 	exp := NewExporter(store)
 	ctx := context.Background()
 
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
 	// 2. 手动构造一个微型 DAG
 	// Chunk
 	chunkData := []byte("hello restore")
-	chunk := core.NewChunk(chunkData)
+	chunk := core.NewChunk(chunkData, hasher)
 	require.NoError(t, store.Put(ctx, chunk))
 
 	// FileNode
-	fileNode, err := core.NewFileNode(int64(len(chunkData)), []core.ChunkLink{core.NewChunkLink(chunk)})
+	fileNode, err := core.NewFileNode(int64(len(chunkData)), []core.ChunkLink{core.NewChunkLink(chunk)}, false, hasher)
 	require.NoError(t, err)
 	require.NoError(t, store.Put(ctx, fileNode))
 
 	// Tree (Root -> "test.txt")
 	treeEntry := core.NewFileEntry("test.txt", fileNode.ID(), fileNode.TotalSize)
-	tree, err := core.NewTree([]core.TreeEntry{treeEntry})
+	tree, err := core.NewTree([]core.TreeEntry{treeEntry}, hasher)
 	require.NoError(t, err)
 	require.NoError(t, store.Put(ctx, tree))
 
 	// Commit
-	commit, err := core.NewCommit(tree.ID(), nil, "Tester", "Init")
+	commit, err := core.NewCommit(tree.ID(), nil, "Tester", "Init", hasher)
 	require.NoError(t, err)
 	require.NoError(t, store.Put(ctx, commit))
 
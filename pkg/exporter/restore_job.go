@@ -0,0 +1,330 @@
+package exporter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/types"
+
+	"golang.org/x/sync/errgroup"
+	"gorm.io/datatypes"
+)
+
+// checkpointFlushInterval 是 RestoreJob 每写完多少个 Chunk 就把位图刷一次盘，而不是每写一个
+// Chunk 就往数据库打一次——几百 GB 的模型有几百万个 Chunk，那样数据库会被打爆
+const checkpointFlushInterval = 64
+
+// fileCheckpoint 记录单个文件的还原进度：按 Chunk 下标的完成位图
+// Chunk 的大小/偏移量由 FileNode.Chunks 本身决定，是确定性的——同一个 TreeHash 重新展开
+// 永远得到同一份 Chunk 列表，位图下标天然对齐，不需要额外持久化偏移量
+type fileCheckpoint struct {
+	Hash      types.Hash `json:"hash"` // 这个路径对应的 FileNode hash，resume 时用来发现 Tree 变了
+	NumChunks int        `json:"num_chunks"`
+	Bitmap    []byte     `json:"bitmap"` // 每个 bit 对应一个 Chunk，1 = 已写入
+}
+
+func newFileCheckpoint(hash types.Hash, numChunks int) *fileCheckpoint {
+	return &fileCheckpoint{Hash: hash, NumChunks: numChunks, Bitmap: make([]byte, (numChunks+7)/8)}
+}
+
+func (c *fileCheckpoint) isDone(i int) bool {
+	return i/8 < len(c.Bitmap) && c.Bitmap[i/8]&(1<<uint(i%8)) != 0
+}
+
+func (c *fileCheckpoint) markDone(i int) {
+	if i/8 < len(c.Bitmap) {
+		c.Bitmap[i/8] |= 1 << uint(i%8)
+	}
+}
+
+func (c *fileCheckpoint) complete() bool {
+	for i := 0; i < c.NumChunks; i++ {
+		if !c.isDone(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// resumeChunkJob 是单个文件内部，恢复一个 Chunk 所需的全部信息
+type resumeChunkJob struct {
+	hash   types.Hash
+	offset int64
+	size   int
+	index  int // 在 FileNode.Chunks 里的下标，对应 fileCheckpoint 位图的 bit 位置
+}
+
+// RestoreJob 是一次可断点续传的 Tree -> 目录还原：跟 Exporter.RestoreTree 的区别是，它把每个
+// 文件的 Chunk 完成位图持久化到 meta.Repository，进程崩溃重启后 Exporter.ResumeRestore 只补
+// 下载还没写完的 Chunk，而不是把整个文件（甚至整棵 Tree）重新下载一遍
+type RestoreJob struct {
+	ID        string
+	TreeHash  types.Hash
+	TargetDir string
+
+	exp  *Exporter
+	repo *meta.Repository
+
+	mu         sync.Mutex
+	progress   map[string]*fileCheckpoint // 相对路径 -> 该文件的位图
+	sinceFlush int                        // 上次 flush 之后新标记完成的 Chunk 数
+}
+
+// NewRestoreJob 为一次全新的还原创建一条持久化记录，返回的 RestoreJob 可以立刻 Run
+func NewRestoreJob(ctx context.Context, exp *Exporter, repo *meta.Repository, treeHash types.Hash, targetDir string) (*RestoreJob, error) {
+	id, err := newRestoreJobID()
+	if err != nil {
+		return nil, err
+	}
+
+	job := &RestoreJob{
+		ID:        id,
+		TreeHash:  treeHash,
+		TargetDir: targetDir,
+		exp:       exp,
+		repo:      repo,
+		progress:  make(map[string]*fileCheckpoint),
+	}
+
+	model := &meta.RestoreJobModel{
+		JobID:     job.ID,
+		TreeHash:  treeHash.String(),
+		TargetDir: targetDir,
+		Status:    "running",
+	}
+	if err := repo.CreateRestoreJob(ctx, model); err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// ResumeRestore 按 jobID 重新加载一条未完成的 RestoreJob：TreeHash/TargetDir 和已经写完的
+// Chunk 位图都从 meta.Repository 里恢复，调用方随后调用 Run 就只会补下载缺的部分
+func (e *Exporter) ResumeRestore(ctx context.Context, repo *meta.Repository, jobID string) (*RestoreJob, error) {
+	model, err := repo.GetRestoreJob(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	progress := make(map[string]*fileCheckpoint)
+	if len(model.Files) > 0 {
+		if err := json.Unmarshal(model.Files, &progress); err != nil {
+			return nil, fmt.Errorf("corrupted restore job checkpoint: %w", err)
+		}
+	}
+
+	return &RestoreJob{
+		ID:        model.JobID,
+		TreeHash:  types.Hash(model.TreeHash),
+		TargetDir: model.TargetDir,
+		exp:       e,
+		repo:      repo,
+		progress:  progress,
+	}, nil
+}
+
+// Run 把 job.TreeHash 还原到 job.TargetDir，跳过位图里已经标记完成的 Chunk；执行期间每写完
+// checkpointFlushInterval 个 Chunk 就把位图刷一次盘，完成后把任务标记为 done
+func (job *RestoreJob) Run(ctx context.Context, onRestore RestoreCallback) error {
+	if err := job.restoreTree(ctx, job.TreeHash, job.TargetDir, onRestore); err != nil {
+		return err
+	}
+	if err := job.flush(ctx); err != nil {
+		return err
+	}
+	return job.repo.FinishRestoreJob(ctx, job.ID)
+}
+
+// restoreTree 递归还原一棵 Tree，跟 Exporter.RestoreTree 的遍历逻辑一致，只是叶子文件走
+// restoreFile（带位图）而不是直接 ExportFile
+func (job *RestoreJob) restoreTree(ctx context.Context, treeHash types.Hash, targetDir string, onRestore RestoreCallback) error {
+	reader, err := job.exp.store.Get(ctx, treeHash.String())
+	if err != nil {
+		return fmt.Errorf("failed to get tree %s: %w", treeHash, err)
+	}
+	treeBytes, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+
+	var tree core.Tree
+	if err := core.DecodeObject(treeBytes, &tree); err != nil {
+		return fmt.Errorf("failed to decode tree: %w", err)
+	}
+
+	for _, entry := range tree.Entries {
+		fullPath := filepath.Join(targetDir, entry.Name)
+		entryHash := types.Hash(entry.Cid.Hash)
+
+		if entry.Type == core.EntryDir {
+			if err := os.MkdirAll(fullPath, 0755); err != nil {
+				return fmt.Errorf("failed to create dir %s: %w", fullPath, err)
+			}
+			if err := job.restoreTree(ctx, entryHash, fullPath, onRestore); err != nil {
+				return err
+			}
+			continue
+		}
+
+		relPath, err := filepath.Rel(job.TargetDir, fullPath)
+		if err != nil {
+			relPath = fullPath
+		}
+		if err := job.restoreFile(ctx, relPath, fullPath, entryHash); err != nil {
+			return err
+		}
+		if onRestore != nil {
+			onRestore(fullPath, entryHash, entry.Size)
+		}
+	}
+	return nil
+}
+
+// restoreFile 恢复单个文件：重新打开（而不是截断）目标文件，并发下载位图里还没标记完成的
+// Chunk，每写完一个就原地更新位图
+func (job *RestoreJob) restoreFile(ctx context.Context, relPath, fullPath string, hash types.Hash) error {
+	nodeReader, err := job.exp.store.Get(ctx, hash.String())
+	if err != nil {
+		return fmt.Errorf("failed to get filenode meta: %w", err)
+	}
+	nodeBytes, err := io.ReadAll(nodeReader)
+	nodeReader.Close()
+	if err != nil {
+		return err
+	}
+
+	var fileNode core.FileNode
+	if err := core.DecodeObject(nodeBytes, &fileNode); err != nil {
+		return fmt.Errorf("failed to decode filenode: %w", err)
+	}
+
+	cp := job.checkpointFor(relPath, hash, len(fileNode.Chunks))
+	if cp.complete() {
+		// 上一次运行已经把这个文件整个写完了，只是进程崩在了落盘 "done" 状态之前
+		return nil
+	}
+
+	// O_RDWR|O_CREATE：resume 时复用已经写了一部分的文件，而不是像 os.Create 那样截断清零
+	f, err := os.OpenFile(fullPath, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", fullPath, err)
+	}
+	defer f.Close()
+
+	g, gctx := errgroup.WithContext(ctx)
+	jobsCh := make(chan resumeChunkJob, RestoreWorkerCount*2)
+
+	g.Go(func() error {
+		defer close(jobsCh)
+		var offset int64
+		for i, chunk := range fileNode.Chunks {
+			if !job.isChunkDone(cp, i) {
+				rj := resumeChunkJob{hash: types.Hash(chunk.Hash.Hash), offset: offset, size: chunk.Size, index: i}
+				select {
+				case jobsCh <- rj:
+				case <-gctx.Done():
+					return gctx.Err()
+				}
+			}
+			offset += int64(chunk.Size)
+		}
+		return nil
+	})
+
+	for range RestoreWorkerCount {
+		g.Go(func() error {
+			for rj := range jobsCh {
+				rc, err := job.exp.store.Get(gctx, rj.hash.String())
+				if err != nil {
+					return fmt.Errorf("download chunk %s failed: %w", rj.hash, err)
+				}
+				data, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil {
+					return err
+				}
+				if len(data) != rj.size {
+					return fmt.Errorf("integrity error: chunk %s size mismatch (want %d, got %d)", rj.hash, rj.size, len(data))
+				}
+
+				if _, err := f.WriteAt(data, rj.offset); err != nil {
+					return fmt.Errorf("writeAt failed at offset %d of %s: %w", rj.offset, relPath, err)
+				}
+				if err := job.markChunkDone(gctx, cp, rj.index); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	return g.Wait()
+}
+
+// checkpointFor 返回 relPath 对应的位图，必要时新建一个（首次见到这个文件，或者它的 Hash/
+// Chunk 数跟上次记录的不一样——比如 Tree 变了，位图作废重来）
+func (job *RestoreJob) checkpointFor(relPath string, hash types.Hash, numChunks int) *fileCheckpoint {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	cp, ok := job.progress[relPath]
+	if !ok || cp.Hash != hash || cp.NumChunks != numChunks {
+		cp = newFileCheckpoint(hash, numChunks)
+		job.progress[relPath] = cp
+	}
+	return cp
+}
+
+func (job *RestoreJob) isChunkDone(cp *fileCheckpoint, i int) bool {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return cp.isDone(i)
+}
+
+// markChunkDone 原地更新位图，每攒够 checkpointFlushInterval 个就刷一次盘
+func (job *RestoreJob) markChunkDone(ctx context.Context, cp *fileCheckpoint, i int) error {
+	job.mu.Lock()
+	cp.markDone(i)
+	job.sinceFlush++
+	shouldFlush := job.sinceFlush >= checkpointFlushInterval
+	if shouldFlush {
+		job.sinceFlush = 0
+	}
+	job.mu.Unlock()
+
+	if !shouldFlush {
+		return nil
+	}
+	return job.flush(ctx)
+}
+
+// flush 把当前的位图快照整体序列化并写回 meta.Repository
+func (job *RestoreJob) flush(ctx context.Context) error {
+	job.mu.Lock()
+	data, err := json.Marshal(job.progress)
+	job.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal restore job checkpoint: %w", err)
+	}
+	return job.repo.SaveRestoreJobCheckpoint(ctx, job.ID, datatypes.JSON(data))
+}
+
+// newRestoreJobID 生成一个 RestoreJob ID：16 字节随机数的十六进制表示，跟 pkg/task 的
+// newTaskID 用的是同一套思路，但两者是独立的 ID 空间（一次 checkout 的 task 记录和它对应的
+// restore job 记录各有各的 ID）
+func newRestoreJobID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate restore job id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
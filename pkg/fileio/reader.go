@@ -0,0 +1,146 @@
+// Package fileio 提供对 (可能是 Pyramid 多层的) FileNode 的惰性随机读取
+//
+// 放在独立的包里而不是 pkg/core，是因为读取叶子 Chunk 需要 storage.Store，而 pkg/storage
+// 反过来依赖 pkg/core（Store.Put 接收 core.Object），core 直接依赖 storage 会成环。
+package fileio
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// FileReader 支持对一个 FileNode 做 io.ReaderAt 风格的随机读取
+//
+// 它只在 ReadAt 被调用、且请求区间确实落在某个叶子 Chunk 上时才会向 Store 发起 Get；
+// 构造时只加载根节点本身，不会把整棵树或任何 Chunk 提前拉到内存里——对几十 GB 的模型权重
+// 文件，提前拉取是不可接受的。
+type FileReader struct {
+	store storage.Store
+	root  *core.FileNode
+}
+
+// NewFileReader 加载 rootHash 对应的 FileNode（可能是旧版本的扁平节点，也可能是 Pyramid
+// 的根节点）并返回一个可以随机读取的 FileReader
+func NewFileReader(ctx context.Context, store storage.Store, rootHash types.Hash) (*FileReader, error) {
+	node, err := loadFileNode(ctx, store, rootHash)
+	if err != nil {
+		return nil, err
+	}
+	return &FileReader{store: store, root: node}, nil
+}
+
+// Size 返回文件的总字节数
+func (r *FileReader) Size() int64 { return r.root.TotalSize }
+
+// ReadAt 实现 io.ReaderAt：只拉取与 [off, off+len(p)) 相交的叶子 Chunk，
+// 其余分支在遍历时被直接跳过，不产生任何网络/磁盘 I/O
+func (r *FileReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, fmt.Errorf("fileio: negative offset %d", off)
+	}
+	if off >= r.root.TotalSize {
+		return 0, io.EOF
+	}
+
+	want := int64(len(p))
+	atEOF := false
+	if off+want >= r.root.TotalSize {
+		want = r.root.TotalSize - off
+		atEOF = true
+	}
+
+	n, err := r.readNode(context.Background(), r.root, 0, off, p[:want])
+	if err != nil {
+		return n, err
+	}
+	if atEOF {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// readNode 在全局区间 [nodeOffset, nodeOffset+node.TotalSize) 里，读取与
+// [off, off+len(dst)) 相交的部分并写入 dst 里对应的位置，返回写入的字节数
+func (r *FileReader) readNode(ctx context.Context, node *core.FileNode, nodeOffset, off int64, dst []byte) (int, error) {
+	want := off + int64(len(dst))
+	written := 0
+	cur := nodeOffset
+
+	for _, link := range node.Chunks {
+		childStart := cur
+		childEnd := cur + int64(link.Size)
+		cur = childEnd
+
+		if childEnd <= off || childStart >= want {
+			continue // 跟请求区间完全不相交，跳过，不发起任何 I/O
+		}
+
+		hash := types.Hash(link.Hash.Hash)
+		var n int
+		var err error
+		if node.IsInternal {
+			child, loadErr := loadFileNode(ctx, r.store, hash)
+			if loadErr != nil {
+				return written, loadErr
+			}
+			n, err = r.readNode(ctx, child, childStart, off, dst)
+		} else {
+			n, err = r.readChunk(ctx, hash, childStart, off, dst)
+		}
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// readChunk 拉取一个叶子 Chunk 的全部内容，并把它跟 [off, off+len(dst)) 相交的那一段
+// 拷贝进 dst 里正确的偏移处
+func (r *FileReader) readChunk(ctx context.Context, hash types.Hash, chunkStart, off int64, dst []byte) (int, error) {
+	reader, err := r.store.Get(ctx, hash.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch chunk %s: %w", hash, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read chunk %s: %w", hash, err)
+	}
+
+	var skipInChunk, skipInDst int64
+	if chunkStart < off {
+		skipInChunk = off - chunkStart
+	} else {
+		skipInDst = chunkStart - off
+	}
+
+	return copy(dst[skipInDst:], data[skipInChunk:]), nil
+}
+
+// loadFileNode 从 Store 里取出一个 FileNode 并解码（复用 pkg/exporter 的解码套路：
+// 把原始字节反序列化进零值结构体，未导出的 hash/rawBytes 字段不参与 CBOR，不需要回填）
+func loadFileNode(ctx context.Context, store storage.Store, hash types.Hash) (*core.FileNode, error) {
+	reader, err := store.Get(ctx, hash.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch filenode %s: %w", hash, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read filenode %s: %w", hash, err)
+	}
+
+	var node core.FileNode
+	if err := core.DecodeObject(data, &node); err != nil {
+		return nil, fmt.Errorf("failed to decode filenode %s: %w", hash, err)
+	}
+	return &node, nil
+}
@@ -0,0 +1,148 @@
+package gc
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/storage/pack"
+	"tensorvault/pkg/types"
+)
+
+// DefaultPackOlderThan 是 `tv gc --pack` 默认只打包多久没被碰过的 loose 对象：
+// Pack 的目标是把早就稳定下来的老对象收进 pack，避免它们无限期占着 inode
+const DefaultPackOlderThan = 24 * time.Hour
+
+// PackStats 汇总一次 Pack 的结果
+type PackStats struct {
+	Scanned  int
+	Packed   int    // 被收进新 pack 里的对象数
+	PackName string // 新生成的 pack 名字（不含扩展名）；没有对象被打包时为空
+}
+
+// rawObject 把从 loose 存储读出来的原始字节重新包装成 core.Object，好喂给 pack.Writer——
+// Pack 阶段不需要关心对象具体类型里的字段长什么样，只需要它的 Hash/Bytes/Type 三件事
+type rawObject struct {
+	hash    types.Hash
+	data    []byte
+	objType core.ObjectType
+}
+
+func (r rawObject) ID() types.Hash        { return r.hash }
+func (r rawObject) Bytes() []byte         { return r.data }
+func (r rawObject) Type() core.ObjectType { return r.objType }
+
+// Pack 扫描 store 里超过 olderThan 时间没被修改过的 loose 对象，把它们合并进一个新的
+// pack 文件，并从 loose 分片目录里删除原件。
+//
+// 跟 Repack/Sweep 只需要一个实现了 storage.RawStore 的后端不同，Pack 需要知道 pack 文件
+// 该放哪、新 pack 写完后要注册进谁的搜索集合——这是 disk.MultiAdapter 自己才有的、没有
+// 也不该进任何存储能力接口的本地布局细节，所以这里仍然要求 store 链路里必须有一个
+// *disk.MultiAdapter，而不是像 Repack/Sweep 那样只认一个能力接口
+func Pack(ctx context.Context, store storage.Store, olderThan time.Duration, onProgress ProgressFunc) (PackStats, error) {
+	multi, ok := multiAdapterBackend(store)
+	if !ok {
+		return PackStats{}, fmt.Errorf("--pack requires MultiAdapter-backed disk storage")
+	}
+	adapter := multi.LooseAdapter()
+
+	objects, err := adapter.ListObjects(ctx)
+	if err != nil {
+		return PackStats{}, fmt.Errorf("failed to scan object store: %w", err)
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Hash < objects[j].Hash })
+
+	stats := PackStats{Scanned: len(objects)}
+
+	cutoff := time.Now().Add(-olderThan)
+	var candidates []storage.ObjectMeta
+	for _, obj := range objects {
+		if obj.ModifiedAt.Before(cutoff) {
+			candidates = append(candidates, obj)
+		}
+	}
+	if len(candidates) == 0 {
+		return stats, nil
+	}
+
+	w, err := pack.NewWriter(multi.PackDir())
+	if err != nil {
+		return stats, fmt.Errorf("failed to start pack writer: %w", err)
+	}
+
+	for i, obj := range candidates {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		data, err := readObjectContent(ctx, adapter, obj.Hash)
+		if err != nil {
+			return stats, fmt.Errorf("failed to read %s: %w", obj.Hash, err)
+		}
+		objType := sniffObjectType(data)
+
+		if err := w.Add(rawObject{hash: types.Hash(obj.Hash), data: data, objType: objType}); err != nil {
+			return stats, fmt.Errorf("failed to add %s to pack: %w", obj.Hash, err)
+		}
+
+		if onProgress != nil && (i+1)%64 == 0 {
+			onProgress(i+1, len(candidates), fmt.Sprintf("packed %d/%d objects", i+1, len(candidates)))
+		}
+	}
+
+	name, err := w.Finish()
+	if err != nil {
+		return stats, fmt.Errorf("failed to finalize pack: %w", err)
+	}
+
+	// 只有 pack 安全落盘之后才删 loose 副本，万一 Finish 之前出错，loose 文件仍然完整保留
+	for _, obj := range candidates {
+		if err := adapter.DeleteRaw(ctx, obj.Hash); err != nil {
+			return stats, fmt.Errorf("failed to remove packed loose object %s: %w", obj.Hash, err)
+		}
+	}
+
+	if err := multi.AddPack(name); err != nil {
+		return stats, fmt.Errorf("failed to register new pack: %w", err)
+	}
+
+	stats.Packed = len(candidates)
+	stats.PackName = name
+	return stats, nil
+}
+
+// multiAdapterBackend 顺着 storage.UnwrapDecorator 链往下找，直到找到 *disk.MultiAdapter
+// 本体——这一个具体类型断言留着没改用能力接口是故意的：Pack 要的是 PackDir/AddPack 这类
+// MultiAdapter 自己才有的本地布局细节，不是什么别的后端也可能实现的能力
+func multiAdapterBackend(store storage.Store) (*disk.MultiAdapter, bool) {
+	for {
+		if m, ok := store.(*disk.MultiAdapter); ok {
+			return m, true
+		}
+		next, ok := storage.UnwrapDecorator(store)
+		if !ok {
+			return nil, false
+		}
+		store = next
+	}
+}
+
+// sniffObjectType 复用 looksLikeChunk 同样的嗅探套路：解不出 CBOR 类型头的就是裸 Chunk
+func sniffObjectType(data []byte) core.ObjectType {
+	var header struct {
+		TypeVal core.ObjectType `cbor:"t"`
+	}
+	if err := core.DecodeObject(data, &header); err != nil {
+		return core.TypeChunk
+	}
+	switch header.TypeVal {
+	case core.TypeCommit, core.TypeTree, core.TypeFileNode, core.TypeDelta:
+		return header.TypeVal
+	default:
+		return core.TypeChunk
+	}
+}
@@ -0,0 +1,148 @@
+// Package gc 实现对象存储的垃圾回收与压缩
+// Repack 扫描全部 chunk（不按写入时间过滤），把彼此相似的对象重写成 DeltaObject；Pack 把大量零散的
+// loose 对象合并进 pack 文件，省掉 inode 和目录项开销，见 pack.go
+package gc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// deltaRepackWindow 是 repack 扫描时保留的"最近候选 base"数量
+// 只跟窗口内的对象比较，而不是全量两两比较，避免仓库很大时 repack 退化成 O(n^2)
+const deltaRepackWindow = 16
+
+// RepackStats 汇总一次 Repack 的结果，供 CLI 打印或任务进度上报使用
+type RepackStats struct {
+	Scanned       int
+	Repacked      int
+	OriginalBytes int64
+	RepackedBytes int64
+}
+
+// SavedBytes 返回这次 repack 省下的字节数
+func (s RepackStats) SavedBytes() int64 {
+	return s.OriginalBytes - s.RepackedBytes
+}
+
+// ProgressFunc 在扫描过程中被间歇调用，不保证每个对象一次（频率由各调用方决定，比如 Repack
+// 每 64 个对象、以及每重写一个对象时各调用一次），用于驱动进度条或任务上报
+// done/total 是已扫描/总对象数；message 是可以直接展示给用户的一行描述
+type ProgressFunc func(done, total int, message string)
+
+// Repack 扫描 store 里的全部对象，不按写入时间过滤，把其中近似重复的 chunk 重写为相对某个
+// base 的 DeltaObject；候选 base 只取扫描顺序里最近的 deltaRepackWindow 个 chunk
+// onProgress 可以为 nil；ctx 被取消时会在下一个对象边界停下并返回 ctx.Err()
+func Repack(ctx context.Context, store storage.Store, onProgress ProgressFunc) (RepackStats, error) {
+	raw, ok := rawBackend(store)
+	if !ok {
+		return RepackStats{}, fmt.Errorf("--repack requires a storage backend that supports storage.RawStore (e.g. disk)")
+	}
+
+	objects, err := raw.ListObjects(ctx)
+	if err != nil {
+		return RepackStats{}, fmt.Errorf("failed to scan object store: %w", err)
+	}
+	// 按 Hash 排序只是为了让每次 repack 的扫描顺序可复现，方便对比日志
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Hash < objects[j].Hash })
+
+	var stats RepackStats
+	window := make([]core.BaseCandidate, 0, deltaRepackWindow)
+
+	for i, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		data, err := readObjectContent(ctx, store, obj.Hash)
+		if err != nil {
+			return stats, fmt.Errorf("failed to read %s: %w", obj.Hash, err)
+		}
+		stats.Scanned++
+
+		if looksLikeChunk(data) {
+			if best, found := core.SelectBase(data, window); found {
+				instructions := core.ComputeDelta(best.Data, data)
+				hasher, err := core.HasherFor(best.Hash.Algo())
+				if err != nil {
+					return stats, fmt.Errorf("failed to resolve hasher for %s: %w", obj.Hash, err)
+				}
+				delta, err := core.NewDeltaObject(best.Hash, int64(len(data)), instructions, hasher)
+				if err != nil {
+					return stats, fmt.Errorf("failed to build delta for %s: %w", obj.Hash, err)
+				}
+				if len(delta.Bytes()) < len(data) {
+					if err := raw.PutRaw(ctx, obj.Hash, delta.Bytes()); err != nil {
+						return stats, fmt.Errorf("failed to write delta for %s: %w", obj.Hash, err)
+					}
+					stats.OriginalBytes += int64(len(data))
+					stats.RepackedBytes += int64(len(delta.Bytes()))
+					stats.Repacked++
+					if onProgress != nil {
+						onProgress(i+1, len(objects), fmt.Sprintf("%s: %d -> %d bytes (base %s)", obj.Hash[:8], len(data), len(delta.Bytes()), best.Hash.String()[:8]))
+					}
+				}
+			}
+
+			window = append(window, core.BaseCandidate{Hash: types.Hash(obj.Hash), Data: data})
+			if len(window) > deltaRepackWindow {
+				window = window[1:]
+			}
+		}
+
+		if onProgress != nil && (i+1)%64 == 0 {
+			onProgress(i+1, len(objects), fmt.Sprintf("scanned %d/%d objects", i+1, len(objects)))
+		}
+	}
+
+	return stats, nil
+}
+
+// rawBackend 顺着 storage.UnwrapDecorator 链找到第一个实现了 storage.RawStore 的 Store
+// （Repack 既要 ListObjects 枚举候选，又要 PutRaw 原地重写）。以前这里硬编码只认
+// *disk.Adapter/*disk.MultiAdapter，现在改成认能力接口：任何后端只要实现了它就能参与
+// gc，不需要在这里再加一个 case
+func rawBackend(store storage.Store) (storage.RawStore, bool) {
+	for {
+		if r, ok := store.(storage.RawStore); ok {
+			return r, true
+		}
+		next, ok := storage.UnwrapDecorator(store)
+		if !ok {
+			return nil, false
+		}
+		store = next
+	}
+}
+
+func readObjectContent(ctx context.Context, store storage.Store, hash string) ([]byte, error) {
+	reader, err := store.Get(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// looksLikeChunk 复用 pkg/exporter/printer.go 的类型嗅探套路：
+// 能被 DAG-CBOR 解出已知类型头的，是 Commit/Tree/FileNode/Delta，不是原始 Chunk
+func looksLikeChunk(data []byte) bool {
+	var header struct {
+		TypeVal core.ObjectType `cbor:"t"`
+	}
+	if err := core.DecodeObject(data, &header); err != nil {
+		return true
+	}
+	switch header.TypeVal {
+	case core.TypeCommit, core.TypeTree, core.TypeFileNode, core.TypeDelta:
+		return false
+	default:
+		return true
+	}
+}
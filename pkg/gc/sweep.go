@@ -0,0 +1,252 @@
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"tensorvault/pkg/bloom"
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/task"
+	"tensorvault/pkg/types"
+)
+
+// DefaultSweepGrace 是 `tv gc --sweep` 默认的宽限期：对象落盘之后这么久都没被 mark 阶段
+// 摸到，才会被认为真的不可达。宽限期存在是为了躲开"Chunk 刚写进 Store，但它所属的
+// FileNode/Tree/Commit 还没来得及落盘"这个时间窗口——没有它，一次正在进行中的 ingest
+// 随时可能被 Sweep 误删
+const DefaultSweepGrace = 24 * time.Hour
+
+// liveSetExpectedObjects/liveSetTargetFPR 决定 mark 阶段那个 Bloom Filter 的 m/k：
+// 假阳性只会让 Sweep 多放过几个其实已经死掉的对象（留到下一轮再清），绝不会导致误删。
+// 这里取 0.001，比 ingester 去重用的 0.01 更严，每轮放过的死对象更少
+const (
+	liveSetExpectedObjects = 1_000_000
+	liveSetTargetFPR       = 0.001
+)
+
+// liveIngestTaskTypes 是 Sweep 前安全检查要看守的任务类型：这些任务会往 Store 里写
+// 新对象，但相应的 FileNode/Tree/Commit 落盘之前，新写的 Chunk 在 mark 阶段的 live-set
+// 里还找不到，必须等它们跑完
+var liveIngestTaskTypes = []string{string(task.TypeUpload), string(task.TypeTreeBuild), string(task.TypeCommit)}
+
+// ErrIngestInProgress 在有正在进行的 ingest 类任务时返回，Sweep 会拒绝执行
+var ErrIngestInProgress = errors.New("gc: refusing to sweep while an ingest job is in flight")
+
+// SweepStats 汇总一次 Sweep 的结果
+type SweepStats struct {
+	Roots      int // mark 阶段遍历的活跃引用 (分支 + HEAD) 数量
+	Scanned    int // sweep 阶段扫描到的落盘对象总数
+	Tombstoned int // 被判定为不可达的对象数 (非 dry-run 时每个都写了 tombstone)
+	Deleted    int // 真正从 Store 里删除的对象数 (dry-run 时恒为 0)
+	DryRun     bool
+}
+
+// Sweep 执行一次标记-清除 GC：
+//
+//  1. Mark —— 从每一条活跃引用 (所有本地分支 + HEAD) 出发，顺着 Commit 的 parent 链、
+//     Tree 的目录项、FileNode 的 (Pyramid) Chunk 链一路遍历，把摸到的每一个对象哈希记进
+//     一个 Bloom Filter，作为这次 Sweep 的"活跃集合"
+//  2. Sweep —— 列出 Store 里现存的全部对象，对每一个满足 "早于 grace 宽限期" 且"不在活跃
+//     集合里"的对象，先写一条 tombstone 记录，再真正删除它
+//
+// dryRun 为 true 时只统计会被删除的对象，不写 tombstone，也不真正删除任何东西
+func Sweep(ctx context.Context, store storage.Store, repo *meta.Repository, refMgr *refs.Manager, grace time.Duration, dryRun bool, onProgress ProgressFunc) (SweepStats, error) {
+	var stats SweepStats
+	stats.DryRun = dryRun
+
+	busy, err := repo.HasActiveTask(ctx, liveIngestTaskTypes)
+	if err != nil {
+		return stats, fmt.Errorf("failed to check for in-flight ingest jobs: %w", err)
+	}
+	if busy {
+		return stats, ErrIngestInProgress
+	}
+
+	raw, ok := rawBackend(store)
+	if !ok {
+		return stats, fmt.Errorf("--sweep requires a storage backend that supports storage.RawStore (e.g. disk)")
+	}
+
+	roots, err := liveRoots(ctx, refMgr)
+	if err != nil {
+		return stats, fmt.Errorf("failed to enumerate live refs: %w", err)
+	}
+	stats.Roots = len(roots)
+
+	live := bloom.New(liveSetExpectedObjects, liveSetTargetFPR)
+	visited := make(map[types.Hash]bool) // 结构节点 (commit/tree/filenode) 的精确去重，Bloom 只用于最终的活跃集合
+	for _, root := range roots {
+		if err := markCommit(ctx, store, root, live, visited); err != nil {
+			return stats, fmt.Errorf("failed to walk commit %s: %w", root, err)
+		}
+	}
+
+	objects, err := raw.ListObjects(ctx)
+	if err != nil {
+		return stats, fmt.Errorf("failed to scan object store: %w", err)
+	}
+	stats.Scanned = len(objects)
+
+	cutoff := time.Now().Add(-grace)
+	for i, obj := range objects {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		if obj.ModifiedAt.After(cutoff) {
+			// 太新了：有可能是一次尚未完工的 ingest 刚写下的 Chunk，留给下一轮 Sweep 判断
+			continue
+		}
+		if live.MayContain(types.Hash(obj.Hash)) {
+			continue
+		}
+
+		stats.Tombstoned++
+		if onProgress != nil && (i+1)%64 == 0 {
+			onProgress(i+1, len(objects), fmt.Sprintf("scanned %d/%d objects, %d unreachable so far", i+1, len(objects), stats.Tombstoned))
+		}
+		if dryRun {
+			continue
+		}
+
+		if err := repo.CreateTombstone(ctx, types.Hash(obj.Hash), "gc_sweep"); err != nil {
+			return stats, fmt.Errorf("failed to tombstone %s: %w", obj.Hash, err)
+		}
+		if err := raw.DeleteRaw(ctx, obj.Hash); err != nil {
+			return stats, fmt.Errorf("failed to delete %s: %w", obj.Hash, err)
+		}
+		stats.Deleted++
+	}
+
+	return stats, nil
+}
+
+// liveRoots 返回所有活跃引用当前指向的 Commit Hash：HEAD (无论是 attached 还是 detached)
+// 以及每一条本地分支。跟 worktree.Checkout 解析分支名的方式一致，直接用 "refs/heads/<name>"
+// 去查 Ref 表，而不是在 gc 包里重新实现一遍 refs.Manager 的符号引用解析逻辑
+func liveRoots(ctx context.Context, refMgr *refs.Manager) ([]types.Hash, error) {
+	var roots []types.Hash
+
+	if head, _, err := refMgr.GetHead(ctx); err == nil {
+		roots = append(roots, head)
+	} else if !errors.Is(err, refs.ErrNoHead) {
+		return nil, err
+	}
+
+	branches, err := refMgr.ListBranches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range branches {
+		hash, _, err := refMgr.GetRef(ctx, "refs/heads/"+b.Name)
+		if err != nil {
+			return nil, err
+		}
+		if hash != "" {
+			roots = append(roots, hash)
+		}
+	}
+
+	return roots, nil
+}
+
+// markCommit 递归遍历一条 Commit 的历史 (沿 parent 链) 及其 Tree，把摸到的每个对象哈希
+// 记进 live。visited 负责精确去重：不同分支、不同 Commit 之间共享的祖先/子树很常见，
+// 没有它复杂度会随分支数指数级增长
+func markCommit(ctx context.Context, store storage.Store, hash types.Hash, live *bloom.Filter, visited map[types.Hash]bool) error {
+	if hash.IsZero() || visited[hash] {
+		return nil
+	}
+	visited[hash] = true
+
+	var commit core.Commit
+	if err := getObject(ctx, store, hash, &commit); err != nil {
+		return fmt.Errorf("failed to load commit %s: %w", hash, err)
+	}
+	live.Add(hash)
+
+	if err := markTree(ctx, store, types.Hash(commit.TreeCid.Hash), live, visited); err != nil {
+		return err
+	}
+	for _, p := range commit.Parents {
+		if err := markCommit(ctx, store, types.Hash(p.Hash), live, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markTree 递归遍历一棵 Tree：目录项递归进子 Tree，文件项摸到对应的 FileNode
+func markTree(ctx context.Context, store storage.Store, hash types.Hash, live *bloom.Filter, visited map[types.Hash]bool) error {
+	if hash.IsZero() || visited[hash] {
+		return nil
+	}
+	visited[hash] = true
+
+	var tree core.Tree
+	if err := getObject(ctx, store, hash, &tree); err != nil {
+		return fmt.Errorf("failed to load tree %s: %w", hash, err)
+	}
+	live.Add(hash)
+
+	for _, entry := range tree.Entries {
+		if entry.Type == core.EntryDir {
+			if err := markTree(ctx, store, types.Hash(entry.Cid.Hash), live, visited); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := markFileNode(ctx, store, types.Hash(entry.Cid.Hash), live, visited); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// markFileNode 遍历一个 FileNode：IsInternal 为 true 时 Chunks 里的每个 Link 指向的是
+// 另一个 FileNode (Pyramid 布局)，需要递归；否则是叶子 Chunk，直接记进 live
+func markFileNode(ctx context.Context, store storage.Store, hash types.Hash, live *bloom.Filter, visited map[types.Hash]bool) error {
+	if hash.IsZero() || visited[hash] {
+		return nil
+	}
+	visited[hash] = true
+
+	var node core.FileNode
+	if err := getObject(ctx, store, hash, &node); err != nil {
+		return fmt.Errorf("failed to load filenode %s: %w", hash, err)
+	}
+	live.Add(hash)
+
+	for _, chunk := range node.Chunks {
+		if node.IsInternal {
+			if err := markFileNode(ctx, store, types.Hash(chunk.Hash.Hash), live, visited); err != nil {
+				return err
+			}
+			continue
+		}
+		live.Add(types.Hash(chunk.Hash.Hash))
+	}
+	return nil
+}
+
+// getObject 读取并解码一个对象，跟 exporter.RestoreTree/ExportFile 里重复出现的
+// "Get -> ReadAll -> DecodeObject" 三连是同一套
+func getObject(ctx context.Context, store storage.Store, hash types.Hash, out any) error {
+	reader, err := store.Get(ctx, hash.String())
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	return core.DecodeObject(data, out)
+}
@@ -0,0 +1,141 @@
+package gc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/ingester"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/types"
+)
+
+// setupSweepTestRepo 构建一个隔离的内存 meta.Repository，迁移 Sweep 用得到的全部表
+func setupSweepTestRepo(t *testing.T) *meta.Repository {
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	metaDB := meta.NewWithConn(db)
+	require.NoError(t, metaDB.AutoMigrate(&meta.Ref{}, &meta.BranchModel{}, &meta.TaskModel{}, &meta.TombstoneModel{}))
+
+	return meta.NewRepository(metaDB)
+}
+
+// commitSingleFile ingest 一份数据，包成单文件 Tree，再包成一个 Commit，返回 Commit Hash
+func commitSingleFile(t *testing.T, store *disk.Adapter, ctx context.Context, content string) core.Commit {
+	t.Helper()
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
+	fileNode, err := ingester.NewIngester(store, hasher).IngestFile(ctx, bytes.NewReader([]byte(content)))
+	require.NoError(t, err)
+
+	entry := core.NewFileEntry("model.bin", fileNode.ID(), fileNode.TotalSize)
+	tree, err := core.NewTree([]core.TreeEntry{entry}, hasher)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, tree))
+
+	commit, err := core.NewCommit(tree.ID(), nil, "tester", "add model.bin", hasher)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, commit))
+
+	return *commit
+}
+
+func TestSweep_KeepsReachableObjects(t *testing.T) {
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+	repo := setupSweepTestRepo(t)
+	refMgr := refs.NewManager(repo)
+	ctx := context.Background()
+
+	commit := commitSingleFile(t, store, ctx, "hello world")
+	require.NoError(t, refMgr.CreateBranch(ctx, "main", commit.ID()))
+	require.NoError(t, refMgr.Checkout(ctx, "main"))
+
+	stats, err := Sweep(ctx, store, repo, refMgr, 0, false, nil)
+	require.NoError(t, err)
+	// HEAD (attached) 和 refs/heads/main 都指向同一个 commit，liveRoots 把两者都算作一个根，
+	// 所以这里是 2 而不是 1——Mark 阶段的 visited 去重保证它们只会被遍历一次
+	assert.Equal(t, 2, stats.Roots)
+	assert.Zero(t, stats.Deleted, "仍然被 main 分支引用的对象不应该被删除")
+
+	exists, err := store.Has(ctx, commit.ID().String())
+	require.NoError(t, err)
+	assert.True(t, exists, "commit 本身必须在 live-set 里")
+}
+
+func TestSweep_DeletesUnreachableObjectsPastGrace(t *testing.T) {
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+	repo := setupSweepTestRepo(t)
+	refMgr := refs.NewManager(repo)
+	ctx := context.Background()
+
+	// 一个从来没有被任何分支/HEAD 引用过的孤立 Chunk
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	orphan := core.NewChunk([]byte("nobody points at me"), hasher)
+	require.NoError(t, store.Put(ctx, orphan))
+
+	stats, err := Sweep(ctx, store, repo, refMgr, 0, false, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Deleted)
+
+	exists, err := store.Has(ctx, orphan.ID().String())
+	require.NoError(t, err)
+	assert.False(t, exists, "不可达的对象应该被真正删除")
+
+	tombstones, err := repo.HasActiveTask(ctx, []string{"upload"}) // 只是复用已有方法顺手确认没有残留任务
+	require.NoError(t, err)
+	assert.False(t, tombstones)
+}
+
+func TestSweep_DryRunDoesNotDelete(t *testing.T) {
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+	repo := setupSweepTestRepo(t)
+	refMgr := refs.NewManager(repo)
+	ctx := context.Background()
+
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	orphan := core.NewChunk([]byte("dry run me"), hasher)
+	require.NoError(t, store.Put(ctx, orphan))
+
+	stats, err := Sweep(ctx, store, repo, refMgr, 0, true, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Tombstoned)
+	assert.Zero(t, stats.Deleted)
+
+	exists, err := store.Has(ctx, orphan.ID().String())
+	require.NoError(t, err)
+	assert.True(t, exists, "--sweep-dry-run 不应该真正删除任何东西")
+}
+
+func TestSweep_RefusesWhileIngestInProgress(t *testing.T) {
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+	repo := setupSweepTestRepo(t)
+	refMgr := refs.NewManager(repo)
+	ctx := context.Background()
+
+	require.NoError(t, repo.CreateTask(ctx, &meta.TaskModel{ID: "t1", Type: "upload", Status: "running"}))
+
+	_, err = Sweep(ctx, store, repo, refMgr, time.Hour, false, nil)
+	assert.ErrorIs(t, err, ErrIngestInProgress)
+}
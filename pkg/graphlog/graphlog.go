@@ -0,0 +1,118 @@
+// Package graphlog 渲染 `tv log --graph` 的 ASCII 提交图，跟 git-log --graph 的观感
+// 对齐：* 代表这一行展示的提交，| 代表一条还在往下延伸的历史线，合并提交会在它自己那行
+// 下面多打一行 | 用来展示新开出的那些 lane。
+//
+// 这个包只管渲染，不管遍历顺序——调用方负责按 commit.Timestamp 用优先队列把提交按时间
+// 逆序弹出（cmd/tv/commands/log.go 的职责），每弹出一个就调用一次 Renderer.Next。
+package graphlog
+
+import "strings"
+
+// Node 是渲染一行图需要的最小信息：Hash 本身、它的直接父节点（按 core.Commit.Parents
+// 的顺序，parents[0] 是"主线"），以及用来排序的时间戳
+type Node struct {
+	Hash      string
+	Parents   []string
+	Timestamp int64
+}
+
+// Renderer 在连续的 Next 调用之间维护一组 lane（图里的纵向列）：lanes[i] 记录第 i 条
+// lane 正在等待哪个 hash 出现；"" 表示这条 lane 当前空闲，可以被复用
+type Renderer struct {
+	lanes []string
+}
+
+// NewRenderer 返回一个空白状态的渲染器，准备好接收遍历到的第一个（时间戳最新的）提交
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+// Next 渲染提交 n 所在的那一行图形前缀，并把 lane 状态推进到"等待它的父节点"。
+//
+// prefix 是画有 '*' 的那一行，永远要打印；mergeLine 只在本次提交开出了新 lane（它有
+// 不止一个父节点，且至少有一个父节点还没有别的 lane 在等）时非空，调用方应该在打印完
+// 提交自己的信息之后再补一行 mergeLine，对应 git 在合并提交下面多出的那行连接线。
+func (g *Renderer) Next(n Node) (prefix string, mergeLine string) {
+	col := g.laneOf(n.Hash)
+	if col == -1 {
+		// 没有任何 lane 在等这个 hash：它是一个新的遍历起点（--all 里某个 ref 自己的
+		// 尖端，或者这是整个遍历的第一个提交），给它开一条新 lane
+		col = g.allocate(n.Hash)
+	}
+
+	prefix = g.render(col, '*')
+
+	if len(n.Parents) == 0 {
+		// 没有父节点了 (根提交)：这条 lane 到头，收起
+		g.lanes[col] = ""
+	} else {
+		// 用第一父节点替换掉本 lane 正在等的 hash —— lane 本身不消失，只是继续往下
+		// 跟着主线走
+		g.lanes[col] = n.Parents[0]
+
+		opened := false
+		for _, p := range n.Parents[1:] {
+			if g.laneOf(p) != -1 {
+				// 已经有别的 lane 在等这个父节点了：两条历史线在这个祖先汇合，
+				// 不需要重复开一条新 lane
+				continue
+			}
+			g.allocate(p)
+			opened = true
+		}
+		if opened {
+			mergeLine = g.render(col, '|')
+		}
+	}
+
+	g.compact()
+	return prefix, mergeLine
+}
+
+// laneOf 返回当前正在等待 hash 的 lane 下标，找不到返回 -1
+func (g *Renderer) laneOf(hash string) int {
+	for i, h := range g.lanes {
+		if h == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// allocate 为 hash 认领一条 lane：优先复用已经空闲的槽位，没有空闲槽位才追加新列，
+// 这样图形不会比实际并发的分支数更宽
+func (g *Renderer) allocate(hash string) int {
+	for i, h := range g.lanes {
+		if h == "" {
+			g.lanes[i] = hash
+			return i
+		}
+	}
+	g.lanes = append(g.lanes, hash)
+	return len(g.lanes) - 1
+}
+
+// compact 去掉末尾连续空闲的 lane，让最右侧的分支全部收起之后图形宽度能跟着变窄，
+// 而不是永远留着一串用不到的空白列
+func (g *Renderer) compact() {
+	for len(g.lanes) > 0 && g.lanes[len(g.lanes)-1] == "" {
+		g.lanes = g.lanes[:len(g.lanes)-1]
+	}
+}
+
+// render 画一行：markCol 那一列画 mark，其它还有 lane 占用的列画 '|'，空闲列画空格
+func (g *Renderer) render(markCol int, mark byte) string {
+	var b strings.Builder
+	for i := range g.lanes {
+		switch {
+		case i == markCol:
+			b.WriteByte(mark)
+		case g.lanes[i] != "":
+			b.WriteByte('|')
+		default:
+			b.WriteByte(' ')
+		}
+		b.WriteByte(' ')
+	}
+	return b.String()
+}
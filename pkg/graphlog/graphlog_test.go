@@ -0,0 +1,58 @@
+package graphlog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderer_LinearHistory(t *testing.T) {
+	g := NewRenderer()
+
+	prefix, merge := g.Next(Node{Hash: "c3", Parents: []string{"c2"}, Timestamp: 3})
+	assert.Equal(t, "* ", prefix)
+	assert.Empty(t, merge)
+
+	prefix, merge = g.Next(Node{Hash: "c2", Parents: []string{"c1"}, Timestamp: 2})
+	assert.Equal(t, "* ", prefix, "一条直线历史应该一直停在同一条 lane 上")
+	assert.Empty(t, merge)
+
+	prefix, merge = g.Next(Node{Hash: "c1", Parents: nil, Timestamp: 1})
+	assert.Equal(t, "* ", prefix)
+	assert.Empty(t, merge)
+}
+
+func TestRenderer_MergeCommitOpensAndConvergesLanes(t *testing.T) {
+	g := NewRenderer()
+
+	// merge 提交有两个父节点：main 线上的 p1，和一条侧支 p2
+	prefix, merge := g.Next(Node{Hash: "merge", Parents: []string{"p1", "p2"}, Timestamp: 10})
+	assert.Equal(t, "* ", prefix)
+	assert.Equal(t, "| | ", merge, "开出一条新 lane 之后，应该多打一行展示它")
+
+	// p1（第一父节点）继续沿用原来那条 lane
+	prefix, _ = g.Next(Node{Hash: "p1", Parents: []string{"base"}, Timestamp: 9})
+	assert.Equal(t, "* | ", prefix)
+
+	// p2（侧支）用的是新开的那条 lane
+	prefix, _ = g.Next(Node{Hash: "p2", Parents: []string{"base"}, Timestamp: 8})
+	assert.Equal(t, "| * ", prefix, "侧支应该画在新开的那条 lane 上")
+
+	// 两条历史线在 base 汇合：base 同时是 p1 和 p2 的父节点，不应该再多开一条 lane
+	prefix, merge = g.Next(Node{Hash: "base", Parents: nil, Timestamp: 1})
+	assert.Empty(t, merge, "收敛到共同祖先时不应该再开新 lane")
+	assert.Contains(t, prefix, "*")
+}
+
+func TestRenderer_CollapsesFreedTrailingLanes(t *testing.T) {
+	g := NewRenderer()
+
+	g.Next(Node{Hash: "merge", Parents: []string{"p1", "p2"}, Timestamp: 10})
+	// p2 是根提交（没有父节点了），它那条 lane 应该立刻收起
+	_, _ = g.Next(Node{Hash: "p2", Parents: nil, Timestamp: 9})
+
+	// 再往下走的提交只剩一条活跃 lane，图形宽度应该收窄回 1 列，而不是留着 p2 那条
+	// 已经没用的空列
+	prefix, _ := g.Next(Node{Hash: "p1", Parents: nil, Timestamp: 1})
+	assert.Equal(t, "* ", prefix)
+}
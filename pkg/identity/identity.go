@@ -0,0 +1,93 @@
+package identity
+
+import "fmt"
+
+// Identity 是姓名/邮箱加一把签名 Key 的组合——跟 git 的 "user.name"/"user.email"
+// 对应，只是这里多绑定了一把可以实际产出签名的 Key
+type Identity struct {
+	Name  string
+	Email string
+	Key   Key
+}
+
+// HasPrivateKey 报告这个身份能不能用来签名。从协作者那里导入的公钥身份只能拿来 Verify。
+// 实际尝试签名一次代价太高（尤其 OpenPGP），所以按具体实现类型直接查私钥是否存在
+func (id Identity) HasPrivateKey() bool {
+	switch k := id.Key.(type) {
+	case *Ed25519Key:
+		return k.PrivateKey() != nil
+	case *OpenPGPKey:
+		return k.entity.PrivateKey != nil
+	default:
+		return false
+	}
+}
+
+// Record 是 Identity 落盘到 .tv/identities.json 的可序列化形式。Key 接口本身没法直接
+// json.Marshal（Ed25519Key/OpenPGPKey 的字段都是私有的），Record 把每种算法各自的
+// key material 摊平成字节，加载时再按 Algorithm 字段还原回对应的 Key 实现
+type Record struct {
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Algorithm   string `json:"algorithm"`
+	Fingerprint string `json:"fingerprint"`
+	PublicKey   []byte `json:"public_key"`
+	PrivateKey  []byte `json:"private_key,omitempty"` // 公钥身份（只导入用来验证）不写这个字段
+}
+
+// ToRecord 把 Identity 摊平成可以写进 JSON 的 Record
+func ToRecord(id Identity) (Record, error) {
+	rec := Record{
+		Name:        id.Name,
+		Email:       id.Email,
+		Algorithm:   id.Key.Algorithm(),
+		Fingerprint: id.Key.Fingerprint(),
+	}
+	switch k := id.Key.(type) {
+	case *Ed25519Key:
+		rec.PublicKey = k.PublicKey()
+		rec.PrivateKey = k.PrivateKey()
+	case *OpenPGPKey:
+		pub, err := k.Marshal(false)
+		if err != nil {
+			return Record{}, err
+		}
+		rec.PublicKey = pub
+		if k.entity.PrivateKey != nil {
+			priv, err := k.Marshal(true)
+			if err != nil {
+				return Record{}, err
+			}
+			rec.PrivateKey = priv
+		}
+	default:
+		return Record{}, fmt.Errorf("identity: unknown key type %T, cannot serialize", id.Key)
+	}
+	return rec, nil
+}
+
+// FromRecord 从磁盘读到的 Record 还原出一个可用的 Identity
+func FromRecord(rec Record) (Identity, error) {
+	var key Key
+	var err error
+	switch rec.Algorithm {
+	case "ed25519":
+		if len(rec.PrivateKey) > 0 {
+			key, err = NewEd25519KeyPair(rec.PublicKey, rec.PrivateKey)
+		} else {
+			key, err = NewEd25519PublicKey(rec.PublicKey)
+		}
+	case "openpgp":
+		if len(rec.PrivateKey) > 0 {
+			key, err = ParseOpenPGPKey(rec.PrivateKey)
+		} else {
+			key, err = ParseOpenPGPKey(rec.PublicKey)
+		}
+	default:
+		return Identity{}, fmt.Errorf("identity: unknown algorithm %q in record", rec.Algorithm)
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+	return Identity{Name: rec.Name, Email: rec.Email, Key: key}, nil
+}
@@ -0,0 +1,75 @@
+package identity
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEd25519Key_SignVerify(t *testing.T) {
+	key, err := GenerateEd25519Key()
+	require.NoError(t, err)
+
+	sig, err := key.Sign([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, key.Verify([]byte("hello"), sig))
+	assert.Error(t, key.Verify([]byte("tampered"), sig))
+
+	pubOnly, err := NewEd25519PublicKey(key.PublicKey())
+	require.NoError(t, err)
+	assert.Equal(t, key.Fingerprint(), pubOnly.Fingerprint())
+	_, err = pubOnly.Sign([]byte("hello"))
+	assert.ErrorIs(t, err, ErrNoPrivateKey)
+}
+
+func TestOpenPGPKey_SignVerify(t *testing.T) {
+	key, err := GenerateOpenPGPKey("Alice", "alice@example.com")
+	require.NoError(t, err)
+
+	sig, err := key.Sign([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, key.Verify([]byte("hello"), sig))
+	assert.Error(t, key.Verify([]byte("tampered"), sig))
+
+	pub, err := key.Marshal(false)
+	require.NoError(t, err)
+	pubOnly, err := ParseOpenPGPKey(pub)
+	require.NoError(t, err)
+	assert.Equal(t, key.Fingerprint(), pubOnly.Fingerprint())
+	_, err = pubOnly.Sign([]byte("hello"))
+	assert.ErrorIs(t, err, ErrNoPrivateKey)
+}
+
+func TestStore_AddListFindPersist(t *testing.T) {
+	dir := t.TempDir()
+	storePath := filepath.Join(dir, "identities.json")
+
+	store, err := NewStore(storePath)
+	require.NoError(t, err)
+
+	key, err := GenerateEd25519Key()
+	require.NoError(t, err)
+	id := Identity{Name: "Alice", Email: "alice@example.com", Key: key}
+	require.NoError(t, store.Add(id))
+	require.NoError(t, store.Save())
+
+	reloaded, err := NewStore(storePath)
+	require.NoError(t, err)
+
+	ids, err := reloaded.List()
+	require.NoError(t, err)
+	require.Len(t, ids, 1)
+	assert.Equal(t, "Alice", ids[0].Name)
+	assert.True(t, ids[0].HasPrivateKey())
+
+	found, ok, err := reloaded.Find(key.Fingerprint())
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "alice@example.com", found.Email)
+
+	_, ok, err = reloaded.Find("deadbeef")
+	require.NoError(t, err)
+	assert.False(t, ok)
+}
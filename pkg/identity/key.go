@@ -0,0 +1,28 @@
+// Package identity 实现 go-git-bug 那一套"身份 = 姓名/邮箱 + 一把可插拔的签名 Key"
+// 模型：commit 需要签名时不关心 Key 背后到底是 Ed25519 还是 OpenPGP，core.Commit.Sign/
+// VerifySignature 只认 identity.Key 这个接口
+package identity
+
+import "errors"
+
+// ErrNoPrivateKey 表示这把 Key 只有公钥部分（比如从协作者那里导入、用来验证对方签名的
+// Key），不能用来签名
+var ErrNoPrivateKey = errors.New("identity: key has no private component, cannot sign")
+
+// Key 是一把可插拔的签名/验证密钥。Ed25519Key 和 OpenPGPKey 是目前仅有的两个实现，
+// 新增算法只需要再实现这一个接口，不需要改 core.Commit 或 Identity 的任何一行
+type Key interface {
+	// Algorithm 是这把 Key 的算法标识（"ed25519" / "openpgp"），落盘到 Record.Algorithm
+	// 和 CommitModel.Signature 里，Load 时按这个字段决定实例化哪个实现
+	Algorithm() string
+
+	// Fingerprint 是这把 Key 公钥部分的稳定标识。CommitModel.SignerFingerprint 和
+	// refs.Manager 的签名者允许列表都按这个字符串匹配，不关心底层公钥字节长什么样
+	Fingerprint() string
+
+	// Sign 对 data 产出一个 detached 签名。Key 只有公钥时返回 ErrNoPrivateKey
+	Sign(data []byte) ([]byte, error)
+
+	// Verify 校验 sig 是不是 data 在这把 Key 对应私钥下的合法签名
+	Verify(data, sig []byte) error
+}
@@ -0,0 +1,90 @@
+package identity
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ProtonMail/go-crypto/openpgp"
+	"github.com/ProtonMail/go-crypto/openpgp/packet"
+)
+
+// OpenPGPKey 是 Key 在 OpenPGP 上的实现，用 ProtonMail/go-crypto（go-git 自己签 commit/
+// tag 时用的同一个 fork；标准库历史上从没有、x/crypto/openpgp 已经冻结不再维护）包一层。
+// 存在的意义是让已经有 GPG 身份、习惯 `git commit -S` 的人可以把同一把 Key 原样搬过来，
+// 不用为 TensorVault 专门再生成一把 Ed25519
+type OpenPGPKey struct {
+	entity *openpgp.Entity // entity.PrivateKey 为 nil 表示这是导入的别人的公钥
+}
+
+// GenerateOpenPGPKey 生成一个全新的 OpenPGP 身份（走 go-crypto 的默认配置，即 2048 位 RSA）
+func GenerateOpenPGPKey(name, email string) (*OpenPGPKey, error) {
+	entity, err := openpgp.NewEntity(name, "", email, &packet.Config{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate openpgp key: %w", err)
+	}
+	return &OpenPGPKey{entity: entity}, nil
+}
+
+// ParseOpenPGPKey 从一段 ASCII-armored 或二进制的 keyring 数据解析出一把 Key。同一个
+// 函数服务两种场景：导入协作者公开发布的 GPG 公钥（只读，用来验证），或者 Store 从磁盘
+// 加载自己之前用 Marshal(withPrivate=true) 存下的身份（entity.PrivateKey 会被还原出来，
+// 这把 Key 就能重新签名）——ReadKeyRing 本身就是按数据里实际有什么来还原的，不需要
+// 调用方预先声明
+func ParseOpenPGPKey(data []byte) (*OpenPGPKey, error) {
+	entities, err := openpgp.ReadKeyRing(bytes.NewReader(data))
+	if err != nil {
+		entities, err = openpgp.ReadArmoredKeyRing(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse openpgp key: %w", err)
+	}
+	if len(entities) == 0 {
+		return nil, fmt.Errorf("openpgp keyring is empty")
+	}
+	return &OpenPGPKey{entity: entities[0]}, nil
+}
+
+// Marshal 序列化这把 Key。withPrivate=true 且这把 Key 确实带私钥时，序列化结果里包含
+// 私钥包，喂给 ParseOpenPGPKey 能原样复原出一把能签名的 Key——这是 Store 落盘/加载本地
+// 身份用的序列化格式；withPrivate=false（或者这把 Key 本来就只有公钥）序列化出的是可以
+// 安全分享给协作者、让对方拿去验证你签名的公钥导出
+func (k *OpenPGPKey) Marshal(withPrivate bool) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	if withPrivate && k.entity.PrivateKey != nil {
+		err = k.entity.SerializePrivate(&buf, nil)
+	} else {
+		err = k.entity.Serialize(&buf)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize openpgp key: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (k *OpenPGPKey) Algorithm() string { return "openpgp" }
+
+// Fingerprint 是 OpenPGP 标准的 160-bit 主公钥指纹，大写十六进制——跟 `gpg
+// --fingerprint` 打印的格式一致，方便跟已有的 GPG 工作流对照
+func (k *OpenPGPKey) Fingerprint() string {
+	return fmt.Sprintf("%X", k.entity.PrimaryKey.Fingerprint)
+}
+
+func (k *OpenPGPKey) Sign(data []byte) ([]byte, error) {
+	if k.entity.PrivateKey == nil {
+		return nil, ErrNoPrivateKey
+	}
+	var buf bytes.Buffer
+	if err := openpgp.DetachSign(&buf, k.entity, bytes.NewReader(data), nil); err != nil {
+		return nil, fmt.Errorf("openpgp: failed to sign: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (k *OpenPGPKey) Verify(data, sig []byte) error {
+	_, err := openpgp.CheckDetachedSignature(openpgp.EntityList{k.entity}, bytes.NewReader(data), bytes.NewReader(sig), nil)
+	if err != nil {
+		return fmt.Errorf("openpgp: signature verification failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package identity
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// storeSchemaVersion 是 identities.json 的格式版本号，跟 pkg/index 的 schemaVersion 是
+// 同一个套路：留一个字段，以后格式变了有地方挂迁移逻辑，不用一上来就过度设计
+const storeSchemaVersion = 1
+
+// Store 管理本地已知的签名身份：既包括"我自己"的（带私钥，`tv commit -S` 签名时用），
+// 也包括从协作者那里导入的公钥身份（只用来在 `tv log --show-signature`/ref 允许列表里验证）
+type Store struct {
+	path    string // 物理文件路径 (.tv/identities.json)
+	Version int      `json:"version"`
+	Records []Record `json:"identities"`
+	mu      sync.RWMutex
+}
+
+// NewStore 加载或创建一个新的 Store
+func NewStore(storePath string) (*Store, error) {
+	s := &Store{path: storePath}
+
+	if _, err := os.Stat(storePath); err == nil {
+		data, err := os.ReadFile(storePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read identity store: %w", err)
+		}
+		if err := json.Unmarshal(data, s); err != nil {
+			return nil, fmt.Errorf("corrupted identity store: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	} else {
+		s.Version = storeSchemaVersion
+	}
+
+	return s, nil
+}
+
+// Add 写入或覆盖一个身份（按 Fingerprint 去重，重复 Add 同一把 Key 等于更新 Name/Email）
+func (s *Store) Add(id Identity) error {
+	rec, err := ToRecord(id)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, existing := range s.Records {
+		if existing.Fingerprint == rec.Fingerprint {
+			s.Records[i] = rec
+			return nil
+		}
+	}
+	s.Records = append(s.Records, rec)
+	return nil
+}
+
+// List 返回当前已知的所有身份
+func (s *Store) List() ([]Identity, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ids := make([]Identity, 0, len(s.Records))
+	for _, rec := range s.Records {
+		id, err := FromRecord(rec)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Find 按指纹查找一个身份，找不到时返回 ok=false
+func (s *Store) Find(fingerprint string) (Identity, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, rec := range s.Records {
+		if rec.Fingerprint == fingerprint {
+			id, err := FromRecord(rec)
+			return id, true, err
+		}
+	}
+	return Identity{}, false, nil
+}
+
+// Save 将 Store 持久化到磁盘
+func (s *Store) Save() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0600) // 0600：私钥也在这份文件里，不能像 index.json 那样 0644
+}
@@ -1,68 +1,322 @@
+// Package ignore 实现 .tvignore 文件的解析与匹配（gitignore 兼容）
+//
+// 规则按目录层级组合：仓库根目录（以及任何子目录）下的 .tvignore 只对它自己和它下面的路径生效，
+// 更深目录的规则可以覆盖更浅层目录定下的结论；negation ("!") 可以重新纳入一个之前被排除的路径——
+// 但前提是它的父目录本身没有被排除（这和真实 Git 的行为一致：Git 根本不会进入一个被忽略的目录，
+// 所以目录内部的 "!" 规则永远不会被看到）。
+//
+// 除了纳入版本控制的 .tvignore，.tv/info/exclude 提供一个不会被提交、只对本地 checkout 生效
+// 的全局排除文件（对齐 Git 的 $GIT_DIR/info/exclude），优先级低于任何 .tvignore、高于内置的
+// defaultRules——适合放个人的编辑器临时文件之类不该连累其他协作者的规则。
 package ignore
 
 import (
+	"bufio"
 	"os"
 	"path/filepath"
-
-	gitignore "github.com/sabhiram/go-gitignore"
+	"strings"
+	"sync"
 )
 
-// Matcher 封装了忽略逻辑
-// 它负责判断一个文件是否应该被 TensorVault 忽略
-type Matcher struct {
-	ignorer *gitignore.GitIgnore
+// Rule 是 .tvignore 里解析出来的一行规则
+type Rule struct {
+	Raw      string // 原始文本，用于 `tv check-ignore` 展示给用户
+	Negate   bool   // 是否以 "!" 开头
+	DirOnly  bool   // 是否以 "/" 结尾（只匹配目录）
+	Anchored bool   // 是否锚定到规则所在目录（带 "/" 的模式），否则在其下任意深度都生效
+	Pattern  string // 去掉 "!" 和前后 "/" 之后的 glob（可能包含 "**"）
+	Source   string // 这条规则来自哪个 .tvignore 文件
 }
 
-// NewMatcher 初始化忽略匹配器
-// rootPath: 仓库根目录（用于查找 .tvignore 文件）
-func NewMatcher(rootPath string) (*Matcher, error) {
-	// 1. 定义系统级默认忽略规则 (Hardcoded Defaults)
-	// 这些规则强制生效，防止用户误操作导致严重问题
-	defaultRules := []string{
-		// --- 关键系统目录 ---
-		".tv",  // 绝对禁止索引仓库元数据目录，否则会导致无限递归死循环！
-		".git", // 忽略 Git 仓库数据
-
-		// --- 安全与配置 ---
+// defaultRules 是强制生效的系统级规则，防止用户误操作导致严重问题
+func defaultRules() []Rule {
+	names := []string{
+		".tv",         // 绝对禁止索引仓库元数据目录，否则会导致无限递归死循环！
+		".git",        // 忽略 Git 仓库数据
 		"config.yaml", // 防止 S3 Secret Key 泄露
 		".env",        // 防止环境变量文件泄露
+		".DS_Store",   // macOS
+		"Thumbs.db",   // Windows
+	}
+	rules := make([]Rule, 0, len(names))
+	for _, n := range names {
+		rules = append(rules, Rule{Raw: n, Pattern: n, Source: "<default>"})
+	}
+	return rules
+}
+
+// Matcher 封装了分层的忽略逻辑，按目录缓存已解析的规则集
+type Matcher struct {
+	root string
+
+	mu    sync.Mutex
+	cache map[string][]Rule // key: 相对仓库根目录的目录路径（"" 代表根目录）
+}
+
+// NewMatcher 初始化忽略匹配器
+// root: 仓库根目录（.tvignore 的查找起点）
+func NewMatcher(root string) (*Matcher, error) {
+	return &Matcher{
+		root:  root,
+		cache: make(map[string][]Rule),
+	}, nil
+}
+
+// Match 判断 path（相对仓库根目录）是否匹配某条忽略规则
+// matched 为 true 时，negated 进一步说明这条规则是不是一条 "!" 再纳入规则——
+// 真正应该跳过的判断是 `matched && !negated`
+func (m *Matcher) Match(path string, isDir bool) (matched bool, negated bool) {
+	matched, negated, _, _ = m.explain(path, isDir)
+	return matched, negated
+}
+
+// Ignored 是 Match 的便捷封装：直接回答"这个路径应该被跳过吗"
+func (m *Matcher) Ignored(path string, isDir bool) bool {
+	matched, negated := m.Match(path, isDir)
+	return matched && !negated
+}
+
+// Explain 和 Match 一样，但额外返回命中的具体规则，供 `tv check-ignore` 展示
+func (m *Matcher) Explain(path string, isDir bool) (matched bool, negated bool, rule Rule, found bool) {
+	return m.explain(path, isDir)
+}
+
+// IgnoredEntry 是 ListIgnored 报告的一条结果：哪个路径被忽略了，以及命中的具体规则
+type IgnoredEntry struct {
+	Path string // 相对仓库根目录
+	Rule Rule   // 命中的规则，Rule.Source 标出它来自哪个 .tvignore（或 <default>/info/exclude）
+}
+
+// ListIgnored 遍历 root（通常就是仓库根目录）下的所有路径，收集被忽略的文件和目录，
+// 供 `tv status --ignored` 展示"具体哪些文件被排除、排除它的规则来自哪个文件"。
+// 跟真实 Git 一样：一旦某个目录本身被忽略就不再往下走，否则深层被 "!" 重新纳入的文件
+// 永远进不了这份报告——这跟 explain 里 "父目录已排除则内部规则不生效" 是同一套语义
+func (m *Matcher) ListIgnored(root string) ([]IgnoredEntry, error) {
+	var entries []IgnoredEntry
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil
+		}
+		relPath = filepath.ToSlash(relPath)
+
+		matched, negated, rule, found := m.explain(relPath, d.IsDir())
+		if found && matched && !negated {
+			entries = append(entries, IgnoredEntry{Path: relPath, Rule: rule})
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
 
-		// --- 常见垃圾文件 ---
-		".DS_Store", // macOS
-		"Thumbs.db", // Windows
+func (m *Matcher) explain(path string, isDir bool) (matched bool, negated bool, rule Rule, found bool) {
+	path = strings.Trim(filepath.ToSlash(path), "/")
+	if path == "" {
+		return false, false, Rule{}, false
 	}
+	segments := strings.Split(path, "/")
 
-	var ignorer *gitignore.GitIgnore
-	var err error
+	excluded := false
+	for i := range segments {
+		isLast := i == len(segments)-1
+		entryIsDir := isDir || !isLast
 
-	// 2. 检查用户是否有 .tvignore 文件
-	ignoreFilePath := filepath.Join(rootPath, ".tvignore")
+		if excluded {
+			// 父目录已经被排除：Git 不会进入一个被忽略的目录，所以这里面任何规则
+			// （包括 "!" 重新纳入）都不会生效，结论原样继承自上一层。
+			continue
+		}
 
-	if _, errStat := os.Stat(ignoreFilePath); errStat == nil {
-		// 情况 A: 用户定义了 .tvignore
-		// 我们把“文件内容”和“默认规则”合并编译
-		// 库函数 CompileIgnoreFileAndLines 会自动处理读取和解析
-		ignorer, err = gitignore.CompileIgnoreFileAndLines(ignoreFilePath, defaultRules...)
-	} else {
-		// 情况 B: 用户没定义 .tvignore
-		// 仅编译默认规则
-		ignorer = gitignore.CompileIgnoreLines(defaultRules...)
+		levelMatched, levelNegated := false, false
+		var levelRule Rule
+		parentDir := strings.Join(segments[:i], "/")
+		entryPath := strings.Join(segments[:i+1], "/")
+
+		for _, dir := range ancestorChain(parentDir) {
+			rules, err := m.rulesForDir(dir)
+			if err != nil {
+				continue
+			}
+			rel := relativeTo(dir, entryPath)
+			if ok, neg, r := matchRules(rules, rel, entryIsDir); ok {
+				levelMatched, levelNegated, levelRule = true, neg, r
+			}
+		}
+
+		matched, negated, rule = levelMatched, levelNegated, levelRule
+		found = matched
+		excluded = matched && !negated
 	}
+	return matched, negated, rule, found
+}
 
-	if err != nil {
+// rulesForDir 返回物理定义在 dir（相对仓库根目录，""表示根目录）这一层的规则，并缓存解析结果
+func (m *Matcher) rulesForDir(dir string) ([]Rule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rules, ok := m.cache[dir]; ok {
+		return rules, nil
+	}
+
+	var rules []Rule
+	if dir == "" {
+		rules = append(rules, defaultRules()...)
+		rules = append(rules, m.globalRules()...)
+	}
+
+	ignoreFilePath := filepath.Join(m.root, dir, ".tvignore")
+	data, err := os.ReadFile(ignoreFilePath)
+	if err == nil {
+		rules = append(rules, parseRules(string(data), ignoreFilePath)...)
+	} else if !os.IsNotExist(err) {
 		return nil, err
 	}
 
-	return &Matcher{ignorer: ignorer}, nil
+	m.cache[dir] = rules
+	return rules, nil
+}
+
+// globalRules 读取仓库级但不纳入版本控制的排除规则 (.tv/info/exclude)，对齐 Git 的
+// $GIT_DIR/info/exclude：优先级低于任何 .tvignore（包括仓库根目录的），但高于 defaultRules——
+// 跟 matchRules "最后一条命中的规则获胜" 的语义配合，靠的就是这里的追加顺序
+func (m *Matcher) globalRules() []Rule {
+	path := filepath.Join(m.root, ".tv", "info", "exclude")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	return parseRules(string(data), path)
+}
+
+// parseRules 把一个 .tvignore 文件的内容解析成规则列表
+func parseRules(content, source string) []Rule {
+	var rules []Rule
+	sc := bufio.NewScanner(strings.NewReader(content))
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		r := Rule{Raw: line, Source: source}
+		p := line
+		if strings.HasPrefix(p, "!") {
+			r.Negate = true
+			p = p[1:]
+		}
+		if strings.HasSuffix(p, "/") {
+			r.DirOnly = true
+			p = strings.TrimSuffix(p, "/")
+		}
+		if strings.HasPrefix(p, "/") {
+			r.Anchored = true
+			p = strings.TrimPrefix(p, "/")
+		}
+		if strings.Contains(p, "/") {
+			r.Anchored = true
+		}
+		r.Pattern = p
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+// matchRules 依次应用同一层级的规则，最后一条命中的规则获胜（gitignore 的标准语义）
+func matchRules(rules []Rule, relPath string, isDir bool) (matched bool, negated bool, rule Rule) {
+	for _, r := range rules {
+		if r.DirOnly && !isDir {
+			continue
+		}
+		if patternMatches(r, relPath) {
+			matched, negated, rule = true, r.Negate, r
+		}
+	}
+	return matched, negated, rule
+}
+
+// patternMatches 按规则是否"锚定"选择匹配策略：
+// 锚定规则（带 "/"）相对自己所在目录做完整路径匹配，支持 "**"；
+// 非锚定规则（裸文件名）只匹配 basename，但可以在其所在目录下任意深度命中
+func patternMatches(r Rule, relPath string) bool {
+	if r.Anchored {
+		return globMatchSegments(strings.Split(r.Pattern, "/"), strings.Split(relPath, "/"))
+	}
+	base := relPath
+	if idx := strings.LastIndex(relPath, "/"); idx >= 0 {
+		base = relPath[idx+1:]
+	}
+	ok, err := filepath.Match(r.Pattern, base)
+	return err == nil && ok
 }
 
-// Matches 检查给定的路径是否匹配忽略规则
-// path: 应该是相对于仓库根目录的相对路径 (例如 "data/model.bin")
-// 返回: true 表示应该忽略 (Skip), false 表示应该保留 (Keep)
-// TODO: 尾部斜杠问题？
-func (m *Matcher) Matches(path string) bool {
-	if m.ignorer == nil {
+// globMatchSegments 支持 "**" 匹配任意层级（包括零层）的递归 glob 匹配
+func globMatchSegments(pattern, path []string) bool {
+	if len(pattern) == 0 {
+		return len(path) == 0
+	}
+	if pattern[0] == "**" {
+		if len(pattern) == 1 {
+			return true
+		}
+		for i := 0; i <= len(path); i++ {
+			if globMatchSegments(pattern[1:], path[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(path) == 0 {
+		return false
+	}
+	ok, err := filepath.Match(pattern[0], path[0])
+	if err != nil || !ok {
 		return false
 	}
-	return m.ignorer.MatchesPath(path)
+	return globMatchSegments(pattern[1:], path[1:])
+}
+
+// ancestorChain 返回从仓库根目录 ("") 到 dir（含 dir 本身）的完整目录链，浅层在前
+func ancestorChain(dir string) []string {
+	if dir == "" {
+		return []string{""}
+	}
+	parts := strings.Split(dir, "/")
+	chain := make([]string, 0, len(parts)+1)
+	chain = append(chain, "")
+	cur := ""
+	for _, p := range parts {
+		if cur == "" {
+			cur = p
+		} else {
+			cur = cur + "/" + p
+		}
+		chain = append(chain, cur)
+	}
+	return chain
+}
+
+// relativeTo 把 path（相对仓库根）转换成相对 dir 的路径
+func relativeTo(dir, path string) string {
+	if dir == "" {
+		return path
+	}
+	return strings.TrimPrefix(path, dir+"/")
 }
@@ -20,20 +20,21 @@ func TestMatcher_Defaults(t *testing.T) {
 	// 3. 验证默认规则
 	tests := []struct {
 		path     string
+		isDir    bool
 		shouldIg bool
 	}{
-		{".tv", true},
-		{".tv/objects/aa", true}, // 子路径也应该被忽略
-		{".git", true},
-		{"config.yaml", true},
-		{".DS_Store", true},
-		{"main.go", false}, // 普通文件不应忽略
-		{"data/model.bin", false},
+		{".tv", true, true},
+		{".tv/objects/aa", false, true}, // 子路径也应该被忽略
+		{".git", true, true},
+		{"config.yaml", false, true},
+		{".DS_Store", false, true},
+		{"main.go", false, false}, // 普通文件不应忽略
+		{"data/model.bin", false, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.path, func(t *testing.T) {
-			assert.Equal(t, tt.shouldIg, matcher.Matches(tt.path), "Path: %s", tt.path)
+			assert.Equal(t, tt.shouldIg, matcher.Ignored(tt.path, tt.isDir), "Path: %s", tt.path)
 		})
 	}
 }
@@ -46,7 +47,7 @@ func TestMatcher_WithUserFile(t *testing.T) {
 	ignoreContent := `
 # 这是注释
 *.log
-temp
+temp/
 !important.log
 `
 	err := os.WriteFile(filepath.Join(tmpDir, ".tvignore"), []byte(ignoreContent), 0644)
@@ -59,29 +60,81 @@ temp
 	// 4. 验证混合规则 (默认 + 用户)
 	tests := []struct {
 		path     string
+		isDir    bool
 		shouldIg bool
 	}{
 		// --- 默认规则依然要生效 ---
-		{".tv", true},
-		{"config.yaml", true},
+		{".tv", true, true},
+		{"config.yaml", false, true},
 
 		// --- 用户规则生效 ---
-		{"app.log", true},        // *.log
-		{"logs/error.log", true}, // *.log 递归
-		{"temp", true},           // temp/
-		{"temp/file", true},
+		{"app.log", false, true},        // *.log
+		{"logs/error.log", false, true}, // *.log 递归
+		{"temp", true, true},            // temp/ (directory-only)
+		{"temp/file", false, true},      // 父目录被排除，连带其下内容
 
 		// --- 正常文件 ---
-		{"main.go", false},
+		{"main.go", false, false},
 
 		// --- 负向规则 (Whitelisting) ---
-		// 注意：取决于 go-gitignore 库的具体实现，通常支持 !
-		{"important.log", false},
+		{"important.log", false, false},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.path, func(t *testing.T) {
-			assert.Equal(t, tt.shouldIg, matcher.Matches(tt.path), "Path: %s", tt.path)
+			assert.Equal(t, tt.shouldIg, matcher.Ignored(tt.path, tt.isDir), "Path: %s", tt.path)
 		})
 	}
 }
+
+func TestMatcher_Hierarchical(t *testing.T) {
+	// 验证子目录的 .tvignore 只对它自己往下生效，且更深的规则能覆盖浅层的结论
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".tvignore"), []byte("*.ckpt\n"), 0644))
+
+	subDir := filepath.Join(tmpDir, "experiments")
+	require.NoError(t, os.MkdirAll(subDir, 0755))
+	// 子目录重新纳入自己这一级的 *.ckpt
+	require.NoError(t, os.WriteFile(filepath.Join(subDir, ".tvignore"), []byte("!best.ckpt\n"), 0644))
+
+	matcher, err := NewMatcher(tmpDir)
+	require.NoError(t, err)
+
+	assert.True(t, matcher.Ignored("model.ckpt", false), "root rule should apply at root")
+	assert.True(t, matcher.Ignored("experiments/other.ckpt", false), "root rule should cascade into subdir")
+	assert.False(t, matcher.Ignored("experiments/best.ckpt", false), "deeper .tvignore should override shallower rule")
+
+	// root 的 .tvignore 不应该影响它的兄弟目录之外的、同名但路径不同的层级关系之外的东西
+	assert.False(t, matcher.Ignored("experiments/best.ckpt.meta", false))
+}
+
+func TestMatcher_GlobalExcludeFile(t *testing.T) {
+	// .tv/info/exclude 的优先级应该低于 .tvignore，但依然生效
+	tmpDir := t.TempDir()
+
+	require.NoError(t, os.MkdirAll(filepath.Join(tmpDir, ".tv", "info"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".tv", "info", "exclude"), []byte("*.swp\n*.ckpt\n"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".tvignore"), []byte("!best.ckpt\n"), 0644))
+
+	matcher, err := NewMatcher(tmpDir)
+	require.NoError(t, err)
+
+	assert.True(t, matcher.Ignored("scratch.swp", false), "exclude-only rule should still apply")
+	assert.True(t, matcher.Ignored("model.ckpt", false), "exclude rule should apply when .tvignore doesn't mention the path")
+	assert.False(t, matcher.Ignored("best.ckpt", false), "repo .tvignore should win over the lower-priority global exclude")
+}
+
+func TestMatcher_NegationBlockedByExcludedParent(t *testing.T) {
+	// 一个目录被排除之后，它内部再精确的 "!" 规则也无法把文件重新纳入——
+	// 和真实 Git 的行为一致：Git 根本不会进入一个被忽略的目录
+	tmpDir := t.TempDir()
+	ignoreContent := "build/\n!build/keep.txt\n"
+	require.NoError(t, os.WriteFile(filepath.Join(tmpDir, ".tvignore"), []byte(ignoreContent), 0644))
+
+	matcher, err := NewMatcher(tmpDir)
+	require.NoError(t, err)
+
+	assert.True(t, matcher.Ignored("build", true))
+	assert.True(t, matcher.Ignored("build/keep.txt", false))
+}
@@ -12,17 +12,42 @@ import (
 	"time"
 )
 
+// schemaVersion 是 index.json 的格式版本号。v1 (隐式，字段里没有 version) 只记录了
+// Hash/Size/ModifiedAt；v2 加上了 LinearHash 和剩下几个 stat 字段，让 `tv push` 能在
+// stat 没变的前提下信任缓存的 LinearHash，不用每次都重新 io.Copy 整个文件算一遍 SHA-256
+const schemaVersion = 2
+
 // Entry 代表暂存区中的一条记录
 type Entry struct {
-	Path       string     `json:"path"`        // 相对路径 (如 "data/model.bin")
-	Hash       types.Hash `json:"hash"`        // FileNode 的 Hash (Merkle Root)
-	Size       int64      `json:"size"`        // 文件大小
-	ModifiedAt time.Time  `json:"modified_at"` // 修改时间
+	Path       string           `json:"path"`                  // 相对路径 (如 "data/model.bin")
+	Hash       types.Hash       `json:"hash"`                  // FileNode 的 Hash (Merkle Root)
+	LinearHash types.LinearHash `json:"linear_hash,omitempty"` // tv add 读文件时顺带算出的整份内容 SHA-256，push 用它探测秒传
+	Size       int64            `json:"size"`                  // 文件大小
+	ModifiedAt time.Time        `json:"modified_at"`           // mtime
+	ChangedAt  time.Time        `json:"changed_at,omitempty"`  // ctime（元数据变更时间，跟 go-git index 的 stat_data 对齐）
+	Inode      uint64           `json:"inode,omitempty"`
+	Mode       uint32           `json:"mode,omitempty"`
+}
+
+// Fresh 判断 e 记录的 stat 快照是否还跟磁盘上的 info 一致。size/mtime/ctime/inode 但凡有
+// 一项对不上，就认为 LinearHash 不可信——调用方必须重新读取文件内容现算一遍。单独比较
+// ModifiedAt 不够：有些工具会保留 mtime 但重写 inode（比如某些原子替换式的 checkpoint 写入器），
+// 加上 ctime/inode 这两项复用的是 go-git 对付同一类问题的思路
+func (e Entry) Fresh(info os.FileInfo) bool {
+	if e.LinearHash == "" {
+		return false
+	}
+	ctime, inode := statData(info)
+	return e.Size == info.Size() &&
+		e.ModifiedAt.Equal(info.ModTime()) &&
+		e.ChangedAt.Equal(ctime) &&
+		e.Inode == inode
 }
 
 // Index 管理暂存区状态
 type Index struct {
 	path    string           // 物理文件路径 (.tv/index)
+	Version int              `json:"version"`
 	Entries map[string]Entry `json:"entries"`
 	mu      sync.RWMutex
 }
@@ -43,20 +68,44 @@ func NewIndex(indexPath string) (*Index, error) {
 		if err := json.Unmarshal(data, idx); err != nil {
 			return nil, fmt.Errorf("corrupted index file: %w", err)
 		}
+
+		// 注意：Version 必须在 Unmarshal 之后再判断——老版本的 index.json 压根没有这个
+		// 字段，JSON 解码不会写它，所以这里读到的就是老文件真实存的版本号（没有就是零值 0）
+		if idx.migrate() {
+			if err := idx.Save(); err != nil {
+				return nil, fmt.Errorf("failed to persist migrated index: %w", err)
+			}
+		}
 	} else if !os.IsNotExist(err) {
 		return nil, err
+	} else {
+		// 全新仓库：没有旧文件可迁移，直接落在当前 schema 版本上
+		idx.Version = schemaVersion
 	}
 
 	return idx, nil
 }
 
-// Add 更新一条记录
+// migrate 把一份老版本的 index.json 升到 schemaVersion。v1 索引里的 Entry 本来就没有
+// LinearHash/ChangedAt/Inode 这些字段，JSON 解码时已经零值填充好了——这里真正要做的只是
+// 把 Version 打上去，这样下次加载不用再跑一遍这个检查。没有任何字段需要反向推导或者
+// 重新打开文件现算，因为 v1 -> v2 纯粹是新增字段，旧数据本来就兼容
+func (i *Index) migrate() bool {
+	if i.Version >= schemaVersion {
+		return false
+	}
+	i.Version = schemaVersion
+	return true
+}
+
+// Add 更新一条记录（不记录 LinearHash/stat 快照；调用方自己没有现成的 os.FileInfo 时用这个，
+// 比如 checkout/reset 从 Tree 展开文件，或者测试里只关心 Hash/Size）
 func (i *Index) Add(path string, hash types.Hash, size int64) {
-	key := CleanPath(path) // <--- 统一清洗
+	key := CleanPath(path)
 	i.mu.Lock()
 	defer i.mu.Unlock()
 
-	i.Entries[path] = Entry{
+	i.Entries[key] = Entry{
 		Path:       key,
 		Hash:       hash,
 		Size:       size,
@@ -64,6 +113,71 @@ func (i *Index) Add(path string, hash types.Hash, size int64) {
 	}
 }
 
+// AddStaged 是 Add 的完整版本：额外记录 tv add 摄取文件时顺带算出的 LinearHash，以及当时的
+// stat 快照。之后 push 只要 stat 还对得上，就能直接复用这个 LinearHash，不需要重新读一遍文件
+func (i *Index) AddStaged(path string, hash types.Hash, linearHash types.LinearHash, size int64, info os.FileInfo) {
+	key := CleanPath(path)
+	ctime, inode := statData(info)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	i.Entries[key] = Entry{
+		Path:       key,
+		Hash:       hash,
+		LinearHash: linearHash,
+		Size:       size,
+		ModifiedAt: info.ModTime(),
+		ChangedAt:  ctime,
+		Inode:      inode,
+		Mode:       uint32(info.Mode()),
+	}
+}
+
+// UpdateLinearHash 在 push 因为 stat 对不上而不得不重新现算 LinearHash 之后，把结果和这次
+// 读取时的 stat 快照写回暂存区，这样下一次 push 同一个文件就能命中缓存了。path 根本没有被
+// 暂存过时（比如 `tv push` 直接跟一个不在 Index 里的文件路径）是个 no-op
+func (i *Index) UpdateLinearHash(path string, linearHash types.LinearHash, info os.FileInfo) {
+	key := CleanPath(path)
+	ctime, inode := statData(info)
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	e, ok := i.Entries[key]
+	if !ok {
+		return
+	}
+	e.LinearHash = linearHash
+	e.ModifiedAt = info.ModTime()
+	e.ChangedAt = ctime
+	e.Inode = inode
+	e.Mode = uint32(info.Mode())
+	i.Entries[key] = e
+}
+
+// Get 返回 path 对应的暂存记录
+func (i *Index) Get(path string) (Entry, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	e, ok := i.Entries[CleanPath(path)]
+	return e, ok
+}
+
+// GetLinearHash 返回 path 对应暂存项此前记录的 LinearHash，连 os.Stat 都不做——调用方如果
+// 需要确认缓存仍然新鲜，应该自己结合 Entry.Fresh 做二次校验（Get 而不是这个方法）。这个方法
+// 存在的意义是让 CheckFile 能在连文件都不用碰（既不 Stat 也不 Open）的极端场景下调用，比如
+// 调用方已经通过其他渠道（例如刚做完的一次 status）确信文件没有变化
+func (i *Index) GetLinearHash(path string) (types.LinearHash, bool) {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	e, ok := i.Entries[CleanPath(path)]
+	if !ok || e.LinearHash == "" {
+		return "", false
+	}
+	return e.LinearHash, true
+}
+
 // Save 将暂存区持久化到磁盘
 func (i *Index) Save() error {
 	i.mu.RLock()
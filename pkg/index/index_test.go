@@ -1,8 +1,12 @@
 package index
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
+	"time"
+
+	"tensorvault/pkg/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -88,6 +92,81 @@ func TestIndex_Lifecycle(t *testing.T) {
 	assert.Equal(t, 0, len(idx.Entries))
 }
 
+func TestIndex_AddStaged_FreshAndStaleLinearHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "model.bin")
+	require.NoError(t, os.WriteFile(filePath, []byte("weights"), 0644))
+
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+
+	idx, err := NewIndex(filepath.Join(tmpDir, "index.json"))
+	require.NoError(t, err)
+
+	idx.AddStaged("model.bin", "hash-merkle", types.LinearHash("hash-linear"), info.Size(), info)
+
+	entry, ok := idx.Get("model.bin")
+	require.True(t, ok)
+	assert.Equal(t, types.LinearHash("hash-linear"), entry.LinearHash)
+	assert.True(t, entry.Fresh(info), "stat snapshot was just taken, should be fresh")
+
+	linearHash, ok := idx.GetLinearHash("model.bin")
+	require.True(t, ok)
+	assert.Equal(t, types.LinearHash("hash-linear"), linearHash)
+
+	// 内容变了但 mtime 没变也会发生（比如某些写入器不更新 mtime），模拟一下 size 变化
+	stale := entry
+	stale.Size = entry.Size + 1
+	assert.False(t, stale.Fresh(info), "mismatched size should never be considered fresh")
+}
+
+func TestIndex_UpdateLinearHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	filePath := filepath.Join(tmpDir, "a.bin")
+	require.NoError(t, os.WriteFile(filePath, []byte("v1"), 0644))
+	info, err := os.Stat(filePath)
+	require.NoError(t, err)
+
+	idx, err := NewIndex(filepath.Join(tmpDir, "index.json"))
+	require.NoError(t, err)
+
+	// 没有被暂存过的路径：no-op，不会凭空创建一条记录
+	idx.UpdateLinearHash("a.bin", types.LinearHash("ignored"), info)
+	_, ok := idx.Get("a.bin")
+	assert.False(t, ok)
+
+	idx.Add("a.bin", "hash-merkle", info.Size())
+	idx.UpdateLinearHash("a.bin", types.LinearHash("hash-linear"), info)
+
+	entry, ok := idx.Get("a.bin")
+	require.True(t, ok)
+	assert.Equal(t, types.LinearHash("hash-linear"), entry.LinearHash)
+	assert.True(t, entry.Fresh(info))
+}
+
+func TestIndex_MigratesOldSchemaOnLoad(t *testing.T) {
+	tmpDir := t.TempDir()
+	indexPath := filepath.Join(tmpDir, "index.json")
+
+	// 模拟一份 v1 时代写下的 index.json：没有 version 字段，Entry 也没有 linear_hash/inode 等
+	oldContent := `{"entries":{"readme.md":{"path":"readme.md","hash":"hash-abc","size":500,"modified_at":"` +
+		time.Now().Format(time.RFC3339Nano) + `"}}}`
+	require.NoError(t, os.WriteFile(indexPath, []byte(oldContent), 0644))
+
+	idx, err := NewIndex(indexPath)
+	require.NoError(t, err)
+
+	assert.Equal(t, schemaVersion, idx.Version, "loading an old index should bump it to the current schema version")
+	entry, ok := idx.Get("readme.md")
+	require.True(t, ok)
+	assert.Equal(t, types.LinearHash(""), entry.LinearHash, "v1 entries have no LinearHash until the next tv add/push rehashes them")
+
+	// 迁移应该落盘，下次加载不用再跑一遍
+	raw, err := os.ReadFile(indexPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"version": 2`)
+}
+
 func TestCleanPath(t *testing.T) {
 	tests := []struct {
 		input    string
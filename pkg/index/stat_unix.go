@@ -0,0 +1,20 @@
+//go:build !windows
+
+package index
+
+import (
+	"os"
+	"syscall"
+	"time"
+)
+
+// statData 从 os.FileInfo 底层的 syscall.Stat_t 里取出 ctime 和 inode。这两个字段标准库
+// 不直接暴露（只有 ModTime 对应 mtime），跟 go-git 的 index 解码器取 stat_data 用的是同一个
+// Sys() 断言套路
+func statData(info os.FileInfo) (ctime time.Time, inode uint64) {
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return time.Time{}, 0
+	}
+	return time.Unix(stat.Ctim.Sec, stat.Ctim.Nsec), stat.Ino
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package index
+
+import (
+	"os"
+	"time"
+)
+
+// statData 在 Windows 上没有 inode 或者 Unix 式 ctime 的对等物。两边都固定返回零值，这样
+// Entry.Fresh 的比较实质上退化成只看 size 和 mtime——比 Unix 版宽松：内容变了、但 size 和
+// mtime 都没变的文件在这里会被判成"新鲜"（Unix 版还能靠 ctime/inode 发现这种改动）
+func statData(info os.FileInfo) (ctime time.Time, inode uint64) {
+	return time.Time{}, 0
+}
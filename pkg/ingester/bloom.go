@@ -0,0 +1,200 @@
+package ingester
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+
+	"tensorvault/pkg/bloom"
+)
+
+const (
+	// bloomObjectKey 是 Bloom Filter checkpoint 在 Store 里的固定路径，不是内容寻址的
+	// Hash —— 这张表本身就是会变的 (每次 Add 都不一样)，所以用一个约定的 well-known key
+	bloomObjectKey = ".tv/bloom/chunks.bf"
+
+	// bloomExpectedChunks/bloomTargetFPR 决定 Filter 的 m/k：按一个中等规模模型仓库的
+	// Chunk 数量级估算，假阳性率 1% 意味着 MayContain 误报时多付出的成本只是一次
+	// store.Has，比起省下的 Put 往返依然便宜得多
+	bloomExpectedChunks = 1_000_000
+	bloomTargetFPR      = 0.01
+
+	// bloomCheckpointEvery 是新增多少个 Chunk 就把 Filter 刷一次盘，跟
+	// exporter.RestoreJob 里 checkpointFlushInterval 是同样的权衡：刷太勤会把存储打爆，
+	// 刷太松则进程崩溃时损失的"热身"效果变大——这里的代价只是性能而非正确性，所以可以松一些
+	bloomCheckpointEvery = 256
+)
+
+// pendingChunk 是 worker 在 flush 一个 batch 之前攒在本地缓冲区里的一个 Chunk，
+// jobIndex 保留着它在 generateJobs 里的原始顺序号，flush 完成后用它把 result 发
+// 回 collect 做乱序重组
+type pendingChunk struct {
+	jobIndex int
+	chunk    *core.Chunk
+}
+
+// putChunks 是 putChunk 的批量版本：先用 Bloom Filter + Has 做跟单个版本完全一样的
+// 去重判定，筛出真正需要写的那些，然后优先走 storage.BatchStore（S3 多对象上传、单事务
+// 的 Postgres/Bolt 等），一次往返写完一整批；Store 没实现 BatchStore 时退化为逐个 Put，
+// 行为上和批之前的 putChunk 完全等价
+func (ing *Ingester) putChunks(ctx context.Context, batch []pendingChunk) error {
+	if len(batch) == 0 {
+		return nil
+	}
+
+	filter := ing.loadBloomFilter(ctx)
+
+	toWrite := make([]pendingChunk, 0, len(batch))
+	for _, pc := range batch {
+		ing.chunksSeen.Add(1)
+		hash := pc.chunk.ID()
+		if filter.MayContain(hash) {
+			exists, err := ing.store.Has(ctx, hash.String())
+			if err != nil {
+				return err
+			}
+			if exists {
+				ing.chunksDeduped.Add(1)
+				continue
+			}
+			// 假阳性：Filter 说可能存在，但 Has 说没有，照常写
+		}
+		toWrite = append(toWrite, pc)
+	}
+	if len(toWrite) == 0 {
+		return nil
+	}
+
+	batcher, ok := ing.store.(storage.BatchStore)
+	if !ok {
+		for _, pc := range toWrite {
+			if err := ing.store.Put(ctx, pc.chunk); err != nil {
+				return err
+			}
+			filter.Add(pc.chunk.ID())
+		}
+		ing.maybeCheckpointBloom(ctx, len(toWrite))
+		return nil
+	}
+
+	objs := make([]core.Object, len(toWrite))
+	for i, pc := range toWrite {
+		objs[i] = pc.chunk
+	}
+
+	err := batcher.PutBatch(ctx, objs)
+	var batchErr *storage.BatchError
+	if errors.As(err, &batchErr) {
+		// 批量写入部分失败：只对报出来的那几个下标重试逐个 Put，已经成功的不重复发，
+		// 跟请求里要求的"报失败对象的下标而不是整批失败"对应起来
+		failedAt := make(map[int]error, len(batchErr.Failures))
+		for _, f := range batchErr.Failures {
+			failedAt[f.Index] = f.Err
+		}
+		for i, pc := range toWrite {
+			if ferr, isFailed := failedAt[i]; isFailed {
+				if retryErr := ing.store.Put(ctx, pc.chunk); retryErr != nil {
+					return fmt.Errorf("chunk %s failed in batch (%w) and retry failed: %w", pc.chunk.ID(), ferr, retryErr)
+				}
+			}
+			filter.Add(pc.chunk.ID())
+		}
+		ing.maybeCheckpointBloom(ctx, len(toWrite))
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("batch put failed: %w", err)
+	}
+
+	for _, pc := range toWrite {
+		filter.Add(pc.chunk.ID())
+	}
+	ing.maybeCheckpointBloom(ctx, len(toWrite))
+	return nil
+}
+
+// loadBloomFilter 返回本次 ingest 会话共享的 Filter，第一次调用时尝试从 bloomObjectKey
+// 读取上一次留下的 checkpoint；读不到 (不存在/损坏/Store 暂时不支持) 就从零开始一个新的——
+// 代价只是头几个 Chunk 会多做几次不必要的 Put，不影响正确性
+func (ing *Ingester) loadBloomFilter(ctx context.Context) *bloom.Filter {
+	ing.bloomOnce.Do(func() {
+		f, err := readBloomFilter(ctx, ing.store)
+		if err != nil {
+			f = bloom.New(bloomExpectedChunks, bloomTargetFPR)
+		}
+		ing.bloom = f
+	})
+	return ing.bloom
+}
+
+func readBloomFilter(ctx context.Context, store storage.Store) (*bloom.Filter, error) {
+	reader, err := store.Get(ctx, bloomObjectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	return bloom.Unmarshal(data)
+}
+
+// maybeCheckpointBloom 每攒够 bloomCheckpointEvery 个新 Chunk 就把 Filter 刷一次盘。
+// n 是这次调用新增的 Chunk 数量 —— 批量 flush 时一次性记一批的数量，而不是退化成循环调用
+// n 次，省得每个 Chunk 都去抢 bloomMu
+func (ing *Ingester) maybeCheckpointBloom(ctx context.Context, n int) {
+	ing.bloomMu.Lock()
+	ing.sinceCheckpoint += n
+	shouldFlush := ing.sinceCheckpoint >= bloomCheckpointEvery
+	if shouldFlush {
+		ing.sinceCheckpoint = 0
+	}
+	ing.bloomMu.Unlock()
+
+	if shouldFlush {
+		ing.checkpointBloom(ctx)
+	}
+}
+
+// checkpointBloom 把当前 Filter 写回 bloomObjectKey。写之前先 Merge 一次远端现状的
+// Filter：多个客户端各自独立 Add 互不冲突 (bit 位只会越置越多)，Merge 之后谁先谁后写入
+// 都会收敛到同一个并集状态，而不是后写的覆盖掉先写的
+//
+// 普通的 Store.Put 对同一个 Key 只写一次就不会再覆盖 (内容寻址对象的幂等写语义)，所以这里
+// 需要的是能无条件覆盖写的能力——跟 pkg/gc 的 PutRaw 用法一致，挂在同一个可选的
+// storage.RawStore 接口上。拿不到这个能力时 (比如远端只是纯 S3) 就放弃这一轮 checkpoint，
+// Filter 仍然留在内存里，只是这个进程退出后下次要重新热身
+func (ing *Ingester) checkpointBloom(ctx context.Context) {
+	adapter, ok := rawStoreBackend(ing.store)
+	if !ok {
+		return
+	}
+
+	if remote, err := readBloomFilter(ctx, ing.store); err == nil {
+		_ = ing.bloom.Merge(remote) //nolint:errcheck // m/k 不一致理论上不会发生；发生了也只是跳过这次 merge
+	}
+
+	_ = adapter.PutRaw(ctx, bloomObjectKey, ing.bloom.Marshal()) //nolint:errcheck // checkpoint 失败不影响正确性，下次还会再试
+}
+
+// rawStoreBackend 顺着 storage.UnwrapDecorator 链往下找到第一个实现了 storage.RawStore
+// 的 Store——只有它能无条件覆盖写任意 key。跟 pkg/gc 的 rawBackend 是同一个思路，共用
+// 同一个解包辅助函数
+func rawStoreBackend(store storage.Store) (storage.RawStore, bool) {
+	for {
+		if r, ok := store.(storage.RawStore); ok {
+			return r, true
+		}
+		next, ok := storage.UnwrapDecorator(store)
+		if !ok {
+			return nil, false
+		}
+		store = next
+	}
+}
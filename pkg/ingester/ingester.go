@@ -5,11 +5,19 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
+	"sync/atomic"
 
+	"tensorvault/pkg/bloom"
 	"tensorvault/pkg/chunker"
 	"tensorvault/pkg/core"
 	"tensorvault/pkg/storage"
+	"tensorvault/pkg/telemetry"
+	"tensorvault/pkg/types"
 
+	"github.com/spf13/viper"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -17,18 +25,76 @@ import (
 const (
 	WorkerCount    = 16              // 并发上传的 Worker 数量
 	ReadBufferSize = 1 * 1024 * 1024 // 每次从磁盘读取 1MB 进行处理 (Generator buffer)
+
+	// batchMaxChunks/batchMaxBytes 是每个 Worker 本地攒批的阈值：攒够 64 个 Chunk 或者
+	// 4MiB（先到者为准）就 flush 一次。类比 LevelDB 的 Batch——攒太多会让单次 flush 的
+	// 延迟过高，也会在失败重试时把太多已经传了一半的数据重新发一遍
+	batchMaxChunks = 64
+	batchMaxBytes  = 4 * 1024 * 1024
 )
 
 type Ingester struct {
 	store   storage.Store
-	chunker *chunker.Chunker
+	chunker chunker.Chunker
+	hasher  core.Hasher
+
+	// bloomOnce 保证 Bloom Filter 只在第一次真正 ingest 的时候才去读远端 checkpoint，
+	// 而不是在每个 Ingester 实例创建时都去 I/O 一次 (大部分调用方只是用来算 Hash，根本不碰 Put)
+	bloomOnce       sync.Once
+	bloom           *bloom.Filter
+	bloomMu         sync.Mutex
+	sinceCheckpoint int
+
+	// chunksSeen/chunksDeduped 供 IngestFile 结束时计算 dedup ratio 上报到 span：
+	// putChunks 里每处理一个 Chunk 就计入 chunksSeen，Bloom Filter + Has 判定已存在、
+	// 跳过 Put 的那些额外计入 chunksDeduped。用 atomic 而不是加锁，因为 WorkerCount 个
+	// worker 并发调用 putChunks，每处理一个 Chunk 都要改这两个计数器
+	chunksSeen    atomic.Int64
+	chunksDeduped atomic.Int64
 }
 
-func NewIngester(store storage.Store) *Ingester {
+func NewIngester(store storage.Store, hasher core.Hasher) *Ingester {
 	return &Ingester{
 		store:   store,
-		chunker: chunker.NewChunker(),
+		chunker: newConfiguredChunker(),
+		hasher:  hasher,
+	}
+}
+
+// newConfiguredChunker 按 config.yaml 的 chunker.* 字段构造分块算法：tv init 会把
+// 当时选定的算法和参数写进仓库配置，之后每次 tv add 都要用同一套参数切分，否则同一份
+// 文件在不同次 add 之间会切出不一样的边界，去重直接失效。没配 chunker.algo（老仓库、
+// 或者用户没动过这段）就退回 DefaultConfig()，也就是原来唯一的 Gear 实现，保证行为
+// 对已有仓库不变
+func newConfiguredChunker() chunker.Chunker {
+	cfg := chunker.DefaultConfig()
+	if algo := viper.GetString("chunker.algo"); algo != "" {
+		cfg.Algo = chunker.Algo(algo)
+	}
+	if v := viper.GetInt("chunker.min"); v > 0 {
+		cfg.Min = v
+	}
+	if v := viper.GetInt("chunker.avg"); v > 0 {
+		cfg.Avg = v
+	}
+	if v := viper.GetInt("chunker.max"); v > 0 {
+		cfg.Max = v
+	}
+	if v := viper.GetInt64("chunker.polynomial"); v > 0 {
+		cfg.Polynomial = uint64(v)
 	}
+	if v := viper.GetInt64("chunker.seed"); v != 0 {
+		cfg.Seed = v
+	}
+
+	c, err := chunker.NewChunker(cfg)
+	if err != nil {
+		// 配置里的算法名写错了也不应该让 tv add 直接崩掉——退回出厂默认实现，
+		// 让命令至少能跑起来（这跟 buildAuthenticator 对未知 auth.mode 直接报错
+		// 不同：鉴权配错是安全问题必须硬失败，分块算法配错顶多是去重率变差）
+		c, _ = chunker.NewChunker(chunker.DefaultConfig())
+	}
+	return c
 }
 
 // job 代表一个待处理的 Chunk 任务 (Generator -> Worker)
@@ -44,7 +110,27 @@ type result struct {
 }
 
 // IngestFile 使用流水线并发处理文件
-func (ing *Ingester) IngestFile(ctx context.Context, reader io.Reader) (*core.FileNode, error) {
+func (ing *Ingester) IngestFile(ctx context.Context, reader io.Reader) (fileNode *core.FileNode, err error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "ingester.IngestFile")
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(otelcodes.Error, err.Error())
+		} else if fileNode != nil {
+			seen, deduped := ing.chunksSeen.Load(), ing.chunksDeduped.Load()
+			var dedupRatio float64
+			if seen > 0 {
+				dedupRatio = float64(deduped) / float64(seen)
+			}
+			span.SetAttributes(
+				attribute.Int("chunk_count", len(fileNode.Chunks)),
+				attribute.Int64("bytes", fileNode.TotalSize),
+				attribute.Int64("chunks_deduped", deduped),
+				attribute.Float64("dedup_ratio", dedupRatio),
+			)
+		}
+		span.End()
+	}()
 
 	// channels 带有 buffer，起到背压 (Backpressure) 的作用
 	jobsCh := make(chan job, WorkerCount*2)
@@ -59,21 +145,46 @@ func (ing *Ingester) IngestFile(ctx context.Context, reader io.Reader) (*core.Fi
 	})
 
 	// 3. 启动 Workers (属于 Layer A)
+	// 每个 Worker 在本地攒一批 Chunk 再 flush，而不是每个 Job 都单独 Put 一次：
+	// Store 实现了 storage.BatchStore 时（S3 多对象上传、单事务的 Postgres/Bolt 等）
+	// 一批只打一次往返，省掉每个对象单独的 TLS 握手/DB round-trip
 	for range WorkerCount {
 		producerG.Go(func() error {
-			for j := range jobsCh {
-				chunkObj := core.NewChunk(j.data)
+			var batch []pendingChunk
+			var batchBytes int64
+
+			flush := func() error {
+				if len(batch) == 0 {
+					return nil
+				}
 				// 注意：这里用的是 producerCtx，一旦报错，所有 Worker + Gen 立即停止
-				if err := ing.store.Put(producerCtx, chunkObj); err != nil {
+				if err := ing.putChunks(producerCtx, batch); err != nil {
 					return err
 				}
-				select {
-				case resultsCh <- result{index: j.index, link: core.NewChunkLink(chunkObj)}:
-				case <-producerCtx.Done():
-					return producerCtx.Err()
+				for _, pc := range batch {
+					select {
+					case resultsCh <- result{index: pc.jobIndex, link: core.NewChunkLink(pc.chunk)}:
+					case <-producerCtx.Done():
+						return producerCtx.Err()
+					}
 				}
+				batch = batch[:0]
+				batchBytes = 0
+				return nil
 			}
-			return nil
+
+			for j := range jobsCh {
+				chunkObj := core.NewChunk(j.data, ing.hasher)
+				batch = append(batch, pendingChunk{jobIndex: j.index, chunk: chunkObj})
+				batchBytes += int64(len(j.data))
+
+				if len(batch) >= batchMaxChunks || batchBytes >= batchMaxBytes {
+					if err := flush(); err != nil {
+						return err
+					}
+				}
+			}
+			return flush()
 		})
 	}
 
@@ -86,7 +197,7 @@ func (ing *Ingester) IngestFile(ctx context.Context, reader io.Reader) (*core.Fi
 	// 主线程：执行 Collector(Layer C)
 	// 如果 producerG 出错，resultsCh 会被关闭（因为 generateJobs 或 worker 退出），
 	// 或者 ctx 被 cancel。Collector 会在读取 channel 或 ctx check 时感知到。
-	fileNode, err := ing.collect(ctx, resultsCh)
+	fileNode, err = ing.collect(ctx, resultsCh)
 	if err != nil {
 		// 优先返回 producer 的错误（那是根因）
 		if pErr := producerG.Wait(); pErr != nil {
@@ -108,6 +219,60 @@ func (ing *Ingester) IngestFile(ctx context.Context, reader io.Reader) (*core.Fi
 	return fileNode, nil
 }
 
+// HashReader 计算一段内容的 FileNode Hash，但不会把任何 Chunk 写入 Store
+// 用于 `tv status` 这类只读场景：我们只关心内容是否发生变化，不应该为了比较
+// 就产生新的对象写入（尤其是在大文件上反复执行时）
+func (ing *Ingester) HashReader(ctx context.Context, reader io.Reader) (types.Hash, error) {
+	builder := core.NewFileNodeBuilder(ing.hasher)
+	buffer := make([]byte, ReadBufferSize)
+	var remainder []byte
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+
+		n, err := reader.Read(buffer)
+		if n > 0 {
+			processingData := make([]byte, len(remainder)+n)
+			copy(processingData, remainder)
+			copy(processingData[len(remainder):], buffer[:n])
+
+			cutPoints := ing.chunker.Cut(processingData)
+			start := 0
+			for _, end := range cutPoints {
+				chunkData := make([]byte, end-start)
+				copy(chunkData, processingData[start:end])
+				builder.Add(core.NewChunk(chunkData, ing.hasher))
+				start = end
+			}
+
+			if start < len(processingData) {
+				remainder = make([]byte, len(processingData)-start)
+				copy(remainder, processingData[start:])
+			} else {
+				remainder = nil
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			if len(remainder) > 0 {
+				builder.Add(core.NewChunk(remainder, ing.hasher))
+			}
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("read error: %w", err)
+		}
+	}
+
+	// putter=nil：只计算 Hash，不持久化任何中间层 FileNode（也不会有 Chunk 被写过）
+	node, err := builder.Build(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	return node.ID(), nil
+}
+
 // generateJobs 实现流式 CDC 切分
 func (ing *Ingester) generateJobs(ctx context.Context, reader io.Reader, jobsCh chan<- job) error {
 	buffer := make([]byte, ReadBufferSize)
@@ -169,7 +334,7 @@ func (ing *Ingester) generateJobs(ctx context.Context, reader io.Reader, jobsCh
 }
 
 // collect 收集结果并重组
-func (ing *Ingester) collect(_ context.Context, results <-chan result) (*core.FileNode, error) {
+func (ing *Ingester) collect(ctx context.Context, results <-chan result) (*core.FileNode, error) {
 	// 乱序缓冲池
 	pending := make(map[int]core.ChunkLink)
 
@@ -205,5 +370,7 @@ func (ing *Ingester) collect(_ context.Context, results <-chan result) (*core.Fi
 		return nil, fmt.Errorf("integrity error: missing chunks in sequence (pending: %d)", len(pending))
 	}
 
-	return core.NewFileNode(totalSize, chunks)
+	// 超过 core.K 个 Chunk 时，BuildTree 会自动把它们打包成中间层 FileNode 并立即 Put 进
+	// ing.store；根节点仍然留给调用方 (IngestFile) 去 Put，跟以前的行为保持一致
+	return core.BuildTree(ctx, ing.store, totalSize, chunks, ing.hasher)
 }
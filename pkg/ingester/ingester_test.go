@@ -4,23 +4,35 @@ import (
 	"bytes"
 	"context"
 	"crypto/rand"
+	"fmt"
 	"io"
+	"sync"
 	"testing"
 
 	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
 	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// testHasher 是测试用的默认哈希算法，跟仓库未配置 hashing.algo 时的隐式默认值一致
+func testHasher(t *testing.T) core.Hasher {
+	t.Helper()
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	return hasher
+}
+
 func TestIngestFlow(t *testing.T) {
 	// 1. 准备环境
 	tmpDir := t.TempDir()
 	store, err := disk.NewAdapter(tmpDir)
 	require.NoError(t, err)
 
-	ing := NewIngester(store)
+	ing := NewIngester(store, testHasher(t))
 	ctx := context.Background()
 
 	// 2. 准备一个“大”文件 (100KB 随机数据，足以触发多次切分)
@@ -62,7 +74,7 @@ func TestIngest_Concurrency_LargeData(t *testing.T) {
 	store, err := disk.NewAdapter(tmpDir)
 	require.NoError(t, err)
 
-	ing := NewIngester(store)
+	ing := NewIngester(store, testHasher(t))
 	ctx := context.Background()
 
 	// 2. 生成 5MB 随机数据
@@ -107,7 +119,7 @@ func TestIngest_Concurrency_LargeData(t *testing.T) {
 		require.NoError(t, err)
 
 		// 验证当前块的 Hash 是否真的匹配 (防御性检查)
-		actualHash := core.CalculateBlobHash(chunkBytes)
+		actualHash := core.CalculateBlobHash(chunkBytes, testHasher(t))
 		assert.Equal(t, link.Cid.Hash, actualHash, "Chunk %d content mismatch with hash", i)
 
 		reassembled.Write(chunkBytes)
@@ -120,3 +132,66 @@ func TestIngest_Concurrency_LargeData(t *testing.T) {
 		t.Log("✅ Integrity Verified: 5MB data reassembled perfectly.")
 	}
 }
+
+// fakeBatchStore 包了一层 *disk.Adapter，额外实现 storage.BatchStore，并且在第一次
+// PutBatch 调用里故意让下标 0 那个对象"失败"一次——用来验证 putChunks 在批量部分失败
+// 之后仍然能让所有 Chunk 落盘（测试只检查最终每个 Chunk 都在 Store 里，不统计重发次数）
+type fakeBatchStore struct {
+	*disk.Adapter
+
+	mu            sync.Mutex
+	batchCalls    int
+	failedOnceIdx bool
+}
+
+func (f *fakeBatchStore) PutBatch(ctx context.Context, objs []core.Object) error {
+	f.mu.Lock()
+	f.batchCalls++
+	simulateFailure := !f.failedOnceIdx
+	if simulateFailure {
+		f.failedOnceIdx = true
+	}
+	f.mu.Unlock()
+
+	var failures []storage.BatchFailure
+	for i, obj := range objs {
+		if simulateFailure && i == 0 {
+			failures = append(failures, storage.BatchFailure{Index: i, Err: fmt.Errorf("simulated transient failure")})
+			continue
+		}
+		if err := f.Adapter.Put(ctx, obj); err != nil {
+			return err
+		}
+	}
+	if len(failures) > 0 {
+		return &storage.BatchError{Failures: failures}
+	}
+	return nil
+}
+
+func TestIngestFile_BatchStorePartialFailureRetry(t *testing.T) {
+	inner, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+	store := &fakeBatchStore{Adapter: inner}
+
+	ing := NewIngester(store, testHasher(t))
+	ctx := context.Background()
+
+	content := bytes.Repeat([]byte("Batched TensorVault chunks go through PutBatch "), 5000)
+	fileNode, err := ing.IngestFile(ctx, bytes.NewReader(content))
+	require.NoError(t, err)
+
+	assert.Greater(t, store.batchCalls, 0, "应该至少触发过一次 PutBatch")
+
+	// 第一次 PutBatch 里被模拟失败、靠单个 Put 重试成功的那个 Chunk，最终也应该落盘，
+	// 跟其它所有 Chunk 一样完整
+	for i, link := range fileNode.Chunks {
+		exists, err := store.Has(ctx, link.Hash.Hash)
+		require.NoError(t, err)
+		assert.True(t, exists, "chunk %d should exist in store after batch+retry", i)
+	}
+
+	exists, err := store.Has(ctx, fileNode.ID().String())
+	require.NoError(t, err)
+	assert.True(t, exists, "FileNode 本身应该被持久化")
+}
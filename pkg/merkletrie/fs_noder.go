@@ -0,0 +1,92 @@
+package merkletrie
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/types"
+)
+
+// HashFile 计算磁盘上一个文件当前内容对应的 Hash
+//
+// 调用方通常会传入一个带 size/mtime 缓存的快路径实现（比如信任 Index 里已经记录的 Hash），
+// 而不是每次都重新读取、切分整个文件——参见 worktree.Walker.hashWorktreeFile。
+type HashFile func(ctx context.Context, fullPath string, info os.FileInfo) (types.Hash, error)
+
+// FilesystemNoder 把磁盘上的一个目录/文件包装成 Noder
+//
+// 跟 TreeNoder/IndexNoder 不同，真实文件系统没有现成的、可以直接比较的目录 Hash：必须先
+// 把子树内容都哈希出来，才能用跟 core.NewTree 一样的算法算出当前目录的 Hash。所以构造
+// FilesystemNoder 本身是 O(files) 的——它并不具备跳过未改动子树的能力，这个能力来自于
+// 把它的对侧换成 TreeNoder 或 IndexNoder（它们的 Hash 在没有改动时可以做到 O(1) 获取）。
+type FilesystemNoder struct {
+	name     string
+	isDir    bool
+	hash     types.Hash
+	size     int64
+	children []Noder
+}
+
+// NewFilesystemNoder 递归遍历 rootDir，对每个文件调用 hashFile 计算 Hash
+func NewFilesystemNoder(ctx context.Context, rootDir string, hashFile HashFile, hasher core.Hasher) (*FilesystemNoder, error) {
+	return buildFSNode(ctx, rootDir, "", hashFile, hasher)
+}
+
+func buildFSNode(ctx context.Context, fullPath, name string, hashFile HashFile, hasher core.Hasher) (*FilesystemNoder, error) {
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("merkletrie: failed to stat %s: %w", fullPath, err)
+	}
+
+	if !info.IsDir() {
+		hash, err := hashFile(ctx, fullPath, info)
+		if err != nil {
+			return nil, fmt.Errorf("merkletrie: failed to hash %s: %w", fullPath, err)
+		}
+		return &FilesystemNoder{name: name, hash: hash, size: info.Size()}, nil
+	}
+
+	dirEntries, err := os.ReadDir(fullPath)
+	if err != nil {
+		return nil, fmt.Errorf("merkletrie: failed to read dir %s: %w", fullPath, err)
+	}
+	names := make([]string, 0, len(dirEntries))
+	byName := make(map[string]os.DirEntry, len(dirEntries))
+	for _, e := range dirEntries {
+		names = append(names, e.Name())
+		byName[e.Name()] = e
+	}
+	sort.Strings(names)
+
+	entries := make([]core.TreeEntry, 0, len(names))
+	children := make([]Noder, 0, len(names))
+	for _, name := range names {
+		child, err := buildFSNode(ctx, filepath.Join(fullPath, name), name, hashFile, hasher)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+		if byName[name].IsDir() {
+			entries = append(entries, core.NewDirEntry(name, child.hash))
+		} else {
+			entries = append(entries, core.NewFileEntry(name, child.hash, child.size))
+		}
+	}
+
+	tree, err := core.NewTree(entries, hasher)
+	if err != nil {
+		return nil, err
+	}
+	return &FilesystemNoder{name: name, isDir: true, hash: tree.ID(), children: children}, nil
+}
+
+func (n *FilesystemNoder) Name() string     { return n.name }
+func (n *FilesystemNoder) Hash() types.Hash { return n.hash }
+func (n *FilesystemNoder) IsDir() bool      { return n.isDir }
+func (n *FilesystemNoder) Children(ctx context.Context) ([]Noder, error) {
+	return n.children, nil
+}
@@ -0,0 +1,117 @@
+package merkletrie
+
+import (
+	"context"
+	"path"
+	"sort"
+	"strings"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/index"
+	"tensorvault/pkg/types"
+)
+
+// IndexNoder 把暂存区 (Index) 包装成 Noder
+//
+// Index 里只有「文件路径 -> FileNode Hash」的扁平记录，没有现成的目录 Hash —— 构造时按
+// treebuilder.Builder 完全一样的算法（自底向上、用 core.NewTree）就地把每一级目录的 Hash
+// 算出来，但不会把任何 Tree 对象写入 Store（只读对比，没有副作用）。只要 Index 的内容跟
+// 某次提交时完全一致，这里算出来的目录 Hash 就会跟那次提交的 Tree Hash 完全相等——这正是
+// DiffTree 能跳过未改动子树的前提。
+type IndexNoder struct {
+	name     string
+	hash     types.Hash
+	isDir    bool
+	children []Noder
+}
+
+// NewIndexNoder 从 idx 的快照构建出根目录节点
+func NewIndexNoder(idx *index.Index, hasher core.Hasher) (*IndexNoder, error) {
+	root := newIdxDir("")
+	for p, entry := range idx.Snapshot() {
+		root.addFile(p, entry)
+	}
+	return root.build(hasher)
+}
+
+func (n *IndexNoder) Name() string     { return n.name }
+func (n *IndexNoder) Hash() types.Hash { return n.hash }
+func (n *IndexNoder) IsDir() bool      { return n.isDir }
+func (n *IndexNoder) Children(ctx context.Context) ([]Noder, error) {
+	return n.children, nil
+}
+
+// -----------------------------------------------------------------------------
+// 内部辅助结构：内存目录树（算法跟 pkg/treebuilder 的 node 一致）
+// -----------------------------------------------------------------------------
+
+type idxNode struct {
+	name     string
+	isDir    bool
+	entry    index.Entry
+	children map[string]*idxNode
+}
+
+func newIdxDir(name string) *idxNode {
+	return &idxNode{name: name, isDir: true, children: make(map[string]*idxNode)}
+}
+
+func (n *idxNode) mkdirP(dirPath string) *idxNode {
+	if dirPath == "" || dirPath == "." {
+		return n
+	}
+	current := n
+	for _, part := range strings.Split(dirPath, "/") {
+		if part == "" {
+			continue
+		}
+		if _, ok := current.children[part]; !ok {
+			current.children[part] = newIdxDir(part)
+		}
+		current = current.children[part]
+	}
+	return current
+}
+
+func (n *idxNode) addFile(fullPath string, entry index.Entry) {
+	dir, name := path.Split(fullPath)
+	dir = strings.TrimSuffix(dir, "/")
+	parent := n.mkdirP(dir)
+	parent.children[name] = &idxNode{name: name, entry: entry}
+}
+
+// build 递归地把内存节点转换成 IndexNoder
+func (n *idxNode) build(hasher core.Hasher) (*IndexNoder, error) {
+	if !n.isDir {
+		return &IndexNoder{name: n.name, hash: n.entry.Hash}, nil
+	}
+
+	names := make([]string, 0, len(n.children))
+	for name := range n.children {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	entries := make([]core.TreeEntry, 0, len(names))
+	children := make([]Noder, 0, len(names))
+	for _, name := range names {
+		child := n.children[name]
+		childNoder, err := child.build(hasher)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, childNoder)
+
+		if child.isDir {
+			entries = append(entries, core.NewDirEntry(name, childNoder.hash))
+		} else {
+			entries = append(entries, core.NewFileEntry(name, childNoder.hash, child.entry.Size))
+		}
+	}
+
+	tree, err := core.NewTree(entries, hasher)
+	if err != nil {
+		return nil, err
+	}
+	return &IndexNoder{name: n.name, hash: tree.ID(), isDir: true, children: children}, nil
+}
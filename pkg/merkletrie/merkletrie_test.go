@@ -0,0 +1,146 @@
+package merkletrie
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/index"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/treebuilder"
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+// -----------------------------------------------------------------------------
+// fakeNoder：纯内存的 Noder 实现，用来验证 DiffTree 本身的对比/短路逻辑，
+// 不依赖任何具体数据源
+// -----------------------------------------------------------------------------
+
+type fakeNoder struct {
+	name     string
+	hash     types.Hash
+	isDir    bool
+	children []Noder
+
+	opened *int // 每次 Children() 被调用就 +1，用来证明短路生效
+}
+
+func (n *fakeNoder) Name() string     { return n.name }
+func (n *fakeNoder) Hash() types.Hash { return n.hash }
+func (n *fakeNoder) IsDir() bool      { return n.isDir }
+func (n *fakeNoder) Children(ctx context.Context) ([]Noder, error) {
+	if n.opened != nil {
+		*n.opened++
+	}
+	return n.children, nil
+}
+
+func fakeFile(name string, hash types.Hash) *fakeNoder {
+	return &fakeNoder{name: name, hash: hash}
+}
+
+func fakeDir(name string, hash types.Hash, opened *int, children ...Noder) *fakeNoder {
+	return &fakeNoder{name: name, hash: hash, isDir: true, children: children, opened: opened}
+}
+
+func TestDiffTree_DetectsInsertDeleteModify(t *testing.T) {
+	unchangedCalls := 0
+
+	a := fakeDir("", "root-a", nil,
+		fakeFile("same.txt", "h-same"),
+		fakeFile("changed.txt", "h-old"),
+		fakeFile("removed.txt", "h-gone"),
+		fakeDir("untouched", "dir-same", &unchangedCalls, fakeFile("inner.bin", "h-inner")),
+	)
+	b := fakeDir("", "root-b", nil,
+		fakeFile("same.txt", "h-same"),
+		fakeFile("changed.txt", "h-new"),
+		fakeFile("added.txt", "h-added"),
+		fakeDir("untouched", "dir-same", &unchangedCalls, fakeFile("inner.bin", "h-inner")),
+	)
+
+	changes, err := DiffTree(context.Background(), a, b)
+	require.NoError(t, err)
+
+	byPath := make(map[string]Change, len(changes))
+	for _, c := range changes {
+		byPath[c.Path] = c
+	}
+
+	require.Equal(t, Modify, byPath["changed.txt"].Action)
+	require.Equal(t, types.Hash("h-old"), byPath["changed.txt"].OldHash)
+	require.Equal(t, types.Hash("h-new"), byPath["changed.txt"].NewHash)
+
+	require.Equal(t, Delete, byPath["removed.txt"].Action)
+	require.Equal(t, Insert, byPath["added.txt"].Action)
+
+	_, unchangedReported := byPath["same.txt"]
+	require.False(t, unchangedReported, "identical files must not be reported")
+	_, untouchedDirReported := byPath["untouched/inner.bin"]
+	require.False(t, untouchedDirReported, "unchanged subtree must not be reported")
+
+	// 关键断言：两侧目录 Hash 相同的子树，Children() 一次都不应该被调用
+	require.Equal(t, 0, unchangedCalls, "DiffTree must short-circuit subtrees with equal hash")
+}
+
+func TestDiffTree_OneSideMissing(t *testing.T) {
+	root := fakeDir("", "root", nil, fakeFile("a.txt", "h1"), fakeFile("b.txt", "h2"))
+
+	insertions, err := DiffTree(context.Background(), nil, root)
+	require.NoError(t, err)
+	require.Len(t, insertions, 2)
+	for _, c := range insertions {
+		require.Equal(t, Insert, c.Action)
+	}
+
+	deletions, err := DiffTree(context.Background(), root, nil)
+	require.NoError(t, err)
+	require.Len(t, deletions, 2)
+	for _, c := range deletions {
+		require.Equal(t, Delete, c.Action)
+	}
+}
+
+// TestIndexNoder_MatchesPersistedTreeHash 验证 IndexNoder 就地算出来的 Hash 跟
+// treebuilder.Builder 对同一份 Index 实际写入 Store 算出来的 Tree Hash 完全一致——
+// 这正是 Walker.diffTreeVsIndex 能够把未改动子树短路掉的前提
+func TestIndexNoder_MatchesPersistedTreeHash(t *testing.T) {
+	tmpDir := t.TempDir()
+	store, err := disk.NewAdapter(tmpDir)
+	require.NoError(t, err)
+
+	idx, err := index.NewIndex(filepath.Join(tmpDir, "index.json"))
+	require.NoError(t, err)
+	idx.Add("a.txt", "1111111111111111111111111111111111111111111111111111111111111111", 10)
+	idx.Add("sub/b.txt", "2222222222222222222222222222222222222222222222222222222222222222", 20)
+
+	ctx := context.Background()
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	builder := treebuilder.NewBuilder(store, hasher)
+	rootHash, err := builder.Build(ctx, idx)
+	require.NoError(t, err)
+
+	indexNoder, err := NewIndexNoder(idx, hasher)
+	require.NoError(t, err)
+	require.Equal(t, rootHash, indexNoder.Hash())
+
+	treeNoder := NewTreeNoder(store, rootHash)
+	changes, err := DiffTree(ctx, treeNoder, indexNoder)
+	require.NoError(t, err)
+	require.Empty(t, changes, "identical tree and index must diff to no changes")
+
+	// 现在改动 index 里的一个文件，预期只有那一条路径被报告出来
+	idx.Add("sub/b.txt", "3333333333333333333333333333333333333333333333333333333333333333", 30)
+	indexNoder2, err := NewIndexNoder(idx, hasher)
+	require.NoError(t, err)
+
+	changes, err = DiffTree(ctx, treeNoder, indexNoder2)
+	require.NoError(t, err)
+	require.Len(t, changes, 1)
+	require.Equal(t, "sub/b.txt", changes[0].Path)
+	require.Equal(t, Modify, changes[0].Action)
+}
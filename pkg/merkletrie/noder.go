@@ -0,0 +1,143 @@
+// Package merkletrie 提供一个与具体数据源无关的 Merkle 树三路/两路对比算法
+//
+// 设计上直接参考 go-git 的 utils/merkletrie：核心抽象是 Noder，算法本身完全不知道
+// 自己对比的是 HEAD 的 Tree、暂存区 Index 还是真实文件系统 —— 只要两棵树在某个节点上
+// Hash 相同，就认定这个节点（连同它整棵子树）没有变化，直接跳过、不会展开 Children()。
+// 这正是让 `tv status` 在一个巨大但基本没动过的目录上做到 O(depth) 而不是 O(files) 的
+// 关键：未改动的子树从不会被递归进去。
+package merkletrie
+
+import (
+	"context"
+	"sort"
+
+	"tensorvault/pkg/types"
+)
+
+// Noder 是 DiffTree 唯一依赖的抽象
+type Noder interface {
+	Name() string
+	Hash() types.Hash
+	IsDir() bool
+	// Children 只有目录节点才需要返回非空结果；调用方保证只在必要（两侧 Hash 不同）时才调用它
+	Children(ctx context.Context) ([]Noder, error)
+}
+
+// Action 描述一条变更相对于左侧 (a) 的语义
+type Action string
+
+const (
+	Insert Action = "insert" // 只在 b 里存在
+	Delete Action = "delete" // 只在 a 里存在
+	Modify Action = "modify" // 两侧都存在，但 Hash 不同
+)
+
+// Change 是 DiffTree 返回的一条路径级变更
+//
+// OldHash/NewHash 携带了触发这条变更的两侧 Hash（缺失的一侧留空），调用方经常还需要它们——
+// 比如 worktree.Walker 需要 OldHash 去跟工作区实际内容的 Hash 做第三路比较，不用为此
+// 再单独发起一次对 HEAD Tree 的查询。
+type Change struct {
+	Path    string
+	Action  Action
+	OldHash types.Hash // a 侧的 Hash；a 缺失（Insert）时为空
+	NewHash types.Hash // b 侧的 Hash；b 缺失（Delete）时为空
+}
+
+// DiffTree 对比两棵概念上的 Merkle 树 a、b，返回按路径排序的变更列表
+func DiffTree(ctx context.Context, a, b Noder) ([]Change, error) {
+	var changes []Change
+	if err := diffAt(ctx, "", a, b, &changes); err != nil {
+		return nil, err
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes, nil
+}
+
+func diffAt(ctx context.Context, path string, a, b Noder, out *[]Change) error {
+	switch {
+	case a == nil && b == nil:
+		return nil
+	case a == nil:
+		return collectAll(ctx, path, b, Insert, out)
+	case b == nil:
+		return collectAll(ctx, path, a, Delete, out)
+	}
+
+	if a.Hash() == b.Hash() {
+		return nil // 子树完全相同，不展开
+	}
+
+	if !a.IsDir() || !b.IsDir() {
+		// 两侧都是文件但内容不同，或者同名路径一侧是文件一侧是目录：都当作一次 Modify
+		*out = append(*out, Change{Path: path, Action: Modify, OldHash: a.Hash(), NewHash: b.Hash()})
+		return nil
+	}
+
+	aChildren, err := childrenByName(ctx, a)
+	if err != nil {
+		return err
+	}
+	bChildren, err := childrenByName(ctx, b)
+	if err != nil {
+		return err
+	}
+
+	names := make(map[string]struct{}, len(aChildren)+len(bChildren))
+	for name := range aChildren {
+		names[name] = struct{}{}
+	}
+	for name := range bChildren {
+		names[name] = struct{}{}
+	}
+
+	for name := range names {
+		if err := diffAt(ctx, joinPath(path, name), aChildren[name], bChildren[name], out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectAll 把 n 为根的整棵子树下的所有叶子路径都记为同一个 action（用于一侧整个缺失的情况）
+func collectAll(ctx context.Context, path string, n Noder, action Action, out *[]Change) error {
+	if !n.IsDir() {
+		change := Change{Path: path, Action: action}
+		if action == Insert {
+			change.NewHash = n.Hash()
+		} else {
+			change.OldHash = n.Hash()
+		}
+		*out = append(*out, change)
+		return nil
+	}
+	children, err := n.Children(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range children {
+		if err := collectAll(ctx, joinPath(path, c.Name()), c, action, out); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func childrenByName(ctx context.Context, n Noder) (map[string]Noder, error) {
+	children, err := n.Children(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := make(map[string]Noder, len(children))
+	for _, c := range children {
+		m[c.Name()] = c
+	}
+	return m, nil
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "/" + name
+}
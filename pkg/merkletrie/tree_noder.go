@@ -0,0 +1,63 @@
+package merkletrie
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// TreeNoder 把一个 core.Tree（通过 Hash 寻址）包装成 Noder
+//
+// 子节点只有在 Children 被调用时才从 Store 里解码，从不会提前把整棵 Merkle 树拉到内存里。
+type TreeNoder struct {
+	store storage.Store
+	name  string
+	hash  types.Hash
+	isDir bool
+}
+
+// NewTreeNoder 构造一棵 HEAD 树的根节点。rootHash 为空代表空仓库（没有任何提交），
+// 此时这个节点就是一个没有子节点的空目录
+func NewTreeNoder(store storage.Store, rootHash types.Hash) *TreeNoder {
+	return &TreeNoder{store: store, hash: rootHash, isDir: true}
+}
+
+func (n *TreeNoder) Name() string     { return n.name }
+func (n *TreeNoder) Hash() types.Hash { return n.hash }
+func (n *TreeNoder) IsDir() bool      { return n.isDir }
+
+func (n *TreeNoder) Children(ctx context.Context) ([]Noder, error) {
+	if !n.isDir || n.hash == "" {
+		return nil, nil
+	}
+
+	reader, err := n.store.Get(ctx, n.hash.String())
+	if err != nil {
+		return nil, fmt.Errorf("merkletrie: failed to fetch tree %s: %w", n.hash, err)
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("merkletrie: failed to read tree %s: %w", n.hash, err)
+	}
+
+	var tree core.Tree
+	if err := core.DecodeObject(data, &tree); err != nil {
+		return nil, fmt.Errorf("merkletrie: object %s is not a valid tree: %w", n.hash, err)
+	}
+
+	children := make([]Noder, 0, len(tree.Entries))
+	for _, e := range tree.Entries {
+		children = append(children, &TreeNoder{
+			store: n.store,
+			name:  e.Name,
+			hash:  types.Hash(e.Cid.Hash),
+			isDir: e.Type == core.EntryDir,
+		})
+	}
+	return children, nil
+}
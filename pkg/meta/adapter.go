@@ -58,7 +58,7 @@ func NewDB(ctx context.Context, cfg Config) (*DB, error) {
 	}
 
 	// 自动迁移表结构
-	err = db.AutoMigrate(&Ref{}, &CommitModel{}, &FileIndex{})
+	err = db.AutoMigrate(&Ref{}, &CommitModel{}, &FileIndex{}, &BranchModel{}, &TaskModel{}, &RestoreJobModel{}, &TombstoneModel{}, &UploadSessionModel{}, &RepoConfigModel{})
 	if err != nil {
 		return nil, fmt.Errorf("auto migration failed: %w", err)
 	}
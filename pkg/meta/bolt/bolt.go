@@ -0,0 +1,264 @@
+// Package bolt 提供一个基于 go.etcd.io/bbolt 的嵌入式 Repository 后端。
+//
+// 动机：pkg/meta.Repository 构建在 GORM/Postgres 之上，单机试用 CLI 的用户得先起一个
+// 数据库才能跑 `tv commit`/`tv log`。这个包把 Repository 暴露的 Ref/Commit 读写面
+// （GetRef、UpdateRef 的 CAS 语义、IndexCommit 的幂等写入、GetCommit、FindCommitsByAuthor）
+// 原样搬到一个进程内的嵌入式 KV 存储上：返回值直接复用 meta.Ref / meta.CommitModel 和
+// meta.Err* 哨兵错误，调用方不需要关心底层到底是 SQL 还是 KV。
+//
+// 范围说明：pkg/meta.Repository 现在已经不只是这 5 个方法——分支追踪、任务队列、
+// Restore Job、Tombstone 都挂在同一个结构体上，而且 Repository 是个具体类型而非接口，
+// refs.Manager / task.Manager / pkg/gc 等调用方直接拿着 *meta.Repository 用它的全部方法。
+// 要让这个包在 pkg/app 里对它们透明可替换，得先把 Repository 拆成接口——那是一次牵一发
+// 动全身的重构，不在这个改动的范围内。这里先把 bbolt 版本的读写面做对、做全、测试覆盖，
+// 等分支追踪/任务队列也需要一个无数据库答案的时候，再把 pkg/app 的装配逻辑接上来。
+//
+// Bucket 布局：
+//   - "refs"：key 是 ref 名字，value 是 CBOR 编码的 {commit_hash, version, updated_at}
+//   - "commits"：key 是 commit hash，value 是 CBOR 编码的 meta.CommitModel
+//   - "commits_by_author"：二级索引，key 是 author|timestamp_be|hash 拼接的复合键，
+//     value 只存 commit hash，真正的记录仍然去 "commits" 里取；timestamp 用大端编码，
+//     保证同一作者下字节序就是时间序，方便 Cursor.Seek 前缀扫描
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/types"
+
+	"github.com/fxamacker/cbor/v2"
+	"go.etcd.io/bbolt"
+	"gorm.io/datatypes"
+)
+
+var (
+	refsBucket            = []byte("refs")
+	commitsBucket         = []byte("commits")
+	commitsByAuthorBucket = []byte("commits_by_author")
+)
+
+// Store 是 meta.Repository 的 Ref/Commit 读写面在 bbolt 上的实现
+type Store struct {
+	db *bbolt.DB
+}
+
+// Open 打开（或创建）一个 bbolt 文件，并确保所有用到的 bucket 都存在
+func Open(path string) (*Store, error) {
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bolt store at %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		for _, name := range [][]byte{refsBucket, commitsBucket, commitsByAuthorBucket} {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize bolt buckets: %w", err)
+	}
+
+	return &Store{db: db}, nil
+}
+
+// Close 释放底层文件句柄
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// refRecord 是 "refs" bucket 里存储的值，对应 meta.Ref 去掉主键 Name 之后的部分
+type refRecord struct {
+	CommitHash string
+	Version    int64
+	UpdatedAt  time.Time
+}
+
+// GetRef 获取分支的当前指向，语义和 meta.Repository.GetRef 一致
+func (s *Store) GetRef(ctx context.Context, name string) (*meta.Ref, error) {
+	var ref meta.Ref
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(refsBucket).Get([]byte(name))
+		if raw == nil {
+			return meta.ErrRefNotFound
+		}
+		var rec refRecord
+		if err := cbor.Unmarshal(raw, &rec); err != nil {
+			return fmt.Errorf("corrupt ref record for %q: %w", name, err)
+		}
+		ref = meta.Ref{Name: name, CommitHash: rec.CommitHash, Version: rec.Version, UpdatedAt: rec.UpdatedAt}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &ref, nil
+}
+
+// UpdateRef 原子更新引用 (CAS)，语义和 meta.Repository.UpdateRef 一致：
+// oldVersion == 0 表示"第一次创建"，已存在则失败；否则要求存储里的版本号严格匹配 oldVersion
+func (s *Store) UpdateRef(ctx context.Context, name string, newHash types.Hash, oldVersion int64) error {
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(refsBucket)
+		raw := b.Get([]byte(name))
+
+		var cur refRecord
+		if raw != nil {
+			if err := cbor.Unmarshal(raw, &cur); err != nil {
+				return fmt.Errorf("corrupt ref record for %q: %w", name, err)
+			}
+		}
+
+		if oldVersion == 0 {
+			if raw != nil {
+				return meta.ErrConcurrentUpdate
+			}
+			cur = refRecord{CommitHash: string(newHash), Version: 1, UpdatedAt: time.Now()}
+		} else {
+			if raw == nil || cur.Version != oldVersion {
+				return meta.ErrConcurrentUpdate
+			}
+			cur.CommitHash = string(newHash)
+			cur.Version++
+			cur.UpdatedAt = time.Now()
+		}
+
+		encoded, err := cbor.Marshal(cur)
+		if err != nil {
+			return fmt.Errorf("failed to encode ref record: %w", err)
+		}
+		return b.Put([]byte(name), encoded)
+	})
+}
+
+// IndexCommit 把 core.Commit 投影进 "commits" bucket，并同步更新按作者查询的二级索引。
+// 和 meta.Repository.IndexCommit 一样是幂等写入：hash 已经存在就什么都不做
+func (s *Store) IndexCommit(ctx context.Context, c *core.Commit) error {
+	var parentHashes []types.Hash
+	for _, p := range c.Parents {
+		parentHashes = append(parentHashes, types.Hash(p.Hash))
+	}
+	parentsJSON, err := json.Marshal(parentHashes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal parents: %w", err)
+	}
+
+	hash := string(c.ID())
+	model := meta.CommitModel{
+		Hash:      hash,
+		Author:    c.Author,
+		Message:   c.Message,
+		Timestamp: c.Timestamp,
+		TreeHash:  string(c.TreeCid.Hash),
+		Parents:   datatypes.JSON(parentsJSON),
+		CreatedAt: time.Unix(c.Timestamp, 0),
+	}
+	if c.Signature != nil {
+		model.SignerFingerprint = c.Signature.Fingerprint
+		model.Signature = c.Signature.Sig
+	}
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		commits := tx.Bucket(commitsBucket)
+		if commits.Get([]byte(hash)) != nil {
+			return nil
+		}
+
+		encoded, err := cbor.Marshal(model)
+		if err != nil {
+			return fmt.Errorf("failed to encode commit: %w", err)
+		}
+		if err := commits.Put([]byte(hash), encoded); err != nil {
+			return err
+		}
+
+		byAuthor := tx.Bucket(commitsByAuthorBucket)
+		return byAuthor.Put(authorKey(model.Author, model.Timestamp, hash), []byte(hash))
+	})
+}
+
+// GetCommit 按 hash 查询一条已索引的 commit
+func (s *Store) GetCommit(ctx context.Context, hash types.Hash) (*meta.CommitModel, error) {
+	var model meta.CommitModel
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		raw := tx.Bucket(commitsBucket).Get([]byte(hash))
+		if raw == nil {
+			return meta.ErrCommitNotFound
+		}
+		return cbor.Unmarshal(raw, &model)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &model, nil
+}
+
+// FindCommitsByAuthor 通过 "commits_by_author" 二级索引前缀扫描，按时间倒序返回最近 limit 条
+func (s *Store) FindCommitsByAuthor(ctx context.Context, author string, limit int) ([]meta.CommitModel, error) {
+	prefix := append([]byte(author), 0x00)
+
+	var hashes [][]byte
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		c := tx.Bucket(commitsByAuthorBucket).Cursor()
+		for k, v := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = c.Next() {
+			hashes = append(hashes, append([]byte(nil), v...))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make([]meta.CommitModel, 0, len(hashes))
+	err = s.db.View(func(tx *bbolt.Tx) error {
+		b := tx.Bucket(commitsBucket)
+		for _, h := range hashes {
+			raw := b.Get(h)
+			if raw == nil {
+				continue
+			}
+			var m meta.CommitModel
+			if err := cbor.Unmarshal(raw, &m); err != nil {
+				return fmt.Errorf("corrupt commit record for %q: %w", h, err)
+			}
+			commits = append(commits, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// commits_by_author 的 key 本身已经按时间正序排列，这里直接按 Timestamp 倒序排一遍，
+	// 跟 meta.Repository.FindCommitsByAuthor 的 "ORDER BY timestamp DESC" 保持一致
+	sort.Slice(commits, func(i, j int) bool { return commits[i].Timestamp > commits[j].Timestamp })
+	if limit > 0 && len(commits) > limit {
+		commits = commits[:limit]
+	}
+	return commits, nil
+}
+
+// authorKey 拼出 "commits_by_author" 的复合键：author|timestamp_be|hash
+// timestamp 用大端编码是关键——这样字节序和数值序一致，同一作者的 key 天然按时间正序排列
+func authorKey(author string, timestamp int64, hash string) []byte {
+	buf := make([]byte, 0, len(author)+1+8+1+len(hash))
+	buf = append(buf, author...)
+	buf = append(buf, 0x00)
+	var tsBytes [8]byte
+	binary.BigEndian.PutUint64(tsBytes[:], uint64(timestamp))
+	buf = append(buf, tsBytes[:]...)
+	buf = append(buf, 0x00)
+	buf = append(buf, hash...)
+	return buf
+}
@@ -0,0 +1,127 @@
+package bolt
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path/filepath"
+	"testing"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockHash 生成合法的测试用 Hash，和 pkg/meta 里的同名辅助函数保持一致
+func mockHash(input string) types.Hash {
+	sum := sha256.Sum256([]byte(input))
+	return types.Hash(hex.EncodeToString(sum[:]))
+}
+
+func setupTestStore(t *testing.T) *Store {
+	path := filepath.Join(t.TempDir(), "meta.bolt")
+	store, err := Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func TestStore_Ref_CAS(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	refName := "HEAD"
+	hashV1 := mockHash("v1")
+	hashV2 := mockHash("v2")
+
+	// 首次创建
+	require.NoError(t, store.UpdateRef(ctx, refName, hashV1, 0))
+
+	ref, err := store.GetRef(ctx, refName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), ref.Version)
+	assert.Equal(t, string(hashV1), ref.CommitHash)
+
+	// 并发冲突：version 对不上
+	err = store.UpdateRef(ctx, refName, hashV2, 999)
+	assert.ErrorIs(t, err, meta.ErrConcurrentUpdate)
+
+	// 正确版本号的更新
+	require.NoError(t, store.UpdateRef(ctx, refName, hashV2, 1))
+	ref, err = store.GetRef(ctx, refName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), ref.Version)
+	assert.Equal(t, string(hashV2), ref.CommitHash)
+}
+
+func TestStore_UpdateRef_ConcurrentCreate(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	require.NoError(t, store.UpdateRef(ctx, "HEAD", mockHash("A"), 0))
+
+	err := store.UpdateRef(ctx, "HEAD", mockHash("B"), 0)
+	assert.ErrorIs(t, err, meta.ErrConcurrentUpdate, "第二次 oldVersion=0 的创建应该因为 ref 已存在而失败")
+}
+
+func TestStore_GetRef_NotFound(t *testing.T) {
+	store := setupTestStore(t)
+	_, err := store.GetRef(context.Background(), "refs/heads/does-not-exist")
+	assert.ErrorIs(t, err, meta.ErrRefNotFound)
+}
+
+func TestStore_IndexCommit_Idempotency(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
+	c, err := core.NewCommit(mockHash("tree"), nil, "Bob", "Update", hasher)
+	require.NoError(t, err)
+
+	require.NoError(t, store.IndexCommit(ctx, c))
+	require.NoError(t, store.IndexCommit(ctx, c), "重复索引同一个 commit 不应该报错")
+
+	got, err := store.GetCommit(ctx, c.ID())
+	require.NoError(t, err)
+	assert.Equal(t, "Bob", got.Author)
+}
+
+func TestStore_GetCommit_NotFound(t *testing.T) {
+	store := setupTestStore(t)
+	_, err := store.GetCommit(context.Background(), mockHash("nope"))
+	assert.ErrorIs(t, err, meta.ErrCommitNotFound)
+}
+
+func TestStore_FindCommitsByAuthor(t *testing.T) {
+	store := setupTestStore(t)
+	ctx := context.Background()
+
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
+	c1, err := core.NewCommit(mockHash("t1"), nil, "Alice", "1", hasher)
+	require.NoError(t, err)
+	c1.Timestamp = 1000
+
+	c2, err := core.NewCommit(mockHash("t2"), nil, "Bob", "2", hasher)
+	require.NoError(t, err)
+
+	c3, err := core.NewCommit(mockHash("t3"), nil, "Alice", "3", hasher)
+	require.NoError(t, err)
+	c3.Timestamp = 3000 // 最新
+
+	require.NoError(t, store.IndexCommit(ctx, c1))
+	require.NoError(t, store.IndexCommit(ctx, c2))
+	require.NoError(t, store.IndexCommit(ctx, c3))
+
+	results, err := store.FindCommitsByAuthor(ctx, "Alice", 10)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	assert.Equal(t, c3.ID().String(), results[0].Hash, "最新的 commit 应该排第一")
+	assert.Equal(t, c1.ID().String(), results[1].Hash, "最老的 commit 应该排最后")
+}
@@ -27,7 +27,9 @@ func mockHash(input string) types.Hash {
 // 这让主测试代码极其干净
 func mustNewCommit(t *testing.T, treeHash types.Hash, parents []types.Hash, author, msg string, msgAndArgs ...any) *core.Commit {
 	t.Helper()
-	c, err := core.NewCommit(treeHash, parents, author, msg)
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	c, err := core.NewCommit(treeHash, parents, author, msg, hasher)
 	require.NoError(t, err, msgAndArgs...) // 透传消息
 	return c
 }
@@ -12,8 +12,10 @@ type Ref struct {
 	// Name 是主键，例如 "HEAD" 或 "refs/heads/main"
 	Name string `gorm:"primaryKey;type:varchar(255)"`
 
-	// CommitHash 指向当前的 Commit ID
-	CommitHash string `gorm:"type:char(64);not null"`
+	// CommitHash 指向当前的 Commit ID。列宽是 varchar(80) 而不是 char(64)：带算法标签的
+	// CID（比如 "blake3:" + 64 位十六进制，见 types.CID）比裸 SHA-256 字符串长，char(64)
+	// 放不下
+	CommitHash string `gorm:"type:varchar(80);not null"`
 
 	// Version 用于乐观锁并发控制 (CAS)
 	// 每次更新时 +1，防止并发覆盖
@@ -26,8 +28,9 @@ type Ref struct {
 // 用于快速查询历史 (tv log)，支持按作者、时间、元数据搜索
 // 注意：为了避免跟 core.Commit 混淆，我们叫它 CommitModel
 type CommitModel struct {
-	// Hash 是主键 (Merkle Root)
-	Hash string `gorm:"primaryKey;type:char(64)"`
+	// Hash 是主键 (Merkle Root)。varchar(80) 而不是 char(64)：带算法标签的 CID
+	// （"blake3:" + 64 位十六进制）比裸 SHA-256 字符串长
+	Hash string `gorm:"primaryKey;type:varchar(80)"`
 
 	// 基础元数据 (B-Tree 索引，适合排序和精确查找)
 	Author    string `gorm:"index;type:varchar(100)"`
@@ -35,7 +38,7 @@ type CommitModel struct {
 	Timestamp int64  `gorm:"index"` // 使用 int64 存时间戳，方便范围查询
 
 	// 树结构指针
-	TreeHash string `gorm:"type:char(64);not null"`
+	TreeHash string `gorm:"type:varchar(80);not null"`
 
 	// --- AI Infra 核心特性 ---
 
@@ -49,6 +52,18 @@ type CommitModel struct {
 	// 关键：使用 GIN 索引 (type:gin) 支持 {"accuracy": 0.9} 这种任意字段的毫秒级检索
 	Meta datatypes.JSON `gorm:"index:idx_commit_meta"`
 
+	// --- 签名 commit (core.Commit.Signature) 的投影 ---
+
+	// SignerFingerprint 为空表示这个 commit 没有签名。这一列建了索引，但 refs.Manager 的
+	// 签名者允许列表和 `tv log --show-signature` 都不读它：两者都是加载对象存储里完整的
+	// commit 对象，从它的签名字节判断
+	SignerFingerprint string `gorm:"index;type:varchar(64)"`
+
+	// Signature 是 core.Signature.Sig 的原始签名字节，跟 SignerFingerprint 一样只在
+	// commit 被签名时非空。放关系型数据库里纯粹是为了 `tv log` 展示方便，校验逻辑不依赖
+	// 这份投影——真正可信的数据始终是对象存储里那份完整编码过的 core.Commit
+	Signature []byte
+
 	CreatedAt time.Time
 }
 
@@ -56,3 +71,189 @@ type CommitModel struct {
 func (CommitModel) TableName() string {
 	return "commits"
 }
+
+// BranchModel 存储一条本地分支的 upstream 追踪配置
+// 对应 Git 的 .git/config 里的 [branch "xxx"] 小节 (go-git: config/branch.go)
+// 注意：分支当前指向哪个 Commit 仍然记在 Ref 表里 (Name = "refs/heads/<Name>")，
+// 这里只记追踪信息，职责上和 Ref 分开
+type BranchModel struct {
+	// Name 是主键，裸分支名，例如 "main"（不带 refs/heads/ 前缀）
+	Name string `gorm:"primaryKey;type:varchar(255)"`
+
+	// Remote 是追踪的远程名，例如 "origin"
+	Remote string `gorm:"type:varchar(255)"`
+
+	// Merge 是追踪的远程引用，例如 "refs/heads/main"
+	Merge string `gorm:"type:varchar(255)"`
+
+	// Rebase 决定同步上游时是 rebase 还是 merge
+	Rebase bool `gorm:"default:false"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 强制指定表名
+func (BranchModel) TableName() string {
+	return "branches"
+}
+
+// TaskModel 是一个异步任务在 Postgres 里的持久化记录
+// 对应 pkg/task 的 Job：worker 进程重启后，靠 HeartbeatAt 判断哪些 "running" 任务其实已经
+// 死掉了 (worker 挂了但没来得及把状态改回 pending)，从而安全地重新派发
+type TaskModel struct {
+	// ID 是主键，由 pkg/task 生成 (crypto/rand 十六进制字符串)
+	ID string `gorm:"primaryKey;type:varchar(36)"`
+
+	// Type 对应 task.Type: upload / checkout / gc_repack / tree_build
+	Type string `gorm:"index;type:varchar(32)"`
+
+	// PayloadJSON 是任务的入参 (例如 upload 任务的本地文件路径)
+	PayloadJSON datatypes.JSON
+
+	// Status: pending / running / succeeded / failed / cancelled
+	Status string `gorm:"index;type:varchar(16)"`
+
+	// CancelRequested 由 CancelJob 设置；worker 在下一次检查点会看到它并退出
+	CancelRequested bool `gorm:"default:false"`
+
+	Progress int    // 0-100
+	Message  string `gorm:"type:text"` // 最近一次进度上报的人类可读描述
+	Error    string `gorm:"type:text"` // 失败时的错误信息
+
+	// Log 是按时间顺序追加的日志行 (JSON 数组的字符串)，供 `tv jobs logs` 展示
+	Log datatypes.JSON
+
+	// HeartbeatAt 由 worker 在执行期间定期刷新；长时间不更新说明该 worker 已经不在了
+	HeartbeatAt time.Time
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 强制指定表名
+func (TaskModel) TableName() string {
+	return "tasks"
+}
+
+// RestoreJobModel 持久化一次可断点续传的 Tree -> 目录还原的进度 (exporter.RestoreJob)
+// 它跟 TaskModel 是分开的表：TaskModel 是通用的任务队列记录 (进度条、日志、取消)，这张表只
+// 关心"哪些 Chunk 已经写盘了"，这样进程崩溃重启后 Exporter.ResumeRestore 只需要补下载缺的
+// 部分，而不是把几百 GB 的模型从头下载一遍
+type RestoreJobModel struct {
+	// JobID 是主键，由 exporter 生成 (crypto/rand 十六进制字符串)，跟 TaskModel.ID 是两套
+	// 独立的 ID 空间：一次 checkout 既有一个 task 记录 (进度/取消)，也有一个 restore job 记录
+	// (断点位图)
+	JobID string `gorm:"primaryKey;type:varchar(36)"`
+
+	TreeHash  string `gorm:"type:varchar(80);not null"`
+	TargetDir string `gorm:"type:text;not null"`
+
+	// Files 是 JSON 编码的 map[相对路径]*fileCheckpoint，记录每个文件各自的 Chunk 完成位图
+	Files datatypes.JSON
+
+	// Status: running / done
+	Status string `gorm:"index;type:varchar(16)"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 强制指定表名
+func (RestoreJobModel) TableName() string {
+	return "restore_jobs"
+}
+
+// TombstoneModel 记录一次对象删除的审计痕迹，灵感来自 Prometheus TSDB 的 tombstone 模型：
+// gc.Sweep 在判定一个对象不可达、真正从 Store 删除之前，先在这里落一条"这个对象本该消失"
+// 的记录——这样即使进程在删除过程中崩溃，事后也能从这张表看出哪些对象处于已判定待删但
+// 可能还没删干净的状态，而不是留下一个无法解释的"对象凭空消失了"
+type TombstoneModel struct {
+	// ObjectHash 是主键：同一个对象只会被记录一次，哪怕它后来又被重新引用、之后又被
+	// 再次判定为不可达——Sweep 只依赖当次 mark 阶段算出的活跃集合做判断，不读这张表
+	ObjectHash string `gorm:"primaryKey;type:varchar(80)"`
+
+	DeletedAt time.Time `gorm:"index"`
+	Reason    string    `gorm:"type:varchar(64)"` // 例如 "gc_sweep"
+}
+
+// TableName 强制指定表名
+func (TombstoneModel) TableName() string {
+	return "tombstones"
+}
+
+// UploadSessionModel 持久化一次可断点续传的分片上传：客户端先用本地的 CDC 切分算出整份
+// 文件的 Chunk 哈希/大小列表交给 InitUpload 登记，之后每个 Chunk 各来一次 UploadPart，
+// 哪怕客户端进程中途重启、或者换了台机器继续传，服务端都能靠 Received 位图告诉它还缺哪些——
+// 跟 RestoreJobModel 是同一个思路，只是方向反过来（那边是下行断点续传，这里是上行）
+type UploadSessionModel struct {
+	// UploadID 是主键，由 pkg/upload 生成 (crypto/rand 十六进制字符串)
+	UploadID string `gorm:"primaryKey;type:varchar(36)"`
+
+	// LinearHash/SizeBytes 是整份文件的声明身份，跟 CheckFile/Upload 路径用的是同一个
+	// LinearHash 命名空间，InitUpload 完成后可以直接喂给 SaveFileIndex
+	LinearHash string `gorm:"index;type:varchar(64)"`
+	SizeBytes  int64
+
+	// ChunkHashes/ChunkSizes 是 InitUpload 登记时客户端声明的、按偏移顺序排列的 Chunk
+	// 列表，两个 JSON 数组下标一一对应；一旦登记就不再变化——CompleteUpload 组装 FileNode
+	// 时需要的正是这个固定顺序
+	ChunkHashes datatypes.JSON
+	ChunkSizes  datatypes.JSON
+
+	// Received 是按 Chunk 下标的完成位图（1 = 该 Chunk 已经落盘，不管是 InitUpload 时
+	// 发现已存在，还是后续 UploadPart 传上来的），编码方式跟 exporter.fileCheckpoint.Bitmap
+	// 一致
+	Received datatypes.JSON
+
+	// Status: in_progress / completed / aborted
+	Status string `gorm:"index;type:varchar(16)"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// TableName 强制指定表名
+func (UploadSessionModel) TableName() string {
+	return "upload_sessions"
+}
+
+// FileIndex 是 CheckFile/Upload 的"秒传"去重索引：LinearHash（客户端本地算出的
+// 整份文件 SHA-256）-> MerkleRoot（服务端 CDC 切分、建树后得到的 FileNode 哈希）。
+// 同一个 LinearHash 在不同租户之间必须各自独立，否则租户 A 上传过的文件会让租户 B
+// 免费"秒传"到——哪怕 A、B 互不信任，内容相同这件事本身也不该跨租户泄露，所以主键是
+// (TenantID, LinearHash) 的组合，不是单独的 LinearHash
+type FileIndex struct {
+	TenantID   string `gorm:"primaryKey;type:varchar(64)"`
+	LinearHash string `gorm:"primaryKey;type:char(64)"`
+
+	// MerkleRoot 是 FileNode 的 CID，跟 CommitModel.Hash 一样可能带算法标签，所以也是
+	// varchar(80) 而不是 char(64)
+	MerkleRoot string `gorm:"type:varchar(80);not null"`
+	SizeBytes  int64
+
+	CreatedAt time.Time
+}
+
+// TableName 强制指定表名
+func (FileIndex) TableName() string {
+	return "file_indexes"
+}
+
+// RepoConfigModel 持久化"整仓库定死一次、不会每次启动都重新决定"的配置项——目前只有
+// HashingAlgoConfigKey 一条。这跟 chunker.algo 那种配置不一样：chunker 算法变了只影响
+// 新内容的切分边界，旧 Chunk 照样能按老边界找到；但哈希算法决定了对象怎么寻址，运行时
+// 中途切换会让已经写盘的旧对象在新算法下变得找不到。NewApp 每次打开仓库都要跟这张表
+// 核对一次，核对逻辑见 pkg/app.lockHashingAlgo
+type RepoConfigModel struct {
+	Key   string `gorm:"primaryKey;type:varchar(64)"`
+	Value string `gorm:"type:varchar(255);not null"`
+}
+
+// TableName 强制指定表名
+func (RepoConfigModel) TableName() string {
+	return "repo_config"
+}
+
+// HashingAlgoConfigKey 是 RepoConfigModel 里存哈希算法锁定值的 key
+const HashingAlgoConfigKey = "hashing.algo"
@@ -17,9 +17,15 @@ import (
 )
 
 var (
-	ErrRefNotFound      = errors.New("reference not found")
-	ErrConcurrentUpdate = errors.New("concurrent update detected (CAS failed)")
-	ErrCommitNotFound   = errors.New("commit not found in metadata")
+	ErrRefNotFound        = errors.New("reference not found")
+	ErrConcurrentUpdate   = errors.New("concurrent update detected (CAS failed)")
+	ErrCommitNotFound     = errors.New("commit not found in metadata")
+	ErrBranchExists       = errors.New("branch already exists")
+	ErrBranchNotFound     = errors.New("branch not found")
+	ErrTaskNotFound       = errors.New("task not found")
+	ErrNoTaskAvailable    = errors.New("no pending task available")
+	ErrRestoreJobNotFound = errors.New("restore job not found")
+	ErrUploadNotFound     = errors.New("upload session not found")
 )
 
 // Repository 封装所有对 SQL 数据库的操作
@@ -52,51 +58,72 @@ func (r *Repository) GetRef(ctx context.Context, name string) (*Ref, error) {
 	return &ref, nil
 }
 
+// ListRefs 列出所有引用（包括 HEAD 和 refs/heads/*），按名称排序。
+// 供 `tv log --all` 枚举遍历起点用——跟 ListBranches 不一样：ListBranches 只看分支的
+// upstream 追踪配置，不关心它当前指向哪个 Commit
+func (r *Repository) ListRefs(ctx context.Context) ([]Ref, error) {
+	var refs []Ref
+	err := r.db.GetConn().WithContext(ctx).
+		Order("name ASC").
+		Find(&refs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+	return refs, nil
+}
+
 // UpdateRef 原子更新引用 (CAS - Compare And Swap)
 // oldVersion: 你之前读到的版本号。如果数据库里现在的版本号不等于这个，说明有人抢先改了，更新失败。
 func (r *Repository) UpdateRef(ctx context.Context, name string, newHash types.Hash, oldVersion int64) error {
 	// 开启事务 (虽然单条 SQL 不需要显式事务，但为了扩展性保留习惯)
 	return r.db.GetConn().WithContext(ctx).Transaction(func(tx *gorm.DB) error {
-		// 场景 A: 第一次创建 (Create)
-		if oldVersion == 0 {
-			ref := Ref{
-				Name:       name,
-				CommitHash: newHash,
-				Version:    1,
-			}
-			// 如果已经存在 (Name 冲突)，则报错
-			if err := tx.Create(&ref).Error; err != nil {
-				//兼容性,处理不同数据库(PG与SQLite)的唯一约束错误
-				if errors.Is(err, gorm.ErrDuplicatedKey) ||
-					strings.Contains(err.Error(), "UNIQUE constraint failed") {
-					return ErrConcurrentUpdate
-				}
-				return fmt.Errorf("failed to create ref: %w", err)
+		return updateRef(ctx, tx, name, newHash, oldVersion)
+	})
+}
+
+// updateRef 是 UpdateRef 的实现，接受一个 *gorm.DB 而不是固定用 r.db.GetConn()。
+// 和 indexCommit 一样，这样 Batch 里的 BatchTx 可以把它跑在外层已经开好的事务句柄上，
+// 不用再嵌套开一层事务（SQLite 不支持嵌套的 BEGIN）
+func updateRef(ctx context.Context, db *gorm.DB, name string, newHash types.Hash, oldVersion int64) error {
+	// 场景 A: 第一次创建 (Create)
+	if oldVersion == 0 {
+		ref := Ref{
+			Name:       name,
+			CommitHash: newHash,
+			Version:    1,
+		}
+		// 如果已经存在 (Name 冲突)，则报错
+		if err := db.WithContext(ctx).Create(&ref).Error; err != nil {
+			//兼容性,处理不同数据库(PG与SQLite)的唯一约束错误
+			if errors.Is(err, gorm.ErrDuplicatedKey) ||
+				strings.Contains(err.Error(), "UNIQUE constraint failed") {
+				return ErrConcurrentUpdate
 			}
-			return nil
+			return fmt.Errorf("failed to create ref: %w", err)
 		}
+		return nil
+	}
 
-		// 场景 B: 更新现有引用 (Update with CAS)
-		// SQL: UPDATE refs SET commit_hash = ?, version = version + 1 WHERE name = ? AND version = ?
-		result := tx.Model(&Ref{}).
-			Where("name = ? AND version = ?", name, oldVersion).
-			Updates(map[string]any{
-				"commit_hash": newHash,
-				"version":     gorm.Expr("version + 1"), // 版本号自增
-				"updated_at":  time.Now(),
-			})
+	// 场景 B: 更新现有引用 (Update with CAS)
+	// SQL: UPDATE refs SET commit_hash = ?, version = version + 1 WHERE name = ? AND version = ?
+	result := db.WithContext(ctx).Model(&Ref{}).
+		Where("name = ? AND version = ?", name, oldVersion).
+		Updates(map[string]any{
+			"commit_hash": newHash,
+			"version":     gorm.Expr("version + 1"), // 版本号自增
+			"updated_at":  time.Now(),
+		})
 
-		if result.Error != nil {
-			return result.Error
-		}
+	if result.Error != nil {
+		return result.Error
+	}
 
-		// 关键检查：如果影响行数为 0，说明 version 不匹配（被人抢先改了）
-		if result.RowsAffected == 0 {
-			return ErrConcurrentUpdate
-		}
+	// 关键检查：如果影响行数为 0，说明 version 不匹配（被人抢先改了）
+	if result.RowsAffected == 0 {
+		return ErrConcurrentUpdate
+	}
 
-		return nil
-	})
+	return nil
 }
 
 // -----------------------------------------------------------------------------
@@ -106,6 +133,12 @@ func (r *Repository) UpdateRef(ctx context.Context, name string, newHash types.H
 // IndexCommit 将 core.Commit 对象“投影”到 SQL 数据库中
 // 这样我们就可以用 SQL 进行复杂查询 (按作者、时间、Meta 搜索)
 func (r *Repository) IndexCommit(ctx context.Context, c *core.Commit) error {
+	return indexCommit(ctx, r.db.GetConn(), c)
+}
+
+// indexCommit 是 IndexCommit 的实现，接受一个 *gorm.DB 而不是固定用 r.db.GetConn()，
+// 这样 Batch 里的 BatchTx 可以把同一段逻辑跑在一个事务句柄上，而不用复制一份
+func indexCommit(ctx context.Context, db *gorm.DB, c *core.Commit) error {
 	// 1. 转换 Parents (Link -> []string -> JSON)
 	var parentHashes []types.Hash
 	for _, p := range c.Parents {
@@ -127,10 +160,14 @@ func (r *Repository) IndexCommit(ctx context.Context, c *core.Commit) error {
 		CreatedAt: time.Unix(c.Timestamp, 0),
 		// Meta: 未来如果有 Extra 字段，在这里 map 进去
 	}
+	if c.Signature != nil {
+		model.SignerFingerprint = c.Signature.Fingerprint
+		model.Signature = c.Signature.Sig
+	}
 
 	// 3. 写入数据库 (幂等写入)
 	// 如果 Hash 已存在，则什么都不做 (Do Nothing)
-	err = r.db.GetConn().WithContext(ctx).
+	err = db.WithContext(ctx).
 		Clauses(clause.OnConflict{
 			Columns:   []clause.Column{{Name: "hash"}}, // 冲突列
 			DoNothing: true,                            // 忽略
@@ -169,3 +206,635 @@ func (r *Repository) FindCommitsByAuthor(ctx context.Context, author string, lim
 		Find(&commits).Error
 	return commits, err
 }
+
+// -----------------------------------------------------------------------------
+// 3. 分支管理 (Branches)
+// -----------------------------------------------------------------------------
+
+// CreateBranch 插入一条新的分支追踪记录
+// 分支指向哪个 Commit 不归这里管，调用方（refs.Manager）负责在 Ref 表里同步创建对应的 refs/heads/<name>
+func (r *Repository) CreateBranch(ctx context.Context, branch *BranchModel) error {
+	err := r.db.GetConn().WithContext(ctx).Create(branch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) ||
+			strings.Contains(err.Error(), "UNIQUE constraint failed") {
+			return ErrBranchExists
+		}
+		return fmt.Errorf("failed to create branch: %w", err)
+	}
+	return nil
+}
+
+// GetBranch 读取一条分支的追踪记录
+func (r *Repository) GetBranch(ctx context.Context, name string) (*BranchModel, error) {
+	var branch BranchModel
+	err := r.db.GetConn().WithContext(ctx).
+		Where("name = ?", name).
+		First(&branch).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBranchNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &branch, nil
+}
+
+// DeleteBranch 删除一条分支的追踪记录（不影响它在 Ref 表里留下的历史）
+func (r *Repository) DeleteBranch(ctx context.Context, name string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Where("name = ?", name).
+		Delete(&BranchModel{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete branch: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrBranchNotFound
+	}
+	return nil
+}
+
+// ListBranches 列出所有本地分支
+func (r *Repository) ListBranches(ctx context.Context) ([]BranchModel, error) {
+	var branches []BranchModel
+	err := r.db.GetConn().WithContext(ctx).
+		Order("name ASC").
+		Find(&branches).Error
+	return branches, err
+}
+
+// SetUpstream 更新一条分支的 upstream 追踪配置 (`git branch --set-upstream-to`)
+func (r *Repository) SetUpstream(ctx context.Context, name, remote, merge string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&BranchModel{}).
+		Where("name = ?", name).
+		Updates(map[string]any{
+			"remote":     remote,
+			"merge":      merge,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to set upstream: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrBranchNotFound
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 4. 异步任务队列 (Tasks)
+// -----------------------------------------------------------------------------
+
+// CreateTask 插入一条新任务，初始状态由调用方在 task.Status 里给出，这里原样写入
+func (r *Repository) CreateTask(ctx context.Context, task *TaskModel) error {
+	if err := r.db.GetConn().WithContext(ctx).Create(task).Error; err != nil {
+		return fmt.Errorf("failed to create task: %w", err)
+	}
+	return nil
+}
+
+// GetTask 按 ID 读取一条任务
+func (r *Repository) GetTask(ctx context.Context, id string) (*TaskModel, error) {
+	var task TaskModel
+	err := r.db.GetConn().WithContext(ctx).
+		Where("id = ?", id).
+		First(&task).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTaskNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// ListTasks 按创建时间倒序列出最近的任务，用于 `tv jobs ls`
+func (r *Repository) ListTasks(ctx context.Context, limit int) ([]TaskModel, error) {
+	var tasks []TaskModel
+	err := r.db.GetConn().WithContext(ctx).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&tasks).Error
+	return tasks, err
+}
+
+// ClaimNextTask 原子地认领一条 pending 任务并把它标记为 running
+// 和 UpdateRef 的 CAS 思路一样：先挑候选，再用 "WHERE id = ? AND status = 'pending'" 的
+// 条件更新去抢；抢不到（被别的 worker 抢先）就试下一个候选，而不是用数据库特定的行锁语法，
+// 这样同一套代码不用关心底层到底是不是 Postgres
+func (r *Repository) ClaimNextTask(ctx context.Context, workerID string) (*TaskModel, error) {
+	var candidates []TaskModel
+	err := r.db.GetConn().WithContext(ctx).
+		Where("status = ?", "pending").
+		Order("created_at ASC").
+		Limit(20).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan pending tasks: %w", err)
+	}
+
+	now := time.Now()
+	for _, candidate := range candidates {
+		result := r.db.GetConn().WithContext(ctx).
+			Model(&TaskModel{}).
+			Where("id = ? AND status = ?", candidate.ID, "pending").
+			Updates(map[string]any{
+				"status":       "running",
+				"message":      fmt.Sprintf("claimed by %s", workerID),
+				"heartbeat_at": now,
+				"updated_at":   now,
+			})
+		if result.Error != nil {
+			return nil, fmt.Errorf("failed to claim task %s: %w", candidate.ID, result.Error)
+		}
+		if result.RowsAffected == 1 {
+			candidate.Status = "running"
+			candidate.HeartbeatAt = now
+			return &candidate, nil
+		}
+		// RowsAffected == 0 意味着另一个 worker 抢先了，试下一个候选
+	}
+	return nil, ErrNoTaskAvailable
+}
+
+// UpdateTaskProgress 更新任务的进度百分比和最近一条人类可读描述，并刷新心跳
+func (r *Repository) UpdateTaskProgress(ctx context.Context, id string, progress int, message string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&TaskModel{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"progress":     progress,
+			"message":      message,
+			"heartbeat_at": time.Now(),
+			"updated_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update task progress: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// TouchTaskHeartbeat 只刷新心跳时间戳，不触碰 progress/message
+// worker 在执行期间周期性调用它，证明自己还活着，和真正上报进度（UpdateTaskProgress）分开，
+// 避免心跳把调用方刚上报的进度又覆盖回去
+func (r *Repository) TouchTaskHeartbeat(ctx context.Context, id string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&TaskModel{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"heartbeat_at": time.Now(),
+			"updated_at":   time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to refresh task heartbeat: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// AppendTaskLog 把一行日志追加到任务的 Log 数组里，供 `tv jobs logs` 查看
+func (r *Repository) AppendTaskLog(ctx context.Context, id string, line string) error {
+	task, err := r.GetTask(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	if len(task.Log) > 0 {
+		if err := json.Unmarshal(task.Log, &lines); err != nil {
+			return fmt.Errorf("corrupted task log: %w", err)
+		}
+	}
+	lines = append(lines, line)
+
+	encoded, err := json.Marshal(lines)
+	if err != nil {
+		return fmt.Errorf("failed to marshal task log: %w", err)
+	}
+
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&TaskModel{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"log":        datatypes.JSON(encoded),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to append task log: %w", result.Error)
+	}
+	return nil
+}
+
+// FinishTask 把任务标记为一个终态 (succeeded / failed / cancelled)
+func (r *Repository) FinishTask(ctx context.Context, id string, status string, errMsg string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&TaskModel{}).
+		Where("id = ?", id).
+		Updates(map[string]any{
+			"status":     status,
+			"error":      errMsg,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to finish task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// RequestTaskCancel 给任务打上"请求取消"标记；实际取消发生在 worker 下一次检查点
+func (r *Repository) RequestTaskCancel(ctx context.Context, id string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&TaskModel{}).
+		Where("id = ? AND status IN ?", id, []string{"pending", "running"}).
+		Updates(map[string]any{
+			"cancel_requested": true,
+			"updated_at":       time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to request task cancellation: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrTaskNotFound
+	}
+	return nil
+}
+
+// ReclaimStaleTasks 把心跳超过 staleAfter 还没更新的 running 任务打回 pending
+// 用在进程启动时：上次运行的 worker 没来得及优雅退出（崩溃/被 kill），留下一堆卡在 running 的任务
+func (r *Repository) ReclaimStaleTasks(ctx context.Context, staleAfter time.Duration) (int64, error) {
+	cutoff := time.Now().Add(-staleAfter)
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&TaskModel{}).
+		Where("status = ? AND heartbeat_at < ?", "running", cutoff).
+		Updates(map[string]any{
+			"status":     "pending",
+			"message":    "resumed after worker restart",
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to reclaim stale tasks: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// -----------------------------------------------------------------------------
+// 5. 可断点续传的还原任务 (Restore Jobs)
+// -----------------------------------------------------------------------------
+
+// CreateRestoreJob 插入一条新的还原任务记录，初始状态由调用方在 job.Status 里给出，这里原样写入
+func (r *Repository) CreateRestoreJob(ctx context.Context, job *RestoreJobModel) error {
+	if err := r.db.GetConn().WithContext(ctx).Create(job).Error; err != nil {
+		return fmt.Errorf("failed to create restore job: %w", err)
+	}
+	return nil
+}
+
+// GetRestoreJob 按 JobID 读取一条还原任务记录（包括它目前的 Chunk 完成位图）
+func (r *Repository) GetRestoreJob(ctx context.Context, jobID string) (*RestoreJobModel, error) {
+	var job RestoreJobModel
+	err := r.db.GetConn().WithContext(ctx).
+		Where("job_id = ?", jobID).
+		First(&job).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrRestoreJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// SaveRestoreJobCheckpoint 把最新的 Chunk 完成位图刷盘，供进程崩溃重启后 ResumeRestore 读取
+func (r *Repository) SaveRestoreJobCheckpoint(ctx context.Context, jobID string, files datatypes.JSON) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&RestoreJobModel{}).
+		Where("job_id = ?", jobID).
+		Updates(map[string]any{
+			"files":      files,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save restore job checkpoint: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRestoreJobNotFound
+	}
+	return nil
+}
+
+// FinishRestoreJob 把一条还原任务标记为 done（整棵 Tree 已经还原完毕）
+func (r *Repository) FinishRestoreJob(ctx context.Context, jobID string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&RestoreJobModel{}).
+		Where("job_id = ?", jobID).
+		Updates(map[string]any{
+			"status":     "done",
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to finish restore job: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrRestoreJobNotFound
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 6. 可断点续传的分片上传 (Upload Sessions)
+// -----------------------------------------------------------------------------
+
+// CreateUploadSession 插入一条新的上传会话记录，初始状态由调用方在 session.Status 里给出，这里原样写入
+func (r *Repository) CreateUploadSession(ctx context.Context, session *UploadSessionModel) error {
+	if err := r.db.GetConn().WithContext(ctx).Create(session).Error; err != nil {
+		return fmt.Errorf("failed to create upload session: %w", err)
+	}
+	return nil
+}
+
+// GetUploadSession 按 UploadID 读取一条上传会话记录（包括它目前的 Chunk 完成位图）
+func (r *Repository) GetUploadSession(ctx context.Context, uploadID string) (*UploadSessionModel, error) {
+	var session UploadSessionModel
+	err := r.db.GetConn().WithContext(ctx).
+		Where("upload_id = ?", uploadID).
+		First(&session).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrUploadNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// SaveUploadSessionProgress 把最新的 Chunk 完成位图刷盘，供进程崩溃重启后继续上传时读取
+func (r *Repository) SaveUploadSessionProgress(ctx context.Context, uploadID string, received datatypes.JSON) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&UploadSessionModel{}).
+		Where("upload_id = ?", uploadID).
+		Updates(map[string]any{
+			"received":   received,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save upload session progress: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUploadNotFound
+	}
+	return nil
+}
+
+// FinishUploadSession 把一条上传会话标记为 completed（FileNode 已经组装并写入 Store）
+func (r *Repository) FinishUploadSession(ctx context.Context, uploadID string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&UploadSessionModel{}).
+		Where("upload_id = ?", uploadID).
+		Updates(map[string]any{
+			"status":     "completed",
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to finish upload session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUploadNotFound
+	}
+	return nil
+}
+
+// AbortUploadSession 把一条上传会话标记为 aborted。已经写入 Store 的 Chunk 不会被回收：
+// 内容寻址 + 去重意味着别的文件完全可能已经在引用同一个 Chunk，贸然删除会波及它们
+func (r *Repository) AbortUploadSession(ctx context.Context, uploadID string) error {
+	result := r.db.GetConn().WithContext(ctx).
+		Model(&UploadSessionModel{}).
+		Where("upload_id = ?", uploadID).
+		Updates(map[string]any{
+			"status":     "aborted",
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to abort upload session: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return ErrUploadNotFound
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 7. 垃圾回收墓碑 (Tombstones)
+// -----------------------------------------------------------------------------
+
+// CreateTombstone 记录一个被 gc.Sweep 判定为不可达、即将被删除的对象
+// 幂等写入 (Do Nothing on conflict)：同一个对象哈希不会产生第二条记录
+func (r *Repository) CreateTombstone(ctx context.Context, objectHash types.Hash, reason string) error {
+	err := r.db.GetConn().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "object_hash"}},
+			DoNothing: true,
+		}).
+		Create(&TombstoneModel{
+			ObjectHash: string(objectHash),
+			DeletedAt:  time.Now(),
+			Reason:     reason,
+		}).Error
+	if err != nil {
+		return fmt.Errorf("failed to create tombstone for %s: %w", objectHash, err)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 7. 异步任务队列：安全检查辅助
+// -----------------------------------------------------------------------------
+
+// HasActiveTask 检查是否存在处于 pending/running 状态、且类型属于 taskTypes 的任务
+// 供 gc.Sweep 在开始之前做安全检查：一次正在进行中的 ingest 任务可能已经把 Chunk 写进了
+// Store，但对应的 FileNode/Tree/Commit 还没来得及落盘，这段窗口期新写的对象在 mark 阶段
+// 的活跃集合里还看不到，贸然 Sweep 会把它们当成垃圾删掉
+func (r *Repository) HasActiveTask(ctx context.Context, taskTypes []string) (bool, error) {
+	var count int64
+	err := r.db.GetConn().WithContext(ctx).
+		Model(&TaskModel{}).
+		Where("type IN ? AND status IN ?", taskTypes, []string{"pending", "running"}).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("failed to check active tasks: %w", err)
+	}
+	return count > 0, nil
+}
+
+// -----------------------------------------------------------------------------
+// 8. 文件索引 (秒传去重，按租户隔离)
+// -----------------------------------------------------------------------------
+
+// GetFileIndex 按 (tenantID, linearHash) 查询秒传索引。查不到时返回 (nil, nil)，
+// 不是错误——CheckFile 的语义是"没查到=需要上传"，不是系统故障
+func (r *Repository) GetFileIndex(ctx context.Context, tenantID string, linearHash types.LinearHash) (*FileIndex, error) {
+	var idx FileIndex
+	err := r.db.GetConn().WithContext(ctx).
+		Where("tenant_id = ? AND linear_hash = ?", tenantID, string(linearHash)).
+		First(&idx).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query file index: %w", err)
+	}
+	return &idx, nil
+}
+
+// SaveFileIndex 记录一次上传成功后的 LinearHash -> MerkleRoot 映射，供同一租户下次
+// CheckFile 秒传复用。同一个 (tenantID, linearHash) 再次写入时直接覆盖——可能是客户端
+// 对同一份内容重新走了一遍完整上传（比如怀疑上次的索引脏了），新结果应该取代旧的
+func (r *Repository) SaveFileIndex(ctx context.Context, tenantID string, linearHash types.LinearHash, merkleRoot types.Hash, size int64) error {
+	return saveFileIndex(ctx, r.db.GetConn(), tenantID, linearHash, merkleRoot, size)
+}
+
+// saveFileIndex 是 SaveFileIndex 的实现，接受一个 *gorm.DB，供 BatchTx 复用
+func saveFileIndex(ctx context.Context, db *gorm.DB, tenantID string, linearHash types.LinearHash, merkleRoot types.Hash, size int64) error {
+	idx := FileIndex{
+		TenantID:   tenantID,
+		LinearHash: string(linearHash),
+		MerkleRoot: string(merkleRoot),
+		SizeBytes:  size,
+		CreatedAt:  time.Now(),
+	}
+
+	err := db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}, {Name: "linear_hash"}},
+			DoUpdates: clause.AssignmentColumns([]string{"merkle_root", "size_bytes", "created_at"}),
+		}).
+		Create(&idx).Error
+	if err != nil {
+		return fmt.Errorf("failed to save file index: %w", err)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 9. 仓库级配置 (一次定死、不随每次启动重新决定的配置项)
+// -----------------------------------------------------------------------------
+
+// GetRepoConfig 读取一条仓库级配置。查不到时返回 ("", false, nil)，不是错误——
+// 调用方（目前只有 pkg/app.lockHashingAlgo）要靠这个区分"这个仓库是第一次打开，
+// 还没锁定过"和"数据库本身出了故障"
+func (r *Repository) GetRepoConfig(ctx context.Context, key string) (string, bool, error) {
+	var cfg RepoConfigModel
+	err := r.db.GetConn().WithContext(ctx).
+		Where("key = ?", key).
+		First(&cfg).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to query repo config %q: %w", key, err)
+	}
+	return cfg.Value, true, nil
+}
+
+// SetRepoConfig 写入一条仓库级配置，同一个 key 已存在时覆盖。只应该在 GetRepoConfig
+// 报告"还没锁定过"之后调用一次——锁定之后这张表就不该再变，靠的是调用方的纪律，这张表
+// 本身不阻止覆盖写入
+func (r *Repository) SetRepoConfig(ctx context.Context, key, value string) error {
+	cfg := RepoConfigModel{Key: key, Value: value}
+	err := r.db.GetConn().WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "key"}},
+			DoUpdates: clause.AssignmentColumns([]string{"value"}),
+		}).
+		Create(&cfg).Error
+	if err != nil {
+		return fmt.Errorf("failed to save repo config %q: %w", key, err)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 10. 操作包 (Batch)：借鉴 git-bug 把多个 operation 打包进一个原子提交的思路
+// -----------------------------------------------------------------------------
+
+// BatchTx 是 Batch 回调内部唯一可见的写入句柄。它只暴露需要被原子组合的那几个操作
+// (IndexCommit / SaveFileIndex / UpdateRef)，而不是整个 *Repository：如果回调能拿到
+// *Repository，调用方很容易不小心调用一个跟这次事务无关的方法（比如 ListBranches），
+// 看起来能跑，实际上读到的是事务之外的连接、看不到本次 Batch 里还没提交的写入
+type BatchTx struct {
+	db  *gorm.DB
+	ctx context.Context
+}
+
+// IndexCommit 见 Repository.IndexCommit，区别是运行在 Batch 开的事务里
+func (tx *BatchTx) IndexCommit(c *core.Commit) error {
+	return indexCommit(tx.ctx, tx.db, c)
+}
+
+// SaveFileIndex 见 Repository.SaveFileIndex，区别是运行在 Batch 开的事务里
+func (tx *BatchTx) SaveFileIndex(tenantID string, linearHash types.LinearHash, merkleRoot types.Hash, size int64) error {
+	return saveFileIndex(tx.ctx, tx.db, tenantID, linearHash, merkleRoot, size)
+}
+
+// UpdateRef 见 Repository.UpdateRef，区别是运行在 Batch 开的事务里：这批操作里的每一次
+// CAS 检查看到的都是同一个事务快照，不会受外部并发写入干扰
+func (tx *BatchTx) UpdateRef(name string, newHash types.Hash, oldVersion int64) error {
+	return updateRef(tx.ctx, tx.db, name, newHash, oldVersion)
+}
+
+// Batch 把一组写操作 (IndexCommit/SaveFileIndex/UpdateRef 的任意组合) 包进同一个 GORM
+// 事务原子提交：fn 内部任何一步返回 error，整批全部回滚；fn 返回 nil 才真正 COMMIT。
+//
+// 用 Connection() 而不是 gorm 自带的 Transaction()，是因为我们需要在事务开始的那条
+// SQL 语句上做文章：SQLite 默认的 BEGIN 是 DEFERRED —— 第一条语句如果是 SELECT 只拿
+// 读锁，真正写的时候才尝试把锁升级为写锁，这时如果另一个事务也持有读锁并同时想升级，
+// 两边都会在升级阶段被 SQLITE_BUSY 打回，而不是干净地在 CAS 比较那一步被拒绝。
+// BEGIN IMMEDIATE 在事务一开始就拿写锁，把这类 write-skew 收敛回 ErrConcurrentUpdate
+// 这个单一、可预期的失败路径，而不是一个随机出现的"数据库忙"错误。
+// Postgres 没有这个问题（它的 MVCC 读不加锁），所以那边照常用普通 BEGIN。
+func (r *Repository) Batch(ctx context.Context, fn func(tx *BatchTx) error) (err error) {
+	conn := r.db.GetConn()
+	beginSQL := "BEGIN"
+	if conn.Dialector.Name() == "sqlite" {
+		beginSQL = "BEGIN IMMEDIATE"
+	}
+
+	return conn.Connection(func(pinned *gorm.DB) (txErr error) {
+		pinned = pinned.WithContext(ctx)
+
+		if txErr = pinned.Exec(beginSQL).Error; txErr != nil {
+			return fmt.Errorf("failed to begin batch transaction: %w", txErr)
+		}
+		defer func() {
+			if p := recover(); p != nil {
+				pinned.Exec("ROLLBACK")
+				panic(p)
+			}
+			if txErr != nil {
+				pinned.Exec("ROLLBACK")
+			}
+		}()
+
+		if txErr = fn(&BatchTx{db: pinned, ctx: ctx}); txErr != nil {
+			return txErr
+		}
+		if txErr = pinned.Exec("COMMIT").Error; txErr != nil {
+			return fmt.Errorf("failed to commit batch transaction: %w", txErr)
+		}
+		return nil
+	})
+}
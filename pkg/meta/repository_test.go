@@ -2,13 +2,16 @@ package meta
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 
 	"tensorvault/pkg/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gorm.io/datatypes"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
@@ -23,7 +26,7 @@ func setupTestRepo(t *testing.T) *Repository {
 	require.NoError(t, err)
 
 	metaDB := NewWithConn(db)
-	require.NoError(t, metaDB.AutoMigrate(&Ref{}, &CommitModel{}, &FileIndex{}))
+	require.NoError(t, metaDB.AutoMigrate(&Ref{}, &CommitModel{}, &FileIndex{}, &RestoreJobModel{}, &TaskModel{}, &TombstoneModel{}, &UploadSessionModel{}, &RepoConfigModel{}))
 
 	return NewRepository(metaDB)
 }
@@ -185,3 +188,242 @@ func TestRepository_FileIndex_Flow(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, merkleRoot, got.MerkleRoot, "Existing index should be immutable")
 }
+
+func TestRepository_RestoreJob_Lifecycle(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	// 1. 创建
+	job := &RestoreJobModel{
+		JobID:     "job1",
+		TreeHash:  string(mockHash("tree_data")),
+		TargetDir: "/tmp/restore",
+		Status:    "running",
+	}
+	require.NoError(t, repo.CreateRestoreJob(ctx, job))
+
+	// 2. 读取一条不存在的任务
+	_, err := repo.GetRestoreJob(ctx, "does-not-exist")
+	assert.ErrorIs(t, err, ErrRestoreJobNotFound)
+
+	// 3. 保存断点位图
+	checkpoint := datatypes.JSON(`{"a.bin":{"hash":"deadbeef","num_chunks":2,"bitmap":"AQ=="}}`)
+	require.NoError(t, repo.SaveRestoreJobCheckpoint(ctx, job.JobID, checkpoint))
+
+	got, err := repo.GetRestoreJob(ctx, job.JobID)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(checkpoint), string(got.Files))
+	assert.Equal(t, "running", got.Status)
+
+	// 4. 保存一个不存在的任务的断点应该报错，而不是静默地什么都不做
+	err = repo.SaveRestoreJobCheckpoint(ctx, "does-not-exist", checkpoint)
+	assert.ErrorIs(t, err, ErrRestoreJobNotFound)
+
+	// 5. 标记完成
+	require.NoError(t, repo.FinishRestoreJob(ctx, job.JobID))
+	got, err = repo.GetRestoreJob(ctx, job.JobID)
+	require.NoError(t, err)
+	assert.Equal(t, "done", got.Status)
+}
+
+func TestRepository_UploadSession_Lifecycle(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	// 1. 创建
+	session := &UploadSessionModel{
+		UploadID:    "upload1",
+		LinearHash:  string(mockHash("file_content")),
+		SizeBytes:   2048,
+		ChunkHashes: datatypes.JSON(`["deadbeef","c0ffee"]`),
+		ChunkSizes:  datatypes.JSON(`[1024,1024]`),
+		Received:    datatypes.JSON(`"AA=="`),
+		Status:      "in_progress",
+	}
+	require.NoError(t, repo.CreateUploadSession(ctx, session))
+
+	// 2. 读取一条不存在的会话
+	_, err := repo.GetUploadSession(ctx, "does-not-exist")
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+
+	// 3. 保存完成位图
+	received := datatypes.JSON(`"AQ=="`)
+	require.NoError(t, repo.SaveUploadSessionProgress(ctx, session.UploadID, received))
+
+	got, err := repo.GetUploadSession(ctx, session.UploadID)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(received), string(got.Received))
+	assert.Equal(t, "in_progress", got.Status)
+
+	// 4. 保存一个不存在的会话的位图应该报错，而不是静默地什么都不做
+	err = repo.SaveUploadSessionProgress(ctx, "does-not-exist", received)
+	assert.ErrorIs(t, err, ErrUploadNotFound)
+
+	// 5. 标记完成
+	require.NoError(t, repo.FinishUploadSession(ctx, session.UploadID))
+	got, err = repo.GetUploadSession(ctx, session.UploadID)
+	require.NoError(t, err)
+	assert.Equal(t, "completed", got.Status)
+}
+
+func TestRepository_UploadSession_Abort(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	session := &UploadSessionModel{
+		UploadID:    "upload2",
+		LinearHash:  string(mockHash("other_content")),
+		SizeBytes:   1024,
+		ChunkHashes: datatypes.JSON(`["deadbeef"]`),
+		ChunkSizes:  datatypes.JSON(`[1024]`),
+		Received:    datatypes.JSON(`"AA=="`),
+		Status:      "in_progress",
+	}
+	require.NoError(t, repo.CreateUploadSession(ctx, session))
+
+	require.NoError(t, repo.AbortUploadSession(ctx, session.UploadID))
+	got, err := repo.GetUploadSession(ctx, session.UploadID)
+	require.NoError(t, err)
+	assert.Equal(t, "aborted", got.Status)
+}
+
+func TestRepository_CreateTombstone_Idempotent(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	hash := mockHash("unreachable_chunk")
+	require.NoError(t, repo.CreateTombstone(ctx, hash, "gc_sweep"))
+
+	// 重复标记同一个对象不应该报错 (Do Nothing on conflict)
+	require.NoError(t, repo.CreateTombstone(ctx, hash, "gc_sweep"))
+
+	var count int64
+	require.NoError(t, repo.db.GetConn().WithContext(ctx).Model(&TombstoneModel{}).Where("object_hash = ?", hash).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
+
+func TestRepository_HasActiveTask(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	has, err := repo.HasActiveTask(ctx, []string{"upload", "tree_build"})
+	require.NoError(t, err)
+	assert.False(t, has, "没有任务的时候不应该报告有活跃任务")
+
+	require.NoError(t, repo.CreateTask(ctx, &TaskModel{ID: "t1", Type: "upload", Status: "pending"}))
+
+	has, err = repo.HasActiveTask(ctx, []string{"upload", "tree_build"})
+	require.NoError(t, err)
+	assert.True(t, has)
+
+	// 不在查询类型列表里的任务不应该影响结果
+	has, err = repo.HasActiveTask(ctx, []string{"checkout"})
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	require.NoError(t, repo.FinishTask(ctx, "t1", "succeeded", ""))
+	has, err = repo.HasActiveTask(ctx, []string{"upload", "tree_build"})
+	require.NoError(t, err)
+	assert.False(t, has, "终态任务不应该再被算作活跃")
+}
+
+func TestRepository_Batch_RollsBackOnInnerFailure(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+	refName := "refs/heads/main"
+
+	require.NoError(t, repo.Batch(ctx, func(tx *BatchTx) error {
+		return tx.UpdateRef(refName, mockHash("v1"), 0)
+	}))
+
+	ref, err := repo.GetRef(ctx, refName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), ref.Version)
+
+	// IndexCommit 在批次里先成功执行，随后 UpdateRef 因为版本号过期而失败：
+	// 整批必须原子回滚，已经"执行过"的 IndexCommit 也不能留下痕迹
+	commitObj := mustNewCommit(t, mockHash("tree_rollback"), nil, "Carol", "should not survive")
+	err = repo.Batch(ctx, func(tx *BatchTx) error {
+		if err := tx.IndexCommit(commitObj); err != nil {
+			return err
+		}
+		return tx.UpdateRef(refName, mockHash("v2"), 999) // 错误的版本号
+	})
+	assert.ErrorIs(t, err, ErrConcurrentUpdate)
+
+	_, err = repo.GetCommit(ctx, commitObj.ID())
+	assert.ErrorIs(t, err, ErrCommitNotFound, "失败的 Batch 必须整体回滚，包括其中已经执行过的 IndexCommit")
+
+	ref, err = repo.GetRef(ctx, refName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), ref.Version, "回滚后 ref 不应该被改动")
+}
+
+func TestRepository_Batch_ConcurrentCASExactlyOneWins(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+	refName := "refs/heads/main"
+
+	require.NoError(t, repo.Batch(ctx, func(tx *BatchTx) error {
+		return tx.UpdateRef(refName, mockHash("v1"), 0)
+	}))
+
+	const racers = 2
+	var wg sync.WaitGroup
+	results := make([]error, racers)
+	wg.Add(racers)
+	for i := 0; i < racers; i++ {
+		i := i
+		go func() {
+			defer wg.Done()
+			results[i] = repo.Batch(ctx, func(tx *BatchTx) error {
+				return tx.UpdateRef(refName, mockHash(fmt.Sprintf("racer-%d", i)), 1)
+			})
+		}()
+	}
+	wg.Wait()
+
+	var successCount, conflictCount int
+	for _, err := range results {
+		switch {
+		case err == nil:
+			successCount++
+		case errors.Is(err, ErrConcurrentUpdate):
+			conflictCount++
+		default:
+			t.Fatalf("unexpected batch error: %v", err)
+		}
+	}
+	assert.Equal(t, 1, successCount, "恰好一个并发 Batch 应该赢得 CAS")
+	assert.Equal(t, 1, conflictCount, "另一个应该干净地拿到 ErrConcurrentUpdate，而不是被 SQLITE_BUSY 之类的瞬时错误打回")
+
+	ref, err := repo.GetRef(ctx, refName)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), ref.Version, "只有赢家那次更新应该生效")
+}
+
+func TestRepository_RepoConfig_LockAndOverwrite(t *testing.T) {
+	repo := setupTestRepo(t)
+	ctx := context.Background()
+
+	// 1. 还没锁定过
+	val, found, err := repo.GetRepoConfig(ctx, HashingAlgoConfigKey)
+	require.NoError(t, err)
+	assert.False(t, found)
+	assert.Empty(t, val)
+
+	// 2. 第一次打开仓库时锁定
+	require.NoError(t, repo.SetRepoConfig(ctx, HashingAlgoConfigKey, "blake3"))
+
+	val, found, err = repo.GetRepoConfig(ctx, HashingAlgoConfigKey)
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "blake3", val)
+
+	// 3. 覆盖写入（例如运维手动纠正误配置后重试）
+	require.NoError(t, repo.SetRepoConfig(ctx, HashingAlgoConfigKey, "sha256"))
+
+	val, _, err = repo.GetRepoConfig(ctx, HashingAlgoConfigKey)
+	require.NoError(t, err)
+	assert.Equal(t, "sha256", val)
+}
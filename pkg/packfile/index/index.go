@@ -0,0 +1,154 @@
+// Package index 实现 pack 的 .idx 边车文件：把一个 pkg/packfile pack 里每个对象的 hash
+// 映射到它在 pack 里的字节偏移量，这样服务端做完整性校验或按需取某个对象时，不需要先把
+// 整个 pack 解压一遍才能定位——直接查 .idx 拿到 offset 后 seek 过去读就行。
+//
+// 布局沿用 pkg/storage/pack 里本地 GC pack 索引已经验证过的思路（256 桶 fanout 表 + 按
+// hash 排序的定长条目数组，go-git idxfile 的简化版），只是换了个不冲突的魔数，因为这是
+// 一份独立的格式，服务于独立的 (网络 pack 而非本地 GC pack) 用途。
+package index
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	"tensorvault/pkg/types"
+)
+
+const (
+	magic         = "TVPXI"
+	formatVersion = 1
+	hashHexLen    = 64 // SHA-256 十六进制长度，跟 types.Hash 的约定一致
+	fanoutSize    = 256
+	entrySize     = hashHexLen + 8 // hash 的十六进制文本 + 8 字节大端 offset
+)
+
+// Entry 是一条 hash -> pack 内偏移量的映射
+type Entry struct {
+	Hash   types.Hash
+	Offset int64
+}
+
+// fanoutBucket 把 hash 的前两个十六进制字符解析成一个 0-255 的桶号，跟 disk.Adapter.layout
+// 用同样两位做分片目录是同一个思路：先把搜索范围缩小到 1/256，再在桶内二分查找
+func fanoutBucket(hash types.Hash) int {
+	s := hash.String()
+	if len(s) < 2 {
+		return 0
+	}
+	b, err := hex.DecodeString(s[:2])
+	if err != nil || len(b) == 0 {
+		return 0
+	}
+	return int(b[0])
+}
+
+// Write 把 entries 按 hash 字典序排序后写成一个 .idx 文件：魔数 + 版本 + fanout 表 + 排序
+// 好的定长条目数组
+func Write(w io.Writer, entries []Entry) error {
+	sorted := make([]Entry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Hash < sorted[j].Hash })
+
+	var fanout [fanoutSize]uint32
+	for _, e := range sorted {
+		bucket := fanoutBucket(e.Hash)
+		for i := bucket; i < fanoutSize; i++ {
+			fanout[i]++
+		}
+	}
+
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(magic); err != nil {
+		return err
+	}
+	if err := bw.WriteByte(formatVersion); err != nil {
+		return err
+	}
+	for _, count := range fanout {
+		if err := binary.Write(bw, binary.BigEndian, count); err != nil {
+			return err
+		}
+	}
+	for _, e := range sorted {
+		if len(e.Hash) != hashHexLen {
+			return fmt.Errorf("index: refusing to index malformed hash %q", e.Hash)
+		}
+		if _, err := bw.WriteString(e.Hash.String()); err != nil {
+			return err
+		}
+		if err := binary.Write(bw, binary.BigEndian, e.Offset); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// Index 是加载进内存的 .idx，支持按 hash 查 offset
+type Index struct {
+	fanout  [fanoutSize]uint32
+	entries []Entry
+}
+
+// Read 把一个 .idx 文件整份读进内存；跟 pkg/storage/pack 的 readIndex 一样的权衡——哪怕
+// pack 里有几十万个对象，.idx 也就几十 MB，直接读进内存省掉了引入 mmap 的复杂度
+func Read(r io.Reader) (*Index, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("index: failed to read: %w", err)
+	}
+
+	if len(data) < len(magic)+1 || string(data[:len(magic)]) != magic {
+		return nil, fmt.Errorf("index: not a valid index file")
+	}
+	pos := len(magic)
+	version := data[pos]
+	pos++
+	if version != formatVersion {
+		return nil, fmt.Errorf("index: unsupported version %d", version)
+	}
+
+	idx := &Index{}
+	for i := 0; i < fanoutSize; i++ {
+		idx.fanout[i] = binary.BigEndian.Uint32(data[pos : pos+4])
+		pos += 4
+	}
+
+	total := idx.fanout[fanoutSize-1]
+	idx.entries = make([]Entry, 0, total)
+	for pos+entrySize <= len(data) {
+		hash := types.Hash(data[pos : pos+hashHexLen])
+		offset := binary.BigEndian.Uint64(data[pos+hashHexLen : pos+entrySize])
+		idx.entries = append(idx.entries, Entry{Hash: hash, Offset: int64(offset)})
+		pos += entrySize
+	}
+
+	// entries 在写入时已经按 hash 排过序，这里只是防止 .idx 被改坏后静默产生错误结果
+	if !sort.SliceIsSorted(idx.entries, func(i, j int) bool { return idx.entries[i].Hash < idx.entries[j].Hash }) {
+		return nil, fmt.Errorf("index: entries are not sorted by hash")
+	}
+
+	return idx, nil
+}
+
+// Lookup 用 fanout 表把搜索范围缩小到对应的桶，再在桶内二分查找 hash 对应的偏移量
+func (idx *Index) Lookup(hash types.Hash) (int64, bool) {
+	bucket := fanoutBucket(hash)
+	lo := 0
+	if bucket > 0 {
+		lo = int(idx.fanout[bucket-1])
+	}
+	hi := int(idx.fanout[bucket])
+
+	i := sort.Search(hi-lo, func(i int) bool { return idx.entries[lo+i].Hash >= hash }) + lo
+	if i < hi && idx.entries[i].Hash == hash {
+		return idx.entries[i].Offset, true
+	}
+	return 0, false
+}
+
+// Len 返回索引里的条目数
+func (idx *Index) Len() int { return len(idx.entries) }
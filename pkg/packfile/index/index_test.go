@@ -0,0 +1,45 @@
+package index
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tensorvault/pkg/types"
+)
+
+func TestWriteRead_Lookup(t *testing.T) {
+	entries := []Entry{
+		{Hash: types.Hash("bb" + repeat("0", 62)), Offset: 120},
+		{Hash: types.Hash("aa" + repeat("0", 62)), Offset: 12},
+		{Hash: types.Hash("cc" + repeat("0", 62)), Offset: 500},
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, entries))
+
+	idx, err := Read(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.Equal(t, 3, idx.Len())
+
+	off, ok := idx.Lookup(types.Hash("aa" + repeat("0", 62)))
+	require.True(t, ok)
+	assert.EqualValues(t, 12, off)
+
+	off, ok = idx.Lookup(types.Hash("cc" + repeat("0", 62)))
+	require.True(t, ok)
+	assert.EqualValues(t, 500, off)
+
+	_, ok = idx.Lookup(types.Hash("dd" + repeat("0", 62)))
+	assert.False(t, ok)
+}
+
+func repeat(s string, n int) string {
+	out := make([]byte, 0, n*len(s))
+	for i := 0; i < n; i++ {
+		out = append(out, s...)
+	}
+	return string(out)
+}
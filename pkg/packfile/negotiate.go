@@ -0,0 +1,242 @@
+package packfile
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// ComputeMissing 实现 push 前的 Have/Want 协商：haves 是客户端认为服务端已经有的 commit
+// （通常是上一次成功 push 过的 commit），先从它们出发遍历整棵 DAG（commit -> tree ->
+// filenode -> chunk，包括每个 commit 的祖先链）圈出一个"已知"集合；然后从 want（这次要推
+// 的新 commit）出发做同样的遍历，凡是落在"已知"集合之外的对象都收进返回值——这就是这次
+// push 真正需要塞进 pack 里的对象。思路上跟 go-git 的 pack negotiation 一致，只是这里
+// "已知"集合是本地直接按 haves 遍历算出来的，不需要来回交换对象列表
+func ComputeMissing(ctx context.Context, store storage.Store, want types.Hash, haves []types.Hash) ([]types.Hash, error) {
+	known := make(map[types.Hash]struct{})
+	for _, h := range haves {
+		if err := walkKnownCommit(ctx, store, h, known); err != nil {
+			return nil, fmt.Errorf("packfile: failed to walk have %s: %w", h, err)
+		}
+	}
+
+	seen := make(map[types.Hash]struct{})
+	var missing []types.Hash
+	if err := walkWantCommit(ctx, store, want, known, seen, &missing); err != nil {
+		return nil, fmt.Errorf("packfile: failed to walk want %s: %w", want, err)
+	}
+	return missing, nil
+}
+
+func readObject(ctx context.Context, store storage.Store, h types.Hash) ([]byte, error) {
+	rc, err := store.Get(ctx, h.String())
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+func loadCommit(ctx context.Context, store storage.Store, h types.Hash) (*core.Commit, error) {
+	data, err := readObject(ctx, store, h)
+	if err != nil {
+		return nil, err
+	}
+	var c core.Commit
+	if err := core.DecodeObject(data, &c); err != nil {
+		return nil, fmt.Errorf("corrupt commit %s: %w", h, err)
+	}
+	return &c, nil
+}
+
+func loadTree(ctx context.Context, store storage.Store, h types.Hash) (*core.Tree, error) {
+	data, err := readObject(ctx, store, h)
+	if err != nil {
+		return nil, err
+	}
+	var t core.Tree
+	if err := core.DecodeObject(data, &t); err != nil {
+		return nil, fmt.Errorf("corrupt tree %s: %w", h, err)
+	}
+	return &t, nil
+}
+
+func loadFileNode(ctx context.Context, store storage.Store, h types.Hash) (*core.FileNode, error) {
+	data, err := readObject(ctx, store, h)
+	if err != nil {
+		return nil, err
+	}
+	var fn core.FileNode
+	if err := core.DecodeObject(data, &fn); err != nil {
+		return nil, fmt.Errorf("corrupt filenode %s: %w", h, err)
+	}
+	return &fn, nil
+}
+
+// walkKnownCommit 把从 h 能到达的整条祖先链、以及每个 commit 下面的整棵对象树都标记为已知
+func walkKnownCommit(ctx context.Context, store storage.Store, h types.Hash, known map[types.Hash]struct{}) error {
+	if h == "" {
+		return nil
+	}
+	if _, ok := known[h]; ok {
+		return nil
+	}
+	known[h] = struct{}{}
+
+	c, err := loadCommit(ctx, store, h)
+	if err != nil {
+		return err
+	}
+	if err := walkKnownTree(ctx, store, types.Hash(c.TreeCid.Hash), known); err != nil {
+		return err
+	}
+	for _, p := range c.Parents {
+		if err := walkKnownCommit(ctx, store, types.Hash(p.Hash), known); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkKnownTree(ctx context.Context, store storage.Store, h types.Hash, known map[types.Hash]struct{}) error {
+	if _, ok := known[h]; ok {
+		return nil
+	}
+	known[h] = struct{}{}
+
+	t, err := loadTree(ctx, store, h)
+	if err != nil {
+		return err
+	}
+	for _, e := range t.Entries {
+		eh := types.Hash(e.Cid.Hash)
+		if e.Type == core.EntryDir {
+			if err := walkKnownTree(ctx, store, eh, known); err != nil {
+				return err
+			}
+		} else if err := walkKnownFileNode(ctx, store, eh, known); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkKnownFileNode(ctx context.Context, store storage.Store, h types.Hash, known map[types.Hash]struct{}) error {
+	if _, ok := known[h]; ok {
+		return nil
+	}
+	known[h] = struct{}{}
+
+	fn, err := loadFileNode(ctx, store, h)
+	if err != nil {
+		return err
+	}
+	for _, link := range fn.Chunks {
+		lh := types.Hash(link.Hash.Hash)
+		if fn.IsInternal {
+			// Pyramid 结构：IsInternal 的 FileNode 的 Chunks 指向的是别的 FileNode，
+			// 不是叶子 Chunk，需要继续往下递归
+			if err := walkKnownFileNode(ctx, store, lh, known); err != nil {
+				return err
+			}
+		} else {
+			known[lh] = struct{}{}
+		}
+	}
+	return nil
+}
+
+// walkWantCommit 跟 walkKnownCommit 遍历的是同一棵图，但遇到已经在 known 里的对象就剪枝，
+// 把剩下的（也就是服务端真正缺的）对象追加进 missing。seen 避免在同一次 want 遍历里对
+// 共享的子树重复处理（多个 commit 之间、多个文件之间经常有大量共享的 chunk/tree）
+func walkWantCommit(ctx context.Context, store storage.Store, h types.Hash, known, seen map[types.Hash]struct{}, missing *[]types.Hash) error {
+	if h == "" {
+		return nil
+	}
+	if _, ok := seen[h]; ok {
+		return nil
+	}
+	seen[h] = struct{}{}
+	if _, ok := known[h]; ok {
+		return nil
+	}
+	*missing = append(*missing, h)
+
+	c, err := loadCommit(ctx, store, h)
+	if err != nil {
+		return err
+	}
+	if err := walkWantTree(ctx, store, types.Hash(c.TreeCid.Hash), known, seen, missing); err != nil {
+		return err
+	}
+	for _, p := range c.Parents {
+		if err := walkWantCommit(ctx, store, types.Hash(p.Hash), known, seen, missing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkWantTree(ctx context.Context, store storage.Store, h types.Hash, known, seen map[types.Hash]struct{}, missing *[]types.Hash) error {
+	if _, ok := seen[h]; ok {
+		return nil
+	}
+	seen[h] = struct{}{}
+	if _, ok := known[h]; ok {
+		return nil
+	}
+	*missing = append(*missing, h)
+
+	t, err := loadTree(ctx, store, h)
+	if err != nil {
+		return err
+	}
+	for _, e := range t.Entries {
+		eh := types.Hash(e.Cid.Hash)
+		if e.Type == core.EntryDir {
+			if err := walkWantTree(ctx, store, eh, known, seen, missing); err != nil {
+				return err
+			}
+		} else if err := walkWantFileNode(ctx, store, eh, known, seen, missing); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkWantFileNode(ctx context.Context, store storage.Store, h types.Hash, known, seen map[types.Hash]struct{}, missing *[]types.Hash) error {
+	if _, ok := seen[h]; ok {
+		return nil
+	}
+	seen[h] = struct{}{}
+	if _, ok := known[h]; ok {
+		return nil
+	}
+	*missing = append(*missing, h)
+
+	fn, err := loadFileNode(ctx, store, h)
+	if err != nil {
+		return err
+	}
+	for _, link := range fn.Chunks {
+		lh := types.Hash(link.Hash.Hash)
+		if fn.IsInternal {
+			if err := walkWantFileNode(ctx, store, lh, known, seen, missing); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, ok := seen[lh]; ok {
+			continue
+		}
+		seen[lh] = struct{}{}
+		if _, ok := known[lh]; !ok {
+			*missing = append(*missing, lh)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package packfile
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/ingester"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/types"
+)
+
+// buildCommit ingest 一份随机数据作为单文件 Tree，包成一个 Commit（可选挂一个 parent）
+func buildCommit(t *testing.T, store *disk.Adapter, ctx context.Context, name string, size int, parent types.Hash) (*core.Commit, *core.FileNode) {
+	t.Helper()
+	data := make([]byte, size)
+	_, err := rand.Read(data)
+	require.NoError(t, err)
+
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
+	fileNode, err := ingester.NewIngester(store, hasher).IngestFile(ctx, bytes.NewReader(data))
+	require.NoError(t, err)
+
+	entry := core.NewFileEntry(name, fileNode.ID(), fileNode.TotalSize)
+	tree, err := core.NewTree([]core.TreeEntry{entry}, hasher)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, tree))
+
+	var parents []types.Hash
+	if parent != "" {
+		parents = []types.Hash{parent}
+	}
+	commit, err := core.NewCommit(tree.ID(), parents, "tester", "test commit", hasher)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, commit))
+
+	return commit, fileNode
+}
+
+func TestComputeMissing_FreshPushReturnsEverything(t *testing.T) {
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	commit, fileNode := buildCommit(t, store, ctx, "model.bin", 50*1024, "")
+
+	missing, err := ComputeMissing(ctx, store, commit.ID(), nil)
+	require.NoError(t, err)
+
+	// commit + tree + filenode + 至少一个 chunk
+	assert.GreaterOrEqual(t, len(missing), 3+len(fileNode.Chunks))
+	assert.Contains(t, missing, commit.ID())
+}
+
+func TestComputeMissing_SecondPushOnlyReturnsNewObjects(t *testing.T) {
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	commit1, _ := buildCommit(t, store, ctx, "model.bin", 50*1024, "")
+	commit2, fileNode2 := buildCommit(t, store, ctx, "model-v2.bin", 50*1024, commit1.ID())
+
+	missing, err := ComputeMissing(ctx, store, commit2.ID(), []types.Hash{commit1.ID()})
+	require.NoError(t, err)
+
+	assert.Contains(t, missing, commit2.ID())
+	assert.NotContains(t, missing, commit1.ID(), "已经 have 的 commit 及其整棵子树不应该再出现")
+	// commit2 + 它自己的 tree + filenode + chunks，但不应该包含 commit1 那一侧的对象
+	assert.GreaterOrEqual(t, len(missing), 3+len(fileNode2.Chunks))
+}
+
+func TestComputeMissing_UpToDateReturnsNothing(t *testing.T) {
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	commit, _ := buildCommit(t, store, ctx, "model.bin", 50*1024, "")
+
+	missing, err := ComputeMissing(ctx, store, commit.ID(), []types.Hash{commit.ID()})
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
@@ -0,0 +1,270 @@
+// Package packfile 实现了一种批量传输多个 core.Object 的紧凑格式 (TVPX)：一次 push 要发
+// 成百上千个 Chunk/FileNode/Tree/Commit 时，不用每个对象单独走一次 Upload RPC，而是把它们
+// 编码进一个流：12 字节头 (魔数 + 版本 + 对象数)，然后每个对象是 varint 类型标签 + varint
+// 原始大小 + varint 压缩后大小 + zlib 压缩数据，最后追加整个 pack (不含这个 trailer 自己) 的
+// SHA-256 摘要，供接收方做一次性完整性校验。压缩长度显式写在前面而不是依赖 zlib 自我定界，
+// 是为了让 Writer.WriteObject 能精确报出每个对象的起始偏移量——pkg/packfile/index 就是靠这
+// 个偏移量建 hash -> offset 索引的。
+//
+// 这跟 pkg/storage/pack 不是一回事：那个包是 GC (tv gc --pack) 把本地一堆零散对象折叠进
+// 单个文件、减少 inode 数量用的，对象之间离得很近、没必要再压缩一遍；这里针对的是网络
+// 传输，带宽比 CPU 贵得多，所以每个对象单独 zlib 压缩。两者的头部格式也刻意不同（"TVPK"
+// 已经被 pkg/storage/pack 占用，这里用 "TVPX" 以免两种完全不同的文件被误认成同一种格式）。
+//
+// 这替换的是 push.go 今天"每个文件一次 Upload RPC"的循环；要真正用在 push/fetch 上，
+// DataService 还需要一对新的 PushPack/FetchPack 双向流 RPC（客户端先发 HaveWant 协商帧，
+// 服务端用 ComputeMissing 算出缺的对象，客户端再把这些对象编码成一个 pack 发过去）。这个
+// RPC 定义需要改 pkg/api/tvrpc/v1 的 proto 并重新生成，而这个仓库里那份生成代码本身就不存
+// 在（跟这次改动无关的环境缺口，在更早的改动里已经确认过）。所以这里先把 pack 的编解码格式
+// 和 Have/Want 协商逻辑做扎实、做可测试；RPC 层的接线留到那份 proto 存在之后再做。
+package packfile
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+
+	"tensorvault/pkg/core"
+)
+
+const (
+	magic         = "TVPX"
+	formatVersion = 1
+
+	// HeaderSize 是 12 字节头的长度：4 字节魔数 + 4 字节版本 + 4 字节对象数
+	HeaderSize = 12
+)
+
+// objTag 是 pack 内部标记对象类型用的 varint 枚举，跟 core.ObjectType 的字符串值一一对应——
+// 用定长小整数而不是直接写字符串，省掉每个对象重复几个字节的开销
+type objTag uint64
+
+const (
+	tagChunk objTag = iota + 1
+	tagFileNode
+	tagTree
+	tagCommit
+)
+
+var tagToType = map[objTag]core.ObjectType{
+	tagChunk:    core.TypeChunk,
+	tagFileNode: core.TypeFileNode,
+	tagTree:     core.TypeTree,
+	tagCommit:   core.TypeCommit,
+}
+
+var typeToTag = map[core.ObjectType]objTag{
+	core.TypeChunk:    tagChunk,
+	core.TypeFileNode: tagFileNode,
+	core.TypeTree:     tagTree,
+	core.TypeCommit:   tagCommit,
+}
+
+// Writer 把一串 core.Object 编码成一个 TVPX pack。调用方必须提前知道对象总数（头部在文件
+// 开头就声明了），Close 时会校验 WriteObject 被调用的次数是否跟声明的一致
+type Writer struct {
+	w       io.Writer
+	count   uint32
+	written uint32
+	offset  int64
+	sum     hash.Hash
+}
+
+// NewWriter 写出 pack 头部并返回一个可以开始追加对象的 Writer
+func NewWriter(w io.Writer, count uint32) (*Writer, error) {
+	pw := &Writer{w: w, count: count, sum: sha256.New()}
+
+	header := make([]byte, HeaderSize)
+	copy(header[0:4], magic)
+	binary.BigEndian.PutUint32(header[4:8], formatVersion)
+	binary.BigEndian.PutUint32(header[8:12], count)
+	if err := pw.write(header); err != nil {
+		return nil, err
+	}
+	return pw, nil
+}
+
+// write 把数据同时写进底层 io.Writer 和运行中的 SHA-256——trailer 就是靠这个累积摘要算出来的
+func (pw *Writer) write(p []byte) error {
+	n, err := pw.w.Write(p)
+	pw.offset += int64(n)
+	if err != nil {
+		return err
+	}
+	pw.sum.Write(p)
+	return nil
+}
+
+// WriteObject 追加一个对象，返回它在 pack 里的起始字节偏移量（从 pack 开头算起、包含
+// 12 字节头部，指向这个对象的类型标签，所以第一个对象的偏移量是 HeaderSize），供调用方
+// 喂给 pkg/packfile/index 建索引
+func (pw *Writer) WriteObject(obj core.Object) (int64, error) {
+	if pw.written >= pw.count {
+		return 0, fmt.Errorf("packfile: writing more objects than declared count %d", pw.count)
+	}
+
+	tag, ok := typeToTag[obj.Type()]
+	if !ok {
+		return 0, fmt.Errorf("packfile: unsupported object type %q", obj.Type())
+	}
+
+	data := obj.Bytes()
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return 0, fmt.Errorf("packfile: failed to compress object %s: %w", obj.ID(), err)
+	}
+	if err := zw.Close(); err != nil {
+		return 0, fmt.Errorf("packfile: failed to flush compressed object %s: %w", obj.ID(), err)
+	}
+
+	startOffset := pw.offset
+
+	var lenHeader [3 * binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(lenHeader[0:], uint64(tag))
+	n += binary.PutUvarint(lenHeader[n:], uint64(len(data)))
+	n += binary.PutUvarint(lenHeader[n:], uint64(compressed.Len()))
+	if err := pw.write(lenHeader[:n]); err != nil {
+		return 0, err
+	}
+	if err := pw.write(compressed.Bytes()); err != nil {
+		return 0, err
+	}
+
+	pw.written++
+	return startOffset, nil
+}
+
+// Close 校验对象数量写对了，并追加整个 pack 的 SHA-256 trailer
+func (pw *Writer) Close() error {
+	if pw.written != pw.count {
+		return fmt.Errorf("packfile: declared %d objects but only wrote %d", pw.count, pw.written)
+	}
+	_, err := pw.w.Write(pw.sum.Sum(nil))
+	return err
+}
+
+// Entry 是 Reader.Next 返回的一个已解压对象
+type Entry struct {
+	Type   core.ObjectType
+	Data   []byte
+	Offset int64 // 在 pack 里的起始偏移量，跟 index.Entry.Offset 是同一个值
+}
+
+// countingReader 包一层 *bufio.Reader，顺带记录已经消费的字节数，用来给每个 Entry 算
+// 起始偏移量；同时实现 io.ByteReader，这样 binary.ReadUvarint 不会再触发额外的内部缓冲
+type countingReader struct {
+	r *bufio.Reader
+	n int64
+}
+
+func (c *countingReader) ReadByte() (byte, error) {
+	b, err := c.r.ReadByte()
+	if err == nil {
+		c.n++
+	}
+	return b, err
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// Reader 顺序解析一个 TVPX pack。它只管对象区，不管 trailer——trailer 的校验交给
+// VerifyTrailer，在整个 pack 已经落地成可以整体处理的字节切片之后再做一次性核对
+type Reader struct {
+	cr    *countingReader
+	count uint32
+	read  uint32
+}
+
+// NewReader 读取并校验 pack 头部，返回一个可以用 Next 遍历的 Reader
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("packfile: failed to read header: %w", err)
+	}
+	if string(header[0:4]) != magic {
+		return nil, fmt.Errorf("packfile: bad magic %q", header[0:4])
+	}
+	if v := binary.BigEndian.Uint32(header[4:8]); v != formatVersion {
+		return nil, fmt.Errorf("packfile: unsupported version %d", v)
+	}
+	count := binary.BigEndian.Uint32(header[8:12])
+
+	return &Reader{cr: &countingReader{r: br, n: HeaderSize}, count: count}, nil
+}
+
+// Count 返回 pack 头部声明的对象总数
+func (pr *Reader) Count() uint32 { return pr.count }
+
+// Next 返回下一个对象；所有对象读完之后返回 io.EOF（此时底层 reader 正好停在 trailer 前面）
+func (pr *Reader) Next() (*Entry, error) {
+	if pr.read >= pr.count {
+		return nil, io.EOF
+	}
+
+	startOffset := pr.cr.n
+
+	tagVal, err := binary.ReadUvarint(pr.cr)
+	if err != nil {
+		return nil, fmt.Errorf("packfile: failed to read type tag: %w", err)
+	}
+	objType, ok := tagToType[objTag(tagVal)]
+	if !ok {
+		return nil, fmt.Errorf("packfile: unknown type tag %d", tagVal)
+	}
+
+	usize, err := binary.ReadUvarint(pr.cr)
+	if err != nil {
+		return nil, fmt.Errorf("packfile: failed to read uncompressed size: %w", err)
+	}
+
+	csize, err := binary.ReadUvarint(pr.cr)
+	if err != nil {
+		return nil, fmt.Errorf("packfile: failed to read compressed size: %w", err)
+	}
+
+	compressed := make([]byte, csize)
+	if _, err := io.ReadFull(pr.cr, compressed); err != nil {
+		return nil, fmt.Errorf("packfile: failed to read compressed object: %w", err)
+	}
+
+	zr, err := zlib.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("packfile: corrupt zlib stream: %w", err)
+	}
+	data := make([]byte, usize)
+	if _, err := io.ReadFull(zr, data); err != nil {
+		return nil, fmt.Errorf("packfile: failed to decompress object: %w", err)
+	}
+	if err := zr.Close(); err != nil {
+		return nil, fmt.Errorf("packfile: corrupt zlib stream: %w", err)
+	}
+
+	pr.read++
+	return &Entry{Type: objType, Data: data, Offset: startOffset}, nil
+}
+
+// VerifyTrailer 校验一个完整 pack（已经整个缓冲成内存切片）末尾的 SHA-256 trailer跟前面
+// 所有字节是否一致
+func VerifyTrailer(pack []byte) error {
+	if len(pack) < sha256.Size {
+		return fmt.Errorf("packfile: pack too short to contain a trailer")
+	}
+	body, trailer := pack[:len(pack)-sha256.Size], pack[len(pack)-sha256.Size:]
+	sum := sha256.Sum256(body)
+	if !bytes.Equal(sum[:], trailer) {
+		return fmt.Errorf("packfile: trailer mismatch, pack is corrupt or truncated")
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package packfile
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/types"
+)
+
+func TestWriterReader_RoundTrip(t *testing.T) {
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	c1 := core.NewChunk([]byte("hello world"), hasher)
+	c2 := core.NewChunk([]byte("a second, different chunk"), hasher)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, 2)
+	require.NoError(t, err)
+	_, err = w.WriteObject(c1)
+	require.NoError(t, err)
+	_, err = w.WriteObject(c2)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.NoError(t, VerifyTrailer(buf.Bytes()))
+
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, r.Count())
+
+	e1, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, core.TypeChunk, e1.Type)
+	assert.Equal(t, c1.Bytes(), e1.Data)
+
+	e2, err := r.Next()
+	require.NoError(t, err)
+	assert.Equal(t, c2.Bytes(), e2.Data)
+	assert.Greater(t, e2.Offset, e1.Offset)
+
+	_, err = r.Next()
+	assert.ErrorIs(t, err, io.EOF)
+}
+
+func TestWriter_RejectsCountMismatch(t *testing.T) {
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	c1 := core.NewChunk([]byte("only one"), hasher)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, 2)
+	require.NoError(t, err)
+	_, err = w.WriteObject(c1)
+	require.NoError(t, err)
+
+	err = w.Close()
+	assert.Error(t, err, "declared 2 objects but only wrote 1 should fail on Close")
+}
+
+func TestVerifyTrailer_DetectsCorruption(t *testing.T) {
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	c1 := core.NewChunk([]byte("tamper with me"), hasher)
+
+	var buf bytes.Buffer
+	w, err := NewWriter(&buf, 1)
+	require.NoError(t, err)
+	_, err = w.WriteObject(c1)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	corrupted := buf.Bytes()
+	corrupted[len(corrupted)-1] ^= 0xFF
+	assert.Error(t, VerifyTrailer(corrupted))
+}
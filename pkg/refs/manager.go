@@ -4,32 +4,168 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"strings"
 
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/identity"
 	"tensorvault/pkg/meta"
+	"tensorvault/pkg/storage"
 	"tensorvault/pkg/types"
 )
 
 var (
-	// ErrNoHead 当仓库是新的（还没有 HEAD 记录）时返回
+	// ErrNoHead 当仓库是新的（还没有 HEAD 记录），或者 HEAD 挂在一个还没有任何提交的分支上时返回
 	ErrNoHead = errors.New("HEAD not found (clean repo)")
 
-	// ErrStaleHead 当尝试更新 HEAD 但版本号不匹配时返回（并发冲突）
+	// ErrStaleHead 当尝试更新 HEAD/分支但版本号不匹配时返回（并发冲突）
 	ErrStaleHead = errors.New("HEAD has changed since you last read it")
+
+	// ErrBranchExists 创建分支时，同名分支已存在
+	ErrBranchExists = errors.New("branch already exists")
+
+	// ErrBranchNotFound 操作一个不存在的分支
+	ErrBranchNotFound = errors.New("branch not found")
+
+	// ErrUnsignedCommit 当 RequireSignedBy 配置了签名者白名单、但推进 HEAD/分支的 commit
+	// 根本没有签名时返回
+	ErrUnsignedCommit = errors.New("refs: commit is not signed")
+
+	// ErrUntrustedSigner 当推进 HEAD/分支的 commit 签了名、但签名者指纹不在白名单里，
+	// 或者签名本身校验不通过时返回
+	ErrUntrustedSigner = errors.New("refs: commit signer is not trusted")
 )
 
-// Manager 负责管理引用 (Refs)
+const (
+	// headSymbolicPrefix 标记 HEAD 处于 "attached" 状态：内容形如 "ref: refs/heads/main"
+	// 模仿 Git 的 .git/HEAD 明文格式。没有这个前缀时，HEAD 的内容就是一个 detached 的 commit hash
+	headSymbolicPrefix = "ref: "
+
+	// branchRefPrefix 是分支在 Ref 表里的命名空间，对齐 Git 的 refs/heads/*
+	branchRefPrefix = "refs/heads/"
+)
+
+// Branch 描述一条本地分支及其 upstream 追踪配置
+// 字段对齐 go-git 的 config.Branch：Name 是本地分支名，Remote/Merge 描述它追踪的上游，
+// Rebase 决定 `tv pull` 这类操作在同步上游时是 rebase 还是 merge
+type Branch struct {
+	Name   string
+	Remote string
+	Merge  string // 上游引用，例如 "refs/heads/main"
+	Rebase bool
+}
+
+// Manager 负责管理引用 (Refs) 和分支 (Branches)
 // Phase 3: 底层由本地文件改为 PostgreSQL
 type Manager struct {
 	repo *meta.Repository
+
+	// store/allowedSigners 非 nil 时，UpdateHead 在 CAS 之前会去对象存储里把 newHash
+	// 对应的 Commit 取出来，校验它是不是被 allowedSigners 里的某把 Key 签过名。两者
+	// 默认都是零值（关闭检查）——不是每个仓库都要求签名 commit，这个行为必须是显式开启的
+	store          storage.Store
+	allowedSigners map[string]identity.Key
 }
 
 func NewManager(repo *meta.Repository) *Manager {
 	return &Manager{repo: repo}
 }
 
-// GetHead 读取当前 HEAD 的 Hash 和 版本号
+// RequireSignedBy 开启签名者白名单检查：此后任何推进 HEAD/分支尖端的 commit 都必须被
+// allowed 中的某把 Key 签过名，否则 UpdateHead 返回 ErrUnsignedCommit/ErrUntrustedSigner。
+// allowed 为空等于关闭检查（默认状态）。校验读的是对象存储里 commit 对象本身的签名字节，
+// 不是 meta 数据库里 CommitModel.SignerFingerprint 那份投影——后者只是给 `tv log` 展示
+// 用的缓存，不应该是安全检查的依据
+func (m *Manager) RequireSignedBy(store storage.Store, allowed map[string]identity.Key) {
+	m.store = store
+	m.allowedSigners = allowed
+}
+
+// checkSigner 是 UpdateHead 的内部守卫，见 RequireSignedBy 的文档
+func (m *Manager) checkSigner(ctx context.Context, hash types.Hash) error {
+	if len(m.allowedSigners) == 0 {
+		return nil
+	}
+
+	reader, err := m.store.Get(ctx, string(hash))
+	if err != nil {
+		return fmt.Errorf("failed to load commit %s for signature check: %w", hash, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read commit %s for signature check: %w", hash, err)
+	}
+
+	var c core.Commit
+	if err := core.DecodeObject(data, &c); err != nil {
+		return fmt.Errorf("object %s is corrupted or not a commit: %w", hash, err)
+	}
+
+	if c.Signature == nil {
+		return ErrUnsignedCommit
+	}
+	key, ok := m.allowedSigners[c.Signature.Fingerprint]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUntrustedSigner, c.Signature.Fingerprint)
+	}
+	if err := c.VerifySignature(key); err != nil {
+		return fmt.Errorf("%w: %v", ErrUntrustedSigner, err)
+	}
+	return nil
+}
+
+// branchRefName 把一个裸分支名规范化成它在 Ref 表里的 Name（refs/heads/<name>）
+func branchRefName(name string) string {
+	if strings.HasPrefix(name, branchRefPrefix) {
+		return name
+	}
+	return branchRefPrefix + name
+}
+
+// -----------------------------------------------------------------------------
+// HEAD：支持 attached（符号引用到某个分支）和 detached（直接指向一个 commit）两种状态
+// -----------------------------------------------------------------------------
+
+// CurrentBranch 返回 HEAD 当前 attach 到的分支名
+// 如果 HEAD 处于 detached 状态（或仓库是空的），返回 ("", false, nil)
+func (m *Manager) CurrentBranch(ctx context.Context) (string, bool, error) {
+	ref, err := m.repo.GetRef(ctx, "HEAD")
+	if err != nil {
+		if errors.Is(err, meta.ErrRefNotFound) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	branchRef, attached := strings.CutPrefix(string(ref.CommitHash), headSymbolicPrefix)
+	if !attached {
+		return "", false, nil
+	}
+	return strings.TrimPrefix(branchRef, branchRefPrefix), true, nil
+}
+
+// GetHead 读取当前 HEAD 指向的 Commit Hash 和版本号
+// 如果 HEAD 是 attached 的，透明地解析到它所指向分支的当前 Commit；
+// 分支存在但还没有任何提交时，返回 ErrNoHead（和全新仓库的情况一致）
 // 返回: (hash, version, error)
 func (m *Manager) GetHead(ctx context.Context) (types.Hash, int64, error) {
+	branch, attached, err := m.CurrentBranch(ctx)
+	if err != nil {
+		return "", 0, err
+	}
+	if attached {
+		hash, ver, err := m.GetRef(ctx, branchRefName(branch))
+		if err != nil {
+			return "", 0, err
+		}
+		if hash == "" {
+			return "", 0, ErrNoHead
+		}
+		return hash, ver, nil
+	}
+
 	ref, err := m.repo.GetRef(ctx, "HEAD")
 	if err != nil {
 		if errors.Is(err, meta.ErrRefNotFound) {
@@ -41,10 +177,23 @@ func (m *Manager) GetHead(ctx context.Context) (types.Hash, int64, error) {
 }
 
 // UpdateHead 原子更新 HEAD
-// 必须提供 oldVersion 以进行乐观锁检查 (CAS)
-// 如果是第一次提交，oldVersion 传 0
+// 如果 HEAD 当前 attached 到某个分支，实际推进的是那个分支（这就是 Git "commit on a branch
+// advances the branch, not HEAD itself" 的语义）；否则直接更新 HEAD 自己（detached 模式）。
+// 必须提供 oldVersion 以进行乐观锁检查 (CAS)；如果是第一次提交，oldVersion 传 0
 func (m *Manager) UpdateHead(ctx context.Context, newHash types.Hash, oldVersion int64) error {
-	err := m.repo.UpdateRef(ctx, "HEAD", newHash, oldVersion)
+	if err := m.checkSigner(ctx, newHash); err != nil {
+		return err
+	}
+
+	branch, attached, err := m.CurrentBranch(ctx)
+	if err != nil {
+		return err
+	}
+	if attached {
+		return m.UpdateRef(ctx, branchRefName(branch), newHash, oldVersion)
+	}
+
+	err = m.repo.UpdateRef(ctx, "HEAD", newHash, oldVersion)
 	if err != nil {
 		if errors.Is(err, meta.ErrConcurrentUpdate) {
 			return ErrStaleHead
@@ -53,3 +202,194 @@ func (m *Manager) UpdateHead(ctx context.Context, newHash types.Hash, oldVersion
 	}
 	return nil
 }
+
+// -----------------------------------------------------------------------------
+// 通用引用读写：供分支 Ref（refs/heads/*）和其它命名引用复用
+// -----------------------------------------------------------------------------
+
+// GetRef 读取任意一个引用当前指向的 Commit Hash 和版本号
+// 如果引用不存在，返回 ("", 0, nil) —— 调用方（比如 MetaService）据此判断"尚不存在"，
+// 而不是把"没有这个引用"当成一个错误
+func (m *Manager) GetRef(ctx context.Context, name string) (types.Hash, int64, error) {
+	ref, err := m.repo.GetRef(ctx, name)
+	if err != nil {
+		if errors.Is(err, meta.ErrRefNotFound) {
+			return "", 0, nil
+		}
+		return "", 0, fmt.Errorf("failed to get ref %s: %w", name, err)
+	}
+	return ref.CommitHash, ref.Version, nil
+}
+
+// RefEntry 描述一条引用当前指向的 Commit，供需要枚举"所有已知遍历起点"的场景使用
+// (例如 `tv log --all`)
+type RefEntry struct {
+	Name string
+	Hash types.Hash
+}
+
+// ListRefs 列出所有指向具体 Commit 的引用，按名称排序。跳过 HEAD 处于 attached
+// 状态时那条符号引用本身（内容形如 "ref: refs/heads/main"，不是一个 Commit Hash）——
+// 它所指向的分支本来就会出现在返回列表里，保留符号引用只会让调用方多一个需要特殊处理
+// 的、实际上不是合法遍历起点的条目
+func (m *Manager) ListRefs(ctx context.Context) ([]RefEntry, error) {
+	models, err := m.repo.ListRefs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list refs: %w", err)
+	}
+
+	entries := make([]RefEntry, 0, len(models))
+	for _, ref := range models {
+		if strings.HasPrefix(ref.CommitHash, headSymbolicPrefix) {
+			continue
+		}
+		entries = append(entries, RefEntry{Name: ref.Name, Hash: types.Hash(ref.CommitHash)})
+	}
+	return entries, nil
+}
+
+// UpdateRef 原子更新 (CAS) 任意一个引用
+func (m *Manager) UpdateRef(ctx context.Context, name string, newHash types.Hash, oldVersion int64) error {
+	if err := m.repo.UpdateRef(ctx, name, newHash, oldVersion); err != nil {
+		if errors.Is(err, meta.ErrConcurrentUpdate) {
+			return ErrStaleHead
+		}
+		return fmt.Errorf("failed to update ref %s: %w", name, err)
+	}
+	return nil
+}
+
+// RefUpdate 描述 CompareAndSwapMany 里一条待更新的引用
+type RefUpdate struct {
+	Name       string
+	NewHash    types.Hash
+	OldVersion int64
+}
+
+// CompareAndSwapMany 原子地更新一批引用：要么全部生效，要么一个都不生效。
+// 典型场景是一次 merge push 需要同时推进 refs/heads/main 和 refs/tags/v1——如果逐个
+// 调用 UpdateRef，分支推进成功、标签推进因为并发冲突失败这种半推进状态是暴露给调用方
+// 自己去清理的烂摊子；这里借着 meta.Repository.Batch 把整批 CAS 检查放进同一个事务，
+// 其中任何一条失败都回滚其余已经"看起来成功"的更新
+func (m *Manager) CompareAndSwapMany(ctx context.Context, updates []RefUpdate) error {
+	// 批量更新走的是 meta.Repository.Batch 这条独立路径，不经过 UpdateHead——如果这里不重新
+	// 过一遍 checkSigner，pkg/remote 的 merge-push（HTTP/in-process）就能绕开 RequireSignedBy
+	// 直接把任意未签名/不受信任的 commit 推进到 refs/heads/*。先于事务逐条检查，任何一条没过
+	// 签名门禁就直接失败，不碰 m.repo.Batch
+	for _, u := range updates {
+		if err := m.checkSigner(ctx, u.NewHash); err != nil {
+			return err
+		}
+	}
+
+	err := m.repo.Batch(ctx, func(tx *meta.BatchTx) error {
+		for _, u := range updates {
+			if err := tx.UpdateRef(u.Name, u.NewHash, u.OldVersion); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, meta.ErrConcurrentUpdate) {
+			return ErrStaleHead
+		}
+		return fmt.Errorf("failed to compare-and-swap refs: %w", err)
+	}
+	return nil
+}
+
+// -----------------------------------------------------------------------------
+// 分支管理
+// -----------------------------------------------------------------------------
+
+// CreateBranch 创建一条新分支，指向 startHash。跟 UpdateHead 一样要过 checkSigner——
+// 不然签名白名单就只保护"推进一条已有分支/HEAD"，client 端随手建一条新分支指向对象库里
+// 任意一个未签名/不受信任的 commit，再 checkout 过去，就绕过了整个签名门禁
+func (m *Manager) CreateBranch(ctx context.Context, name string, startHash types.Hash) error {
+	if err := m.checkSigner(ctx, startHash); err != nil {
+		return err
+	}
+
+	if err := m.repo.CreateBranch(ctx, &meta.BranchModel{Name: name}); err != nil {
+		if errors.Is(err, meta.ErrBranchExists) {
+			return ErrBranchExists
+		}
+		return fmt.Errorf("failed to create branch %s: %w", name, err)
+	}
+
+	// 分支的 Ref 是首次创建（oldVersion = 0），和 UpdateHead 首次提交的约定一致
+	if err := m.repo.UpdateRef(ctx, branchRefName(name), startHash, 0); err != nil {
+		return fmt.Errorf("failed to initialize ref for branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// DeleteBranch 删除一条本地分支（不会删除它的 Ref 历史，只移除分支本身的追踪记录）
+func (m *Manager) DeleteBranch(ctx context.Context, name string) error {
+	if err := m.repo.DeleteBranch(ctx, name); err != nil {
+		if errors.Is(err, meta.ErrBranchNotFound) {
+			return ErrBranchNotFound
+		}
+		return fmt.Errorf("failed to delete branch %s: %w", name, err)
+	}
+	return nil
+}
+
+// ListBranches 列出所有本地分支及其 upstream 追踪配置
+func (m *Manager) ListBranches(ctx context.Context) ([]Branch, error) {
+	models, err := m.repo.ListBranches(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list branches: %w", err)
+	}
+
+	branches := make([]Branch, 0, len(models))
+	for _, bm := range models {
+		branches = append(branches, Branch{
+			Name:   bm.Name,
+			Remote: bm.Remote,
+			Merge:  bm.Merge,
+			Rebase: bm.Rebase,
+		})
+	}
+	return branches, nil
+}
+
+// SetUpstream 设置某条本地分支追踪的远程引用，对应 `git branch --set-upstream-to`
+func (m *Manager) SetUpstream(ctx context.Context, name, remote, merge string) error {
+	if err := m.repo.SetUpstream(ctx, name, remote, merge); err != nil {
+		if errors.Is(err, meta.ErrBranchNotFound) {
+			return ErrBranchNotFound
+		}
+		return fmt.Errorf("failed to set upstream for %s: %w", name, err)
+	}
+	return nil
+}
+
+// Checkout 把 HEAD 切换为 attached 状态，指向名为 name 的分支
+// 这是 `tv checkout <branch-name>`（区别于传入 commit hash 的 detached checkout）背后的逻辑
+func (m *Manager) Checkout(ctx context.Context, name string) error {
+	if _, err := m.repo.GetBranch(ctx, name); err != nil {
+		if errors.Is(err, meta.ErrBranchNotFound) {
+			return ErrBranchNotFound
+		}
+		return fmt.Errorf("failed to resolve branch %s: %w", name, err)
+	}
+
+	var oldVersion int64
+	headRef, err := m.repo.GetRef(ctx, "HEAD")
+	if err == nil {
+		oldVersion = headRef.Version
+	} else if !errors.Is(err, meta.ErrRefNotFound) {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+
+	symbolic := types.Hash(headSymbolicPrefix + branchRefName(name))
+	if err := m.repo.UpdateRef(ctx, "HEAD", symbolic, oldVersion); err != nil {
+		if errors.Is(err, meta.ErrConcurrentUpdate) {
+			return ErrStaleHead
+		}
+		return fmt.Errorf("failed to attach HEAD to %s: %w", name, err)
+	}
+	return nil
+}
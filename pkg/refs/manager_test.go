@@ -2,9 +2,15 @@ package refs
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/identity"
 	"tensorvault/pkg/meta"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/types"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -13,6 +19,14 @@ import (
 	"gorm.io/gorm/logger"
 )
 
+// mockTreeHash 生成一个合法的 32 字节 Hex 字符串，供本文件里需要真的走 core.NewCommit
+// （从而需要 Link 能正确 hex.Decode）的测试用——跟 pkg/core/helpers_test.go 的 mockHash
+// 是同一个套路
+func mockTreeHash(input string) types.Hash {
+	sum := sha256.Sum256([]byte(input))
+	return types.Hash(hex.EncodeToString(sum[:]))
+}
+
 // TODO: read
 // setupTestEnv 搭建基于内存 SQLite 的测试环境
 func setupTestEnv(t *testing.T) *Manager {
@@ -103,3 +117,172 @@ func TestRefFlow_OptimisticLocking(t *testing.T) {
 	assert.Equal(t, hashB, currHash, "HEAD 应该保持为用户 B 的值")
 	assert.Equal(t, int64(2), currVer)
 }
+
+func TestManager_CompareAndSwapMany(t *testing.T) {
+	mgr := setupTestEnv(t)
+	ctx := context.Background()
+
+	mainRef := "refs/heads/main"
+	tagRef := "refs/tags/v1"
+
+	// 首次创建两个引用（oldVersion 都传 0，和 UpdateRef 的约定一致）
+	require.NoError(t, mgr.CompareAndSwapMany(ctx, []RefUpdate{
+		{Name: mainRef, NewHash: "hash_main_v1", OldVersion: 0},
+		{Name: tagRef, NewHash: "hash_tag_v1", OldVersion: 0},
+	}))
+
+	mainHash, mainVer, err := mgr.GetRef(ctx, mainRef)
+	require.NoError(t, err)
+	assert.Equal(t, types.Hash("hash_main_v1"), mainHash)
+	assert.Equal(t, int64(1), mainVer)
+
+	// 一次 merge push 同时推进两个引用
+	require.NoError(t, mgr.CompareAndSwapMany(ctx, []RefUpdate{
+		{Name: mainRef, NewHash: "hash_main_v2", OldVersion: mainVer},
+		{Name: tagRef, NewHash: "hash_tag_v2", OldVersion: 1},
+	}))
+
+	mainHash, mainVer, err = mgr.GetRef(ctx, mainRef)
+	require.NoError(t, err)
+	assert.Equal(t, types.Hash("hash_main_v2"), mainHash)
+	assert.Equal(t, int64(2), mainVer)
+
+	tagHash, tagVer, err := mgr.GetRef(ctx, tagRef)
+	require.NoError(t, err)
+	assert.Equal(t, types.Hash("hash_tag_v2"), tagHash)
+	assert.Equal(t, int64(2), tagVer)
+
+	// 其中一个引用的版本号过期 -> 整批都不应该生效，main 也不能被单独推进
+	err = mgr.CompareAndSwapMany(ctx, []RefUpdate{
+		{Name: mainRef, NewHash: "hash_main_v3", OldVersion: mainVer},
+		{Name: tagRef, NewHash: "hash_tag_v3", OldVersion: 999}, // 过期版本号
+	})
+	assert.ErrorIs(t, err, ErrStaleHead)
+
+	mainHash, mainVer, err = mgr.GetRef(ctx, mainRef)
+	require.NoError(t, err)
+	assert.Equal(t, types.Hash("hash_main_v2"), mainHash, "tag 那条失败时 main 也不应该被单独推进")
+	assert.Equal(t, int64(2), mainVer)
+}
+
+func TestRefFlow_RequireSignedBy(t *testing.T) {
+	mgr := setupTestEnv(t)
+	ctx := context.Background()
+
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+
+	trusted, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+	untrusted, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+
+	mgr.RequireSignedBy(store, map[string]identity.Key{trusted.Fingerprint(): trusted})
+
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
+	signedCommit, err := core.NewCommit(mockTreeHash("tree1"), nil, "alice", "signed", hasher)
+	require.NoError(t, err)
+	require.NoError(t, signedCommit.Sign(trusted))
+	require.NoError(t, store.Put(ctx, signedCommit))
+
+	err = mgr.UpdateHead(ctx, signedCommit.ID(), 0)
+	require.NoError(t, err, "被白名单里的身份签过名的 commit 应该被接受")
+
+	unsignedCommit, err := core.NewCommit(mockTreeHash("tree2"), nil, "alice", "unsigned", hasher)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, unsignedCommit))
+
+	_, _, err = mgr.GetHead(ctx)
+	require.NoError(t, err)
+	err = mgr.UpdateHead(ctx, unsignedCommit.ID(), 1)
+	assert.ErrorIs(t, err, ErrUnsignedCommit)
+
+	untrustedSignedCommit, err := core.NewCommit(mockTreeHash("tree3"), nil, "alice", "untrusted", hasher)
+	require.NoError(t, err)
+	require.NoError(t, untrustedSignedCommit.Sign(untrusted))
+	require.NoError(t, store.Put(ctx, untrustedSignedCommit))
+
+	err = mgr.UpdateHead(ctx, untrustedSignedCommit.ID(), 1)
+	assert.ErrorIs(t, err, ErrUntrustedSigner)
+}
+
+// TestRefFlow_RequireSignedBy_CompareAndSwapMany 覆盖 merge-push 走的批量 CAS 路径：
+// 这是 pkg/remote 的 HTTP/in-process push 实际调用的入口，RequireSignedBy 必须在这里
+// 也拦住未签名/不受信任的 commit，不能只护着 UpdateHead 那一条路
+func TestRefFlow_RequireSignedBy_CompareAndSwapMany(t *testing.T) {
+	mgr := setupTestEnv(t)
+	ctx := context.Background()
+
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+
+	trusted, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+
+	mgr.RequireSignedBy(store, map[string]identity.Key{trusted.Fingerprint(): trusted})
+
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
+	signedCommit, err := core.NewCommit(mockTreeHash("batch-signed"), nil, "alice", "signed", hasher)
+	require.NoError(t, err)
+	require.NoError(t, signedCommit.Sign(trusted))
+	require.NoError(t, store.Put(ctx, signedCommit))
+
+	err = mgr.CompareAndSwapMany(ctx, []RefUpdate{
+		{Name: "refs/heads/main", NewHash: signedCommit.ID(), OldVersion: 0},
+	})
+	require.NoError(t, err, "被白名单里的身份签过名的 commit 应该被接受")
+
+	unsignedCommit, err := core.NewCommit(mockTreeHash("batch-unsigned"), nil, "alice", "unsigned", hasher)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, unsignedCommit))
+
+	err = mgr.CompareAndSwapMany(ctx, []RefUpdate{
+		{Name: "refs/heads/main", NewHash: unsignedCommit.ID(), OldVersion: 1},
+	})
+	assert.ErrorIs(t, err, ErrUnsignedCommit, "merge-push 批量更新 refs/heads/main 不能绕开签名者白名单")
+
+	mainHash, mainVer, err := mgr.GetRef(ctx, "refs/heads/main")
+	require.NoError(t, err)
+	assert.Equal(t, signedCommit.ID(), mainHash, "被拒绝的未签名更新不应该推进 main")
+	assert.Equal(t, int64(1), mainVer)
+}
+
+// TestRefFlow_RequireSignedBy_CreateBranch 覆盖建分支那条路：CreateBranch 直接把
+// startHash 写成新分支的初始 Ref，不经过 UpdateHead，所以必须自己也过一遍 checkSigner，
+// 否则 `tv branch <name> <untrusted-hash>` 就绕开了签名门禁
+func TestRefFlow_RequireSignedBy_CreateBranch(t *testing.T) {
+	mgr := setupTestEnv(t)
+	ctx := context.Background()
+
+	store, err := disk.NewAdapter(t.TempDir())
+	require.NoError(t, err)
+
+	trusted, err := identity.GenerateEd25519Key()
+	require.NoError(t, err)
+
+	mgr.RequireSignedBy(store, map[string]identity.Key{trusted.Fingerprint(): trusted})
+
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
+	unsignedCommit, err := core.NewCommit(mockTreeHash("branch-unsigned"), nil, "alice", "unsigned", hasher)
+	require.NoError(t, err)
+	require.NoError(t, store.Put(ctx, unsignedCommit))
+
+	err = mgr.CreateBranch(ctx, "feature", unsignedCommit.ID())
+	assert.ErrorIs(t, err, ErrUnsignedCommit, "新建分支指向未签名 commit 不能绕开签名者白名单")
+
+	_, err = mgr.repo.GetBranch(ctx, "feature")
+	assert.ErrorIs(t, err, meta.ErrBranchNotFound, "签名校验失败时不应该留下一条孤立的分支记录")
+
+	signedCommit, err := core.NewCommit(mockTreeHash("branch-signed"), nil, "alice", "signed", hasher)
+	require.NoError(t, err)
+	require.NoError(t, signedCommit.Sign(trusted))
+	require.NoError(t, store.Put(ctx, signedCommit))
+
+	require.NoError(t, mgr.CreateBranch(ctx, "feature", signedCommit.ID()), "被白名单里的身份签过名的 commit 应该被接受")
+}
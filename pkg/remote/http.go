@@ -0,0 +1,462 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// -----------------------------------------------------------------------------
+// 在线协议：/upload-pack 把一批对象挨个编码成下面这种定长前缀的帧，client/server
+// 共用同一对 encode/decodeFrame——不用 JSON 是因为对象内容本身已经是不透明的二进制
+// (DAG-CBOR)，没必要先 base64 一遍再塞进 JSON 字符串
+// -----------------------------------------------------------------------------
+
+// writeFrame 写一帧：[2 字节 hash 长度][hash][2 字节 type 长度][type][4 字节 data 长度][data]
+func writeFrame(w io.Writer, obj core.Object) error {
+	hash := []byte(obj.ID().String())
+	typ := []byte(obj.Type())
+	data := obj.Bytes()
+
+	if err := binary.Write(w, binary.BigEndian, uint16(len(hash))); err != nil {
+		return err
+	}
+	if _, err := w.Write(hash); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint16(len(typ))); err != nil {
+		return err
+	}
+	if _, err := w.Write(typ); err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.BigEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// readFrame 读回 writeFrame 写的一帧；遇到 io.EOF（且还没开始读下一帧的长度前缀）时
+// 原样把 io.EOF 传回去，调用方据此判断流正常结束
+func readFrame(r io.Reader) (types.Hash, core.ObjectType, []byte, error) {
+	var hashLen uint16
+	if err := binary.Read(r, binary.BigEndian, &hashLen); err != nil {
+		return "", "", nil, err
+	}
+	hash := make([]byte, hashLen)
+	if _, err := io.ReadFull(r, hash); err != nil {
+		return "", "", nil, err
+	}
+
+	var typLen uint16
+	if err := binary.Read(r, binary.BigEndian, &typLen); err != nil {
+		return "", "", nil, err
+	}
+	typ := make([]byte, typLen)
+	if _, err := io.ReadFull(r, typ); err != nil {
+		return "", "", nil, err
+	}
+
+	var dataLen uint32
+	if err := binary.Read(r, binary.BigEndian, &dataLen); err != nil {
+		return "", "", nil, err
+	}
+	data := make([]byte, dataLen)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", "", nil, err
+	}
+
+	return types.Hash(hash), core.ObjectType(typ), data, nil
+}
+
+// uploadPackRequest 是 POST /upload-pack 的请求体
+type uploadPackRequest struct {
+	Wants []types.Hash `json:"wants"`
+}
+
+const objectTypeHeader = "X-Object-Type"
+
+// -----------------------------------------------------------------------------
+// Server：把本地 store/refs 暴露成 /refs、/objects/{hash}、/upload-pack 三个端点
+// -----------------------------------------------------------------------------
+
+// Server 把一个本地仓库（store + refs）暴露成 git-smart-http 风格的几个端点，供
+// Client 在另一台机器/另一个进程里驱动。它自己不监听端口——调用方（通常是
+// cmd/tv-server 或者测试里的 httptest.Server）负责把 Handler() 挂到一个真正的
+// http.Server 上
+type Server struct {
+	store storage.Store
+	refs  *refs.Manager
+}
+
+// NewServer 用一对本地的 storage.Store/refs.Manager 构造一个 HTTP 远端服务端
+func NewServer(store storage.Store, refMgr *refs.Manager) *Server {
+	return &Server{store: store, refs: refMgr}
+}
+
+// Handler 返回挂载了全部端点的 http.Handler
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/refs", s.handleRefs)
+	mux.HandleFunc("/objects/", s.handleObject)
+	mux.HandleFunc("/upload-pack", s.handleUploadPack)
+	return mux
+}
+
+func (s *Server) handleRefs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		entries, err := s.refs.ListRefs(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		out := make(map[string]RefEntry, len(entries))
+		for _, e := range entries {
+			_, version, err := s.refs.GetRef(ctx, e.Name)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			out[e.Name] = RefEntry{Hash: e.Hash, Version: version}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(out)
+
+	case http.MethodPost:
+		var updates []RefUpdate
+		if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+			http.Error(w, fmt.Sprintf("invalid ref update payload: %v", err), http.StatusBadRequest)
+			return
+		}
+		refUpdates := make([]refs.RefUpdate, len(updates))
+		for i, u := range updates {
+			refUpdates[i] = refs.RefUpdate{Name: u.Name, NewHash: u.NewHash, OldVersion: u.OldVersion}
+		}
+		if err := s.refs.CompareAndSwapMany(ctx, refUpdates); err != nil {
+			if errors.Is(err, refs.ErrStaleHead) {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleObject(w http.ResponseWriter, r *http.Request) {
+	hash := strings.TrimPrefix(r.URL.Path, "/objects/")
+	if hash == "" {
+		http.Error(w, "missing object hash", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+
+	switch r.Method {
+	case http.MethodGet:
+		rc, err := s.store.Get(ctx, hash)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer rc.Close()
+
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set(objectTypeHeader, string(sniffObjectType(data)))
+		_, _ = w.Write(data)
+
+	case http.MethodPut:
+		data, err := io.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		typ := core.ObjectType(r.Header.Get(objectTypeHeader))
+		if typ == "" {
+			typ = sniffObjectType(data)
+		}
+		if err := s.store.Put(ctx, newWireObject(types.Hash(hash), typ, data)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+
+	case http.MethodHead:
+		// 只问"有没有"，不读也不回 body——Client.HasObject 用这个代替
+		// "GET 整个对象再把 body 丢掉"，避免浪费一次完整的对象传输
+		exists, err := s.store.Has(ctx, hash)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) handleUploadPack(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req uploadPackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, fmt.Sprintf("invalid upload-pack request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/octet-stream")
+	flusher, _ := w.(http.Flusher)
+
+	for _, hash := range req.Wants {
+		rc, err := s.store.Get(ctx, hash.String())
+		if err != nil {
+			// 流已经开始往外写了，这时候出错没法再改 HTTP 状态码，只能提前结束响应；
+			// 客户端的 Fetch 只会少收到后面的对象，由 Pull 核对 want 列表后报错
+			return
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return
+		}
+		if err := writeFrame(w, newWireObject(hash, sniffObjectType(data), data)); err != nil {
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Client：实现 Remote，把三个端点包回同一套接口
+// -----------------------------------------------------------------------------
+
+// Client 是 Remote 的 HTTP 实现，baseURL 指向一个挂了 Server.Handler() 的地址
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// NewClient 用远端的 base URL（不带末尾的 "/"）构造一个 HTTP Remote 客户端
+func NewClient(baseURL string) *Client {
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), http: http.DefaultClient}
+}
+
+// NewClientWithHTTPClient 跟 NewClient 一样，但允许调用方传入自定义的 *http.Client——
+// 比如配置了自定义 CA/客户端证书的那种，供 pkg/client 的 RemoteTransport HTTPS 实现使用。
+// hc 为 nil 时退化成 http.DefaultClient
+func NewClientWithHTTPClient(baseURL string, hc *http.Client) *Client {
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), http: hc}
+}
+
+func (c *Client) LsRemote(ctx context.Context) (map[string]RefEntry, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/refs", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote: ls-remote request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote: ls-remote returned %s", resp.Status)
+	}
+
+	var out map[string]RefEntry
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("remote: failed to decode ls-remote response: %w", err)
+	}
+	return out, nil
+}
+
+func (c *Client) Fetch(ctx context.Context, wants []types.Hash) (<-chan core.Object, error) {
+	body, err := json.Marshal(uploadPackRequest{Wants: wants})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/upload-pack", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote: upload-pack request failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, fmt.Errorf("remote: upload-pack returned %s", resp.Status)
+	}
+
+	out := make(chan core.Object)
+	go func() {
+		defer close(out)
+		defer resp.Body.Close()
+		for {
+			hash, typ, data, err := readFrame(resp.Body)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- newWireObject(hash, typ, data):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (c *Client) Push(ctx context.Context, updates []RefUpdate, objects <-chan core.Object) error {
+	for obj := range objects {
+		if err := c.PutObject(ctx, obj); err != nil {
+			return err
+		}
+	}
+	return c.PushRefs(ctx, updates)
+}
+
+// PutObject 把单个对象 PUT 到 /objects/{hash}。Push 按顺序对 objects 管道里的每个对象
+// 调用这个方法；pkg/client 的 RemoteTransport HTTP 实现也直接复用它做单对象推送，不用
+// 再摆一条 channel
+func (c *Client) PutObject(ctx context.Context, obj core.Object) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.baseURL+"/objects/"+obj.ID().String(), bytes.NewReader(obj.Bytes()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(objectTypeHeader, string(obj.Type()))
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote: failed to push object %s: %w", obj.ID(), err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("remote: push of object %s returned %s", obj.ID(), resp.Status)
+	}
+	return nil
+}
+
+// GetObject GET 回单个对象，按 X-Object-Type 响应头还原它的类型（跟 handleObject 写回去
+// 的那个头对应），不存在时返回 storage.ErrNotFound，方便调用方用 errors.Is 判断
+func (c *Client) GetObject(ctx context.Context, hash types.Hash) (core.Object, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/objects/"+hash.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("remote: get object %s failed: %w", hash, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, storage.ErrNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("remote: get object %s returned %s", hash, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("remote: failed to read object %s: %w", hash, err)
+	}
+	typ := core.ObjectType(resp.Header.Get(objectTypeHeader))
+	if typ == "" {
+		typ = sniffObjectType(data)
+	}
+	return newWireObject(hash, typ, data), nil
+}
+
+// HasObject 用 HEAD /objects/{hash} 问远端有没有这个对象，不传输 body
+func (c *Client) HasObject(ctx context.Context, hash types.Hash) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.baseURL+"/objects/"+hash.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("remote: has object %s failed: %w", hash, err)
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("remote: has object %s returned %s", hash, resp.Status)
+	}
+}
+
+// PushRefs 只做 Push 的 CAS 更新引用部分，不涉及对象传输——RemoteTransport 的 HTTP
+// 实现在对象已经通过 PutObject 单独推送完之后单独调用这个方法
+func (c *Client) PushRefs(ctx context.Context, updates []RefUpdate) error {
+	body, err := json.Marshal(updates)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/refs", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("remote: ref update request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return nil
+	case http.StatusConflict:
+		return ErrConcurrentUpdate
+	default:
+		return fmt.Errorf("remote: ref update returned %s", resp.Status)
+	}
+}
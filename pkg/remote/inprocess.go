@@ -0,0 +1,81 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// InProcess 直接包一个本地的 *refs.Manager + storage.Store，在同一个进程里实现
+// Remote——没有网络往返，主要用于测试（跟 HTTP 实现跑同一套行为断言，确保协议语义
+// 不会因为传输层不同而跑偏），也可以用来表示一次"本地路径克隆"
+type InProcess struct {
+	refs  *refs.Manager
+	store storage.Store
+}
+
+// NewInProcess 用一对本地的 refs.Manager/storage.Store 构造一个 Remote
+func NewInProcess(refMgr *refs.Manager, store storage.Store) *InProcess {
+	return &InProcess{refs: refMgr, store: store}
+}
+
+func (p *InProcess) LsRemote(ctx context.Context) (map[string]RefEntry, error) {
+	entries, err := p.refs.ListRefs(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("remote: in-process ls-remote failed: %w", err)
+	}
+
+	out := make(map[string]RefEntry, len(entries))
+	for _, e := range entries {
+		_, version, err := p.refs.GetRef(ctx, e.Name)
+		if err != nil {
+			return nil, fmt.Errorf("remote: failed to read version of %s: %w", e.Name, err)
+		}
+		out[e.Name] = RefEntry{Hash: e.Hash, Version: version}
+	}
+	return out, nil
+}
+
+func (p *InProcess) Fetch(ctx context.Context, wants []types.Hash) (<-chan core.Object, error) {
+	out := make(chan core.Object)
+	go func() {
+		defer close(out)
+		for _, h := range wants {
+			obj, err := ReadObject(ctx, p.store, h)
+			if err != nil {
+				return
+			}
+			select {
+			case out <- obj:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (p *InProcess) Push(ctx context.Context, updates []RefUpdate, objects <-chan core.Object) error {
+	for obj := range objects {
+		if err := p.store.Put(ctx, obj); err != nil {
+			return fmt.Errorf("remote: failed to store pushed object %s: %w", obj.ID(), err)
+		}
+	}
+
+	refUpdates := make([]refs.RefUpdate, len(updates))
+	for i, u := range updates {
+		refUpdates[i] = refs.RefUpdate{Name: u.Name, NewHash: u.NewHash, OldVersion: u.OldVersion}
+	}
+	if err := p.refs.CompareAndSwapMany(ctx, refUpdates); err != nil {
+		if errors.Is(err, refs.ErrStaleHead) {
+			return ErrConcurrentUpdate
+		}
+		return fmt.Errorf("remote: failed to update refs: %w", err)
+	}
+	return nil
+}
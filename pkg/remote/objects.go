@@ -0,0 +1,124 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// wireObject 是一个收到原始字节、但还没有（也不需要）被解码成具体类型的 core.Object。
+// 跟 pkg/gc/pack.go 的 rawObject 是同一个套路："下一跳只关心 Hash/Bytes/Type 三件事"，
+// 不需要反过来通过反射把私有字段塞回 core.Commit/Tree/FileNode——那几个类型的 hash/
+// rawBytes 字段本来就只在本包内可写，wireObject 绕开了这个限制，也省了一次多余的解码
+type wireObject struct {
+	hash types.Hash
+	data []byte
+	typ  core.ObjectType
+}
+
+func newWireObject(hash types.Hash, typ core.ObjectType, data []byte) wireObject {
+	return wireObject{hash: hash, data: data, typ: typ}
+}
+
+// ReadObject 从 store 里读出 hash 对应的原始字节，包装成一个可以直接喂给 Remote.Push
+// 的 core.Object。InProcess.Fetch 和 cmd/tv/commands/push.go 的按 hash 推送逻辑共用
+// 这一个函数，省得各自再写一遍"Get -> ReadAll -> 嗅探类型 -> 包装"
+func ReadObject(ctx context.Context, store storage.Store, hash types.Hash) (core.Object, error) {
+	rc, err := store.Get(ctx, hash.String())
+	if err != nil {
+		return nil, fmt.Errorf("remote: failed to read object %s: %w", hash, err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("remote: failed to read object %s: %w", hash, err)
+	}
+	return newWireObject(hash, sniffObjectType(data), data), nil
+}
+
+// NewObject 把裸字节包装成一个可以直接喂给 storage.Store.Put 或者 Remote.Push 的
+// core.Object。typ 留空时退化成 sniffObjectType 猜测——pkg/client 的 RemoteTransport
+// 从 gRPC PutChunk/GetChunk 这类不带类型头的 RPC 响应里拿到裸字节时就是这么用的
+func NewObject(hash types.Hash, typ core.ObjectType, data []byte) core.Object {
+	if typ == "" {
+		typ = sniffObjectType(data)
+	}
+	return newWireObject(hash, typ, data)
+}
+
+func (o wireObject) ID() types.Hash        { return o.hash }
+func (o wireObject) Bytes() []byte         { return o.data }
+func (o wireObject) Type() core.ObjectType { return o.typ }
+
+// sniffObjectType 复用 pkg/gc 同样的嗅探套路：按 CBOR 里的 "t" 字段猜对象类型，解不出
+// 类型头的（比如裸 Chunk，叶子数据从来不带这个字段）一律当作 TypeChunk
+func sniffObjectType(data []byte) core.ObjectType {
+	var header struct {
+		TypeVal core.ObjectType `cbor:"t"`
+	}
+	if err := core.DecodeObject(data, &header); err != nil {
+		return core.TypeChunk
+	}
+	switch header.TypeVal {
+	case core.TypeCommit, core.TypeTree, core.TypeFileNode, core.TypeDelta:
+		return header.TypeVal
+	default:
+		return core.TypeChunk
+	}
+}
+
+// objectChildren 解码 obj 并返回它直接引用的子节点 hash：commit 是它的 tree 加全部
+// 父节点，tree 是它的全部目录项，filenode 是它的全部 chunk（或者，Pyramid 布局下，子
+// filenode）。叶子 Chunk（以及任何嗅探不出类型头的原始字节）没有子节点，返回 nil。
+//
+// 这是 Pull 的 BFS 在收到一个对象之后、决定下一轮该再要哪些 hash 时用的——跟
+// pkg/packfile 的 walkWant* 系列遍历的是同一棵图，区别只是 packfile 操作的是本地已经
+// 落盘、可以随时整棵递归下去的对象，这里操作的是刚从远端收到、一次只能看到一层的对象
+func objectChildren(obj core.Object) ([]types.Hash, error) {
+	switch obj.Type() {
+	case core.TypeCommit:
+		var c core.Commit
+		if err := core.DecodeObject(obj.Bytes(), &c); err != nil {
+			return nil, err
+		}
+		children := make([]types.Hash, 0, len(c.Parents)+1)
+		if c.TreeCid.Hash != "" {
+			children = append(children, c.TreeCid.Hash)
+		}
+		for _, p := range c.Parents {
+			children = append(children, p.Hash)
+		}
+		return children, nil
+
+	case core.TypeTree:
+		var t core.Tree
+		if err := core.DecodeObject(obj.Bytes(), &t); err != nil {
+			return nil, err
+		}
+		children := make([]types.Hash, 0, len(t.Entries))
+		for _, e := range t.Entries {
+			children = append(children, e.Cid.Hash)
+		}
+		return children, nil
+
+	case core.TypeFileNode:
+		var fn core.FileNode
+		if err := core.DecodeObject(obj.Bytes(), &fn); err != nil {
+			return nil, err
+		}
+		children := make([]types.Hash, 0, len(fn.Chunks))
+		for _, link := range fn.Chunks {
+			children = append(children, link.Hash.Hash)
+		}
+		return children, nil
+
+	default:
+		// TypeChunk/TypeDelta 是叶子，没有更多要追的子节点
+		return nil, nil
+	}
+}
@@ -0,0 +1,95 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// Pull 是 `tv fetch` 背后的客户端编排逻辑：先 LsRemote 看远端现在有哪些引用，再从这些
+// 引用的 Commit hash 出发一轮一轮地调用 r.Fetch，把本地 local 里还没有的对象取回来
+// 并 Put 进去。
+//
+// 每一轮的 want 列表只包含"当前已知边界上、本地确实没有"的 hash——一个对象一旦在本地
+// Store.Has 里能查到，就认为它能到达的整棵子图也都已经在本地了（内容寻址下这是安全的：
+// 子节点的 hash 是父节点内容的一部分，没有子节点就不可能算出同一个父节点 hash），不会
+// 再往下展开，这样重复 fetch 同一个远端时大部分请求会在第一轮就被 Has 挡掉。
+// 每一轮的结果都会先落盘再继续 BFS，所以整个过程在任意一轮中断都可以直接重新调用
+// Pull 恢复，不会重复下载已经落盘的对象。
+func Pull(ctx context.Context, r Remote, local storage.Store) (map[string]RefEntry, error) {
+	refs, err := r.LsRemote(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("remote: failed to list remote refs: %w", err)
+	}
+
+	seen := make(map[types.Hash]struct{})
+	var frontier []types.Hash
+	for _, entry := range refs {
+		if entry.Hash == "" {
+			continue
+		}
+		frontier = append(frontier, entry.Hash)
+	}
+
+	for len(frontier) > 0 {
+		wants, err := filterMissing(ctx, local, frontier, seen)
+		if err != nil {
+			return nil, err
+		}
+		frontier = nil
+		if len(wants) == 0 {
+			continue
+		}
+
+		objects, err := r.Fetch(ctx, wants)
+		if err != nil {
+			return nil, fmt.Errorf("remote: fetch failed: %w", err)
+		}
+
+		got := make(map[types.Hash]struct{}, len(wants))
+		for obj := range objects {
+			if err := local.Put(ctx, obj); err != nil {
+				return nil, fmt.Errorf("remote: failed to store fetched object %s: %w", obj.ID(), err)
+			}
+			got[obj.ID()] = struct{}{}
+			children, err := objectChildren(obj)
+			if err != nil {
+				return nil, fmt.Errorf("remote: failed to inspect fetched object %s: %w", obj.ID(), err)
+			}
+			frontier = append(frontier, children...)
+		}
+		for _, h := range wants {
+			if _, ok := got[h]; !ok {
+				return nil, fmt.Errorf("remote: fetch stream ended before object %s arrived", h)
+			}
+		}
+	}
+
+	return refs, nil
+}
+
+// filterMissing 去重 hashes（相对于 seen，跨轮次共享）并过滤掉本地已经 Has 的那些，
+// 剩下的才是这一轮真正需要发去 Fetch 的 want 列表
+func filterMissing(ctx context.Context, local storage.Store, hashes []types.Hash, seen map[types.Hash]struct{}) ([]types.Hash, error) {
+	var wants []types.Hash
+	for _, h := range hashes {
+		if h == "" {
+			continue
+		}
+		if _, ok := seen[h]; ok {
+			continue
+		}
+		seen[h] = struct{}{}
+
+		has, err := local.Has(ctx, h.String())
+		if err != nil {
+			return nil, fmt.Errorf("remote: failed to check local presence of %s: %w", h, err)
+		}
+		if !has {
+			wants = append(wants, h)
+		}
+	}
+	return wants, nil
+}
@@ -0,0 +1,98 @@
+package remote
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// registrySchemaVersion 是 remotes.json 的格式版本号，跟 pkg/identity.storeSchemaVersion
+// 是同一个套路：留一个字段，以后格式变了有地方挂迁移逻辑，不用一上来就过度设计
+const registrySchemaVersion = 1
+
+// Entry 是一条具名远端记录，落盘到 .tv/remotes.json
+type Entry struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+// Registry 管理本地已知的具名远端（`tv remote add` 写入，`tv fetch`/`tv push <name>`
+// 读出来解析成一个 Remote 实现），是 identity.Store 在"远端地址"这个领域的对应物
+type Registry struct {
+	path    string  // 物理文件路径 (.tv/remotes.json)
+	Version int     `json:"version"`
+	Entries []Entry `json:"remotes"`
+	mu      sync.RWMutex
+}
+
+// NewRegistry 加载或创建一个新的 Registry
+func NewRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path}
+
+	if _, err := os.Stat(path); err == nil {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read remote registry: %w", err)
+		}
+		if err := json.Unmarshal(data, r); err != nil {
+			return nil, fmt.Errorf("corrupted remote registry: %w", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	} else {
+		r.Version = registrySchemaVersion
+	}
+
+	return r, nil
+}
+
+// Add 写入或覆盖一个具名远端（按 Name 去重，重复 Add 同一个名字等于改 URL）
+func (r *Registry) Add(name, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, existing := range r.Entries {
+		if existing.Name == name {
+			r.Entries[i].URL = url
+			return nil
+		}
+	}
+	r.Entries = append(r.Entries, Entry{Name: name, URL: url})
+	return nil
+}
+
+// Get 按名字查找一个远端，找不到时返回 ok=false
+func (r *Registry) Get(name string) (Entry, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.Entries {
+		if e.Name == name {
+			return e, true
+		}
+	}
+	return Entry{}, false
+}
+
+// List 返回当前已知的所有远端
+func (r *Registry) List() []Entry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	out := make([]Entry, len(r.Entries))
+	copy(out, r.Entries)
+	return out
+}
+
+// Save 将 Registry 持久化到磁盘
+func (r *Registry) Save() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0644)
+}
@@ -0,0 +1,56 @@
+// Package remote 把"同步一个远端 TensorVault 仓库"抽象成一个小接口，类比 go-git 的
+// transport.Transport：调用方（tv fetch/tv push）不需要关心背后到底是一次 HTTP 往返、
+// 还是进程内直连的另一个 *refs.Manager/storage.Store——语义永远是"列出远端引用 /
+// 拉取本地缺的对象 / 推送新对象并 CAS 更新引用"这三件事。
+//
+// 两套实现：InProcess（直接包一个本地 *refs.Manager + storage.Store，供测试和
+// file://风格的本地克隆使用）和 HTTP（Client 实现 Remote，Server 把同一套语义暴露成
+// /refs、/objects/{hash}、/upload-pack 三个端点）。
+package remote
+
+import (
+	"context"
+	"errors"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/types"
+)
+
+// ErrConcurrentUpdate 在 Push 尝试 CAS 更新一条远端引用、但远端该引用的版本号已经被
+// 别人推进过时返回——对应 meta.ErrConcurrentUpdate/refs.ErrStaleHead 在跨进程场景下的
+// 等价物。调用方（tv push）应该提示用户先 tv fetch 再重试，而不是无脑覆盖
+var ErrConcurrentUpdate = errors.New("remote: ref update rejected (stale version, fetch first)")
+
+// RefEntry 描述远端一条引用当前指向的 Commit 以及它的版本号。Version 就是 Push 发起
+// CAS 更新时必须带上的 oldVersion，取值约定跟 meta.Ref.Version/refs.Manager.GetRef
+// 完全一致——两边版本号体系不统一的话，CAS 判断就没有意义
+type RefEntry struct {
+	Hash    types.Hash
+	Version int64
+}
+
+// RefUpdate 描述 Push 想要对远端某条引用做的一次 CAS 更新
+type RefUpdate struct {
+	Name       string
+	NewHash    types.Hash
+	OldVersion int64
+}
+
+// Remote 是对一个远端 TensorVault 仓库的抽象
+type Remote interface {
+	// LsRemote 返回远端当前所有引用及其版本号，调用方据此决定 fetch/push 该从哪个
+	// hash 开始算，以及 push 时该把哪个版本号当作 CAS 的 oldVersion
+	LsRemote(ctx context.Context) (map[string]RefEntry, error)
+
+	// Fetch 按需流式取回 wants 列表本身这几个对象（不会自动展开成整棵子图——调用方
+	// 负责解码每个返回的对象、找出它引用的子节点、检查本地是否已经有、再发起下一轮
+	// Fetch，见 Pull）。返回的 channel 在全部取完（或者出错中止）之后关闭
+	Fetch(ctx context.Context, wants []types.Hash) (<-chan core.Object, error)
+
+	// Push 先把 objects 发送完，再尝试用 updates 里每条引用的 OldVersion 做 CAS。
+	// 任何一条版本不匹配都返回 ErrConcurrentUpdate；已经发送成功的对象不会被回滚——
+	// 这跟 meta.Repository.Batch 刻意保留的"要么全部生效要么全部不生效"不是一回事：
+	// objects 这时已经落了盘，多传的对象不是错误，调用方该做的是重新 LsRemote 再
+	// 重试一次 CAS，而不是指望远端把已经写完的对象再吐出来
+	Push(ctx context.Context, updates []RefUpdate, objects <-chan core.Object) error
+}
@@ -0,0 +1,201 @@
+// 认证/租户身份：每个 RPC 在进入业务逻辑之前，先由 UnaryAuthInterceptor/
+// StreamAuthInterceptor 从 "authorization: Bearer <token>" 元数据里解出一个
+// Identity，塞进 context.Context，再往下传。DataService 等业务层只管从 ctx 里
+// 要 Identity，不关心这个 token 到底是 JWT 还是静态 API Key——那是 Authenticator
+// 实现细节，跟 pkg/storage 的"调用方只认 Store 接口，不关心底层是磁盘还是 S3"是
+// 同一种解耦方式
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ErrMissingToken 表示请求没有带 authorization 元数据，或者格式不是 "Bearer <token>"
+var ErrMissingToken = errors.New("auth: missing bearer token")
+
+// ErrInvalidToken 表示 token 格式正确但校验不过（签名不对、过期、API Key 不存在等）
+var ErrInvalidToken = errors.New("auth: invalid token")
+
+// Identity 是一次请求通过认证后得到的身份：TenantID 是强制隔离各租户数据的依据，
+// Subject 只用于日志/审计，不参与任何隔离逻辑
+type Identity struct {
+	TenantID string
+	Subject  string
+}
+
+// identityContextKey 是存取 Identity 专用的 context key 类型，避免跟其他包的
+// context value 撞 key（标准做法，见 context.WithValue 的文档建议）
+type identityContextKey struct{}
+
+// WithIdentity 把 Identity 挂到 ctx 上，业务层用 IdentityFromContext 取回
+func WithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityContextKey{}, id)
+}
+
+// IdentityFromContext 取回 UnaryAuthInterceptor/StreamAuthInterceptor 挂在 ctx 上的
+// Identity；ok=false 表示这个 ctx 没有经过认证拦截器（比如本地 CLI 直连，或者认证
+// 被显式关闭）
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityContextKey{}).(Identity)
+	return id, ok
+}
+
+// Authenticator 校验一个 bearer token 并返回对应的身份
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (Identity, error)
+}
+
+// JWTAuthenticator 用一个共享密钥校验 HMAC 签名的 JWT；Token 的 claims 里必须有
+// "tenant" (必填) 和 "sub" (可选，缺省用 "tenant" 兜底)
+type JWTAuthenticator struct {
+	secret []byte
+}
+
+// NewJWTAuthenticator 用 secret 构造一个 JWTAuthenticator
+func NewJWTAuthenticator(secret string) *JWTAuthenticator {
+	return &JWTAuthenticator{secret: []byte(secret)}
+}
+
+func (a *JWTAuthenticator) Authenticate(ctx context.Context, token string) (Identity, error) {
+	parsed, err := jwt.Parse(token, func(t *jwt.Token) (any, error) {
+		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return a.secret, nil
+	})
+	if err != nil || !parsed.Valid {
+		return Identity{}, ErrInvalidToken
+	}
+
+	claims, ok := parsed.Claims.(jwt.MapClaims)
+	if !ok {
+		return Identity{}, ErrInvalidToken
+	}
+	tenantID, _ := claims["tenant"].(string)
+	if tenantID == "" {
+		return Identity{}, fmt.Errorf("%w: missing tenant claim", ErrInvalidToken)
+	}
+	subject, _ := claims["sub"].(string)
+	if subject == "" {
+		subject = tenantID
+	}
+	return Identity{TenantID: tenantID, Subject: subject}, nil
+}
+
+// StaticAPIKeyAuthenticator 把一组预先配置好的 "API Key -> 租户" 映射当成最简单的
+// 认证方式：没有过期、没有签名校验，只适合内部/测试环境，生产环境应该用 JWTAuthenticator
+type StaticAPIKeyAuthenticator struct {
+	keys map[string]Identity
+}
+
+// NewStaticAPIKeyAuthenticator 用 apiKey -> tenantID 的映射构造一个 Authenticator
+func NewStaticAPIKeyAuthenticator(keyToTenant map[string]string) *StaticAPIKeyAuthenticator {
+	keys := make(map[string]Identity, len(keyToTenant))
+	for key, tenant := range keyToTenant {
+		keys[key] = Identity{TenantID: tenant, Subject: tenant}
+	}
+	return &StaticAPIKeyAuthenticator{keys: keys}
+}
+
+func (a *StaticAPIKeyAuthenticator) Authenticate(ctx context.Context, token string) (Identity, error) {
+	id, ok := a.keys[token]
+	if !ok {
+		return Identity{}, ErrInvalidToken
+	}
+	return id, nil
+}
+
+// bearerToken 从 gRPC 元数据里取出 "authorization: Bearer <token>"
+func bearerToken(ctx context.Context) (string, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", ErrMissingToken
+	}
+	values := md.Get("authorization")
+	if len(values) == 0 {
+		return "", ErrMissingToken
+	}
+	const prefix = "Bearer "
+	if !strings.HasPrefix(values[0], prefix) {
+		return "", ErrMissingToken
+	}
+	return strings.TrimPrefix(values[0], prefix), nil
+}
+
+// authenticate 是 Unary/Stream 两个拦截器共用的核心逻辑：取 token、认证、查/记
+// RPS 配额，返回挂好 Identity 的新 ctx
+func authenticate(ctx context.Context, authenticator Authenticator, quotas *QuotaManager) (context.Context, error) {
+	token, err := bearerToken(ctx)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	id, err := authenticator.Authenticate(ctx, token)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, err.Error())
+	}
+
+	if quotas != nil {
+		if err := quotas.AllowRequest(id.TenantID); err != nil {
+			return nil, status.Error(codes.ResourceExhausted, err.Error())
+		}
+	}
+
+	return WithIdentity(ctx, id), nil
+}
+
+// UnaryAuthInterceptor 构造一个校验 bearer token、注入租户身份、检查 RPS 配额的
+// Unary 拦截器。quotas 为 nil 时跳过配额检查，只做认证——方便只想要鉴权、暂时不
+// 配额管控的部署
+func UnaryAuthInterceptor(authenticator Authenticator, quotas *QuotaManager) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		newCtx, err := authenticate(ctx, authenticator, quotas)
+		if err != nil {
+			return nil, err
+		}
+		return handler(newCtx, req)
+	}
+}
+
+// StreamAuthInterceptor 是 UnaryAuthInterceptor 的流式版本：额外负责并发流配额——
+// 在 handler 运行期间持有一个"槽位"，handler 返回（无论成功失败）时释放，这样
+// "同一租户同时打开的流数量"才有一个准确、实时的计数
+func StreamAuthInterceptor(authenticator Authenticator, quotas *QuotaManager) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		newCtx, err := authenticate(ss.Context(), authenticator, quotas)
+		if err != nil {
+			return err
+		}
+
+		id, _ := IdentityFromContext(newCtx)
+		if quotas != nil {
+			release, err := quotas.AcquireStream(id.TenantID)
+			if err != nil {
+				return status.Error(codes.ResourceExhausted, err.Error())
+			}
+			defer release()
+		}
+
+		return handler(srv, &authenticatedServerStream{ServerStream: ss, ctx: newCtx})
+	}
+}
+
+// authenticatedServerStream 包装 grpc.ServerStream，只替换 Context()——handler 里
+// 调用 stream.Context() 拿到的必须是挂了 Identity 的那个 ctx，而不是原始的
+type authenticatedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedServerStream) Context() context.Context {
+	return s.ctx
+}
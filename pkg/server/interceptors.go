@@ -2,12 +2,21 @@ package server
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"runtime/debug"
 	"time"
 
+	"tensorvault/pkg/telemetry"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 )
 
@@ -109,3 +118,237 @@ func recoverFromPanic(p any) error {
 	// 返回一个友好的 gRPC Internal 错误给客户端，而不是直接断开连接
 	return status.Errorf(codes.Internal, "internal server error: panic recovered")
 }
+
+// =============================================================================
+// 3. Tracing Interceptor (分布式追踪上下文传播)
+// =============================================================================
+
+// hashedRequestByHash/hashedRequestBySha256 是两个非常宽松的接口断言：几乎所有带"按内容寻址的对象"的 gRPC 请求
+// (CheckFileRequest.Sha256、DownloadRequest.Hash……) 在生成的 pb.go 里都会有形如
+// GetHash()/GetSha256() 这样的 getter。与其在这里 import tvrpc 给每个具体请求类型都
+// 写一个 case（新增一个带 hash 字段的 RPC 就要回来改一次这个文件），不如用 duck typing：
+// 只要请求类型实现了其中一个 getter，就把值当 tv.object.hash 打到 span 上
+type hashedRequestByHash interface {
+	GetHash() string
+}
+type hashedRequestBySha256 interface {
+	GetSha256() string
+}
+
+// requestObjectHash 尝试从 req 里顺出一个内容哈希，顺不出来就返回 ""——调用方据此决定
+// 要不要打 tv.object.hash 属性，这样新增一个没有哈希字段的 RPC 不会产生任何噪音属性
+func requestObjectHash(req any) string {
+	switch r := req.(type) {
+	case hashedRequestByHash:
+		return r.GetHash()
+	case hashedRequestBySha256:
+		return r.GetSha256()
+	default:
+		return ""
+	}
+}
+
+// spanAttributesForRPC 是 Unary/Stream 两个 Tracing Interceptor 共用的收尾逻辑：把
+// rpc.method 和结束时的 rpc.grpc.status_code 打到 span 上，err 非 nil 时再 RecordError。
+// tv.object.hash（Unary 里顺得出来的话）和 panic 时的 span event 由两个拦截器自己打——
+// panic event 区别于 span.RecordError：
+// RecordError 标记的是"这次调用失败了"，AddEvent("panic") 标记的是"这次失败具体是
+// 因为一次 panic"，两者在 Jaeger 时间线上分别体现为错误状态和一个独立的事件点
+func spanAttributesForRPC(span trace.Span, method string, err error) {
+	st, _ := status.FromError(err)
+	span.SetAttributes(
+		attribute.String("rpc.method", method),
+		attribute.String("rpc.grpc.status_code", st.Code().String()),
+	)
+	if err != nil {
+		span.RecordError(err)
+	}
+}
+
+// UnaryTracingInterceptor 从客户端传来的 gRPC metadata 里提取 trace context（如果客户端
+// 通过 pkg/client 的 tracing DialOption 注入了的话），再围绕这次调用开一个 span，span 的
+// 名字直接用 FullMethod——这样服务端这一跳产生的 ingester.IngestFile/disk.Put 等子 span
+// 才能正确挂在客户端发起的那条 trace 下面，而不是各自变成孤立的根 span。必须排在
+// Recovery 之后（这样 panic 能被 span 观察到）、Metrics/Logging 之前：见
+// cmd/tv-server/main.go 里 recovery → tracing → metrics → logging → handler 的顺序
+func UnaryTracingInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	ctx = extractTraceContext(ctx)
+	ctx, span := telemetry.Tracer.Start(ctx, info.FullMethod)
+	defer func() {
+		if hash := requestObjectHash(req); hash != "" {
+			span.SetAttributes(attribute.String("tv.object.hash", hash))
+		}
+		spanAttributesForRPC(span, info.FullMethod, err)
+		span.End()
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			span.AddEvent("panic", trace.WithAttributes(attribute.String("panic.value", fmt.Sprint(r))))
+			err = status.Errorf(codes.Internal, "panic: %v", r)
+			panic(r) // 继续向上抛，真正的恢复交给 UnaryRecoveryInterceptor
+		}
+	}()
+
+	resp, err = handler(ctx, req)
+	return resp, err
+}
+
+// StreamTracingInterceptor 是 UnaryTracingInterceptor 的流式版本，专供 DataService 的
+// Upload/Download 用：跟 StreamAuthInterceptor 一样，需要包一层 grpc.ServerStream
+// 才能把带 span 的 ctx 传给业务 handler（ServerStream.Context() 没有 setter）
+func StreamTracingInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+	ctx := extractTraceContext(ss.Context())
+	ctx, span := telemetry.Tracer.Start(ctx, info.FullMethod)
+	defer func() {
+		spanAttributesForRPC(span, info.FullMethod, err)
+		span.End()
+	}()
+	defer func() {
+		if r := recover(); r != nil {
+			span.AddEvent("panic", trace.WithAttributes(attribute.String("panic.value", fmt.Sprint(r))))
+			err = status.Errorf(codes.Internal, "panic: %v", r)
+			panic(r)
+		}
+	}()
+
+	err = handler(srv, &tracingServerStream{ServerStream: ss, ctx: ctx})
+	return err
+}
+
+// tracingServerStream 用带 span 的 ctx 覆盖底层 ServerStream.Context()，其余方法原样透传
+type tracingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tracingServerStream) Context() context.Context { return s.ctx }
+
+// metadataCarrier 把 gRPC 的 metadata.MD 适配成 otel propagation.TextMapCarrier，
+// 用于从客户端传来的 metadata 里提取 (或者往外发的 metadata 里写入) W3C traceparent
+type metadataCarrier metadata.MD
+
+func (c metadataCarrier) Get(key string) string {
+	vals := metadata.MD(c).Get(key)
+	if len(vals) == 0 {
+		return ""
+	}
+	return vals[0]
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+var _ propagation.TextMapCarrier = metadataCarrier(nil)
+
+// extractTraceContext 从 incoming metadata 里提取客户端注入的 trace context；客户端没有
+// 注入 tracing DialOption 时 metadata 里自然没有 traceparent，Extract 原样返回 ctx，
+// span 会变成一个新的根 span——不强制要求所有客户端都升级
+func extractTraceContext(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, metadataCarrier(md))
+}
+
+// =============================================================================
+// 4. Metrics Interceptor (Prometheus)
+// =============================================================================
+
+// Metrics 是 Unary/StreamMetricsInterceptor 共用的一组 Prometheus 采集器，跟 pkg/telemetry
+// 里全进程共享一个 Tracer 是同一个理由：所有 RPC 都往同一组 Collector 里记，/metrics
+// 端点才能看到完整的调用画像，而不是好几份各自为政、互相对不上号的小计数器
+type Metrics struct {
+	handledTotal    *prometheus.CounterVec
+	handlingSeconds *prometheus.HistogramVec
+	msgReceived     *prometheus.CounterVec
+	msgSent         *prometheus.CounterVec
+}
+
+// NewMetrics 在 reg 上注册本包需要的 Collector 并返回可以直接喂给
+// Unary/StreamMetricsInterceptor 的 *Metrics。reg 通常是 cmd/tv-server 启动时创建的
+// 那一个 prometheus.Registry，同一个 Registry 再喂给 promhttp.Handler 暴露 /metrics
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		handledTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_handled_total",
+			Help: "Total number of RPCs completed, by method and final gRPC status code.",
+		}, []string{"method", "code"}),
+		handlingSeconds: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "grpc_server_handling_seconds",
+			Help:    "Histogram of the time (in seconds) it took to handle an RPC, by method.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method"}),
+		msgReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_msg_received_total",
+			Help: "Total number of stream messages received, by method.",
+		}, []string{"method"}),
+		msgSent: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "grpc_server_msg_sent_total",
+			Help: "Total number of stream messages sent, by method.",
+		}, []string{"method"}),
+	}
+	reg.MustRegister(m.handledTotal, m.handlingSeconds, m.msgReceived, m.msgSent)
+	return m
+}
+
+// UnaryMetricsInterceptor 记一次 handled_total + 一次 handling_seconds 观测值。
+// 排在 Tracing 之后、Logging 之前：跟 span 覆盖范围一致，但不依赖 span 是否存在——
+// 即使调用方完全没配 OTel exporter，Prometheus 这条链路也要能独立工作
+func (m *Metrics) UnaryMetricsInterceptor(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	m.observe(info.FullMethod, start, err)
+	return resp, err
+}
+
+// StreamMetricsInterceptor 是 UnaryMetricsInterceptor 的流式版本，额外用
+// metricsServerStream 包一层 ServerStream 来给每个 Recv/Send 消息计数
+func (m *Metrics) StreamMetricsInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	start := time.Now()
+	err := handler(srv, &metricsServerStream{ServerStream: ss, method: info.FullMethod, metrics: m})
+	m.observe(info.FullMethod, start, err)
+	return err
+}
+
+// observe 是 Unary/StreamMetricsInterceptor 共用的收尾逻辑
+func (m *Metrics) observe(method string, start time.Time, err error) {
+	st, _ := status.FromError(err)
+	m.handledTotal.WithLabelValues(method, st.Code().String()).Inc()
+	m.handlingSeconds.WithLabelValues(method).Observe(time.Since(start).Seconds())
+}
+
+// metricsServerStream 包装 grpc.ServerStream，在 RecvMsg/SendMsg 成功时分别给
+// grpc_server_msg_received_total/grpc_server_msg_sent_total 计数——跟 tracingServerStream
+// 包装同一个接口是为了给 Context() 换上带 span 的 ctx 不一样，这里纯粹是为了拦截
+// RecvMsg/SendMsg 两个方法
+type metricsServerStream struct {
+	grpc.ServerStream
+	method  string
+	metrics *Metrics
+}
+
+func (s *metricsServerStream) RecvMsg(m any) error {
+	err := s.ServerStream.RecvMsg(m)
+	if err == nil {
+		s.metrics.msgReceived.WithLabelValues(s.method).Inc()
+	}
+	return err
+}
+
+func (s *metricsServerStream) SendMsg(m any) error {
+	err := s.ServerStream.SendMsg(m)
+	if err == nil {
+		s.metrics.msgSent.WithLabelValues(s.method).Inc()
+	}
+	return err
+}
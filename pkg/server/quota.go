@@ -0,0 +1,170 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/time/rate"
+)
+
+// ErrRPSExceeded 表示某个租户在当前时间窗口内的请求速率超过了配置的上限
+var ErrRPSExceeded = errors.New("quota: request rate limit exceeded")
+
+// ErrTooManyStreams 表示某个租户同时打开的流式 RPC（Upload/Download）数量已达上限
+var ErrTooManyStreams = errors.New("quota: too many concurrent streams")
+
+// ErrStorageQuotaExceeded 表示某个租户已存储的字节数加上这次要写入的量会超过配额
+var ErrStorageQuotaExceeded = errors.New("quota: storage quota exceeded")
+
+// Quota 描述单个租户的三项限额；任意字段为零值表示该项不限制
+type Quota struct {
+	// MaxBytesStored 是租户允许占用的总存储字节数上限
+	MaxBytesStored int64
+	// MaxRPS 是每秒允许的请求数上限（含 Unary 和 Stream 的建连请求）
+	MaxRPS float64
+	// MaxConcurrentStreams 是同时打开的 Upload/Download 流数量上限
+	MaxConcurrentStreams int
+}
+
+// tenantState 是一个租户的运行时计数器：限流器、当前存储用量、当前并发流数
+type tenantState struct {
+	limiter           *rate.Limiter
+	quota             Quota
+	bytesStored       int64
+	concurrentStreams int32
+}
+
+// QuotaManager 按租户 ID 管理配额与实时用量。租户如果不在配置里，会退回
+// defaultQuota（通常配成一个比较保守的值，防止没配置的租户无限制地消耗资源）
+type QuotaManager struct {
+	mu           sync.Mutex
+	defaultQuota Quota
+	tenants      map[string]*tenantState
+}
+
+// NewQuotaManager 用每租户配额表 + 一个默认配额（应用于不在表里的租户）构造
+// QuotaManager
+func NewQuotaManager(perTenant map[string]Quota, defaultQuota Quota) *QuotaManager {
+	return &QuotaManager{
+		defaultQuota: defaultQuota,
+		tenants:      newTenantStates(perTenant),
+	}
+}
+
+// Reload 用新的配额表原地替换 defaultQuota 和每个已知租户的限额配置，用于配置热更新
+// （viper.OnConfigChange）场景。刻意不重置已经存在的运行时用量（当前并发流数、已用
+// 存储字节数）——那两项反映的是此刻世界的真实状态，不是"配置"的一部分，重新读一遍
+// 配置文件不应该把它们清零。配置里消失的租户退回新的 defaultQuota，配置里新出现的
+// 租户在这里直接按配置建好状态（不等 stateFor 懒创建，否则它会拿到 defaultQuota）
+func (m *QuotaManager) Reload(perTenant map[string]Quota, defaultQuota Quota) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.defaultQuota = defaultQuota
+	for tenant, state := range m.tenants {
+		q, ok := perTenant[tenant]
+		if !ok {
+			q = defaultQuota
+		}
+		state.quota = q
+		if q.MaxRPS > 0 {
+			state.limiter = rate.NewLimiter(rate.Limit(q.MaxRPS), int(q.MaxRPS)+1)
+		} else {
+			state.limiter = nil
+		}
+	}
+	for tenant, q := range perTenant {
+		if _, ok := m.tenants[tenant]; !ok {
+			m.tenants[tenant] = newTenantState(q)
+		}
+	}
+}
+
+func newTenantStates(perTenant map[string]Quota) map[string]*tenantState {
+	states := make(map[string]*tenantState, len(perTenant))
+	for tenant, q := range perTenant {
+		states[tenant] = newTenantState(q)
+	}
+	return states
+}
+
+func newTenantState(q Quota) *tenantState {
+	state := &tenantState{quota: q}
+	if q.MaxRPS > 0 {
+		// burst 跟限速值本身挂钩：允许短暂地一次性消耗一秒的配额，而不是严格到
+		// "每次请求必须间隔 1/MaxRPS 秒"，那样对正常的偶发性突发流量太不友好
+		state.limiter = rate.NewLimiter(rate.Limit(q.MaxRPS), int(q.MaxRPS)+1)
+	}
+	return state
+}
+
+// stateFor 取（必要时懒创建）一个租户的运行时状态；调用方必须持有 m.mu
+func (m *QuotaManager) stateFor(tenantID string) *tenantState {
+	if state, ok := m.tenants[tenantID]; ok {
+		return state
+	}
+	state := newTenantState(m.defaultQuota)
+	m.tenants[tenantID] = state
+	return state
+}
+
+// AllowRequest 检查租户的 RPS 限额；没有配置 MaxRPS（即限额为 0）的租户不限速
+func (m *QuotaManager) AllowRequest(tenantID string) error {
+	m.mu.Lock()
+	state := m.stateFor(tenantID)
+	limiter := state.limiter
+	m.mu.Unlock()
+
+	if limiter != nil && !limiter.Allow() {
+		return fmt.Errorf("%w: tenant %s", ErrRPSExceeded, tenantID)
+	}
+	return nil
+}
+
+// AcquireStream 占用租户的一个并发流槽位，返回的 release 必须在流结束时调用
+// （无论成功还是失败）来归还槽位
+func (m *QuotaManager) AcquireStream(tenantID string) (release func(), err error) {
+	m.mu.Lock()
+	state := m.stateFor(tenantID)
+	limit := state.quota.MaxConcurrentStreams
+	m.mu.Unlock()
+
+	if limit > 0 {
+		if int(atomic.AddInt32(&state.concurrentStreams, 1)) > limit {
+			atomic.AddInt32(&state.concurrentStreams, -1)
+			return nil, fmt.Errorf("%w: tenant %s (limit %d)", ErrTooManyStreams, tenantID, limit)
+		}
+		return func() { atomic.AddInt32(&state.concurrentStreams, -1) }, nil
+	}
+	return func() {}, nil
+}
+
+// RemainingBytes 返回租户配置的 MaxBytesStored 上限和当前已用量，不做任何记账。
+// 供流式上传在还不知道最终文件大小时，提前算出"这次最多还能写多少字节"，边读边核对
+// （见 pkg/service.quotaLimitedReader），而不是等 ing.IngestFile 把整份文件读完、
+// 全部落盘之后才用 AddBytesStored 发现超额——limit 为 0 表示这个租户不限制
+func (m *QuotaManager) RemainingBytes(tenantID string) (limit, used int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.stateFor(tenantID)
+	return state.quota.MaxBytesStored, state.bytesStored
+}
+
+// AddBytesStored 把 delta（可以为负，比如 GC 回收空间后）计入租户已用存储量，
+// 超过 MaxBytesStored 时拒绝并且不记账。DataService.Upload 在内容已经落盘、完整性校验
+// 通过之后、建索引之前调用它做最终记账；写入过程中的提前拦截靠 RemainingBytes 配合
+// pkg/service.quotaLimitedReader 完成
+func (m *QuotaManager) AddBytesStored(tenantID string, delta int64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	state := m.stateFor(tenantID)
+	if state.quota.MaxBytesStored > 0 && state.bytesStored+delta > state.quota.MaxBytesStored {
+		return fmt.Errorf("%w: tenant %s (used %d + %d > limit %d)", ErrStorageQuotaExceeded, tenantID, state.bytesStored, delta, state.quota.MaxBytesStored)
+	}
+	state.bytesStored += delta
+	return nil
+}
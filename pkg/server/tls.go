@@ -0,0 +1,46 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+
+	"google.golang.org/grpc/credentials"
+)
+
+// TLSConfig 描述服务端 TLS/mTLS 设置，字段跟 viper 里的 server.tls.* key 一一对应
+type TLSConfig struct {
+	CertFile string
+	KeyFile  string
+	// ClientCAFile 非空时启用 mTLS：只有用这个 CA 签发的客户端证书才能建立连接。
+	// 留空时退化成普通单向 TLS（只校验服务端身份，不要求客户端证书）
+	ClientCAFile string
+}
+
+// LoadTLSCredentials 根据 cfg 构造一份 grpc.Creds() 可用的 TransportCredentials
+func LoadTLSCredentials(cfg TLSConfig) (credentials.TransportCredentials, error) {
+	cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls: failed to load server cert/key: %w", err)
+	}
+
+	tlsCfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	}
+
+	if cfg.ClientCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.ClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("tls: failed to read client CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("tls: failed to parse client CA file %s", cfg.ClientCAFile)
+		}
+		tlsCfg.ClientCAs = pool
+		tlsCfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return credentials.NewTLS(tlsCfg), nil
+}
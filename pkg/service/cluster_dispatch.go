@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	tvrpc "tensorvault/pkg/api/tvrpc/v1"
+	"tensorvault/pkg/cluster"
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/treebuilder"
+	"tensorvault/pkg/types"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// -----------------------------------------------------------------------------
+// BuildTree 分片派发
+// -----------------------------------------------------------------------------
+
+// shardFileMap 按路径的第一级目录把 FileMap 拆开：同一个顶层目录下的所有文件分到同一组，
+// 并且去掉了这段公共前缀（worker 只负责构建它自己那个子树，不需要知道自己在整棵树里挂在
+// 哪里）；没有任何"/"的根级文件单独留下，它们不值得为了一两个文件专门派发一次 RPC，
+// 由 master 自己直接组装成 TreeEntry
+func shardFileMap(fileMap map[string]string) (shards map[string]map[string]string, rootFiles map[string]string) {
+	shards = make(map[string]map[string]string)
+	rootFiles = make(map[string]string)
+
+	for path, hash := range fileMap {
+		idx := strings.IndexByte(path, '/')
+		if idx < 0 {
+			rootFiles[path] = hash
+			continue
+		}
+		prefix, rest := path[:idx], path[idx+1:]
+		if shards[prefix] == nil {
+			shards[prefix] = make(map[string]string)
+		}
+		shards[prefix][rest] = hash
+	}
+	return shards, rootFiles
+}
+
+// buildTreeSharded 是 BuildTree 在集群模式下的实现：按顶层目录分片，把每个分片派发给
+// NodeRegistry 认为当前最闲的 worker，最后把 worker 各自返回的子树根和未分片的根级文件
+// 合并成一棵完整的树。单个分片派发失败就让整个请求失败——部分成功的 Merkle Tree 没有意义
+func (s *MetaService) buildTreeSharded(ctx context.Context, req *tvrpc.BuildTreeRequest) (*tvrpc.BuildTreeResponse, error) {
+	shards, rootFiles := shardFileMap(req.FileMap)
+
+	var entries []core.TreeEntry
+
+	if len(rootFiles) > 0 {
+		var rootHashes []types.Hash
+		for _, h := range rootFiles {
+			rootHashes = append(rootHashes, types.Hash(h))
+		}
+		sizeMap, err := s.app.Repository.GetSizesByMerkleRoots(ctx, rootHashes)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to query sizes for root-level files: %v", err)
+		}
+		for name, hashStr := range rootFiles {
+			size, found := sizeMap[hashStr]
+			if !found {
+				return nil, status.Errorf(codes.NotFound, "size metadata not found for %s", hashStr)
+			}
+			entries = append(entries, core.NewFileEntry(name, types.Hash(hashStr), size))
+		}
+	}
+
+	prefixes := make([]string, 0, len(shards))
+	for prefix := range shards {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+
+	for _, prefix := range prefixes {
+		node, ok := s.app.Cluster.LeastLoaded()
+		if !ok {
+			return nil, status.Errorf(codes.Unavailable, "no healthy worker available to build shard %q", prefix)
+		}
+
+		node.Acquire()
+		resp, err := node.Meta().BuildTree(ctx, &tvrpc.BuildTreeRequest{FileMap: shards[prefix]})
+		node.Release()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "worker %s failed to build shard %q: %v", node.Addr(), prefix, err)
+		}
+		entries = append(entries, core.NewDirEntry(prefix, types.Hash(resp.TreeHash)))
+	}
+
+	builder := treebuilder.NewBuilder(s.app.Store, s.app.Hasher)
+	rootHash, err := builder.MergeEntries(ctx, entries)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to merge sharded tree: %v", err)
+	}
+
+	fmt.Printf("✅ [BuildTree] Success (sharded across %d worker shard(s)). Root: %s\n", len(prefixes), rootHash)
+
+	return &tvrpc.BuildTreeResponse{
+		TreeHash: rootHash.String(),
+	}, nil
+}
+
+// -----------------------------------------------------------------------------
+// Upload 流代理
+// -----------------------------------------------------------------------------
+
+// proxyUpload 把一条正在进行中的 Upload 流原样转发给 worker：master 不做任何切片/哈希
+// 计算，只负责把客户端发来的帧依次转发，再把 worker 算出来的结果透传回客户端。
+// firstReq 是调用方已经 Recv 出来的握手帧（Data.Upload 在决定走代理路径之前必须先看一眼
+// 这一帧是不是合法的 FileMeta），这里重新把它送进 worker 流，避免丢失第一帧
+func (s *DataService) proxyUpload(stream grpc.ClientStreamingServer[tvrpc.UploadRequest, tvrpc.UploadResponse], firstReq *tvrpc.UploadRequest, node *cluster.WorkerClient) error {
+	node.Acquire()
+	defer node.Release()
+
+	workerStream, err := node.Data().Upload(stream.Context())
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "failed to open upload stream to worker %s: %v", node.Addr(), err)
+	}
+
+	if err := workerStream.Send(firstReq); err != nil {
+		return status.Errorf(codes.Internal, "failed to forward metadata frame to worker %s: %v", node.Addr(), err)
+	}
+
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return status.Errorf(codes.Internal, "failed to receive chunk from client: %v", err)
+		}
+		if err := workerStream.Send(req); err != nil {
+			return status.Errorf(codes.Internal, "failed to forward chunk to worker %s: %v", node.Addr(), err)
+		}
+	}
+
+	resp, err := workerStream.CloseAndRecv()
+	if err != nil {
+		return status.Errorf(codes.Internal, "worker %s failed to ingest upload: %v", node.Addr(), err)
+	}
+
+	fmt.Printf("➡️  [Upload] Delegated to worker %s (Hash: %s)\n", node.Addr(), resp.Hash)
+	return stream.SendAndClose(resp)
+}
@@ -7,18 +7,37 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 
 	tvrpc "tensorvault/pkg/api/tvrpc/v1"
 	"tensorvault/pkg/app"
 	"tensorvault/pkg/ingester"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/server"
 	"tensorvault/pkg/storage"
 	"tensorvault/pkg/types"
+	"tensorvault/pkg/upload"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// defaultTenant 是没有经过 server.UnaryAuthInterceptor/StreamAuthInterceptor（鉴权
+// 被关闭，或者调用方是进程内部、本来就不走 gRPC 拦截器链）时使用的租户 ID，保留单租户
+// 场景下的旧行为：所有文件索引都落在同一个命名空间下，不强制要求部署方必须先配置鉴权
+const defaultTenant = "default"
+
+// tenantFromContext 取出 UnaryAuthInterceptor/StreamAuthInterceptor 挂在 ctx 上的
+// 租户 ID；没有 Identity 时退回 defaultTenant，而不是报错——这样鉴权功能可以按需
+// 渐进式开启，不开鉴权的部署不会突然炸掉
+func tenantFromContext(ctx context.Context) string {
+	if id, ok := server.IdentityFromContext(ctx); ok && id.TenantID != "" {
+		return id.TenantID
+	}
+	return defaultTenant
+}
+
 type DataService struct {
 	tvrpc.UnimplementedDataServiceServer
 	app *app.App
@@ -44,9 +63,9 @@ func (s *DataService) CheckFile(ctx context.Context, req *tvrpc.CheckFileRequest
 		return nil, status.Error(codes.InvalidArgument, "invalid sha256 format")
 	}
 
-	// 2. 查询元数据索引
-	// s.app.Repository 是我们在 Step 2 中增强过的
-	idx, err := s.app.Repository.GetFileIndex(ctx, linearHash)
+	// 2. 查询元数据索引（按租户隔离，见 meta.Repository.GetFileIndex 的文档注释）
+	tenant := tenantFromContext(ctx)
+	idx, err := s.app.Repository.GetFileIndex(ctx, tenant, linearHash)
 	if err != nil {
 		// 数据库查询出错 (Connection Refused 等) -> 返回 Internal Error
 		return nil, status.Errorf(codes.Internal, "failed to query file index: %v", err)
@@ -63,8 +82,11 @@ func (s *DataService) CheckFile(ctx context.Context, req *tvrpc.CheckFileRequest
 	// 如果 Hash 一样但 Size 不一样，说明发生碰撞（或者数据库脏数据）
 	// 这种情况下我们不敢复用，强制客户端重新上传
 	if idx.SizeBytes != req.Size {
-		fmt.Printf("⚠️ Hash Collision or Corruption detected! Hash: %s, DB Size: %d, Req Size: %d\n",
-			linearHash, idx.SizeBytes, req.Size)
+		s.app.Logger.Warn("hash collision or corruption detected",
+			slog.String("linear_hash", string(linearHash)),
+			slog.Int64("db_size", idx.SizeBytes),
+			slog.Int64("req_size", req.Size),
+		)
 		return &tvrpc.CheckFileResponse{
 			Exists: false, // 欺骗客户端说不存在，强制重传
 		}, nil
@@ -79,14 +101,19 @@ func (s *DataService) CheckFile(ctx context.Context, req *tvrpc.CheckFileRequest
 		return nil, status.Errorf(codes.Internal, "storage check failed: %v", err)
 	}
 	if !exists {
-		fmt.Printf("⚠️ Data Integrity Alert: Index exists for %s but FileNode %s is missing in store.\n",
-			linearHash, idx.MerkleRoot)
+		s.app.Logger.Warn("data integrity alert: index exists but filenode missing in store",
+			slog.String("linear_hash", string(linearHash)),
+			slog.String("merkle_root", string(idx.MerkleRoot)),
+		)
 		// 索引悬空，需要重传
 		return &tvrpc.CheckFileResponse{Exists: false}, nil
 	}
 
 	// 6. Cache Hit (秒传成功)
-	fmt.Printf("⚡ [CheckFile] Instant upload for %s (Hash: %s)\n", linearHash[:8], idx.MerkleRoot[:8])
+	s.app.Logger.Info("instant upload (dedup hit)",
+		slog.String("linear_hash", string(linearHash[:8])),
+		slog.String("merkle_root", string(idx.MerkleRoot[:8])),
+	)
 
 	// 这里需要处理 optional 字段的赋值
 	// proto3 optional 对应 Go 的指针类型 *string
@@ -124,7 +151,20 @@ func (s *DataService) Upload(stream grpc.ClientStreamingServer[tvrpc.UploadReque
 		return status.Errorf(codes.InvalidArgument, "invalid sha256 in metadata: %s", meta.Sha256)
 	}
 
-	fmt.Printf("🚀 [Upload] Receiving: %s (Claimed Hash: %s...)\n", meta.Path, clientLinearHash[:8])
+	s.app.Logger.Info("upload receiving",
+		slog.String("path", meta.Path),
+		slog.String("claimed_hash", string(clientLinearHash[:8])),
+	)
+
+	// --- Step 1.1: 集群模式 (Delegation) ---
+	// master 不在本地切片/计算哈希，直接把整条流转发给当前最闲的 worker；worker 完成
+	// Step 2~6 的全部工作（切片、哈希、完整性校验、建索引），master 只透传它的结果
+	if s.app.Cluster != nil {
+		if node, ok := s.app.Cluster.LeastLoaded(); ok {
+			return s.proxyUpload(stream, firstReq, node)
+		}
+		// 没有健康 worker：退回下面的单进程老路径
+	}
 
 	// --- Step 2: 组装阶段 (Wiring) ---
 	// 1. gRPC Stream -> io.Reader
@@ -137,13 +177,31 @@ func (s *DataService) Upload(stream grpc.ClientStreamingServer[tvrpc.UploadReque
 	teeReader := io.TeeReader(streamReader, hasher)
 
 	// 4. 创建 Ingester
-	ing := ingester.NewIngester(s.app.Store)
+	ing := ingester.NewIngester(s.app.Store, s.app.Hasher)
 
 	// --- Step 3: 执行阶段 (Execution) ---
 	// Ingester 读取 teeReader -> 触发 Hasher 计算 -> 触发 CDC 切分 -> 上传 S3
+	//
+	// 这个 RPC 是单条连续流，握手帧（tvrpc.FileMeta）里只有 Path/Sha256，没有声明文件
+	// 总大小——跟下面 InitUpload 那条分片协议不一样，那边客户端会先报 req.Size。所以这里
+	// 没法照搬"写之前用声明的大小做一次性准入检查"：还没读完流就是不知道最终有多大。
+	// 用 quotaLimitedReader 包一层 teeReader 代替：一边读一边核对这个租户还剩多少配额，
+	// 读超了立刻在 Ingester 还在消费流的时候就地失败，而不是让它把整份文件读完、全部落盘
+	// 之后才用 Step 4.5 的 AddBytesStored 发现超额——这样配额上限对单次上传是真正生效的,
+	// 不只是写完了秋后算账
 	ctx := stream.Context()
-	fileNode, err := ing.IngestFile(ctx, teeReader)
+	tenant := tenantFromContext(ctx)
+	var reader io.Reader = teeReader
+	if s.app.Quotas != nil {
+		if limit, used := s.app.Quotas.RemainingBytes(tenant); limit > 0 {
+			reader = &quotaLimitedReader{r: teeReader, tenant: tenant, remaining: limit - used}
+		}
+	}
+	fileNode, err := ing.IngestFile(ctx, reader)
 	if err != nil {
+		if errors.Is(err, server.ErrStorageQuotaExceeded) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
 		return status.Errorf(codes.Internal, "ingestion failed: %v", err)
 	}
 
@@ -155,23 +213,41 @@ func (s *DataService) Upload(stream grpc.ClientStreamingServer[tvrpc.UploadReque
 	if serverLinearHash != clientLinearHash {
 		// 这是一个严重错误：数据在传输过程中损坏，或者客户端撒谎了
 		// 即使 S3 已经存了数据，我们也不能认领它（它是脏数据）
-		fmt.Printf("❌ [Upload] Integrity Check Failed!\nClaimed: %s\nActual : %s\n", clientLinearHash, serverLinearHash)
+		s.app.Logger.Error("upload integrity check failed",
+			slog.String("claimed", string(clientLinearHash)),
+			slog.String("actual", string(serverLinearHash)),
+		)
 		return status.Errorf(codes.DataLoss, "integrity check failed: data corruption detected")
 	}
 
+	// --- Step 4.5: 存储配额 (Quota) ---
+	// 在确认建索引之前做最终记账：上面的 quotaLimitedReader 只是边读边核对的提前预警，
+	// 真正权威的检查-并记账仍然是这里的 AddBytesStored（带着互斥锁，一次性原子地比较
+	// 并写入 bytesStored）——哪怕这份内容在 Store 里因为去重没有实际占用新空间，对这个
+	// 租户来说"拥有一份这么大的文件"本身就是要计入配额的，跟 pkg/upload.Manager 的去重
+	// （跳过已存在的 Chunk）是两回事——那是底层存储的去重优化，这里是租户账目
+	if s.app.Quotas != nil {
+		if err := s.app.Quotas.AddBytesStored(tenant, fileNode.TotalSize); err != nil {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+	}
+
 	// --- Step 5: 建立索引 (Indexing) ---
 	// 校验通过，说明 S3 里的数据是完好且正确的。
 	// 现在我们将 LinearHash -> MerkleRoot 的关系写入数据库，供下次 CheckFile 使用。
-	err = s.app.Repository.SaveFileIndex(ctx, serverLinearHash, fileNode.ID(), fileNode.TotalSize)
+	err = s.app.Repository.SaveFileIndex(ctx, tenant, serverLinearHash, fileNode.ID(), fileNode.TotalSize)
 	if err != nil {
 		// 索引写入失败不应影响上传成功的判定（属于非关键路径失败）
 		// 但为了系统健康，我们需要记录日志
-		fmt.Printf("⚠️ [Upload] Failed to save file index: %v\n", err)
+		s.app.Logger.Warn("failed to save file index", slog.Any("err", err))
 		// 选择：是报错还是忽略？
 		// 架构决策：忽略错误。文件已经安全存入 S3 并返回了 Hash，用户可以继续工作。
 		// 只是下次没法“秒传”而已。这是“可用性优先”。
 	} else {
-		fmt.Printf("✅ [Upload] Index saved. Linear: %s -> Merkle: %s\n", serverLinearHash[:8], fileNode.ID()[:8])
+		s.app.Logger.Info("file index saved",
+			slog.String("linear_hash", string(serverLinearHash[:8])),
+			slog.String("merkle_root", string(fileNode.ID()[:8])),
+		)
 	}
 
 	// --- Step 6: 响应阶段 (Response) ---
@@ -181,6 +257,27 @@ func (s *DataService) Upload(stream grpc.ClientStreamingServer[tvrpc.UploadReque
 	})
 }
 
+// quotaLimitedReader 包在 teeReader 外面，在 Ingester 边读边切分的同时实时核对这个租户
+// 还剩多少存储配额（见 server.QuotaManager.RemainingBytes 的文档注释），一旦累计读到的
+// 字节数超过 remaining 就立刻返回 server.ErrStorageQuotaExceeded 中断流——这样 Ingester
+// 会在流还没读完的时候就地停手，不会把整份文件读完、全部落盘之后才发现超额
+type quotaLimitedReader struct {
+	r         io.Reader
+	tenant    string
+	remaining int64
+}
+
+func (q *quotaLimitedReader) Read(p []byte) (int, error) {
+	n, err := q.r.Read(p)
+	if n > 0 {
+		q.remaining -= int64(n)
+		if q.remaining < 0 {
+			return n, fmt.Errorf("%w: tenant %s", server.ErrStorageQuotaExceeded, q.tenant)
+		}
+	}
+	return n, err
+}
+
 // =============================================================================
 // 2. Download (Server-Side Streaming)
 // =============================================================================
@@ -202,18 +299,15 @@ func (s *DataService) Download(req *tvrpc.DownloadRequest, stream grpc.ServerStr
 		// 注意：ExpandHash 是 Store 接口的一部分，我们在 Phase 1 已经实现了
 		fullHash, err := s.app.Store.ExpandHash(ctx, types.HashPrefix(inputHash))
 		if err != nil {
-			if errors.Is(err, storage.ErrNotFound) {
-				return status.Errorf(codes.NotFound, "hash prefix %s not found", inputHash)
-			}
-			if errors.Is(err, storage.ErrAmbiguousHash) {
-				return status.Errorf(codes.InvalidArgument, "hash prefix %s is ambiguous", inputHash)
-			}
-			return status.Errorf(codes.Internal, "hash expansion failed: %v", err)
+			return mapExpandHashError(inputHash, err)
 		}
 		targetHash = fullHash
 	}
 
-	fmt.Printf("📦 [Download] Serving: %s (Expanded from: %s)\n", targetHash, inputHash)
+	s.app.Logger.Info("download serving",
+		slog.String("hash", string(targetHash)),
+		slog.String("requested", inputHash),
+	)
 
 	// --- Step 2: 组装适配器 ---
 	// 把 gRPC stream 伪装成 io.Writer
@@ -240,3 +334,88 @@ func (s *DataService) Download(req *tvrpc.DownloadRequest, stream grpc.ServerStr
 
 	return nil
 }
+
+// =============================================================================
+// 3. Resumable Multipart Upload (Client-Driven Chunk Offloading)
+// =============================================================================
+//
+// 跟上面 Upload() 的一条连续流不同，这四个 RPC 让客户端自己在本地做 CDC 切分，先用
+// InitUpload 登记整份文件的 Chunk 列表、问清楚哪些已经在服务端存在（去重），再只传输
+// 缺的那些 Chunk（可以并行、可以跨进程重启续传），最后用 CompleteUpload 让服务端按登记
+// 的顺序组装出 FileNode。进度全部落在 meta.Repository（见 pkg/upload），不依赖某次 RPC
+// 调用或者某个 gRPC server 实例的生命周期
+
+// InitUpload 登记一次新的分片上传会话，返回 uploadId 和目前还缺的 Chunk 哈希列表
+func (s *DataService) InitUpload(ctx context.Context, req *tvrpc.InitUploadRequest) (*tvrpc.InitUploadResponse, error) {
+	linearHash := types.LinearHash(req.Sha256)
+	if !linearHash.IsValid() {
+		return nil, status.Error(codes.InvalidArgument, "invalid sha256 format")
+	}
+	if len(req.ChunkHashes) != len(req.ChunkSizes) {
+		return nil, status.Error(codes.InvalidArgument, "chunk_hashes and chunk_sizes must have the same length")
+	}
+
+	chunkHashes := make([]types.Hash, len(req.ChunkHashes))
+	for i, h := range req.ChunkHashes {
+		chunkHashes[i] = types.Hash(h)
+	}
+
+	mgr := upload.NewManager(s.app.Store, s.app.Repository, s.app.Hasher)
+	sessionID, missing, err := mgr.Init(ctx, linearHash, req.Size, chunkHashes, req.ChunkSizes)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to init upload: %v", err)
+	}
+
+	missingStrs := make([]string, len(missing))
+	for i, h := range missing {
+		missingStrs[i] = h.String()
+	}
+	return &tvrpc.InitUploadResponse{UploadId: sessionID, MissingChunkHashes: missingStrs}, nil
+}
+
+// UploadPart 写入一个此前被 InitUpload 报告为缺失的 Chunk
+func (s *DataService) UploadPart(ctx context.Context, req *tvrpc.UploadPartRequest) (*tvrpc.UploadPartResponse, error) {
+	mgr := upload.NewManager(s.app.Store, s.app.Repository, s.app.Hasher)
+	err := mgr.UploadPart(ctx, req.UploadId, types.Hash(req.ChunkHash), req.Data)
+	if err != nil {
+		return nil, mapUploadError(err)
+	}
+	return &tvrpc.UploadPartResponse{}, nil
+}
+
+// CompleteUpload 在所有 Chunk 都已落盘后，按登记顺序组装 FileNode 并持久化
+func (s *DataService) CompleteUpload(ctx context.Context, req *tvrpc.CompleteUploadRequest) (*tvrpc.CompleteUploadResponse, error) {
+	mgr := upload.NewManager(s.app.Store, s.app.Repository, s.app.Hasher)
+	fileNode, err := mgr.Complete(ctx, req.UploadId)
+	if err != nil {
+		return nil, mapUploadError(err)
+	}
+	return &tvrpc.CompleteUploadResponse{Hash: fileNode.ID().String(), TotalSize: fileNode.TotalSize}, nil
+}
+
+// AbortUpload 放弃一个未完成的上传会话。已经写入 Store 的 Chunk 不会被回收——内容寻址 +
+// 去重意味着它们完全可能已经被其他文件引用
+func (s *DataService) AbortUpload(ctx context.Context, req *tvrpc.AbortUploadRequest) (*tvrpc.AbortUploadResponse, error) {
+	mgr := upload.NewManager(s.app.Store, s.app.Repository, s.app.Hasher)
+	if err := mgr.Abort(ctx, req.UploadId); err != nil {
+		return nil, mapUploadError(err)
+	}
+	return &tvrpc.AbortUploadResponse{}, nil
+}
+
+// mapUploadError 把 pkg/upload 的哨兵错误映射成合适的 gRPC 状态码
+func mapUploadError(err error) error {
+	switch {
+	case errors.Is(err, meta.ErrUploadNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, upload.ErrChunkNotInSession),
+		errors.Is(err, upload.ErrChunkSizeMismatch),
+		errors.Is(err, upload.ErrChunkHashMismatch),
+		errors.Is(err, upload.ErrSessionFinished):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, upload.ErrIncomplete):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	default:
+		return status.Errorf(codes.Internal, "upload operation failed: %v", err)
+	}
+}
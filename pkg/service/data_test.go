@@ -119,7 +119,7 @@ func TestDataService_Upload_HappyPath(t *testing.T) {
 
 	// 3. 验证响应
 	require.NotNil(t, stream.Response)
-	_ = core.CalculateBlobHash(data) // 因为只有一块，FileNode Hash = Hash(FileNode{Chunk})，这里略复杂，我们直接验证 TotalSize
+	_ = core.CalculateBlobHash(data, app.Hasher) // 因为只有一块，FileNode Hash = Hash(FileNode{Chunk})，这里略复杂，我们直接验证 TotalSize
 	assert.NotEmpty(t, stream.Response.Hash)
 	assert.Equal(t, int64(len(data)), stream.Response.TotalSize)
 
@@ -205,10 +205,10 @@ func TestDataService_Download_HappyPath(t *testing.T) {
 
 	// 1. 准备数据：先手动存一个文件进去
 	data := []byte("downloadable content")
-	chunk := core.NewChunk(data)
+	chunk := core.NewChunk(data, app.Hasher)
 	require.NoError(t, app.Store.Put(ctx, chunk))
 
-	fileNode, err := core.NewFileNode(int64(len(data)), []core.ChunkLink{core.NewChunkLink(chunk)})
+	fileNode, err := core.NewFileNode(int64(len(data)), []core.ChunkLink{core.NewChunkLink(chunk)}, false, app.Hasher)
 	require.NoError(t, err)
 	require.NoError(t, app.Store.Put(ctx, fileNode))
 	targetHash := fileNode.ID()
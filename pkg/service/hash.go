@@ -0,0 +1,25 @@
+package service
+
+import (
+	"errors"
+
+	"tensorvault/pkg/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mapExpandHashError 把 Store.ExpandHash 的错误翻译成 gRPC 状态码，供 MetaService.ExpandHash
+// 和 DataService.Download 共用，确保两个入口对同一类错误报出一致的状态码，不会出现"同一个
+// 短哈希在 ExpandHash RPC 里是一种错误码，在 Download 里又是另一种"的不一致体验。
+// FailedPrecondition 用于歧义哈希：调用方的前置条件（"这个前缀唯一对应一个对象"）不成立，
+// 需要补充更多字符重新请求，而不是参数格式本身有问题 (那才是 InvalidArgument)
+func mapExpandHashError(prefix string, err error) error {
+	if errors.Is(err, storage.ErrNotFound) {
+		return status.Errorf(codes.NotFound, "hash prefix %s not found", prefix)
+	}
+	if errors.Is(err, storage.ErrAmbiguousHash) {
+		return status.Errorf(codes.FailedPrecondition, "hash prefix %s is ambiguous: %v", prefix, err)
+	}
+	return status.Errorf(codes.Internal, "hash expansion failed: %v", err)
+}
@@ -11,6 +11,8 @@ import (
 	"tensorvault/pkg/index"
 	"tensorvault/pkg/meta"
 	"tensorvault/pkg/refs"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/storage/cache"
 	"tensorvault/pkg/storage/disk"
 	"tensorvault/pkg/types"
 
@@ -26,10 +28,12 @@ func setupTestApp(t *testing.T) *app.App {
 	tmpDir := t.TempDir()
 
 	// 1. Store
+	// 套上 LRUStore，让测试也走一遍缓存路径，而不是只测试裸的 disk.Adapter
 	storePath := filepath.Join(tmpDir, "objects")
 	require.NoError(t, os.MkdirAll(storePath, 0755))
-	store, err := disk.NewAdapter(storePath)
+	diskStore, err := disk.NewAdapter(storePath)
 	require.NoError(t, err)
+	var store storage.Store = cache.NewLRU(diskStore, cache.Options{})
 
 	// 2. Index
 	idxPath := filepath.Join(tmpDir, "index.json")
@@ -50,16 +54,24 @@ func setupTestApp(t *testing.T) *app.App {
 	repo := meta.NewRepository(metaDB)
 	refMgr := refs.NewManager(repo)
 
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+
 	return &app.App{
 		Store:      store,
 		Index:      idx,
 		Refs:       refMgr,
 		RepoPath:   tmpDir,
 		Repository: repo,
+		Hasher:     hasher,
 	}
 }
 
 // 辅助函数：生成合法 Hash
 func mockHash(input string) types.Hash {
-	return core.CalculateBlobHash([]byte(input))
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	if err != nil {
+		panic(err)
+	}
+	return core.CalculateBlobHash([]byte(input), hasher)
 }
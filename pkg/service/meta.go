@@ -4,21 +4,31 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"time"
 
 	tvrpc "tensorvault/pkg/api/tvrpc/v1"
 	"tensorvault/pkg/app"
+	"tensorvault/pkg/blame"
 	"tensorvault/pkg/core"
 	"tensorvault/pkg/index"
 	"tensorvault/pkg/refs"
+	"tensorvault/pkg/task"
 	"tensorvault/pkg/treebuilder"
 	"tensorvault/pkg/types"
+	"tensorvault/pkg/worktree"
 
 	"buf.build/go/protovalidate"
 
+	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// jobPollInterval 是 GetJob 在任务还没到终态时重新轮询 meta.Repository 的间隔。
+// task.Manager 的心跳/轮询粒度本来就是 ~500ms 级别，这里没必要轮得更勤
+const jobPollInterval = 500 * time.Millisecond
+
 type MetaService struct {
 	tvrpc.UnimplementedMetaServiceServer
 	app       *app.App
@@ -96,6 +106,26 @@ func (s *MetaService) GetRef(ctx context.Context, req *tvrpc.GetRefRequest) (*tv
 	}, nil
 }
 
+// ExpandHash 把一个 (可能缩写的) Hash 前缀展开成完整哈希。本地命令一直是直接调用
+// Store.ExpandHash，这个 RPC 只是把同一份能力搬到 gRPC 上，让 `tv cat <prefix>
+// --server host:port` 这类远程命令也能像本地一样使用短哈希，不用强迫用户粘贴完整的
+// 64 位十六进制
+func (s *MetaService) ExpandHash(ctx context.Context, req *tvrpc.ExpandHashRequest) (*tvrpc.ExpandHashResponse, error) {
+	if err := s.validator.Validate(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	prefix := types.HashPrefix(req.Prefix)
+	if err := core.ValidateHashPrefix(prefix, s.app.Hasher.Algo()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	full, err := s.app.Store.ExpandHash(ctx, prefix)
+	if err != nil {
+		return nil, mapExpandHashError(req.Prefix, err)
+	}
+	return &tvrpc.ExpandHashResponse{Hash: full.String()}, nil
+}
+
 // Commit 处理提交请求
 func (s *MetaService) Commit(ctx context.Context, req *tvrpc.CommitRequest) (*tvrpc.CommitResponse, error) {
 	// 1. Runtime Validation
@@ -110,8 +140,39 @@ func (s *MetaService) Commit(ctx context.Context, req *tvrpc.CommitRequest) (*tv
 		parents = append(parents, types.Hash(p))
 	}
 
+	// 2.1 Amend：沿用被替换 commit 的 parents，忽略调用方传来的 ParentHashes——跟本地
+	// `tv commit --amend` 是同一个语义（amend 永远是"接在原来 commit 的父节点之后"，
+	// 不是"接在原来 commit 之后"）。tree/author/message 仍然完全信任调用方：客户端已经在
+	// 本地做过"index 是否为空 -> 要不要复用旧 tree"之类的决策，服务端不重复这层判断
+	var previousCommit types.Hash
+	if req.Amend {
+		previousCommit = types.Hash(req.PreviousCommit)
+		if previousCommit == "" {
+			return nil, status.Error(codes.InvalidArgument, "amend requires previous_commit")
+		}
+
+		reader, err := s.app.Store.Get(ctx, previousCommit)
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, "failed to load commit %s being amended: %v", previousCommit, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read commit %s being amended: %v", previousCommit, err)
+		}
+		var amended core.Commit
+		if err := core.DecodeObject(data, &amended); err != nil {
+			return nil, status.Errorf(codes.Internal, "object %s is not a commit: %v", previousCommit, err)
+		}
+
+		parents = nil
+		for _, p := range amended.Parents {
+			parents = append(parents, p.Hash)
+		}
+	}
+
 	// 3. Create Commit (Immutable)
-	commitObj, err := core.NewCommit(treeHash, parents, req.Author, req.Message)
+	commitObj, err := core.NewCommit(treeHash, parents, req.Author, req.Message, s.app.Hasher)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to create commit object: %v", err)
 	}
@@ -127,23 +188,28 @@ func (s *MetaService) Commit(ctx context.Context, req *tvrpc.CommitRequest) (*tv
 	}
 
 	// 6. Update Reference (CAS)
-	targetBranch := req.BranchName
-	if targetBranch == "" {
-		targetBranch = "HEAD"
-	}
-
-	// Get current version for Optimistic Locking
-	_, currentVer, err := s.app.Refs.GetRef(ctx, targetBranch)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to resolve ref %s: %v", targetBranch, err)
+	// 不再信任调用方传来的 BranchName：commit 永远推进调用方当前所在的位置——
+	// 如果 HEAD attached 到某条分支，就推进那条分支；否则（detached）直接推进 HEAD。
+	// 这是 refs.Manager.UpdateHead 已经封装好的语义，和 `tv commit` CLI 路径完全一致。
+	_, currentVer, err := s.app.Refs.GetHead(ctx)
+	if err != nil && !errors.Is(err, refs.ErrNoHead) {
+		return nil, status.Errorf(codes.Internal, "failed to resolve HEAD: %v", err)
 	}
 
 	// Atomic Update
-	if err := s.app.Refs.UpdateRef(ctx, targetBranch, commitObj.ID(), currentVer); err != nil {
-		return nil, status.Errorf(codes.Aborted, "concurrent update detected on %s: %v", targetBranch, err)
+	if err := s.app.Refs.UpdateHead(ctx, commitObj.ID(), currentVer); err != nil {
+		return nil, status.Errorf(codes.Aborted, "concurrent update detected on HEAD: %v", err)
 	}
 
-	fmt.Printf("✅ [Server] New Commit: %s -> %s (Author: %s)\n", targetBranch, commitObj.ID(), req.Author)
+	branch, attached, err := s.app.Refs.CurrentBranch(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve current branch: %v", err)
+	}
+	target := "HEAD (detached)"
+	if attached {
+		target = branch
+	}
+	fmt.Printf("✅ [Server] New Commit: %s -> %s (Author: %s)\n", target, commitObj.ID(), req.Author)
 
 	return &tvrpc.CommitResponse{
 		CommitHash: commitObj.ID().String(),
@@ -159,6 +225,13 @@ func (s *MetaService) BuildTree(ctx context.Context, req *tvrpc.BuildTreeRequest
 
 	fmt.Printf("🏗️ [BuildTree] Building tree from %d files...\n", len(req.FileMap))
 
+	// 1.1 集群模式：如果配置了健康的 worker，按路径前缀分片派发，master 只负责合并结果。
+	// 没有任何健康 worker 时（standalone、或者 master 配置了但全部失联）直接走下面的
+	// 单进程老路径——这正是"现有单进程路径作为 worker 为空时的兜底"
+	if s.app.Cluster != nil && s.app.Cluster.Len() > 0 {
+		return s.buildTreeSharded(ctx, req)
+	}
+
 	// 2. 构建内存索引 (Transient Index)
 	// 我们复用 index.Index 结构，但手动初始化，不绑定磁盘文件
 	tempIndex := &index.Index{
@@ -195,7 +268,7 @@ func (s *MetaService) BuildTree(ctx context.Context, req *tvrpc.BuildTreeRequest
 
 	// 4. 执行构建 (Heavy Lifting)
 	// 复用 treebuilder，它会自动处理目录层级拆分、排序、Hash计算和持久化
-	builder := treebuilder.NewBuilder(s.app.Store)
+	builder := treebuilder.NewBuilder(s.app.Store, s.app.Hasher)
 	rootHash, err := builder.Build(ctx, tempIndex)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to build merkle tree: %v", err)
@@ -207,3 +280,235 @@ func (s *MetaService) BuildTree(ctx context.Context, req *tvrpc.BuildTreeRequest
 		TreeHash: rootHash.String(),
 	}, nil
 }
+
+// Status 对比某个分支 HEAD 指向的 Tree 与客户端上报的本地路径快照
+// 它把 pkg/worktree 的 Merkletrie diff 能力原样暴露给瘦客户端：
+// 客户端不需要自己遍历本地仓库的历史对象，只需要把当下各文件路径的 Hash 发上来即可
+func (s *MetaService) Status(ctx context.Context, req *tvrpc.StatusRequest) (*tvrpc.StatusResponse, error) {
+	if err := s.validator.Validate(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	branch := req.BranchName
+	if branch == "" {
+		branch = "HEAD"
+	}
+
+	headHash, _, err := s.app.Refs.GetRef(ctx, branch)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to resolve ref %s: %v", branch, err)
+	}
+
+	var treeHash types.Hash
+	if headHash != "" {
+		reader, err := s.app.Store.Get(ctx, headHash)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to load HEAD commit: %v", err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read HEAD commit: %v", err)
+		}
+		var commit core.Commit
+		if err := core.DecodeObject(data, &commit); err != nil {
+			return nil, status.Errorf(codes.Internal, "HEAD object %s is not a commit: %v", headHash, err)
+		}
+		treeHash = commit.TreeCid.Hash
+	}
+
+	walker := worktree.NewWalker(s.app.Store, s.app.Hasher)
+	treeSnapshot, err := walker.FlattenTree(ctx, treeHash)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to flatten tree %s: %v", treeHash, err)
+	}
+
+	localSnapshot := make(map[string]types.Hash, len(req.LocalHashes))
+	for path, hash := range req.LocalHashes {
+		localSnapshot[path] = types.Hash(hash)
+	}
+
+	diff := worktree.DiffTreeVsSnapshot(treeSnapshot, localSnapshot)
+
+	resp := &tvrpc.StatusResponse{
+		Entries: make([]*tvrpc.StatusEntry, 0, len(diff)),
+	}
+	for _, e := range diff {
+		resp.Entries = append(resp.Entries, &tvrpc.StatusEntry{
+			Path:  e.Path,
+			State: string(e.State),
+		})
+	}
+	return resp, nil
+}
+
+// Blame 复刻 `tv blame` CLI 命令的逻辑，让瘦客户端不需要先把整条 commit 历史和沿途的
+// Tree/FileNode 对象都拉到本地，就能拿到某个文件的逐 Chunk 溯源结果。StartCommit 留空
+// 时从当前 HEAD 开始，跟 GetHead/Status 对"没指定就是 HEAD"的约定一致
+func (s *MetaService) Blame(ctx context.Context, req *tvrpc.BlameRequest) (*tvrpc.BlameResponse, error) {
+	if err := s.validator.Validate(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	startCommit := types.Hash(req.StartCommit)
+	if startCommit == "" {
+		head, _, err := s.app.Refs.GetHead(ctx)
+		if err != nil {
+			if errors.Is(err, refs.ErrNoHead) {
+				return nil, status.Error(codes.FailedPrecondition, "repository has no commits yet")
+			}
+			return nil, status.Errorf(codes.Internal, "failed to resolve HEAD: %v", err)
+		}
+		startCommit = head
+	}
+
+	blamer := blame.NewBlamer(s.app.Store)
+	records, err := blamer.Blame(ctx, req.Path, startCommit)
+	if err != nil {
+		if errors.Is(err, blame.ErrPathNotFound) {
+			return nil, status.Errorf(codes.NotFound, "%v", err)
+		}
+		return nil, status.Errorf(codes.Internal, "blame failed: %v", err)
+	}
+
+	resp := &tvrpc.BlameResponse{Records: make([]*tvrpc.BlameRecord, 0, len(records))}
+	for _, r := range records {
+		resp.Records = append(resp.Records, &tvrpc.BlameRecord{
+			RangeStart: r.Range.Start,
+			RangeEnd:   r.Range.End,
+			CommitHash: r.CommitHash.String(),
+			Author:     r.Author,
+			Timestamp:  r.Timestamp,
+			Message:    r.Message,
+		})
+	}
+	return resp, nil
+}
+
+// SubmitBuildTree 是 BuildTree 的异步版本：不在本次 RPC 里跑完整个构建，而是把它丢进
+// pkg/task 的持久化队列，立刻把 job_id 还给调用方。客户端用 GetJob 轮询/接收进度，或者
+// 继续调用同步的 BuildTree——两条路径跑的是同一套 treebuilder 逻辑（见
+// pkg/app/jobs.go 里 task.TypeTreeBuild 的 Handler），只是调用方式不同
+//
+// 集群分片（buildTreeSharded）目前只在同步路径里做：异步任务的 Handler 跑在单个进程里，
+// 大仓库如果需要分片，应该继续走同步 RPC
+func (s *MetaService) SubmitBuildTree(ctx context.Context, req *tvrpc.BuildTreeRequest) (*tvrpc.SubmitJobResponse, error) {
+	if err := s.validator.Validate(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if s.app.Jobs == nil {
+		return nil, status.Error(codes.FailedPrecondition, "task queue not available (requires the metadata database)")
+	}
+
+	payload := app.TreeBuildPayload{FileMap: req.FileMap}
+	id, err := s.app.Jobs.Submit(ctx, task.TypeTreeBuild, payload)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to submit tree build job: %v", err)
+	}
+
+	fmt.Printf("🏗️ [SubmitBuildTree] queued job %s (%d files)\n", id, len(req.FileMap))
+	return &tvrpc.SubmitJobResponse{JobId: id}, nil
+}
+
+// SubmitCommit 是 Commit 的异步版本：同样的 amend 语义（忽略调用方传来的 ParentHashes，
+// 沿用被替换 commit 的 parents），只是把"建 tree（如果需要）+ 落盘 commit + 推进 HEAD"这一整
+// 段挪到后台任务里执行，立刻返回 job_id。HEAD 的 CAS 版本号在 Handler 实际执行时才读取，
+// 不是提交这次 RPC 时读取的——job 在队列里等待期间，HEAD 完全可能已经被别的 commit 推进过
+func (s *MetaService) SubmitCommit(ctx context.Context, req *tvrpc.CommitRequest) (*tvrpc.SubmitJobResponse, error) {
+	if err := s.validator.Validate(req); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if s.app.Jobs == nil {
+		return nil, status.Error(codes.FailedPrecondition, "task queue not available (requires the metadata database)")
+	}
+
+	parents := req.ParentHashes
+	if req.Amend {
+		if req.PreviousCommit == "" {
+			return nil, status.Error(codes.InvalidArgument, "amend requires previous_commit")
+		}
+		reader, err := s.app.Store.Get(ctx, types.Hash(req.PreviousCommit))
+		if err != nil {
+			return nil, status.Errorf(codes.NotFound, "failed to load commit %s being amended: %v", req.PreviousCommit, err)
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to read commit %s being amended: %v", req.PreviousCommit, err)
+		}
+		var amended core.Commit
+		if err := core.DecodeObject(data, &amended); err != nil {
+			return nil, status.Errorf(codes.Internal, "object %s is not a commit: %v", req.PreviousCommit, err)
+		}
+		parents = nil
+		for _, p := range amended.Parents {
+			parents = append(parents, p.Hash.String())
+		}
+	}
+
+	payload := app.CommitPayload{
+		TreeHash: req.TreeHash,
+		Parents:  parents,
+		Author:   req.Author,
+		Message:  req.Message,
+	}
+	id, err := s.app.Jobs.Submit(ctx, task.TypeCommit, payload)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to submit commit job: %v", err)
+	}
+
+	fmt.Printf("✅ [SubmitCommit] queued job %s (Author: %s)\n", id, req.Author)
+	return &tvrpc.SubmitJobResponse{JobId: id}, nil
+}
+
+// GetJob 把一个后台任务（SubmitBuildTree/SubmitCommit 提交的，也包括 upload/checkout/
+// gc_repack）的状态转换流式推给调用方：Queued -> Running -> Succeeded/Failed，中间夹带
+// 进度百分比和最近一条人类可读描述。没有消息队列或者 pubsub 可用，所以这里用轮询模拟
+// "推送"：每 jobPollInterval 检查一次，状态或进度有变化才发一帧，省掉无意义的重复帧
+func (s *MetaService) GetJob(req *tvrpc.GetJobRequest, stream grpc.ServerStreamingServer[tvrpc.GetJobResponse]) error {
+	if err := s.validator.Validate(req); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	if s.app.Jobs == nil {
+		return status.Error(codes.FailedPrecondition, "task queue not available (requires the metadata database)")
+	}
+
+	ctx := stream.Context()
+	ticker := time.NewTicker(jobPollInterval)
+	defer ticker.Stop()
+
+	var lastStatus task.Status
+	var lastProgress int
+	first := true
+	for {
+		job, err := s.app.Jobs.Status(ctx, req.JobId)
+		if err != nil {
+			return status.Errorf(codes.NotFound, "job %s not found: %v", req.JobId, err)
+		}
+
+		if first || job.Status != lastStatus || job.Progress != lastProgress {
+			if err := stream.Send(&tvrpc.GetJobResponse{
+				Status:   string(job.Status),
+				Progress: int32(job.Progress),
+				Message:  job.Message,
+				Error:    job.Error,
+			}); err != nil {
+				return err
+			}
+			first = false
+			lastStatus = job.Status
+			lastProgress = job.Progress
+		}
+
+		switch job.Status {
+		case task.StatusSucceeded, task.StatusFailed, task.StatusCancelled:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -12,6 +13,7 @@ import (
 	tvrpc "tensorvault/pkg/api/tvrpc/v1"
 	"tensorvault/pkg/app"
 	"tensorvault/pkg/core"
+	"tensorvault/pkg/refs"
 	"tensorvault/pkg/types"
 )
 
@@ -135,3 +137,108 @@ func TestMetaService_BuildTree(t *testing.T) {
 	require.NoError(t, err)
 	assert.True(t, exists, "Root tree object should be persisted")
 }
+
+// TestMetaService_SubmitBuildTree_NoJobQueue 验证没有任务队列（setupTestApp 不启动
+// task.Manager，模拟没配 --db 或 worker 数为 0）时，Submit* 系列 RPC 给出明确的
+// FailedPrecondition，而不是 panic 或者吞掉请求
+func TestMetaService_SubmitBuildTree_NoJobQueue(t *testing.T) {
+	svc, _ := setupTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.SubmitBuildTree(ctx, &tvrpc.BuildTreeRequest{
+		FileMap: map[string]string{"data/train.csv": mockHash("x").String()},
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// TestMetaService_SubmitCommit_NoJobQueue 见 TestMetaService_SubmitBuildTree_NoJobQueue
+func TestMetaService_SubmitCommit_NoJobQueue(t *testing.T) {
+	svc, _ := setupTestService(t)
+	ctx := context.Background()
+
+	_, err := svc.SubmitCommit(ctx, &tvrpc.CommitRequest{
+		Message:  "async commit",
+		Author:   "Tester",
+		TreeHash: mockHash("root_tree").String(),
+	})
+	require.Error(t, err)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+// commitFileAt 是 Blame 测试的共用夹具：把单个文件 path -> content 写成一棵只有一个
+// 文件的 Tree，再包成一个 Commit 落盘，最后把 HEAD 推进到它。parent 为空串表示初始提交
+func commitFileAt(t *testing.T, app *app.App, path, content, author, message, parent string) *core.Commit {
+	t.Helper()
+	ctx := context.Background()
+
+	chunk := core.NewChunk([]byte(content), app.Hasher)
+	require.NoError(t, app.Store.Put(ctx, chunk))
+
+	fileNode, err := core.NewFileNode(chunk.Size(), []core.ChunkLink{core.NewChunkLink(chunk)}, false, app.Hasher)
+	require.NoError(t, err)
+	require.NoError(t, app.Store.Put(ctx, fileNode))
+
+	tree, err := core.NewTree([]core.TreeEntry{core.NewFileEntry(path, fileNode.ID(), fileNode.TotalSize)}, app.Hasher)
+	require.NoError(t, err)
+	require.NoError(t, app.Store.Put(ctx, tree))
+
+	var parents []types.Hash
+	if parent != "" {
+		parents = []types.Hash{types.Hash(parent)}
+	}
+	commit, err := core.NewCommit(tree.ID(), parents, author, message, app.Hasher)
+	require.NoError(t, err)
+	require.NoError(t, app.Store.Put(ctx, commit))
+
+	_, ver, err := app.Refs.GetHead(ctx)
+	if err != nil && !errors.Is(err, refs.ErrNoHead) {
+		require.NoError(t, err)
+	}
+	require.NoError(t, app.Refs.UpdateHead(ctx, commit.ID(), ver))
+	return commit
+}
+
+// TestMetaService_Blame_TracesBackToFirstIntroduction 验证内容没变的 Chunk 会一路追溯到
+// 最早引入它的 commit，而不是一律归因到 StartCommit
+func TestMetaService_Blame_TracesBackToFirstIntroduction(t *testing.T) {
+	svc, app := setupTestService(t)
+	ctx := context.Background()
+
+	first := commitFileAt(t, app, "weights.bin", "shard-v1", "Alice", "initial shard", "")
+	second := commitFileAt(t, app, "weights.bin", "shard-v1", "Bob", "unrelated change elsewhere", first.ID().String())
+
+	resp, err := svc.Blame(ctx, &tvrpc.BlameRequest{Path: "weights.bin", StartCommit: second.ID().String()})
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, first.ID().String(), resp.Records[0].CommitHash)
+	assert.Equal(t, "Alice", resp.Records[0].Author)
+}
+
+// TestMetaService_Blame_AttributesChangedContentToTheCommitThatChangedIt 验证内容变化的
+// Chunk 归因到引入新内容的那个 commit，而不是沿用父提交的归属
+func TestMetaService_Blame_AttributesChangedContentToTheCommitThatChangedIt(t *testing.T) {
+	svc, app := setupTestService(t)
+	ctx := context.Background()
+
+	first := commitFileAt(t, app, "weights.bin", "shard-v1", "Alice", "initial shard", "")
+	second := commitFileAt(t, app, "weights.bin", "shard-v2", "Bob", "retrained shard", first.ID().String())
+
+	resp, err := svc.Blame(ctx, &tvrpc.BlameRequest{Path: "weights.bin", StartCommit: second.ID().String()})
+	require.NoError(t, err)
+	require.Len(t, resp.Records, 1)
+	assert.Equal(t, second.ID().String(), resp.Records[0].CommitHash)
+	assert.Equal(t, "Bob", resp.Records[0].Author)
+}
+
+// TestMetaService_Blame_PathNotFound 验证请求一个不存在的路径返回 NotFound 而不是 Internal
+func TestMetaService_Blame_PathNotFound(t *testing.T) {
+	svc, app := setupTestService(t)
+	ctx := context.Background()
+
+	commit := commitFileAt(t, app, "weights.bin", "shard-v1", "Alice", "initial shard", "")
+
+	_, err := svc.Blame(ctx, &tvrpc.BlameRequest{Path: "does-not-exist.bin", StartCommit: commit.ID().String()})
+	require.Error(t, err)
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,73 @@
+package service
+
+import (
+	"context"
+	"io"
+
+	tvrpc "tensorvault/pkg/api/tvrpc/v1"
+	"tensorvault/pkg/app"
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PeerService 是 ClusterStore 在一致性哈希环下的节点间 RPC：当前节点算出某个 chunk
+// 哈希归另一个 peer 管时，通过这个服务把 Put/Get/Has 转发给真正拥有它的节点，而不是
+// 像 cluster.NodeRegistry 那样代理整条 Upload 流——PeerService 只搬运单个 chunk，
+// 粒度比 DataService.Upload 细得多
+//
+// 注意：实现直接操作 s.app.Store（本地存储），绝不经过 storage/clusterstore.Adapter，
+// 否则一个不属于本节点的请求被错误路由过来时会再次触发一轮转发，可能在环配置有误
+// 时形成死循环
+type PeerService struct {
+	tvrpc.UnimplementedPeerServiceServer
+	app *app.App
+}
+
+func NewPeerService(application *app.App) *PeerService {
+	return &PeerService{app: application}
+}
+
+// PutChunk 把调用方直接发来的 chunk 原始字节写入本地 Store；Hash 由服务端重新计算
+// 而不是信任调用方传来的 req.Hash，跟 DataService.Upload 对客户端声明的 LinearHash
+// 做完整性校验是同一个"不信任调用方"的原则，只是这里粒度小，可以直接重算而不用
+// 先收完整个流再比对
+func (s *PeerService) PutChunk(ctx context.Context, req *tvrpc.PutChunkRequest) (*tvrpc.PutChunkResponse, error) {
+	chunk := core.NewChunk(req.Data, s.app.Hasher)
+	if err := s.app.Store.Put(ctx, chunk); err != nil {
+		return nil, status.Errorf(codes.Internal, "peer: failed to put chunk: %v", err)
+	}
+	return &tvrpc.PutChunkResponse{Hash: chunk.ID().String()}, nil
+}
+
+// GetChunk 从本地 Store 读出一个 chunk 的完整字节并整块返回。Chunk 经过 CDC 切分，
+// 体积本来就被 chunker 的目标窗口 (KB 级) 限制住了，不需要像 DataService.Download
+// 那样用 server-streaming 分帧传输
+func (s *PeerService) GetChunk(ctx context.Context, req *tvrpc.GetChunkRequest) (*tvrpc.GetChunkResponse, error) {
+	rc, err := s.app.Store.Get(ctx, req.Hash)
+	if err != nil {
+		if err == storage.ErrNotFound {
+			return nil, status.Errorf(codes.NotFound, "peer: chunk %s not found", req.Hash)
+		}
+		return nil, status.Errorf(codes.Internal, "peer: failed to get chunk: %v", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "peer: failed to read chunk: %v", err)
+	}
+	return &tvrpc.GetChunkResponse{Data: data}, nil
+}
+
+// HasChunk 只问本地 Store 是否已经有这个 chunk，不读数据本体——ClusterStore.Has
+// 转发这个请求用的正是这条轻量路径
+func (s *PeerService) HasChunk(ctx context.Context, req *tvrpc.HasChunkRequest) (*tvrpc.HasChunkResponse, error) {
+	ok, err := s.app.Store.Has(ctx, req.Hash)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "peer: failed to check chunk: %v", err)
+	}
+	return &tvrpc.HasChunkResponse{Exists: ok}, nil
+}
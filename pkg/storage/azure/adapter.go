@@ -0,0 +1,160 @@
+// Package azure 实现了基于 Azure Blob Storage 的 storage.Store 后端，结构跟
+// pkg/storage/gcs 几乎一一对应：同样的两级哈希分片 Key、同样的 Has-before-Put 幂等
+// 检查、同样不做手动分片（由 azblob SDK 在内部处理大 Blob 的分块上传），只是把
+// 云厂商专有的 NotFound 错误码换成了 Azure 的 bloberror.BlobNotFound
+package azure
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+)
+
+// Adapter 实现了 storage.Store 接口
+type Adapter struct {
+	client    *azblob.Client
+	container string
+}
+
+// Config 用于初始化 Adapter
+type Config struct {
+	// ServiceURL 形如 https://<account>.blob.core.windows.net
+	ServiceURL string
+	Container  string
+	// ConnectionString 非空时优先使用共享密钥连接字符串；否则退回
+	// DefaultAzureCredential（托管身份、环境变量、az cli 登录态等），跟 pkg/storage/gcs
+	// 的 CredentialsFile-或默认凭据链 是同一个取舍
+	ConnectionString string
+}
+
+// NewAdapter 初始化 Azure Blob 客户端并定位到目标 Container
+func NewAdapter(cfg Config) (*Adapter, error) {
+	if cfg.Container == "" {
+		return nil, fmt.Errorf("azure: container is required")
+	}
+
+	var client *azblob.Client
+	var err error
+	if cfg.ConnectionString != "" {
+		client, err = azblob.NewClientFromConnectionString(cfg.ConnectionString, nil)
+	} else {
+		if cfg.ServiceURL == "" {
+			return nil, fmt.Errorf("azure: service_url is required when connection_string is not set")
+		}
+		var cred *azidentity.DefaultAzureCredential
+		cred, err = azidentity.NewDefaultAzureCredential(nil)
+		if err == nil {
+			client, err = azblob.NewClient(cfg.ServiceURL, cred, nil)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("azure: failed to create client: %w", err)
+	}
+
+	return &Adapter{client: client, container: cfg.Container}, nil
+}
+
+// transformKey 把 Hash 转换成 Blob 名称 (两级目录分片，跟 pkg/storage/gcs 一致)
+func transformKey(hash types.Hash) string {
+	hashStr := string(hash)
+	if len(hashStr) < 2 {
+		return hashStr
+	}
+	return hashStr[:2] + "/" + hashStr[2:]
+}
+
+// Put 上传对象。先做一次 Has 幂等检查，已存在就跳过
+func (a *Adapter) Put(ctx context.Context, obj core.Object) error {
+	hash := obj.ID()
+
+	exists, err := a.Has(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("azure put existence check failed: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	key := transformKey(hash)
+	_, err = a.client.UploadBuffer(ctx, a.container, key, obj.Bytes(), nil)
+	if err != nil {
+		return fmt.Errorf("azure put failed: %w", err)
+	}
+	return nil
+}
+
+// Get 下载对象
+func (a *Adapter) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
+	key := transformKey(hash)
+
+	resp, err := a.client.DownloadStream(ctx, a.container, key, nil)
+	if err != nil {
+		if bloberror.HasCode(err, bloberror.BlobNotFound) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("azure get failed: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// Has 检查对象是否存在
+func (a *Adapter) Has(ctx context.Context, hash types.Hash) (bool, error) {
+	key := transformKey(hash)
+
+	blobClient := a.client.ServiceClient().NewContainerClient(a.container).NewBlobClient(key)
+	_, err := blobClient.GetProperties(ctx, nil)
+	if err == nil {
+		return true, nil
+	}
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return false, nil
+	}
+	return false, fmt.Errorf("azure head failed: %w", err)
+}
+
+// ExpandHash 利用前缀查询扩展短哈希，语义跟 pkg/storage/gcs.Adapter.ExpandHash 完全一致
+func (a *Adapter) ExpandHash(ctx context.Context, shortHash types.HashPrefix) (types.Hash, error) {
+	inputStr := string(shortHash)
+	if len(inputStr) < 4 {
+		return "", fmt.Errorf("hash prefix too short")
+	}
+	prefix := inputStr[:2] + "/" + inputStr[2:]
+
+	var matches []string
+	pager := a.client.NewListBlobsFlatPager(a.container, &azblob.ListBlobsFlatOptions{
+		Prefix: &prefix,
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("azure list failed: %w", err)
+		}
+		for _, blob := range page.Segment.BlobItems {
+			if blob.Name != nil {
+				matches = append(matches, *blob.Name)
+			}
+		}
+		if len(matches) > 1 {
+			break
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", storage.ErrNotFound
+	}
+	if len(matches) > 1 {
+		return "", storage.ErrAmbiguousHash
+	}
+
+	hash := strings.Replace(matches[0], "/", "", 1)
+	return types.Hash(hash), nil
+}
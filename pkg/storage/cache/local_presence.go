@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"tensorvault/pkg/types"
+)
+
+// presenceShardCount 是 shardedPresenceCache 的分片数：用哈希十六进制表示的第一个字符
+// （0-9a-f）做 key，16 个分片足够把热点 chunk 的 Has 调用打散到不同的锁上，不会因为
+// 一次 ingest 里几十个 goroutine 并发问 "这个 chunk 存在吗" 而串行在同一把锁上排队
+const presenceShardCount = 16
+
+// defaultMaxPresenceEntries 是 shardedPresenceCache 的默认总容量（按条目数，不是字节）：
+// 存在性记录本身只是一个定长的 hash 字符串，不像 LRUStore 缓存的对象字节那样体积悬殊，
+// 按条目数限额等价于按字节数限额，但实现简单得多——不需要在每次淘汰时重新计算占用字节
+const defaultMaxPresenceEntries = 1 << 20 // ~100 万条哈希，按 64 字节/条算约 64MB
+
+// shardedPresenceCache 是 CachedStore.Has 的本地第一梯队：只记录"这个 hash 存不存在"，
+// 不缓存对象字节（字节缓存是 LRUStore/Get 的职责）。命中这里意味着 Has 完全不用碰
+// Redis，这正是 Put/Get 之外、dedup 扫描（pkg/ingester/bloom.go 的 putChunks）重复问
+// 同一个热点 chunk 时省下 Redis 网络往返的关键一环
+type shardedPresenceCache struct {
+	shards [presenceShardCount]*presenceShard
+}
+
+type presenceShard struct {
+	mu       sync.Mutex
+	maxItems int
+	ll       *list.List
+	items    map[types.Hash]*list.Element
+}
+
+func newShardedPresenceCache(maxEntries int) *shardedPresenceCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultMaxPresenceEntries
+	}
+	c := &shardedPresenceCache{}
+	perShard := maxEntries / presenceShardCount
+	if perShard < 1 {
+		perShard = 1
+	}
+	for i := range c.shards {
+		c.shards[i] = &presenceShard{
+			maxItems: perShard,
+			ll:       list.New(),
+			items:    make(map[types.Hash]*list.Element),
+		}
+	}
+	return c
+}
+
+// shardFor 用 hash 的第一个字符分流到固定分片——跟 presenceShardCount 的注释呼应，
+// 同一个 hash 永远落在同一个分片，淘汰和查询不需要跨分片协调
+func (c *shardedPresenceCache) shardFor(hash types.Hash) *presenceShard {
+	if len(hash) == 0 {
+		return c.shards[0]
+	}
+	// 取摘要部分（去掉 "blake3:" 这类算法前缀）的第一个十六进制字符，0-9a-f 正好对应 0-15
+	d := hash.Digest()
+	if len(d) == 0 {
+		return c.shards[0]
+	}
+	switch ch := d[0]; {
+	case ch >= '0' && ch <= '9':
+		return c.shards[ch-'0']
+	case ch >= 'a' && ch <= 'f':
+		return c.shards[ch-'a'+10]
+	}
+	return c.shards[int(d[0])%presenceShardCount]
+}
+
+func (c *shardedPresenceCache) has(hash types.Hash) bool {
+	s := c.shardFor(hash)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	el, ok := s.items[hash]
+	if !ok {
+		return false
+	}
+	s.ll.MoveToFront(el)
+	return true
+}
+
+func (c *shardedPresenceCache) mark(hash types.Hash) {
+	s := c.shardFor(hash)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.items[hash]; ok {
+		s.ll.MoveToFront(el)
+		return
+	}
+	el := s.ll.PushFront(hash)
+	s.items[hash] = el
+	if s.ll.Len() > s.maxItems {
+		oldest := s.ll.Back()
+		s.ll.Remove(oldest)
+		delete(s.items, oldest.Value.(types.Hash))
+	}
+}
@@ -0,0 +1,141 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+
+	"tensorvault/pkg/types"
+)
+
+// cacheEntry 是两种 LRU 队列共用的节点 payload
+type cacheEntry struct {
+	hash types.Hash
+	data []byte
+}
+
+// byteBoundedLRU 是一个按总字节数限额的 LRU：写入对象超出预算时，从队尾（最久未使用）
+// 开始淘汰，直到总字节数回到预算以内。用于 Commit/Tree/FileNode 和小 Chunk —— 这批对象
+// 数量多但单个体积小，按条数限制容易出现"预算花在了大对象上，小对象全被挤走"的问题，
+// 按字节数限制更贴近实际的内存占用
+type byteBoundedLRU struct {
+	mu       sync.Mutex
+	maxBytes int64
+	curBytes int64
+	ll       *list.List
+	items    map[types.Hash]*list.Element
+}
+
+func newByteBoundedLRU(maxBytes int64) *byteBoundedLRU {
+	return &byteBoundedLRU{
+		maxBytes: maxBytes,
+		ll:       list.New(),
+		items:    make(map[types.Hash]*list.Element),
+	}
+}
+
+func (c *byteBoundedLRU) get(hash types.Hash) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[hash]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).data, true
+}
+
+func (c *byteBoundedLRU) has(hash types.Hash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.items[hash]
+	return ok
+}
+
+func (c *byteBoundedLRU) put(hash types.Hash, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[hash]; ok {
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&cacheEntry{hash: hash, data: data})
+	c.items[hash] = el
+	c.curBytes += int64(len(data))
+
+	for c.curBytes > c.maxBytes && c.ll.Len() > 1 {
+		c.evictOldest()
+	}
+}
+
+func (c *byteBoundedLRU) evictOldest() {
+	oldest := c.ll.Back()
+	if oldest == nil {
+		return
+	}
+	c.ll.Remove(oldest)
+	entry := oldest.Value.(*cacheEntry)
+	delete(c.items, entry.hash)
+	c.curBytes -= int64(len(entry.data))
+}
+
+// countBoundedLRU 是一个按条目数限额的 LRU：超过上限就淘汰队尾。用于体积超过
+// chunker.MaxSize 的大对象（由 LRUStore.admit 分流过来）——这类对象数量少，按字节数算预算意义不大，而且一个大 Chunk
+// 体积就接近甚至超过小对象的整条字节预算，混进 byteBoundedLRU 会把其它小对象全部挤出去
+type countBoundedLRU struct {
+	mu       sync.Mutex
+	maxCount int
+	ll       *list.List
+	items    map[types.Hash]*list.Element
+}
+
+func newCountBoundedLRU(maxCount int) *countBoundedLRU {
+	return &countBoundedLRU{
+		maxCount: maxCount,
+		ll:       list.New(),
+		items:    make(map[types.Hash]*list.Element),
+	}
+}
+
+func (c *countBoundedLRU) get(hash types.Hash) ([]byte, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.items[hash]
+	if !ok {
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return el.Value.(*cacheEntry).data, true
+}
+
+func (c *countBoundedLRU) has(hash types.Hash) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.items[hash]
+	return ok
+}
+
+func (c *countBoundedLRU) put(hash types.Hash, data []byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[hash]; ok {
+		c.ll.MoveToFront(el)
+		return
+	}
+
+	el := c.ll.PushFront(&cacheEntry{hash: hash, data: data})
+	c.items[hash] = el
+
+	for c.ll.Len() > c.maxCount {
+		oldest := c.ll.Back()
+		if oldest == nil {
+			break
+		}
+		c.ll.Remove(oldest)
+		delete(c.items, oldest.Value.(*cacheEntry).hash)
+	}
+}
@@ -0,0 +1,118 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"tensorvault/pkg/chunker"
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// largeObjectThreshold 划分"小对象"和"大对象"的界线：chunker 切出来的 Chunk 永远不会
+// 超过 chunker.MaxSize，所以拿它当分界线最自然——凡是超过这个体积的，基本可以确定是一个
+// 没有被 CDC 切分命中小块边界、独立存在的大 Chunk
+const largeObjectThreshold = chunker.MaxSize
+
+const (
+	// DefaultMaxBytes 是小对象字节预算 LRU 的默认总容量
+	DefaultMaxBytes = 64 * 1024 * 1024 // 64MB
+	// DefaultMaxLargeChunks 是大 Chunk 计数队列的默认长度
+	DefaultMaxLargeChunks = 16
+)
+
+// Options 配置 LRUStore 两条缓存队列各自的容量
+type Options struct {
+	MaxBytes       int64 // 小对象 (Commit/Tree/FileNode/小 Chunk) 的总字节预算，<=0 时用 DefaultMaxBytes
+	MaxLargeChunks int   // 大 Chunk 的独立计数队列长度，<=0 时用 DefaultMaxLargeChunks
+}
+
+// LRUStore 是一个装饰器，给底层 storage.Store 加一层纯内存的对象缓存
+//
+// 参考 go-git plumbing/cache 的拆分思路：按字节预算淘汰的 LRU 放 Commit/Tree/FileNode 和
+// 小 Chunk，大 Chunk 单独进一个按数量限制的队列——否则一个几十 MB 的大 Chunk 足以把字节
+// 预算瞬间打满，连带把其它本该留在缓存里的小对象全部挤出去。
+//
+// 跟 CachedStore（Redis 存在性缓存，只记录"有没有"）不一样，这里缓存的是对象的原始字节
+// 本体，全程只在进程内存里，不经过网络，所以 Get 命中时完全不用碰 backend。
+type LRUStore struct {
+	backend storage.Store
+	small   *byteBoundedLRU
+	large   *countBoundedLRU
+}
+
+// NewLRU 用 opts 描述的容量包裹 backend
+func NewLRU(backend storage.Store, opts Options) *LRUStore {
+	maxBytes := opts.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	maxLargeChunks := opts.MaxLargeChunks
+	if maxLargeChunks <= 0 {
+		maxLargeChunks = DefaultMaxLargeChunks
+	}
+
+	return &LRUStore{
+		backend: backend,
+		small:   newByteBoundedLRU(maxBytes),
+		large:   newCountBoundedLRU(maxLargeChunks),
+	}
+}
+
+// Put 穿透写入 backend，成功后把内容按大小灌进对应的缓存队列
+func (s *LRUStore) Put(ctx context.Context, obj core.Object) error {
+	if err := s.backend.Put(ctx, obj); err != nil {
+		return err
+	}
+	s.admit(obj.ID(), obj.Bytes())
+	return nil
+}
+
+// Get 优先从缓存返回；未命中则回源 backend，并在内容大小允许的情况下回填缓存
+func (s *LRUStore) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	key := types.Hash(hash)
+	if data, ok := s.small.get(key); ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if data, ok := s.large.get(key); ok {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+
+	reader, err := s.backend.Get(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	s.admit(key, data)
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Has 缓存能直接回答就不问 backend；缓存没有不代表 backend 也没有，所以未命中时还是要穿透
+func (s *LRUStore) Has(ctx context.Context, hash string) (bool, error) {
+	key := types.Hash(hash)
+	if s.small.has(key) || s.large.has(key) {
+		return true, nil
+	}
+	return s.backend.Has(ctx, hash)
+}
+
+// Backend 返回被装饰的底层存储，供需要拿到具体实现（比如 *disk.Adapter）的维护类命令使用
+func (s *LRUStore) Backend() storage.Store {
+	return s.backend
+}
+
+// admit 按内容大小把数据放进小对象字节预算队列或者大对象计数队列
+func (s *LRUStore) admit(hash types.Hash, data []byte) {
+	if len(data) <= largeObjectThreshold {
+		s.small.put(hash, data)
+		return
+	}
+	s.large.put(hash, data)
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"sync/atomic"
+	"testing"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lruSpyStore 是一个统计调用次数的 storage.Store 存根，专门服务于 LRUStore 的测试——
+// 跟上面 redis_store_test.go 的 SpyStore 不是一回事：那个是给 CachedStore 用的，
+// 方法签名走的是 types.Hash；这里要挂在 LRUStore.backend (storage.Store) 上，
+// 必须老老实实按接口声明的 plain string 来写
+type lruSpyStore struct {
+	getCount int32
+	hasCount int32
+	objects  map[string][]byte
+}
+
+func newLRUSpyStore() *lruSpyStore {
+	return &lruSpyStore{objects: make(map[string][]byte)}
+}
+
+func (s *lruSpyStore) Put(ctx context.Context, obj core.Object) error {
+	s.objects[obj.ID().String()] = obj.Bytes()
+	return nil
+}
+
+func (s *lruSpyStore) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	atomic.AddInt32(&s.getCount, 1)
+	data, ok := s.objects[hash]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (s *lruSpyStore) Has(ctx context.Context, hash string) (bool, error) {
+	atomic.AddInt32(&s.hasCount, 1)
+	_, ok := s.objects[hash]
+	return ok, nil
+}
+
+type lruMockObject struct {
+	id   types.Hash
+	data []byte
+}
+
+func (m lruMockObject) ID() types.Hash        { return m.id }
+func (m lruMockObject) Bytes() []byte         { return m.data }
+func (m lruMockObject) Type() core.ObjectType { return core.TypeChunk }
+
+func TestLRUStore_GetHitsAvoidBackend(t *testing.T) {
+	ctx := context.Background()
+	spy := newLRUSpyStore()
+	lru := NewLRU(spy, Options{})
+
+	hash := types.Hash("aaaa111122223333444455556666777788889999000011112222333344445555")
+	obj := lruMockObject{id: hash, data: []byte("hello world")}
+	require.NoError(t, lru.Put(ctx, obj))
+
+	reader, err := lru.Get(ctx, hash.String())
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(data))
+
+	// Put 已经把内容灌进了缓存，Get 不应该再碰 backend
+	assert.Equal(t, int32(0), atomic.LoadInt32(&spy.getCount), "Get must be served from cache after Put")
+}
+
+func TestLRUStore_HasAnsweredFromCacheAlone(t *testing.T) {
+	ctx := context.Background()
+	spy := newLRUSpyStore()
+	lru := NewLRU(spy, Options{})
+
+	hash := types.Hash("bbbb111122223333444455556666777788889999000011112222333344445555")
+	obj := lruMockObject{id: hash, data: []byte("small")}
+	require.NoError(t, lru.Put(ctx, obj))
+
+	ok, err := lru.Has(ctx, hash.String())
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&spy.hasCount), "Has must be answered from cache without touching backend")
+}
+
+func TestLRUStore_MissFallsThroughAndBackfills(t *testing.T) {
+	ctx := context.Background()
+	spy := newLRUSpyStore()
+	lru := NewLRU(spy, Options{})
+
+	hash := types.Hash("cccc111122223333444455556666777788889999000011112222333344445555")
+	spy.objects[hash.String()] = []byte("from backend")
+
+	reader, err := lru.Get(ctx, hash.String())
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, "from backend", string(data))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&spy.getCount))
+
+	// 第二次应该从缓存回填命中，不再打到 backend
+	_, err = lru.Get(ctx, hash.String())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&spy.getCount), "second Get must be served from the backfilled cache")
+}
+
+func TestLRUStore_SmallAndLargeObjectsUseSeparateBudgets(t *testing.T) {
+	ctx := context.Background()
+	spy := newLRUSpyStore()
+	// 字节预算小到只能放下一个小对象；计数预算只放得下一个大对象
+	lru := NewLRU(spy, Options{MaxBytes: 16, MaxLargeChunks: 1})
+
+	small := lruMockObject{id: types.Hash("small-hash-aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), data: []byte("tiny")}
+	large1 := lruMockObject{id: types.Hash("large-hash-1aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), data: bytes.Repeat([]byte("x"), largeObjectThreshold+1)}
+	large2 := lruMockObject{id: types.Hash("large-hash-2aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"), data: bytes.Repeat([]byte("y"), largeObjectThreshold+1)}
+
+	require.NoError(t, lru.Put(ctx, small))
+	require.NoError(t, lru.Put(ctx, large1))
+	require.NoError(t, lru.Put(ctx, large2))
+
+	// 小对象仍然在缓存里：写入一个大 Chunk 不应该挤掉它
+	ok, err := lru.Has(ctx, small.id.String())
+	require.NoError(t, err)
+	assert.True(t, ok, "a large chunk must not evict small objects out of the byte-bounded LRU")
+
+	// 大对象队列只放得下 1 个，large1 应该已经被 large2 挤出去了
+	spy.objects[large1.id.String()] = large1.data
+	_, err = lru.Get(ctx, large1.id.String())
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&spy.getCount), "large1 must have been evicted by large2 and fall through to backend")
+}
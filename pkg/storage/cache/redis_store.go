@@ -1,27 +1,75 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
+	"sync"
 	"time"
 
 	"tensorvault/pkg/core"
 	"tensorvault/pkg/storage"
+	"tensorvault/pkg/telemetry"
 	"tensorvault/pkg/types"
 
 	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+	"golang.org/x/sync/singleflight"
 )
 
+// DefaultMaxCachedBlobBytes 是 Config.MaxCachedBlobBytes 未设置时的默认阈值：
+// Commit/Tree 和绝大多数小 FileNode 都在这个量级以内，值得进 Redis；大 Chunk 不值得，
+// 见 Get 上的注释
+const DefaultMaxCachedBlobBytes = 64 * 1024
+
 // CachedStore 是一个装饰器，它为底层的 storage.Store 添加 Redis 缓存层
 type CachedStore struct {
 	backend storage.Store // 被装饰的底层存储 (如 S3)
 	client  *redis.Client // Redis 客户端
 	ttl     time.Duration // 缓存过期时间 (例如 24h)
+
+	maxCachedBlobBytes int64 // 0 表示关闭 Blob 字节缓存，只缓存 Has 的存在性
+	sf                 singleflight.Group
+
+	local *shardedPresenceCache // Has 的本地第一梯队，命中了就完全不用碰 Redis
+
+	statsMu     sync.Mutex
+	blobHits    int64
+	blobMisses  int64
+	bytesServed int64
+
+	tierMu      sync.Mutex
+	localHits   int64
+	redisHits   int64
+	backendHits int64
+}
+
+// Stats 是 Has 在 LocalLRU → Redis → BackendStore 三级里各命中了多少次的快照，
+// 用来验证"热点 chunk 反复被问 Has 时，大部分流量有没有被本地缓存拦住"——
+// 跟 BlobCacheStats 的区别是那个只统计 Get 的字节缓存，这个统计的是 Has 的存在性判断
+type Stats struct {
+	LocalHits   int64
+	RedisHits   int64
+	BackendHits int64
+}
+
+// Stats 返回 Has 在三级缓存里各自命中次数的快照
+func (s *CachedStore) Stats() Stats {
+	s.tierMu.Lock()
+	defer s.tierMu.Unlock()
+	return Stats{LocalHits: s.localHits, RedisHits: s.redisHits, BackendHits: s.backendHits}
 }
+
 type Config struct {
 	RedisURL string        // 标准连接字符串: redis://<user>:<password>@<host>:<port>/<db>
 	TTL      time.Duration // 过期时间
+
+	// MaxCachedBlobBytes 是 Get 把对象原始字节缓存进 Redis 的大小上限：Commit、Tree
+	// 和小 FileNode 通常在几百字节到几 KB，反复读它们的全量 S3/OSS GET 很浪费；大
+	// Chunk 几十上百 MB，塞进 Redis 只会把内存挤爆，所以不缓存，继续走穿透。
+	// 0 表示完全关闭这层字节缓存，Get 退化为纯穿透 (历史行为)。
+	MaxCachedBlobBytes int64
 	// 未来可扩展:
 	// PoolSize int
 	// DialTimeout time.Duration
@@ -45,9 +93,11 @@ func NewCachedStore(backend storage.Store, cfg Config) (*CachedStore, error) {
 	}
 
 	return &CachedStore{
-		backend: backend,
-		client:  client,
-		ttl:     cfg.TTL,
+		backend:            backend,
+		client:             client,
+		ttl:                cfg.TTL,
+		maxCachedBlobBytes: cfg.MaxCachedBlobBytes,
+		local:              newShardedPresenceCache(0),
 	}, nil
 }
 
@@ -56,8 +106,26 @@ func (s *CachedStore) cacheKey(hash types.Hash) string {
 	return "tv:obj:" + string(hash)
 }
 
+// blobCacheKey 是 Get 用来缓存原始字节的 Key，跟 cacheKey (只记录存在性的 "tv:obj:")
+// 分开命名空间，避免 Has 的 "1" 占位值跟真实 Blob 字节混在一起
+func (s *CachedStore) blobCacheKey(hash types.Hash) string {
+	return "tv:blob:" + string(hash)
+}
+
 // Has 优先查 Redis，实现毫秒级去重
 func (s *CachedStore) Has(ctx context.Context, hash types.Hash) (bool, error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "cache.CachedStore.Has")
+	defer span.End()
+
+	// 0. 查本地进程内缓存：这一层完全不涉及网络，对 dedup 扫描里反复问同一个热点
+	// chunk 的场景（同一次 ingest 短时间内问好几次同一个 hash）最有效——连 Redis
+	// 的一次网络往返都省掉了
+	if s.local.has(hash) {
+		span.SetAttributes(attribute.Bool("cache_hit", true), attribute.String("cache_tier", "local"))
+		s.recordTierHit(&s.localHits)
+		return true, nil
+	}
+
 	key := s.cacheKey(hash)
 
 	// 1. 查 Redis
@@ -71,17 +139,25 @@ func (s *CachedStore) Has(ctx context.Context, hash types.Hash) (bool, error) {
 	} else if val > 0 {
 		// Cache Hit!
 		// 无需发起 S3 网络请求，直接返回。这是性能提升的关键。
+		span.SetAttributes(attribute.Bool("cache_hit", true), attribute.String("cache_tier", "redis"))
+		s.recordTierHit(&s.redisHits)
+		s.local.mark(hash)
 		return true, nil
 	}
 
 	// 2. 缓存未命中 (Cache Miss)，查底层存储
 	found, err := s.backend.Has(ctx, hash)
 	if err != nil {
+		span.RecordError(err)
 		return false, err
 	}
 
+	span.SetAttributes(attribute.Bool("cache_hit", false), attribute.String("cache_tier", "backend"))
+
 	// 3. 缓存回填 (Cache Fill)
 	if found {
+		s.recordTierHit(&s.backendHits)
+		s.local.mark(hash)
 		// 关键点：异步写入 Redis，不要阻塞主流程
 		// 使用 context.Background() 确保即使上层 ctx 取消，回填也能完成
 		go func() {
@@ -94,25 +170,45 @@ func (s *CachedStore) Has(ctx context.Context, hash types.Hash) (bool, error) {
 	return found, nil
 }
 
+// recordTierHit 给三级命中计数器之一加一，集中在一个方法里做加锁，避免每个调用点
+// 各自拿 tierMu 还得小心别拿错字段
+func (s *CachedStore) recordTierHit(counter *int64) {
+	s.tierMu.Lock()
+	*counter++
+	s.tierMu.Unlock()
+}
+
 // Put 上传对象。利用 Has 的缓存能力进行预检。
 func (s *CachedStore) Put(ctx context.Context, obj core.Object) error {
+	ctx, span := telemetry.Tracer.Start(ctx, "cache.CachedStore.Put")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("bytes", int64(len(obj.Bytes()))))
+
 	// 1. 利用上面的 Has 方法检查存在性
 	// 如果 Redis 里有，这一步耗时 < 1ms，直接跳过上传
 	exists, err := s.Has(ctx, obj.ID())
 	if err != nil {
+		span.RecordError(err)
 		return err
 	}
 	if exists {
+		// dedup_ratio 对单次 Put 调用只有 0/1 两种取值，聚合趋势要看 ingester.IngestFile
+		// 那条 span 上的 dedup_ratio 属性——那里才是"一份文件里有多少比例 Chunk 被去重"
+		// 这个问题真正有意义的统计粒度
+		span.SetAttributes(attribute.Bool("dedup_skip", true))
 		return nil // 幂等性：已存在
 	}
+	span.SetAttributes(attribute.Bool("dedup_skip", false))
 
 	// 2. 穿透到底层存储 (上传 S3)
 	if err := s.backend.Put(ctx, obj); err != nil {
+		span.RecordError(err)
 		return err
 	}
 
-	// 3. 写入缓存
-	// 只有 S3 上传成功了，才写 Redis
+	// 3. 写入缓存（本地 + Redis 都写，对齐 LocalLRU → Redis → BackendStore 三级
+	// 都要 write-through 的预期，而不是只等下一次 Has 懒回填本地层）
+	s.local.mark(obj.ID())
 	key := s.cacheKey(obj.ID())
 	// 这里的 Set 错误可以忽略，不影响主流程
 	s.client.Set(ctx, key, "1", s.ttl)
@@ -120,13 +216,100 @@ func (s *CachedStore) Put(ctx context.Context, obj core.Object) error {
 	return nil
 }
 
-// Get 透传 - 我们不缓存 Blob 数据
-// 原因：AI Chunk 可能很大，Redis 内存极其宝贵，只存元数据(Existence)性价比最高。
+// Get 对体积在 MaxCachedBlobBytes 以内的对象 (Commit/Tree/小 FileNode) 做字节级缓存：
+// 先查 Redis 的 "tv:blob:<hash>"，命中就直接从内存数据切片生成 Reader，完全不碰底层
+// 存储。未命中时穿透到 backend.Get，并用 singleflight 按 hash 去重——同一个冷 Commit
+// 被多个并发的 tv log/tv show 同时请求时，只会真正发起一次底层 GET，其余调用者共享
+// 同一份结果，避免打垮 S3/OSS 的 QPS。
+// 超过 MaxCachedBlobBytes 的大对象同样经过 singleflight、被整份读进内存后交给所有共享
+// 的调用者，只是读完之后不回填进 Redis (见 MaxCachedBlobBytes 的注释)。
 func (s *CachedStore) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
-	return s.backend.Get(ctx, hash)
+	if s.maxCachedBlobBytes <= 0 {
+		return s.backend.Get(ctx, hash)
+	}
+
+	key := s.blobCacheKey(hash)
+
+	// 1. 查 Redis Blob 缓存
+	data, err := s.client.Get(ctx, key).Bytes()
+	if err == nil {
+		s.recordHit(int64(len(data)))
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	if err != redis.Nil {
+		// 架构决策：跟 Has 一样的缓存故障降级，Redis 挂了不应该让读操作跟着挂
+		fmt.Printf("WARN: Redis error: %v\n", err)
+	}
+
+	// 2. 缓存未命中，singleflight 按 hash 去重，collapse 并发读同一个冷对象的请求
+	v, err, _ := s.sf.Do(string(hash), func() (interface{}, error) {
+		reader, err := s.backend.Get(ctx, hash)
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+
+		buf, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to buffer blob for caching: %w", err)
+		}
+
+		// 3. 缓存回填：只缓存体积在阈值以内的对象
+		if int64(len(buf)) <= s.maxCachedBlobBytes {
+			if setErr := s.client.Set(context.Background(), key, buf, s.ttl).Err(); setErr != nil {
+				fmt.Printf("WARN: Redis blob cache fill error: %v\n", setErr)
+			}
+		}
+
+		return buf, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.recordMiss()
+	return io.NopCloser(bytes.NewReader(v.([]byte))), nil
+}
+
+// BlobCacheStats is a point-in-time snapshot of Get's Redis blob-cache
+// effectiveness, meant to be polled by a future Prometheus exporter (see
+// pkg/cache.Stats for the equivalent on the in-process LRU layer).
+type BlobCacheStats struct {
+	Hits        int64
+	Misses      int64
+	BytesServed int64
+}
+
+// BlobCacheStats returns a snapshot of the Get blob-cache hit/miss/bytes-served counters.
+func (s *CachedStore) BlobCacheStats() BlobCacheStats {
+	s.statsMu.Lock()
+	defer s.statsMu.Unlock()
+	return BlobCacheStats{
+		Hits:        s.blobHits,
+		Misses:      s.blobMisses,
+		BytesServed: s.bytesServed,
+	}
+}
+
+func (s *CachedStore) recordHit(n int64) {
+	s.statsMu.Lock()
+	s.blobHits++
+	s.bytesServed += n
+	s.statsMu.Unlock()
+}
+
+func (s *CachedStore) recordMiss() {
+	s.statsMu.Lock()
+	s.blobMisses++
+	s.statsMu.Unlock()
 }
 
 // ExpandHash 透传
 func (s *CachedStore) ExpandHash(ctx context.Context, short types.HashPrefix) (types.Hash, error) {
 	return s.backend.ExpandHash(ctx, short)
 }
+
+// Backend 返回被装饰的底层存储，供需要拿到具体实现（比如 *disk.Adapter）的维护类命令使用
+func (s *CachedStore) Backend() storage.Store {
+	return s.backend
+}
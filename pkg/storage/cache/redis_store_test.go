@@ -1,6 +1,7 @@
 package cache
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -10,6 +11,7 @@ import (
 	"time"
 
 	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
 	"tensorvault/pkg/types"
 
 	"github.com/stretchr/testify/assert"
@@ -23,6 +25,7 @@ import (
 type SpyStore struct {
 	hasCount int32
 	putCount int32
+	getCount int32
 	objects  map[types.Hash][]byte
 }
 
@@ -44,8 +47,17 @@ func (s *SpyStore) Put(ctx context.Context, obj core.Object) error {
 	return nil
 }
 
-// 其他接口存根 (Stub)
-func (s *SpyStore) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) { return nil, nil }
+// Get 记录调用次数，返回预先 Put 过的字节，供 TestCachedStore_Get_BlobCache 统计
+// 是否真的穿透了 Redis 缓存
+func (s *SpyStore) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
+	atomic.AddInt32(&s.getCount, 1)
+	data, ok := s.objects[hash]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
 func (s *SpyStore) ExpandHash(ctx context.Context, short types.HashPrefix) (types.Hash, error) {
 	return "", nil
 }
@@ -131,4 +143,65 @@ func TestCachedStore_Integration(t *testing.T) {
 	} else {
 		t.Fatal("❌ FAILURE: Leaky abstraction, traffic hit the backend.")
 	}
+
+	// --- Step 4: 重复读同一个热点 hash 由本地进程内缓存直接回答 ---
+	// Step 2 的 Put 已经把这个 hash 记进了本地层。spy.hasCount 维持不变只说明没有穿透到
+	// backend——Redis 里本来就有这个 key，即使本地层没拦住它也不会涨；本地层确实拦住了
+	// 请求、没有再碰 Redis，由最后对 LocalHits 的断言来证明
+	for i := 0; i < 5; i++ {
+		exists, err = cachedStore.Has(ctx, hash)
+		require.NoError(t, err)
+		assert.True(t, exists)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&spy.hasCount), "backend Has() must not be called again once the local tier is warm")
+
+	stats := cachedStore.Stats()
+	assert.GreaterOrEqual(t, stats.LocalHits, int64(5), "repeated reads of a hot hash should be served by the local tier")
+}
+
+func TestCachedStore_Get_BlobCache(t *testing.T) {
+	redisAddr := "localhost:6379"
+	conn, err := net.DialTimeout("tcp", redisAddr, 1*time.Second)
+	if err != nil {
+		t.Skipf("Skipping Redis integration test: %v", err)
+	}
+	conn.Close()
+
+	ctx := context.Background()
+	spy := NewSpyStore()
+	redisURL := fmt.Sprintf("redis://%s/0", redisAddr)
+	cfg := Config{
+		RedisURL:           redisURL,
+		TTL:                1 * time.Hour,
+		MaxCachedBlobBytes: 1024,
+	}
+	cachedStore, err := NewCachedStore(spy, cfg)
+	require.NoError(t, err)
+	cachedStore.client.FlushDB(ctx)
+
+	hash := types.Hash("aaaa111122223333444455556666777788889999000011112222333344445555")
+	spy.objects[hash] = []byte("tiny tree payload")
+
+	// --- Step 1: Cache Miss，读穿透到 backend ---
+	reader, err := cachedStore.Get(ctx, hash)
+	require.NoError(t, err)
+	data, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	reader.Close()
+	assert.Equal(t, []byte("tiny tree payload"), data)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&spy.getCount), "backend Get() should be called on miss")
+
+	// --- Step 2: Cache Hit，Redis 里应该已经回填了完整字节 ---
+	reader, err = cachedStore.Get(ctx, hash)
+	require.NoError(t, err)
+	data, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	reader.Close()
+	assert.Equal(t, []byte("tiny tree payload"), data)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&spy.getCount), "backend Get() should NOT be called again on hit")
+
+	stats := cachedStore.BlobCacheStats()
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(1), stats.Misses)
+	assert.Equal(t, int64(len("tiny tree payload")), stats.BytesServed)
 }
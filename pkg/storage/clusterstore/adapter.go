@@ -0,0 +1,128 @@
+// Package clusterstore 实现了一致性哈希环下的分片存储装饰器：每个 chunk 哈希固定
+// 归属环上的某一个 peer 节点，本节点拥有的哈希直接穿透到本地 backend，其它节点
+// 拥有的哈希通过 cluster.PeerPool 转发给真正的owner——跟 pkg/storage/cache.CachedStore
+// 的关系是：CachedStore 让"每个节点都保留一份热数据的副本"，而 Adapter 让"每份数据
+// 只住在一个节点上"，两者可以叠加使用（先查本地 Cache，再决定穿透到本地盘还是转发
+// 给 peer）
+package clusterstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	tvrpc "tensorvault/pkg/api/tvrpc/v1"
+	"tensorvault/pkg/cluster"
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PeerDialer 解析一个 peer 地址对应的 PeerServiceClient。Adapter 只依赖这个接口而不是
+// 直接依赖 *cluster.PeerPool，方便测试时换成假实现
+type PeerDialer interface {
+	Get(addr string) (tvrpc.PeerServiceClient, bool)
+}
+
+// Adapter 用一致性哈希环把 Put/Get/Has 路由到本地 backend 或者对应的 peer
+type Adapter struct {
+	backend  storage.Store
+	ring     *cluster.HashRing
+	selfAddr string // 本节点在环上的地址；owner 算出来等于它时走本地 backend
+	peers    PeerDialer
+}
+
+// NewAdapter 用被装饰的本地存储 backend、一致性哈希环 ring、本节点地址 selfAddr
+// 和 peer 连接池 peers 构造一个 Adapter
+func NewAdapter(backend storage.Store, ring *cluster.HashRing, selfAddr string, peers PeerDialer) *Adapter {
+	return &Adapter{backend: backend, ring: ring, selfAddr: selfAddr, peers: peers}
+}
+
+// owner 返回 hash 的归属地址；remote=false 表示应该走本地 backend（环为空，或者
+// 归属正好就是本节点）
+func (a *Adapter) owner(hash types.Hash) (addr string, remote bool) {
+	owner, ok := a.ring.Owner(hash)
+	if !ok || owner == a.selfAddr {
+		return "", false
+	}
+	return owner, true
+}
+
+func (a *Adapter) Put(ctx context.Context, obj core.Object) error {
+	addr, remote := a.owner(obj.ID())
+	if !remote {
+		return a.backend.Put(ctx, obj)
+	}
+
+	peer, ok := a.peers.Get(addr)
+	if !ok {
+		return fmt.Errorf("clusterstore: no connection to peer %s (owner of %s)", addr, obj.ID())
+	}
+	if _, err := peer.PutChunk(ctx, &tvrpc.PutChunkRequest{Data: obj.Bytes()}); err != nil {
+		return fmt.Errorf("clusterstore: failed to put on peer %s: %w", addr, err)
+	}
+	return nil
+}
+
+func (a *Adapter) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
+	addr, remote := a.owner(hash)
+	if !remote {
+		return a.backend.Get(ctx, hash)
+	}
+
+	peer, ok := a.peers.Get(addr)
+	if !ok {
+		return nil, fmt.Errorf("clusterstore: no connection to peer %s (owner of %s)", addr, hash)
+	}
+	resp, err := peer.GetChunk(ctx, &tvrpc.GetChunkRequest{Hash: string(hash)})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("clusterstore: failed to get from peer %s: %w", addr, err)
+	}
+	return io.NopCloser(bytes.NewReader(resp.Data)), nil
+}
+
+func (a *Adapter) Has(ctx context.Context, hash types.Hash) (bool, error) {
+	addr, remote := a.owner(hash)
+	if !remote {
+		return a.backend.Has(ctx, hash)
+	}
+
+	peer, ok := a.peers.Get(addr)
+	if !ok {
+		return false, fmt.Errorf("clusterstore: no connection to peer %s (owner of %s)", addr, hash)
+	}
+	resp, err := peer.HasChunk(ctx, &tvrpc.HasChunkRequest{Hash: string(hash)})
+	if err != nil {
+		return false, fmt.Errorf("clusterstore: failed to check on peer %s: %w", addr, err)
+	}
+	return resp.Exists, nil
+}
+
+// expander 是 backend 可选实现的本地前缀展开能力，跟 cache.CachedStore.ExpandHash
+// 透传给 backend 的方式一致
+type expander interface {
+	ExpandHash(ctx context.Context, short types.HashPrefix) (types.Hash, error)
+}
+
+// ExpandHash 只在本地 backend 上展开前缀：按完整哈希路由的环没法在不知道完整哈希
+// 之前判断归属，所以只能展开本节点已经拥有的对象。其它 peer 不会被询问：只存在于
+// 别的节点上的对象展开不出来，也不参与歧义判断
+func (a *Adapter) ExpandHash(ctx context.Context, short types.HashPrefix) (types.Hash, error) {
+	exp, ok := a.backend.(expander)
+	if !ok {
+		return "", fmt.Errorf("clusterstore: backend does not support ExpandHash")
+	}
+	return exp.ExpandHash(ctx, short)
+}
+
+// Backend 返回被装饰的本地存储，供需要拿到具体实现的维护类命令使用
+func (a *Adapter) Backend() storage.Store {
+	return a.backend
+}
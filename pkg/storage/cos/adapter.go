@@ -0,0 +1,231 @@
+// Package cos 实现了基于腾讯云 COS 的 storage.Store 后端，跟 pkg/storage/oss 是
+// 同一批需求下加的姊妹实现：同样的分片 Key、同样的 Has-before-Put 幂等检查。
+//
+// 有一点跟 OSS 不一样：COS 原生只支持对 PUT 内容做 SHA-1 校验（x-cos-content-sha1），
+// 没有 OSS 那种请求级别的 SHA-256 校验头。所以这里退而求其次，把完整性校验落到
+// x-cos-meta-sha256 自定义元数据里，PUT 完之后立刻 Head 一次回读确认落地的值跟本地
+// 算出来的一致——这只能保证"服务端存下来的元数据没被截断/污染"，没法像 OSS 那样让
+// 服务端在写入前就校验 payload 本身，是个比 OSS 弱一档的保证，这里如实记录。
+package cos
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"github.com/tencentyun/cos-go-sdk-v5"
+)
+
+// multipartThreshold 跟 pkg/storage/oss 保持一致的分片上传阈值
+const multipartThreshold = 32 * 1024 * 1024 // 32MiB
+
+// partSize 分片大小；COS 要求除最后一片外每片不小于 1MiB
+const partSize = 8 * 1024 * 1024 // 8MiB
+
+// sha256MetaKey 是我们用来存放内容 SHA-256 的自定义元数据 Key，落地后会带上
+// x-cos-meta- 前缀，即 x-cos-meta-sha256
+const sha256MetaKey = "sha256"
+
+// Adapter 实现了 storage.Store 接口
+type Adapter struct {
+	client *cos.Client
+}
+
+// Config 用于初始化 Adapter
+type Config struct {
+	// BucketURL 形如 https://<bucket>-<appid>.cos.<region>.myqcloud.com
+	BucketURL string
+	SecretID  string
+	SecretKey string
+}
+
+// NewAdapter 根据 Bucket URL 和密钥构造 COS 客户端
+func NewAdapter(cfg Config) (*Adapter, error) {
+	u, err := parseBucketURL(cfg.BucketURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cos bucket url: %w", err)
+	}
+
+	client := cos.NewClient(&cos.BaseURL{BucketURL: u}, &http.Client{
+		Transport: &cos.AuthorizationTransport{
+			SecretID:  cfg.SecretID,
+			SecretKey: cfg.SecretKey,
+		},
+	})
+
+	return &Adapter{client: client}, nil
+}
+
+// transformKey 把 Hash 转换成 COS Object Key (两级目录分片，跟 pkg/storage/s3 一致)
+func transformKey(hash types.Hash) string {
+	hashStr := string(hash)
+	if len(hashStr) < 2 {
+		return hashStr
+	}
+	return hashStr[:2] + "/" + hashStr[2:]
+}
+
+// Put 上传对象并在元数据里记录 SHA-256，再回读一次确认元数据落地无误
+func (a *Adapter) Put(ctx context.Context, obj core.Object) error {
+	hash := obj.ID()
+
+	exists, err := a.Has(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("cos put existence check failed: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	data := obj.Bytes()
+	if len(data) > multipartThreshold {
+		return a.putMultipart(ctx, hash, data)
+	}
+	return a.putSingle(ctx, hash, data)
+}
+
+func (a *Adapter) putSingle(ctx context.Context, hash types.Hash, data []byte) error {
+	key := transformKey(hash)
+	sum := sha256.Sum256(data)
+	sumHex := hex.EncodeToString(sum[:])
+
+	opt := &cos.ObjectPutOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+			ContentType: "application/cbor",
+			XCosMetaXXX: &http.Header{
+				"x-cos-meta-" + sha256MetaKey: []string{sumHex},
+			},
+		},
+	}
+
+	if _, err := a.client.Object.Put(ctx, key, bytes.NewReader(data), opt); err != nil {
+		return fmt.Errorf("cos put failed: %w", err)
+	}
+
+	return a.verifyMeta(ctx, key, sumHex)
+}
+
+// verifyMeta 回读 Head 确认 x-cos-meta-sha256 元数据跟预期一致，弥补 COS 没有
+// OSS 那种请求级 SHA-256 校验头的短板
+func (a *Adapter) verifyMeta(ctx context.Context, key, wantSum string) error {
+	resp, err := a.client.Object.Head(ctx, key, nil)
+	if err != nil {
+		return fmt.Errorf("cos post-put head check failed: %w", err)
+	}
+	got := resp.Header.Get("x-cos-meta-" + sha256MetaKey)
+	if got != wantSum {
+		return fmt.Errorf("cos put integrity check failed: meta sha256 mismatch, want %s got %s", wantSum, got)
+	}
+	return nil
+}
+
+// putMultipart 把大对象切成固定大小的分片发起分片上传；任意一步失败都尝试
+// AbortMultipartUpload 清理掉服务端已接收的半成品分片
+func (a *Adapter) putMultipart(ctx context.Context, hash types.Hash, data []byte) error {
+	key := transformKey(hash)
+	sum := sha256.Sum256(data)
+	sumHex := hex.EncodeToString(sum[:])
+
+	initResult, _, err := a.client.Object.InitiateMultipartUpload(ctx, key, &cos.InitiateMultipartUploadOptions{
+		ObjectPutHeaderOptions: &cos.ObjectPutHeaderOptions{
+			ContentType: "application/cbor",
+			XCosMetaXXX: &http.Header{
+				"x-cos-meta-" + sha256MetaKey: []string{sumHex},
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("cos initiate multipart upload failed: %w", err)
+	}
+	uploadID := initResult.UploadID
+
+	var parts []cos.Object
+	for i, offset := 0, int64(0); offset < int64(len(data)); i, offset = i+1, offset+partSize {
+		end := offset + partSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		partNumber := i + 1
+		resp, err := a.client.Object.UploadPart(ctx, key, uploadID, partNumber, bytes.NewReader(data[offset:end]), nil)
+		if err != nil {
+			_, _ = a.client.Object.AbortMultipartUpload(ctx, key, uploadID)
+			return fmt.Errorf("cos upload part %d failed: %w", partNumber, err)
+		}
+		parts = append(parts, cos.Object{PartNumber: partNumber, ETag: resp.Header.Get("ETag")})
+	}
+
+	if _, _, err := a.client.Object.CompleteMultipartUpload(ctx, key, uploadID, &cos.CompleteMultipartUploadOptions{Parts: parts}); err != nil {
+		_, _ = a.client.Object.AbortMultipartUpload(ctx, key, uploadID)
+		return fmt.Errorf("cos complete multipart upload failed: %w", err)
+	}
+
+	return a.verifyMeta(ctx, key, sumHex)
+}
+
+// Get 下载对象
+func (a *Adapter) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
+	key := transformKey(hash)
+
+	resp, err := a.client.Object.Get(ctx, key, nil)
+	if err != nil {
+		if cos.IsNotFoundError(err) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("cos get failed: %w", err)
+	}
+	return resp.Body, nil
+}
+
+// Has 检查对象是否存在
+func (a *Adapter) Has(ctx context.Context, hash types.Hash) (bool, error) {
+	key := transformKey(hash)
+
+	exist, err := a.client.Object.IsExist(ctx, key)
+	if err != nil {
+		return false, fmt.Errorf("cos head failed: %w", err)
+	}
+	return exist, nil
+}
+
+// ExpandHash 利用前缀 List 扩展短哈希，语义跟 pkg/storage/s3.Adapter.ExpandHash 一致
+func (a *Adapter) ExpandHash(ctx context.Context, shortHash types.HashPrefix) (types.Hash, error) {
+	inputStr := string(shortHash)
+	if len(inputStr) < 4 {
+		return "", fmt.Errorf("hash prefix too short")
+	}
+
+	prefix := inputStr[:2] + "/" + inputStr[2:]
+
+	result, _, err := a.client.Bucket.Get(ctx, &cos.BucketGetOptions{
+		Prefix:  prefix,
+		MaxKeys: 2,
+	})
+	if err != nil {
+		return "", fmt.Errorf("cos list failed: %w", err)
+	}
+
+	if len(result.Contents) == 0 {
+		return "", storage.ErrNotFound
+	}
+	if len(result.Contents) > 1 {
+		return "", storage.ErrAmbiguousHash
+	}
+
+	hash := strings.Replace(result.Contents[0].Key, "/", "", 1)
+	return types.Hash(hash), nil
+}
+
+// parseBucketURL 解析形如 https://bucket-appid.cos.region.myqcloud.com 的 URL
+func parseBucketURL(raw string) (*url.URL, error) {
+	return url.Parse(raw)
+}
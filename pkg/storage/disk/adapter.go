@@ -1,6 +1,7 @@
 package disk
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"io"
@@ -12,6 +13,10 @@ import (
 	"tensorvault/pkg/storage"
 )
 
+// maxDeltaChainDepth 限制 Get 展开 DeltaObject 链的最大深度
+// 防止 repack 反复发生后链条无限变长，拖慢读路径延迟（对齐请求里 "bounded chain depth" 的要求）
+const maxDeltaChainDepth = 50
+
 // Adapter 实现了 storage.Store 接口
 type Adapter struct {
 	rootPath string // 比如: /home/user/.tv/objects
@@ -77,17 +82,157 @@ func (s *Adapter) Put(ctx context.Context, obj core.Object) error {
 	return nil
 }
 
+// Get 读取 hash 对应的对象。如果落盘内容其实是一个 DeltaObject（由 `tv gc --repack` 写入），
+// 会沿着 base 链透明展开，调用方拿到的始终是还原后的完整字节，无需关心它是否被 Delta 压缩过
 func (s *Adapter) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	data, err := s.readRaw(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	resolved, err := s.resolveDelta(hash, data, 0)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(resolved)), nil
+}
+
+// readRaw 读取 hash 对应的原始落盘字节，不做任何 Delta 解析
+func (s *Adapter) readRaw(hash string) ([]byte, error) {
 	targetPath := s.layout(hash)
 
-	f, err := os.Open(targetPath)
+	data, err := os.ReadFile(targetPath)
 	if os.IsNotExist(err) {
 		return nil, storage.ErrNotFound
 	}
 	if err != nil {
 		return nil, err
 	}
-	return f, nil
+	return data, nil
+}
+
+// resolveDelta 探测 data 是否是一个 DeltaObject，是的话就递归展开 base 链，直到拿到完整对象
+// 探测方式与 pkg/exporter/printer.go 的类型嗅探一致：解不出 CBOR 头就说明是原始数据，原样返回
+func (s *Adapter) resolveDelta(hash string, data []byte, depth int) ([]byte, error) {
+	var header struct {
+		TypeVal core.ObjectType `cbor:"t"`
+	}
+	if err := core.DecodeObject(data, &header); err != nil || header.TypeVal != core.TypeDelta {
+		return data, nil
+	}
+
+	if depth >= maxDeltaChainDepth {
+		return nil, fmt.Errorf("delta chain for %s exceeds max depth %d", hash, maxDeltaChainDepth)
+	}
+
+	var delta core.DeltaObject
+	if err := core.DecodeObject(data, &delta); err != nil {
+		return nil, fmt.Errorf("object %s looked like a delta but failed to decode: %w", hash, err)
+	}
+
+	baseHash := delta.BaseHash.Hash
+	baseData, err := s.readRaw(baseHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read delta base %s for %s: %w", baseHash, hash, err)
+	}
+	baseData, err = s.resolveDelta(baseHash, baseData, depth+1)
+	if err != nil {
+		return nil, err
+	}
+
+	return core.ApplyDelta(baseData, delta.Instructions, delta.TargetSize)
+}
+
+// PutRaw 把 data 原封不动地写到 hash 对应的物理路径上，绕开 core.Object.ID() 寻址
+// 仅供 `tv gc --repack` 使用：repack 需要把某个 Chunk 的内容原地重写成 DeltaObject，
+// 但读它的人依然要用这个 Chunk 原本的内容哈希来找到它，所以不能走常规的 Put(obj)
+//
+// ctx 目前未使用，只是为了满足 storage.RawStore 接口、并跟 Put/Get/Has 保持同样的签名形状
+func (s *Adapter) PutRaw(ctx context.Context, hash string, data []byte) error {
+	targetPath := s.layout(hash)
+
+	dir := filepath.Dir(targetPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	tempFile, err := os.CreateTemp(dir, "temp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if _, err := tempFile.Write(data); err != nil {
+		tempFile.Close()
+		return err
+	}
+	tempFile.Close()
+
+	return os.Rename(tempFile.Name(), targetPath)
+}
+
+// DeleteRaw 删除 hash 对应的落盘文件，不做任何存在性之外的检查
+// 供 `tv gc --pack`（对象打进 pack 之后清理 loose 副本）和 `tv gc --sweep`（物理删除
+// mark 阶段判定为不可达的对象）使用——跟 PutRaw 一样，这个能力没有必要进基础的
+// storage.Store 接口，挂在可选的 storage.RawStore 上
+func (s *Adapter) DeleteRaw(ctx context.Context, hash string) error {
+	targetPath := s.layout(hash)
+	if err := os.Remove(targetPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// isShardDir 判断一个目录名是不是 layout() 产生的两位十六进制分片目录
+func isShardDir(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	for _, c := range name {
+		if !((c >= '0' && c <= '9') || (c >= 'a' && c <= 'f')) {
+			return false
+		}
+	}
+	return true
+}
+
+// ListObjects 遍历所有分片目录，列出当前落盘的全部对象及其大小，实现 storage.Lister
+// ctx 目前未使用，只是为了满足接口、并跟 Put/Get/Has 保持同样的签名形状
+func (s *Adapter) ListObjects(ctx context.Context) ([]storage.ObjectMeta, error) {
+	var objects []storage.ObjectMeta
+
+	shards, err := os.ReadDir(s.rootPath)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, shard := range shards {
+		if !shard.IsDir() || !isShardDir(shard.Name()) {
+			// 跳过非分片目录，比如 MultiAdapter 在 rootPath 下放 pack 文件用的 "packs" 子目录
+			continue
+		}
+		shardDir := filepath.Join(s.rootPath, shard.Name())
+		entries, err := os.ReadDir(shardDir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, storage.ObjectMeta{
+				Hash:       shard.Name() + entry.Name(),
+				Size:       info.Size(),
+				ModifiedAt: info.ModTime(),
+			})
+		}
+	}
+
+	return objects, nil
 }
 
 func (s *Adapter) Has(ctx context.Context, hash string) (bool, error) {
@@ -0,0 +1,233 @@
+package disk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/storage/pack"
+	"tensorvault/pkg/telemetry"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// packsSubdir 是 pack 文件相对 Adapter 根目录的固定子目录，跟 loose 对象的分片目录
+// 平级放在一起，方便整个仓库只用一个 rootPath 配置
+const packsSubdir = "packs"
+
+// MultiAdapter 把 loose 对象 (*Adapter) 和若干个 pack.Reader 组合成一个单一的 storage.Store
+//
+// 查找顺序固定是 loose 优先、pack 其次：最近写入的对象总是先落 loose，只有被 gc.Pack
+// 显式打包过才会从 loose 目录里消失、转而只存在于某个 pack 里。这个顺序保证了新写入的
+// 对象不需要等打包就能立刻读到，同时让"已经打包"的旧对象也能透明地被找到——调用方
+// （pkg/service、pkg/treebuilder 等）完全不需要关心一个 hash 到底是 loose 还是已经被打包。
+//
+// Put/Get/Has 都挂了 telemetry.Tracer span：这是默认 storage.type（也是
+// TestPhase1_Workflow 实际跑的那条路径）的 storage.Store 实现，所以先在这里落地
+// "每个 storage.Store 实现都要有 span" 的模式；s3/oss/cos/gcs/azure 的 Adapter
+// 走的是同一套 Put/Get/Has 形状，后续有人碰它们的时候照抄这里的写法即可。
+type MultiAdapter struct {
+	loose *Adapter
+
+	packDir string
+	mu      sync.RWMutex
+	packs   []*pack.Reader
+}
+
+// NewMultiAdapter 创建一个磁盘适配器：root 下既有 loose 对象的分片目录，也有 root/packs
+// 下已经存在的 pack 文件——后者会在这里被全部打开，常驻内存的只是它们的 .idx 索引
+func NewMultiAdapter(root string) (*MultiAdapter, error) {
+	loose, err := NewAdapter(root)
+	if err != nil {
+		return nil, err
+	}
+
+	packDir := filepath.Join(root, packsSubdir)
+	if err := os.MkdirAll(packDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pack dir: %w", err)
+	}
+
+	m := &MultiAdapter{loose: loose, packDir: packDir}
+	if err := m.loadPacks(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// loadPacks 扫描 packDir 下所有的 .idx 文件，把对应的 pack 打开并加入搜索集合
+func (m *MultiAdapter) loadPacks() error {
+	entries, err := os.ReadDir(m.packDir)
+	if err != nil {
+		return fmt.Errorf("failed to scan pack dir: %w", err)
+	}
+
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".idx") {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ".idx")
+		reader, err := pack.OpenReader(pack.PackPath(m.packDir, name), pack.IdxPath(m.packDir, name))
+		if err != nil {
+			return fmt.Errorf("failed to open pack %s: %w", name, err)
+		}
+		m.packs = append(m.packs, reader)
+	}
+	return nil
+}
+
+// LooseAdapter 返回底层的 *Adapter，供 gc 之类需要 ListObjects/PutRaw/DeleteRaw 等
+// loose-only 能力的维护类命令使用
+func (m *MultiAdapter) LooseAdapter() *Adapter {
+	return m.loose
+}
+
+// ListObjects 实现 storage.Lister，委托给 loose adapter：已经打进 pack 的对象不在这份
+// 列表里——gc.Repack/gc.Sweep 只关心还没打包、值得重写/可能不可达的 loose 对象，已经
+// 打包的对象早就被 gc.Pack 判定过一轮了
+func (m *MultiAdapter) ListObjects(ctx context.Context) ([]storage.ObjectMeta, error) {
+	return m.loose.ListObjects(ctx)
+}
+
+// PutRaw/DeleteRaw 实现 storage.RawStore，同样只委托给 loose adapter：pack 文件是只读的
+// 索引+内容两个文件，没有"原地改写某一个对象"这回事
+func (m *MultiAdapter) PutRaw(ctx context.Context, hash string, data []byte) error {
+	return m.loose.PutRaw(ctx, hash, data)
+}
+
+func (m *MultiAdapter) DeleteRaw(ctx context.Context, hash string) error {
+	return m.loose.DeleteRaw(ctx, hash)
+}
+
+// PackDir 返回存放 pack 文件的目录，gc.Pack 往这里写新 pack
+func (m *MultiAdapter) PackDir() string {
+	return m.packDir
+}
+
+// AddPack 把 gc.Pack 刚刚写好的一个 pack 注册进搜索集合，不需要重启/重新打开 MultiAdapter
+func (m *MultiAdapter) AddPack(name string) error {
+	reader, err := pack.OpenReader(pack.PackPath(m.packDir, name), pack.IdxPath(m.packDir, name))
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.packs = append(m.packs, reader)
+	m.mu.Unlock()
+	return nil
+}
+
+func (m *MultiAdapter) Put(ctx context.Context, obj core.Object) error {
+	ctx, span := telemetry.Tracer.Start(ctx, "disk.MultiAdapter.Put")
+	defer span.End()
+	span.SetAttributes(attribute.Int64("bytes", int64(len(obj.Bytes()))))
+
+	// 新对象一律先落 loose；是否打包是 gc.Pack 之后才做的事
+	if err := m.loose.Put(ctx, obj); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+func (m *MultiAdapter) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "disk.MultiAdapter.Get")
+	defer span.End()
+
+	reader, err := m.loose.Get(ctx, hash)
+	if err == nil {
+		span.SetAttributes(attribute.String("source", "loose"))
+		return reader, nil
+	}
+	if err != storage.ErrNotFound {
+		span.RecordError(err)
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, p := range m.packs {
+		if reader, err := p.Get(ctx, hash); err == nil {
+			span.SetAttributes(attribute.String("source", "pack"))
+			return reader, nil
+		} else if err != storage.ErrNotFound {
+			span.RecordError(err)
+			return nil, err
+		}
+	}
+	span.SetAttributes(attribute.String("source", "miss"))
+	return nil, storage.ErrNotFound
+}
+
+func (m *MultiAdapter) Has(ctx context.Context, hash string) (bool, error) {
+	ctx, span := telemetry.Tracer.Start(ctx, "disk.MultiAdapter.Has")
+	defer span.End()
+
+	found, err := m.loose.Has(ctx, hash)
+	if err != nil {
+		span.RecordError(err)
+		return false, err
+	}
+	if found {
+		span.SetAttributes(attribute.Bool("found", true), attribute.String("source", "loose"))
+		return true, nil
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, p := range m.packs {
+		if found, err := p.Has(ctx, hash); err != nil {
+			span.RecordError(err)
+			return false, err
+		} else if found {
+			span.SetAttributes(attribute.Bool("found", true), attribute.String("source", "pack"))
+			return true, nil
+		}
+	}
+	span.SetAttributes(attribute.Bool("found", false))
+	return false, nil
+}
+
+// ExpandHash 把 loose 的分片目录和每个 pack 的 fanout 表当成一个整体来做前缀展开：
+// 任何一边多于一个匹配，或者两边合起来多于一个匹配，都视为歧义
+func (m *MultiAdapter) ExpandHash(ctx context.Context, short string) (string, error) {
+	matches := make(map[string]struct{})
+
+	if full, err := m.loose.ExpandHash(ctx, short); err == nil {
+		matches[full] = struct{}{}
+	} else if err != storage.ErrNotFound {
+		return "", err
+	}
+
+	m.mu.RLock()
+	for _, p := range m.packs {
+		found, err := p.ExpandHash(short)
+		if err != nil {
+			m.mu.RUnlock()
+			return "", err
+		}
+		for _, h := range found {
+			matches[h] = struct{}{}
+		}
+	}
+	m.mu.RUnlock()
+
+	switch len(matches) {
+	case 0:
+		return "", storage.ErrNotFound
+	case 1:
+		for h := range matches {
+			return h, nil
+		}
+	}
+
+	all := make([]string, 0, len(matches))
+	for h := range matches {
+		all = append(all, h)
+	}
+	return "", fmt.Errorf("%w: %v", storage.ErrAmbiguousHash, all)
+}
@@ -0,0 +1,341 @@
+// Package erasure 实现了一个基于 Reed–Solomon 纠删码的 storage.Store 装饰器：
+// 不像 pkg/storage/cache 那样在单个底层 Store 前面挡一层缓存，这里把每个对象横向
+// 切分到多个底层 storage.Store（本地磁盘、不同的 S3/OSS/COS 桶、远程节点……）上去，
+// 用校验分片换冗余。K=4/M=2 这样的配置只需要 (K+M)/K = 1.5x 存储，就能容忍同时丢失
+// 任意 2 个分片而不丢数据——比起三副本 (3x 存储换 "能丢 2 份" 的同等冗余度) 省下一半
+// 空间，代价是读/写都要摸 K+M 个 backend 而不是 1 个
+package erasure
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"github.com/klauspost/reedsolomon"
+)
+
+// healWriteTimeout 是 Get 发现某个分片缺失、现场重建后，把它写回去那个 backend 用的
+// 超时：这是个尽力而为的后台动作，不应该让一次慢 backend 拖住调用方已经拿到手的结果，
+// 所以跟 Get 本身的 ctx 脱钩，单独给一个有限的超时
+const healWriteTimeout = 10 * time.Second
+
+// Config 描述一个纠删码存储池的分片布局
+type Config struct {
+	// DataShards (K) 是原始数据被切成的分片数
+	DataShards int
+	// ParityShards (M) 是额外计算出的校验分片数：池子能容忍同时丢失至多 ParityShards
+	// 个分片（数据或校验皆可）而不丢数据
+	ParityShards int
+}
+
+// Store 把每个对象切成 Config.DataShards 个数据分片 + Config.ParityShards 个校验分片，
+// 分别写到 shards 里对应下标的底层 storage.Store 上：shards[i] 永远只负责分片号 i，
+// 这个对应关系在整个池的生命周期里必须保持稳定，否则 Reconstruct 会用错位的分片拼出
+// 错误的数据
+type Store struct {
+	shards []storage.Store
+	cfg    Config
+	enc    reedsolomon.Encoder
+}
+
+// NewStore 用 cfg 描述的 (K, M) 纠删布局包裹 shards；len(shards) 必须恰好等于
+// DataShards + ParityShards
+func NewStore(shards []storage.Store, cfg Config) (*Store, error) {
+	if cfg.DataShards <= 0 || cfg.ParityShards <= 0 {
+		return nil, fmt.Errorf("erasure: data and parity shard counts must both be positive")
+	}
+	total := cfg.DataShards + cfg.ParityShards
+	if len(shards) != total {
+		return nil, fmt.Errorf("erasure: expected %d backing stores (%d data + %d parity), got %d", total, cfg.DataShards, cfg.ParityShards, len(shards))
+	}
+
+	enc, err := reedsolomon.New(cfg.DataShards, cfg.ParityShards)
+	if err != nil {
+		return nil, fmt.Errorf("erasure: failed to initialize reed-solomon encoder: %w", err)
+	}
+
+	return &Store{shards: shards, cfg: cfg, enc: enc}, nil
+}
+
+// shardObject 把一段原始分片字节包装成 core.Object，好塞进底层 storage.Store.Put——
+// 它的 ID 不是内容哈希，是"这个分片在这个对象里的位置"，后面 Get/Reconstruct 全靠这个
+// 确定性的 key 去对应的 backend 按位置取回，而不是按内容寻址
+type shardObject struct {
+	id   types.Hash
+	data []byte
+}
+
+func (o shardObject) Type() core.ObjectType { return core.TypeChunk }
+func (o shardObject) ID() types.Hash        { return o.id }
+func (o shardObject) Bytes() []byte         { return o.data }
+
+// shardKey 和 metaKey 共用同一个命名空间（对象哈希 + 后缀），两者不会互相冲突，是因为
+// 真实的 Hash 摘要不包含 "."
+func shardKey(hash types.Hash, idx int) types.Hash {
+	return types.Hash(fmt.Sprintf("%s.shard%d", hash, idx))
+}
+
+func metaKey(hash types.Hash) types.Hash {
+	return types.Hash(fmt.Sprintf("%s.meta", hash))
+}
+
+// manifestMagic 是 shardManifest 序列化格式的版本标记，跟 pkg/bloom 的 Filter.Marshal
+// 是同一个套路：万一以后改编码方式，unmarshalShardManifest 能识别出不兼容的旧格式
+const manifestMagic = "TVEM1"
+
+// shardManifest 描述一个对象的分片布局，并且给每个分片都存了一份 SHA-256：readShards
+// 不能只靠"backend.Get 没报错"就认定一个分片是好的——backend 完全可能发生静默的位翻转
+// 或者截断而不报错，shardHashes 让我们能分辨出"看似存在、实际已损坏"的分片，把它们和
+// 真正缺失的分片同等对待，一并交给 Reconstruct 补救（repair-on-read）
+type shardManifest struct {
+	contentLength int64
+	shardSize     int
+	dataShards    int
+	parityShards  int
+	shardHashes   [][sha256.Size]byte
+}
+
+// marshal 序列化成: 5 字节 magic + contentLength(8) + shardSize(8) + dataShards(4) +
+// parityShards(4) + 每个分片 32 字节的 SHA-256，按分片下标顺序排列
+func (m shardManifest) marshal() []byte {
+	buf := make([]byte, 0, len(manifestMagic)+8+8+4+4+len(m.shardHashes)*sha256.Size)
+	buf = append(buf, manifestMagic...)
+
+	var u64 [8]byte
+	binary.BigEndian.PutUint64(u64[:], uint64(m.contentLength))
+	buf = append(buf, u64[:]...)
+	binary.BigEndian.PutUint64(u64[:], uint64(m.shardSize))
+	buf = append(buf, u64[:]...)
+
+	var u32 [4]byte
+	binary.BigEndian.PutUint32(u32[:], uint32(m.dataShards))
+	buf = append(buf, u32[:]...)
+	binary.BigEndian.PutUint32(u32[:], uint32(m.parityShards))
+	buf = append(buf, u32[:]...)
+
+	for _, h := range m.shardHashes {
+		buf = append(buf, h[:]...)
+	}
+	return buf
+}
+
+// unmarshalShardManifest 从 marshal 写出的字节还原一个 shardManifest
+func unmarshalShardManifest(data []byte) (shardManifest, error) {
+	const headerLen = 8 + 8 + 4 + 4
+	if len(data) < len(manifestMagic)+headerLen || string(data[:len(manifestMagic)]) != manifestMagic {
+		return shardManifest{}, fmt.Errorf("erasure: corrupted or incompatible manifest")
+	}
+
+	off := len(manifestMagic)
+	contentLength := int64(binary.BigEndian.Uint64(data[off:]))
+	off += 8
+	shardSize := int(binary.BigEndian.Uint64(data[off:]))
+	off += 8
+	dataShards := int(binary.BigEndian.Uint32(data[off:]))
+	off += 4
+	parityShards := int(binary.BigEndian.Uint32(data[off:]))
+	off += 4
+
+	total := dataShards + parityShards
+	rest := data[off:]
+	if len(rest) != total*sha256.Size {
+		return shardManifest{}, fmt.Errorf("erasure: corrupted manifest (want %d shard hashes, got %d bytes)", total, len(rest))
+	}
+
+	hashes := make([][sha256.Size]byte, total)
+	for i := 0; i < total; i++ {
+		copy(hashes[i][:], rest[i*sha256.Size:(i+1)*sha256.Size])
+	}
+
+	return shardManifest{
+		contentLength: contentLength,
+		shardSize:     shardSize,
+		dataShards:    dataShards,
+		parityShards:  parityShards,
+		shardHashes:   hashes,
+	}, nil
+}
+
+// Put 把 obj 切成 K 个数据分片，计算出 M 个校验分片，再并行写到各自的 backend 上。
+// 一份 shardManifest（原始字节长度 + 分片布局 + 每个分片的 SHA-256）被全量复制到每一
+// 个 backend——这份元数据小到可以忽略存储放大，但 Get 在 Join 阶段必须知道确切长度
+// 才能去掉 Split 时打的 padding，每个分片的哈希又是 readShards 判断"这个分片是否被
+// 静默损坏"的唯一依据，放一份都读不到就真的没法重建/校验了，所以值得在每个 backend
+// 上都存一份而不是跟数据分片一样只存一份
+func (s *Store) Put(ctx context.Context, obj core.Object) error {
+	data := obj.Bytes()
+	shards, err := s.enc.Split(data)
+	if err != nil {
+		return fmt.Errorf("erasure: failed to split %s into shards: %w", obj.ID(), err)
+	}
+	if err := s.enc.Encode(shards); err != nil {
+		return fmt.Errorf("erasure: failed to compute parity for %s: %w", obj.ID(), err)
+	}
+
+	manifest := shardManifest{
+		contentLength: int64(len(data)),
+		shardSize:     len(shards[0]),
+		dataShards:    s.cfg.DataShards,
+		parityShards:  s.cfg.ParityShards,
+		shardHashes:   make([][sha256.Size]byte, len(shards)),
+	}
+	for i, shard := range shards {
+		manifest.shardHashes[i] = sha256.Sum256(shard)
+	}
+	manifestBytes := manifest.marshal()
+
+	errs := make([]error, len(s.shards))
+	var wg sync.WaitGroup
+	for i, backend := range s.shards {
+		wg.Add(1)
+		go func(i int, backend storage.Store) {
+			defer wg.Done()
+			if err := backend.Put(ctx, shardObject{id: metaKey(obj.ID()), data: manifestBytes}); err != nil {
+				errs[i] = fmt.Errorf("failed to write manifest: %w", err)
+				return
+			}
+			errs[i] = backend.Put(ctx, shardObject{id: shardKey(obj.ID(), i), data: shards[i]})
+		}(i, backend)
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("erasure: failed to write shard %d of %s: %w", i, obj.ID(), err)
+		}
+	}
+	return nil
+}
+
+// Get 并行从每个 backend 读回分片号 i 对应的分片；凑不齐原始的 K 个也没关系，只要
+// K+M 个里至少有 K 个读到了，就用 Reconstruct 把缺的补出来，再 Join 回原始字节。
+// 补出来的分片会被懒写回它们本来所在、但这次读取失败的那个 backend（见 healShards），
+// 这样下一次 Get 同一个对象就不用再现场重建一遍
+func (s *Store) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	h := types.Hash(hash)
+	manifest, err := s.readManifest(ctx, h)
+	if err != nil {
+		return nil, err
+	}
+
+	shards, missing := s.readShards(ctx, h, manifest)
+	if present := len(shards) - len(missing); present < s.cfg.DataShards {
+		return nil, fmt.Errorf("erasure: only %d/%d shards available for %s, need at least %d to reconstruct", present, len(shards), hash, s.cfg.DataShards)
+	}
+
+	if len(missing) > 0 {
+		if err := s.enc.Reconstruct(shards); err != nil {
+			return nil, fmt.Errorf("erasure: failed to reconstruct %s: %w", hash, err)
+		}
+		s.healShards(h, shards, missing)
+	}
+
+	var buf bytes.Buffer
+	if err := s.enc.Join(&buf, shards, int(manifest.contentLength)); err != nil {
+		return nil, fmt.Errorf("erasure: failed to join shards for %s: %w", hash, err)
+	}
+	return io.NopCloser(bytes.NewReader(buf.Bytes())), nil
+}
+
+// Has 只探测元数据副本，不需要把 K+M 个数据分片都摸一遍——元数据跟数据分片总是在
+// Put 里一起写下的，查它足以回答"这个对象存在吗"
+func (s *Store) Has(ctx context.Context, hash string) (bool, error) {
+	_, err := s.readManifest(ctx, types.Hash(hash))
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// readManifest 依次（不是并行——这是一份很小的读取，犯不上为它开 K+M 个 goroutine）
+// 尝试从每个 backend 读回 obj 的 shardManifest，第一个成功的就返回
+func (s *Store) readManifest(ctx context.Context, hash types.Hash) (shardManifest, error) {
+	var lastErr error
+	for _, backend := range s.shards {
+		reader, err := backend.Get(ctx, string(metaKey(hash)))
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		data, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		manifest, err := unmarshalShardManifest(data)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return manifest, nil
+	}
+	if lastErr == nil {
+		lastErr = storage.ErrNotFound
+	}
+	return shardManifest{}, fmt.Errorf("erasure: failed to read manifest for %s: %w", hash, lastErr)
+}
+
+// readShards 并行从每个 backend 取回自己那份分片，并用 manifest 里存的 SHA-256 校验
+// 内容：取不到的（backend 下线、干脆从没写过）或者读到了但哈希对不上的（静默损坏）
+// 都在结果里留空位，下标记进 missing，交给调用方统一走 Reconstruct + healShards 补救——
+// 这就是"corrupted shard must be re-encoded and rewritten during Get"的由来：损坏的
+// 分片跟缺失的分片在这里被同等对待
+func (s *Store) readShards(ctx context.Context, hash types.Hash, manifest shardManifest) (shards [][]byte, missing []int) {
+	shards = make([][]byte, len(s.shards))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for i, backend := range s.shards {
+		wg.Add(1)
+		go func(i int, backend storage.Store) {
+			defer wg.Done()
+			reader, err := backend.Get(ctx, string(shardKey(hash, i)))
+			if err != nil {
+				mu.Lock()
+				missing = append(missing, i)
+				mu.Unlock()
+				return
+			}
+			defer reader.Close()
+			data, err := io.ReadAll(reader)
+			if err != nil || (i < len(manifest.shardHashes) && sha256.Sum256(data) != manifest.shardHashes[i]) {
+				mu.Lock()
+				missing = append(missing, i)
+				mu.Unlock()
+				return
+			}
+			shards[i] = data
+		}(i, backend)
+	}
+	wg.Wait()
+	return shards, missing
+}
+
+// healShards 把 Reconstruct 刚补出来的分片异步写回本来缺了它们的那些 backend。用
+// context.Background() 加独立超时而不是调用方的 ctx：这次 Get 已经靠重建拿到了正确
+// 结果，修复是顺手的额外工作，不应该因为调用方的 ctx 被取消就连带失败或者拖慢返回
+func (s *Store) healShards(hash types.Hash, shards [][]byte, missing []int) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), healWriteTimeout)
+		defer cancel()
+		for _, i := range missing {
+			obj := shardObject{id: shardKey(hash, i), data: shards[i]}
+			if err := s.shards[i].Put(ctx, obj); err != nil {
+				fmt.Printf("WARN: erasure: failed to heal shard %d of %s: %v\n", i, hash, err)
+			}
+		}
+	}()
+}
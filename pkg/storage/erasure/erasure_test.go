@@ -0,0 +1,137 @@
+package erasure
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"testing"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memStore 是一个最简单的内存 storage.Store，专门供本文件的分片重建测试使用
+type memStore struct {
+	objects map[types.Hash][]byte
+}
+
+func newMemStore() *memStore {
+	return &memStore{objects: make(map[types.Hash][]byte)}
+}
+
+func (m *memStore) Put(ctx context.Context, obj core.Object) error {
+	m.objects[obj.ID()] = obj.Bytes()
+	return nil
+}
+
+func (m *memStore) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	data, ok := m.objects[types.Hash(hash)]
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+func (m *memStore) Has(ctx context.Context, hash string) (bool, error) {
+	_, ok := m.objects[types.Hash(hash)]
+	return ok, nil
+}
+
+// blobObject 是测试里唯一需要的 core.Object 实现：拿一段字节当作一个待存储的对象
+type blobObject struct {
+	id   types.Hash
+	data []byte
+}
+
+func (o blobObject) Type() core.ObjectType { return core.TypeChunk }
+func (o blobObject) ID() types.Hash        { return o.id }
+func (o blobObject) Bytes() []byte         { return o.data }
+
+func newTestStore(t *testing.T, shardCount int) (*Store, []*memStore) {
+	t.Helper()
+	mems := make([]*memStore, shardCount)
+	shards := make([]storage.Store, shardCount)
+	for i := range mems {
+		mems[i] = newMemStore()
+		shards[i] = mems[i]
+	}
+	s, err := NewStore(shards, Config{DataShards: 4, ParityShards: shardCount - 4})
+	require.NoError(t, err)
+	return s, mems
+}
+
+func TestErasureStore_PutGet_RoundTrip(t *testing.T) {
+	s, _ := newTestStore(t, 6) // K=4, M=2
+	ctx := context.Background()
+
+	obj := blobObject{id: types.Hash("deadbeef"), data: []byte("a tensor shard, larger than a single reed-solomon stripe boundary")}
+	require.NoError(t, s.Put(ctx, obj))
+
+	reader, err := s.Get(ctx, string(obj.ID()))
+	require.NoError(t, err)
+	defer reader.Close()
+
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, obj.Bytes(), got)
+}
+
+func TestErasureStore_Get_ReconstructsFromMissingShards(t *testing.T) {
+	s, mems := newTestStore(t, 6) // K=4, M=2: 最多能丢 2 个分片
+	ctx := context.Background()
+
+	obj := blobObject{id: types.Hash("c0ffee"), data: []byte("weight shard content that survives losing up to M backends")}
+	require.NoError(t, s.Put(ctx, obj))
+
+	// 模拟两个 backend 掉线（正好是纠删码能容忍的上限）：直接清空它们存的分片
+	delete(mems[1].objects, shardKey(obj.ID(), 1))
+	delete(mems[4].objects, shardKey(obj.ID(), 4))
+
+	reader, err := s.Get(ctx, string(obj.ID()))
+	require.NoError(t, err)
+	defer reader.Close()
+
+	got, err := io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, obj.Bytes(), got)
+
+	// 懒修复 (healShards) 是异步的，这里不断言它一定跑完了；只验证重建本身的正确性
+}
+
+func TestErasureStore_Get_FailsWhenTooManyShardsMissing(t *testing.T) {
+	s, mems := newTestStore(t, 6) // K=4, M=2
+	ctx := context.Background()
+
+	obj := blobObject{id: types.Hash("baadf00d"), data: []byte("this one loses more shards than the pool can tolerate")}
+	require.NoError(t, s.Put(ctx, obj))
+
+	// 丢 3 个分片：超过 M=2 能容忍的上限
+	delete(mems[1].objects, shardKey(obj.ID(), 1))
+	delete(mems[3].objects, shardKey(obj.ID(), 3))
+	delete(mems[5].objects, shardKey(obj.ID(), 5))
+
+	_, err := s.Get(ctx, string(obj.ID()))
+	require.Error(t, err)
+}
+
+func TestScrubber_HealNormalScan_HealsMissingShard(t *testing.T) {
+	s, mems := newTestStore(t, 6)
+	ctx := context.Background()
+
+	obj := blobObject{id: types.Hash("f00dcafe"), data: []byte("scrubbed shard content")}
+	require.NoError(t, s.Put(ctx, obj))
+	delete(mems[2].objects, shardKey(obj.ID(), 2))
+
+	lister := func(ctx context.Context) ([]types.Hash, error) {
+		return []types.Hash{obj.ID()}, nil
+	}
+	stats, err := NewScrubber(s, lister).HealNormalScan(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, stats.Scanned)
+	assert.Equal(t, 1, stats.Healed)
+	assert.Equal(t, 0, stats.Failed)
+}
@@ -0,0 +1,109 @@
+package erasure
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// ScrubStats 汇总一次扫描的结果
+type ScrubStats struct {
+	Scanned int
+	Healed  int // 发现分片缺失、且成功靠剩余分片重建回来的对象数
+	Failed  int // 剩余分片不够重建（或者重建本身失败）的对象数，需要人工介入
+}
+
+// ProgressFunc 周期性上报扫描进度，签名跟 pkg/gc.ProgressFunc 保持一致，方便 CLI 端
+// 复用同一套打印逻辑
+type ProgressFunc func(done, total int, message string)
+
+// HashLister 返回需要巡检的全部对象哈希。Scrubber 本身不关心这份列表从哪来：可以是
+// 某个 backend 恰好是 disk.Adapter 时的 ListObjects，也可以是 pkg/meta.Repository 里
+// 记录的 Commit/FileIndex 哈希集合，由调用方按自己的部署拼出来
+type HashLister func(ctx context.Context) ([]types.Hash, error)
+
+// Scrubber 对一个 erasure.Store 做 HealNormalScan/HealDeepScan 风格的巡检：尽早发现
+// 分片丢失，而不是等到真正读不回 K 个分片、数据已经不可逆丢失时才暴露问题
+type Scrubber struct {
+	store  *Store
+	lister HashLister
+}
+
+func NewScrubber(store *Store, lister HashLister) *Scrubber {
+	return &Scrubber{store: store, lister: lister}
+}
+
+// HealNormalScan 只用 Has 探测每个对象的每个分片是否都能在自己的 backend 上找到，不
+// 下载分片内容；一旦发现某个对象有分片缺失，就跟 DeepScan 一样触发一次真正的 Get
+// （复用 Store.Get 自带的 Reconstruct + 懒写回逻辑），不在这里重新实现一遍重建
+func (s *Scrubber) HealNormalScan(ctx context.Context, onProgress ProgressFunc) (ScrubStats, error) {
+	return s.scan(ctx, false, onProgress)
+}
+
+// HealDeepScan 在 NormalScan 的基础上，即使没探测到分片缺失，也对每个对象实际 Get
+// 一遍：Has 只能确认分片文件存在，确认不了它的内容依然完整可用，只有真正 Join 一次
+// 才能发现"文件在、但已经没法正确还原"这类问题
+func (s *Scrubber) HealDeepScan(ctx context.Context, onProgress ProgressFunc) (ScrubStats, error) {
+	return s.scan(ctx, true, onProgress)
+}
+
+func (s *Scrubber) scan(ctx context.Context, deep bool, onProgress ProgressFunc) (ScrubStats, error) {
+	hashes, err := s.lister(ctx)
+	if err != nil {
+		return ScrubStats{}, fmt.Errorf("erasure: failed to list objects to scrub: %w", err)
+	}
+
+	var stats ScrubStats
+	for i, hash := range hashes {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+		stats.Scanned++
+
+		missing := s.probeMissing(ctx, hash)
+		switch {
+		case len(missing) == 0 && !deep:
+			// 分片都在，NormalScan 到此为止，没必要多花一次 Get 去读内容
+		case len(missing) == 0 && deep:
+			if _, err := s.store.Get(ctx, string(hash)); err != nil {
+				stats.Failed++
+			}
+		default:
+			if _, err := s.store.Get(ctx, string(hash)); err != nil {
+				stats.Failed++
+			} else {
+				stats.Healed++
+			}
+		}
+
+		if onProgress != nil && (i+1)%64 == 0 {
+			onProgress(i+1, len(hashes), fmt.Sprintf("scanned %d/%d objects, healed %d so far", i+1, len(hashes), stats.Healed))
+		}
+	}
+	return stats, nil
+}
+
+// probeMissing 只用 Has 探测每个分片是否存在，不下载内容——比 Store.readShards 里那套
+// 完整的 Get 便宜得多，NormalScan 的巡检开销基本就是它
+func (s *Scrubber) probeMissing(ctx context.Context, hash types.Hash) []int {
+	var mu sync.Mutex
+	var missing []int
+	var wg sync.WaitGroup
+	for i, backend := range s.store.shards {
+		wg.Add(1)
+		go func(i int, backend storage.Store) {
+			defer wg.Done()
+			ok, err := backend.Has(ctx, string(shardKey(hash, i)))
+			if err != nil || !ok {
+				mu.Lock()
+				missing = append(missing, i)
+				mu.Unlock()
+			}
+		}(i, backend)
+	}
+	wg.Wait()
+	return missing
+}
@@ -0,0 +1,160 @@
+// Package gcs 实现了基于 Google Cloud Storage 的 storage.Store 后端，结构上照搬
+// pkg/storage/s3：同样的 Hash -> "aa/bbcc..." 两级分片 Key、同样的 Has-before-Put
+// 幂等检查、同样把云厂商的 NotFound 错误映射回 storage.ErrNotFound。没有像 OSS/COS
+// 那样实现手动分片上传——GCS 客户端库自己会在内部按 ChunkSize 把大对象的写入拆成多次
+// resumable upload 请求，调用方不需要关心
+package gcs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	gcstorage "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+)
+
+// Adapter 实现了 storage.Store 接口
+type Adapter struct {
+	client *gcstorage.Client
+	bucket string
+}
+
+// Config 用于初始化 Adapter
+type Config struct {
+	Bucket string
+	// CredentialsFile 是服务账号 JSON 密钥文件路径；留空时退回 GCS 客户端库的默认凭据
+	// 链（环境变量 GOOGLE_APPLICATION_CREDENTIALS、metadata server 等）
+	CredentialsFile string
+}
+
+// NewAdapter 初始化 GCS 客户端并定位到目标 Bucket
+func NewAdapter(ctx context.Context, cfg Config) (*Adapter, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("gcs: bucket is required")
+	}
+
+	var opts []option.ClientOption
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
+	}
+
+	client, err := gcstorage.NewClient(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("gcs: failed to create client: %w", err)
+	}
+
+	return &Adapter{client: client, bucket: cfg.Bucket}, nil
+}
+
+// transformKey 把 Hash 转换成 GCS Object Key (两级目录分片，跟 pkg/storage/s3 一致)
+func transformKey(hash types.Hash) string {
+	hashStr := string(hash)
+	if len(hashStr) < 2 {
+		return hashStr
+	}
+	return hashStr[:2] + "/" + hashStr[2:]
+}
+
+func (a *Adapter) object(key string) *gcstorage.ObjectHandle {
+	return a.client.Bucket(a.bucket).Object(key)
+}
+
+// Put 上传对象。先做一次 Has 幂等检查，已存在就跳过——GCS 的写入本身不便宜，没必要为
+// 已经去重过的内容重复支付一次 resumable upload 的往返
+func (a *Adapter) Put(ctx context.Context, obj core.Object) error {
+	hash := obj.ID()
+
+	exists, err := a.Has(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("gcs put existence check failed: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	key := transformKey(hash)
+	w := a.object(key).NewWriter(ctx)
+	w.ContentType = "application/cbor"
+
+	if _, err := w.Write(obj.Bytes()); err != nil {
+		_ = w.Close()
+		return fmt.Errorf("gcs put failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("gcs put failed to finalize: %w", err)
+	}
+	return nil
+}
+
+// Get 下载对象
+func (a *Adapter) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
+	key := transformKey(hash)
+
+	r, err := a.object(key).NewReader(ctx)
+	if err != nil {
+		if errors.Is(err, gcstorage.ErrObjectNotExist) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("gcs get failed: %w", err)
+	}
+	return r, nil
+}
+
+// Has 检查对象是否存在
+func (a *Adapter) Has(ctx context.Context, hash types.Hash) (bool, error) {
+	key := transformKey(hash)
+
+	_, err := a.object(key).Attrs(ctx)
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, gcstorage.ErrObjectNotExist) {
+		return false, nil
+	}
+	return false, fmt.Errorf("gcs head failed: %w", err)
+}
+
+// ExpandHash 利用 Prefix 查询扩展短哈希，语义跟 pkg/storage/s3.Adapter.ExpandHash 完全
+// 一致：0 个结果是 NotFound，1 个是唯一解，大于 1 个是 Ambiguous
+func (a *Adapter) ExpandHash(ctx context.Context, shortHash types.HashPrefix) (types.Hash, error) {
+	inputStr := string(shortHash)
+	if len(inputStr) < 4 {
+		return "", fmt.Errorf("hash prefix too short")
+	}
+	prefix := inputStr[:2] + "/" + inputStr[2:]
+
+	it := a.client.Bucket(a.bucket).Objects(ctx, &gcstorage.Query{Prefix: prefix})
+
+	var matches []string
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return "", fmt.Errorf("gcs list failed: %w", err)
+		}
+		matches = append(matches, attrs.Name)
+		if len(matches) > 1 {
+			break
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", storage.ErrNotFound
+	}
+	if len(matches) > 1 {
+		return "", storage.ErrAmbiguousHash
+	}
+
+	hash := strings.Replace(matches[0], "/", "", 1)
+	return types.Hash(hash), nil
+}
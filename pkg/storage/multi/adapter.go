@@ -0,0 +1,199 @@
+// Package multi 实现了一个把多个 storage.Store 后端组合成一个的装饰器：跟
+// pkg/storage/erasure 纵向切分单个对象不同，这里每个对象整体只属于其中一个（或
+// 镜像模式下全部）后端，目的不是纠删冗余，而是让用户可以按权重把流量分摊到多个云厂商
+// 之间（比如从 MinIO 平滑过渡到阿里云 OSS：新对象按权重逐步切到 OSS，旧对象仍然能在
+// 原来的后端上读到），或者单纯为了镜像备份加一层读失败的自动故障转移
+package multi
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"sort"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// Mode 决定写入时如何在多个 Backend 之间分布一个对象
+type Mode string
+
+const (
+	// ModeStripe 让每个对象确定性地只写入一个 Backend（按 Hash 加权选择），整体效果
+	// 是把对象按权重条带化分布到各个后端——适合多云容量/成本分摊，不追求冗余
+	ModeStripe Mode = "stripe"
+	// ModeMirror 把每个对象并行写入全部 Backend，只要有一个失败就整体失败——用权重
+	// 决定 Get/Has 的尝试顺序，不影响 Put 的行为（镜像模式下权重只是"优先读哪个"）
+	ModeMirror Mode = "mirror"
+)
+
+// Backend 是参与组合的一个底层存储及其权重
+type Backend struct {
+	Store storage.Store
+	// Weight 必须为正数；ModeStripe 下决定对象落在这个 Backend 上的概率，ModeMirror
+	// 下决定 Get/Has 故障转移时尝试这个 Backend 的优先级（权重越高越先尝试）
+	Weight int
+}
+
+// Config 描述一组 Backend 和它们的组合方式
+type Config struct {
+	Mode     Mode
+	Backends []Backend
+}
+
+// Adapter 实现了 storage.Store 接口
+type Adapter struct {
+	mode Config
+	// order 是按 Weight 从高到低排好序的 backend 列表，Get/Has 的故障转移、
+	// ExpandHash 的遍历顺序都复用这一份，保证"权重高的先试"这条语义在各个方法间一致
+	order       []Backend
+	totalWeight int
+}
+
+// NewAdapter 用 cfg 描述的后端集合构造一个 Adapter；backend 数量不能为 0，且每个
+// Weight 都必须为正数
+func NewAdapter(cfg Config) (*Adapter, error) {
+	if len(cfg.Backends) == 0 {
+		return nil, fmt.Errorf("multi: at least one backend is required")
+	}
+	if cfg.Mode != ModeStripe && cfg.Mode != ModeMirror {
+		return nil, fmt.Errorf("multi: unknown mode %q", cfg.Mode)
+	}
+
+	totalWeight := 0
+	for i, b := range cfg.Backends {
+		if b.Weight <= 0 {
+			return nil, fmt.Errorf("multi: backend %d has non-positive weight %d", i, b.Weight)
+		}
+		totalWeight += b.Weight
+	}
+
+	order := append([]Backend(nil), cfg.Backends...)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].Weight > order[j].Weight })
+
+	return &Adapter{mode: cfg, order: order, totalWeight: totalWeight}, nil
+}
+
+// pickStripeBackend 把 hash 映射到一个确定性的 [0, totalWeight) 桶里，再按权重累加
+// 定位落在哪个 backend——同一个 hash 任何时候算出来的结果都一样，这样 Get 才能在
+// 不知道当初 Put 选了哪个 backend 的情况下，重新推算出同一个答案
+func (a *Adapter) pickStripeBackend(hash types.Hash) storage.Store {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(hash))
+	bucket := int(h.Sum32() % uint32(a.totalWeight))
+
+	acc := 0
+	for _, b := range a.mode.Backends {
+		acc += b.Weight
+		if bucket < acc {
+			return b.Store
+		}
+	}
+	// 理论上走不到这里（bucket 必然落在累加总和以内），兜底返回第一个 backend
+	return a.mode.Backends[0].Store
+}
+
+// Put 在 ModeStripe 下只写入按权重选中的那一个 backend；在 ModeMirror 下并行写入
+// 全部 backend，要求每一个都成功——跟 pkg/storage/erasure.Store.Put 的"收集错误、
+// 全部成功才算数"是同一种全有全无语义，因为镜像的意义就在于任何一份缺失都意味着
+// 将来某次故障转移读到的是旧数据或者读不到
+func (a *Adapter) Put(ctx context.Context, obj core.Object) error {
+	if a.mode.Mode == ModeStripe {
+		backend := a.pickStripeBackend(obj.ID())
+		if err := backend.Put(ctx, obj); err != nil {
+			return fmt.Errorf("multi: stripe put failed: %w", err)
+		}
+		return nil
+	}
+
+	errs := make([]error, len(a.mode.Backends))
+	done := make(chan int, len(a.mode.Backends))
+	for i, b := range a.mode.Backends {
+		go func(i int, b Backend) {
+			errs[i] = b.Store.Put(ctx, obj)
+			done <- i
+		}(i, b)
+	}
+	for range a.mode.Backends {
+		<-done
+	}
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("multi: mirror put failed on backend %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// Get 按权重从高到低依次尝试每个 backend，第一个命中的就是答案；stripe 模式下
+// 理论上只有一个 backend 真的有这个对象，但仍然遍历全部 backend 兜底——用户换了
+// 权重配置、或者手动把对象从一个后端搬到了另一个，都不应该让 Get 找不到
+func (a *Adapter) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
+	var lastErr error
+	for _, b := range a.order {
+		r, err := b.Store.Get(ctx, hash)
+		if err == nil {
+			return r, nil
+		}
+		if err != storage.ErrNotFound {
+			lastErr = err
+			continue
+		}
+	}
+	if lastErr != nil {
+		return nil, fmt.Errorf("multi: all backends failed, last error: %w", lastErr)
+	}
+	return nil, storage.ErrNotFound
+}
+
+// Has 跟 Get 共用同一套"权重优先、失败转移"的遍历顺序
+func (a *Adapter) Has(ctx context.Context, hash types.Hash) (bool, error) {
+	var lastErr error
+	for _, b := range a.order {
+		found, err := b.Store.Has(ctx, hash)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if found {
+			return true, nil
+		}
+	}
+	if lastErr != nil {
+		return false, fmt.Errorf("multi: all backends failed, last error: %w", lastErr)
+	}
+	return false, nil
+}
+
+// ExpandHash 依次询问每个 backend（按权重顺序），返回第一个给出非 ErrNotFound 结果
+// 的答案。不同 backend 各自内部的歧义判断（比如两个对象在同一个 backend 里都匹配
+// 前缀）照常由那个 backend 自己的 ExpandHash 报出来；跨 backend 的歧义（同一个
+// 前缀在两个不同 backend 上命中了不同对象）这里没有处理——因为 stripe 模式下一个
+// 对象只应该存在于一个 backend，正常运行时不会出现这种情况
+func (a *Adapter) ExpandHash(ctx context.Context, shortHash types.HashPrefix) (types.Hash, error) {
+	type expander interface {
+		ExpandHash(ctx context.Context, shortHash types.HashPrefix) (types.Hash, error)
+	}
+
+	var lastErr error
+	for _, b := range a.order {
+		e, ok := b.Store.(expander)
+		if !ok {
+			continue
+		}
+		full, err := e.ExpandHash(ctx, shortHash)
+		if err == nil {
+			return full, nil
+		}
+		if err != storage.ErrNotFound {
+			lastErr = err
+		}
+	}
+	if lastErr != nil {
+		return "", fmt.Errorf("multi: all backends failed, last error: %w", lastErr)
+	}
+	return "", storage.ErrNotFound
+}
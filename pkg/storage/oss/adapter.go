@@ -0,0 +1,201 @@
+// Package oss 实现了基于阿里云 OSS 的 storage.Store 后端，供没法用 S3/MinIO 的
+// 国内部署场景使用。整体结构照搬 pkg/storage/s3：同样的 Hash -> "aa/bbcc..." 两级
+// 分片 Key、同样的 Has-before-Put 幂等检查、同样把云厂商的 NotFound 错误映射回
+// storage.ErrNotFound。
+package oss
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"github.com/aliyun/aliyun-oss-go-sdk/oss"
+)
+
+// multipartThreshold 超过这个大小的对象走分片上传——OSS 单次 PutObject 没有强制大小上限，
+// 但大 FileNode chunk 一次性整体上传会占用单条请求太久。putMultipart 按顺序逐片上传，
+// 任意一片失败就中止整个分片上传，不会单独重传失败的那一片
+const multipartThreshold = 32 * 1024 * 1024 // 32MiB
+
+// partSize 是分片上传时每一片的大小；OSS 要求除最后一片外每片不小于 100KiB
+const partSize = 8 * 1024 * 1024 // 8MiB
+
+// Adapter 实现了 storage.Store 接口
+type Adapter struct {
+	bucket *oss.Bucket
+}
+
+// Config 用于初始化 Adapter
+type Config struct {
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	AccessKeySecret string
+}
+
+// NewAdapter 初始化 OSS 客户端并定位到目标 Bucket
+func NewAdapter(cfg Config) (*Adapter, error) {
+	opts := []oss.ClientOption{}
+	if cfg.Region != "" {
+		opts = append(opts, oss.Region(cfg.Region))
+	}
+
+	client, err := oss.New(cfg.Endpoint, cfg.AccessKeyID, cfg.AccessKeySecret, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create oss client: %w", err)
+	}
+
+	bucket, err := client.Bucket(cfg.Bucket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate oss bucket %s: %w", cfg.Bucket, err)
+	}
+
+	return &Adapter{bucket: bucket}, nil
+}
+
+// transformKey 把 Hash 转换成 OSS Object Key (两级目录分片，跟 pkg/storage/s3 一致)
+func transformKey(hash types.Hash) string {
+	hashStr := string(hash)
+	if len(hashStr) < 2 {
+		return hashStr
+	}
+	return hashStr[:2] + "/" + hashStr[2:]
+}
+
+// Put 上传对象。小对象走普通 PutObject，带上 X-Oss-Content-Sha256 头——这是 OSS V4
+// 签名里的"全量内容校验"机制，服务端会重新计算收到的 payload 的 SHA-256 并跟这个头比对，
+// 不一致直接拒绝写入，不需要我们自己读回来验证。大对象（超过 multipartThreshold）走分片
+// 上传，因为 OSS 的单次 PutObject 请求体是一次性整体发送的，太大的对象失败重传代价太高
+func (a *Adapter) Put(ctx context.Context, obj core.Object) error {
+	hash := obj.ID()
+
+	exists, err := a.Has(ctx, hash)
+	if err != nil {
+		return fmt.Errorf("oss put existence check failed: %w", err)
+	}
+	if exists {
+		return nil
+	}
+
+	data := obj.Bytes()
+	if len(data) > multipartThreshold {
+		return a.putMultipart(hash, data)
+	}
+	return a.putSingle(hash, data)
+}
+
+func (a *Adapter) putSingle(hash types.Hash, data []byte) error {
+	sum := sha256.Sum256(data)
+	key := transformKey(hash)
+
+	err := a.bucket.PutObject(key, bytes.NewReader(data),
+		oss.ContentType("application/cbor"),
+		oss.SetHeader("X-Oss-Content-Sha256", hex.EncodeToString(sum[:])),
+	)
+	if err != nil {
+		return fmt.Errorf("oss put failed: %w", err)
+	}
+	return nil
+}
+
+// putMultipart 把大对象切成固定大小的分片并发起分片上传；中途任意一步失败都会尝试
+// AbortMultipartUpload 清理掉服务端已经接收的半成品分片，避免留下占用存储空间又
+// 永远拿不到完整对象的垃圾 UploadId
+func (a *Adapter) putMultipart(hash types.Hash, data []byte) error {
+	key := transformKey(hash)
+
+	imur, err := a.bucket.InitiateMultipartUpload(key, oss.ContentType("application/cbor"))
+	if err != nil {
+		return fmt.Errorf("oss initiate multipart upload failed: %w", err)
+	}
+
+	var parts []oss.UploadPart
+	for i, offset := 0, int64(0); offset < int64(len(data)); i, offset = i+1, offset+partSize {
+		end := offset + partSize
+		if end > int64(len(data)) {
+			end = int64(len(data))
+		}
+		part, err := a.bucket.UploadPart(imur, bytes.NewReader(data[offset:end]), end-offset, i+1)
+		if err != nil {
+			_ = a.bucket.AbortMultipartUpload(imur)
+			return fmt.Errorf("oss upload part %d failed: %w", i+1, err)
+		}
+		parts = append(parts, part)
+	}
+
+	if _, err := a.bucket.CompleteMultipartUpload(imur, parts); err != nil {
+		_ = a.bucket.AbortMultipartUpload(imur)
+		return fmt.Errorf("oss complete multipart upload failed: %w", err)
+	}
+	return nil
+}
+
+// Get 下载对象
+func (a *Adapter) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
+	key := transformKey(hash)
+
+	body, err := a.bucket.GetObject(key)
+	if err != nil {
+		if isNotExist(err) {
+			return nil, storage.ErrNotFound
+		}
+		return nil, fmt.Errorf("oss get failed: %w", err)
+	}
+	return body, nil
+}
+
+// Has 检查对象是否存在
+func (a *Adapter) Has(ctx context.Context, hash types.Hash) (bool, error) {
+	key := transformKey(hash)
+
+	exist, err := a.bucket.IsObjectExist(key)
+	if err != nil {
+		return false, fmt.Errorf("oss head failed: %w", err)
+	}
+	return exist, nil
+}
+
+// ExpandHash 利用前缀 List 扩展短哈希，语义跟 pkg/storage/s3.Adapter.ExpandHash 完全一致：
+// 0 个结果是 NotFound，1 个是唯一解，大于 1 个是 Ambiguous
+func (a *Adapter) ExpandHash(ctx context.Context, shortHash types.HashPrefix) (types.Hash, error) {
+	inputStr := string(shortHash)
+	if len(inputStr) < 4 {
+		return "", fmt.Errorf("hash prefix too short")
+	}
+
+	prefix := inputStr[:2] + "/" + inputStr[2:]
+
+	result, err := a.bucket.ListObjectsV2(oss.Prefix(prefix), oss.MaxKeys(2))
+	if err != nil {
+		return "", fmt.Errorf("oss list failed: %w", err)
+	}
+
+	if len(result.Objects) == 0 {
+		return "", storage.ErrNotFound
+	}
+	if len(result.Objects) > 1 {
+		return "", storage.ErrAmbiguousHash
+	}
+
+	hash := strings.Replace(result.Objects[0].Key, "/", "", 1)
+	return types.Hash(hash), nil
+}
+
+// isNotExist 把 OSS SDK 的 404 错误识别出来，映射回 storage.ErrNotFound
+func isNotExist(err error) bool {
+	var svcErr oss.ServiceError
+	if errors.As(err, &svcErr) {
+		return svcErr.StatusCode == 404
+	}
+	return strings.Contains(err.Error(), "404")
+}
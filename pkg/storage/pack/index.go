@@ -0,0 +1,153 @@
+package pack
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sort"
+
+	"tensorvault/pkg/types"
+)
+
+const (
+	idxMagic   = "TVIDX"
+	idxVersion = 2
+	hashHexLen = 64 // SHA-256 十六进制长度，跟 types.Hash 的约定一致
+	fanoutSize = 256
+)
+
+// indexEntry 是 idx 文件里一条排序后的 hash -> pack 文件内字节偏移量 + 长度记录。
+// Length 跟 pack 条目头里的长度字段是重复信息，但这份冗余换来的是 Get 不需要先 seek
+// 读一次 entryHeaderLen 字节才知道该读多少——一次 seek+read 就能拿到完整 payload
+type indexEntry struct {
+	Hash   types.Hash
+	Offset uint64
+	Length uint32
+}
+
+// fanoutBucket 把 hash 的前两个十六进制字符解析成一个 0-255 的桶号
+// 这跟 disk.Adapter.layout 用同样两位做分片目录是同一个思路：先用第一个字节把搜索范围
+// 缩小到 1/256，再在桶内做二分查找，定位 O(1) 摊销到 O(log(N/256))
+func fanoutBucket(hash types.Hash) int {
+	s := hash.String()
+	if len(s) < 2 {
+		return 0
+	}
+	b, err := hex.DecodeString(s[:2])
+	if err != nil || len(b) == 0 {
+		return 0
+	}
+	return int(b[0])
+}
+
+// writeIndex 把已经按 Hash 排序好的 entries 连同 fanout 表写到 path，末尾追加一个
+// CRC32 校验和（对 magic 到最后一条 entry 为止的全部字节计算），跟 git 的 .idx 文件
+// 结尾放 packfile 和 idx 自身两个校验和是同一个思路——这里只做了一个，因为 idx 内容
+// 本身就完全由 pack 文件重新派生得到，不需要额外一份 pack-checksum 来交叉验证
+func writeIndex(path string, entries []indexEntry) error {
+	var fanout [fanoutSize]uint32
+	for _, e := range entries {
+		bucket := fanoutBucket(e.Hash)
+		for i := bucket; i < fanoutSize; i++ {
+			fanout[i]++
+		}
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create pack index %s: %w", path, err)
+	}
+	defer f.Close()
+
+	crc := crc32.NewIEEE()
+	w := bufio.NewWriter(io.MultiWriter(f, crc))
+	if _, err := w.WriteString(idxMagic); err != nil {
+		return err
+	}
+	if err := w.WriteByte(idxVersion); err != nil {
+		return err
+	}
+	for _, count := range fanout {
+		if err := binary.Write(w, binary.BigEndian, count); err != nil {
+			return err
+		}
+	}
+	for _, e := range entries {
+		if len(e.Hash) != hashHexLen {
+			return fmt.Errorf("pack: refusing to index malformed hash %q", e.Hash)
+		}
+		if _, err := w.WriteString(e.Hash.String()); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, e.Offset); err != nil {
+			return err
+		}
+		if err := binary.Write(w, binary.BigEndian, e.Length); err != nil {
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return binary.Write(f, binary.BigEndian, crc.Sum32())
+}
+
+// readIndex 把 path 指向的 idx 文件整份读进内存，返回 fanout 表和按 Hash 排序的 entries
+//
+// 一个 idx 文件只有"hash + offset"，哪怕 pack 里有几十万个对象也就几十 MB，比它对应的
+// pack 文件本身小得多——直接读进内存省掉了引入平台相关 mmap 系统调用的复杂度，实际效果
+// 跟"内存映射"要达到的目的（避免每次查找都走一次文件系统调用）是一样的
+func readIndex(path string) ([fanoutSize]uint32, []indexEntry, error) {
+	var fanout [fanoutSize]uint32
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fanout, nil, fmt.Errorf("failed to read pack index %s: %w", path, err)
+	}
+
+	if len(data) < len(idxMagic)+1+4 || string(data[:len(idxMagic)]) != idxMagic {
+		return fanout, nil, fmt.Errorf("pack: %s is not a valid index file", path)
+	}
+
+	// 最后 4 字节是写入时算好的 CRC32，覆盖它之前的全部字节——先校验再解析，
+	// 避免对着一份已经损坏的文件按字节偏移瞎解析出看似合理实则错误的 entries
+	body := data[:len(data)-4]
+	wantCRC := binary.BigEndian.Uint32(data[len(data)-4:])
+	if gotCRC := crc32.ChecksumIEEE(body); gotCRC != wantCRC {
+		return fanout, nil, fmt.Errorf("pack: index %s failed CRC32 checksum (corrupted)", path)
+	}
+
+	pos := len(idxMagic)
+	version := body[pos]
+	pos++
+	if version != idxVersion {
+		return fanout, nil, fmt.Errorf("pack: unsupported index version %d in %s", version, path)
+	}
+
+	for i := 0; i < fanoutSize; i++ {
+		fanout[i] = binary.BigEndian.Uint32(body[pos : pos+4])
+		pos += 4
+	}
+
+	total := fanout[fanoutSize-1]
+	entries := make([]indexEntry, 0, total)
+	entrySize := hashHexLen + 8 + 4
+	for pos+entrySize <= len(body) {
+		hash := types.Hash(body[pos : pos+hashHexLen])
+		offset := binary.BigEndian.Uint64(body[pos+hashHexLen : pos+hashHexLen+8])
+		length := binary.BigEndian.Uint32(body[pos+hashHexLen+8 : pos+entrySize])
+		entries = append(entries, indexEntry{Hash: hash, Offset: offset, Length: length})
+		pos += entrySize
+	}
+
+	// entries 在写入时已经排过序，这里的校验只是防止 idx 文件被手工改坏后静默产生错误结果
+	if !sort.SliceIsSorted(entries, func(i, j int) bool { return entries[i].Hash < entries[j].Hash }) {
+		return fanout, nil, fmt.Errorf("pack: index %s is not sorted by hash", path)
+	}
+
+	return fanout, entries, nil
+}
@@ -0,0 +1,109 @@
+package pack
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type mockObject struct {
+	id      types.Hash
+	data    []byte
+	objType core.ObjectType
+}
+
+func (m mockObject) ID() types.Hash        { return m.id }
+func (m mockObject) Bytes() []byte         { return m.data }
+func (m mockObject) Type() core.ObjectType { return m.objType }
+
+func TestWriterReader_RoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	objects := []mockObject{
+		{id: "1111111111111111111111111111111111111111111111111111111111111111", data: []byte("chunk one"), objType: core.TypeChunk},
+		{id: "2222222222222222222222222222222222222222222222222222222222222222", data: []byte("a tree blob"), objType: core.TypeTree},
+		{id: "3333333333333333333333333333333333333333333333333333333333333333", data: []byte("a commit blob"), objType: core.TypeCommit},
+	}
+
+	w, err := NewWriter(dir)
+	require.NoError(t, err)
+	for _, obj := range objects {
+		require.NoError(t, w.Add(obj))
+	}
+	require.Equal(t, len(objects), w.Len())
+
+	name, err := w.Finish()
+	require.NoError(t, err)
+
+	r, err := OpenReader(PackPath(dir, name), IdxPath(dir, name))
+	require.NoError(t, err)
+	defer r.Close()
+
+	for _, obj := range objects {
+		ok, err := r.Has(ctx, obj.id.String())
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		reader, err := r.Get(ctx, obj.id.String())
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, obj.data, data)
+
+		objType, size, ok := r.Stat(obj.id.String())
+		require.True(t, ok)
+		assert.Equal(t, obj.objType, objType)
+		assert.Equal(t, int64(len(obj.data)), size)
+	}
+
+	ok, err := r.Has(ctx, "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	err = r.Put(ctx, objects[0])
+	assert.Error(t, err, "pack readers must be read-only")
+}
+
+func TestReader_ExpandHash(t *testing.T) {
+	dir := t.TempDir()
+
+	w, err := NewWriter(dir)
+	require.NoError(t, err)
+	obj := mockObject{id: "abcd111111111111111111111111111111111111111111111111111111111111", data: []byte("x"), objType: core.TypeChunk}
+	require.NoError(t, w.Add(obj))
+	name, err := w.Finish()
+	require.NoError(t, err)
+
+	r, err := OpenReader(PackPath(dir, name), IdxPath(dir, name))
+	require.NoError(t, err)
+	defer r.Close()
+
+	matches, err := r.ExpandHash("abcd")
+	require.NoError(t, err)
+	require.Len(t, matches, 1)
+	assert.Equal(t, obj.id.String(), matches[0])
+
+	_, err = r.ExpandHash("ab")
+	assert.Error(t, err, "prefixes shorter than 4 chars must be rejected")
+}
+
+func TestWriter_FinishWithNoEntriesFails(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewWriter(dir)
+	require.NoError(t, err)
+
+	_, err = w.Finish()
+	assert.Error(t, err)
+
+	entries, globErr := filepath.Glob(filepath.Join(dir, "*"))
+	require.NoError(t, globErr)
+	assert.Empty(t, entries, "an empty pack must not leave a temp file behind")
+}
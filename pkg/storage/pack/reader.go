@@ -0,0 +1,155 @@
+package pack
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// Reader 是一个只读的 storage.Store 实现，对应一个 pack-<hash>.tv + pack-<hash>.idx 文件对
+//
+// Open 时把 .idx 整份读进内存（参见 readIndex 的注释），Get/Has 都只需要一次 fanout 查表
+// + 桶内二分查找就能定位到 pack 文件里的字节偏移，再 seek 一次读出 payload
+type Reader struct {
+	file    *os.File
+	mu      sync.Mutex // os.File.Seek + Read 不是并发安全的组合操作
+	fanout  [fanoutSize]uint32
+	entries []indexEntry // 按 Hash 排序
+}
+
+// OpenReader 打开 packPath/idxPath 这一对 pack 文件
+func OpenReader(packPath, idxPath string) (*Reader, error) {
+	fanout, entries, err := readIndex(idxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(packPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pack file %s: %w", packPath, err)
+	}
+
+	return &Reader{file: f, fanout: fanout, entries: entries}, nil
+}
+
+// Close 关闭底层的 pack 文件句柄
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
+
+// Len 返回这个 pack 里的对象数量
+func (r *Reader) Len() int {
+	return len(r.entries)
+}
+
+// find 在 entries 里查找 hash 精确匹配的记录，用 fanout 表把搜索范围先缩小到对应的桶
+func (r *Reader) find(hash string) (indexEntry, bool) {
+	bucket := fanoutBucket(types.Hash(hash))
+	lo := 0
+	if bucket > 0 {
+		lo = int(r.fanout[bucket-1])
+	}
+	hi := int(r.fanout[bucket])
+
+	idx := sort.Search(hi-lo, func(i int) bool {
+		return r.entries[lo+i].Hash.String() >= hash
+	})
+	idx += lo
+
+	if idx < hi && r.entries[idx].Hash.String() == hash {
+		return r.entries[idx], true
+	}
+	return indexEntry{}, false
+}
+
+// Has 检查 hash 是否在这个 pack 里
+func (r *Reader) Has(ctx context.Context, hash string) (bool, error) {
+	_, ok := r.find(hash)
+	return ok, nil
+}
+
+// Get 读取 hash 对应的对象原始字节。pack 内部存的是 core.Object.Bytes() 的原样拷贝，
+// 调用方拿到的数据跟从 disk.Adapter 读到的完全一致，上层（core.DecodeObject）不需要
+// 关心对象到底是来自 loose 文件还是某个 pack
+func (r *Reader) Get(ctx context.Context, hash string) (io.ReadCloser, error) {
+	entry, ok := r.find(hash)
+	if !ok {
+		return nil, storage.ErrNotFound
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// entry.Length 来自 idx（已经过 CRC32 校验），直接 seek 到 payload 起点读取，
+	// 不需要像 v1 格式那样先读一次条目头才知道该读多少字节
+	if _, err := r.file.Seek(int64(entry.Offset)+entryHeaderLen, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("pack: failed to seek to %s: %w", hash, err)
+	}
+	payload := make([]byte, entry.Length)
+	if _, err := io.ReadFull(r.file, payload); err != nil {
+		return nil, fmt.Errorf("pack: failed to read entry payload for %s: %w", hash, err)
+	}
+
+	return io.NopCloser(bytes.NewReader(payload)), nil
+}
+
+// Stat 返回 hash 对应对象的类型和大小，不读取 payload——供只关心分布统计、不关心内容的
+// 调用方（比如 gc 扫描一个 pack 里各类型对象占比）使用，省掉一次不必要的 payload 拷贝
+func (r *Reader) Stat(hash string) (core.ObjectType, int64, bool) {
+	entry, ok := r.find(hash)
+	if !ok {
+		return "", 0, false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 类型码是 pack 条目头里唯一 idx 没有冗余存一份的字段，只需要读 1 个字节；
+	// 长度直接用 idx 里已经校验过的 entry.Length，不用再读一次 8 字节的头部长度
+	if _, err := r.file.Seek(int64(entry.Offset), io.SeekStart); err != nil {
+		return "", 0, false
+	}
+	var typeByte [1]byte
+	if _, err := io.ReadFull(r.file, typeByte[:]); err != nil {
+		return "", 0, false
+	}
+	return codeType[typeByte[0]], int64(entry.Length), true
+}
+
+// Put 总是失败：pack 一旦写定就是只读的，新对象应该先落 loose，攒够了再由 gc.Pack
+// 重新打包——这跟 disk.Adapter 的 PutRaw 只给 gc 用、不进 storage.Store 接口是同一个道理
+func (r *Reader) Put(ctx context.Context, obj core.Object) error {
+	return fmt.Errorf("pack: store is read-only, cannot Put %s", obj.ID())
+}
+
+// ExpandHash 在这一个 pack 的索引范围内做前缀匹配（前缀至少 4 位），返回这个 pack 里
+// 所有匹配的完整 hash；它不报歧义，唯一还是歧义由调用方根据返回的个数判断
+func (r *Reader) ExpandHash(short string) ([]string, error) {
+	if len(short) < 4 {
+		return nil, fmt.Errorf("hash prefix too short (min 4 chars)")
+	}
+
+	bucket := fanoutBucket(types.Hash(short))
+	lo := 0
+	if bucket > 0 {
+		lo = int(r.fanout[bucket-1])
+	}
+	hi := int(r.fanout[bucket])
+
+	var matches []string
+	for _, e := range r.entries[lo:hi] {
+		if strings.HasPrefix(e.Hash.String(), short) {
+			matches = append(matches, e.Hash.String())
+		}
+	}
+	return matches, nil
+}
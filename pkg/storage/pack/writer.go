@@ -0,0 +1,161 @@
+// Package pack 实现把大量零散小对象合并存放的 pack 文件格式，参考 go-git 的
+// plumbing/format/packfile + idxfile 设计。
+//
+// disk.Adapter 的"一个对象一个文件"布局在对象数量达到百万级时会把 inode 和目录项用光，
+// 也会让 rsync/备份这类按文件遍历的工具慢得不成样子。pack 把一批对象顺序拼进一个
+// pack-<hash>.tv 文件，旁边配一个 pack-<hash>.idx 做 hash -> 偏移量的索引，用跟
+// disk.Adapter 两位十六进制分片同样的思路做了一张 fanout 表，查找是一次 fanout 查表加
+// 桶内二分查找。
+package pack
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"tensorvault/pkg/core"
+)
+
+const (
+	packMagic      = "TVPK"
+	packVersion    = 1
+	entryHeaderLen = 1 + 8 // 1 字节类型码 + 8 字节大端长度
+)
+
+// typeCode 把 core.ObjectType 映射成 pack 条目头里的一个字节，这样扫描 pack 文件统计
+// 对象类型分布时不需要把每条 payload 都解一遍 CBOR
+var typeCode = map[core.ObjectType]byte{
+	core.TypeChunk:    0,
+	core.TypeFileNode: 1,
+	core.TypeTree:     2,
+	core.TypeCommit:   3,
+	core.TypeDelta:    4,
+}
+
+var codeType = func() map[byte]core.ObjectType {
+	m := make(map[byte]core.ObjectType, len(typeCode))
+	for t, c := range typeCode {
+		m[c] = t
+	}
+	return m
+}()
+
+// Writer 把一批 core.Object 顺序写成一个 pack 文件，外加一份排序后的 .idx 索引
+//
+// 用法：NewWriter -> 多次 Add -> Finish。pack 文件内容的 Hash 在 Finish 时才能确定，
+// 所以写入过程中先落到一个临时文件，Finish 成功后才按内容 Hash 正式重命名——这跟
+// disk.Adapter.Put 先写临时文件再 Rename 的幂等写入思路是一致的
+type Writer struct {
+	dir     string
+	file    *os.File
+	hasher  hasher
+	buf     *bufio.Writer
+	offset  uint64
+	entries []indexEntry
+}
+
+// hasher 是 writer 需要的最小哈希接口，避免直接依赖 hash.Hash 的全部方法
+type hasher interface {
+	io.Writer
+	Sum(b []byte) []byte
+}
+
+// NewWriter 在 dir 下准备好一个新 pack 的临时文件
+func NewWriter(dir string) (*Writer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create pack dir: %w", err)
+	}
+
+	f, err := os.CreateTemp(dir, "pack-*.tmp")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pack temp file: %w", err)
+	}
+
+	h := sha256.New()
+	buf := bufio.NewWriter(io.MultiWriter(f, h))
+
+	w := &Writer{dir: dir, file: f, hasher: h, buf: buf}
+	if _, err := w.buf.WriteString(packMagic); err != nil {
+		f.Close()
+		return nil, err
+	}
+	if err := w.buf.WriteByte(packVersion); err != nil {
+		f.Close()
+		return nil, err
+	}
+	w.offset = uint64(len(packMagic)) + 1
+
+	return w, nil
+}
+
+// Add 把一个对象追加到 pack 流里，记录它在文件中的偏移量供 Finish 写索引使用
+func (w *Writer) Add(obj core.Object) error {
+	code, ok := typeCode[obj.Type()]
+	if !ok {
+		return fmt.Errorf("pack: unsupported object type %q", obj.Type())
+	}
+	payload := obj.Bytes()
+
+	entryOffset := w.offset
+	if err := w.buf.WriteByte(code); err != nil {
+		return err
+	}
+	if err := binary.Write(w.buf, binary.BigEndian, uint64(len(payload))); err != nil {
+		return err
+	}
+	if _, err := w.buf.Write(payload); err != nil {
+		return err
+	}
+	w.offset += uint64(entryHeaderLen) + uint64(len(payload))
+
+	w.entries = append(w.entries, indexEntry{Hash: obj.ID(), Offset: entryOffset, Length: uint32(len(payload))})
+	return nil
+}
+
+// Len 返回目前已经写入的对象数量
+func (w *Writer) Len() int {
+	return len(w.entries)
+}
+
+// Finish 刷盘、按内容 Hash 给 pack 文件正式命名，并在旁边写出排序好的 .idx 文件
+// 返回这个 pack 的名字（不含扩展名），形如 "pack-<hash>"
+func (w *Writer) Finish() (string, error) {
+	if len(w.entries) == 0 {
+		w.file.Close()
+		os.Remove(w.file.Name())
+		return "", fmt.Errorf("pack: refusing to finish an empty pack")
+	}
+
+	if err := w.buf.Flush(); err != nil {
+		w.file.Close()
+		return "", err
+	}
+	if err := w.file.Close(); err != nil {
+		return "", err
+	}
+
+	name := "pack-" + hex.EncodeToString(w.hasher.Sum(nil))
+
+	finalPath := filepath.Join(w.dir, name+".tv")
+	if err := os.Rename(w.file.Name(), finalPath); err != nil {
+		return "", fmt.Errorf("failed to finalize pack file: %w", err)
+	}
+
+	sort.Slice(w.entries, func(i, j int) bool { return w.entries[i].Hash < w.entries[j].Hash })
+	idxPath := filepath.Join(w.dir, name+".idx")
+	if err := writeIndex(idxPath, w.entries); err != nil {
+		return "", err
+	}
+
+	return name, nil
+}
+
+// PackPath/IdxPath 返回 dir 下某个 pack 名字对应的两个文件路径
+func PackPath(dir, name string) string { return filepath.Join(dir, name+".tv") }
+func IdxPath(dir, name string) string  { return filepath.Join(dir, name+".idx") }
@@ -17,12 +17,18 @@ import (
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"golang.org/x/sync/errgroup"
 )
 
 // Adapter 实现了 storage.Store 接口
 type Adapter struct {
 	client *s3.Client
 	bucket string
+
+	// sse 为 nil 表示没有配置 SSE-C；非 nil 时 Put/Get/Has 以及分片上传的每个请求都会
+	// 带上这三个头。ExpandHash 用的 ListObjectsV2 不受影响——SSE-C 只挡"读对象内容/元数据"，
+	// 不挡"列出有哪些 Key"，这也是 sharding 前缀扫描在启用 SSE-C 之后还能继续工作的原因
+	sse *sseParams
 }
 
 // Config 用于初始化 Adapter
@@ -32,6 +38,14 @@ type Config struct {
 	Bucket          string
 	AccessKeyID     string
 	SecretAccessKey string
+
+	// EncryptionKey 非空时启用 SSE-C（客户端托管密钥的服务端加密），必须恰好 32 字节。
+	// 跟 KeyProvider 同时设置时 KeyProvider 优先——EncryptionKey 只是"把一个 staticKeyProvider
+	// 包起来"的语法糖，方便最常见的"密钥就是一段配置"场景不用手写 KeyProvider 实现
+	EncryptionKey []byte
+
+	// KeyProvider 是 EncryptionKey 的替代品，用于密钥来自环境变量/文件/KMS 等场景
+	KeyProvider KeyProvider
 }
 
 // NewAdapter 初始化 S3 客户端 (适配 AWS SDK v2 最新规范)
@@ -74,10 +88,75 @@ func NewAdapter(ctx context.Context, cfg Config) (*Adapter, error) {
 		}
 	}
 
-	return &Adapter{
+	// 4. (可选) SSE-C：解析密钥、算好 base64(key)/base64(md5(key))，缓存在 Adapter 上
+	// 供每次请求复用，不用每次 Put/Get 都重新算一遍
+	var sse *sseParams
+	keyProvider := cfg.KeyProvider
+	if keyProvider == nil && len(cfg.EncryptionKey) > 0 {
+		keyProvider = staticKeyProvider{key: cfg.EncryptionKey}
+	}
+	if keyProvider != nil {
+		key, err := keyProvider.SSECustomerKey(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("sse: failed to resolve customer key: %w", err)
+		}
+		sse, err = newSSEParams(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	adapter := &Adapter{
 		client: client,
 		bucket: cfg.Bucket,
-	}, nil
+		sse:    sse,
+	}
+
+	// 5. (可选) Canary 校验：如果桶里已经有对象是用另一把密钥加密的，SSE-C 的错误只在
+	// 访问那个对象的时候才会暴露（"key doesn't match"），而不是在 NewAdapter 这里——
+	// 写一个固定 Key 的探针对象、立刻用同一把密钥读回来，能在连接阶段就把这类配置错误
+	// 挡住，而不是等到某次业务 Get 失败才发现密钥配错了
+	if sse != nil {
+		if err := adapter.checkEncryptionKeyCanary(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return adapter, nil
+}
+
+// sseCanaryKey 是固定的探针对象 Key，不会跟任何真实的 Hash 分片路径（"aa/bbcc..."）
+// 冲突
+const sseCanaryKey = "_tensorvault_sse_canary"
+
+// checkEncryptionKeyCanary 用当前密钥写一个探针对象（如果还不存在的话），然后立刻用
+// 同一把密钥读回来。如果桶里已经有一个用不同密钥写的探针，读回来会因为 SSE-C 密钥
+// 不匹配失败，此时我们明确报错而不是任由后续每一次 Get 都费解地失败
+func (s *Adapter) checkEncryptionKeyCanary(ctx context.Context) error {
+	_, err := s.client.HeadObject(ctx, s.applySSEHead(&s3.HeadObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(sseCanaryKey),
+	}))
+	if err == nil {
+		return nil // 探针已经存在，而且用当前密钥能读——说明密钥是对的
+	}
+
+	var notFound *s3types.NotFound
+	if !errors.As(err, &notFound) {
+		// 探针存在，但读不出来：最可能的原因就是桶里的对象是用另一把密钥加密的
+		return fmt.Errorf("sse: bucket %q appears to contain objects encrypted with a different key (canary check failed): %w", s.bucket, err)
+	}
+
+	// 探针不存在，用当前密钥创建一个，供下次 NewAdapter 校验
+	_, err = s.client.PutObject(ctx, s.applySSEPut(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(sseCanaryKey),
+		Body:   bytes.NewReader([]byte("tensorvault-sse-canary")),
+	}))
+	if err != nil {
+		return fmt.Errorf("sse: failed to write canary object: %w", err)
+	}
+	return nil
 }
 
 // transformKey 将 Hash 转换为 S3 Key (Sharding)
@@ -90,7 +169,10 @@ func (s *Adapter) transformKey(hash types.Hash) string {
 	return hashStr[:2] + "/" + hashStr[2:]
 }
 
-// Put 上传对象
+// Put 上传对象。实现上委托给 PutStream（已知长度的 bytes.Reader）：Chunk/FileNode
+// 大多数时候都很小，PutStream 会自己在 size 低于 multipartThreshold 时退化成单次
+// PutObject，跟原来的行为完全一致；只有碰巧配置了很大 MaxSize 的超大 Chunk，或者
+// 序列化后体积可观的 FileNode，才会真正走到下面 multipart.go 里的分片上传
 func (s *Adapter) Put(ctx context.Context, obj core.Object) error {
 	// 1. 幂等性检查 (去重)
 	// 对于 S3，Head 请求比 Put 请求便宜且快。如果已存在，直接跳过。
@@ -102,20 +184,45 @@ func (s *Adapter) Put(ctx context.Context, obj core.Object) error {
 		return nil
 	}
 
-	key := s.transformKey(obj.ID())
 	data := obj.Bytes()
+	return s.PutStream(ctx, obj.ID().String(), bytes.NewReader(data), int64(len(data)))
+}
 
-	// 2. 执行上传
-	_, err = s.client.PutObject(ctx, &s3.PutObjectInput{
-		Bucket: aws.String(s.bucket),
-		Key:    aws.String(key),
-		Body:   bytes.NewReader(data),
-		// 标记 Content-Type 有助于在浏览器中预览，虽然对逻辑无影响
-		ContentType: aws.String("application/cbor"),
-	})
+// PutBatch 实现 storage.BatchStore：并发上传一整批对象，复用同一个 s3.Client（连接池已经
+// 在 SDK 内部维护，不需要每个对象单独握手）。调用方（pkg/ingester）在攒批之前已经做过
+// Bloom Filter 去重，这里就不重复 Put 的 Has 幂等检查了，直接上传。单个对象失败不取消
+// 整批——失败的连同下标一起收进 *storage.BatchError，让调用方只重试失败的那几个
+func (s *Adapter) PutBatch(ctx context.Context, objs []core.Object) error {
+	errs := make([]error, len(objs))
+
+	g, gctx := errgroup.WithContext(ctx)
+	for i, obj := range objs {
+		g.Go(func() error {
+			key := s.transformKey(obj.ID())
+			_, err := s.client.PutObject(gctx, s.applySSEPut(&s3.PutObjectInput{
+				Bucket:      aws.String(s.bucket),
+				Key:         aws.String(key),
+				Body:        bytes.NewReader(obj.Bytes()),
+				ContentType: aws.String("application/cbor"),
+			}))
+			if err != nil {
+				// 故意不 return err：一个对象失败不应该通过 errgroup 取消其它还在飞的上传，
+				// 失败原因单独记到 errs[i] 里，批结束后统一报告
+				errs[i] = fmt.Errorf("s3 batch put failed for %s: %w", obj.ID(), err)
+			}
+			return nil
+		})
+	}
+	_ = g.Wait() //nolint:errcheck // 每个 goroutine 从不返回 error，失败已经记在 errs 里了
 
-	if err != nil {
-		return fmt.Errorf("s3 put failed: %w", err)
+	var failures []storage.BatchFailure
+	for i, err := range errs {
+		if err != nil {
+			failures = append(failures, storage.BatchFailure{Index: i, Err: err})
+		}
+	}
+	if len(failures) > 0 {
+		return &storage.BatchError{Failures: failures}
 	}
 	return nil
 }
@@ -124,10 +231,10 @@ func (s *Adapter) Put(ctx context.Context, obj core.Object) error {
 func (s *Adapter) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, error) {
 	key := s.transformKey(hash)
 
-	resp, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+	resp, err := s.client.GetObject(ctx, s.applySSEGet(&s3.GetObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
-	})
+	}))
 
 	if err != nil {
 		// 将 AWS 的 NoSuchKey 错误映射为我们自己的 ErrNotFound
@@ -145,10 +252,10 @@ func (s *Adapter) Get(ctx context.Context, hash types.Hash) (io.ReadCloser, erro
 func (s *Adapter) Has(ctx context.Context, hash types.Hash) (bool, error) {
 	key := s.transformKey(hash)
 
-	_, err := s.client.HeadObject(ctx, &s3.HeadObjectInput{
+	_, err := s.client.HeadObject(ctx, s.applySSEHead(&s3.HeadObjectInput{
 		Bucket: aws.String(s.bucket),
 		Key:    aws.String(key),
-	})
+	}))
 
 	if err == nil {
 		return true, nil
@@ -167,7 +274,9 @@ func (s *Adapter) Has(ctx context.Context, hash types.Hash) (bool, error) {
 	return false, err
 }
 
-// ExpandHash 利用 Prefix 查询扩展短哈希
+// ExpandHash 利用 Prefix 查询扩展短哈希。即使启用了 SSE-C，这里也不需要带任何
+// SSECustomerKey* 头——ListObjectsV2 返回的只是 Key 名字，不碰对象内容或加密元数据，
+// SSE-C 挡的是 Get/Head 这类要读内容的请求，列举 Key 不受影响
 func (s *Adapter) ExpandHash(ctx context.Context, shortHash types.HashPrefix) (types.Hash, error) {
 	inputStr := string(shortHash)
 	if len(inputStr) < 4 {
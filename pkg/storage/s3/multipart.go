@@ -0,0 +1,156 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"tensorvault/pkg/types"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultPartSize 是分片上传每一片的大小，8 MiB 是 AWS 官方 SDK Uploader 用的同一个
+	// 默认值——分片太小会让分片数接近 S3 一万片的硬上限，太大则丧失了"失败只重传一片"的
+	// 好处
+	defaultPartSize = 8 * 1024 * 1024
+
+	// multipartThreshold 等于 defaultPartSize：切不出第二片就没有分片上传的意义，
+	// 单次 PutObject 往返更便宜
+	multipartThreshold = defaultPartSize
+
+	// partUploadWorkers 控制单个对象内部并发上传多少个分片。特意调得比
+	// ingester.WorkerCount 小：分片上传的并发是"对象内部"的维度，会跟 ingester 对多个
+	// Chunk 的外层并发叠乘，16 x 16 对连接池和出口带宽的压力太大
+	partUploadWorkers = 4
+)
+
+// PutStream 实现 storage.StreamingStore。size 低于 multipartThreshold 时退化为单次
+// PutObject（小对象没必要多付三次往返的分片协议开销）；达到阈值则用
+// CreateMultipartUpload + 并发 UploadPart + CompleteMultipartUpload。ctx 被取消或
+// 任意一片上传失败时，都会尝试 AbortMultipartUpload 清理掉已经落在 S3 侧的分片，
+// 避免留下既不完整、也永远收不到 Complete 的"幽灵"分片占用存储配额
+func (s *Adapter) PutStream(ctx context.Context, hash string, r io.Reader, size int64) error {
+	key := s.transformKey(types.Hash(hash))
+
+	if size < multipartThreshold {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("s3 put stream: failed to buffer object %s: %w", hash, err)
+		}
+		_, err = s.client.PutObject(ctx, s.applySSEPut(&s3.PutObjectInput{
+			Bucket:      aws.String(s.bucket),
+			Key:         aws.String(key),
+			Body:        bytes.NewReader(data),
+			ContentType: aws.String("application/cbor"),
+		}))
+		if err != nil {
+			return fmt.Errorf("s3 put stream failed: %w", err)
+		}
+		return nil
+	}
+
+	return s.putStreamMultipart(ctx, key, hash, r, size)
+}
+
+// putStreamMultipart 承担 PutStream 里真正的分片上传逻辑，拆成单独一个函数只是为了
+// 让 PutStream 本身的"小对象走捷径"分支不被大段分片代码淹没
+func (s *Adapter) putStreamMultipart(ctx context.Context, key, hash string, r io.Reader, size int64) error {
+	created, err := s.client.CreateMultipartUpload(ctx, s.applySSECreateMultipart(&s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(s.bucket),
+		Key:         aws.String(key),
+		ContentType: aws.String("application/cbor"),
+	}))
+	if err != nil {
+		return fmt.Errorf("s3 put stream: failed to start multipart upload for %s: %w", hash, err)
+	}
+	uploadID := created.UploadId
+
+	// abort 用独立的 context.Background()：ctx 本身多半就是导致我们要 abort 的那个
+	// 已取消/已超时的 context，用它去发 AbortMultipartUpload 请求十有八九会立刻失败
+	abort := func() {
+		_, abortErr := s.client.AbortMultipartUpload(context.Background(), &s3.AbortMultipartUploadInput{
+			Bucket:   aws.String(s.bucket),
+			Key:      aws.String(key),
+			UploadId: uploadID,
+		})
+		if abortErr != nil {
+			fmt.Printf("Warning: failed to abort multipart upload for %s: %v\n", hash, abortErr)
+		}
+	}
+
+	numParts := int((size + defaultPartSize - 1) / defaultPartSize)
+
+	var mu sync.Mutex
+	parts := make([]s3types.CompletedPart, 0, numParts)
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.SetLimit(partUploadWorkers)
+
+	// r 是单个 io.Reader，不支持并发 Read，所以分片数据必须按顺序读出来；真正值得
+	// 并发展开的是上传本身（网络 I/O 才是瓶颈），所以读取留在主循环里串行做，
+	// 读完一片就把"上传这一片"丢给 worker 池
+	for partNum := int32(1); int64(partNum) <= int64(numParts); partNum++ {
+		remaining := size - int64(partNum-1)*defaultPartSize
+		n := int64(defaultPartSize)
+		if remaining < n {
+			n = remaining
+		}
+
+		buf := make([]byte, n)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			abort()
+			return fmt.Errorf("s3 put stream: failed to read part %d for %s: %w", partNum, hash, err)
+		}
+
+		partNum := partNum
+		g.Go(func() error {
+			resp, err := s.client.UploadPart(gctx, s.applySSEUploadPart(&s3.UploadPartInput{
+				Bucket:     aws.String(s.bucket),
+				Key:        aws.String(key),
+				UploadId:   uploadID,
+				PartNumber: aws.Int32(partNum),
+				Body:       bytes.NewReader(buf),
+			}))
+			if err != nil {
+				return fmt.Errorf("part %d: %w", partNum, err)
+			}
+			mu.Lock()
+			parts = append(parts, s3types.CompletedPart{ETag: resp.ETag, PartNumber: aws.Int32(partNum)})
+			mu.Unlock()
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		abort()
+		return fmt.Errorf("s3 put stream: multipart upload failed for %s: %w", hash, err)
+	}
+	if ctx.Err() != nil {
+		abort()
+		return ctx.Err()
+	}
+
+	// CompleteMultipartUpload 要求 Parts 严格按 PartNumber 升序；worker 池并发完成的
+	// 顺序跟分片编号顺序无关，这里补一次排序
+	sort.Slice(parts, func(i, j int) bool { return *parts[i].PartNumber < *parts[j].PartNumber })
+
+	_, err = s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(s.bucket),
+		Key:             aws.String(key),
+		UploadId:        uploadID,
+		MultipartUpload: &s3types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		abort()
+		return fmt.Errorf("s3 put stream: failed to complete multipart upload for %s: %w", hash, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package s3
+
+import (
+	"context"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSSEParams_RejectsWrongKeyLength(t *testing.T) {
+	_, err := newSSEParams([]byte("too-short"))
+	assert.Error(t, err)
+}
+
+func TestNewSSEParams_ComputesAlgorithmAndMD5(t *testing.T) {
+	key := make([]byte, 32)
+	for i := range key {
+		key[i] = byte(i)
+	}
+
+	params, err := newSSEParams(key)
+	require.NoError(t, err)
+	assert.Equal(t, "AES256", params.algorithm)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(key), params.keyB64)
+	assert.NotEmpty(t, params.keyMD5B64)
+}
+
+func TestEnvKeyProvider_DecodesBase64Key(t *testing.T) {
+	key := make([]byte, 32)
+	t.Setenv("TV_TEST_SSE_KEY", base64.StdEncoding.EncodeToString(key))
+
+	p := EnvKeyProvider{EnvVar: "TV_TEST_SSE_KEY"}
+	got, err := p.SSECustomerKey(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, key, got)
+}
+
+func TestEnvKeyProvider_ErrorsWhenUnset(t *testing.T) {
+	p := EnvKeyProvider{EnvVar: "TV_TEST_SSE_KEY_MISSING"}
+	_, err := p.SSECustomerKey(context.Background())
+	assert.Error(t, err)
+}
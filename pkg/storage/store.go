@@ -3,7 +3,9 @@ package storage
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"time"
 
 	"tensorvault/pkg/core"
 )
@@ -30,3 +32,104 @@ type Store interface {
 	// Delete (可选，MVP 阶段可以先不实现，因为 CAS 通常只增不删)
 	// Delete(ctx context.Context, hash string) error
 }
+
+// Decorator 是装饰器型 Store（pkg/storage/cache.CachedStore/LRUStore、pkg/cache.CachingStore）
+// 共同满足的窄接口：只声明 Backend() 是为了避开 import 环——那几个包反过来导入
+// tensorvault/pkg/storage，没法在这里直接引用它们的具体类型。UnwrapDecorator 配合它
+// 使用，取代以前在 pkg/gc/pkg/ingester 里各自手写一遍的 "case *cache.CachedStore: ...
+// case *cache.LRUStore: ... case *objcache.CachingStore: ..." 分支
+type Decorator interface {
+	Store
+	Backend() Store
+}
+
+// UnwrapDecorator 剥开一层 Decorator，拿到它包装的下一个 Store；store 不是（已知的）
+// 装饰器时返回 ok=false。调用方通常是在顺着链条找一个可选能力接口（见下面 Lister/
+// RawStore 的用法），找到就停，找不到就再剥一层，直到 UnwrapDecorator 也报 false 为止
+func UnwrapDecorator(store Store) (Store, bool) {
+	if d, ok := store.(Decorator); ok {
+		return d.Backend(), true
+	}
+	return nil, false
+}
+
+// BatchStore 是一个可选的能力接口：像 S3 多对象上传、或者一个用单个事务落盘的
+// Postgres/Bolt 后端，批量写入比调用方在外面循环 Put 便宜得多（省掉重复的 TLS 握手/
+// DB 往返）。调用方应该用类型断言探测这个接口，探测不到就照常退化为逐个 Put——
+// 跟 UnwrapDecorator 的解包模式反过来：那边是"顺着装饰器链找到具体能力"，
+// 这里是"接口直接声明一个可选能力"，因为批量写入不是某一个具体实现独占的，很多
+// 后端都能做
+type BatchStore interface {
+	Store
+
+	// PutBatch 尝试一次性写入 objs。部分对象失败时必须返回 *BatchError，把失败对象在
+	// objs 里的下标报出来，而不是让调用方去猜到底是哪几个；调用方可以据此只重试失败的
+	//那部分，不用把整批重新发一遍
+	PutBatch(ctx context.Context, objs []core.Object) error
+}
+
+// StreamingStore 是一个可选的能力接口：像 S3 这类对象存储，大对象应该走分片上传而不是
+// 一次性把整个 Body 塞进单次 PutObject——不仅要扛住单次请求体积上限，也避免调用方为了
+// 拼出一份完整 Body 而被迫先把整个对象读进内存。跟 BatchStore 同样的理由：不是所有后端
+// 都受益于分片上传（disk.Adapter 直接 io.Copy 到文件就是最优解），所以这是一个可选接口，
+// 调用方用类型断言探测，探测不到就照常退化为 Put
+type StreamingStore interface {
+	Store
+
+	// PutStream 把 hash 对应的内容从 r 里读出并持久化，size 是内容总长度（调用方必须
+	// 预先知道——分片上传要提前规划分多少片）。实现应该在 size 低于某个阈值时退化为
+	// 单次整体写入，只有真正的大对象才值得付分片上传的额外往返开销
+	PutStream(ctx context.Context, hash string, r io.Reader, size int64) error
+}
+
+// ObjectMeta 描述一个落盘/落库对象的元信息，不含内容本身——供 Lister.ListObjects 的
+// 调用方（目前是 pkg/gc）决定要不要进一步 Get 它，不必为了拿元信息就先把内容读出来
+type ObjectMeta struct {
+	Hash       string
+	Size       int64
+	ModifiedAt time.Time
+}
+
+// Lister 是一个可选的能力接口：枚举这个 backend 当前持有的全部对象。不是所有后端都适合
+// 实现它——列出一个对象存储桶里的全部 key 往往意味着翻页扫描整个桶，不像本地磁盘那样
+// 只是遍历一下分片目录——所以跟 BatchStore/StreamingStore 一样用可选接口表达，调用方
+// 用类型断言探测。pkg/gc 的 Sweep/Repack 需要枚举整个对象空间来构建候选集合，以前
+// 硬编码只认 *disk.Adapter，现在改成认这个接口：任何后端（s3/oss/cos/gcs/azure 的
+// Adapter 将来需要时）都可以自己实现 ListObjects 来参与 gc，不需要 pkg/gc 再加一个 case
+type Lister interface {
+	Store
+
+	// ListObjects 列出全部对象及其元信息，这是一次全量扫描，只应该被后台维护类命令
+	// （如 gc）调用，不要在热路径上用它
+	ListObjects(ctx context.Context) ([]ObjectMeta, error)
+}
+
+// RawStore 是第二个可选的能力接口：按调用方给定的 hash 直接读/写/删内容，绕开 Put 那套
+// "从 obj.ID() 重新推出路径" 的寻址方式。pkg/gc 需要它：Repack 把一个 Chunk 的内容原地
+// 重写成 DeltaObject，但必须继续能用这个 Chunk 原本的哈希找到它（不是 DeltaObject 自己
+// 的 CID）；Sweep 需要物理删除 mark 阶段判定为不可达的对象——这两件事都没法通过
+// Put（总是用 obj.ID() 重新算路径）或者 Store 接口里那个还没实现的 Delete 表达
+type RawStore interface {
+	Lister
+
+	// PutRaw 把 data 原封不动地写到 hash 对应的位置，不做任何寻址之外的解释
+	PutRaw(ctx context.Context, hash string, data []byte) error
+
+	// DeleteRaw 删除 hash 对应的内容，不做存在性之外的检查
+	DeleteRaw(ctx context.Context, hash string) error
+}
+
+// BatchFailure 记录一次 PutBatch 里失败的对象：它在原始切片里的下标，以及失败原因
+type BatchFailure struct {
+	Index int
+	Err   error
+}
+
+// BatchError 由 PutBatch 在部分（或全部）对象写入失败时返回
+type BatchError struct {
+	Failures []BatchFailure
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("batch put failed for %d object(s)", len(e.Failures))
+}
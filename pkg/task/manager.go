@@ -0,0 +1,264 @@
+package task
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"tensorvault/pkg/meta"
+)
+
+const (
+	// heartbeatInterval 是 worker 在执行任务期间刷新心跳的周期
+	heartbeatInterval = 5 * time.Second
+
+	// staleAfter 是 running 任务被视为"worker 已经死了"的心跳超时时间
+	// 必须明显大于 heartbeatInterval，避免把正常运行中、只是还没来得及刷新心跳的任务误判为僵死
+	staleAfter = 30 * time.Second
+
+	// pollInterval 是 worker 在队列里没有 pending 任务时的轮询间隔
+	pollInterval = 500 * time.Millisecond
+)
+
+// Manager 管理一个 worker 池，从 Postgres 里的 tasks 表认领并执行任务
+type Manager struct {
+	repo    *meta.Repository
+	workers int
+
+	mu       sync.Mutex
+	handlers map[Type]Handler
+	cancels  map[string]context.CancelFunc // 正在本进程内执行的任务 -> 它的 cancel 函数
+
+	wg sync.WaitGroup
+}
+
+// NewManager 创建一个任务管理器；workers 是并发执行任务的 goroutine 数量
+func NewManager(repo *meta.Repository, workers int) *Manager {
+	if workers <= 0 {
+		workers = 1
+	}
+	return &Manager{
+		repo:     repo,
+		workers:  workers,
+		handlers: make(map[Type]Handler),
+		cancels:  make(map[string]context.CancelFunc),
+	}
+}
+
+// Handle 注册某种任务类型的处理函数。必须在 Start 之前调用
+func (m *Manager) Handle(t Type, h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers[t] = h
+}
+
+// Start 启动 worker 池
+// 启动前会先把心跳过期的 running 任务重新打回 pending ——上次进程退出时没能跑完的任务，
+// 这次（或被别的 worker）重新捡起来继续跑
+func (m *Manager) Start(ctx context.Context) error {
+	reclaimed, err := m.repo.ReclaimStaleTasks(ctx, staleAfter)
+	if err != nil {
+		return fmt.Errorf("failed to reclaim stale tasks: %w", err)
+	}
+	if reclaimed > 0 {
+		log.Printf("⚙️  [task] resumed %d task(s) left running by a previous process\n", reclaimed)
+	}
+
+	for i := 0; i < m.workers; i++ {
+		workerID := fmt.Sprintf("worker-%d", i)
+		m.wg.Add(1)
+		go m.runWorker(ctx, workerID)
+	}
+	return nil
+}
+
+// Wait 阻塞直到所有 worker goroutine 退出（ctx 被取消之后）
+func (m *Manager) Wait() {
+	m.wg.Wait()
+}
+
+// Submit 把一个新任务写入队列，返回它的 ID
+func (m *Manager) Submit(ctx context.Context, t Type, payload any) (string, error) {
+	id, err := newTaskID()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal task payload: %w", err)
+	}
+
+	now := time.Now()
+	model := &meta.TaskModel{
+		ID:          id,
+		Type:        string(t),
+		PayloadJSON: data,
+		Status:      string(StatusPending),
+		HeartbeatAt: now,
+	}
+	if err := m.repo.CreateTask(ctx, model); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// Status 返回任务的当前状态
+func (m *Manager) Status(ctx context.Context, id string) (*Job, error) {
+	model, err := m.repo.GetTask(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return toJob(model), nil
+}
+
+// List 返回最近的任务，供 `tv jobs ls` 使用
+func (m *Manager) List(ctx context.Context, limit int) ([]Job, error) {
+	models, err := m.repo.ListTasks(ctx, limit)
+	if err != nil {
+		return nil, err
+	}
+	jobs := make([]Job, 0, len(models))
+	for i := range models {
+		jobs = append(jobs, *toJob(&models[i]))
+	}
+	return jobs, nil
+}
+
+// Cancel 请求取消一个任务：如果它正在本进程内跑，直接调用它的 context.CancelFunc；
+// 无论如何都会在数据库里打上 cancel_requested 标记，这样即使任务是被另一个进程认领的，
+// 它下一次检查 Reporter.Cancelled() 时也会看到
+func (m *Manager) Cancel(ctx context.Context, id string) error {
+	m.mu.Lock()
+	cancel, running := m.cancels[id]
+	m.mu.Unlock()
+	if running {
+		cancel()
+	}
+	return m.repo.RequestTaskCancel(ctx, id)
+}
+
+func toJob(model *meta.TaskModel) *Job {
+	var lines []string
+	if len(model.Log) > 0 {
+		_ = json.Unmarshal(model.Log, &lines)
+	}
+	return &Job{
+		ID:       model.ID,
+		Type:     Type(model.Type),
+		Status:   Status(model.Status),
+		Progress: model.Progress,
+		Message:  model.Message,
+		Error:    model.Error,
+		Log:      lines,
+	}
+}
+
+// runWorker 是单个 worker 的主循环：反复认领任务，跑完一个再认领下一个
+func (m *Manager) runWorker(ctx context.Context, workerID string) {
+	defer m.wg.Done()
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			model, err := m.repo.ClaimNextTask(ctx, workerID)
+			if err != nil {
+				continue // 没任务可抢，或者抢输了，下个 tick 再试
+			}
+			m.execute(ctx, workerID, model)
+		}
+	}
+}
+
+// execute 跑一个已经被认领的任务，负责心跳、进度上报、取消和最终状态落盘
+func (m *Manager) execute(parent context.Context, workerID string, model *meta.TaskModel) {
+	m.mu.Lock()
+	handler, ok := m.handlers[Type(model.Type)]
+	m.mu.Unlock()
+	if !ok {
+		_ = m.repo.FinishTask(parent, model.ID, string(StatusFailed), fmt.Sprintf("no handler registered for task type %q", model.Type))
+		return
+	}
+
+	taskCtx, cancel := context.WithCancel(parent)
+	m.mu.Lock()
+	m.cancels[model.ID] = cancel
+	m.mu.Unlock()
+	defer func() {
+		m.mu.Lock()
+		delete(m.cancels, model.ID)
+		m.mu.Unlock()
+		cancel()
+	}()
+
+	stopHeartbeat := m.startHeartbeat(taskCtx, model.ID)
+	defer stopHeartbeat()
+
+	reporter := &taskReporter{repo: m.repo, id: model.ID}
+	err := handler(taskCtx, json.RawMessage(model.PayloadJSON), reporter)
+
+	switch {
+	case err == nil:
+		_ = m.repo.FinishTask(parent, model.ID, string(StatusSucceeded), "")
+	case err == ErrCancelled || reporter.Cancelled():
+		_ = m.repo.FinishTask(parent, model.ID, string(StatusCancelled), "")
+	default:
+		_ = m.repo.FinishTask(parent, model.ID, string(StatusFailed), err.Error())
+	}
+}
+
+// startHeartbeat 定期把任务的 heartbeat_at 往后推，防止它在还在正常运行时被别的进程
+// 误判为僵死任务而重新派发。返回一个停止函数
+func (m *Manager) startHeartbeat(ctx context.Context, id string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(heartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = m.repo.TouchTaskHeartbeat(ctx, id)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// taskReporter 是 Reporter 接口针对 Manager 的实现，直接写回 Postgres
+type taskReporter struct {
+	repo *meta.Repository
+	id   string
+
+	mu        sync.Mutex
+	cancelled bool
+}
+
+func (r *taskReporter) Progress(pct int, message string) {
+	_ = r.repo.UpdateTaskProgress(context.Background(), r.id, pct, message)
+}
+
+func (r *taskReporter) Log(line string) {
+	_ = r.repo.AppendTaskLog(context.Background(), r.id, line)
+}
+
+func (r *taskReporter) Cancelled() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancelled {
+		return true
+	}
+	model, err := r.repo.GetTask(context.Background(), r.id)
+	if err == nil && model.CancelRequested {
+		r.cancelled = true
+	}
+	return r.cancelled
+}
@@ -0,0 +1,80 @@
+// Package task 实现一个由 Postgres 支撑的持久化任务队列（参考 cloudreve 的 task 包思路）
+//
+// 长耗时操作（上传、checkout、gc --repack、构建 Merkle Tree）不再堵塞调用方：
+// 提交一个 Job，worker 池从 tasks 表里认领任务去跑，调用方可以随时查询进度，或者请求
+// 协作式取消。进程重启后，worker 会把心跳过期的 "running" 任务重新打回 "pending"，
+// 保证它们最终会被别的（或重启后的同一个）worker 跑完。
+package task
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+)
+
+// Type 标识一个任务的类型，决定由哪个 Handler 处理
+type Type string
+
+const (
+	TypeUpload    Type = "upload"     // 分片上传一个本地文件
+	TypeCheckout  Type = "checkout"   // 还原一个 Tree 到工作区
+	TypeGCRepack  Type = "gc_repack"  // 对象存储的 delta 压缩
+	TypeTreeBuild Type = "tree_build" // 从 Index 构建 Merkle Tree
+	TypeCommit    Type = "commit"     // 构建 Tree（如果需要）并记录一个 Commit，推进 HEAD
+)
+
+// Status 是任务的生命周期状态
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+	StatusCancelled Status = "cancelled"
+)
+
+// ErrCancelled 由 Handler 在检测到取消请求时返回，Manager 会据此把任务标成 StatusCancelled
+// 而不是 StatusFailed
+var ErrCancelled = fmt.Errorf("task cancelled")
+
+// Job 是 meta.TaskModel 面向调用方的只读投影
+type Job struct {
+	ID       string
+	Type     Type
+	Status   Status
+	Progress int
+	Message  string
+	Error    string
+	Log      []string
+}
+
+// Reporter 是 Handler 在执行期间上报进度、写日志的唯一入口
+// Handler 不应该直接碰 meta.Repository：这样无论 worker 跑在进程内还是（未来）跑在独立的
+// worker 进程里，Handler 的实现都不用变
+type Reporter interface {
+	// Progress 更新 0-100 的完成度和一句人类可读描述
+	Progress(pct int, message string)
+	// Log 追加一行日志，供 `tv jobs logs <id>` 查看
+	Log(line string)
+	// Cancelled 报告调用方是否已经请求取消——Handler 应该在耗时循环里周期性检查它
+	Cancelled() bool
+}
+
+// Handler 执行一种任务类型的具体逻辑
+// payload 是 Submit 时传入对象的 JSON 编码；Handler 负责自己 Unmarshal 成具体类型
+// Handler 必须让 ctx 和 Reporter.Cancelled() 贯穿到它调用的 chunker/store/exporter 里，
+// 取消请求才能真正停下正在进行的 I/O，而不是等到下一个任务再生效
+type Handler func(ctx context.Context, payload json.RawMessage, r Reporter) error
+
+// newTaskID 生成一个任务 ID：16 字节随机数的十六进制表示，足够在单个仓库内避免碰撞，
+// 不需要为此引入一个完整的 uuid 依赖
+func newTaskID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate task id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
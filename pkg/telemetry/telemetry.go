@@ -0,0 +1,12 @@
+// Package telemetry 提供全项目统一的 OpenTelemetry Tracer。
+//
+// ingester/exporter/cache/storage 各处埋点都从这里取 Tracer，而不是各自去调用
+// otel.Tracer("...")，保证所有 span 共享同一个 instrumentation scope 名字——这样在
+// Jaeger/Tempo 里按服务过滤的时候，不会出现"同一个二进制里冒出好几个互不相关的
+// instrumentation scope"这种噪音。
+package telemetry
+
+import "go.opentelemetry.io/otel"
+
+// Tracer 是整个 tensorvault 进程内统一使用的 Tracer 实例
+var Tracer = otel.Tracer("tensorvault")
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"tensorvault/pkg/core"
+	"tensorvault/pkg/ignore"
 	"tensorvault/pkg/index"
 	"tensorvault/pkg/storage"
 	"tensorvault/pkg/types"
@@ -15,11 +16,40 @@ import (
 
 // Builder 负责将暂存区转换为 Merkle Tree
 type Builder struct {
-	store storage.Store
+	store   storage.Store
+	hasher  core.Hasher
+	ignorer *ignore.Matcher // 可选，见 WithIgnoreMatcher
 }
 
-func NewBuilder(store storage.Store) *Builder {
-	return &Builder{store: store}
+func NewBuilder(store storage.Store, hasher core.Hasher) *Builder {
+	return &Builder{store: store, hasher: hasher}
+}
+
+// WithIgnoreMatcher 给 Builder 挂上一个 .tvignore 匹配器，Build 时会跳过匹配到的 Index 条目
+// `tv add` 已经在写入 Index 之前做过一次忽略检查，这里是第二道防线：万一有条目绕过 add
+// 直接进了 Index（比如脚本拼装、旧版本留下的脏数据），也不会被带进 Merkle Tree。
+// 不是所有调用方都能提供一个有意义的仓库根目录（比如 pkg/service 里直接从远端 FileMap
+// 构建临时 Tree 的场景），所以留空是合法的，此时不做任何过滤。
+func (b *Builder) WithIgnoreMatcher(m *ignore.Matcher) *Builder {
+	b.ignorer = m
+	return b
+}
+
+// MergeEntries 把一组已经就绪的 TreeEntry（比如几个 worker 各自构建好的子树根，
+// 加上几个没有被分片出去的根级文件）直接组装成一棵 Tree 并持久化，不再遍历任何 Index。
+// 供 pkg/service 的 BuildTree 分片路径使用：每个分片已经由某个 worker 节点独立构建并写入
+// 了共享 Store，master 这一侧只需要把返回的子树根 Hash 当作目录项拼起来
+func (b *Builder) MergeEntries(ctx context.Context, entries []core.TreeEntry) (types.Hash, error) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+
+	treeObj, err := core.NewTree(entries, b.hasher)
+	if err != nil {
+		return "", fmt.Errorf("failed to create tree object: %w", err)
+	}
+	if err := b.store.Put(ctx, treeObj); err != nil {
+		return "", fmt.Errorf("failed to store tree: %w", err)
+	}
+	return treeObj.ID(), nil
 }
 
 // Build 执行构建过程，返回根树的 Hash
@@ -29,6 +59,9 @@ func (b *Builder) Build(ctx context.Context, idx *index.Index) (types.Hash, erro
 
 	snapshot := idx.Snapshot()
 	for path, entry := range snapshot {
+		if b.ignorer != nil && b.ignorer.Ignored(path, false) {
+			continue
+		}
 		root.addFile(path, entry)
 	}
 	// 2. 自底向上计算 Hash 并持久化
@@ -141,7 +174,7 @@ func (b *Builder) writeNode(ctx context.Context, n *node) (types.Hash, error) {
 	}
 
 	// 3. 创建 core.Tree 对象
-	treeObj, err := core.NewTree(entries)
+	treeObj, err := core.NewTree(entries, b.hasher)
 	if err != nil {
 		return "", fmt.Errorf("failed to create tree object: %w", err)
 	}
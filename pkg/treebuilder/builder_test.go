@@ -4,12 +4,22 @@ import (
 	"context"
 	"testing"
 
+	"tensorvault/pkg/core"
 	"tensorvault/pkg/index"
 	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/types"
 
 	"github.com/stretchr/testify/require"
 )
 
+// testHasher 是测试用的默认哈希算法，跟仓库未配置 hash_algo 时的隐式默认值一致
+func testHasher(t *testing.T) core.Hasher {
+	t.Helper()
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	return hasher
+}
+
 func TestTreeBuilder(t *testing.T) {
 	// 1. Setup
 	tmpDir := t.TempDir()
@@ -29,7 +39,7 @@ func TestTreeBuilder(t *testing.T) {
 	idx.Add("sub/b.txt", mockHash("content-b"), 200)
 
 	// 3. 执行 Build
-	builder := NewBuilder(store)
+	builder := NewBuilder(store, testHasher(t))
 	rootHash, err := builder.Build(context.Background(), idx)
 	require.NoError(t, err)
 
@@ -51,6 +61,28 @@ func TestTreeBuilder(t *testing.T) {
 	// 更严谨的测试会把 Root Tree 解码，找到 "sub" 的 Hash，再确认该 Hash 存在。
 }
 
+func TestTreeBuilder_MergeEntries(t *testing.T) {
+	// 模拟集群模式下 buildTreeSharded 的调用场景：一个根级文件 entry，加上一个
+	// 已经由"worker"构建好的子树 entry，直接合并成根 Tree，不经过任何 Index
+	tmpDir := t.TempDir()
+	store, err := disk.NewAdapter(tmpDir)
+	require.NoError(t, err)
+
+	entries := []core.TreeEntry{
+		core.NewFileEntry("root.txt", types.Hash(mockHash("root.txt")), 10),
+		core.NewDirEntry("sub", types.Hash(mockHash("sub-tree"))),
+	}
+
+	builder := NewBuilder(store, testHasher(t))
+	rootHash, err := builder.MergeEntries(context.Background(), entries)
+	require.NoError(t, err)
+	require.NotEmpty(t, rootHash)
+
+	reader, err := store.Get(context.Background(), rootHash)
+	require.NoError(t, err)
+	reader.Close()
+}
+
 // 辅助 Mock
 func mockHash(s string) string {
 	// 简单的占位符，实际需用真实 SHA256
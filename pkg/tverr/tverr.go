@@ -0,0 +1,85 @@
+// Package tverr gives the `tv` CLI a small set of typed, wrappable errors so that
+// cmd/tv/commands (and anything else driving the command tree, e.g. downstream automation
+// shelling out to the binary) can tell "what kind of failure was this" apart from the
+// human-readable message, without string-matching error text.
+//
+// A sentinel (ErrNotInitialized, ErrRemoteUnreachable, ErrHashMismatch, ErrConfigInvalid,
+// ErrObjectMissing) identifies the category; Wrap attaches one to an underlying cause the same
+// way fmt.Errorf("%w", ...) would, and Contains/ContainsType use errors.Is under the hood so
+// callers can still walk an arbitrarily wrapped chain to ask "was this a config problem?"
+package tverr
+
+import "errors"
+
+// Sentinel categories a wrapped error can be tagged with. Each corresponds to a distinct exit
+// code in cmd/tv/commands.Execute, so downstream automation can branch on the process's exit
+// status instead of scraping stderr.
+var (
+	// ErrNotInitialized means the command needs a tv repository (an *app.App) but none could
+	// be built — no .tv directory, or PersistentPreRunE's AppFactory call failed
+	ErrNotInitialized = errors.New("tensorvault repository not initialized")
+
+	// ErrRemoteUnreachable means a remote client/transport could not be constructed or dialed
+	ErrRemoteUnreachable = errors.New("remote unreachable")
+
+	// ErrHashMismatch means a hash (or hash prefix) doesn't match what this repository expects
+	// — e.g. a prefix tagged with a hash algorithm the repository isn't locked to
+	ErrHashMismatch = errors.New("hash mismatch")
+
+	// ErrConfigInvalid means the config file (or an env var overriding it) couldn't be loaded
+	// or parsed
+	ErrConfigInvalid = errors.New("invalid configuration")
+
+	// ErrObjectMissing means a referenced object isn't present in local storage
+	ErrObjectMissing = errors.New("object missing")
+)
+
+// wrapped pairs a sentinel category with the underlying cause, the same shape fmt.Errorf's
+// %w produces but keeping the sentinel itself easily extractable via Contains/ContainsType
+// instead of requiring callers to parse the error string
+type wrapped struct {
+	kind error
+	err  error
+}
+
+func (w *wrapped) Error() string {
+	if w.err == nil {
+		return w.kind.Error()
+	}
+	return w.kind.Error() + ": " + w.err.Error()
+}
+
+func (w *wrapped) Unwrap() error { return w.err }
+
+// Is makes errors.Is(Wrap(ErrConfigInvalid, cause), ErrConfigInvalid) report true, the same way
+// it would for a plain %w-wrapped sentinel
+func (w *wrapped) Is(target error) bool { return w.kind == target }
+
+// Wrap tags err with kind (one of this package's sentinels). Returns nil if err is nil, same
+// convention as fmt.Errorf with a nil %w operand producing a non-nil error would NOT honor, so
+// callers can write `return tverr.Wrap(tverr.ErrConfigInvalid, someCall())` unconditionally
+func Wrap(kind, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &wrapped{kind: kind, err: err}
+}
+
+// Contains reports whether err's chain has been tagged by Wrap at all, and if so, with which
+// sentinel. Useful for "is this one of ours, and if so which" without having to try every
+// sentinel one at a time
+func Contains(err error) (kind error, ok bool) {
+	var w *wrapped
+	if !errors.As(err, &w) {
+		return nil, false
+	}
+	return w.kind, true
+}
+
+// ContainsType reports whether err's chain is tagged with this specific kind — the check
+// cmd/tv/commands.Execute's exit-code switch runs once per sentinel ("is this a config
+// problem? a missing object?"). Equivalent to errors.Is(err, kind), spelled out for readability
+// at that call site
+func ContainsType(err error, kind error) bool {
+	return errors.Is(err, kind)
+}
@@ -0,0 +1,50 @@
+package tverr
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrap_NilErrorPassesThrough(t *testing.T) {
+	assert.NoError(t, Wrap(ErrConfigInvalid, nil))
+}
+
+func TestWrap_IsMatchesSentinel(t *testing.T) {
+	cause := errors.New("no config.yaml found")
+	err := Wrap(ErrConfigInvalid, cause)
+
+	assert.True(t, errors.Is(err, ErrConfigInvalid))
+	assert.False(t, errors.Is(err, ErrRemoteUnreachable), "不应该匹配别的 sentinel")
+	assert.True(t, errors.Is(err, cause), "Unwrap 应该能追到原始 cause")
+}
+
+func TestWrap_PreservesCauseMessage(t *testing.T) {
+	cause := fmt.Errorf("dial tcp: connection refused")
+	err := Wrap(ErrRemoteUnreachable, cause)
+
+	assert.Contains(t, err.Error(), ErrRemoteUnreachable.Error())
+	assert.Contains(t, err.Error(), cause.Error())
+}
+
+func TestContains_FindsTaggedSentinel(t *testing.T) {
+	err := fmt.Errorf("checkout failed: %w", Wrap(ErrObjectMissing, errors.New("boom")))
+
+	kind, ok := Contains(err)
+	assert.True(t, ok)
+	assert.Equal(t, ErrObjectMissing, kind)
+}
+
+func TestContains_UntaggedErrorReportsFalse(t *testing.T) {
+	_, ok := Contains(errors.New("plain error, never wrapped"))
+	assert.False(t, ok)
+}
+
+func TestContainsType_ChecksSpecificSentinel(t *testing.T) {
+	err := fmt.Errorf("commit failed: %w", Wrap(ErrHashMismatch, errors.New("algo mismatch")))
+
+	assert.True(t, ContainsType(err, ErrHashMismatch))
+	assert.False(t, ContainsType(err, ErrNotInitialized))
+}
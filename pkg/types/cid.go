@@ -0,0 +1,108 @@
+// pkg/types/cid.go
+package types
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+
+	"github.com/zeebo/blake3"
+)
+
+// HashAlgo 标识生成一个 CID 用的哈希算法。新增算法只需要在这里加一个常量，再在
+// core 包里注册一个对应的 Hasher 实现——Hash/CID 本身的字符串形式和校验逻辑不用跟着改
+type HashAlgo byte
+
+const (
+	// AlgoSHA256 是仓库从一开始就使用的算法。字符串形式里没有任何前缀时隐式就是它，
+	// 这样建仓以来写过的全部 64 位十六进制 Hash 不需要重写就能继续通过校验
+	AlgoSHA256 HashAlgo = iota
+	// AlgoBLAKE3 在大块数据上比 SHA-256 快 3-5 倍，适合张量权重这种体积的 Chunk；
+	// 字符串形式带 "blake3:" 前缀，跟隐式的 SHA-256 区分开
+	AlgoBLAKE3
+)
+
+func (a HashAlgo) String() string {
+	switch a {
+	case AlgoBLAKE3:
+		return "blake3"
+	default:
+		return "sha256"
+	}
+}
+
+// ParseHashAlgo 把 CID 里冒号前的算法名解析回 HashAlgo；无法识别的名字返回 ok=false，
+// 交给调用方决定是报错还是退回默认值。导出出来是因为不止 Hash.Algo 自己用得到——
+// core.ValidateHashPrefix 校验用户敲的短哈希前缀时也要做同一个解析
+func ParseHashAlgo(name string) (HashAlgo, bool) {
+	switch name {
+	case "sha256":
+		return AlgoSHA256, true
+	case "blake3":
+		return AlgoBLAKE3, true
+	default:
+		return 0, false
+	}
+}
+
+// algoFromPrefix 是 ParseHashAlgo 的包内别名，保留下来只是不想为了一次改名把
+// common.go 里既有的调用点也跟着改一遍
+func algoFromPrefix(prefix string) (HashAlgo, bool) { return ParseHashAlgo(prefix) }
+
+// Name 是 String 的同义词：跟 go-git 的 crypto.Hash 命名习惯看齐，调用方按接口
+// 编程时更直觉——"给我这个算法的名字"而不是"把它格式化成字符串"
+func (a HashAlgo) Name() string { return a.String() }
+
+// Size 返回该算法摘要的字节长度。两种算法目前都产出 32 字节，写成方法而不是散落的
+// 字面量 32，是为了以后真的接入一个摘要长度不同的算法时只用改这一处
+func (a HashAlgo) Size() int { return 32 }
+
+// Multicodec 返回 Link 的 CBOR 编码里标记算法用的单字节码（玩法仿 multihash，但不是
+// 向 multicodec 注册表申请来的真实码位——这里没有跨进程/跨系统互操作的需求，纯粹是
+// 让一个 Link 自描述自己是哪种算法编出来的）。0x00 留给 multibase identity 前缀，
+// 不会被当成某个算法的合法码
+func (a HashAlgo) Multicodec() byte {
+	if a == AlgoBLAKE3 {
+		return 0x02
+	}
+	return 0x01
+}
+
+// AlgoFromMulticodec 是 Multicodec 的逆运算，供 Link.UnmarshalCBOR 把编码里的单字节码
+// 还原回 HashAlgo。未识别的码返回错误而不是静默退回 SHA-256——那样一个损坏的 Link
+// 会被悄悄读成别的算法，摘要校验稀里糊涂地过，比直接报错更危险
+func AlgoFromMulticodec(code byte) (HashAlgo, error) {
+	switch code {
+	case 0x01:
+		return AlgoSHA256, nil
+	case 0x02:
+		return AlgoBLAKE3, nil
+	default:
+		return 0, fmt.Errorf("unknown hash algo multicodec code 0x%02x", code)
+	}
+}
+
+// New 返回一个可以流式写入的 hash.Hash 实例，给需要边读边算摘要、不想先把整份数据
+// 攒进内存再调 core.Hasher.Sum 的调用方用（比如体积大到要走分片上传的 Chunk）
+func (a HashAlgo) New() hash.Hash {
+	if a == AlgoBLAKE3 {
+		return blake3.New()
+	}
+	return sha256.New()
+}
+
+// CID 是 Hash 的 multihash 风格变体：字符串形式是 "<algo>:<hex digest>"，但 AlgoSHA256
+// 省略前缀，直接就是裸的 64 位十六进制字符串——那是建仓以来唯一写过的形式，必须原样
+// 保持合法。CID 被显式定义为 Hash 的别名而不是独立类型，这样现有成百上千个签名是
+// types.Hash 的函数不用改一个字符就能透明接受带算法标签的新 CID
+type CID = Hash
+
+// NewCID 从算法和摘要字节组装出标签化的 CID 字符串
+func NewCID(algo HashAlgo, digest []byte) CID {
+	hexDigest := hex.EncodeToString(digest)
+	if algo == AlgoSHA256 {
+		return CID(hexDigest)
+	}
+	return CID(algo.String() + ":" + hexDigest)
+}
@@ -0,0 +1,39 @@
+package types
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCID_SHA256OmitsPrefix(t *testing.T) {
+	digest := []byte(strings.Repeat("\xab", 32))
+	cid := NewCID(AlgoSHA256, digest)
+
+	assert.NotContains(t, string(cid), ":", "sha256 CIDs must stay byte-identical to pre-multi-hash Hash values")
+	assert.Equal(t, AlgoSHA256, cid.Algo())
+	assert.True(t, cid.IsValid())
+}
+
+func TestNewCID_BLAKE3HasPrefix(t *testing.T) {
+	digest := []byte(strings.Repeat("\xcd", 32))
+	cid := NewCID(AlgoBLAKE3, digest)
+
+	assert.True(t, strings.HasPrefix(string(cid), "blake3:"))
+	assert.Equal(t, AlgoBLAKE3, cid.Algo())
+	assert.True(t, cid.IsValid())
+}
+
+func TestHash_Algo_UnknownPrefixFallsBackToSHA256(t *testing.T) {
+	h := Hash("md5:deadbeef")
+	assert.Equal(t, AlgoSHA256, h.Algo())
+}
+
+func TestHash_Digest_StripsOnlyKnownPrefix(t *testing.T) {
+	plain := Hash(strings.Repeat("a", 64))
+	assert.Equal(t, string(plain), plain.Digest())
+
+	tagged := Hash("blake3:" + strings.Repeat("b", 64))
+	assert.Equal(t, strings.Repeat("b", 64), tagged.Digest())
+}
@@ -1,15 +1,40 @@
 // pkg/types/common.go
 package types
 
-// Hash 代表对象的唯一标识符 (SHA256 Hex String)
-// 这是一个“值对象”，应当是不可变的。
+import "strings"
+
+// Hash 代表对象的唯一标识符。历史上一直是裸的 SHA-256 Hex String，现在同时也是
+// CID 的底层类型（见 cid.go）：没有 "<algo>:" 前缀时隐式仍是 SHA-256，带前缀的是
+// 其他算法（比如 BLAKE3）产出的摘要。这是一个"值对象"，应当是不可变的。
 type Hash string
 
 func (h Hash) String() string { return string(h) }
 
 // 验证 Hash 合法性
-func (h Hash) IsZero() bool  { return h == "" }
-func (h Hash) IsValid() bool { return len(h) == 64 } // 简单的长度检查
+func (h Hash) IsZero() bool { return h == "" }
+
+// IsValid 只校验摘要部分的长度（两种算法都产出 32 字节 = 64 位十六进制），不关心
+// 是否带算法前缀——裸 Hash 和带前缀的 CID 都用这同一套规则
+func (h Hash) IsValid() bool { return len(h.Digest()) == 64 }
+
+// Algo 解析 h 携带的算法标签。没有标签（裸 64 位十六进制，建仓以来所有旧对象的形式）
+// 或者标签无法识别，都隐式当作 AlgoSHA256
+func (h Hash) Algo() HashAlgo {
+	if idx := strings.IndexByte(string(h), ':'); idx >= 0 {
+		if algo, ok := algoFromPrefix(string(h)[:idx]); ok {
+			return algo
+		}
+	}
+	return AlgoSHA256
+}
+
+// Digest 返回去掉算法前缀之后的十六进制摘要部分
+func (h Hash) Digest() string {
+	if idx := strings.IndexByte(string(h), ':'); idx >= 0 {
+		return string(h)[idx+1:]
+	}
+	return string(h)
+}
 
 type LinearHash string
 
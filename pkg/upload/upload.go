@@ -0,0 +1,281 @@
+// Package upload 实现可断点续传的分片上传会话：客户端用本地的内容定义切分（CDC）算出整份
+// 文件的 Chunk 哈希/大小列表，先登记一个 Session，服务端据此回答哪些 Chunk 已经存在（去重），
+// 客户端只需要传输缺的那些，哪怕中途进程重启、换机器续传也没关系——跟 pkg/exporter.RestoreJob
+// 是同一个断点续传思路，只是方向反过来：那边是下行（Tree -> 本地目录），这里是上行（本地
+// Chunk 流 -> Store）。
+//
+// 一份会话不持有任何内存态：Session 的全部状态（Chunk 列表、完成位图）都落在
+// meta.UploadSessionModel 里，每次 UploadPart/Complete/Abort 都重新从数据库读取最新状态再
+// 写回——这跟 RestoreJob 在一次 Run 内把位图攒在内存里分批 flush 不一样，是因为分片上传的
+// 各个 Part 天然是一个个独立的 RPC 调用（甚至可能打到不同的 gRPC server 实例上，只要它们共享
+// 同一个 meta.Repository），没有一个贯穿全程的 goroutine 可以持有进度，只能每次都向数据库
+// 要最新状态
+package upload
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+
+	"gorm.io/datatypes"
+)
+
+// ErrChunkNotInSession 表示 UploadPart 传来的 Chunk 哈希不在 InitUpload 登记的列表里——
+// 协议违反，比如客户端传错了 uploadID，或者两次 Init 用了不一致的切分结果
+var ErrChunkNotInSession = errors.New("upload: chunk hash is not part of this session")
+
+// ErrChunkSizeMismatch 表示某个 Chunk 实际传来的字节数跟 InitUpload 登记的声明大小对不上
+var ErrChunkSizeMismatch = errors.New("upload: chunk data size does not match the size declared at init")
+
+// ErrChunkHashMismatch 表示某个 Chunk 实际内容的哈希跟它自称的哈希对不上——内容在传输
+// 途中损坏，或者客户端发错了块
+var ErrChunkHashMismatch = errors.New("upload: chunk content does not match its declared hash")
+
+// ErrIncomplete 表示 Complete 被调用时，仍有 Chunk 没有落盘
+var ErrIncomplete = errors.New("upload: session still has missing chunks")
+
+// ErrSessionFinished 表示对一个已经 completed/aborted 的会话做了 Init 之外的操作
+var ErrSessionFinished = errors.New("upload: session is already completed or aborted")
+
+// Manager 组装一次分片上传所需要的 Store 写入 + meta.Repository 状态持久化
+type Manager struct {
+	store  storage.Store
+	repo   *meta.Repository
+	hasher core.Hasher
+}
+
+func NewManager(store storage.Store, repo *meta.Repository, hasher core.Hasher) *Manager {
+	return &Manager{store: store, repo: repo, hasher: hasher}
+}
+
+// bitmap 跟 exporter.fileCheckpoint.Bitmap 是同一套编码：每个 Chunk 对应一个 bit，1 = 已落盘
+type bitmap []byte
+
+func newBitmap(n int) bitmap {
+	return make(bitmap, (n+7)/8)
+}
+
+func (b bitmap) isDone(i int) bool {
+	return i/8 < len(b) && b[i/8]&(1<<uint(i%8)) != 0
+}
+
+func (b bitmap) markDone(i int) {
+	if i/8 < len(b) {
+		b[i/8] |= 1 << uint(i%8)
+	}
+}
+
+func (b bitmap) complete(n int) bool {
+	for i := 0; i < n; i++ {
+		if !b.isDone(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// Init 登记一次新的上传会话：chunkHashes/chunkSizes 是客户端本地 CDC 切分的结果，按文件内
+// 偏移顺序排列，两个切片必须等长。已经存在于 store 里的 Chunk 会被直接标记为完成，返回给
+// 调用方的是还缺的那部分，客户端只需要传输这些
+func (m *Manager) Init(ctx context.Context, linearHash types.LinearHash, size int64, chunkHashes []types.Hash, chunkSizes []int64) (sessionID string, missing []types.Hash, err error) {
+	if len(chunkHashes) == 0 {
+		return "", nil, fmt.Errorf("upload: chunk list must not be empty")
+	}
+	if len(chunkHashes) != len(chunkSizes) {
+		return "", nil, fmt.Errorf("upload: chunk hash/size lists have different lengths (%d vs %d)", len(chunkHashes), len(chunkSizes))
+	}
+	var declaredTotal int64
+	for _, s := range chunkSizes {
+		declaredTotal += s
+	}
+	if declaredTotal != size {
+		return "", nil, fmt.Errorf("upload: declared chunk sizes sum to %d, does not match file size %d", declaredTotal, size)
+	}
+
+	id, err := newSessionID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	received := newBitmap(len(chunkHashes))
+	for i, h := range chunkHashes {
+		ok, err := m.store.Has(ctx, string(h))
+		if err != nil {
+			return "", nil, fmt.Errorf("upload: failed to probe existing chunk %s: %w", h, err)
+		}
+		if ok {
+			received.markDone(i)
+		} else {
+			missing = append(missing, h)
+		}
+	}
+
+	hashStrs := make([]string, len(chunkHashes))
+	for i, h := range chunkHashes {
+		hashStrs[i] = string(h)
+	}
+	chunkHashesJSON, err := json.Marshal(hashStrs)
+	if err != nil {
+		return "", nil, fmt.Errorf("upload: failed to encode chunk hash list: %w", err)
+	}
+	chunkSizesJSON, err := json.Marshal(chunkSizes)
+	if err != nil {
+		return "", nil, fmt.Errorf("upload: failed to encode chunk size list: %w", err)
+	}
+	receivedJSON, err := json.Marshal(received)
+	if err != nil {
+		return "", nil, fmt.Errorf("upload: failed to encode received bitmap: %w", err)
+	}
+
+	model := &meta.UploadSessionModel{
+		UploadID:    id,
+		LinearHash:  string(linearHash),
+		SizeBytes:   size,
+		ChunkHashes: datatypes.JSON(chunkHashesJSON),
+		ChunkSizes:  datatypes.JSON(chunkSizesJSON),
+		Received:    datatypes.JSON(receivedJSON),
+		Status:      "in_progress",
+	}
+	if err := m.repo.CreateUploadSession(ctx, model); err != nil {
+		return "", nil, err
+	}
+
+	return id, missing, nil
+}
+
+// sessionState 是从 meta.UploadSessionModel 解码出来的、Manager 方法之间传递用的内部视图
+type sessionState struct {
+	model       *meta.UploadSessionModel
+	chunkHashes []types.Hash
+	chunkSizes  []int64
+	received    bitmap
+	indexOf     map[types.Hash]int
+}
+
+func (m *Manager) loadSession(ctx context.Context, sessionID string) (*sessionState, error) {
+	model, err := m.repo.GetUploadSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var hashStrs []string
+	if err := json.Unmarshal(model.ChunkHashes, &hashStrs); err != nil {
+		return nil, fmt.Errorf("upload: corrupted chunk hash list for session %s: %w", sessionID, err)
+	}
+	var sizes []int64
+	if err := json.Unmarshal(model.ChunkSizes, &sizes); err != nil {
+		return nil, fmt.Errorf("upload: corrupted chunk size list for session %s: %w", sessionID, err)
+	}
+	var received bitmap
+	if len(model.Received) > 0 {
+		if err := json.Unmarshal(model.Received, &received); err != nil {
+			return nil, fmt.Errorf("upload: corrupted received bitmap for session %s: %w", sessionID, err)
+		}
+	}
+
+	hashes := make([]types.Hash, len(hashStrs))
+	indexOf := make(map[types.Hash]int, len(hashStrs))
+	for i, h := range hashStrs {
+		hashes[i] = types.Hash(h)
+		indexOf[types.Hash(h)] = i
+	}
+
+	return &sessionState{model: model, chunkHashes: hashes, chunkSizes: sizes, received: received, indexOf: indexOf}, nil
+}
+
+// UploadPart 写入一个分片的数据并在位图里标记完成。chunkHash 必须是 Init 时登记过的其中
+// 一个；data 的内容和长度都会跟登记时的声明核对一遍，防止客户端传错块或者传输损坏
+func (m *Manager) UploadPart(ctx context.Context, sessionID string, chunkHash types.Hash, data []byte) error {
+	state, err := m.loadSession(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if state.model.Status != "in_progress" {
+		return ErrSessionFinished
+	}
+
+	idx, ok := state.indexOf[chunkHash]
+	if !ok {
+		return ErrChunkNotInSession
+	}
+	if int64(len(data)) != state.chunkSizes[idx] {
+		return fmt.Errorf("%w: expected %d bytes, got %d", ErrChunkSizeMismatch, state.chunkSizes[idx], len(data))
+	}
+
+	chunk := core.NewChunk(data, m.hasher)
+	if chunk.ID() != chunkHash {
+		return fmt.Errorf("%w: declared %s, actual %s", ErrChunkHashMismatch, chunkHash, chunk.ID())
+	}
+
+	if state.received.isDone(idx) {
+		// 幂等：客户端超时重传同一个 Part 是正常情况，不应该报错
+		return nil
+	}
+	if err := m.store.Put(ctx, chunk); err != nil {
+		return fmt.Errorf("upload: failed to write chunk %s: %w", chunkHash, err)
+	}
+
+	state.received.markDone(idx)
+	receivedJSON, err := json.Marshal(state.received)
+	if err != nil {
+		return fmt.Errorf("upload: failed to encode received bitmap: %w", err)
+	}
+	return m.repo.SaveUploadSessionProgress(ctx, sessionID, datatypes.JSON(receivedJSON))
+}
+
+// Complete 要求会话登记的全部 Chunk 都已落盘，然后按 Init 时声明的顺序组装（必要时是
+// Pyramid 布局的）FileNode，写入 Store 并把会话标记为 completed
+func (m *Manager) Complete(ctx context.Context, sessionID string) (*core.FileNode, error) {
+	state, err := m.loadSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if state.model.Status != "in_progress" {
+		return nil, ErrSessionFinished
+	}
+	if !state.received.complete(len(state.chunkHashes)) {
+		return nil, ErrIncomplete
+	}
+
+	links := make([]core.ChunkLink, len(state.chunkHashes))
+	for i, h := range state.chunkHashes {
+		links[i] = core.ChunkLink{Hash: core.NewLink(h), Size: int(state.chunkSizes[i])}
+	}
+
+	fileNode, err := core.BuildTree(ctx, m.store, state.model.SizeBytes, links, m.hasher)
+	if err != nil {
+		return nil, fmt.Errorf("upload: failed to assemble filenode: %w", err)
+	}
+	if err := m.store.Put(ctx, fileNode); err != nil {
+		return nil, fmt.Errorf("upload: failed to persist filenode: %w", err)
+	}
+
+	if err := m.repo.FinishUploadSession(ctx, sessionID); err != nil {
+		return nil, err
+	}
+	return fileNode, nil
+}
+
+// Abort 把一个未完成的会话标记为 aborted。已经落盘的 Chunk 不会被删除：内容寻址 + 去重
+// 意味着它们完全可能已经被其他文件引用
+func (m *Manager) Abort(ctx context.Context, sessionID string) error {
+	return m.repo.AbortUploadSession(ctx, sessionID)
+}
+
+// newSessionID 生成一个上传会话 ID：16 字节随机数的十六进制表示，跟 pkg/task.newTaskID /
+// exporter.newRestoreJobID 是同一套思路，但各自是独立的 ID 空间
+func newSessionID() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate upload session id: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,151 @@
+package upload
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testHasher 是测试用的默认哈希算法，跟仓库未配置 hash_algo 时的隐式默认值一致
+func testHasher(t *testing.T) core.Hasher {
+	t.Helper()
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	return hasher
+}
+
+// setupTestManager 构建一个隔离的 Manager：磁盘 Store + 内存 sqlite，风格上跟
+// pkg/service/helper_test.go 的 setupTestApp 一致
+func setupTestManager(t *testing.T) (*Manager, storage.Store) {
+	t.Helper()
+
+	storePath := filepath.Join(t.TempDir(), "objects")
+	store, err := disk.NewAdapter(storePath)
+	require.NoError(t, err)
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	metaDB := meta.NewWithConn(db)
+	require.NoError(t, metaDB.AutoMigrate(&meta.UploadSessionModel{}))
+
+	return NewManager(store, meta.NewRepository(metaDB), testHasher(t)), store
+}
+
+func TestManager_InitUploadCompleteUpload_RoundTrip(t *testing.T) {
+	mgr, store := setupTestManager(t)
+	ctx := context.Background()
+
+	chunkA := core.NewChunk([]byte("first half of the tensor shard.."), testHasher(t))
+	chunkB := core.NewChunk([]byte("second half of the tensor shard."), testHasher(t))
+
+	sessionID, missing, err := mgr.Init(ctx, types.LinearHash(fmt.Sprintf("%064d", 1)), chunkA.Size()+chunkB.Size(),
+		[]types.Hash{chunkA.ID(), chunkB.ID()}, []int64{chunkA.Size(), chunkB.Size()})
+	require.NoError(t, err)
+	require.NotEmpty(t, sessionID)
+	assert.ElementsMatch(t, []types.Hash{chunkA.ID(), chunkB.ID()}, missing)
+
+	require.NoError(t, mgr.UploadPart(ctx, sessionID, chunkA.ID(), chunkA.Bytes()))
+	require.NoError(t, mgr.UploadPart(ctx, sessionID, chunkB.ID(), chunkB.Bytes()))
+
+	fileNode, err := mgr.Complete(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, chunkA.Size()+chunkB.Size(), fileNode.TotalSize)
+	assert.Len(t, fileNode.Chunks, 2)
+
+	exists, err := store.Has(ctx, fileNode.ID().String())
+	require.NoError(t, err)
+	assert.True(t, exists)
+}
+
+func TestManager_Init_SkipsChunksAlreadyInStore(t *testing.T) {
+	mgr, store := setupTestManager(t)
+	ctx := context.Background()
+
+	existing := core.NewChunk([]byte("already deduped chunk"), testHasher(t))
+	require.NoError(t, store.Put(ctx, existing))
+	fresh := core.NewChunk([]byte("brand new chunk"), testHasher(t))
+
+	sessionID, missing, err := mgr.Init(ctx, types.LinearHash(fmt.Sprintf("%064d", 2)), existing.Size()+fresh.Size(),
+		[]types.Hash{existing.ID(), fresh.ID()}, []int64{existing.Size(), fresh.Size()})
+	require.NoError(t, err)
+	assert.Equal(t, []types.Hash{fresh.ID()}, missing)
+
+	// 只上传缺的那一块，已经存在的那块不需要再传
+	require.NoError(t, mgr.UploadPart(ctx, sessionID, fresh.ID(), fresh.Bytes()))
+
+	fileNode, err := mgr.Complete(ctx, sessionID)
+	require.NoError(t, err)
+	assert.Equal(t, existing.Size()+fresh.Size(), fileNode.TotalSize)
+}
+
+func TestManager_UploadPart_RejectsSizeMismatch(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+	ctx := context.Background()
+
+	chunk := core.NewChunk([]byte("declared content"), testHasher(t))
+	sessionID, _, err := mgr.Init(ctx, types.LinearHash(fmt.Sprintf("%064d", 3)), chunk.Size(), []types.Hash{chunk.ID()}, []int64{chunk.Size()})
+	require.NoError(t, err)
+
+	err = mgr.UploadPart(ctx, sessionID, chunk.ID(), []byte("a completely different, longer payload!"))
+	assert.ErrorIs(t, err, ErrChunkSizeMismatch)
+}
+
+func TestManager_UploadPart_RejectsContentHashMismatch(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+	ctx := context.Background()
+
+	chunk := core.NewChunk([]byte("declared content"), testHasher(t))
+	sessionID, _, err := mgr.Init(ctx, types.LinearHash(fmt.Sprintf("%064d", 3)), chunk.Size(), []types.Hash{chunk.ID()}, []int64{chunk.Size()})
+	require.NoError(t, err)
+
+	// 同长度但内容不同：size 校验过不去 hash 校验这一关，才能真正触发 ErrChunkHashMismatch
+	tampered := make([]byte, len(chunk.Bytes()))
+	copy(tampered, chunk.Bytes())
+	tampered[0] ^= 0xFF
+
+	err = mgr.UploadPart(ctx, sessionID, chunk.ID(), tampered)
+	assert.ErrorIs(t, err, ErrChunkHashMismatch)
+}
+
+func TestManager_Complete_FailsWhileChunksMissing(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+	ctx := context.Background()
+
+	chunk := core.NewChunk([]byte("never uploaded"), testHasher(t))
+	sessionID, _, err := mgr.Init(ctx, types.LinearHash(fmt.Sprintf("%064d", 4)), chunk.Size(), []types.Hash{chunk.ID()}, []int64{chunk.Size()})
+	require.NoError(t, err)
+
+	_, err = mgr.Complete(ctx, sessionID)
+	assert.ErrorIs(t, err, ErrIncomplete)
+}
+
+func TestManager_Abort_MarksSessionAborted(t *testing.T) {
+	mgr, _ := setupTestManager(t)
+	ctx := context.Background()
+
+	chunk := core.NewChunk([]byte("abandoned upload"), testHasher(t))
+	sessionID, _, err := mgr.Init(ctx, types.LinearHash(fmt.Sprintf("%064d", 5)), chunk.Size(), []types.Hash{chunk.ID()}, []int64{chunk.Size()})
+	require.NoError(t, err)
+
+	require.NoError(t, mgr.Abort(ctx, sessionID))
+
+	err = mgr.UploadPart(ctx, sessionID, chunk.ID(), chunk.Bytes())
+	assert.ErrorIs(t, err, ErrSessionFinished)
+}
@@ -0,0 +1,291 @@
+// pkg/worktree/checkout.go
+package worktree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/exporter"
+	"tensorvault/pkg/index"
+	"tensorvault/pkg/merkletrie"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// ErrDirty 在 Force 未开启、且工作区存在未提交的改动时由 Checkout/Reset 返回
+var ErrDirty = errors.New("worktree has uncommitted changes")
+
+// CheckoutOptions 描述一次 Checkout 的目标：Branch 和 Hash 二选一，由调用方决定
+// （比如 CLI 先按分支名查一遍 meta.Repository，查不到再当成 commit hash 解析）
+type CheckoutOptions struct {
+	Branch string     // 非空时，HEAD 会在 checkout 之后 attach 到这条分支
+	Hash   types.Hash // Branch 为空时使用，detached checkout 到这个具体 commit
+	Force  bool       // true 时跳过 dirty check，覆盖本地未提交的改动
+}
+
+// Worktree 把 Store、Index、Refs 和本地工作目录串到一起，提供 tree -> worktree 方向的操作
+// （worktree -> tree 方向已经由 pkg/treebuilder 覆盖）。Checkout 和 Reset 都驱动同一套
+// merkletrie diff-apply 循环：只touch发生变化的路径，不会像 exporter.RestoreTree 那样
+// 无脑重写整棵树。
+type Worktree struct {
+	Store  storage.Store
+	Index  *index.Index
+	Refs   *refs.Manager
+	Root   string // 工作目录根路径，Checkout/Reset 还原的文件都相对它展开
+	Hasher core.Hasher
+}
+
+func NewWorktree(store storage.Store, idx *index.Index, refsMgr *refs.Manager, root string, hasher core.Hasher) *Worktree {
+	return &Worktree{Store: store, Index: idx, Refs: refsMgr, Root: root, Hasher: hasher}
+}
+
+// Checkout 把 opts.Branch 或 opts.Hash 解析为一个 commit，diff 当前 Index 与它的 Tree，
+// 只重写发生变化的文件，然后重建 Index 并移动 HEAD（attached 或 detached，取决于 opts.Branch）
+func (wt *Worktree) Checkout(ctx context.Context, opts CheckoutOptions) (types.Hash, error) {
+	attach := opts.Branch != ""
+
+	var commitHash types.Hash
+	if attach {
+		hash, _, err := wt.Refs.GetRef(ctx, "refs/heads/"+opts.Branch)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve branch %s: %w", opts.Branch, err)
+		}
+		if hash == "" {
+			return "", fmt.Errorf("branch '%s' has no commits yet", opts.Branch)
+		}
+		commitHash = hash
+	} else {
+		commitHash = opts.Hash
+	}
+	if commitHash == "" {
+		return "", fmt.Errorf("checkout: no branch or commit specified")
+	}
+
+	commit, err := wt.loadCommit(ctx, commitHash)
+	if err != nil {
+		return "", err
+	}
+
+	if !opts.Force {
+		if err := wt.requireClean(ctx); err != nil {
+			return "", err
+		}
+	}
+
+	if err := wt.applyTree(ctx, commit.TreeCid.Hash); err != nil {
+		return "", fmt.Errorf("checkout failed: %w", err)
+	}
+	if err := wt.Index.Save(); err != nil {
+		return "", fmt.Errorf("failed to save index: %w", err)
+	}
+
+	if attach {
+		if err := wt.Refs.Checkout(ctx, opts.Branch); err != nil {
+			return "", fmt.Errorf("failed to attach HEAD to %s: %w", opts.Branch, err)
+		}
+		return commitHash, nil
+	}
+
+	_, currentVer, err := wt.Refs.GetRef(ctx, "HEAD")
+	if err != nil {
+		return "", fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if err := wt.Refs.UpdateRef(ctx, "HEAD", commitHash, currentVer); err != nil {
+		return "", fmt.Errorf("failed to update HEAD: %w", err)
+	}
+	return commitHash, nil
+}
+
+// ResetMode 决定 Reset 推进到哪一层：HEAD、Index 还是连工作区一起
+type ResetMode string
+
+const (
+	SoftReset  ResetMode = "soft"  // 只移动 HEAD
+	MixedReset ResetMode = "mixed" // HEAD + Index
+	HardReset  ResetMode = "hard"  // HEAD + Index + 工作区
+)
+
+// Reset 把 HEAD 移到 hash，并按 mode 决定是否顺带重写 Index 和工作区
+func (wt *Worktree) Reset(ctx context.Context, mode ResetMode, hash types.Hash) error {
+	commit, err := wt.loadCommit(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	_, currentVer, err := wt.Refs.GetRef(ctx, "HEAD")
+	if err != nil {
+		return fmt.Errorf("failed to read HEAD: %w", err)
+	}
+	if err := wt.Refs.UpdateRef(ctx, "HEAD", hash, currentVer); err != nil {
+		return fmt.Errorf("failed to move HEAD: %w", err)
+	}
+
+	switch mode {
+	case SoftReset:
+		return nil
+
+	case HardReset:
+		// 驱动跟 Checkout 完全一样的 diff-apply 循环：既重写了磁盘上发生变化的文件，
+		// 顺带也把 Index 同步到了目标 Tree，不需要再单独跑一次 resetIndexOnly
+		if err := wt.applyTree(ctx, commit.TreeCid.Hash); err != nil {
+			return fmt.Errorf("failed to restore worktree: %w", err)
+		}
+		return wt.Index.Save()
+
+	case MixedReset:
+		// 只挪 Index，不碰磁盘上的文件，所以不能走 applyTree（它连文件一起写）
+		if err := wt.resetIndexOnly(ctx, commit.TreeCid.Hash); err != nil {
+			return fmt.Errorf("failed to rebuild index: %w", err)
+		}
+		return wt.Index.Save()
+
+	default:
+		return fmt.Errorf("unknown reset mode: %q", mode)
+	}
+}
+
+// -----------------------------------------------------------------------------
+// 内部辅助
+// -----------------------------------------------------------------------------
+
+// applyTree 是 Checkout 和 HardReset 共用的核心：用 merkletrie 对比当前 Index（旧状态）
+// 和目标 Tree（新状态），只为 Insert/Modify 的路径写文件、为 Delete 的路径删文件，
+// 同时把 Index 同步更新到跟目标 Tree 一致。未改动的子树从不会被展开，也就从不会被 touch。
+func (wt *Worktree) applyTree(ctx context.Context, targetTreeHash types.Hash) error {
+	oldNoder, err := merkletrie.NewIndexNoder(wt.Index, wt.Hasher)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot index: %w", err)
+	}
+	newNoder := merkletrie.NewTreeNoder(wt.Store, targetTreeHash)
+
+	changes, err := merkletrie.DiffTree(ctx, oldNoder, newNoder)
+	if err != nil {
+		return fmt.Errorf("failed to diff index against target tree: %w", err)
+	}
+
+	exp := exporter.NewExporter(wt.Store)
+	for _, c := range changes {
+		fullPath := filepath.Join(wt.Root, c.Path)
+
+		switch c.Action {
+		case merkletrie.Delete:
+			if err := os.Remove(fullPath); err != nil && !os.IsNotExist(err) {
+				return fmt.Errorf("failed to remove %s: %w", c.Path, err)
+			}
+			wt.Index.Remove(c.Path)
+
+		case merkletrie.Insert, merkletrie.Modify:
+			size, err := wt.writeFile(ctx, exp, fullPath, c.NewHash)
+			if err != nil {
+				return fmt.Errorf("failed to restore %s: %w", c.Path, err)
+			}
+			wt.Index.Add(c.Path, c.NewHash, size)
+		}
+	}
+	return nil
+}
+
+// writeFile 把 hash 对应的 FileNode 流式导出到 fullPath（必要时先建好父目录），
+// 返回写入后的实际文件大小，供调用方更新 Index
+func (wt *Worktree) writeFile(ctx context.Context, exp *exporter.Exporter, fullPath string, hash types.Hash) (int64, error) {
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return 0, fmt.Errorf("failed to create parent dir for %s: %w", fullPath, err)
+	}
+
+	f, err := os.Create(fullPath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create %s: %w", fullPath, err)
+	}
+
+	if err := exp.ExportFile(ctx, hash, f); err != nil {
+		f.Close()
+		return 0, err
+	}
+	if err := f.Close(); err != nil {
+		return 0, err
+	}
+
+	info, err := os.Stat(fullPath)
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+// resetIndexOnly 把 Index 整个重建成目标 Tree 的扁平快照，不touch磁盘上的任何文件
+// （这就是 MixedReset 跟 HardReset 的唯一区别）。复用 Walker.flattenTree 而不是自己
+// 重新遍历 Tree：两者是完全相同的递归展开逻辑。
+func (wt *Worktree) resetIndexOnly(ctx context.Context, targetTreeHash types.Hash) error {
+	flat := make(map[string]core.TreeEntry)
+	if targetTreeHash != "" {
+		walker := NewWalker(wt.Store, wt.Hasher)
+		if err := walker.flattenTree(ctx, targetTreeHash, "", flat); err != nil {
+			return fmt.Errorf("failed to flatten target tree: %w", err)
+		}
+	}
+
+	wt.Index.Reset()
+	for path, entry := range flat {
+		wt.Index.Add(path, entry.Cid.Hash, entry.Size)
+	}
+	return nil
+}
+
+// requireClean 拒绝在工作区存在未提交改动（Modified/Added/Deleted，Untracked 不算）时继续
+// 执行，逻辑对齐 cmd/tv/commands/checkout.go 原本内联的 dirty check
+func (wt *Worktree) requireClean(ctx context.Context) error {
+	var headTree types.Hash
+	headHash, _, err := wt.Refs.GetHead(ctx)
+	if err != nil {
+		if !errors.Is(err, refs.ErrNoHead) {
+			return fmt.Errorf("failed to resolve HEAD: %w", err)
+		}
+		// 空仓库：没有 HEAD，也就没有 Tree 可对比
+	} else {
+		commit, err := wt.loadCommit(ctx, headHash)
+		if err != nil {
+			return err
+		}
+		headTree = commit.TreeCid.Hash
+	}
+
+	walker := NewWalker(wt.Store, wt.Hasher)
+	report, err := walker.Compute(ctx, headTree, wt.Index, wt.Root)
+	if err != nil {
+		return fmt.Errorf("failed to compute worktree status: %w", err)
+	}
+
+	for _, e := range report.Entries {
+		if e.State != Untracked {
+			return fmt.Errorf("%w: %s (%s); commit your changes or pass Force to discard them", ErrDirty, e.Path, e.State)
+		}
+	}
+	return nil
+}
+
+// loadCommit 读取并解码一个 Commit 对象；跟 cmd/tv/commands/common.go 的同名辅助函数
+// 逻辑完全一致，但那边是给 cmd 包用的未导出函数，pkg/worktree 不能导入 cmd，所以各留一份
+func (wt *Worktree) loadCommit(ctx context.Context, hash types.Hash) (*core.Commit, error) {
+	reader, err := wt.Store.Get(ctx, hash.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve commit %s: %w", hash, err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	var commit core.Commit
+	if err := core.DecodeObject(data, &commit); err != nil {
+		return nil, fmt.Errorf("object %s is corrupted or not a commit: %w", hash, err)
+	}
+	return &commit, nil
+}
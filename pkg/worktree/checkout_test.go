@@ -0,0 +1,158 @@
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/meta"
+	"tensorvault/pkg/refs"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/treebuilder"
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// setupWorktree 给 Checkout/Reset 测试准备一个真实的 Store + Index + Refs（内存 sqlite）。
+// 跟 pkg/service/helper_test.go 的 setupTestApp 是同一套基础设施，只是那边是未导出的，
+// pkg/worktree 用不了，这里就地复制一份。
+func setupWorktree(t *testing.T) *Worktree {
+	t.Helper()
+	store, idx, rootDir := setupRepo(t)
+
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared", t.Name())
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	require.NoError(t, err)
+
+	metaDB := meta.NewWithConn(db)
+	require.NoError(t, metaDB.AutoMigrate(&meta.Ref{}, &meta.CommitModel{}, &meta.FileIndex{}))
+
+	repo := meta.NewRepository(metaDB)
+	refMgr := refs.NewManager(repo)
+
+	return NewWorktree(store, idx, refMgr, rootDir, testHasher(t))
+}
+
+// commitIndex 构建一次提交：从当前 Index 建 Tree，写 Commit 对象，推进 HEAD，清空 Index
+// （跟 cmd/tv/commands/commit.go 的逻辑一致，这里是测试专用的精简版）
+func commitIndex(t *testing.T, wt *Worktree) types.Hash {
+	t.Helper()
+	ctx := context.Background()
+
+	treeHash, err := treebuilder.NewBuilder(wt.Store, wt.Hasher).Build(ctx, wt.Index)
+	require.NoError(t, err)
+
+	commitObj, err := core.NewCommit(treeHash, nil, "tester", "test commit", wt.Hasher)
+	require.NoError(t, err)
+	require.NoError(t, wt.Store.Put(ctx, commitObj))
+
+	_, oldVersion, err := wt.Refs.GetRef(ctx, "HEAD")
+	require.NoError(t, err)
+	require.NoError(t, wt.Refs.UpdateHead(ctx, commitObj.ID(), oldVersion))
+
+	wt.Index.Reset()
+	return commitObj.ID()
+}
+
+func TestWorktree_Checkout_ReconstructsWorktree(t *testing.T) {
+	wt := setupWorktree(t)
+	ctx := context.Background()
+
+	// 1. 在工作区里写两个文件（含子目录），加入 Index 并提交
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Root, "a.txt"), []byte("hello"), 0644))
+	require.NoError(t, os.MkdirAll(filepath.Join(wt.Root, "sub"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Root, "sub", "b.txt"), []byte("world"), 0644))
+
+	hashA := ingestContent(t, wt.Store.(*disk.Adapter), "hello")
+	hashB := ingestContent(t, wt.Store.(*disk.Adapter), "world")
+	wt.Index.Add("a.txt", hashA, 5)
+	wt.Index.Add("sub/b.txt", hashB, 5)
+
+	commitHash := commitIndex(t, wt)
+
+	// 2. 清空工作区，模拟一个全新/损坏的 checkout 目标
+	require.NoError(t, os.Remove(filepath.Join(wt.Root, "a.txt")))
+	require.NoError(t, os.RemoveAll(filepath.Join(wt.Root, "sub")))
+
+	// 3. Checkout 应该逐字节重建工作区，并重建 Index
+	got, err := wt.Checkout(ctx, CheckoutOptions{Hash: commitHash, Force: true})
+	require.NoError(t, err)
+	require.Equal(t, commitHash, got)
+
+	dataA, err := os.ReadFile(filepath.Join(wt.Root, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "hello", string(dataA))
+
+	dataB, err := os.ReadFile(filepath.Join(wt.Root, "sub", "b.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "world", string(dataB))
+
+	snap := wt.Index.Snapshot()
+	require.Equal(t, hashA, snap["a.txt"].Hash)
+	require.Equal(t, hashB, snap["sub/b.txt"].Hash)
+}
+
+func TestWorktree_Reset_Hard(t *testing.T) {
+	wt := setupWorktree(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Root, "a.txt"), []byte("v1"), 0644))
+	hashV1 := ingestContent(t, wt.Store.(*disk.Adapter), "v1")
+	wt.Index.Add("a.txt", hashV1, 2)
+	firstCommit := commitIndex(t, wt)
+
+	// 在第一次提交之后又改了内容并提交第二次
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Root, "a.txt"), []byte("v2-modified"), 0644))
+	hashV2 := ingestContent(t, wt.Store.(*disk.Adapter), "v2-modified")
+	wt.Index.Add("a.txt", hashV2, 11)
+	commitIndex(t, wt)
+
+	// 工作区目前内容是 v2-modified；HardReset 回第一次提交应该把它重写回 v1
+	require.NoError(t, wt.Reset(ctx, HardReset, firstCommit))
+
+	data, err := os.ReadFile(filepath.Join(wt.Root, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "v1", string(data))
+
+	snap := wt.Index.Snapshot()
+	require.Equal(t, hashV1, snap["a.txt"].Hash)
+
+	head, _, err := wt.Refs.GetHead(ctx)
+	require.NoError(t, err)
+	require.Equal(t, firstCommit, head)
+}
+
+func TestWorktree_Reset_Mixed_LeavesWorktreeUntouched(t *testing.T) {
+	wt := setupWorktree(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Root, "a.txt"), []byte("v1"), 0644))
+	hashV1 := ingestContent(t, wt.Store.(*disk.Adapter), "v1")
+	wt.Index.Add("a.txt", hashV1, 2)
+	firstCommit := commitIndex(t, wt)
+
+	require.NoError(t, os.WriteFile(filepath.Join(wt.Root, "a.txt"), []byte("v2-modified"), 0644))
+	hashV2 := ingestContent(t, wt.Store.(*disk.Adapter), "v2-modified")
+	wt.Index.Add("a.txt", hashV2, 11)
+	commitIndex(t, wt)
+
+	require.NoError(t, wt.Reset(ctx, MixedReset, firstCommit))
+
+	// 工作区没被碰过，文件内容还是 v2-modified
+	data, err := os.ReadFile(filepath.Join(wt.Root, "a.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "v2-modified", string(data))
+
+	// 但 Index 已经回退到第一次提交的状态
+	snap := wt.Index.Snapshot()
+	require.Equal(t, hashV1, snap["a.txt"].Hash)
+}
@@ -0,0 +1,321 @@
+// pkg/worktree/status.go
+package worktree
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/ignore"
+	"tensorvault/pkg/index"
+	"tensorvault/pkg/ingester"
+	"tensorvault/pkg/merkletrie"
+	"tensorvault/pkg/storage"
+	"tensorvault/pkg/types"
+)
+
+// State 描述一个路径相对于 HEAD 的状态
+// 命名对齐 go-git 的 worktree_status.go: Unmodified/Modified/Added/Deleted/Untracked
+type State string
+
+const (
+	Unmodified State = "unmodified"
+	Modified   State = "modified"
+	Added      State = "added"
+	Deleted    State = "deleted"
+	Untracked  State = "untracked"
+)
+
+// Entry 代表一条三方 diff 的结果
+type Entry struct {
+	Path  string `json:"path"`
+	State State  `json:"state"`
+}
+
+// Report 是 Status 计算的最终结果
+type Report struct {
+	Entries []Entry `json:"entries"`
+}
+
+// IsClean 判断工作区是否完全干净 (没有任何 Modified/Added/Deleted/Untracked)
+func (r *Report) IsClean() bool {
+	return len(r.Entries) == 0
+}
+
+// Walker 负责在 HEAD 的 Tree、Index 和物理文件系统之间做 Merkletrie 风格的三路对比
+// 它是一个只读组件：从不修改 Index 或磁盘上的内容
+type Walker struct {
+	store  storage.Store
+	hasher core.Hasher
+}
+
+func NewWalker(store storage.Store, hasher core.Hasher) *Walker {
+	return &Walker{store: store, hasher: hasher}
+}
+
+// Compute 对比 headTreeHash (可能为空，代表初始仓库)、idx 和 rootDir 下的实际文件
+// 返回按路径排序的 Report
+func (w *Walker) Compute(ctx context.Context, headTreeHash types.Hash, idx *index.Index, rootDir string) (*Report, error) {
+	// 1. 对比 HEAD Tree 和 Index：用 merkletrie.DiffTree 而不是无脑展开整棵 Tree —— 两侧
+	// Hash 相同的子树会被直接跳过，不产生任何 Store.Get。对一个几十万文件、但自上次提交以来
+	// 什么都没动过的模型仓库，这一步的开销是 O(depth) 而不是 O(files)。
+	inTree, treeHashAt, err := w.diffTreeVsIndex(ctx, headTreeHash, idx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff HEAD tree against index: %w", err)
+	}
+
+	// 2. Index 快照
+	staged := idx.Snapshot()
+
+	// 3. 遍历工作区文件系统
+	matcher, err := ignore.NewMatcher(rootDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ignore rules: %w", err)
+	}
+	fsPaths := make(map[string]os.FileInfo)
+	err = filepath.WalkDir(rootDir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil // 忽略不可访问的子路径，行为对齐 `tv add`
+		}
+		relPath, relErr := filepath.Rel(rootDir, path)
+		if relErr != nil {
+			return nil
+		}
+		relPath = index.CleanPath(relPath)
+		if relPath == "." {
+			return nil
+		}
+		if matcher.Ignored(relPath, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, infoErr := d.Info()
+		if infoErr != nil {
+			return nil
+		}
+		fsPaths[relPath] = info
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk worktree: %w", err)
+	}
+
+	// 4. 合并三方的路径全集
+	allPaths := make(map[string]struct{}, len(inTree)+len(staged)+len(fsPaths))
+	for p := range inTree {
+		allPaths[p] = struct{}{}
+	}
+	for p := range staged {
+		allPaths[p] = struct{}{}
+	}
+	for p := range fsPaths {
+		allPaths[p] = struct{}{}
+	}
+
+	var entries []Entry
+	ing := ingester.NewIngester(w.store, w.hasher)
+
+	for p := range allPaths {
+		_, inTreeP := inTree[p]
+		stagedEntry, inIndex := staged[p]
+		fsInfo, inFS := fsPaths[p]
+
+		switch {
+		case !inFS:
+			// 磁盘上没有了，但历史或暂存区还记得它 -> Deleted
+			if inTreeP || inIndex {
+				entries = append(entries, Entry{Path: p, State: Deleted})
+			}
+
+		case !inTreeP && !inIndex:
+			// 磁盘上有，但 HEAD 和 Index 都不认识 -> Untracked
+			entries = append(entries, Entry{Path: p, State: Untracked})
+
+		case !inTreeP && inIndex:
+			// 只在 Index 里有，HEAD 没有 -> 新增且已暂存
+			entries = append(entries, Entry{Path: p, State: Added})
+
+		default:
+			// 三方都存在：需要判断内容是否变化
+			currentHash, hashErr := w.hashWorktreeFile(ctx, ing, filepath.Join(rootDir, p), fsInfo, stagedEntry, inIndex)
+			if hashErr != nil {
+				return nil, fmt.Errorf("failed to hash %s: %w", p, hashErr)
+			}
+			treeHash, explicit := treeHashAt[p]
+			if !explicit {
+				// 这条路径没有出现在 DiffTree 的结果里，说明它所在的子树在 Tree 和 Index 之间
+				// 被短路跳过了——根据 Merkle 树的性质，这意味着 Tree 侧的 Hash 此时必然等于
+				// Index 侧记录的 Hash，可以直接复用，不需要为了这一条单独去 Store 里查 Tree
+				treeHash = stagedEntry.Hash
+			}
+			if currentHash != treeHash {
+				entries = append(entries, Entry{Path: p, State: Modified})
+			}
+			// 相等则是 Unmodified，Unmodified 的路径我们不列出（对齐 `git status` 默认只显示变更）
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return &Report{Entries: entries}, nil
+}
+
+// diffTreeVsIndex 用 merkletrie.DiffTree 对比 HEAD Tree 和 Index，只有两者出现分叉的
+// 子树才会真正向 Store 发起查询——Hash 相同的子树被直接跳过，不会展开。
+//
+// 返回值：
+//   - inTree：标记哪些路径在 HEAD Tree 里存在
+//   - treeHashAt：只收录跟 Index 不一致（或者只存在于 Tree 里）的路径对应的 Tree 侧 Hash。
+//     对于没有出现在这里、但同时存在于 Tree 和 Index 的路径，调用方可以直接复用 Index 侧的
+//     Hash——Merkle 树的性质保证了被短路跳过的子树里，两侧的 Hash 必然相等
+func (w *Walker) diffTreeVsIndex(ctx context.Context, headTreeHash types.Hash, idx *index.Index) (map[string]struct{}, map[string]types.Hash, error) {
+	staged := idx.Snapshot()
+
+	indexNoder, err := merkletrie.NewIndexNoder(idx, w.hasher)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to build index noder: %w", err)
+	}
+	treeNoder := merkletrie.NewTreeNoder(w.store, headTreeHash)
+
+	changes, err := merkletrie.DiffTree(ctx, treeNoder, indexNoder)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	inTree := make(map[string]struct{}, len(staged))
+	treeHashAt := make(map[string]types.Hash, len(changes))
+	added := make(map[string]struct{}, len(changes))
+
+	for _, c := range changes {
+		switch c.Action {
+		case merkletrie.Delete, merkletrie.Modify:
+			inTree[c.Path] = struct{}{}
+			treeHashAt[c.Path] = c.OldHash
+		case merkletrie.Insert:
+			added[c.Path] = struct{}{}
+		}
+	}
+
+	// 剩下在 Index 里、但没被 DiffTree 报告为 Insert/Modify 的路径，就是那些因为 Tree 和
+	// Index 完全一致而被短路跳过的路径——它们同样"在 Tree 里"
+	for p := range staged {
+		if _, isAdded := added[p]; isAdded {
+			continue
+		}
+		if _, explicit := treeHashAt[p]; explicit {
+			continue
+		}
+		inTree[p] = struct{}{}
+	}
+
+	return inTree, treeHashAt, nil
+}
+
+// hashWorktreeFile 计算一个文件当前内容对应的 FileNode Hash
+// 快路径：如果 size 和 mtime 都没变，直接信任 Index 里缓存的 Hash，避免重新切分/哈希大文件
+// 慢路径：否则重新读取文件内容，走 CDC 切分计算出新的 Merkle Root
+func (w *Walker) hashWorktreeFile(ctx context.Context, ing *ingester.Ingester, fullPath string, info os.FileInfo, staged index.Entry, inIndex bool) (types.Hash, error) {
+	if inIndex && staged.Size == info.Size() && staged.ModifiedAt.Equal(info.ModTime()) {
+		return staged.Hash, nil
+	}
+
+	f, err := os.Open(fullPath)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	hash, err := ing.HashReader(ctx, f)
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// DiffTreeVsSnapshot 对比一个 Merkle Tree 和一份“扁平路径 -> Hash”快照。
+// 与 Walker.Compute 不同，这里没有真实的物理文件系统和 Index 的区分——
+// 调用方（比如 MetaService.Status）只能拿到客户端上报的快照，所以只能做两路对比：
+// 快照里没有的路径 -> Deleted；Tree 里没有的路径 -> Added；两边都有但 Hash 不同 -> Modified。
+func DiffTreeVsSnapshot(tree map[string]types.Hash, snapshot map[string]types.Hash) []Entry {
+	all := make(map[string]struct{}, len(tree)+len(snapshot))
+	for p := range tree {
+		all[p] = struct{}{}
+	}
+	for p := range snapshot {
+		all[p] = struct{}{}
+	}
+
+	var entries []Entry
+	for p := range all {
+		treeHash, inTree := tree[p]
+		snapHash, inSnapshot := snapshot[p]
+
+		switch {
+		case inTree && !inSnapshot:
+			entries = append(entries, Entry{Path: p, State: Deleted})
+		case !inTree && inSnapshot:
+			entries = append(entries, Entry{Path: p, State: Added})
+		case treeHash != snapHash:
+			entries = append(entries, Entry{Path: p, State: Modified})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+	return entries
+}
+
+// FlattenTree 是 flattenTree 的导出包装，供需要扁平化 Tree 的外部调用方使用（例如 Server 端的 Status RPC）
+func (w *Walker) FlattenTree(ctx context.Context, treeHash types.Hash) (map[string]types.Hash, error) {
+	raw := make(map[string]core.TreeEntry)
+	if treeHash != "" {
+		if err := w.flattenTree(ctx, treeHash, "", raw); err != nil {
+			return nil, err
+		}
+	}
+	out := make(map[string]types.Hash, len(raw))
+	for p, e := range raw {
+		out[p] = e.Cid.Hash
+	}
+	return out, nil
+}
+
+// flattenTree 递归展开 Merkle Tree，产出 path -> TreeEntry 的扁平映射（仅文件，目录不计入结果）
+func (w *Walker) flattenTree(ctx context.Context, treeHash types.Hash, prefix string, out map[string]core.TreeEntry) error {
+	reader, err := w.store.Get(ctx, treeHash)
+	if err != nil {
+		return err
+	}
+	data, err := io.ReadAll(reader)
+	reader.Close()
+	if err != nil {
+		return err
+	}
+
+	var tree core.Tree
+	if err := core.DecodeObject(data, &tree); err != nil {
+		return fmt.Errorf("object %s is not a valid tree: %w", treeHash, err)
+	}
+
+	for _, entry := range tree.Entries {
+		childPath := entry.Name
+		if prefix != "" {
+			childPath = prefix + "/" + entry.Name
+		}
+		if entry.Type == core.EntryDir {
+			if err := w.flattenTree(ctx, entry.Cid.Hash, childPath, out); err != nil {
+				return err
+			}
+			continue
+		}
+		out[childPath] = entry
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package worktree
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tensorvault/pkg/core"
+	"tensorvault/pkg/index"
+	"tensorvault/pkg/ingester"
+	"tensorvault/pkg/storage/disk"
+	"tensorvault/pkg/treebuilder"
+	"tensorvault/pkg/types"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testHasher 是测试用的默认哈希算法，跟仓库未配置 hash_algo 时的隐式默认值一致
+func testHasher(t *testing.T) core.Hasher {
+	t.Helper()
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	return hasher
+}
+
+// setupRepo 在临时目录里准备一个最小可用的 Store + Index + 工作区文件
+func setupRepo(t *testing.T) (store *disk.Adapter, idx *index.Index, rootDir string) {
+	t.Helper()
+	rootDir = t.TempDir()
+
+	objStore, err := disk.NewAdapter(filepath.Join(rootDir, ".objects"))
+	require.NoError(t, err)
+
+	idx, err = index.NewIndex(filepath.Join(rootDir, "index.json"))
+	require.NoError(t, err)
+
+	return objStore, idx, rootDir
+}
+
+// ingestContent 通过真实的 Ingester 把内容切片并写入 Store，返回其 FileNode Hash
+func ingestContent(t *testing.T, store *disk.Adapter, content string) types.Hash {
+	t.Helper()
+	ing := ingester.NewIngester(store, testHasher(t))
+	node, err := ing.IngestFile(context.Background(), strings.NewReader(content))
+	require.NoError(t, err)
+	return node.ID()
+}
+
+func TestWalker_Compute_Classifications(t *testing.T) {
+	store, idx, rootDir := setupRepo(t)
+	ctx := context.Background()
+
+	// 1. 提交时刻：committed.txt 和 untouched.txt 都已进入 HEAD
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "committed.txt"), []byte("v1"), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "untouched.txt"), []byte("same"), 0644))
+
+	committedHash := ingestContent(t, store, "v1")
+	untouchedHash := ingestContent(t, store, "same")
+
+	idx.Add("committed.txt", committedHash, int64(len("v1")))
+	idx.Add("untouched.txt", untouchedHash, int64(len("same")))
+
+	builder := treebuilder.NewBuilder(store, testHasher(t))
+	headTree, err := builder.Build(ctx, idx)
+	require.NoError(t, err)
+
+	// 2. 模拟 HEAD 落盘之后工作区又发生的变化
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "committed.txt"), []byte("v2-modified"), 0644)) // Modified
+	require.NoError(t, os.Remove(filepath.Join(rootDir, "untouched.txt")))                                 // Deleted
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "new.txt"), []byte("brand new"), 0644))         // Untracked
+
+	walker := NewWalker(store, testHasher(t))
+	report, err := walker.Compute(ctx, headTree, idx, rootDir)
+	require.NoError(t, err)
+
+	byPath := make(map[string]State)
+	for _, e := range report.Entries {
+		byPath[e.Path] = e.State
+	}
+
+	require.Equal(t, Modified, byPath["committed.txt"])
+	require.Equal(t, Deleted, byPath["untouched.txt"])
+	require.Equal(t, Untracked, byPath["new.txt"])
+}
+
+func TestWalker_Compute_CleanTree(t *testing.T) {
+	store, idx, rootDir := setupRepo(t)
+	ctx := context.Background()
+
+	require.NoError(t, os.WriteFile(filepath.Join(rootDir, "a.txt"), []byte("hello"), 0644))
+	hash := ingestContent(t, store, "hello")
+
+	// 让 Index 缓存的 mtime 与磁盘上的实际 mtime 对齐，这样 Walker 会走"信任缓存"快路径
+	info, err := os.Stat(filepath.Join(rootDir, "a.txt"))
+	require.NoError(t, err)
+	idx.Entries["a.txt"] = index.Entry{Path: "a.txt", Hash: hash, Size: info.Size(), ModifiedAt: info.ModTime()}
+
+	builder := treebuilder.NewBuilder(store, testHasher(t))
+	headTree, err := builder.Build(ctx, idx)
+	require.NoError(t, err)
+
+	walker := NewWalker(store, testHasher(t))
+	report, err := walker.Compute(ctx, headTree, idx, rootDir)
+	require.NoError(t, err)
+
+	require.True(t, report.IsClean(), "expected a clean report, got: %+v", report.Entries)
+}
+
+func TestDiffTreeVsSnapshot(t *testing.T) {
+	tree := map[string]types.Hash{
+		"a.txt": "h1",
+		"b.txt": "h2",
+	}
+	snapshot := map[string]types.Hash{
+		"a.txt": "h1",       // unchanged
+		"b.txt": "h2-other", // modified
+		"c.txt": "h3",       // added
+	}
+
+	entries := DiffTreeVsSnapshot(tree, snapshot)
+	byPath := make(map[string]State)
+	for _, e := range entries {
+		byPath[e.Path] = e.State
+	}
+
+	require.Equal(t, Modified, byPath["b.txt"])
+	require.Equal(t, Added, byPath["c.txt"])
+	_, unmodifiedListed := byPath["a.txt"]
+	require.False(t, unmodifiedListed)
+}
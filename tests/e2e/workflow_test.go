@@ -85,7 +85,9 @@ func TestPhase1_Workflow(t *testing.T) {
 	// 3. 第一次上传 (Cold Upload)
 	// -------------------------------------------------------------
 	t.Log("Step 1: Cold Ingest (Should write to Disk & Redis)...")
-	ing := ingester.NewIngester(cachedStore)
+	hasher, err := core.HasherFor(types.AlgoSHA256)
+	require.NoError(t, err)
+	ing := ingester.NewIngester(cachedStore, hasher)
 
 	start := time.Now()
 	node1, err := ing.IngestFile(ctx, bytes.NewReader(originalData))